@@ -0,0 +1,232 @@
+package accounting
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// EntryType describes the kind of event an accounting entry records.
+type EntryType uint8
+
+const (
+	// EntryTypeLocalChannelOpen records the funds we committed to a
+	// channel that we opened.
+	EntryTypeLocalChannelOpen EntryType = iota
+
+	// EntryTypeRemoteChannelOpen records a channel that was opened by our
+	// peer. As the channel is funded by our peer, the entry has a zero
+	// amount.
+	EntryTypeRemoteChannelOpen
+
+	// EntryTypeChannelOpenFee records the on-chain fees we paid for a
+	// channel funding transaction.
+	EntryTypeChannelOpenFee
+
+	// EntryTypeChannelClose records the funds that were paid out to our
+	// wallet directly by a channel's closing transaction.
+	EntryTypeChannelClose
+
+	// EntryTypeChannelCloseFee records the on-chain fees of the closing
+	// transaction of a channel that we opened. These fees are paid from
+	// our channel balance rather than our wallet.
+	EntryTypeChannelCloseFee
+
+	// EntryTypeReceipt records funds we received, either on-chain or
+	// through the settlement of an invoice.
+	EntryTypeReceipt
+
+	// EntryTypePayment records funds we sent, either on-chain or through
+	// a lightning payment, excluding fees.
+	EntryTypePayment
+
+	// EntryTypeFee records the fees of an on-chain or lightning payment.
+	EntryTypeFee
+
+	// EntryTypeCircularReceipt records the settlement of one of our own
+	// invoices that was paid by one of our own payments, for example to
+	// rebalance our channels.
+	EntryTypeCircularReceipt
+
+	// EntryTypeCircularPayment records a lightning payment to one of our
+	// own invoices, excluding fees.
+	EntryTypeCircularPayment
+
+	// EntryTypeCircularFee records the routing fees of a circular
+	// payment.
+	EntryTypeCircularFee
+
+	// EntryTypeForward records an htlc that we forwarded. As the
+	// forwarded amount only moves between our channels, the entry has a
+	// zero amount.
+	EntryTypeForward
+
+	// EntryTypeForwardFee records the fees we earned for a forward.
+	EntryTypeForwardFee
+
+	// EntryTypeSweep records funds that were swept back to our wallet,
+	// for example from the outputs of a force closed channel.
+	EntryTypeSweep
+
+	// EntryTypeSweepFee records the on-chain fees of a sweep.
+	EntryTypeSweepFee
+
+	// EntryTypeJustice records funds we claimed from a peer that
+	// broadcast a revoked commitment.
+	EntryTypeJustice
+)
+
+// String returns a human readable representation of the entry type.
+func (e EntryType) String() string {
+	switch e {
+	case EntryTypeLocalChannelOpen:
+		return "LocalChannelOpen"
+
+	case EntryTypeRemoteChannelOpen:
+		return "RemoteChannelOpen"
+
+	case EntryTypeChannelOpenFee:
+		return "ChannelOpenFee"
+
+	case EntryTypeChannelClose:
+		return "ChannelClose"
+
+	case EntryTypeChannelCloseFee:
+		return "ChannelCloseFee"
+
+	case EntryTypeReceipt:
+		return "Receipt"
+
+	case EntryTypePayment:
+		return "Payment"
+
+	case EntryTypeFee:
+		return "Fee"
+
+	case EntryTypeCircularReceipt:
+		return "CircularReceipt"
+
+	case EntryTypeCircularPayment:
+		return "CircularPayment"
+
+	case EntryTypeCircularFee:
+		return "CircularFee"
+
+	case EntryTypeForward:
+		return "Forward"
+
+	case EntryTypeForwardFee:
+		return "ForwardFee"
+
+	case EntryTypeSweep:
+		return "Sweep"
+
+	case EntryTypeSweepFee:
+		return "SweepFee"
+
+	case EntryTypeJustice:
+		return "Justice"
+
+	default:
+		return fmt.Sprintf("Unknown(%d)", uint8(e))
+	}
+}
+
+// Account is an account of the ledger that the entries of an accounting
+// report move funds between. Our balance is held by the wallet and channels
+// accounts.
+type Account uint8
+
+const (
+	// AccountWallet holds the funds of our on-chain wallet.
+	AccountWallet Account = iota
+
+	// AccountChannels holds our balance in our channels, including the
+	// outputs of closed channels that haven't been swept yet.
+	AccountChannels
+
+	// AccountExternal holds the funds of the other parties that we
+	// receive funds from or send funds to.
+	AccountExternal
+
+	// AccountFees is the expense account of the on-chain and routing
+	// fees that we paid.
+	AccountFees
+
+	// AccountForwardingIncome is the income account of the fees that we
+	// earned by forwarding htlcs.
+	AccountForwardingIncome
+)
+
+// String returns a human readable representation of the account.
+func (a Account) String() string {
+	switch a {
+	case AccountWallet:
+		return "Wallet"
+
+	case AccountChannels:
+		return "Channels"
+
+	case AccountExternal:
+		return "External"
+
+	case AccountFees:
+		return "Fees"
+
+	case AccountForwardingIncome:
+		return "ForwardingIncome"
+
+	default:
+		return fmt.Sprintf("Unknown(%d)", uint8(a))
+	}
+}
+
+// Entry is a single line of an accounting report in double-entry form. Every
+// entry moves its amount from its credit account to its debit account, so
+// the debits and credits of every event balance, and the change of our
+// balance over a period is the net change of the wallet and channels
+// accounts.
+type Entry struct {
+	// Timestamp is the time at which the event took place. For on-chain
+	// events this is the timestamp of the block that confirmed the
+	// transaction.
+	Timestamp time.Time
+
+	// Amount is the amount of the entry.
+	Amount lnwire.MilliSatoshi
+
+	// DebitAccount is the account the amount of the entry moves to.
+	DebitAccount Account
+
+	// CreditAccount is the account the amount of the entry moves from.
+	CreditAccount Account
+
+	// Type is the kind of event that the entry records.
+	Type EntryType
+
+	// OnChain is true if the entry records an on-chain event.
+	OnChain bool
+
+	// TxID is the hash of the transaction of an on-chain entry.
+	TxID string
+
+	// Reference uniquely identifies the event that the entry belongs to
+	// within its type. This is the channel point for channel entries, the
+	// payment hash for lightning payments and receipts and the channel
+	// pair for forwards.
+	Reference string
+
+	// Note holds additional information about the entry.
+	Note string
+
+	// BTCPrice is the price of one bitcoin at the time of the entry. It
+	// is nil if the report was created without a price source.
+	BTCPrice *Price
+
+	// FiatValue is the value of the entry's amount in the currency of
+	// its price. It is nil if the report was created without a price
+	// source.
+	FiatValue *big.Rat
+}
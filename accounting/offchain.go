@@ -0,0 +1,224 @@
+package accounting
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lntypes"
+)
+
+// offChainEntries returns the entries of all lightning payments, receipts and
+// forwards within the given time range. Payments to our own invoices are
+// accounted for as circular payments and receipts.
+func offChainEntries(cfg *Config, start, end time.Time) ([]*Entry, error) {
+	invoices, err := settledInvoices(cfg, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := succeededPayments(cfg, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		entries       []*Entry
+		invoiceHashes = make(map[lntypes.Hash]struct{})
+		paymentHashes = make(map[lntypes.Hash]struct{})
+	)
+	for _, invoice := range invoices {
+		if invoice.Terms.PaymentPreimage != nil {
+			hash := invoice.Terms.PaymentPreimage.Hash()
+			invoiceHashes[hash] = struct{}{}
+		}
+	}
+	for _, payment := range payments {
+		paymentHashes[payment.Info.PaymentIdentifier] = struct{}{}
+	}
+
+	for _, invoice := range invoices {
+		entryType := EntryTypeReceipt
+
+		var reference string
+		if invoice.Terms.PaymentPreimage != nil {
+			hash := invoice.Terms.PaymentPreimage.Hash()
+			if _, ok := paymentHashes[hash]; ok {
+				entryType = EntryTypeCircularReceipt
+			}
+			reference = hash.String()
+		}
+
+		entries = append(entries, &Entry{
+			Timestamp:     invoice.SettleDate,
+			Amount:        invoice.AmtPaid,
+			DebitAccount:  AccountChannels,
+			CreditAccount: AccountExternal,
+			Type:          entryType,
+			Reference:     reference,
+			Note:          string(invoice.Memo),
+		})
+	}
+
+	for _, payment := range payments {
+		entryType, feeType := EntryTypePayment, EntryTypeFee
+		hash := payment.Info.PaymentIdentifier
+		if _, ok := invoiceHashes[hash]; ok {
+			entryType, feeType = EntryTypeCircularPayment,
+				EntryTypeCircularFee
+		}
+
+		value, fee := payment.SentAmt()
+		entries = append(entries, &Entry{
+			Timestamp:     payment.Info.CreationTime,
+			Amount:        value,
+			DebitAccount:  AccountExternal,
+			CreditAccount: AccountChannels,
+			Type:          entryType,
+			Reference:     hash.String(),
+		})
+
+		if fee > 0 {
+			entries = append(entries, &Entry{
+				Timestamp:     payment.Info.CreationTime,
+				Amount:        fee,
+				DebitAccount:  AccountFees,
+				CreditAccount: AccountChannels,
+				Type:          feeType,
+				Reference:     hash.String(),
+			})
+		}
+	}
+
+	forwards, err := forwardEntries(cfg, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(entries, forwards...), nil
+}
+
+// settledInvoices returns all invoices that were settled within the given
+// time range, including archived invoices.
+func settledInvoices(cfg *Config, start, end time.Time) ([]channeldb.Invoice,
+	error) {
+
+	var invoices []channeldb.Invoice
+	for _, archived := range []bool{false, true} {
+		query := channeldb.InvoiceQuery{
+			NumMaxInvoices:  queryPageSize,
+			Archived:        archived,
+			SettleDateStart: start,
+			SettleDateEnd:   end,
+			States: []channeldb.ContractState{
+				channeldb.ContractSettled,
+			},
+		}
+
+		for {
+			slice, err := cfg.QueryInvoices(query)
+			if err != nil {
+				return nil, err
+			}
+			invoices = append(invoices, slice.Invoices...)
+
+			if uint64(len(slice.Invoices)) < query.NumMaxInvoices {
+				break
+			}
+			query.IndexOffset = slice.LastIndexOffset
+		}
+	}
+
+	return invoices, nil
+}
+
+// succeededPayments returns all succeeded payments that were created within
+// the given time range.
+func succeededPayments(cfg *Config, start, end time.Time) (
+	[]*channeldb.MPPayment, error) {
+
+	query := channeldb.PaymentsQuery{
+		MaxPayments:       queryPageSize,
+		CreationDateStart: start,
+		CreationDateEnd:   end,
+		Statuses: []channeldb.PaymentStatus{
+			channeldb.StatusSucceeded,
+		},
+	}
+
+	var payments []*channeldb.MPPayment
+	for {
+		resp, err := cfg.QueryPayments(query)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, resp.Payments...)
+
+		if uint64(len(resp.Payments)) < query.MaxPayments {
+			return payments, nil
+		}
+		query.IndexOffset = resp.LastIndexOffset
+	}
+}
+
+// forwardEntries returns the entries of all htlcs forwarded within the given
+// time range. The forwarded amount only moves between our channels, so we
+// record it with a zero amount and account for the fee that we earned
+// separately.
+func forwardEntries(cfg *Config, start, end time.Time) ([]*Entry, error) {
+	// The forwarding log is keyed by unix nano timestamps and requires
+	// both bounds to be set.
+	if start.IsZero() {
+		start = time.Unix(0, 0)
+	}
+	if end.IsZero() {
+		end = time.Unix(0, math.MaxInt64)
+	}
+
+	query := channeldb.ForwardingEventQuery{
+		StartTime:    start,
+		EndTime:      end,
+		NumMaxEvents: queryPageSize,
+	}
+
+	var entries []*Entry
+	for {
+		timeSlice, err := cfg.QueryForwardingLog(query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range timeSlice.ForwardingEvents {
+			reference := fmt.Sprintf("%v:%v", event.IncomingChanID,
+				event.OutgoingChanID)
+
+			entries = append(entries, &Entry{
+				Timestamp:     event.Timestamp,
+				DebitAccount:  AccountChannels,
+				CreditAccount: AccountChannels,
+				Type:          EntryTypeForward,
+				Reference:     reference,
+				Note: fmt.Sprintf("incoming: %v, outgoing: %v",
+					event.AmtIn, event.AmtOut),
+			})
+
+			if event.AmtIn > event.AmtOut {
+				fee := event.AmtIn - event.AmtOut
+				entries = append(entries, &Entry{
+					Timestamp:     event.Timestamp,
+					Amount:        fee,
+					DebitAccount:  AccountChannels,
+					CreditAccount: AccountForwardingIncome,
+					Type:          EntryTypeForwardFee,
+					Reference:     reference,
+				})
+			}
+		}
+
+		if len(timeSlice.ForwardingEvents) < int(query.NumMaxEvents) {
+			return entries, nil
+		}
+		query.IndexOffset = timeSlice.LastIndexOffset
+	}
+}
@@ -0,0 +1,475 @@
+package accounting
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// channelInfo holds the details of one of our channels that are needed to
+// account for its opening and closing.
+type channelInfo struct {
+	chanPoint wire.OutPoint
+	capacity  btcutil.Amount
+
+	// openHeight is the height of the block that confirmed the funding
+	// transaction of the channel.
+	openHeight uint32
+
+	// closeSummary is the summary of the confirmed close of the channel,
+	// or nil if the channel hasn't been closed yet.
+	closeSummary *channeldb.ChannelCloseSummary
+}
+
+// fetchChannels returns all of our confirmed channels, both open and closed.
+func fetchChannels(cfg *Config) ([]*channelInfo, error) {
+	openChannels, err := cfg.OpenChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	closedChannels, err := cfg.ClosedChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		channels []*channelInfo
+		known    = make(map[wire.OutPoint]*channelInfo)
+	)
+	for _, channel := range openChannels {
+		if channel.IsPending {
+			continue
+		}
+
+		info := &channelInfo{
+			chanPoint:  channel.FundingOutpoint,
+			capacity:   channel.Capacity,
+			openHeight: channel.ShortChannelID.BlockHeight,
+		}
+		known[info.chanPoint] = info
+		channels = append(channels, info)
+	}
+
+	for _, summary := range closedChannels {
+		// Channels whose funding transaction never confirmed didn't
+		// move any funds.
+		if summary.CloseType == channeldb.FundingCanceled {
+			continue
+		}
+
+		// A channel that is waiting for its closing transaction to
+		// confirm may be reported as both open and closed.
+		info, ok := known[summary.ChanPoint]
+		if !ok {
+			info = &channelInfo{
+				chanPoint:  summary.ChanPoint,
+				capacity:   summary.Capacity,
+				openHeight: summary.ShortChanID.BlockHeight,
+			}
+			known[info.chanPoint] = info
+			channels = append(channels, info)
+		}
+
+		// Only confirmed closes that spent the funding output are
+		// accounted for.
+		if summary.IsPending ||
+			summary.CloseType == channeldb.Abandoned {
+
+			continue
+		}
+		info.closeSummary = summary
+	}
+
+	return channels, nil
+}
+
+// onChainEntries returns the entries of all on-chain events that took place
+// within the given time range.
+func onChainEntries(cfg *Config, start, end time.Time) ([]*Entry, error) {
+	txs, err := cfg.ListTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := fetchChannels(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sweepHashes, err := cfg.ListSweeps()
+	if err != nil {
+		return nil, err
+	}
+	sweeps := make(map[chainhash.Hash]struct{}, len(sweepHashes))
+	for _, hash := range sweepHashes {
+		sweeps[hash] = struct{}{}
+	}
+
+	// Only confirmed transactions are accounted for, as unconfirmed ones
+	// may still be replaced.
+	walletTxs := make(map[chainhash.Hash]*lnwallet.TransactionDetail)
+	for _, tx := range txs {
+		if tx.NumConfirmations > 0 {
+			walletTxs[tx.Hash] = tx
+		}
+	}
+
+	// Index our channels by their funding and closing transactions. We
+	// opened a channel if its funding transaction spent funds from our
+	// wallet.
+	var (
+		fundedBy  = make(map[chainhash.Hash][]*channelInfo)
+		closedBy  = make(map[chainhash.Hash]*channelInfo)
+		breachTxs = make(map[chainhash.Hash]*channelInfo)
+		localOpen = make(map[wire.OutPoint]bool)
+	)
+	for _, channel := range channels {
+		fundingTxid := channel.chanPoint.Hash
+		if tx, ok := walletTxs[fundingTxid]; ok && tx.Value < 0 {
+			fundedBy[fundingTxid] = append(
+				fundedBy[fundingTxid], channel,
+			)
+			localOpen[channel.chanPoint] = true
+		}
+
+		summary := channel.closeSummary
+		if summary == nil {
+			continue
+		}
+
+		closedBy[summary.ClosingTXID] = channel
+		if summary.CloseType == channeldb.BreachClose {
+			breachTxs[summary.ClosingTXID] = channel
+		}
+	}
+
+	var entries []*Entry
+	for _, tx := range txs {
+		if tx.NumConfirmations == 0 {
+			continue
+		}
+
+		timestamp := time.Unix(tx.Timestamp, 0)
+		if !inRange(timestamp, start, end) {
+			continue
+		}
+
+		var txEntries []*Entry
+		if channels, ok := fundedBy[tx.Hash]; ok {
+			txEntries = channelOpenEntries(tx, channels)
+		} else if channel, ok := closedBy[tx.Hash]; ok {
+			txEntries, err = channelCloseEntries(
+				tx, channel, localOpen[channel.chanPoint],
+			)
+		} else {
+			txEntries, err = walletTxEntries(tx, sweeps, breachTxs)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, txEntries...)
+	}
+
+	// Finally, we record the opening of channels by our peers and the
+	// closing of channels whose closing transaction didn't pay to our
+	// wallet, as neither is part of our wallet's transactions.
+	for _, channel := range channels {
+		chanPoint := channel.chanPoint
+		if !localOpen[chanPoint] && channel.openHeight != 0 {
+			timestamp, err := cfg.BlockTimestamp(channel.openHeight)
+			if err != nil {
+				return nil, err
+			}
+
+			if inRange(timestamp, start, end) {
+				entries = append(entries, remoteOpenEntry(
+					timestamp, chanPoint,
+				))
+			}
+		}
+
+		summary := channel.closeSummary
+		if summary == nil {
+			continue
+		}
+		if _, ok := walletTxs[summary.ClosingTXID]; ok {
+			continue
+		}
+
+		timestamp, err := cfg.BlockTimestamp(summary.CloseHeight)
+		if err != nil {
+			return nil, err
+		}
+
+		if inRange(timestamp, start, end) {
+			entries = append(entries, &Entry{
+				Timestamp:     timestamp,
+				DebitAccount:  AccountWallet,
+				CreditAccount: AccountChannels,
+				Type:          EntryTypeChannelClose,
+				OnChain:       true,
+				TxID:          summary.ClosingTXID.String(),
+				Reference:     chanPoint.String(),
+				Note:          closeNote(summary),
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// remoteOpenEntry returns the entry of a channel that was opened by our peer.
+// As our peer funded the channel, the entry has a zero amount.
+func remoteOpenEntry(timestamp time.Time, chanPoint wire.OutPoint) *Entry {
+	return &Entry{
+		Timestamp:     timestamp,
+		DebitAccount:  AccountChannels,
+		CreditAccount: AccountExternal,
+		Type:          EntryTypeRemoteChannelOpen,
+		OnChain:       true,
+		TxID:          chanPoint.Hash.String(),
+		Reference:     chanPoint.String(),
+	}
+}
+
+// channelOpenEntries returns the entries of a funding transaction of one or
+// more channels that we opened.
+func channelOpenEntries(tx *lnwallet.TransactionDetail,
+	channels []*channelInfo) []*Entry {
+
+	var (
+		entries   []*Entry
+		timestamp = time.Unix(tx.Timestamp, 0)
+		txid      = tx.Hash.String()
+		fee       = btcutil.Amount(tx.TotalFees)
+
+		// The value of the transaction is the amount that left our
+		// wallet, including the fee.
+		remaining = -tx.Value - fee
+	)
+	for _, channel := range channels {
+		capacity := lnwire.NewMSatFromSatoshis(channel.capacity)
+		entries = append(entries, &Entry{
+			Timestamp:     timestamp,
+			Amount:        capacity,
+			DebitAccount:  AccountChannels,
+			CreditAccount: AccountWallet,
+			Type:          EntryTypeLocalChannelOpen,
+			OnChain:       true,
+			TxID:          txid,
+			Reference:     channel.chanPoint.String(),
+		})
+		remaining -= channel.capacity
+	}
+
+	if fee > 0 {
+		entries = append(entries, &Entry{
+			Timestamp:     timestamp,
+			Amount:        lnwire.NewMSatFromSatoshis(fee),
+			DebitAccount:  AccountFees,
+			CreditAccount: AccountWallet,
+			Type:          EntryTypeChannelOpenFee,
+			OnChain:       true,
+			TxID:          txid,
+			Reference:     txid,
+		})
+	}
+
+	// Any other funds that the transaction sent elsewhere are accounted
+	// for as a regular payment.
+	if remaining > 0 {
+		entries = append(entries, &Entry{
+			Timestamp:     timestamp,
+			Amount:        lnwire.NewMSatFromSatoshis(remaining),
+			DebitAccount:  AccountExternal,
+			CreditAccount: AccountWallet,
+			Type:          EntryTypePayment,
+			OnChain:       true,
+			TxID:          txid,
+			Reference:     txid,
+		})
+	}
+
+	return entries
+}
+
+// channelCloseEntries returns the entries of a closing transaction that paid
+// to our wallet. If we opened the channel, we also paid the fees of the
+// closing transaction from our channel balance.
+func channelCloseEntries(tx *lnwallet.TransactionDetail, channel *channelInfo,
+	localOpen bool) ([]*Entry, error) {
+
+	var (
+		timestamp = time.Unix(tx.Timestamp, 0)
+		txid      = tx.Hash.String()
+		reference = channel.chanPoint.String()
+	)
+
+	// The closing transaction usually pays from the channel to our
+	// wallet. If it spent more from our wallet than it paid to it, the
+	// funds moved the other way.
+	amt := tx.Value
+	debit, credit := AccountWallet, AccountChannels
+	if amt < 0 {
+		amt = -amt
+		debit, credit = credit, debit
+	}
+
+	entries := []*Entry{{
+		Timestamp:     timestamp,
+		Amount:        lnwire.NewMSatFromSatoshis(amt),
+		DebitAccount:  debit,
+		CreditAccount: credit,
+		Type:          EntryTypeChannelClose,
+		OnChain:       true,
+		TxID:          txid,
+		Reference:     reference,
+		Note:          closeNote(channel.closeSummary),
+	}}
+
+	if !localOpen {
+		return entries, nil
+	}
+
+	// The fee of the closing transaction is the part of the channel's
+	// capacity that isn't paid out by any of its outputs.
+	var closeTx wire.MsgTx
+	if err := closeTx.Deserialize(bytes.NewReader(tx.RawTx)); err != nil {
+		return nil, err
+	}
+
+	fee := channel.capacity
+	for _, txOut := range closeTx.TxOut {
+		fee -= btcutil.Amount(txOut.Value)
+	}
+
+	if fee > 0 {
+		entries = append(entries, &Entry{
+			Timestamp:     timestamp,
+			Amount:        lnwire.NewMSatFromSatoshis(fee),
+			DebitAccount:  AccountFees,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeChannelCloseFee,
+			OnChain:       true,
+			TxID:          txid,
+			Reference:     reference,
+		})
+	}
+
+	return entries, nil
+}
+
+// walletTxEntries returns the entries of a wallet transaction that is neither
+// a funding nor a closing transaction of one of our channels.
+func walletTxEntries(tx *lnwallet.TransactionDetail,
+	sweeps map[chainhash.Hash]struct{},
+	breachTxs map[chainhash.Hash]*channelInfo) ([]*Entry, error) {
+
+	entryType, feeType := EntryTypeReceipt, EntryTypeFee
+	if tx.Value < 0 {
+		entryType = EntryTypePayment
+	}
+
+	// The funds of a transaction move between our wallet and other
+	// parties, except for sweeps that move them from our channels.
+	reference := tx.Hash.String()
+	counterAccount := AccountExternal
+	if _, ok := sweeps[tx.Hash]; ok {
+		entryType, feeType = EntryTypeSweep, EntryTypeSweepFee
+		counterAccount = AccountChannels
+	}
+
+	// A transaction that spends the outputs of a revoked commitment is a
+	// justice transaction. As the breach arbiter publishes these directly,
+	// they are not part of the sweeps.
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(tx.RawTx)); err != nil {
+		return nil, fmt.Errorf("unable to decode transaction %v: %v",
+			tx.Hash, err)
+	}
+	for _, txIn := range msgTx.TxIn {
+		channel, ok := breachTxs[txIn.PreviousOutPoint.Hash]
+		if !ok {
+			continue
+		}
+
+		entryType = EntryTypeJustice
+		reference = channel.chanPoint.String()
+		break
+	}
+
+	var (
+		entries   []*Entry
+		timestamp = time.Unix(tx.Timestamp, 0)
+		txid      = tx.Hash.String()
+		fee       = btcutil.Amount(tx.TotalFees)
+	)
+
+	// The value of a transaction that spends from our wallet includes its
+	// fee, which we account for separately. The fee is paid by the account
+	// the funds of the transaction come from.
+	amt := tx.Value
+	debit, credit := AccountWallet, counterAccount
+	if amt < 0 {
+		amt = -amt - fee
+		debit, credit = counterAccount, AccountWallet
+	}
+
+	if amt > 0 {
+		entries = append(entries, &Entry{
+			Timestamp:     timestamp,
+			Amount:        lnwire.NewMSatFromSatoshis(amt),
+			DebitAccount:  debit,
+			CreditAccount: credit,
+			Type:          entryType,
+			OnChain:       true,
+			TxID:          txid,
+			Reference:     reference,
+			Note:          tx.Label,
+		})
+	}
+
+	if fee > 0 {
+		entries = append(entries, &Entry{
+			Timestamp:     timestamp,
+			Amount:        lnwire.NewMSatFromSatoshis(fee),
+			DebitAccount:  AccountFees,
+			CreditAccount: credit,
+			Type:          feeType,
+			OnChain:       true,
+			TxID:          txid,
+			Reference:     reference,
+		})
+	}
+
+	return entries, nil
+}
+
+// closeNote returns the note of a channel close entry.
+func closeNote(summary *channeldb.ChannelCloseSummary) string {
+	switch summary.CloseType {
+	case channeldb.CooperativeClose:
+		return "cooperative close"
+
+	case channeldb.LocalForceClose:
+		return "local force close"
+
+	case channeldb.RemoteForceClose:
+		return "remote force close"
+
+	case channeldb.BreachClose:
+		return "breach close"
+
+	default:
+		return ""
+	}
+}
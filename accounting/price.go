@@ -0,0 +1,152 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// ErrNoPrice is returned by a price source if it has no price for the
+// requested time.
+var ErrNoPrice = errors.New("no price available")
+
+// Price is the price of one bitcoin in a fiat currency.
+type Price struct {
+	// Timestamp is the time at which the price was recorded.
+	Timestamp time.Time
+
+	// Price is the price of one bitcoin.
+	Price *big.Rat
+
+	// Currency is the fiat currency of the price.
+	Currency string
+}
+
+// PriceSource provides historical bitcoin prices that are used to value the
+// entries of an accounting report in fiat.
+type PriceSource interface {
+	// GetPrice returns the price of one bitcoin at the given time.
+	GetPrice(timestamp time.Time) (*Price, error)
+}
+
+// fiatValue returns the value of the given amount at the given price.
+func fiatValue(amt lnwire.MilliSatoshi, price *Price) *big.Rat {
+	msatPerBtc := new(big.Rat).SetInt64(btcutil.SatoshiPerBitcoin * 1000)
+
+	value := new(big.Rat).SetInt(new(big.Int).SetUint64(uint64(amt)))
+	value.Mul(value, price.Price)
+
+	return value.Quo(value, msatPerBtc)
+}
+
+// CSVPriceSource is a price source that is backed by a list of historical
+// prices, as read from a CSV file. Each record of the file holds a unix
+// timestamp in seconds and the price of one bitcoin at that time, for
+// example:
+//
+//	1609459200,28990.08
+//
+// An optional header line is skipped. The price at a given time is the most
+// recent price that was recorded at or before that time.
+type CSVPriceSource struct {
+	// prices holds the prices of the source, sorted by timestamp.
+	prices []*Price
+}
+
+// A compile-time check to ensure CSVPriceSource implements the PriceSource
+// interface.
+var _ PriceSource = (*CSVPriceSource)(nil)
+
+// NewCSVPriceSource reads the prices in the given currency from the CSV
+// records provided by the reader.
+func NewCSVPriceSource(r io.Reader, currency string) (*CSVPriceSource,
+	error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	source := &CSVPriceSource{}
+	for i, record := range records {
+		timestamp, err := strconv.ParseInt(
+			strings.TrimSpace(record[0]), 10, 64,
+		)
+
+		// The first line is allowed to be a header.
+		if err != nil && i == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp in line "+
+				"%d: %v", i+1, err)
+		}
+
+		priceStr := strings.TrimSpace(record[1])
+		price, ok := new(big.Rat).SetString(priceStr)
+		if !ok || price.Sign() < 0 {
+			return nil, fmt.Errorf("invalid price in line %d: %v",
+				i+1, record[1])
+		}
+
+		source.prices = append(source.prices, &Price{
+			Timestamp: time.Unix(timestamp, 0),
+			Price:     price,
+			Currency:  currency,
+		})
+	}
+
+	if len(source.prices) == 0 {
+		return nil, errors.New("no prices found")
+	}
+
+	sort.SliceStable(source.prices, func(i, j int) bool {
+		return source.prices[i].Timestamp.Before(
+			source.prices[j].Timestamp,
+		)
+	})
+
+	return source, nil
+}
+
+// LoadCSVPriceSource reads the prices in the given currency from the CSV file
+// at the given path.
+func LoadCSVPriceSource(path, currency string) (*CSVPriceSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return NewCSVPriceSource(f, currency)
+}
+
+// GetPrice returns the most recent price that was recorded at or before the
+// given time. ErrNoPrice is returned if the time is before the first price of
+// the source.
+//
+// NOTE: This is part of the PriceSource interface.
+func (c *CSVPriceSource) GetPrice(timestamp time.Time) (*Price, error) {
+	idx := sort.Search(len(c.prices), func(i int) bool {
+		return c.prices[i].Timestamp.After(timestamp)
+	})
+	if idx == 0 {
+		return nil, fmt.Errorf("%w at %v", ErrNoPrice, timestamp)
+	}
+
+	return c.prices[idx-1], nil
+}
@@ -0,0 +1,105 @@
+package accounting
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCSVPriceSource tests reading prices from CSV records and looking up the
+// price at a given time.
+func TestCSVPriceSource(t *testing.T) {
+	t.Parallel()
+
+	records := "timestamp,price\n" +
+		"2000,30000.50\n" +
+		"1000, 20000\n" +
+		"3000,40000\n"
+
+	source, err := NewCSVPriceSource(strings.NewReader(records), "USD")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		timestamp int64
+		price     *big.Rat
+		err       error
+	}{
+		{
+			name:      "before first price",
+			timestamp: 999,
+			err:       ErrNoPrice,
+		},
+		{
+			name:      "exact timestamp",
+			timestamp: 1000,
+			price:     big.NewRat(20000, 1),
+		},
+		{
+			name:      "between prices",
+			timestamp: 2999,
+			price:     big.NewRat(60001, 2),
+		},
+		{
+			name:      "after last price",
+			timestamp: 5000,
+			price:     big.NewRat(40000, 1),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			timestamp := time.Unix(test.timestamp, 0)
+			price, err := source.GetPrice(timestamp)
+			if test.err != nil {
+				require.True(t, errors.Is(err, test.err))
+				return
+			}
+			require.NoError(t, err)
+
+			require.Zero(t, test.price.Cmp(price.Price))
+			require.Equal(t, "USD", price.Currency)
+		})
+	}
+}
+
+// TestCSVPriceSourceInvalid tests that invalid CSV records are rejected.
+func TestCSVPriceSourceInvalid(t *testing.T) {
+	t.Parallel()
+
+	invalid := []string{
+		"",
+		"timestamp,price\n",
+		"1000,20000\nabc,30000\n",
+		"1000,twenty\n",
+		"1000,-1\n",
+		"1000,20000,USD\n",
+	}
+
+	for _, records := range invalid {
+		_, err := NewCSVPriceSource(strings.NewReader(records), "USD")
+		require.Error(t, err, records)
+	}
+}
+
+// TestFiatValue tests the conversion of amounts to fiat.
+func TestFiatValue(t *testing.T) {
+	t.Parallel()
+
+	price := &Price{
+		Price: big.NewRat(30000, 1),
+	}
+
+	// One satoshi at a price of 30,000 per bitcoin is worth 0.0003.
+	value := fiatValue(1000, price)
+	require.Equal(t, "0.00030000", value.FloatString(8))
+
+	// Half a bitcoin.
+	value = fiatValue(50000000000, price)
+	require.Equal(t, "15000.00", value.FloatString(2))
+}
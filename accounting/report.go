@@ -0,0 +1,109 @@
+// Package accounting creates accounting reports of the funds that moved
+// through the node. A report normalizes the node's on-chain transactions,
+// channel opens and closes, sweeps, forwards, payments and invoices into a
+// single list of double-entry entries that move funds between the accounts of
+// a ledger, so that they can be imported into one. If a price source is
+// provided, every entry is valued in fiat at the time it took place.
+package accounting
+
+import (
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwallet"
+)
+
+// queryPageSize is the number of payments, invoices or forwarding events that
+// are queried from the database at once.
+const queryPageSize = 1000
+
+// Config holds the data sources an accounting report is created from.
+type Config struct {
+	// ListTransactions returns all transactions of our wallet, including
+	// unconfirmed ones.
+	ListTransactions func() ([]*lnwallet.TransactionDetail, error)
+
+	// OpenChannels returns all of our open channels.
+	OpenChannels func() ([]*channeldb.OpenChannel, error)
+
+	// ClosedChannels returns the close summaries of all of our closed
+	// channels.
+	ClosedChannels func() ([]*channeldb.ChannelCloseSummary, error)
+
+	// ListSweeps returns the hashes of all sweep transactions that we
+	// published.
+	ListSweeps func() ([]chainhash.Hash, error)
+
+	// QueryForwardingLog is used to query the htlcs we forwarded.
+	QueryForwardingLog func(channeldb.ForwardingEventQuery) (
+		channeldb.ForwardingLogTimeSlice, error)
+
+	// QueryPayments is used to query our lightning payments.
+	QueryPayments func(channeldb.PaymentsQuery) (channeldb.PaymentsResponse,
+		error)
+
+	// QueryInvoices is used to query our invoices.
+	QueryInvoices func(channeldb.InvoiceQuery) (channeldb.InvoiceSlice,
+		error)
+
+	// BlockTimestamp returns the timestamp of the block at the given
+	// height. It is used to date the opening and closing of channels
+	// whose transactions aren't part of our wallet.
+	BlockTimestamp func(height uint32) (time.Time, error)
+
+	// PriceSource is used to value the entries of the report in fiat. If
+	// it is nil, the entries of the report aren't valued.
+	PriceSource PriceSource
+}
+
+// Report creates an accounting report of all events that took place within
+// the inclusive time range [start, end]. A zero start or end time leaves that
+// side of the range unbounded. The entries of the report are sorted by their
+// timestamp.
+func Report(cfg *Config, start, end time.Time) ([]*Entry, error) {
+	log.Debugf("Creating accounting report for %v - %v", start, end)
+
+	entries, err := onChainEntries(cfg, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	offChain, err := offChainEntries(cfg, start, end)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, offChain...)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+
+	if cfg.PriceSource == nil {
+		return entries, nil
+	}
+
+	for _, entry := range entries {
+		price, err := cfg.PriceSource.GetPrice(entry.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.BTCPrice = price
+		entry.FiatValue = fiatValue(entry.Amount, price)
+	}
+
+	return entries, nil
+}
+
+// inRange returns true if the given time is within the inclusive range
+// [start, end]. A zero start or end time leaves that side of the range
+// unbounded.
+func inRange(t, start, end time.Time) bool {
+	if !start.IsZero() && t.Before(start) {
+		return false
+	}
+
+	return end.IsZero() || !t.After(end)
+}
@@ -0,0 +1,556 @@
+package accounting
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/require"
+)
+
+// testTx creates a transaction that spends the given outpoints to outputs of
+// the given values. A unique input index is used to make sure that all test
+// transactions have a different hash.
+func testTx(idx uint32, spends []wire.OutPoint, outputs ...int64) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: idx},
+	})
+	for _, spend := range spends {
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: spend})
+	}
+	for _, value := range outputs {
+		tx.AddTxOut(&wire.TxOut{Value: value})
+	}
+
+	return tx
+}
+
+// walletTx returns the wallet transaction details of the given transaction.
+func walletTx(t *testing.T, tx *wire.MsgTx, timestamp int64,
+	value, fee btcutil.Amount) *lnwallet.TransactionDetail {
+
+	var b bytes.Buffer
+	require.NoError(t, tx.Serialize(&b))
+
+	return &lnwallet.TransactionDetail{
+		Hash:             tx.TxHash(),
+		Value:            value,
+		NumConfirmations: 1,
+		Timestamp:        timestamp,
+		TotalFees:        int64(fee),
+		RawTx:            b.Bytes(),
+	}
+}
+
+// testPayment creates a succeeded payment with the given hash that was sent
+// over a single route.
+func testPayment(hash lntypes.Hash, timestamp int64, amt,
+	fee lnwire.MilliSatoshi) *channeldb.MPPayment {
+
+	return &channeldb.MPPayment{
+		Info: &channeldb.PaymentCreationInfo{
+			PaymentIdentifier: hash,
+			Value:             amt,
+			CreationTime:      time.Unix(timestamp, 0),
+		},
+		HTLCs: []channeldb.HTLCAttempt{{
+			HTLCAttemptInfo: channeldb.HTLCAttemptInfo{
+				Route: route.Route{
+					TotalAmount: amt + fee,
+					Hops: []*route.Hop{{
+						AmtToForward: amt,
+					}},
+				},
+			},
+			Settle: &channeldb.HTLCSettleInfo{},
+		}},
+		Status: channeldb.StatusSucceeded,
+	}
+}
+
+// testInvoice creates a settled invoice with the given preimage.
+func testInvoice(preimage lntypes.Preimage, timestamp int64,
+	amt lnwire.MilliSatoshi) channeldb.Invoice {
+
+	return channeldb.Invoice{
+		Memo:       []byte("memo"),
+		SettleDate: time.Unix(timestamp, 0),
+		AmtPaid:    amt,
+		State:      channeldb.ContractSettled,
+		Terms: channeldb.ContractTerm{
+			PaymentPreimage: &preimage,
+		},
+	}
+}
+
+// testReport holds the data sources of a test accounting report.
+type testReport struct {
+	txs       []*lnwallet.TransactionDetail
+	open      []*channeldb.OpenChannel
+	closed    []*channeldb.ChannelCloseSummary
+	sweeps    []chainhash.Hash
+	forwards  []channeldb.ForwardingEvent
+	payments  []*channeldb.MPPayment
+	invoices  []channeldb.Invoice
+	blockTime map[uint32]int64
+}
+
+// config returns a report config that is backed by the test data. Queries
+// are filtered by the time ranges of the report and return all results at
+// once.
+func (r *testReport) config() *Config {
+	return &Config{
+		ListTransactions: func() ([]*lnwallet.TransactionDetail,
+			error) {
+
+			return r.txs, nil
+		},
+		OpenChannels: func() ([]*channeldb.OpenChannel, error) {
+			return r.open, nil
+		},
+		ClosedChannels: func() ([]*channeldb.ChannelCloseSummary,
+			error) {
+
+			return r.closed, nil
+		},
+		ListSweeps: func() ([]chainhash.Hash, error) {
+			return r.sweeps, nil
+		},
+		QueryForwardingLog: func(q channeldb.ForwardingEventQuery) (
+			channeldb.ForwardingLogTimeSlice, error) {
+
+			var resp channeldb.ForwardingLogTimeSlice
+			for _, event := range r.forwards {
+				if inRange(event.Timestamp, q.StartTime,
+					q.EndTime) {
+
+					resp.ForwardingEvents = append(
+						resp.ForwardingEvents, event,
+					)
+				}
+			}
+
+			return resp, nil
+		},
+		QueryPayments: func(q channeldb.PaymentsQuery) (
+			channeldb.PaymentsResponse, error) {
+
+			var resp channeldb.PaymentsResponse
+			for _, payment := range r.payments {
+				created := payment.Info.CreationTime
+				if inRange(created, q.CreationDateStart,
+					q.CreationDateEnd) {
+
+					resp.Payments = append(
+						resp.Payments, payment,
+					)
+				}
+			}
+
+			return resp, nil
+		},
+		QueryInvoices: func(q channeldb.InvoiceQuery) (
+			channeldb.InvoiceSlice, error) {
+
+			var resp channeldb.InvoiceSlice
+			if q.Archived {
+				return resp, nil
+			}
+
+			for _, invoice := range r.invoices {
+				if inRange(invoice.SettleDate,
+					q.SettleDateStart, q.SettleDateEnd) {
+
+					resp.Invoices = append(
+						resp.Invoices, invoice,
+					)
+				}
+			}
+
+			return resp, nil
+		},
+		BlockTimestamp: func(height uint32) (time.Time, error) {
+			return time.Unix(r.blockTime[height], 0), nil
+		},
+	}
+}
+
+// TestReport tests the creation of an accounting report from all kinds of
+// on-chain and off-chain events.
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// A channel that we opened and cooperatively closed.
+		fundingTx = testTx(1, nil, 500000, 10000)
+		localChan = wire.OutPoint{Hash: fundingTx.TxHash()}
+		closeTx   = testTx(2, []wire.OutPoint{localChan}, 400000,
+			99000)
+
+		// A channel that our peer opened and breached.
+		remoteChan = wire.OutPoint{Hash: chainhash.Hash{1}}
+		breachTx   = testTx(3, []wire.OutPoint{remoteChan}, 70000)
+		justiceTx  = testTx(
+			4, []wire.OutPoint{{Hash: breachTx.TxHash()}}, 69000,
+		)
+
+		// A channel that our peer opened that is still open.
+		openChan = wire.OutPoint{Hash: chainhash.Hash{2}}
+
+		receiveTx = testTx(5, nil, 100000)
+		sendTx    = testTx(6, nil, 20000, 5000)
+		sweepTx   = testTx(7, nil, 50000)
+		unconfTx  = testTx(8, nil, 1000)
+
+		preimage         = lntypes.Preimage{1}
+		circularPreimage = lntypes.Preimage{2}
+		paymentHash      = lntypes.Hash{3}
+	)
+
+	unconfirmed := walletTx(t, unconfTx, 9500, 1000, 0)
+	unconfirmed.NumConfirmations = 0
+
+	r := &testReport{
+		txs: []*lnwallet.TransactionDetail{
+			walletTx(t, receiveTx, 1000, 100000, 0),
+			walletTx(t, fundingTx, 2000, -500300, 300),
+			walletTx(t, sendTx, 3000, -20500, 500),
+			walletTx(t, closeTx, 4000, 400000, 0),
+			walletTx(t, sweepTx, 5000, 50000, 200),
+			walletTx(t, justiceTx, 6000, 69000, 0),
+			unconfirmed,
+		},
+		open: []*channeldb.OpenChannel{{
+			FundingOutpoint: openChan,
+			Capacity:        200000,
+			ShortChannelID: lnwire.ShortChannelID{
+				BlockHeight: 300,
+			},
+		}},
+		closed: []*channeldb.ChannelCloseSummary{
+			{
+				ChanPoint:   localChan,
+				ClosingTXID: closeTx.TxHash(),
+				Capacity:    500000,
+				CloseType:   channeldb.CooperativeClose,
+				ShortChanID: lnwire.ShortChannelID{
+					BlockHeight: 100,
+				},
+			},
+			{
+				ChanPoint:   remoteChan,
+				ClosingTXID: breachTx.TxHash(),
+				Capacity:    300000,
+				CloseHeight: 200,
+				CloseType:   channeldb.BreachClose,
+				ShortChanID: lnwire.ShortChannelID{
+					BlockHeight: 50,
+				},
+			},
+		},
+		sweeps: []chainhash.Hash{sweepTx.TxHash()},
+		forwards: []channeldb.ForwardingEvent{{
+			Timestamp:      time.Unix(7000, 0),
+			IncomingChanID: lnwire.NewShortChanIDFromInt(1),
+			OutgoingChanID: lnwire.NewShortChanIDFromInt(2),
+			AmtIn:          1001000,
+			AmtOut:         1000000,
+		}},
+		invoices: []channeldb.Invoice{
+			testInvoice(preimage, 8000, 30000),
+			testInvoice(circularPreimage, 9000, 10000),
+		},
+		payments: []*channeldb.MPPayment{
+			testPayment(paymentHash, 8500, 40000, 100),
+			testPayment(circularPreimage.Hash(), 9000, 10000, 10),
+		},
+		blockTime: map[uint32]int64{
+			50:  500,
+			200: 5500,
+			300: 1500,
+		},
+	}
+
+	entries, err := Report(r.config(), time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	forwardRef := "0:0:1:0:0:2"
+	expected := []*Entry{
+		{
+			Timestamp:     time.Unix(500, 0),
+			DebitAccount:  AccountChannels,
+			CreditAccount: AccountExternal,
+			Type:          EntryTypeRemoteChannelOpen,
+			OnChain:       true,
+			TxID:          remoteChan.Hash.String(),
+			Reference:     remoteChan.String(),
+		},
+		{
+			Timestamp:     time.Unix(1000, 0),
+			Amount:        100000000,
+			DebitAccount:  AccountWallet,
+			CreditAccount: AccountExternal,
+			Type:          EntryTypeReceipt,
+			OnChain:       true,
+			TxID:          receiveTx.TxHash().String(),
+			Reference:     receiveTx.TxHash().String(),
+		},
+		{
+			Timestamp:     time.Unix(1500, 0),
+			DebitAccount:  AccountChannels,
+			CreditAccount: AccountExternal,
+			Type:          EntryTypeRemoteChannelOpen,
+			OnChain:       true,
+			TxID:          openChan.Hash.String(),
+			Reference:     openChan.String(),
+		},
+		{
+			Timestamp:     time.Unix(2000, 0),
+			Amount:        500000000,
+			DebitAccount:  AccountChannels,
+			CreditAccount: AccountWallet,
+			Type:          EntryTypeLocalChannelOpen,
+			OnChain:       true,
+			TxID:          fundingTx.TxHash().String(),
+			Reference:     localChan.String(),
+		},
+		{
+			Timestamp:     time.Unix(2000, 0),
+			Amount:        300000,
+			DebitAccount:  AccountFees,
+			CreditAccount: AccountWallet,
+			Type:          EntryTypeChannelOpenFee,
+			OnChain:       true,
+			TxID:          fundingTx.TxHash().String(),
+			Reference:     fundingTx.TxHash().String(),
+		},
+		{
+			Timestamp:     time.Unix(3000, 0),
+			Amount:        20000000,
+			DebitAccount:  AccountExternal,
+			CreditAccount: AccountWallet,
+			Type:          EntryTypePayment,
+			OnChain:       true,
+			TxID:          sendTx.TxHash().String(),
+			Reference:     sendTx.TxHash().String(),
+		},
+		{
+			Timestamp:     time.Unix(3000, 0),
+			Amount:        500000,
+			DebitAccount:  AccountFees,
+			CreditAccount: AccountWallet,
+			Type:          EntryTypeFee,
+			OnChain:       true,
+			TxID:          sendTx.TxHash().String(),
+			Reference:     sendTx.TxHash().String(),
+		},
+		{
+			Timestamp:     time.Unix(4000, 0),
+			Amount:        400000000,
+			DebitAccount:  AccountWallet,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeChannelClose,
+			OnChain:       true,
+			TxID:          closeTx.TxHash().String(),
+			Reference:     localChan.String(),
+			Note:          "cooperative close",
+		},
+		{
+			Timestamp:     time.Unix(4000, 0),
+			Amount:        1000000,
+			DebitAccount:  AccountFees,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeChannelCloseFee,
+			OnChain:       true,
+			TxID:          closeTx.TxHash().String(),
+			Reference:     localChan.String(),
+		},
+		{
+			Timestamp:     time.Unix(5000, 0),
+			Amount:        50000000,
+			DebitAccount:  AccountWallet,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeSweep,
+			OnChain:       true,
+			TxID:          sweepTx.TxHash().String(),
+			Reference:     sweepTx.TxHash().String(),
+		},
+		{
+			Timestamp:     time.Unix(5000, 0),
+			Amount:        200000,
+			DebitAccount:  AccountFees,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeSweepFee,
+			OnChain:       true,
+			TxID:          sweepTx.TxHash().String(),
+			Reference:     sweepTx.TxHash().String(),
+		},
+		{
+			Timestamp:     time.Unix(5500, 0),
+			DebitAccount:  AccountWallet,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeChannelClose,
+			OnChain:       true,
+			TxID:          breachTx.TxHash().String(),
+			Reference:     remoteChan.String(),
+			Note:          "breach close",
+		},
+		{
+			Timestamp:     time.Unix(6000, 0),
+			Amount:        69000000,
+			DebitAccount:  AccountWallet,
+			CreditAccount: AccountExternal,
+			Type:          EntryTypeJustice,
+			OnChain:       true,
+			TxID:          justiceTx.TxHash().String(),
+			Reference:     remoteChan.String(),
+		},
+		{
+			Timestamp:     time.Unix(7000, 0),
+			DebitAccount:  AccountChannels,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeForward,
+			Reference:     forwardRef,
+			Note: "incoming: 1001000 mSAT, outgoing: " +
+				"1000000 mSAT",
+		},
+		{
+			Timestamp:     time.Unix(7000, 0),
+			Amount:        1000,
+			DebitAccount:  AccountChannels,
+			CreditAccount: AccountForwardingIncome,
+			Type:          EntryTypeForwardFee,
+			Reference:     forwardRef,
+		},
+		{
+			Timestamp:     time.Unix(8000, 0),
+			Amount:        30000,
+			DebitAccount:  AccountChannels,
+			CreditAccount: AccountExternal,
+			Type:          EntryTypeReceipt,
+			Reference:     preimage.Hash().String(),
+			Note:          "memo",
+		},
+		{
+			Timestamp:     time.Unix(8500, 0),
+			Amount:        40000,
+			DebitAccount:  AccountExternal,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypePayment,
+			Reference:     paymentHash.String(),
+		},
+		{
+			Timestamp:     time.Unix(8500, 0),
+			Amount:        100,
+			DebitAccount:  AccountFees,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeFee,
+			Reference:     paymentHash.String(),
+		},
+		{
+			Timestamp:     time.Unix(9000, 0),
+			Amount:        10000,
+			DebitAccount:  AccountChannels,
+			CreditAccount: AccountExternal,
+			Type:          EntryTypeCircularReceipt,
+			Reference:     circularPreimage.Hash().String(),
+			Note:          "memo",
+		},
+		{
+			Timestamp:     time.Unix(9000, 0),
+			Amount:        10000,
+			DebitAccount:  AccountExternal,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeCircularPayment,
+			Reference:     circularPreimage.Hash().String(),
+		},
+		{
+			Timestamp:     time.Unix(9000, 0),
+			Amount:        10,
+			DebitAccount:  AccountFees,
+			CreditAccount: AccountChannels,
+			Type:          EntryTypeCircularFee,
+			Reference:     circularPreimage.Hash().String(),
+		},
+	}
+	require.Equal(t, expected, entries)
+
+	// The wallet account changes by the value of our confirmed wallet
+	// transactions, and the fees and forwarding income are collected in
+	// their own accounts.
+	balances := make(map[Account]int64)
+	for _, entry := range entries {
+		balances[entry.DebitAccount] += int64(entry.Amount)
+		balances[entry.CreditAccount] -= int64(entry.Amount)
+	}
+	require.EqualValues(t, 98200000, balances[AccountWallet])
+	require.EqualValues(t, 2000110, balances[AccountFees])
+	require.EqualValues(t, -1000, balances[AccountForwardingIncome])
+
+	// A report over a time range should only include the entries within
+	// that range.
+	entries, err = Report(
+		r.config(), time.Unix(3000, 0), time.Unix(5500, 0),
+	)
+	require.NoError(t, err)
+	require.Equal(t, expected[5:12], entries)
+}
+
+// TestReportFiatValues tests that the entries of a report are valued in fiat
+// if a price source is set.
+func TestReportFiatValues(t *testing.T) {
+	t.Parallel()
+
+	receiveTx := testTx(1, nil, 100000)
+	r := &testReport{
+		txs: []*lnwallet.TransactionDetail{
+			walletTx(t, receiveTx, 1000, 100000, 0),
+		},
+		payments: []*channeldb.MPPayment{
+			testPayment(lntypes.Hash{1}, 3000, 2000000, 0),
+		},
+	}
+
+	price1 := &Price{
+		Timestamp: time.Unix(500, 0),
+		Price:     big.NewRat(20000, 1),
+		Currency:  "EUR",
+	}
+	price2 := &Price{
+		Timestamp: time.Unix(2000, 0),
+		Price:     big.NewRat(30000, 1),
+		Currency:  "EUR",
+	}
+
+	cfg := r.config()
+	cfg.PriceSource = &CSVPriceSource{
+		prices: []*Price{price1, price2},
+	}
+
+	entries, err := Report(cfg, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	require.Equal(t, price1, entries[0].BTCPrice)
+	require.Equal(t, "20.00", entries[0].FiatValue.FloatString(2))
+
+	require.Equal(t, price2, entries[1].BTCPrice)
+	require.Equal(t, "0.60", entries[1].FiatValue.FloatString(2))
+
+	// Entries before the first price can't be valued.
+	cfg.PriceSource = &CSVPriceSource{
+		prices: []*Price{price2},
+	}
+	_, err = Report(cfg, time.Time{}, time.Time{})
+	require.ErrorIs(t, err, ErrNoPrice)
+}
@@ -32,6 +32,8 @@ import (
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/btcwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwallet/chanfunding"
+	"github.com/lightningnetwork/lnd/lnwallet/rpcwallet"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/routing/chainview"
 )
@@ -115,8 +117,15 @@ type Config struct {
 	LoaderOptions []btcwallet.LoaderOption
 
 	// CoinSelectionStrategy is the strategy that is used for selecting
-	// coins when funding a transaction.
-	CoinSelectionStrategy wallet.CoinSelectionStrategy
+	// coins when funding a transaction. The wallet's internal coin
+	// selection doesn't know about address groups, so it picks coins at
+	// random if the privacy strategy is used.
+	CoinSelectionStrategy chanfunding.CoinSelectionStrategy
+
+	// RemoteSigner holds the options for using a remote signer. If remote
+	// signing is enabled, the wallet is a watch-only wallet and all
+	// signing and ECDH operations are delegated to the remote signer.
+	RemoteSigner *lncfg.RemoteSigner
 }
 
 const (
@@ -212,9 +221,6 @@ type ChainControl struct {
 	// will be provided to the Wallet *LightningWallet raw pointer below.
 	Wc lnwallet.WalletController
 
-	// MsgSigner is used to sign arbitrary messages.
-	MsgSigner lnwallet.MessageSigner
-
 	// ChainNotifier is used to receive blockchain events that we are interested in.
 	ChainNotifier chainntnfs.ChainNotifier
 
@@ -281,6 +287,12 @@ func NewChainControl(cfg *Config, blockCache *blockcache.BlockCache) (
 			"unknown", cfg.PrimaryChain())
 	}
 
+	walletStrategy := wallet.CoinSelectionLargest
+	switch cfg.CoinSelectionStrategy {
+	case chanfunding.CoinSelectionRandom, chanfunding.CoinSelectionPrivacy:
+		walletStrategy = wallet.CoinSelectionRandom
+	}
+
 	walletConfig := &btcwallet.Config{
 		PrivatePass:           cfg.PrivateWalletPw,
 		PublicPass:            cfg.PublicWalletPw,
@@ -290,7 +302,7 @@ func NewChainControl(cfg *Config, blockCache *blockcache.BlockCache) (
 		CoinType:              cfg.ActiveNetParams.CoinType,
 		Wallet:                cfg.Wallet,
 		LoaderOptions:         cfg.LoaderOptions,
-		CoinSelectionStrategy: cfg.CoinSelectionStrategy,
+		CoinSelectionStrategy: walletStrategy,
 	}
 
 	var err error
@@ -665,7 +677,6 @@ func NewChainControl(cfg *Config, blockCache *blockcache.BlockCache) (
 		return nil, ccCleanup, err
 	}
 
-	cc.MsgSigner = wc
 	cc.Signer = wc
 	cc.ChainIO = wc
 	cc.Wc = wc
@@ -679,6 +690,24 @@ func NewChainControl(cfg *Config, blockCache *blockcache.BlockCache) (
 	keyRing := keychain.NewBtcWalletKeyRing(
 		wc.InternalWallet(), cfg.ActiveNetParams.CoinType,
 	)
+
+	// If remote signing is enabled, the local wallet is watch-only and we
+	// wrap it so all signing and ECDH operations are forwarded to the
+	// remote signer.
+	if cfg.RemoteSigner != nil && cfg.RemoteSigner.Enable {
+		rpcKeyRing, err := rpcwallet.NewRPCKeyRing(
+			keyRing, wc, wc.InternalWallet(), cfg.RemoteSigner,
+		)
+		if err != nil {
+			fmt.Printf("unable to create remote signing key "+
+				"ring: %v\n", err)
+			return nil, ccCleanup, err
+		}
+
+		keyRing = rpcKeyRing
+		cc.Signer = rpcKeyRing
+		cc.Wc = rpcKeyRing
+	}
 	cc.KeyRing = keyRing
 
 	// Create, and start the lnwallet, which handles the core payment
@@ -686,13 +715,15 @@ func NewChainControl(cfg *Config, blockCache *blockcache.BlockCache) (
 	walletCfg := lnwallet.Config{
 		Database:           cfg.RemoteChanDB,
 		Notifier:           cc.ChainNotifier,
-		WalletController:   wc,
+		WalletController:   cc.Wc,
 		Signer:             cc.Signer,
 		FeeEstimator:       cc.FeeEstimator,
 		SecretKeyRing:      keyRing,
 		ChainIO:            cc.ChainIO,
 		DefaultConstraints: channelConstraints,
 		NetParams:          *cfg.ActiveNetParams.Params,
+
+		CoinSelectionStrategy: cfg.CoinSelectionStrategy,
 	}
 	lnWallet, err := lnwallet.NewLightningWallet(walletCfg)
 	if err != nil {
@@ -18,6 +18,8 @@ import (
 	"github.com/lightningnetwork/lnd/channeldb/migration16"
 	"github.com/lightningnetwork/lnd/channeldb/migration20"
 	"github.com/lightningnetwork/lnd/channeldb/migration21"
+	"github.com/lightningnetwork/lnd/channeldb/migration23"
+	"github.com/lightningnetwork/lnd/channeldb/migration24"
 	"github.com/lightningnetwork/lnd/channeldb/migration_01_to_11"
 	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/kvdb"
@@ -195,6 +197,20 @@ var (
 			number:    22,
 			migration: mig.CreateTLB(setIDIndexBucket),
 		},
+		{
+			// Index invoices and payments by creation date and
+			// settled invoices by settle date, so that they can be
+			// queried by date range.
+			number:    23,
+			migration: migration23.MigrateTimeIndexes,
+		},
+		{
+			// Index payments by their destination, payment address
+			// and invoice description, so that they can be looked
+			// up by them.
+			number:    24,
+			migration: migration24.MigratePaymentLookupIndex,
+		},
 	}
 
 	// Big endian is the preferred byte order, due to cursor scans over
@@ -322,12 +338,18 @@ var topLevelBuckets = [][]byte{
 	openChannelBucket,
 	closedChannelBucket,
 	forwardingLogBucket,
+	htlcEventLogBucket,
 	fwdPackagesKey,
 	invoiceBucket,
+	invoiceArchiveBucket,
 	payAddrIndexBucket,
 	setIDIndexBucket,
 	paymentsIndexBucket,
+	paymentsCreationTimeIndexBucket,
+	paymentsLookupIndexBucket,
 	peersBucket,
+	lnurlUsersBucket,
+	recurringPaymentsBucket,
 	nodeInfoBucket,
 	nodeBucket,
 	edgeBucket,
@@ -118,6 +118,10 @@ var (
 	// to the log not having any recorded events.
 	ErrNoForwardingEvents = fmt.Errorf("no recorded forwarding events")
 
+	// ErrNoHtlcEvents is returned in the case that a query fails due to
+	// the htlc event log not having any recorded events.
+	ErrNoHtlcEvents = fmt.Errorf("no recorded htlc events")
+
 	// ErrEdgePolicyOptionalFieldNotFound is an error returned if a channel
 	// policy field is not found in the db even though its message flags
 	// indicate it should be.
@@ -0,0 +1,390 @@
+package channeldb
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+var (
+	// htlcEventLogBucket is the bucket that we'll use to store the htlc
+	// event log. Like the forwarding log, the htlc event log is a time
+	// series database. Each key within the bucket is a timestamp (in nano
+	// seconds since the unix epoch) at which a forwarded htlc was
+	// resolved, and the value is the serialized htlc event.
+	htlcEventLogBucket = []byte("htlc-event-log")
+)
+
+const (
+	// htlcEventSize is the size of a serialized htlc event. The breakdown
+	// is as follows:
+	//
+	//  * 8 byte incoming chan ID || 8 byte outgoing chan ID ||
+	//    8 byte incoming htlc ID || 8 byte outgoing htlc ID ||
+	//    8 byte value in || 8 byte value out || 1 byte outcome ||
+	//    1 byte failed incoming || 2 byte failure code ||
+	//    2 byte failure detail || 8 byte hold time
+	htlcEventSize = 62
+)
+
+// HtlcEventOutcome describes how a forwarded htlc was resolved.
+type HtlcEventOutcome uint8
+
+const (
+	// HtlcOutcomeSettled indicates that the htlc was settled.
+	HtlcOutcomeSettled HtlcEventOutcome = 0
+
+	// HtlcOutcomeLinkFailed indicates that the htlc was failed by our
+	// node, either on the incoming or on the outgoing link.
+	HtlcOutcomeLinkFailed HtlcEventOutcome = 1
+
+	// HtlcOutcomeForwardFailed indicates that the htlc was failed by a
+	// node further down the route.
+	HtlcOutcomeForwardFailed HtlcEventOutcome = 2
+)
+
+// String returns a human readable representation of the outcome.
+func (h HtlcEventOutcome) String() string {
+	switch h {
+	case HtlcOutcomeSettled:
+		return "settled"
+
+	case HtlcOutcomeLinkFailed:
+		return "link failed"
+
+	case HtlcOutcomeForwardFailed:
+		return "forward failed"
+
+	default:
+		return "unknown"
+	}
+}
+
+// HtlcEventLog returns an instance of the HtlcEventLog object backed by the
+// target database instance.
+func (d *DB) HtlcEventLog() *HtlcEventLog {
+	return &HtlcEventLog{
+		db: d,
+	}
+}
+
+// HtlcEventLog is a time series database that logs the resolution of every
+// htlc that we were asked to forward. Unlike the ForwardingLog, which only
+// contains the circuits that were settled, the htlc event log also records
+// the forwards that failed along with the reason they failed, which allows
+// callers to reason about the demand that was turned away.
+type HtlcEventLog struct {
+	db *DB
+}
+
+// HtlcEvent is an event in the htlc event log's time series. Each event
+// describes the full lifecycle of a single forward attempt, from the moment
+// the incoming htlc was offered to us until it was resolved. Fields that were
+// not known at the time of resolution, for instance because the node was
+// restarted while the htlc was in flight, are left zero.
+type HtlcEvent struct {
+	// Timestamp is the resolution time of the htlc.
+	Timestamp time.Time
+
+	// IncomingChanID is the channel the htlc arrived on.
+	IncomingChanID lnwire.ShortChannelID
+
+	// OutgoingChanID is the channel the htlc was forwarded over. For htlcs
+	// that failed on our incoming link, it is the channel the sender asked
+	// us to forward over, if we got as far as decoding the onion.
+	OutgoingChanID lnwire.ShortChannelID
+
+	// IncomingHtlcID is the htlc index on the incoming channel.
+	IncomingHtlcID uint64
+
+	// OutgoingHtlcID is the htlc index on the outgoing channel.
+	OutgoingHtlcID uint64
+
+	// AmtIn is the amount of the incoming htlc.
+	AmtIn lnwire.MilliSatoshi
+
+	// AmtOut is the amount of the outgoing htlc.
+	AmtOut lnwire.MilliSatoshi
+
+	// Outcome describes how the htlc was resolved.
+	Outcome HtlcEventOutcome
+
+	// FailedIncoming is true if the htlc was failed on our incoming link,
+	// and false if it failed on our outgoing link. It is only meaningful
+	// if the outcome is HtlcOutcomeLinkFailed.
+	FailedIncoming bool
+
+	// FailureCode is the wire failure code that we failed the htlc with.
+	// It is only set if the outcome is HtlcOutcomeLinkFailed.
+	FailureCode lnwire.FailCode
+
+	// FailureDetail is an opaque value which further describes a failure
+	// that occurred on one of our links. It is interpreted by the
+	// subsystem that recorded the event.
+	FailureDetail uint16
+
+	// HoldTime is the amount of time that passed between the htlc being
+	// forwarded and it being resolved.
+	HoldTime time.Duration
+}
+
+// encodeHtlcEvent writes out the target htlc event to the passed io.Writer,
+// using the expected DB format. Note that the timestamp isn't serialized as
+// this will be the key value within the bucket.
+func encodeHtlcEvent(w io.Writer, h *HtlcEvent) error {
+	return WriteElements(
+		w, h.IncomingChanID, h.OutgoingChanID, h.IncomingHtlcID,
+		h.OutgoingHtlcID, h.AmtIn, h.AmtOut, uint8(h.Outcome),
+		h.FailedIncoming, uint16(h.FailureCode), h.FailureDetail,
+		uint64(h.HoldTime),
+	)
+}
+
+// decodeHtlcEvent attempts to decode the raw bytes of a serialized htlc event
+// into the target HtlcEvent. Note that the timestamp won't be decoded, as the
+// caller is expected to set this due to the bucket structure of the log.
+func decodeHtlcEvent(r io.Reader, h *HtlcEvent) error {
+	var (
+		outcome     uint8
+		failureCode uint16
+		holdTime    uint64
+	)
+	err := ReadElements(
+		r, &h.IncomingChanID, &h.OutgoingChanID, &h.IncomingHtlcID,
+		&h.OutgoingHtlcID, &h.AmtIn, &h.AmtOut, &outcome,
+		&h.FailedIncoming, &failureCode, &h.FailureDetail, &holdTime,
+	)
+	if err != nil {
+		return err
+	}
+
+	h.Outcome = HtlcEventOutcome(outcome)
+	h.FailureCode = lnwire.FailCode(failureCode)
+	h.HoldTime = time.Duration(holdTime)
+
+	return nil
+}
+
+// AddHtlcEvents adds a series of htlc events to the database. Before
+// inserting, the set of events will be sorted according to their timestamp.
+// This ensures that all writes to disk are sequential.
+func (h *HtlcEventLog) AddHtlcEvents(events []HtlcEvent) error {
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	var timestamp [8]byte
+
+	return kvdb.Batch(h.db.Backend, func(tx kvdb.RwTx) error {
+		logBucket, err := tx.CreateTopLevelBucket(htlcEventLogBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			err := storeHtlcEvent(logBucket, event, timestamp[:])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// storeHtlcEvent stores a htlc event into the given bucket. If a key for the
+// event timestamp already exists in the database, the timestamp is
+// incremented in nanosecond intervals until a free slot is found, in the same
+// way as is done for forwarding events.
+func storeHtlcEvent(bucket walletdb.ReadWriteBucket, event HtlcEvent,
+	timestampScratchSpace []byte) error {
+
+	byteOrder.PutUint64(
+		timestampScratchSpace, uint64(event.Timestamp.UnixNano()),
+	)
+
+	const maxTries = 100
+	for tries := 0; tries < maxTries; tries++ {
+		if bucket.Get(timestampScratchSpace) == nil {
+			break
+		}
+
+		// Collision, try the next nanosecond timestamp.
+		nextNano := event.Timestamp.UnixNano() + 1
+		event.Timestamp = time.Unix(0, nextNano)
+		byteOrder.PutUint64(timestampScratchSpace, uint64(nextNano))
+	}
+
+	var eventBytes [htlcEventSize]byte
+	eventBuf := bytes.NewBuffer(eventBytes[0:0:htlcEventSize])
+	if err := encodeHtlcEvent(eventBuf, &event); err != nil {
+		return err
+	}
+
+	return bucket.Put(timestampScratchSpace, eventBuf.Bytes())
+}
+
+// HtlcEventQuery represents a query to the htlc event log. The query allows a
+// caller to retrieve the events for a particular time slice, optionally
+// restricted to a set of incoming and outgoing channels, offset in that time
+// slice and limiting the total number of responses returned.
+type HtlcEventQuery struct {
+	// StartTime is the start time of the time slice.
+	StartTime time.Time
+
+	// EndTime is the end time of the time slice.
+	EndTime time.Time
+
+	// IncomingChanIDs restricts the query to events that arrived on one
+	// of the given channels. If empty, events for all incoming channels
+	// are returned.
+	IncomingChanIDs []lnwire.ShortChannelID
+
+	// OutgoingChanIDs restricts the query to events that were forwarded
+	// over one of the given channels. If empty, events for all outgoing
+	// channels are returned.
+	OutgoingChanIDs []lnwire.ShortChannelID
+
+	// IndexOffset is the number of matching events within the time slice
+	// to skip. This can be used to start the response at a particular
+	// record.
+	IndexOffset uint32
+
+	// NumMaxEvents is the max number of events to return.
+	NumMaxEvents uint32
+}
+
+// HtlcEventTimeSlice is the response to a htlc event query. It includes the
+// original query, the set of events that match the query, and the offset
+// index of the last item in the set of returned events, which allows callers
+// to resume their query.
+type HtlcEventTimeSlice struct {
+	HtlcEventQuery
+
+	// HtlcEvents is the set of events in our time series that answer the
+	// query embedded above.
+	HtlcEvents []HtlcEvent
+
+	// LastIndexOffset is the index of the last element in the set of
+	// returned HtlcEvents above. Callers can use this to resume their
+	// query in the event that the time slice has too many events to fit
+	// into a single response.
+	LastIndexOffset uint32
+}
+
+// chanIDSet returns a set of the given channel IDs, or nil if no channel IDs
+// are given.
+func chanIDSet(chanIDs []lnwire.ShortChannelID) map[lnwire.ShortChannelID]struct{} {
+	if len(chanIDs) == 0 {
+		return nil
+	}
+
+	set := make(map[lnwire.ShortChannelID]struct{}, len(chanIDs))
+	for _, chanID := range chanIDs {
+		set[chanID] = struct{}{}
+	}
+
+	return set
+}
+
+// Query allows a caller to query the htlc event time series for a particular
+// time slice and set of channels. The caller can control the precise time as
+// well as the number of events to be returned.
+func (h *HtlcEventLog) Query(q HtlcEventQuery) (HtlcEventTimeSlice, error) {
+	var resp HtlcEventTimeSlice
+
+	incoming := chanIDSet(q.IncomingChanIDs)
+	outgoing := chanIDSet(q.OutgoingChanIDs)
+
+	// matches returns true if the event passes the channel filters of the
+	// query.
+	matches := func(event *HtlcEvent) bool {
+		if incoming != nil {
+			if _, ok := incoming[event.IncomingChanID]; !ok {
+				return false
+			}
+		}
+
+		if outgoing != nil {
+			if _, ok := outgoing[event.OutgoingChanID]; !ok {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	// The offset counts matching records only, so that callers can page
+	// through a filtered query. Both counters are set in the reset closure
+	// below, so that they start over if the transaction is retried.
+	var recordsToSkip, recordOffset uint32
+
+	err := kvdb.View(h.db, func(tx kvdb.RTx) error {
+		// If the bucket wasn't found, then there aren't any events to
+		// be returned.
+		logBucket := tx.ReadBucket(htlcEventLogBucket)
+		if logBucket == nil {
+			return ErrNoHtlcEvents
+		}
+
+		var startTime, endTime [8]byte
+		byteOrder.PutUint64(startTime[:], uint64(q.StartTime.UnixNano()))
+		byteOrder.PutUint64(endTime[:], uint64(q.EndTime.UnixNano()))
+
+		logCursor := logBucket.ReadCursor()
+		timestamp, eventBytes := logCursor.Seek(startTime[:])
+		for ; timestamp != nil && bytes.Compare(timestamp, endTime[:]) <= 0; timestamp, eventBytes = logCursor.Next() {
+			// If our current return payload exceeds the max number
+			// of events, then we'll exit now.
+			if uint32(len(resp.HtlcEvents)) >= q.NumMaxEvents {
+				return nil
+			}
+
+			var event HtlcEvent
+			err := decodeHtlcEvent(
+				bytes.NewReader(eventBytes), &event,
+			)
+			if err != nil {
+				return err
+			}
+
+			if !matches(&event) {
+				continue
+			}
+
+			// If we're not yet past the user defined offset, then
+			// we'll continue to seek forward.
+			if recordsToSkip > 0 {
+				recordsToSkip--
+				continue
+			}
+
+			event.Timestamp = time.Unix(
+				0, int64(byteOrder.Uint64(timestamp)),
+			)
+			resp.HtlcEvents = append(resp.HtlcEvents, event)
+
+			recordOffset++
+		}
+
+		return nil
+	}, func() {
+		resp = HtlcEventTimeSlice{
+			HtlcEventQuery: q,
+		}
+		recordsToSkip = q.IndexOffset
+		recordOffset = q.IndexOffset
+	})
+	if err != nil && err != ErrNoHtlcEvents {
+		return HtlcEventTimeSlice{}, err
+	}
+
+	resp.LastIndexOffset = recordOffset
+
+	return resp, nil
+}
@@ -0,0 +1,134 @@
+package channeldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHtlcEventLogStorageAndQuery tests that we're able to store htlc events
+// and then query for them, including the channel filters and pagination.
+func TestHtlcEventLogStorageAndQuery(t *testing.T) {
+	t.Parallel()
+
+	db, cleanUp, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanUp()
+
+	log := db.HtlcEventLog()
+
+	// Querying an empty log should not fail.
+	startTime := time.Unix(1234, 0)
+	timeSlice, err := log.Query(HtlcEventQuery{
+		StartTime:    startTime,
+		EndTime:      startTime.Add(time.Hour),
+		NumMaxEvents: 10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, timeSlice.HtlcEvents)
+
+	chanA := lnwire.NewShortChanIDFromInt(1)
+	chanB := lnwire.NewShortChanIDFromInt(2)
+	chanC := lnwire.NewShortChanIDFromInt(3)
+
+	// We'll create a set of events which alternate between forwards from
+	// channel A to B that settle and forwards from channel B to C that
+	// fail. All events share the same timestamp to exercise the collision
+	// handling of the log.
+	const numEvents = 20
+	events := make([]HtlcEvent, numEvents)
+	for i := 0; i < numEvents; i++ {
+		event := HtlcEvent{
+			Timestamp:      startTime,
+			IncomingHtlcID: uint64(i),
+			OutgoingHtlcID: uint64(i + 100),
+			AmtIn:          lnwire.MilliSatoshi(1000 + i),
+			AmtOut:         lnwire.MilliSatoshi(900 + i),
+			HoldTime:       time.Duration(i) * time.Second,
+		}
+
+		if i%2 == 0 {
+			event.IncomingChanID = chanA
+			event.OutgoingChanID = chanB
+			event.Outcome = HtlcOutcomeSettled
+		} else {
+			event.IncomingChanID = chanB
+			event.OutgoingChanID = chanC
+			event.Outcome = HtlcOutcomeLinkFailed
+			event.FailedIncoming = true
+			event.FailureCode = lnwire.CodeTemporaryChannelFailure
+			event.FailureDetail = 1
+		}
+
+		events[i] = event
+	}
+
+	require.NoError(t, log.AddHtlcEvents(events))
+
+	// Our events should be returned in order, with each timestamp shifted
+	// by a nanosecond.
+	timeSlice, err = log.Query(HtlcEventQuery{
+		StartTime:    startTime,
+		EndTime:      startTime.Add(time.Hour),
+		NumMaxEvents: 100,
+	})
+	require.NoError(t, err)
+	require.Len(t, timeSlice.HtlcEvents, numEvents)
+	require.EqualValues(t, numEvents, timeSlice.LastIndexOffset)
+
+	for i, event := range timeSlice.HtlcEvents {
+		expected := events[i]
+		expected.Timestamp = startTime.Add(time.Duration(i))
+		require.Equal(t, expected, event)
+	}
+
+	// Filtering on the incoming channel A should only return the settled
+	// events.
+	timeSlice, err = log.Query(HtlcEventQuery{
+		StartTime:       startTime,
+		EndTime:         startTime.Add(time.Hour),
+		IncomingChanIDs: []lnwire.ShortChannelID{chanA},
+		NumMaxEvents:    100,
+	})
+	require.NoError(t, err)
+	require.Len(t, timeSlice.HtlcEvents, numEvents/2)
+	for _, event := range timeSlice.HtlcEvents {
+		require.Equal(t, HtlcOutcomeSettled, event.Outcome)
+	}
+
+	// Page through the failed events towards channel C three at a time,
+	// the offset should only count the events that match the filter.
+	var (
+		failed []HtlcEvent
+		offset uint32
+	)
+	for {
+		timeSlice, err = log.Query(HtlcEventQuery{
+			StartTime:       startTime,
+			EndTime:         startTime.Add(time.Hour),
+			OutgoingChanIDs: []lnwire.ShortChannelID{chanC},
+			IndexOffset:     offset,
+			NumMaxEvents:    3,
+		})
+		require.NoError(t, err)
+
+		if len(timeSlice.HtlcEvents) == 0 {
+			break
+		}
+
+		failed = append(failed, timeSlice.HtlcEvents...)
+		offset = timeSlice.LastIndexOffset
+	}
+
+	require.Len(t, failed, numEvents/2)
+	for i, event := range failed {
+		require.Equal(t, uint64(2*i+1), event.IncomingHtlcID)
+		require.Equal(t, HtlcOutcomeLinkFailed, event.Outcome)
+		require.Equal(
+			t, lnwire.CodeTemporaryChannelFailure,
+			event.FailureCode,
+		)
+	}
+}
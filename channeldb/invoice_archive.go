@@ -0,0 +1,395 @@
+package channeldb
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+var (
+	// invoiceArchiveBucket is the name of the top-level bucket that holds
+	// settled invoices which have been moved out of the live invoice
+	// buckets. Archived invoices are no longer part of the invoice indexes
+	// which are scanned on startup, but can still be queried.
+	invoiceArchiveBucket = []byte("invoice-archive")
+
+	// archivedInvoicesBucket is the name of the sub-bucket within the
+	// invoiceArchiveBucket which stores the serialized archived invoices.
+	// Archived invoices keep the add index they had as live invoices, so
+	// that they can be paginated the same way.
+	//
+	// maps: addIndex => invoice
+	archivedInvoicesBucket = []byte("archived-invoices")
+
+	// archivedHashIndexBucket is the name of the sub-bucket within the
+	// invoiceArchiveBucket which indexes the archived invoices by their
+	// payment hash.
+	//
+	// maps: payHash => addIndex
+	archivedHashIndexBucket = []byte("archived-hash-index")
+
+	// archivedInvoiceNumsBucket is the name of the sub-bucket within the
+	// invoiceArchiveBucket which maps the key an archived invoice had in
+	// the live invoice bucket to its add index. Archived invoices keep
+	// their entry in the settle index, which points to that key, so that
+	// settle index based catch-up still returns them. They also keep their
+	// entry in the payment address index, so that their payment address
+	// can't be reused by a new invoice.
+	//
+	// maps: invoiceNum => addIndex
+	archivedInvoiceNumsBucket = []byte("archived-invoice-nums")
+
+	// archiveSettleCursorKey is the key within the invoiceArchiveBucket
+	// which stores the settle index the next archiving run starts at. All
+	// settle index entries below it have either been archived or can never
+	// be archived, so they don't need to be scanned again.
+	archiveSettleCursorKey = []byte("archive-settle-cursor")
+)
+
+// ArchiveSettledInvoices moves at most maxInvoices settled invoices that were
+// settled before the passed time from the live invoice buckets into the
+// invoice archive. Archived invoices are removed from all live invoice
+// indexes except for the settle index, so that they are still returned by
+// InvoicesSettledSince, and the payment address index, so that their payment
+// address can't be reused. Invoices are archived in the order in which they
+// were settled, and the number of archived invoices is returned, so callers
+// can archive in batches until fewer than maxInvoices invoices are returned.
+//
+// Invoices are only archived once all of their htlcs are settled or canceled
+// and their payment hash isn't part of the passed set of payment hashes of
+// htlcs that are still unresolved on one of our channels, as such htlcs may
+// still need to be resolved against the invoice. These invoices are looked at
+// again by the next archiving run.
+//
+// NOTE: AMP invoices are never archived. They are indexed by a payment hash
+// that isn't stored with the invoice and can't be derived from it, as each
+// AMP payment uses its own preimage. As reusable invoices are always AMP
+// invoices, they are never archived either.
+func (d *DB) ArchiveSettledInvoices(settledBefore time.Time,
+	maxInvoices uint64,
+	unresolved map[lntypes.Hash]struct{}) (uint64, error) {
+
+	var numArchived uint64
+	err := kvdb.Update(d, func(tx kvdb.RwTx) error {
+		invoices := tx.ReadWriteBucket(invoiceBucket)
+		if invoices == nil {
+			return nil
+		}
+
+		invoiceIndex := invoices.NestedReadWriteBucket(
+			invoiceIndexBucket,
+		)
+		invoiceAddIndex := invoices.NestedReadWriteBucket(
+			addIndexBucket,
+		)
+		settleIndex := invoices.NestedReadWriteBucket(settleIndexBucket)
+		if invoiceIndex == nil || invoiceAddIndex == nil ||
+			settleIndex == nil {
+
+			return nil
+		}
+
+		setIDIndex := tx.ReadWriteBucket(setIDIndexBucket)
+
+		archive, err := tx.CreateTopLevelBucket(invoiceArchiveBucket)
+		if err != nil {
+			return err
+		}
+		archivedInvoices, err := archive.CreateBucketIfNotExists(
+			archivedInvoicesBucket,
+		)
+		if err != nil {
+			return err
+		}
+		archivedHashIndex, err := archive.CreateBucketIfNotExists(
+			archivedHashIndexBucket,
+		)
+		if err != nil {
+			return err
+		}
+		archivedInvoiceNums, err := archive.CreateBucketIfNotExists(
+			archivedInvoiceNumsBucket,
+		)
+		if err != nil {
+			return err
+		}
+
+		// First collect the invoices to archive, as we can't modify
+		// the invoice buckets while iterating over the settle index.
+		// Settle indexes are assigned in settlement order, so we can
+		// stop at the first invoice that was settled too recently.
+		type archivable struct {
+			invoiceNum []byte
+			invoice    Invoice
+			hash       lntypes.Hash
+		}
+		var (
+			toArchive []archivable
+
+			// resumeKey is the first settle index entry that needs
+			// to be looked at again by the next archiving run.
+			resumeKey []byte
+			lastKey   []byte
+		)
+
+		cursor := settleIndex.ReadWriteCursor()
+		k, v := cursor.First()
+		if start := archive.Get(archiveSettleCursorKey); start != nil {
+			k, v = cursor.Seek(start)
+		}
+		for ; k != nil && uint64(len(toArchive)) < maxInvoices; k, v =
+			cursor.Next() {
+
+			lastKey = copySlice(k)
+
+			// Archived and deleted invoices are no longer part of
+			// the invoice bucket.
+			invoiceNum := copySlice(v)
+			invoice, err := fetchInvoice(invoiceNum, invoices)
+			switch {
+			case err == ErrInvoiceNotFound:
+				continue
+
+			case err != nil:
+				return err
+			}
+
+			// Skip AMP invoices, invoices that aren't settled and
+			// invoices whose payment hash we can't reconstruct, as
+			// they can never be archived.
+			isAMP := invoice.Terms.Features.HasFeature(
+				lnwire.AMPOptional,
+			)
+			if isAMP || invoice.State != ContractSettled ||
+				invoice.Terms.PaymentPreimage == nil ||
+				invoice.SettleIndex != byteOrder.Uint64(k) {
+
+				continue
+			}
+
+			if !invoice.SettleDate.Before(settledBefore) {
+				if resumeKey == nil {
+					resumeKey = lastKey
+				}
+				break
+			}
+
+			// Invoices with unresolved htlcs need to stay live
+			// until their htlcs are resolved.
+			hash := invoice.Terms.PaymentPreimage.Hash()
+			_, isUnresolved := unresolved[hash]
+			if isUnresolved || hasAcceptedHtlcs(&invoice) {
+				if resumeKey == nil {
+					resumeKey = lastKey
+				}
+				continue
+			}
+
+			toArchive = append(toArchive, archivable{
+				invoiceNum: invoiceNum,
+				invoice:    invoice,
+				hash:       hash,
+			})
+		}
+
+		// If every entry we looked at was dealt with for good, the
+		// next run can start right after the last one.
+		if resumeKey == nil && lastKey != nil {
+			var next [8]byte
+			byteOrder.PutUint64(next[:], byteOrder.Uint64(lastKey)+1)
+			resumeKey = next[:]
+		}
+		if resumeKey != nil {
+			err := archive.Put(archiveSettleCursorKey, resumeKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, a := range toArchive {
+			// Make sure the hash index actually points to this
+			// invoice before we remove it.
+			if !bytes.Equal(invoiceIndex.Get(a.hash[:]), a.invoiceNum) {
+				return fmt.Errorf("invoice %v not found in "+
+					"payment hash index", a.hash)
+			}
+
+			var addIndexKey [8]byte
+			byteOrder.PutUint64(addIndexKey[:], a.invoice.AddIndex)
+
+			var buf bytes.Buffer
+			if err := serializeInvoice(&buf, &a.invoice); err != nil {
+				return err
+			}
+			err := archivedInvoices.Put(addIndexKey[:], buf.Bytes())
+			if err != nil {
+				return err
+			}
+			err = archivedHashIndex.Put(a.hash[:], addIndexKey[:])
+			if err != nil {
+				return err
+			}
+			err = archivedInvoiceNums.Put(
+				a.invoiceNum, addIndexKey[:],
+			)
+			if err != nil {
+				return err
+			}
+
+			err = removeLiveInvoice(
+				invoices, invoiceIndex, invoiceAddIndex,
+				setIDIndex, a.invoiceNum, &a.invoice, a.hash,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		numArchived = uint64(len(toArchive))
+		return nil
+	}, func() {
+		numArchived = 0
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return numArchived, nil
+}
+
+// hasAcceptedHtlcs returns true if any of the invoice's htlcs is neither
+// settled nor canceled.
+func hasAcceptedHtlcs(invoice *Invoice) bool {
+	for _, htlc := range invoice.Htlcs {
+		if htlc.State == HtlcStateAccepted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeLiveInvoice removes an invoice and its index entries from the live
+// invoice buckets. The settle index and payment address index entries are
+// kept, see archivedInvoiceNums.
+func removeLiveInvoice(invoices, invoiceIndex, addIndex,
+	setIDIndex kvdb.RwBucket, invoiceNum []byte,
+	invoice *Invoice, hash lntypes.Hash) error {
+
+	if err := invoiceIndex.Delete(hash[:]); err != nil {
+		return err
+	}
+
+	if err := deleteInvoiceTimeIndexes(invoices, invoice); err != nil {
+		return err
+	}
+
+	for _, htlc := range invoice.Htlcs {
+		if htlc.AMP == nil || setIDIndex == nil {
+			continue
+		}
+
+		setID := htlc.AMP.Record.SetID()
+		if !bytes.Equal(setIDIndex.Get(setID[:]), invoiceNum) {
+			continue
+		}
+		if err := setIDIndex.Delete(setID[:]); err != nil {
+			return err
+		}
+	}
+
+	var addIndexKey [8]byte
+	byteOrder.PutUint64(addIndexKey[:], invoice.AddIndex)
+	if err := addIndex.Delete(addIndexKey[:]); err != nil {
+		return err
+	}
+
+	return invoices.Delete(invoiceNum)
+}
+
+// LookupArchivedInvoice looks up an archived invoice by its payment hash. If
+// the invoice isn't part of the archive, ErrInvoiceNotFound is returned.
+func (d *DB) LookupArchivedInvoice(hash lntypes.Hash) (Invoice, error) {
+	var invoice Invoice
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		archive := tx.ReadBucket(invoiceArchiveBucket)
+		if archive == nil {
+			return ErrInvoiceNotFound
+		}
+		archivedInvoices := archive.NestedReadBucket(
+			archivedInvoicesBucket,
+		)
+		archivedHashIndex := archive.NestedReadBucket(
+			archivedHashIndexBucket,
+		)
+		if archivedInvoices == nil || archivedHashIndex == nil {
+			return ErrInvoiceNotFound
+		}
+
+		addIndexKey := archivedHashIndex.Get(hash[:])
+		if addIndexKey == nil {
+			return ErrInvoiceNotFound
+		}
+
+		i, err := fetchInvoice(addIndexKey, archivedInvoices)
+		if err != nil {
+			return err
+		}
+		invoice = i
+
+		return nil
+	}, func() {
+		invoice = Invoice{}
+	})
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	return invoice, nil
+}
+
+// fetchArchivedInvoiceByNum looks up an archived invoice by the key it had in
+// the live invoice bucket. If the invoice isn't part of the archive,
+// ErrInvoiceNotFound is returned.
+func fetchArchivedInvoiceByNum(tx kvdb.RTx, invoiceNum []byte) (Invoice,
+	error) {
+
+	archive := tx.ReadBucket(invoiceArchiveBucket)
+	if archive == nil {
+		return Invoice{}, ErrInvoiceNotFound
+	}
+	archivedInvoices := archive.NestedReadBucket(archivedInvoicesBucket)
+	archivedInvoiceNums := archive.NestedReadBucket(
+		archivedInvoiceNumsBucket,
+	)
+	if archivedInvoices == nil || archivedInvoiceNums == nil {
+		return Invoice{}, ErrInvoiceNotFound
+	}
+
+	addIndexKey := archivedInvoiceNums.Get(invoiceNum)
+	if addIndexKey == nil {
+		return Invoice{}, ErrInvoiceNotFound
+	}
+
+	return fetchInvoice(addIndexKey, archivedInvoices)
+}
+
+// isArchivedInvoice returns true if an invoice with the given payment hash is
+// part of the invoice archive.
+func isArchivedInvoice(tx kvdb.RTx, hash lntypes.Hash) bool {
+	archive := tx.ReadBucket(invoiceArchiveBucket)
+	if archive == nil {
+		return false
+	}
+
+	archivedHashIndex := archive.NestedReadBucket(archivedHashIndexBucket)
+	if archivedHashIndex == nil {
+		return false
+	}
+
+	return archivedHashIndex.Get(hash[:]) != nil
+}
@@ -0,0 +1,227 @@
+package channeldb
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestArchiveSettledInvoices tests that old settled invoices are moved to the
+// invoice archive, are removed from the live invoice indexes and can still be
+// queried afterwards.
+func TestArchiveSettledInvoices(t *testing.T) {
+	t.Parallel()
+
+	archiveClock := clock.NewTestClock(time.Unix(1000, 0))
+	db, cleanUp, err := MakeTestDB(OptionClock(archiveClock))
+	defer cleanUp()
+	require.NoError(t, err, "unable to make test db")
+
+	// Nothing should be archived from an empty database.
+	numArchived, err := db.ArchiveSettledInvoices(
+		archiveClock.Now(), math.MaxUint64, nil,
+	)
+	require.NoError(t, err)
+	require.Zero(t, numArchived)
+
+	// Add five invoices and settle the first four of them, each an hour
+	// after the other. The last invoice remains open.
+	const numInvoices = 5
+	hashes := make([]lntypes.Hash, numInvoices)
+	for i := 0; i < numInvoices; i++ {
+		invoice, err := randInvoice(lnwire.MilliSatoshi(i + 1))
+		require.NoError(t, err)
+
+		hashes[i] = invoice.Terms.PaymentPreimage.Hash()
+		_, err = db.AddInvoice(invoice, hashes[i])
+		require.NoError(t, err)
+
+		if i == numInvoices-1 {
+			continue
+		}
+
+		archiveClock.SetTime(archiveClock.Now().Add(time.Hour))
+		_, err = db.UpdateInvoice(
+			InvoiceRefByHash(hashes[i]),
+			getUpdateInvoice(invoice.Terms.Value),
+		)
+		require.NoError(t, err)
+	}
+
+	queryAll := func(archived bool) []Invoice {
+		resp, err := db.QueryInvoices(InvoiceQuery{
+			NumMaxInvoices: math.MaxUint64,
+			Archived:       archived,
+		})
+		require.NoError(t, err)
+
+		return resp.Invoices
+	}
+
+	require.Empty(t, queryAll(true))
+
+	// Archive the two invoices that were settled first, with a batch size
+	// of one.
+	settledBefore := archiveClock.Now().Add(-90 * time.Minute)
+	numArchived, err = db.ArchiveSettledInvoices(settledBefore, 1, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, numArchived)
+
+	numArchived, err = db.ArchiveSettledInvoices(settledBefore, 1, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, numArchived)
+
+	numArchived, err = db.ArchiveSettledInvoices(settledBefore, 1, nil)
+	require.NoError(t, err)
+	require.Zero(t, numArchived)
+
+	// The first two invoices should now be part of the archive, keeping
+	// their add index, while the others are still live.
+	archived := queryAll(true)
+	require.Len(t, archived, 2)
+	require.EqualValues(t, 1, archived[0].AddIndex)
+	require.EqualValues(t, 2, archived[1].AddIndex)
+	require.Equal(t, ContractSettled, archived[0].State)
+
+	live := queryAll(false)
+	require.Len(t, live, 3)
+	require.EqualValues(t, 3, live[0].AddIndex)
+
+	// Archived invoices shouldn't be found among the live invoices
+	// anymore, but should be found in the archive.
+	for i, hash := range hashes {
+		_, err := db.LookupInvoice(InvoiceRefByHash(hash))
+		_, archiveErr := db.LookupArchivedInvoice(hash)
+
+		if i < 2 {
+			require.Equal(t, ErrInvoiceNotFound, err)
+			require.NoError(t, archiveErr)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, ErrInvoiceNotFound, archiveErr)
+		}
+	}
+
+	// Archived invoices keep their settle index entry, so catching up on
+	// settled invoices still returns all of them.
+	settled, err := db.InvoicesSettledSince(1)
+	require.NoError(t, err)
+	require.Len(t, settled, 3)
+	require.EqualValues(t, 2, settled[0].SettleIndex)
+	require.EqualValues(t, 4, settled[2].SettleIndex)
+
+	// Scanning the invoices should only yield the live invoices.
+	var scanned int
+	err = db.ScanInvoices(
+		func(lntypes.Hash, *Invoice) error {
+			scanned++
+			return nil
+		}, func() {
+			scanned = 0
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 3, scanned)
+
+	// Archived payment hashes can't be reused for new invoices.
+	invoice, err := randInvoice(1)
+	require.NoError(t, err)
+	_, err = db.AddInvoice(invoice, hashes[0])
+	require.Equal(t, ErrDuplicateInvoice, err)
+
+	// Neither can their payment addresses, while looking up an invoice by
+	// the payment address of an archived invoice doesn't find it among
+	// the live invoices.
+	invoice.Terms.PaymentAddr = archived[0].Terms.PaymentAddr
+	_, err = db.AddInvoice(invoice, invoice.Terms.PaymentPreimage.Hash())
+	require.Equal(t, ErrDuplicatePayAddr, err)
+
+	_, err = db.LookupInvoice(
+		InvoiceRefByAddr(archived[0].Terms.PaymentAddr),
+	)
+	require.Equal(t, ErrInvoiceNotFound, err)
+
+	// Once enough time has passed, all settled invoices are archived
+	// while the open invoice is kept.
+	numArchived, err = db.ArchiveSettledInvoices(
+		archiveClock.Now().Add(time.Second), math.MaxUint64, nil,
+	)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, numArchived)
+
+	require.Len(t, queryAll(true), 4)
+	require.Len(t, queryAll(false), 1)
+
+	// Paginating backwards through the archive should work the same way
+	// as it does for live invoices.
+	resp, err := db.QueryInvoices(InvoiceQuery{
+		NumMaxInvoices: 2,
+		Reversed:       true,
+		Archived:       true,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Invoices, 2)
+	require.EqualValues(t, 3, resp.FirstIndexOffset)
+	require.EqualValues(t, 4, resp.LastIndexOffset)
+}
+
+// TestArchiveUnresolvedInvoices tests that settled invoices that are still
+// paid by unresolved htlcs are kept live until the htlcs are resolved.
+func TestArchiveUnresolvedInvoices(t *testing.T) {
+	t.Parallel()
+
+	archiveClock := clock.NewTestClock(time.Unix(1000, 0))
+	db, cleanUp, err := MakeTestDB(OptionClock(archiveClock))
+	defer cleanUp()
+	require.NoError(t, err, "unable to make test db")
+
+	// Add and settle two invoices.
+	hashes := make([]lntypes.Hash, 2)
+	for i := range hashes {
+		invoice, err := randInvoice(lnwire.MilliSatoshi(i + 1))
+		require.NoError(t, err)
+
+		hashes[i] = invoice.Terms.PaymentPreimage.Hash()
+		_, err = db.AddInvoice(invoice, hashes[i])
+		require.NoError(t, err)
+
+		_, err = db.UpdateInvoice(
+			InvoiceRefByHash(hashes[i]),
+			getUpdateInvoice(invoice.Terms.Value),
+		)
+		require.NoError(t, err)
+	}
+
+	// While the htlc that paid the first invoice is unresolved, only the
+	// second invoice is archived.
+	settledBefore := archiveClock.Now().Add(time.Second)
+	unresolved := map[lntypes.Hash]struct{}{
+		hashes[0]: {},
+	}
+	numArchived, err := db.ArchiveSettledInvoices(
+		settledBefore, math.MaxUint64, unresolved,
+	)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, numArchived)
+
+	_, err = db.LookupInvoice(InvoiceRefByHash(hashes[0]))
+	require.NoError(t, err)
+	_, err = db.LookupArchivedInvoice(hashes[1])
+	require.NoError(t, err)
+
+	// Once the htlc is resolved, the next run archives the first invoice
+	// as well.
+	numArchived, err = db.ArchiveSettledInvoices(
+		settledBefore, math.MaxUint64, nil,
+	)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, numArchived)
+
+	_, err = db.LookupArchivedInvoice(hashes[0])
+	require.NoError(t, err)
+}
@@ -160,6 +160,11 @@ func testInvoiceWorkflow(t *testing.T, test invWorkflowTest) {
 	}
 	invPayHash := fakeInvoice.Terms.PaymentPreimage.Hash()
 
+	// Also set the payment tolerances of the invoice, to check that they
+	// are stored along with it.
+	fakeInvoice.Terms.MaxPaymentAmt = 15000
+	fakeInvoice.Terms.AcceptPartial = true
+
 	// Select the payment hash and payment address we will use to lookup or
 	// update the invoice for the remainder of the test.
 	var (
@@ -504,6 +509,34 @@ func TestInvoiceCancelSingleHtlc(t *testing.T) {
 	if invoice.Htlcs[key].State != HtlcStateCanceled {
 		t.Fatalf("expected htlc in state canceled")
 	}
+
+	// Accept another htlc and settle the invoice with it.
+	key2 := CircuitKey{ChanID: lnwire.NewShortChanIDFromInt(1), HtlcID: 5}
+	_, err = db.UpdateInvoice(ref,
+		func(invoice *Invoice) (*InvoiceUpdateDesc, error) {
+			return &InvoiceUpdateDesc{
+				AddHtlcs: map[CircuitKey]*HtlcAcceptDesc{
+					key2: &htlc,
+				},
+				State: &InvoiceStateUpdateDesc{
+					NewState: ContractSettled,
+					Preimage: &preimage,
+				},
+			}, nil
+		})
+	require.NoError(t, err, "unable to settle invoice")
+
+	// Canceling a single htlc of a settled invoice that isn't reusable
+	// must fail.
+	_, err = db.UpdateInvoice(ref,
+		func(invoice *Invoice) (*InvoiceUpdateDesc, error) {
+			return &InvoiceUpdateDesc{
+				CancelHtlcs: map[CircuitKey]struct{}{
+					key2: {},
+				},
+			}, nil
+		})
+	require.Error(t, err)
 }
 
 // TestInvoiceTimeSeries tests that newly added invoices invoices, as well as
@@ -1104,8 +1137,8 @@ func getUpdateInvoice(amt lnwire.MilliSatoshi) InvoiceUpdateCallback {
 	}
 }
 
-// TestCustomRecords tests that custom records are properly recorded in the
-// invoice database.
+// TestCustomRecords tests that custom records and payment metadata are
+// properly recorded in the invoice database.
 func TestCustomRecords(t *testing.T) {
 	t.Parallel()
 
@@ -1131,13 +1164,14 @@ func TestCustomRecords(t *testing.T) {
 		t.Fatalf("unable to add invoice: %v", err)
 	}
 
-	// Accept an htlc with custom records on this invoice.
+	// Accept an htlc with custom records and metadata on this invoice.
 	key := CircuitKey{ChanID: lnwire.NewShortChanIDFromInt(1), HtlcID: 4}
 
 	records := record.CustomSet{
 		100000: []byte{},
 		100001: []byte{1, 2},
 	}
+	metadata := []byte{0x01, 0xfa, 0xfa, 0xf0}
 
 	ref := InvoiceRefByHash(paymentHash)
 	_, err = db.UpdateInvoice(ref,
@@ -1147,6 +1181,7 @@ func TestCustomRecords(t *testing.T) {
 					key: {
 						Amt:           500,
 						CustomRecords: records,
+						Metadata:      metadata,
 					},
 				},
 			}, nil
@@ -1157,7 +1192,7 @@ func TestCustomRecords(t *testing.T) {
 	}
 
 	// Retrieve the invoice from that database and verify that the custom
-	// records are present.
+	// records and metadata are present.
 	dbInvoice, err := db.LookupInvoice(ref)
 	if err != nil {
 		t.Fatalf("unable to lookup invoice: %v", err)
@@ -1171,6 +1206,7 @@ func TestCustomRecords(t *testing.T) {
 		records, dbInvoice.Htlcs[key].CustomRecords,
 		"invalid custom records",
 	)
+	require.Equal(t, metadata, dbInvoice.Htlcs[key].Metadata)
 }
 
 // TestInvoiceHtlcAMPFields asserts that the set id and preimage fields are
@@ -1577,6 +1613,124 @@ func getUpdateInvoiceAMPSettle(setID *[32]byte) InvoiceUpdateCallback {
 	}
 }
 
+// TestReusableInvoiceSets asserts that the htlc sets of a reusable invoice are
+// settled one at a time, leaving the other sets that are still in flight
+// accepted.
+func TestReusableInvoiceSets(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup, err := MakeTestDB()
+	defer cleanup()
+	require.NoError(t, err, "unable to make test db")
+
+	amt := lnwire.NewMSatFromSatoshis(1000)
+	invoice, err := randInvoice(amt)
+	require.Nil(t, err)
+
+	preimage := *invoice.Terms.PaymentPreimage
+	payHash := preimage.Hash()
+	invoice.Terms.MaxAmtPaid = 3 * amt
+
+	// Reusable invoices must be AMP invoices.
+	_, err = db.AddInvoice(invoice, payHash)
+	require.Error(t, err)
+
+	invoice.Terms.Features = ampFeatures
+	_, err = db.AddInvoice(invoice, payHash)
+	require.Nil(t, err)
+
+	ref := InvoiceRefByAddr(invoice.Terms.PaymentAddr)
+	acceptHtlc := func(id uint64, setID *[32]byte) *Invoice {
+		dbInvoice, err := db.UpdateInvoice(ref,
+			func(*Invoice) (*InvoiceUpdateDesc, error) {
+				ampData := &InvoiceHtlcAMPData{
+					Record: *record.NewAMP(
+						[32]byte{}, *setID, 0,
+					),
+					Hash:     payHash,
+					Preimage: &preimage,
+				}
+
+				return &InvoiceUpdateDesc{
+					AddHtlcs: map[CircuitKey]*HtlcAcceptDesc{
+						{HtlcID: id}: {
+							Amt: amt,
+							CustomRecords: make(
+								record.CustomSet,
+							),
+							AMP: ampData,
+						},
+					},
+				}, nil
+			})
+		require.Nil(t, err)
+
+		return dbInvoice
+	}
+	settleSet := func(setID *[32]byte) (*Invoice, error) {
+		return db.UpdateInvoice(ref,
+			func(*Invoice) (*InvoiceUpdateDesc, error) {
+				return &InvoiceUpdateDesc{
+					State: &InvoiceStateUpdateDesc{
+						NewState: ContractSettled,
+						SetID:    setID,
+					},
+				}, nil
+			})
+	}
+
+	// Accept the htlcs of two sets that are in flight concurrently.
+	setID1, setID2, setID3 := &[32]byte{1}, &[32]byte{2}, &[32]byte{3}
+	acceptHtlc(0, setID1)
+	acceptHtlc(1, setID2)
+
+	// Settling the first set must leave the htlc of the second set
+	// accepted, and only count the settled htlc as paid.
+	dbInvoice, err := settleSet(setID1)
+	require.Nil(t, err)
+	require.Equal(t, ContractSettled, dbInvoice.State)
+	require.Equal(t, amt, dbInvoice.AmtPaid)
+	require.Equal(t, 3*amt, dbInvoice.Terms.MaxAmtPaid)
+	require.Equal(t, HtlcStateSettled, dbInvoice.Htlcs[CircuitKey{}].State)
+	require.Equal(
+		t, HtlcStateAccepted,
+		dbInvoice.Htlcs[CircuitKey{HtlcID: 1}].State,
+	)
+
+	// Single htlcs can still be canceled on the settled reusable invoice.
+	acceptHtlc(2, setID3)
+	dbInvoice, err = db.UpdateInvoice(ref,
+		func(*Invoice) (*InvoiceUpdateDesc, error) {
+			return &InvoiceUpdateDesc{
+				CancelHtlcs: map[CircuitKey]struct{}{
+					{HtlcID: 2}: {},
+				},
+			}, nil
+		})
+	require.Nil(t, err)
+	require.Equal(
+		t, HtlcStateCanceled,
+		dbInvoice.Htlcs[CircuitKey{HtlcID: 2}].State,
+	)
+
+	// Now settle the second set.
+	dbInvoice, err = settleSet(setID2)
+	require.Nil(t, err)
+	require.Equal(t, 2*amt, dbInvoice.AmtPaid)
+	require.Equal(
+		t, HtlcStateSettled,
+		dbInvoice.Htlcs[CircuitKey{HtlcID: 1}].State,
+	)
+
+	// Neither a set without accepted htlcs, nor the invoice without a set
+	// id can be settled again.
+	_, err = settleSet(setID2)
+	require.Equal(t, ErrEmptyHTLCSet, err)
+
+	_, err = settleSet(nil)
+	require.Equal(t, ErrInvoiceAlreadySettled, err)
+}
+
 // TestUnexpectedInvoicePreimage asserts that legacy or MPP invoices cannot be
 // settled when referenced by payment address only. Since regular or MPP
 // payments do not store the payment hash explicitly (it is stored in the
@@ -2474,3 +2628,215 @@ func TestAddInvoiceInvalidFeatureDeps(t *testing.T) {
 		lnwire.PaymentAddrOptional,
 	))
 }
+
+// TestQueryInvoicesFilters tests that invoices can be queried by creation and
+// settle date, state, value and memo, and that date range queries can be
+// paginated like regular queries.
+func TestQueryInvoicesFilters(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1000, 0)
+	testClock := clock.NewTestClock(start)
+	db, cleanUp, err := MakeTestDB(OptionClock(testClock))
+	defer cleanUp()
+	require.NoError(t, err, "unable to make test db")
+
+	// Add five invoices that were created an hour apart from each other,
+	// with increasing values. Every other invoice is settled an hour
+	// after the last invoice was created.
+	const numInvoices = 5
+	hashes := make([]lntypes.Hash, numInvoices)
+	for i := 0; i < numInvoices; i++ {
+		invoice, err := randInvoice(lnwire.MilliSatoshi(i+1) * 1000)
+		require.NoError(t, err)
+
+		invoice.CreationDate = start.Add(time.Duration(i) * time.Hour)
+		invoice.Memo = []byte(fmt.Sprintf("order %d", i))
+		if i == 3 {
+			invoice.Memo = []byte("coffee")
+		}
+
+		hashes[i] = invoice.Terms.PaymentPreimage.Hash()
+		_, err = db.AddInvoice(invoice, hashes[i])
+		require.NoError(t, err)
+	}
+
+	settleTime := start.Add(numInvoices * time.Hour)
+	testClock.SetTime(settleTime)
+	for i := 0; i < numInvoices; i += 2 {
+		_, err := db.UpdateInvoice(
+			InvoiceRefByHash(hashes[i]), getUpdateInvoice(1000),
+		)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name  string
+		query InvoiceQuery
+
+		// expectedAddIndexes contains the add indexes of the invoices
+		// we expect our query to return.
+		expectedAddIndexes []uint64
+	}{
+		{
+			name: "creation date range",
+			query: InvoiceQuery{
+				CreationDateStart: start.Add(time.Hour),
+				CreationDateEnd:   start.Add(3 * time.Hour),
+			},
+			expectedAddIndexes: []uint64{2, 3, 4},
+		},
+		{
+			name: "creation date start only",
+			query: InvoiceQuery{
+				CreationDateStart: start.Add(3 * time.Hour),
+			},
+			expectedAddIndexes: []uint64{4, 5},
+		},
+		{
+			name: "creation date range with offset",
+			query: InvoiceQuery{
+				IndexOffset:       2,
+				CreationDateStart: start.Add(time.Hour),
+				CreationDateEnd:   start.Add(3 * time.Hour),
+			},
+			expectedAddIndexes: []uint64{3, 4},
+		},
+		{
+			name: "creation date range reversed",
+			query: InvoiceQuery{
+				NumMaxInvoices:    2,
+				Reversed:          true,
+				CreationDateStart: start.Add(time.Hour),
+				CreationDateEnd:   start.Add(3 * time.Hour),
+			},
+			expectedAddIndexes: []uint64{3, 4},
+		},
+		{
+			name: "creation date range reversed with offset",
+			query: InvoiceQuery{
+				IndexOffset:       4,
+				Reversed:          true,
+				CreationDateStart: start.Add(time.Hour),
+				CreationDateEnd:   start.Add(3 * time.Hour),
+			},
+			expectedAddIndexes: []uint64{2, 3},
+		},
+		{
+			name: "creation date range without invoices",
+			query: InvoiceQuery{
+				CreationDateStart: start.Add(10 * time.Hour),
+			},
+		},
+		{
+			name: "settle date range",
+			query: InvoiceQuery{
+				SettleDateStart: settleTime,
+				SettleDateEnd:   settleTime,
+			},
+			expectedAddIndexes: []uint64{1, 3, 5},
+		},
+		{
+			name: "settle and creation date range",
+			query: InvoiceQuery{
+				CreationDateEnd: start.Add(2 * time.Hour),
+				SettleDateStart: settleTime,
+			},
+			expectedAddIndexes: []uint64{1, 3},
+		},
+		{
+			name: "settle date range before settlement",
+			query: InvoiceQuery{
+				SettleDateEnd: settleTime.Add(-time.Second),
+			},
+		},
+		{
+			name: "open invoices",
+			query: InvoiceQuery{
+				States: []ContractState{ContractOpen},
+			},
+			expectedAddIndexes: []uint64{2, 4},
+		},
+		{
+			name: "settled invoices",
+			query: InvoiceQuery{
+				States: []ContractState{ContractSettled},
+			},
+			expectedAddIndexes: []uint64{1, 3, 5},
+		},
+		{
+			name: "settled invoices reversed with offset",
+			query: InvoiceQuery{
+				IndexOffset: 5,
+				Reversed:    true,
+				States:      []ContractState{ContractSettled},
+			},
+			expectedAddIndexes: []uint64{1, 3},
+		},
+		{
+			name: "value range",
+			query: InvoiceQuery{
+				MinValue: 2000,
+				MaxValue: 4000,
+			},
+			expectedAddIndexes: []uint64{2, 3, 4},
+		},
+		{
+			name: "memo substring",
+			query: InvoiceQuery{
+				MemoSubstring: "order",
+			},
+			expectedAddIndexes: []uint64{1, 2, 3, 5},
+		},
+		{
+			name: "all filters",
+			query: InvoiceQuery{
+				CreationDateStart: start,
+				States: []ContractState{
+					ContractSettled,
+				},
+				MinValue:      2000,
+				MemoSubstring: "order",
+			},
+			expectedAddIndexes: []uint64{3, 5},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if test.query.NumMaxInvoices == 0 {
+				test.query.NumMaxInvoices = math.MaxUint64
+			}
+
+			resp, err := db.QueryInvoices(test.query)
+			require.NoError(t, err)
+
+			var addIndexes []uint64
+			for _, invoice := range resp.Invoices {
+				addIndexes = append(addIndexes, invoice.AddIndex)
+			}
+			require.Equal(t, test.expectedAddIndexes, addIndexes)
+		})
+	}
+
+	// Deleting an invoice also removes it from the time indexes.
+	invoice, err := db.LookupInvoice(InvoiceRefByHash(hashes[2]))
+	require.NoError(t, err)
+	err = db.DeleteInvoice([]InvoiceDeleteRef{{
+		PayHash:     hashes[2],
+		PayAddr:     &invoice.Terms.PaymentAddr,
+		AddIndex:    invoice.AddIndex,
+		SettleIndex: invoice.SettleIndex,
+	}})
+	require.NoError(t, err)
+
+	resp, err := db.QueryInvoices(InvoiceQuery{
+		NumMaxInvoices:  math.MaxUint64,
+		SettleDateStart: settleTime,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Invoices, 2)
+	require.EqualValues(t, 1, resp.Invoices[0].AddIndex)
+	require.EqualValues(t, 5, resp.Invoices[1].AddIndex)
+}
@@ -90,6 +90,21 @@ var (
 	//   settleIndexNo => invoiceKey
 	settleIndexBucket = []byte("invoice-settle-index")
 
+	// invoiceCreationTimeIndexBucket is the name of the sub-bucket within
+	// the invoiceBucket which indexes all invoices by their creation date.
+	// It allows querying invoices by creation date without scanning all of
+	// them.
+	//
+	// maps: creationTime || addIndexNo => nil
+	invoiceCreationTimeIndexBucket = []byte("invoice-creation-time-index")
+
+	// invoiceSettleTimeIndexBucket is the name of the sub-bucket within
+	// the invoiceBucket which indexes all settled invoices by their settle
+	// date.
+	//
+	// maps: settleTime || addIndexNo => nil
+	invoiceSettleTimeIndexBucket = []byte("invoice-settle-time-index")
+
 	// ErrInvoiceAlreadySettled is returned when the invoice is already
 	// settled.
 	ErrInvoiceAlreadySettled = errors.New("invoice already settled")
@@ -193,6 +208,7 @@ const (
 	htlcAMPType      tlv.Type = 19
 	htlcHashType     tlv.Type = 21
 	htlcPreimageType tlv.Type = 23
+	htlcMetadataType tlv.Type = 25
 
 	// A set of tlv type definitions used to serialize invoice bodiees.
 	//
@@ -215,6 +231,10 @@ const (
 	invStateType    tlv.Type = 12
 	amtPaidType     tlv.Type = 13
 	hodlInvoiceType tlv.Type = 14
+
+	maxPaymentAmtType tlv.Type = 15
+	acceptPartialType tlv.Type = 16
+	maxAmtPaidType    tlv.Type = 17
 )
 
 // InvoiceRef is a composite identifier for invoices. Invoices can be referenced
@@ -393,6 +413,24 @@ type ContractTerm struct {
 
 	// Features is the feature vectors advertised on the payment request.
 	Features *lnwire.FeatureVector
+
+	// MaxPaymentAmt is the maximum amount that a single payment to this
+	// invoice may pay, which caps the overpayment of the invoice. If it is
+	// zero, payments of any amount above the invoice value are accepted.
+	MaxPaymentAmt lnwire.MilliSatoshi
+
+	// AcceptPartial indicates that an incomplete mpp payment is accepted
+	// once the htlc hold duration expires, rather than canceled. This is
+	// meant for donations and streaming payments, where any amount paid is
+	// welcome.
+	AcceptPartial bool
+
+	// MaxAmtPaid, if non-zero, makes the invoice reusable. New AMP htlc
+	// sets are accepted even after the invoice was settled, as long as the
+	// amount of all settled and in-flight sets doesn't exceed this cap.
+	// Only AMP invoices can be reusable, as every payment needs its own
+	// payment hashes and preimages.
+	MaxAmtPaid lnwire.MilliSatoshi
 }
 
 // String returns a human-readable description of the prominent contract terms.
@@ -575,6 +613,12 @@ type InvoiceHTLC struct {
 	//
 	// NOTE: This value will only be set for AMP HTLCs.
 	AMP *InvoiceHtlcAMPData
+
+	// Metadata is the payment metadata from the invoice that the sender
+	// included in the final hop's payload.
+	//
+	// NOTE: This value will only be set if the sender included it.
+	Metadata []byte
 }
 
 // Copy makes a deep copy of the target InvoiceHTLC.
@@ -589,6 +633,10 @@ func (h *InvoiceHTLC) Copy() *InvoiceHTLC {
 
 	result.AMP = h.AMP.Copy()
 
+	if h.Metadata != nil {
+		result.Metadata = copySlice(h.Metadata)
+	}
+
 	return &result
 }
 
@@ -684,6 +732,10 @@ type HtlcAcceptDesc struct {
 	//
 	// NOTE: This value will only be set for AMP HTLCs.
 	AMP *InvoiceHtlcAMPData
+
+	// Metadata is the payment metadata that the sender included in the
+	// final hop's payload.
+	Metadata []byte
 }
 
 // InvoiceUpdateDesc describes the changes that should be applied to the
@@ -757,6 +809,12 @@ func validateInvoice(i *Invoice, paymentHash lntypes.Hash) error {
 		return errors.New("non-hodl invoices must have a preimage")
 	}
 
+	// Reusable invoices must be AMP invoices, so that every payment uses
+	// fresh payment hashes and preimages.
+	if i.Terms.MaxAmtPaid > 0 && !isAMP {
+		return errors.New("reusable invoices must be AMP invoices")
+	}
+
 	if len(i.Htlcs) > 0 {
 		return ErrInvoiceHasHtlcs
 	}
@@ -807,6 +865,12 @@ func (d *DB) AddInvoice(newInvoice *Invoice, paymentHash lntypes.Hash) (
 			return ErrDuplicateInvoice
 		}
 
+		// The payment hash of an archived invoice can't be reused
+		// either, as its preimage has already been revealed.
+		if isArchivedInvoice(tx, paymentHash) {
+			return ErrDuplicateInvoice
+		}
+
 		// Check that we aren't inserting an invoice with a duplicate
 		// payment address. The all-zeros payment address is
 		// special-cased to support legacy keysend invoices which don't
@@ -1091,6 +1155,12 @@ func (d *DB) ScanInvoices(
 // InvoiceQuery represents a query to the invoice database. The query allows a
 // caller to retrieve all invoices starting from a particular add index and
 // limit the number of results returned.
+//
+// The date ranges and a query for settled invoices only are served by the
+// invoice time indexes. Such queries return the invoices in the order of the
+// time index, and continue after the invoice at the index offset in that
+// order. All other filters are checked against each invoice that is read, so
+// on their own they still scan all invoices.
 type InvoiceQuery struct {
 	// IndexOffset is the offset within the add indices to start at. This
 	// can be used to start the response at a particular invoice.
@@ -1107,6 +1177,106 @@ type InvoiceQuery struct {
 	// Reversed, if set, indicates that the invoices returned should start
 	// from the IndexOffset and go backwards.
 	Reversed bool
+
+	// Archived, if set, queries the invoice archive instead of the live
+	// invoices. Archived invoices keep their original add index.
+	Archived bool
+
+	// CreationDateStart, if set, only returns invoices that were created
+	// at or after this time.
+	CreationDateStart time.Time
+
+	// CreationDateEnd, if set, only returns invoices that were created at
+	// or before this time.
+	CreationDateEnd time.Time
+
+	// SettleDateStart, if set, only returns invoices that were settled at
+	// or after this time.
+	SettleDateStart time.Time
+
+	// SettleDateEnd, if set, only returns invoices that were settled at or
+	// before this time.
+	SettleDateEnd time.Time
+
+	// States, if set, only returns invoices in one of the given states.
+	States []ContractState
+
+	// MinValue, if set, only returns invoices with a value of at least
+	// this amount.
+	MinValue lnwire.MilliSatoshi
+
+	// MaxValue, if set, only returns invoices with a value of at most this
+	// amount.
+	MaxValue lnwire.MilliSatoshi
+
+	// MemoSubstring, if set, only returns invoices whose memo contains
+	// this string.
+	MemoSubstring string
+}
+
+// filterSettleDate returns true if the query filters invoices by settle date.
+func (q *InvoiceQuery) filterSettleDate() bool {
+	return !q.SettleDateStart.IsZero() || !q.SettleDateEnd.IsZero()
+}
+
+// onlySettled returns true if the query only returns settled invoices. Those
+// are all part of the settle time index.
+func (q *InvoiceQuery) onlySettled() bool {
+	return len(q.States) == 1 && q.States[0] == ContractSettled
+}
+
+// filterCreationDate returns true if the query filters invoices by creation
+// date.
+func (q *InvoiceQuery) filterCreationDate() bool {
+	return !q.CreationDateStart.IsZero() || !q.CreationDateEnd.IsZero()
+}
+
+// matches returns true if the given invoice matches all filters of the query.
+func (q *InvoiceQuery) matches(invoice *Invoice) bool {
+	// Skip any settled or canceled invoices if the caller is only
+	// interested in pending ones.
+	if q.PendingOnly && !invoice.IsPending() {
+		return false
+	}
+
+	if q.filterCreationDate() && !inTimeRange(
+		invoice.CreationDate, q.CreationDateStart, q.CreationDateEnd,
+	) {
+		return false
+	}
+
+	if q.filterSettleDate() && (invoice.SettleDate.IsZero() ||
+		!inTimeRange(
+			invoice.SettleDate, q.SettleDateStart, q.SettleDateEnd,
+		)) {
+
+		return false
+	}
+
+	if len(q.States) > 0 {
+		var stateMatches bool
+		for _, state := range q.States {
+			if invoice.State == state {
+				stateMatches = true
+				break
+			}
+		}
+
+		if !stateMatches {
+			return false
+		}
+	}
+
+	if q.MinValue != 0 && invoice.Terms.Value < q.MinValue {
+		return false
+	}
+
+	if q.MaxValue != 0 && invoice.Terms.Value > q.MaxValue {
+		return false
+	}
+
+	return q.MemoSubstring == "" ||
+		strings.Contains(string(invoice.Memo), q.MemoSubstring)
 }
 
 // InvoiceSlice is the response to a invoice query. It includes the original
@@ -1140,25 +1310,18 @@ func (d *DB) QueryInvoices(q InvoiceQuery) (InvoiceSlice, error) {
 	var resp InvoiceSlice
 
 	err := kvdb.View(d, func(tx kvdb.RTx) error {
-		// If the bucket wasn't found, then there aren't any invoices
-		// within the database yet, so we can simply exit.
-		invoices := tx.ReadBucket(invoiceBucket)
-		if invoices == nil {
-			return ErrNoInvoicesCreated
-		}
-
-		// Get the add index bucket which we will use to iterate through
-		// our indexed invoices.
-		invoiceAddIndex := invoices.NestedReadBucket(addIndexBucket)
-		if invoiceAddIndex == nil {
-			return ErrNoInvoicesCreated
+		// Fetch the bucket we will use to iterate through our indexed
+		// invoices, together with a function that retrieves an
+		// invoice from a value in that bucket.
+		cursor, fetch, err := invoiceQueryCursor(tx, &q)
+		if err != nil {
+			return err
 		}
 
 		// Create a paginator which reads from our add index bucket with
 		// the parameters provided by the invoice query.
 		paginator := newPaginator(
-			invoiceAddIndex.ReadCursor(), q.Reversed, q.IndexOffset,
-			q.NumMaxInvoices,
+			cursor, q.Reversed, q.IndexOffset, q.NumMaxInvoices,
 		)
 
 		// accumulateInvoices looks up an invoice based on the index we
@@ -1166,14 +1329,14 @@ func (d *DB) QueryInvoices(q InvoiceQuery) (InvoiceSlice, error) {
 		// characteristics for our query and returns the number of items
 		// we have added to our set of invoices.
 		accumulateInvoices := func(_, indexValue []byte) (bool, error) {
-			invoice, err := fetchInvoice(indexValue, invoices)
+			invoice, err := fetch(indexValue)
 			if err != nil {
 				return false, err
 			}
 
-			// Skip any settled or canceled invoices if the caller
-			// is only interested in pending ones.
-			if q.PendingOnly && !invoice.IsPending() {
+			// Skip any invoices that don't match the filters of
+			// the query.
+			if !q.matches(&invoice) {
 				return false, nil
 			}
 
@@ -1221,6 +1384,120 @@ func (d *DB) QueryInvoices(q InvoiceQuery) (InvoiceSlice, error) {
 	return resp, nil
 }
 
+// invoiceQueryCursor returns a cursor over the add index of either the live
+// invoices or the invoice archive, and a function that retrieves the invoice
+// a value of that index refers to. If the query filters live invoices by date,
+// the cursor only covers the invoices within the date range, which are read
+// lazily from the time indexes.
+func invoiceQueryCursor(tx kvdb.RTx, q *InvoiceQuery) (kvdb.RCursor,
+	func([]byte) (Invoice, error), error) {
+
+	// Archived invoices are stored by their add index directly, so the
+	// values of the archive are the serialized invoices.
+	if q.Archived {
+		archive := tx.ReadBucket(invoiceArchiveBucket)
+		if archive == nil {
+			return nil, nil, ErrNoInvoicesCreated
+		}
+		archivedInvoices := archive.NestedReadBucket(
+			archivedInvoicesBucket,
+		)
+		if archivedInvoices == nil {
+			return nil, nil, ErrNoInvoicesCreated
+		}
+
+		fetch := func(v []byte) (Invoice, error) {
+			return deserializeInvoice(bytes.NewReader(v))
+		}
+
+		return archivedInvoices.ReadCursor(), fetch, nil
+	}
+
+	// If the bucket wasn't found, then there aren't any invoices within
+	// the database yet, so we can simply exit.
+	invoices := tx.ReadBucket(invoiceBucket)
+	if invoices == nil {
+		return nil, nil, ErrNoInvoicesCreated
+	}
+
+	// Get the add index bucket which we will use to iterate through our
+	// indexed invoices.
+	invoiceAddIndex := invoices.NestedReadBucket(addIndexBucket)
+	if invoiceAddIndex == nil {
+		return nil, nil, ErrNoInvoicesCreated
+	}
+
+	fetch := func(invoiceNum []byte) (Invoice, error) {
+		return fetchInvoice(invoiceNum, invoices)
+	}
+
+	// Settled invoices are usually a small subset of all invoices, so we
+	// prefer the settle time index if the query filters by both dates or
+	// only wants settled invoices. The other filters are checked when the
+	// invoices are fetched.
+	var (
+		bucketKey  []byte
+		start, end time.Time
+	)
+	switch {
+	case q.filterSettleDate() || q.onlySettled():
+		bucketKey = invoiceSettleTimeIndexBucket
+		start, end = q.SettleDateStart, q.SettleDateEnd
+
+	case q.filterCreationDate():
+		bucketKey = invoiceCreationTimeIndexBucket
+		start, end = q.CreationDateStart, q.CreationDateEnd
+
+	default:
+		return invoiceAddIndex.ReadCursor(), fetch, nil
+	}
+
+	timeIndex := invoices.NestedReadBucket(bucketKey)
+	if timeIndex == nil {
+		return nil, nil, ErrNoInvoicesCreated
+	}
+
+	// To resume from an index offset, we look up the time index key of
+	// the invoice at the offset.
+	timeKey := func(addIndexKey []byte) ([]byte, error) {
+		invoiceNum := invoiceAddIndex.Get(addIndexKey)
+		if invoiceNum == nil {
+			return nil, nil
+		}
+
+		invoice, err := fetchInvoice(invoiceNum, invoices)
+		if err != nil {
+			return nil, err
+		}
+
+		t := invoice.CreationDate
+		if bytes.Equal(bucketKey, invoiceSettleTimeIndexBucket) {
+			t = invoice.SettleDate
+		}
+
+		return timeIndexKey(t, invoice.AddIndex), nil
+	}
+
+	cursor := newTimeIndexCursor(
+		timeIndex, invoiceAddIndex, start, end, timeKey,
+	)
+
+	return cursor, fetch, nil
+}
+
+// putInvoiceTimeIndex adds an entry for the invoice with the given add index
+// to the given time index, creating the index if it doesn't exist yet.
+func putInvoiceTimeIndex(invoices kvdb.RwBucket, bucketKey []byte,
+	t time.Time, addIndex uint64) error {
+
+	timeIndex, err := invoices.CreateBucketIfNotExists(bucketKey)
+	if err != nil {
+		return err
+	}
+
+	return timeIndex.Put(timeIndexKey(t, addIndex), nil)
+}
+
 // UpdateInvoice attempts to update an invoice corresponding to the passed
 // payment hash. If an invoice matching the passed payment hash doesn't exist
 // within the database, then the action will fail with a "not found" error.
@@ -1319,7 +1596,15 @@ func (d *DB) InvoicesSettledSince(sinceSettleIndex uint64) ([]Invoice, error) {
 
 			// For each key found, we'll look up the actual
 			// invoice, then accumulate it into our return value.
+			// Archived invoices keep their settle index entry, so
+			// we fall back to the archive if the invoice isn't
+			// live anymore.
 			invoice, err := fetchInvoice(invoiceKey, invoices)
+			if err == ErrInvoiceNotFound {
+				invoice, err = fetchArchivedInvoiceByNum(
+					tx, invoiceKey,
+				)
+			}
 			if err != nil {
 				return err
 			}
@@ -1393,6 +1678,16 @@ func putInvoice(invoices, invoiceIndex, payAddrIndex, addIndex kvdb.RwBucket,
 
 	i.AddIndex = nextAddSeqNo
 
+	// Index the invoice by its creation date, so that it can be queried
+	// by date range.
+	err = putInvoiceTimeIndex(
+		invoices, invoiceCreationTimeIndexBucket, i.CreationDate,
+		nextAddSeqNo,
+	)
+	if err != nil {
+		return 0, err
+	}
+
 	// Finally, serialize the invoice itself to be written to the disk.
 	var buf bytes.Buffer
 	if err := serializeInvoice(&buf, i); err != nil {
@@ -1448,6 +1743,14 @@ func serializeInvoice(w io.Writer, i *Invoice) error {
 		hodlInvoice = 1
 	}
 
+	maxPaymentAmt := uint64(i.Terms.MaxPaymentAmt)
+	maxAmtPaid := uint64(i.Terms.MaxAmtPaid)
+
+	var acceptPartial uint8
+	if i.Terms.AcceptPartial {
+		acceptPartial = 1
+	}
+
 	tlvStream, err := tlv.NewStream(
 		// Memo and payreq.
 		tlv.MakePrimitiveRecord(memoType, &i.Memo),
@@ -1472,6 +1775,11 @@ func serializeInvoice(w io.Writer, i *Invoice) error {
 		tlv.MakePrimitiveRecord(amtPaidType, &amtPaid),
 
 		tlv.MakePrimitiveRecord(hodlInvoiceType, &hodlInvoice),
+
+		// Payment tolerances.
+		tlv.MakePrimitiveRecord(maxPaymentAmtType, &maxPaymentAmt),
+		tlv.MakePrimitiveRecord(acceptPartialType, &acceptPartial),
+		tlv.MakePrimitiveRecord(maxAmtPaidType, &maxAmtPaid),
 	)
 	if err != nil {
 		return err
@@ -1544,6 +1852,13 @@ func serializeHtlcs(w io.Writer, htlcs map[CircuitKey]*InvoiceHTLC) error {
 			}
 		}
 
+		if htlc.Metadata != nil {
+			metadataRecord := tlv.MakePrimitiveRecord(
+				htlcMetadataType, &htlc.Metadata,
+			)
+			records = append(records, metadataRecord)
+		}
+
 		// Convert the custom records to tlv.Record types that are ready
 		// for serialization.
 		customRecords := tlv.MapToRecords(htlc.CustomRecords)
@@ -1616,6 +1931,9 @@ func deserializeInvoice(r io.Reader) (Invoice, error) {
 		amtPaid       uint64
 		state         uint8
 		hodlInvoice   uint8
+		maxPaymentAmt uint64
+		acceptPartial uint8
+		maxAmtPaid    uint64
 
 		creationDateBytes []byte
 		settleDateBytes   []byte
@@ -1647,6 +1965,11 @@ func deserializeInvoice(r io.Reader) (Invoice, error) {
 		tlv.MakePrimitiveRecord(amtPaidType, &amtPaid),
 
 		tlv.MakePrimitiveRecord(hodlInvoiceType, &hodlInvoice),
+
+		// Payment tolerances.
+		tlv.MakePrimitiveRecord(maxPaymentAmtType, &maxPaymentAmt),
+		tlv.MakePrimitiveRecord(acceptPartialType, &acceptPartial),
+		tlv.MakePrimitiveRecord(maxAmtPaidType, &maxAmtPaid),
 	)
 	if err != nil {
 		return i, err
@@ -1678,6 +2001,10 @@ func deserializeInvoice(r io.Reader) (Invoice, error) {
 		i.HodlInvoice = true
 	}
 
+	i.Terms.MaxPaymentAmt = lnwire.MilliSatoshi(maxPaymentAmt)
+	i.Terms.AcceptPartial = acceptPartial != 0
+	i.Terms.MaxAmtPaid = lnwire.MilliSatoshi(maxAmtPaid)
+
 	err = i.CreationDate.UnmarshalBinary(creationDateBytes)
 	if err != nil {
 		return i, err
@@ -1735,6 +2062,7 @@ func deserializeHtlcs(r io.Reader) (map[CircuitKey]*InvoiceHTLC, error) {
 			amp                     = &record.AMP{}
 			hash32                  = &[32]byte{}
 			preimage32              = &[32]byte{}
+			metadata                []byte
 		)
 		tlvStream, err := tlv.NewStream(
 			tlv.MakePrimitiveRecord(chanIDType, &chanID),
@@ -1754,6 +2082,7 @@ func deserializeHtlcs(r io.Reader) (map[CircuitKey]*InvoiceHTLC, error) {
 			),
 			tlv.MakePrimitiveRecord(htlcHashType, hash32),
 			tlv.MakePrimitiveRecord(htlcPreimageType, preimage32),
+			tlv.MakePrimitiveRecord(htlcMetadataType, &metadata),
 		)
 		if err != nil {
 			return nil, err
@@ -1793,6 +2122,9 @@ func deserializeHtlcs(r io.Reader) (map[CircuitKey]*InvoiceHTLC, error) {
 				Preimage: preimage,
 			}
 		}
+		if _, ok := parsedTypes[htlcMetadataType]; ok {
+			htlc.Metadata = metadata
+		}
 
 		// Reconstruct the custom records fields from the parsed types
 		// map return from the tlv parser.
@@ -1917,6 +2249,7 @@ func (d *DB) updateInvoice(hash *lntypes.Hash, invoices,
 			State:         HtlcStateAccepted,
 			CustomRecords: htlcUpdate.CustomRecords,
 			AMP:           htlcUpdate.AMP.Copy(),
+			Metadata:      htlcUpdate.Metadata,
 		}
 
 		invoice.Htlcs[key] = htlc
@@ -1938,7 +2271,9 @@ func (d *DB) updateInvoice(hash *lntypes.Hash, invoices,
 			return nil, fmt.Errorf("added htlc %v canceled", key)
 		}
 
-		err := cancelSingleHtlc(now, htlc, newState)
+		err := cancelSingleHtlc(
+			now, htlc, newState, invoice.Terms.MaxAmtPaid > 0,
+		)
 		if err != nil {
 			return nil, err
 		}
@@ -1966,20 +2301,54 @@ func (d *DB) updateInvoice(hash *lntypes.Hash, invoices,
 		}
 
 		if update.State.NewState == ContractSettled {
-			err := setSettleMetaFields(
+			// AMP invoices are settled once per htlc set, so we
+			// remove the entry of a previous settlement from the
+			// settle time index first.
+			err := deleteSettleTimeIndex(invoices, &invoice)
+			if err != nil {
+				return nil, err
+			}
+
+			err = setSettleMetaFields(
 				settleIndex, invoiceNum, &invoice, now,
 			)
 			if err != nil {
 				return nil, err
 			}
+
+			err = putInvoiceTimeIndex(
+				invoices, invoiceSettleTimeIndexBucket,
+				invoice.SettleDate, invoice.AddIndex,
+			)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
+	// Htlc sets that are added to a reusable invoice are only settled once
+	// they are complete, which is the case when the update settles the
+	// invoice with their set id. Until then, they stay accepted and don't
+	// count towards the amount paid, even if the invoice was settled with
+	// another set already.
+	var (
+		reusable = invoice.Terms.MaxAmtPaid > 0
+		settling = update.State != nil &&
+			update.State.NewState == ContractSettled
+	)
+
 	// With any invoice level state transitions recorded, we'll now finalize
 	// the process by updating the state transitions for individual HTLCs
 	// and recalculate the total amount paid to the invoice.
 	var amtPaid lnwire.MilliSatoshi
 	for key, htlc := range invoice.Htlcs {
+		if reusable && invoice.State == ContractSettled &&
+			htlc.State == HtlcStateAccepted &&
+			!(settling && htlc.IsInHTLCSet(setID)) {
+
+			continue
+		}
+
 		// Set the HTLC preimage for any AMP HTLCs.
 		if setID != nil {
 			preimage, ok := update.State.HTLCPreimages[key]
@@ -2109,9 +2478,24 @@ func updateInvoiceState(invoice *Invoice, hash *lntypes.Hash,
 
 		return nil
 
-	// Once settled, we are in a terminal state.
+	// Once settled, we are in a terminal state. Reusable invoices are the
+	// exception, they can be settled again with a new AMP htlc set.
 	case ContractSettled:
-		return ErrInvoiceAlreadySettled
+		if update.NewState != ContractSettled ||
+			invoice.Terms.MaxAmtPaid == 0 || update.SetID == nil {
+
+			return ErrInvoiceAlreadySettled
+		}
+
+		if update.Preimage != nil {
+			return errors.New("AMP set cannot have preimage")
+		}
+
+		if len(invoice.HTLCSet(update.SetID, HtlcStateAccepted)) == 0 {
+			return ErrEmptyHTLCSet
+		}
+
+		return nil
 
 	// Once canceled, we are in a terminal state.
 	case ContractCanceled:
@@ -2124,10 +2508,13 @@ func updateInvoiceState(invoice *Invoice, hash *lntypes.Hash,
 
 // cancelSingleHtlc validates cancelation of a single htlc and update its state.
 func cancelSingleHtlc(resolveTime time.Time, htlc *InvoiceHTLC,
-	invState ContractState) error {
+	invState ContractState, reusable bool) error {
+
+	// It is only possible to cancel individual htlcs on an open invoice,
+	// or on a reusable invoice that was settled before.
+	if invState != ContractOpen &&
+		!(reusable && invState == ContractSettled) {
 
-	// It is only possible to cancel individual htlcs on an open invoice.
-	if invState != ContractOpen {
 		return fmt.Errorf("htlc canceled on invoice in "+
 			"state %v", invState)
 	}
@@ -2262,6 +2649,39 @@ func setSettleMetaFields(settleIndex kvdb.RwBucket, invoiceNum []byte,
 	return nil
 }
 
+// deleteInvoiceTimeIndexes removes the given invoice from the creation and
+// settle time indexes.
+func deleteInvoiceTimeIndexes(invoices kvdb.RwBucket, invoice *Invoice) error {
+	creationIndex := invoices.NestedReadWriteBucket(
+		invoiceCreationTimeIndexBucket,
+	)
+	if creationIndex != nil {
+		err := creationIndex.Delete(
+			timeIndexKey(invoice.CreationDate, invoice.AddIndex),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return deleteSettleTimeIndex(invoices, invoice)
+}
+
+// deleteSettleTimeIndex removes the given invoice from the settle time index
+// if it has been settled.
+func deleteSettleTimeIndex(invoices kvdb.RwBucket, invoice *Invoice) error {
+	settleIndex := invoices.NestedReadWriteBucket(
+		invoiceSettleTimeIndexBucket,
+	)
+	if settleIndex == nil || invoice.SettleIndex == 0 {
+		return nil
+	}
+
+	return settleIndex.Delete(
+		timeIndexKey(invoice.SettleDate, invoice.AddIndex),
+	)
+}
+
 // InvoiceDeleteRef holds a refererence to an invoice to be deleted.
 type InvoiceDeleteRef struct {
 	// PayHash is the payment hash of the target invoice. All invoices are
@@ -2317,7 +2737,18 @@ func (d *DB) DeleteInvoice(invoicesToDelete []InvoiceDeleteRef) error {
 				return ErrInvoiceNotFound
 			}
 
-			err := invoiceIndex.Delete(ref.PayHash[:])
+			// Fetch the invoice to remove it from the time
+			// indexes, which are keyed by its dates.
+			invoice, err := fetchInvoice(invoiceKey, invoices)
+			if err != nil {
+				return err
+			}
+			err = deleteInvoiceTimeIndexes(invoices, &invoice)
+			if err != nil {
+				return err
+			}
+
+			err = invoiceIndex.Delete(ref.PayHash[:])
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,178 @@
+package channeldb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+var (
+	// lnurlUsersBucket is the name of the top level bucket that holds the
+	// users of the LNURL-pay server. The users are keyed by their user
+	// name.
+	//
+	// lnurl-users
+	//      |
+	//      |-- <username>: <serialized LnurlUser>
+	//      |
+	//      |-- <username>: <serialized LnurlUser>
+	lnurlUsersBucket = []byte("lnurl-users")
+)
+
+var (
+	// ErrLnurlUserNotFound is returned when a LNURL-pay user that is not
+	// known is requested.
+	ErrLnurlUserNotFound = errors.New("lnurl user not found")
+)
+
+// LnurlUser is a user of the LNURL-pay server. Every user can be paid through
+// its own LNURL-pay endpoint, or through the lightning address
+// <username>@<domain>.
+type LnurlUser struct {
+	// Username is the name of the user, which is the local part of the
+	// user's lightning address.
+	Username string
+
+	// Description is the plain text description that is shown to payers
+	// and committed to by the description hash of the user's invoices.
+	Description string
+
+	// MinSendable is the minimum amount that can be paid to the user.
+	MinSendable lnwire.MilliSatoshi
+
+	// MaxSendable is the maximum amount that can be paid to the user.
+	MaxSendable lnwire.MilliSatoshi
+
+	// CommentAllowed is the maximum length of a comment that payers can
+	// attach to a payment. Zero means that comments are not allowed.
+	CommentAllowed uint16
+}
+
+// PutLnurlUser adds the given user to the database. If a user with the same
+// name already exists, it is replaced.
+func (d *DB) PutLnurlUser(user *LnurlUser) error {
+	var b bytes.Buffer
+	if err := serializeLnurlUser(&b, user); err != nil {
+		return err
+	}
+
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		users, err := tx.CreateTopLevelBucket(lnurlUsersBucket)
+		if err != nil {
+			return err
+		}
+
+		return users.Put([]byte(user.Username), b.Bytes())
+	}, func() {})
+}
+
+// FetchLnurlUser returns the user with the given name. ErrLnurlUserNotFound is
+// returned if the user does not exist.
+func (d *DB) FetchLnurlUser(username string) (*LnurlUser, error) {
+	var user *LnurlUser
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		users := tx.ReadBucket(lnurlUsersBucket)
+		if users == nil {
+			return ErrLnurlUserNotFound
+		}
+
+		userBytes := users.Get([]byte(username))
+		if userBytes == nil {
+			return ErrLnurlUserNotFound
+		}
+
+		var err error
+		user, err = deserializeLnurlUser(
+			username, bytes.NewReader(userBytes),
+		)
+		return err
+	}, func() {
+		user = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// FetchLnurlUsers returns all users of the LNURL-pay server, ordered by their
+// name.
+func (d *DB) FetchLnurlUsers() ([]*LnurlUser, error) {
+	var users []*LnurlUser
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		usersBucket := tx.ReadBucket(lnurlUsersBucket)
+		if usersBucket == nil {
+			return nil
+		}
+
+		return usersBucket.ForEach(func(k, v []byte) error {
+			user, err := deserializeLnurlUser(
+				string(k), bytes.NewReader(v),
+			)
+			if err != nil {
+				return err
+			}
+
+			users = append(users, user)
+			return nil
+		})
+	}, func() {
+		users = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// DeleteLnurlUser removes the user with the given name from the database.
+// ErrLnurlUserNotFound is returned if the user does not exist.
+func (d *DB) DeleteLnurlUser(username string) error {
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		users := tx.ReadWriteBucket(lnurlUsersBucket)
+		if users == nil {
+			return ErrLnurlUserNotFound
+		}
+
+		if users.Get([]byte(username)) == nil {
+			return ErrLnurlUserNotFound
+		}
+
+		return users.Delete([]byte(username))
+	}, func() {})
+}
+
+// serializeLnurlUser writes the given user to w. The user name is not
+// serialized, as it is used as the key of the user.
+func serializeLnurlUser(w io.Writer, user *LnurlUser) error {
+	return WriteElements(
+		w, []byte(user.Description), user.MinSendable,
+		user.MaxSendable, user.CommentAllowed,
+	)
+}
+
+// deserializeLnurlUser reads the user with the given name from r.
+func deserializeLnurlUser(username string, r io.Reader) (*LnurlUser,
+	error) {
+
+	user := &LnurlUser{
+		Username: username,
+	}
+
+	var description []byte
+	err := ReadElements(
+		r, &description, &user.MinSendable, &user.MaxSendable,
+		&user.CommentAllowed,
+	)
+	if err != nil {
+		return nil, err
+	}
+	user.Description = string(description)
+
+	return user, nil
+}
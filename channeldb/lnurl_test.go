@@ -0,0 +1,67 @@
+package channeldb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestLnurlUsers tests adding, updating, fetching and deleting LNURL-pay
+// users.
+func TestLnurlUsers(t *testing.T) {
+	db, cleanup, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	// Without any users, lookups fail and the list of users is empty.
+	_, err = db.FetchLnurlUser("alice")
+	require.Equal(t, ErrLnurlUserNotFound, err)
+
+	users, err := db.FetchLnurlUsers()
+	require.NoError(t, err)
+	require.Empty(t, users)
+
+	alice := &LnurlUser{
+		Username:       "alice",
+		Description:    "Tips for alice",
+		MinSendable:    1000,
+		MaxSendable:    100000000,
+		CommentAllowed: 140,
+	}
+	bob := &LnurlUser{
+		Username:    "bob",
+		MinSendable: 1000,
+		MaxSendable: 5000000,
+	}
+
+	require.NoError(t, db.PutLnurlUser(bob))
+	require.NoError(t, db.PutLnurlUser(alice))
+
+	user, err := db.FetchLnurlUser("alice")
+	require.NoError(t, err)
+	require.Equal(t, alice, user)
+
+	// The users are returned ordered by their name.
+	users, err = db.FetchLnurlUsers()
+	require.NoError(t, err)
+	require.Equal(t, []*LnurlUser{alice, bob}, users)
+
+	// Adding a user with an existing name replaces the user.
+	bob.CommentAllowed = 20
+	require.NoError(t, db.PutLnurlUser(bob))
+
+	user, err = db.FetchLnurlUser("bob")
+	require.NoError(t, err)
+	require.Equal(t, bob, user)
+
+	// Delete alice, after which only bob is left.
+	require.NoError(t, db.DeleteLnurlUser("alice"))
+	require.Equal(t, ErrLnurlUserNotFound, db.DeleteLnurlUser("alice"))
+
+	_, err = db.FetchLnurlUser("alice")
+	require.Equal(t, ErrLnurlUserNotFound, err)
+
+	users, err = db.FetchLnurlUsers()
+	require.NoError(t, err)
+	require.Equal(t, []*LnurlUser{bob}, users)
+}
@@ -0,0 +1,341 @@
+package migration23
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+var (
+	// invoiceBucket is the name of the bucket that stores all invoices.
+	invoiceBucket = []byte("invoices")
+
+	// addIndexBucket is the sub-bucket of the invoice bucket that maps
+	// add indexes to invoice numbers.
+	addIndexBucket = []byte("invoice-add-index")
+
+	// invoiceCreationTimeIndexBucket is the sub-bucket of the invoice
+	// bucket that indexes invoices by their creation date.
+	invoiceCreationTimeIndexBucket = []byte("invoice-creation-time-index")
+
+	// invoiceSettleTimeIndexBucket is the sub-bucket of the invoice bucket
+	// that indexes settled invoices by their settle date.
+	invoiceSettleTimeIndexBucket = []byte("invoice-settle-time-index")
+
+	// paymentsRootBucket is the name of the bucket that stores all
+	// payments.
+	paymentsRootBucket = []byte("payments-root-bucket")
+
+	// paymentSequenceKey is the key of the sequence number of a payment,
+	// both for payments and duplicate payments.
+	paymentSequenceKey = []byte("payment-sequence-key")
+
+	// paymentCreationInfoKey is the key of the creation info of a payment,
+	// both for payments and duplicate payments.
+	paymentCreationInfoKey = []byte("payment-creation-info")
+
+	// duplicatePaymentsBucket is the sub-bucket of a payment which holds
+	// its duplicate payments.
+	duplicatePaymentsBucket = []byte("payment-duplicate-bucket")
+
+	// paymentsCreationTimeIndexBucket is the name of the top-level bucket
+	// that indexes payments by their creation time.
+	paymentsCreationTimeIndexBucket = []byte("payments-creation-time-index")
+
+	byteOrder = binary.BigEndian
+)
+
+const (
+	// The tlv types of the invoice body, as of database version 22. All
+	// even types need to be known to decode the body.
+	memoType        tlv.Type = 0
+	createTimeType  tlv.Type = 2
+	settleTimeType  tlv.Type = 3
+	addIndexType    tlv.Type = 4
+	settleIndexType tlv.Type = 5
+	preimageType    tlv.Type = 6
+	cltvDeltaType   tlv.Type = 8
+	paymentAddrType tlv.Type = 10
+	invStateType    tlv.Type = 12
+	hodlInvoiceType tlv.Type = 14
+)
+
+// invoiceTimes holds the fields of an invoice that are needed to index it by
+// creation and settle date.
+type invoiceTimes struct {
+	creationDate time.Time
+	settleDate   time.Time
+	addIndex     uint64
+	settleIndex  uint64
+}
+
+// MigrateTimeIndexes adds indexes that map the creation date of invoices and
+// payments and the settle date of settled invoices to their add index or
+// sequence number respectively. These indexes allow querying invoices and
+// payments by date range without scanning all of them.
+func MigrateTimeIndexes(tx kvdb.RwTx) error {
+	log.Infof("Migrating invoices and payments to add time indexes")
+
+	if err := migrateInvoiceTimeIndexes(tx); err != nil {
+		return err
+	}
+
+	return migratePaymentTimeIndex(tx)
+}
+
+// migrateInvoiceTimeIndexes adds all invoices to the invoice creation time
+// index and all settled invoices to the invoice settle time index.
+func migrateInvoiceTimeIndexes(tx kvdb.RwTx) error {
+	invoices := tx.ReadWriteBucket(invoiceBucket)
+	if invoices == nil {
+		return nil
+	}
+
+	addIndex := invoices.NestedReadBucket(addIndexBucket)
+	if addIndex == nil {
+		return nil
+	}
+
+	// Collect the dates of all invoices first, as we can't modify the
+	// invoice bucket while iterating over its add index.
+	var invoiceList []invoiceTimes
+	err := addIndex.ForEach(func(_, invoiceNum []byte) error {
+		invoiceBytes := invoices.Get(invoiceNum)
+		if invoiceBytes == nil {
+			return fmt.Errorf("invoice %x not found", invoiceNum)
+		}
+
+		times, err := deserializeInvoiceTimes(
+			bytes.NewReader(invoiceBytes),
+		)
+		if err != nil {
+			return err
+		}
+
+		invoiceList = append(invoiceList, times)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	creationIndex, err := invoices.CreateBucketIfNotExists(
+		invoiceCreationTimeIndexBucket,
+	)
+	if err != nil {
+		return err
+	}
+	settleIndex, err := invoices.CreateBucketIfNotExists(
+		invoiceSettleTimeIndexBucket,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, times := range invoiceList {
+		err := creationIndex.Put(
+			TimeIndexKey(times.creationDate, times.addIndex), nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		if times.settleIndex == 0 {
+			continue
+		}
+
+		err = settleIndex.Put(
+			TimeIndexKey(times.settleDate, times.addIndex), nil,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migratePaymentTimeIndex adds all payments, including duplicate payments, to
+// the payment creation time index.
+func migratePaymentTimeIndex(tx kvdb.RwTx) error {
+	timeIndex, err := tx.CreateTopLevelBucket(
+		paymentsCreationTimeIndexBucket,
+	)
+	if err != nil {
+		return err
+	}
+
+	payments := tx.ReadBucket(paymentsRootBucket)
+	if payments == nil {
+		return nil
+	}
+
+	var keys [][]byte
+	err = payments.ForEach(func(k, _ []byte) error {
+		bucket := payments.NestedReadBucket(k)
+		if bucket == nil {
+			return fmt.Errorf("non bucket element in payments " +
+				"bucket")
+		}
+
+		key, err := paymentTimeIndexKey(bucket, false)
+		if err != nil {
+			return err
+		}
+		if key != nil {
+			keys = append(keys, key)
+		}
+
+		duplicates := bucket.NestedReadBucket(duplicatePaymentsBucket)
+		if duplicates == nil {
+			return nil
+		}
+
+		return duplicates.ForEach(func(k, _ []byte) error {
+			subBucket := duplicates.NestedReadBucket(k)
+			if subBucket == nil {
+				return errors.New("non bucket element in " +
+					"duplicate payments bucket")
+			}
+
+			key, err := paymentTimeIndexKey(subBucket, true)
+			if err != nil {
+				return err
+			}
+			if key != nil {
+				keys = append(keys, key)
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := timeIndex.Put(key, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// paymentTimeIndexKey returns the creation time index key of the payment in
+// the given bucket, or nil if the payment has no creation info. Duplicate
+// payments store their creation time in seconds instead of nanoseconds.
+func paymentTimeIndexKey(bucket kvdb.RBucket, duplicate bool) ([]byte,
+	error) {
+
+	seqNum := bucket.Get(paymentSequenceKey)
+	info := bucket.Get(paymentCreationInfoKey)
+	if seqNum == nil || info == nil {
+		return nil, nil
+	}
+
+	// The creation info starts with the 32 byte payment hash and the 8
+	// byte value, followed by the 8 byte creation time.
+	if len(seqNum) != 8 || len(info) < 48 {
+		return nil, errors.New("invalid payment creation info")
+	}
+
+	timestamp := int64(byteOrder.Uint64(info[40:48]))
+
+	var creationTime time.Time
+	switch {
+	case duplicate:
+		creationTime = time.Unix(timestamp, 0)
+
+	case timestamp != 0:
+		creationTime = time.Unix(0, timestamp)
+	}
+
+	return TimeIndexKey(creationTime, byteOrder.Uint64(seqNum)), nil
+}
+
+// deserializeInvoiceTimes decodes the dates and indexes of a serialized
+// invoice.
+func deserializeInvoiceTimes(r io.Reader) (invoiceTimes, error) {
+	var (
+		times              invoiceTimes
+		creationDateBytes  []byte
+		settleDateBytes    []byte
+		memo, preimage     []byte
+		cltvDelta, payAddr []byte
+		state, hodlInvoice []byte
+	)
+
+	var bodyLen int64
+	if err := binary.Read(r, byteOrder, &bodyLen); err != nil {
+		return times, err
+	}
+
+	// Besides the fields we need, the stream must contain all even types
+	// of the invoice body, while unknown odd types are skipped.
+	tlvStream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(memoType, &memo),
+		tlv.MakePrimitiveRecord(createTimeType, &creationDateBytes),
+		tlv.MakePrimitiveRecord(settleTimeType, &settleDateBytes),
+		tlv.MakePrimitiveRecord(addIndexType, &times.addIndex),
+		tlv.MakePrimitiveRecord(settleIndexType, &times.settleIndex),
+		tlv.MakePrimitiveRecord(preimageType, &preimage),
+		tlv.MakePrimitiveRecord(cltvDeltaType, &cltvDelta),
+		tlv.MakePrimitiveRecord(paymentAddrType, &payAddr),
+		tlv.MakePrimitiveRecord(invStateType, &state),
+		tlv.MakePrimitiveRecord(hodlInvoiceType, &hodlInvoice),
+	)
+	if err != nil {
+		return times, err
+	}
+
+	err = tlvStream.Decode(io.LimitReader(r, bodyLen))
+	if err != nil {
+		return times, err
+	}
+
+	if err := times.creationDate.UnmarshalBinary(
+		creationDateBytes,
+	); err != nil {
+		return times, err
+	}
+
+	if err := times.settleDate.UnmarshalBinary(
+		settleDateBytes,
+	); err != nil {
+		return times, err
+	}
+
+	return times, nil
+}
+
+// TimeIndexKeyLen is the length of the keys of a time index, which consist of
+// an 8 byte timestamp followed by an 8 byte sequence number.
+const TimeIndexKeyLen = 16
+
+// TimeIndexKey returns the key of an entry in a time index. Time indexes map
+// the timestamp of an item followed by its sequence number to an empty value,
+// so that items can be looked up by time range without scanning all of them.
+// Both parts are big-endian encoded, which orders the entries by time first.
+// The indexes that are created by this migration are maintained by channeldb
+// afterwards, so it uses this function as well to produce the same keys.
+func TimeIndexKey(t time.Time, seqNum uint64) []byte {
+	// Calling UnixNano() on a zero time yields an undefined result, so we
+	// map zero and pre-epoch times to the start of the index.
+	var unixNano int64
+	if !t.IsZero() && t.UnixNano() > 0 {
+		unixNano = t.UnixNano()
+	}
+
+	key := make([]byte, TimeIndexKeyLen)
+	byteOrder.PutUint64(key[:8], uint64(unixNano))
+	byteOrder.PutUint64(key[8:], seqNum)
+
+	return key
+}
@@ -0,0 +1,197 @@
+package migration23
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb/migtest"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+var (
+	hexStr = migtest.Hex
+
+	invoiceNum1 = hexStr("00000000")
+	invoiceNum2 = hexStr("00000001")
+	addIndex1   = hexStr("0000000000000001")
+	addIndex2   = hexStr("0000000000000002")
+
+	hash1 = hexStr("02acee76ebd53d00824410cf6adecad4f50334dac702bd5a2d3ba01b91709f0e")
+	hash2 = hexStr("62eb3f0a48f954e495d0c14ac63df04a67cefa59dafdbcd3d5046d1f5647840c")
+
+	paymentID1 = hexStr("0000000000000001")
+	paymentID2 = hexStr("0000000000000002")
+	paymentID3 = hexStr("0000000000000003")
+
+	creationTime1 = time.Unix(1000, 1)
+	creationTime2 = time.Unix(2000, 2)
+	settleTime2   = time.Unix(3000, 3)
+	paymentTime3  = time.Unix(4000, 0)
+)
+
+// serializeInvoice serializes an invoice body with the given dates and
+// indexes, followed by an empty htlc set.
+func serializeInvoice(t *testing.T, creationDate, settleDate time.Time,
+	addIndex, settleIndex uint64) string {
+
+	creationDateBytes, err := creationDate.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settleDateBytes, err := settleDate.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memo := []byte("memo")
+	value := uint64(1000)
+	tlvStream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(memoType, &memo),
+		tlv.MakePrimitiveRecord(createTimeType, &creationDateBytes),
+		tlv.MakePrimitiveRecord(settleTimeType, &settleDateBytes),
+		tlv.MakePrimitiveRecord(addIndexType, &addIndex),
+		tlv.MakePrimitiveRecord(settleIndexType, &settleIndex),
+		tlv.MakePrimitiveRecord(7, &value),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	if err := tlvStream.Encode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	_ = binary.Write(&b, byteOrder, uint64(body.Len()))
+	_, _ = b.Write(body.Bytes())
+
+	// An empty htlc set.
+	_ = binary.Write(&b, byteOrder, uint64(0))
+
+	return b.String()
+}
+
+// serializeCreationInfo serializes the creation info of a payment with the
+// given creation timestamp.
+func serializeCreationInfo(hash string, timestamp int64) string {
+	var b bytes.Buffer
+	_, _ = b.WriteString(hash)
+	_ = binary.Write(&b, byteOrder, uint64(1000))
+	_ = binary.Write(&b, byteOrder, uint64(timestamp))
+	_ = binary.Write(&b, byteOrder, uint32(0))
+
+	return b.String()
+}
+
+// TestMigrateTimeIndexes asserts that invoices and payments are added to the
+// new time indexes.
+func TestMigrateTimeIndexes(t *testing.T) {
+	invoices := map[string]interface{}{
+		invoiceNum1: serializeInvoice(
+			t, creationTime1, time.Time{}, 1, 0,
+		),
+		invoiceNum2: serializeInvoice(
+			t, creationTime2, settleTime2, 2, 1,
+		),
+		string(addIndexBucket): map[string]interface{}{
+			addIndex1: invoiceNum1,
+			addIndex2: invoiceNum2,
+		},
+	}
+
+	duplicatePayment := map[string]interface{}{
+		string(paymentSequenceKey): paymentID3,
+		string(paymentCreationInfoKey): serializeCreationInfo(
+			hash2, paymentTime3.Unix(),
+		),
+	}
+
+	payments := map[string]interface{}{
+		// A payment without duplicates.
+		hash1: map[string]interface{}{
+			string(paymentSequenceKey): paymentID1,
+			string(paymentCreationInfoKey): serializeCreationInfo(
+				hash1, creationTime1.UnixNano(),
+			),
+		},
+
+		// A payment with a duplicate, which stores its creation time
+		// in seconds.
+		hash2: map[string]interface{}{
+			string(paymentSequenceKey): paymentID2,
+			string(paymentCreationInfoKey): serializeCreationInfo(
+				hash2, creationTime2.UnixNano(),
+			),
+			string(duplicatePaymentsBucket): map[string]interface{}{
+				paymentID3: duplicatePayment,
+			},
+		},
+	}
+
+	before := func(tx kvdb.RwTx) error {
+		err := migtest.RestoreDB(tx, invoiceBucket, invoices)
+		if err != nil {
+			return err
+		}
+
+		return migtest.RestoreDB(tx, paymentsRootBucket, payments)
+	}
+
+	// After the migration, the invoice bucket should contain the new time
+	// indexes, and the payment creation time index should exist.
+	postInvoices := map[string]interface{}{
+		string(invoiceCreationTimeIndexBucket): map[string]interface{}{
+			string(TimeIndexKey(creationTime1, 1)): "",
+			string(TimeIndexKey(creationTime2, 2)): "",
+		},
+		string(invoiceSettleTimeIndexBucket): map[string]interface{}{
+			string(TimeIndexKey(settleTime2, 2)): "",
+		},
+	}
+	for k, v := range invoices {
+		postInvoices[k] = v
+	}
+
+	postTimeIndex := map[string]interface{}{
+		string(TimeIndexKey(creationTime1, 1)): "",
+		string(TimeIndexKey(creationTime2, 2)): "",
+		string(TimeIndexKey(paymentTime3, 3)):  "",
+	}
+
+	after := func(tx kvdb.RwTx) error {
+		err := migtest.VerifyDB(tx, invoiceBucket, postInvoices)
+		if err != nil {
+			return err
+		}
+
+		err = migtest.VerifyDB(tx, paymentsRootBucket, payments)
+		if err != nil {
+			return err
+		}
+
+		return migtest.VerifyDB(
+			tx, paymentsCreationTimeIndexBucket, postTimeIndex,
+		)
+	}
+
+	migtest.ApplyMigration(t, before, after, MigrateTimeIndexes, false)
+}
+
+// TestMigrateTimeIndexesEmpty asserts that the migration succeeds on a
+// database without invoices and payments.
+func TestMigrateTimeIndexesEmpty(t *testing.T) {
+	after := func(tx kvdb.RwTx) error {
+		return migtest.VerifyDB(
+			tx, paymentsCreationTimeIndexBucket, nil,
+		)
+	}
+
+	migtest.ApplyMigration(
+		t, func(kvdb.RwTx) error { return nil }, after,
+		MigrateTimeIndexes, false,
+	)
+}
@@ -0,0 +1,423 @@
+package migration24
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/lightningnetwork/lnd/zpay32"
+)
+
+var (
+	// paymentsRootBucket is the name of the bucket that stores all
+	// payments.
+	paymentsRootBucket = []byte("payments-root-bucket")
+
+	// paymentSequenceKey is the key of the sequence number of a payment.
+	paymentSequenceKey = []byte("payment-sequence-key")
+
+	// paymentCreationInfoKey is the key of the creation info of a payment.
+	paymentCreationInfoKey = []byte("payment-creation-info")
+
+	// paymentHtlcsBucket is the sub-bucket of a payment which holds a
+	// sub-bucket per htlc attempt.
+	paymentHtlcsBucket = []byte("payment-htlcs-bucket")
+
+	// htlcAttemptInfoKey is the key of the attempt info in the sub-bucket
+	// of an htlc attempt.
+	htlcAttemptInfoKey = []byte("htlc-attempt-info")
+
+	// paymentsLookupIndexBucket is the name of the top-level bucket that
+	// indexes payments by their destination, payment address, invoice
+	// description and label.
+	paymentsLookupIndexBucket = []byte("payments-lookup-index")
+
+	// payReqNetworks are the networks that payment requests are decoded
+	// for. The network of the node isn't known to the migration, so the
+	// payment request is decoded for the network it was created for.
+	payReqNetworks = []*chaincfg.Params{
+		&chaincfg.MainNetParams,
+		&chaincfg.TestNet3Params,
+		&chaincfg.RegressionNetParams,
+		&chaincfg.SimNetParams,
+		&chaincfg.SigNetParams,
+	}
+
+	byteOrder = binary.BigEndian
+)
+
+const (
+	// The tlv types of the optional fields of the payment creation info,
+	// which are appended to its fixed fields.
+	creationInfoDestType        tlv.Type = 1
+	creationInfoPaymentAddrType tlv.Type = 3
+	creationInfoDescriptionType tlv.Type = 5
+
+	// The types of the payment properties in the payments lookup index.
+	lookupDest        byte = 0
+	lookupPaymentAddr byte = 1
+	lookupDescription byte = 2
+
+	// mppOnionType is the tlv type of the MPP record of a hop, which
+	// starts with the payment address.
+	mppOnionType uint64 = 8
+
+	// maxOnionPayloadSize is the maximum size of a tlv record of a hop.
+	maxOnionPayloadSize = 1300
+
+	// maxVarBytesSize is the maximum size of a variable length byte slice
+	// in the payment database.
+	maxVarBytesSize = 66000
+)
+
+// paymentLookupInfo holds the properties of a payment that it is indexed by
+// in the payments lookup index.
+type paymentLookupInfo struct {
+	seqNum      []byte
+	dest        *[33]byte
+	paymentAddr *[32]byte
+	description string
+}
+
+// MigratePaymentLookupIndex creates the payments lookup index, which maps the
+// destination, payment address and invoice description of payments to their
+// sequence numbers. Existing payments only store these properties implicitly,
+// so the destination and payment address are taken from the final hop of
+// their htlc attempts, or from their payment request if no htlc was attempted.
+// The description is taken from the payment request. The properties are added
+// to the creation info of the payments as well, so that their index entries
+// are removed again once the payments are deleted.
+func MigratePaymentLookupIndex(tx kvdb.RwTx) error {
+	log.Infof("Migrating payments to add the payments lookup index")
+
+	lookupIndex, err := tx.CreateTopLevelBucket(paymentsLookupIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	payments := tx.ReadWriteBucket(paymentsRootBucket)
+	if payments == nil {
+		return nil
+	}
+
+	// Collect the properties of all payments first, as we can't modify
+	// the payment buckets while iterating over them. Duplicate payments
+	// are never indexed, so we only look at the payments themselves.
+	infos := make(map[string]*paymentLookupInfo)
+	err = payments.ForEach(func(k, _ []byte) error {
+		bucket := payments.NestedReadBucket(k)
+		if bucket == nil {
+			return fmt.Errorf("non bucket element in payments " +
+				"bucket")
+		}
+
+		info, err := fetchPaymentLookupInfo(bucket)
+		if err != nil {
+			return err
+		}
+		if info != nil {
+			infos[string(k)] = info
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for hash, info := range infos {
+		bucket := payments.NestedReadWriteBucket([]byte(hash))
+
+		creationInfo, err := info.appendTo(
+			bucket.Get(paymentCreationInfoKey),
+		)
+		if err != nil {
+			return err
+		}
+
+		err = bucket.Put(paymentCreationInfoKey, creationInfo)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range info.indexKeys() {
+			if err := lookupIndex.Put(key, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetchPaymentLookupInfo returns the lookup properties of the payment in the
+// given bucket, or nil if the payment has no creation info or none of the
+// properties.
+func fetchPaymentLookupInfo(bucket kvdb.RBucket) (*paymentLookupInfo,
+	error) {
+
+	seqNum := bucket.Get(paymentSequenceKey)
+	creationInfo := bucket.Get(paymentCreationInfoKey)
+	if seqNum == nil || creationInfo == nil {
+		return nil, nil
+	}
+
+	// The creation info consists of the 32 byte payment hash, the 8 byte
+	// value and the 8 byte creation time, followed by the length prefixed
+	// payment request.
+	const payReqOffset = 52
+	if len(creationInfo) < payReqOffset {
+		return nil, errors.New("invalid payment creation info")
+	}
+
+	reqLen := int(byteOrder.Uint32(creationInfo[48:payReqOffset]))
+	switch {
+	case len(creationInfo) < payReqOffset+reqLen:
+		return nil, errors.New("invalid payment creation info")
+
+	// If the creation info already has optional fields, the payment has
+	// been indexed when it was created.
+	case len(creationInfo) > payReqOffset+reqLen:
+		return nil, nil
+	}
+	payReq := creationInfo[payReqOffset : payReqOffset+reqLen]
+
+	info := &paymentLookupInfo{
+		seqNum: seqNum,
+	}
+
+	// The final hop of any htlc attempt holds the destination of the
+	// payment, and the payment address if the payment was made with one.
+	if htlcs := bucket.NestedReadBucket(paymentHtlcsBucket); htlcs != nil {
+		err := htlcs.ForEach(func(k, _ []byte) error {
+			if info.dest != nil {
+				return nil
+			}
+
+			htlc := htlcs.NestedReadBucket(k)
+			if htlc == nil {
+				return nil
+			}
+
+			attemptInfo := htlc.Get(htlcAttemptInfoKey)
+			if attemptInfo == nil {
+				return nil
+			}
+
+			dest, paymentAddr, err := deserializeFinalHop(
+				bytes.NewReader(attemptInfo),
+			)
+			if err != nil {
+				return err
+			}
+			info.dest, info.paymentAddr = dest, paymentAddr
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The description is only part of the payment request. If no htlc was
+	// attempted, it holds the destination and payment address as well.
+	var invoice *zpay32.Invoice
+	if len(payReq) > 0 {
+		invoice = decodePayReq(string(payReq))
+	}
+
+	if invoice != nil && invoice.Description != nil {
+		info.description = *invoice.Description
+	}
+
+	if invoice != nil && info.dest == nil && invoice.Destination != nil {
+		var dest [33]byte
+		copy(dest[:], invoice.Destination.SerializeCompressed())
+		info.dest = &dest
+		info.paymentAddr = invoice.PaymentAddr
+	}
+
+	if info.dest == nil && info.paymentAddr == nil &&
+		info.description == "" {
+
+		return nil, nil
+	}
+
+	return info, nil
+}
+
+// decodePayReq decodes the given payment request for the network it was
+// created for. Nil is returned if it can't be decoded for any network.
+func decodePayReq(payReq string) *zpay32.Invoice {
+	for _, net := range payReqNetworks {
+		invoice, err := zpay32.Decode(payReq, net)
+		if err == nil {
+			return invoice
+		}
+	}
+
+	log.Warnf("Unable to decode payment request %v, not indexing its "+
+		"description", payReq)
+
+	return nil
+}
+
+// deserializeFinalHop decodes the public key and the payment address of the
+// final hop of the route of a serialized htlc attempt info. The payment
+// address is nil if the final hop doesn't carry an MPP record.
+func deserializeFinalHop(r io.Reader) (*[33]byte, *[32]byte, error) {
+	// Skip the 32 byte session key, the 4 byte total time lock and the 8
+	// byte total amount that precede the source public key.
+	var scratch [44]byte
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, nil, err
+	}
+
+	_, err := wire.ReadVarBytes(r, 0, maxVarBytesSize, "[]byte")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var numHops uint32
+	if err := binary.Read(r, byteOrder, &numHops); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		dest        *[33]byte
+		paymentAddr *[32]byte
+	)
+	for i := uint32(0); i < numHops; i++ {
+		dest, paymentAddr, err = deserializeHop(r)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return dest, paymentAddr, nil
+}
+
+// deserializeHop decodes the public key and the payment address of a
+// serialized hop.
+func deserializeHop(r io.Reader) (*[33]byte, *[32]byte, error) {
+	pubKey, err := wire.ReadVarBytes(r, 0, maxVarBytesSize, "[]byte")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pubKey) != 33 {
+		return nil, nil, errors.New("invalid hop public key")
+	}
+
+	var dest [33]byte
+	copy(dest[:], pubKey)
+
+	// Skip the 8 byte channel ID, the 4 byte outgoing time lock, the 8 byte
+	// amount to forward and the legacy payload flag.
+	var scratch [21]byte
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, nil, err
+	}
+
+	var numRecords uint32
+	if err := binary.Read(r, byteOrder, &numRecords); err != nil {
+		return nil, nil, err
+	}
+
+	var paymentAddr *[32]byte
+	for i := uint32(0); i < numRecords; i++ {
+		var recordType uint64
+		err := binary.Read(r, byteOrder, &recordType)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value, err := wire.ReadVarBytes(
+			r, 0, maxOnionPayloadSize, "tlv",
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if recordType != mppOnionType || len(value) < 32 {
+			continue
+		}
+
+		paymentAddr = &[32]byte{}
+		copy(paymentAddr[:], value)
+	}
+
+	return &dest, paymentAddr, nil
+}
+
+// appendTo appends the lookup properties as a tlv stream to the given
+// serialized creation info, which doesn't have any optional fields yet.
+func (p *paymentLookupInfo) appendTo(creationInfo []byte) ([]byte, error) {
+	var (
+		records []tlv.Record
+		desc    = []byte(p.description)
+	)
+	if p.dest != nil {
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoDestType, p.dest,
+		))
+	}
+	if p.paymentAddr != nil {
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoPaymentAddrType, p.paymentAddr,
+		))
+	}
+	if len(desc) > 0 {
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoDescriptionType, &desc,
+		))
+	}
+
+	tlvStream, err := tlv.NewStream(records...)
+	if err != nil {
+		return nil, err
+	}
+
+	b := bytes.NewBuffer(append([]byte(nil), creationInfo...))
+	if err := tlvStream.Encode(b); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// indexKeys returns the keys of the payment in the payments lookup index,
+// which consist of the property type, the property value and the sequence
+// number of the payment. Descriptions are indexed by their SHA256 hash.
+func (p *paymentLookupInfo) indexKeys() [][]byte {
+	var prefixes [][]byte
+	if p.dest != nil {
+		prefixes = append(prefixes, append(
+			[]byte{lookupDest}, p.dest[:]...,
+		))
+	}
+	if p.paymentAddr != nil {
+		prefixes = append(prefixes, append(
+			[]byte{lookupPaymentAddr}, p.paymentAddr[:]...,
+		))
+	}
+	if p.description != "" {
+		hash := sha256.Sum256([]byte(p.description))
+		prefixes = append(prefixes, append(
+			[]byte{lookupDescription}, hash[:]...,
+		))
+	}
+
+	keys := make([][]byte, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		keys = append(keys, append(prefix, p.seqNum...))
+	}
+
+	return keys
+}
@@ -0,0 +1,292 @@
+package migration24
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/channeldb/migtest"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/lightningnetwork/lnd/zpay32"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	hexStr = migtest.Hex
+
+	hash1 = hexStr("02acee76ebd53d00824410cf6adecad4f50334dac702bd5a2d3ba01b91709f0e")
+	hash2 = hexStr("62eb3f0a48f954e495d0c14ac63df04a67cefa59dafdbcd3d5046d1f5647840c")
+	hash3 = hexStr("7f0d8d1d5a48a6c7ccd9e5d4ed3a0f6c3c0b7a4a3f4a9b1a7e5c2c8d9e0f1a2b")
+	hash4 = hexStr("9a2b5c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9")
+
+	paymentID1 = hexStr("0000000000000001")
+	paymentID2 = hexStr("0000000000000002")
+	paymentID3 = hexStr("0000000000000003")
+	paymentID4 = hexStr("0000000000000004")
+
+	attemptID1 = hexStr("0000000000000001")
+	attemptID2 = hexStr("0000000000000002")
+
+	payAddr = [32]byte{0x42}
+
+	description = "coffee"
+)
+
+// serializeCreationInfo serializes the creation info of a payment without
+// any optional fields.
+func serializeCreationInfo(hash, payReq string) string {
+	var b bytes.Buffer
+	_, _ = b.WriteString(hash)
+	_ = binary.Write(&b, byteOrder, uint64(1000))
+	_ = binary.Write(&b, byteOrder, uint64(time.Unix(1, 0).UnixNano()))
+	_ = binary.Write(&b, byteOrder, uint32(len(payReq)))
+	_, _ = b.WriteString(payReq)
+
+	return b.String()
+}
+
+// serializeHop serializes a hop to the given node, which carries an MPP
+// record with the given payment address unless it is nil.
+func serializeHop(b *bytes.Buffer, pubKey []byte, paymentAddr *[32]byte) {
+	_ = wire.WriteVarBytes(b, 0, pubKey)
+	_ = binary.Write(b, byteOrder, uint64(12345))
+	_ = binary.Write(b, byteOrder, uint32(111))
+	_ = binary.Write(b, byteOrder, uint64(555))
+	_ = binary.Write(b, byteOrder, paymentAddr == nil)
+
+	if paymentAddr == nil {
+		_ = binary.Write(b, byteOrder, uint32(0))
+		return
+	}
+
+	// A custom record precedes the MPP record, which consists of the
+	// payment address and the total amount.
+	_ = binary.Write(b, byteOrder, uint32(2))
+	_ = binary.Write(b, byteOrder, uint64(65536))
+	_ = wire.WriteVarBytes(b, 0, []byte{0x01})
+	_ = binary.Write(b, byteOrder, mppOnionType)
+	_ = wire.WriteVarBytes(b, 0, append(paymentAddr[:], 0x03, 0xe8))
+}
+
+// serializeAttemptInfo serializes the info of an htlc attempt whose route has
+// a final hop to the given destination.
+func serializeAttemptInfo(dest []byte, paymentAddr *[32]byte) string {
+	var b bytes.Buffer
+	_, _ = b.Write(bytes.Repeat([]byte{0x01}, 32))
+	_ = binary.Write(&b, byteOrder, uint32(123))
+	_ = binary.Write(&b, byteOrder, uint64(1234567))
+	_ = wire.WriteVarBytes(&b, 0, bytes.Repeat([]byte{0x02}, 33))
+	_ = binary.Write(&b, byteOrder, uint32(2))
+
+	serializeHop(&b, bytes.Repeat([]byte{0x03}, 33), nil)
+	serializeHop(&b, dest, paymentAddr)
+
+	_ = binary.Write(&b, byteOrder, uint64(time.Unix(2, 0).UnixNano()))
+
+	return b.String()
+}
+
+// creationInfoWithFields returns the given serialized creation info with the
+// passed optional fields appended.
+func creationInfoWithFields(t *testing.T, creationInfo string, dest []byte,
+	paymentAddr *[32]byte, desc string) string {
+
+	var (
+		records   []tlv.Record
+		destBytes [33]byte
+		descBytes = []byte(desc)
+	)
+	if dest != nil {
+		copy(destBytes[:], dest)
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoDestType, &destBytes,
+		))
+	}
+	if paymentAddr != nil {
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoPaymentAddrType, paymentAddr,
+		))
+	}
+	if desc != "" {
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoDescriptionType, &descBytes,
+		))
+	}
+
+	tlvStream, err := tlv.NewStream(records...)
+	require.NoError(t, err)
+
+	b := bytes.NewBufferString(creationInfo)
+	require.NoError(t, tlvStream.Encode(b))
+
+	return b.String()
+}
+
+// lookupKey returns the lookup index key of the given property of a payment.
+func lookupKey(lookupType byte, value []byte, seqNum string) string {
+	if lookupType == lookupDescription {
+		hash := sha256.Sum256(value)
+		value = hash[:]
+	}
+
+	return string([]byte{lookupType}) + string(value) + seqNum
+}
+
+// TestMigratePaymentLookupIndex asserts that payments are added to the new
+// payments lookup index, and that their creation info is extended with the
+// properties they are indexed by.
+func TestMigratePaymentLookupIndex(t *testing.T) {
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	dest := privKey.PubKey().SerializeCompressed()
+
+	// Create a regtest payment request with a description, which the
+	// migration has to decode without knowing the network.
+	var payHash [32]byte
+	copy(payHash[:], hash2)
+	invoice, err := zpay32.NewInvoice(
+		&chaincfg.RegressionNetParams, payHash, time.Unix(1, 0),
+		zpay32.Description(description), zpay32.PaymentAddr(payAddr),
+	)
+	require.NoError(t, err)
+
+	payReq, err := invoice.Encode(zpay32.MessageSigner{
+		SignCompact: func(msg []byte) ([]byte, error) {
+			return btcec.SignCompact(
+				btcec.S256(), privKey, chainhash.HashB(msg),
+				true,
+			)
+		},
+	})
+	require.NoError(t, err)
+
+	keysendDest := bytes.Repeat([]byte{0x04}, 33)
+
+	creationInfo1 := serializeCreationInfo(hash1, "")
+	creationInfo2 := serializeCreationInfo(hash2, payReq)
+	creationInfo3 := serializeCreationInfo(hash3, payReq)
+	creationInfo4 := serializeCreationInfo(hash4, "")
+
+	htlcs1 := map[string]interface{}{
+		attemptID1: map[string]interface{}{
+			string(htlcAttemptInfoKey): serializeAttemptInfo(
+				keysendDest, nil,
+			),
+		},
+	}
+	htlcs2 := map[string]interface{}{
+		attemptID1: map[string]interface{}{
+			string(htlcAttemptInfoKey): serializeAttemptInfo(
+				dest, &payAddr,
+			),
+		},
+		attemptID2: map[string]interface{}{
+			string(htlcAttemptInfoKey): serializeAttemptInfo(
+				dest, &payAddr,
+			),
+		},
+	}
+
+	payments := map[string]interface{}{
+		// A keysend payment, which is only indexed by the destination
+		// of its route.
+		hash1: map[string]interface{}{
+			string(paymentSequenceKey):     paymentID1,
+			string(paymentCreationInfoKey): creationInfo1,
+			string(paymentHtlcsBucket):     htlcs1,
+		},
+
+		// A payment of a payment request with htlc attempts.
+		hash2: map[string]interface{}{
+			string(paymentSequenceKey):     paymentID2,
+			string(paymentCreationInfoKey): creationInfo2,
+			string(paymentHtlcsBucket):     htlcs2,
+		},
+
+		// A payment of a payment request without htlc attempts.
+		hash3: map[string]interface{}{
+			string(paymentSequenceKey):     paymentID3,
+			string(paymentCreationInfoKey): creationInfo3,
+		},
+
+		// A payment without any of the properties.
+		hash4: map[string]interface{}{
+			string(paymentSequenceKey):     paymentID4,
+			string(paymentCreationInfoKey): creationInfo4,
+		},
+	}
+
+	before := func(tx kvdb.RwTx) error {
+		return migtest.RestoreDB(tx, paymentsRootBucket, payments)
+	}
+
+	postPayments := map[string]interface{}{
+		hash1: map[string]interface{}{
+			string(paymentSequenceKey): paymentID1,
+			string(paymentCreationInfoKey): creationInfoWithFields(
+				t, creationInfo1, keysendDest, nil, "",
+			),
+			string(paymentHtlcsBucket): htlcs1,
+		},
+		hash2: map[string]interface{}{
+			string(paymentSequenceKey): paymentID2,
+			string(paymentCreationInfoKey): creationInfoWithFields(
+				t, creationInfo2, dest, &payAddr, description,
+			),
+			string(paymentHtlcsBucket): htlcs2,
+		},
+		hash3: map[string]interface{}{
+			string(paymentSequenceKey): paymentID3,
+			string(paymentCreationInfoKey): creationInfoWithFields(
+				t, creationInfo3, dest, &payAddr, description,
+			),
+		},
+		hash4: payments[hash4],
+	}
+
+	descBytes := []byte(description)
+	postLookupIndex := map[string]interface{}{
+		lookupKey(lookupDest, keysendDest, paymentID1):       "",
+		lookupKey(lookupDest, dest, paymentID2):              "",
+		lookupKey(lookupPaymentAddr, payAddr[:], paymentID2): "",
+		lookupKey(lookupDescription, descBytes, paymentID2):  "",
+		lookupKey(lookupDest, dest, paymentID3):              "",
+		lookupKey(lookupPaymentAddr, payAddr[:], paymentID3): "",
+		lookupKey(lookupDescription, descBytes, paymentID3):  "",
+	}
+
+	after := func(tx kvdb.RwTx) error {
+		err := migtest.VerifyDB(tx, paymentsRootBucket, postPayments)
+		if err != nil {
+			return err
+		}
+
+		return migtest.VerifyDB(
+			tx, paymentsLookupIndexBucket, postLookupIndex,
+		)
+	}
+
+	migtest.ApplyMigration(
+		t, before, after, MigratePaymentLookupIndex, false,
+	)
+}
+
+// TestMigratePaymentLookupIndexEmpty asserts that the migration creates the
+// lookup index on a database without payments.
+func TestMigratePaymentLookupIndexEmpty(t *testing.T) {
+	after := func(tx kvdb.RwTx) error {
+		return migtest.VerifyDB(tx, paymentsLookupIndexBucket, nil)
+	}
+
+	migtest.ApplyMigration(
+		t, func(kvdb.RwTx) error { return nil }, after,
+		MigratePaymentLookupIndex, false,
+	)
+}
@@ -114,7 +114,20 @@ func (p paginator) cursorStart() ([]byte, []byte) {
 // whether the item was added. This is required to allow the paginator to
 // determine when the response has the maximum number of required items.
 func (p paginator) query(fetchAndAppend func(k, v []byte) (bool, error)) error {
-	indexKey, indexValue := p.cursorStart()
+	// Cursors that aren't ordered by their keys position themselves
+	// relative to our index offset.
+	var indexKey, indexValue []byte
+	if seeker, ok := p.cursor.(offsetSeeker); ok {
+		var err error
+		indexKey, indexValue, err = seeker.seekOffset(
+			p.indexOffset, p.reversed,
+		)
+		if err != nil {
+			return err
+		}
+	} else {
+		indexKey, indexValue = p.cursorStart()
+	}
 
 	var totalItems int
 	for ; indexKey != nil; indexKey, indexValue = p.nextKey() {
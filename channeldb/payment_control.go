@@ -80,6 +80,10 @@ var (
 	// index is made for a sequence number that is not indexed.
 	errNoSequenceNrIndex = errors.New("payment sequence number index " +
 		"does not exist")
+
+	// errNoLookupIndex is returned when the payments lookup index bucket
+	// doesn't exist, which means the database wasn't migrated.
+	errNoLookupIndex = errors.New("payments lookup index does not exist")
 )
 
 // PaymentControl implements persistence for payments and payment attempts.
@@ -169,6 +173,37 @@ func (p *PaymentControl) InitPayment(paymentHash lntypes.Hash,
 			if err := indexBucket.Delete(seqBytes); err != nil {
 				return err
 			}
+
+			timeKey, err := paymentTimeIndexKey(bucket)
+			if err != nil {
+				return err
+			}
+
+			timeIndex := tx.ReadWriteBucket(
+				paymentsCreationTimeIndexBucket,
+			)
+			if timeKey != nil {
+				if err := timeIndex.Delete(timeKey); err != nil {
+					return err
+				}
+			}
+
+			lookupKeys, err := fetchPaymentLookupIndexKeys(bucket)
+			if err != nil {
+				return err
+			}
+
+			lookupIndex := tx.ReadWriteBucket(
+				paymentsLookupIndexBucket,
+			)
+			if lookupIndex == nil {
+				return errNoLookupIndex
+			}
+			for _, k := range lookupKeys {
+				if err := lookupIndex.Delete(k); err != nil {
+					return err
+				}
+			}
 		}
 
 		// Once we have obtained a sequence number, we add an entry
@@ -181,6 +216,30 @@ func (p *PaymentControl) InitPayment(paymentHash lntypes.Hash,
 			return err
 		}
 
+		// Index the payment by its creation time, so that it can be
+		// queried by date range.
+		timeIndex := tx.ReadWriteBucket(paymentsCreationTimeIndexBucket)
+		err = timeIndex.Put(
+			timeIndexKey(
+				info.CreationTime, byteOrder.Uint64(sequenceNum),
+			), nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		// Index the payment by its destination, payment address,
+		// description and label, so that it can be looked up by them.
+		lookupIndex := tx.ReadWriteBucket(paymentsLookupIndexBucket)
+		if lookupIndex == nil {
+			return errNoLookupIndex
+		}
+		for _, k := range paymentLookupIndexKeys(info, sequenceNum) {
+			if err := lookupIndex.Put(k, nil); err != nil {
+				return err
+			}
+		}
+
 		err = bucket.Put(paymentSequenceKey, sequenceNum)
 		if err != nil {
 			return err
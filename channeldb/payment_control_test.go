@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -768,6 +770,230 @@ func TestPaymentControlDeletePayments(t *testing.T) {
 	})
 }
 
+// TestPaymentControlDeleteSinglePayment tests that DeletePayment deletes a
+// single completed payment, and archives it before doing so.
+func TestPaymentControlDeleteSinglePayment(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	pControl := NewPaymentControl(db)
+
+	// Create two failed payments and an in-flight one.
+	var infos []*PaymentCreationInfo
+	for i := 0; i < 3; i++ {
+		info, _, _, err := genInfo()
+		require.NoError(t, err)
+
+		err = pControl.InitPayment(info.PaymentIdentifier, info)
+		require.NoError(t, err)
+
+		if i < 2 {
+			_, err = pControl.Fail(
+				info.PaymentIdentifier, FailureReasonNoRoute,
+			)
+			require.NoError(t, err)
+		}
+
+		infos = append(infos, info)
+	}
+
+	var archived []*MPPayment
+	archive := func(payments []*MPPayment) error {
+		archived = append(archived, payments...)
+		return nil
+	}
+
+	// Deleting an unknown payment or an in-flight one fails.
+	var unknownHash lntypes.Hash
+	err = db.DeletePayment(unknownHash, false, archive)
+	require.ErrorIs(t, err, ErrPaymentNotInitiated)
+
+	err = db.DeletePayment(infos[2].PaymentIdentifier, false, archive)
+	require.ErrorIs(t, err, ErrPaymentInFlight)
+	require.Empty(t, archived)
+
+	// The first payment is archived and deleted, while the other two are
+	// kept.
+	err = db.DeletePayment(infos[0].PaymentIdentifier, false, archive)
+	require.NoError(t, err)
+
+	require.Len(t, archived, 1)
+	require.Equal(t, infos[0].PaymentIdentifier,
+		archived[0].Info.PaymentIdentifier)
+	require.Equal(t, StatusFailed, archived[0].Status)
+
+	dbPayments, err := db.FetchPayments()
+	require.NoError(t, err)
+	require.Len(t, dbPayments, 2)
+	require.Equal(t, infos[1].PaymentIdentifier,
+		dbPayments[0].Info.PaymentIdentifier)
+
+	assertNoIndex(t, pControl, 1)
+	assertPaymentIndex(t, pControl, infos[1].PaymentIdentifier)
+
+	// A failing archive function prevents the deletion.
+	errArchive := errors.New("archive failed")
+	err = db.DeletePayment(
+		infos[1].PaymentIdentifier, false, func([]*MPPayment) error {
+			return errArchive
+		},
+	)
+	require.ErrorIs(t, err, errArchive)
+
+	dbPayments, err = db.FetchPayments()
+	require.NoError(t, err)
+	require.Len(t, dbPayments, 2)
+}
+
+// TestDeleteFilteredPayments tests that DeleteFilteredPayments only deletes
+// the payments that were created before the given time, and archives them.
+func TestDeleteFilteredPayments(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	pControl := NewPaymentControl(db)
+	start := time.Unix(1000, 0)
+
+	// Create four failed payments that were created an hour apart from
+	// each other.
+	const numPayments = 4
+	var infos []*PaymentCreationInfo
+	for i := 0; i < numPayments; i++ {
+		info, _, _, err := genInfo()
+		require.NoError(t, err)
+
+		info.CreationTime = start.Add(time.Duration(i) * time.Hour)
+		err = pControl.InitPayment(info.PaymentIdentifier, info)
+		require.NoError(t, err)
+
+		_, err = pControl.Fail(
+			info.PaymentIdentifier, FailureReasonNoRoute,
+		)
+		require.NoError(t, err)
+
+		infos = append(infos, info)
+	}
+
+	var archived []lntypes.Hash
+	numDeleted, err := db.DeleteFilteredPayments(PaymentsDeleteQuery{
+		CreatedBefore: start.Add(2 * time.Hour),
+		Archive: func(payments []*MPPayment) error {
+			for _, payment := range payments {
+				archived = append(
+					archived, payment.Info.PaymentIdentifier,
+				)
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, numDeleted)
+
+	require.Equal(t, []lntypes.Hash{
+		infos[0].PaymentIdentifier, infos[1].PaymentIdentifier,
+	}, archived)
+
+	dbPayments, err := db.FetchPayments()
+	require.NoError(t, err)
+	require.Len(t, dbPayments, 2)
+	for i, payment := range dbPayments {
+		require.Equal(t, infos[i+2].PaymentIdentifier,
+			payment.Info.PaymentIdentifier)
+	}
+
+	// The deleted payments are removed from the creation time index.
+	resp, err := db.QueryPayments(PaymentsQuery{
+		MaxPayments:       math.MaxUint64,
+		IncludeIncomplete: true,
+		CreationDateStart: start,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Payments, 2)
+
+	// Nothing else was created before the same time.
+	numDeleted, err = db.DeleteFilteredPayments(PaymentsDeleteQuery{
+		CreatedBefore: start.Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Zero(t, numDeleted)
+}
+
+// TestDeletePaymentsArchiveSnapshot tests that payments are archived outside
+// of the transaction that deletes them, and that payments that are initialized
+// again after they were archived are kept.
+func TestDeletePaymentsArchiveSnapshot(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	pControl := NewPaymentControl(db)
+
+	// Create two failed payments.
+	var infos []*PaymentCreationInfo
+	for i := 0; i < 2; i++ {
+		info, _, _, err := genInfo()
+		require.NoError(t, err)
+
+		err = pControl.InitPayment(info.PaymentIdentifier, info)
+		require.NoError(t, err)
+
+		_, err = pControl.Fail(
+			info.PaymentIdentifier, FailureReasonNoRoute,
+		)
+		require.NoError(t, err)
+
+		infos = append(infos, info)
+	}
+
+	// While the payments are archived, the second one is retried and
+	// fails again. As the archive is written outside of any database
+	// transaction, it can modify the database without blocking.
+	var archived []*MPPayment
+	archive := func(payments []*MPPayment) error {
+		archived = append(archived, payments...)
+
+		hash := infos[1].PaymentIdentifier
+		err := pControl.InitPayment(hash, infos[1])
+		if err != nil {
+			return err
+		}
+
+		_, err = pControl.Fail(hash, FailureReasonNoRoute)
+		return err
+	}
+
+	numDeleted, err := db.DeleteFilteredPayments(PaymentsDeleteQuery{
+		Archive: archive,
+	})
+	require.NoError(t, err)
+	require.Len(t, archived, 2)
+
+	// Only the first payment is deleted. The retried payment wasn't
+	// archived in its current state, so it is kept.
+	require.Equal(t, 1, numDeleted)
+
+	dbPayments, err := db.FetchPayments()
+	require.NoError(t, err)
+	require.Len(t, dbPayments, 1)
+	require.Equal(t, infos[1].PaymentIdentifier,
+		dbPayments[0].Info.PaymentIdentifier)
+
+	for _, payment := range archived {
+		if payment.Info.PaymentIdentifier != infos[1].PaymentIdentifier {
+			continue
+		}
+		require.Less(t, payment.SequenceNum, dbPayments[0].SequenceNum)
+	}
+}
+
 // TestPaymentControlMultiShard checks the ability of payment control to
 // have multiple in-flight HTLCs for a single payment.
 func TestPaymentControlMultiShard(t *testing.T) {
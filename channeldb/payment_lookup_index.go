@@ -0,0 +1,225 @@
+package channeldb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+// paymentLookupType is the type of a payment property that payments are
+// indexed by in the payments lookup index.
+type paymentLookupType byte
+
+const (
+	// paymentLookupDest indexes payments by their destination.
+	paymentLookupDest paymentLookupType = 0
+
+	// paymentLookupPaymentAddr indexes payments by their payment address.
+	paymentLookupPaymentAddr paymentLookupType = 1
+
+	// paymentLookupDescription indexes payments by the description of the
+	// invoice that is paid.
+	paymentLookupDescription paymentLookupType = 2
+
+	// paymentLookupLabel indexes payments by their label.
+	paymentLookupLabel paymentLookupType = 3
+)
+
+// paymentLookupPrefix returns the prefix of the lookup index keys of all
+// payments with the given property value. Descriptions and labels are hashed,
+// so that all prefixes of a type have the same length.
+func paymentLookupPrefix(t paymentLookupType, value []byte) []byte {
+	if t == paymentLookupDescription || t == paymentLookupLabel {
+		hash := sha256.Sum256(value)
+		value = hash[:]
+	}
+
+	prefix := make([]byte, 0, 1+len(value)+8)
+	prefix = append(prefix, byte(t))
+	return append(prefix, value...)
+}
+
+// paymentLookupIndexKeys returns the lookup index keys of the payment with the
+// given creation info and sequence number. Lookup index keys consist of the
+// prefix of a property value followed by the sequence number of the payment.
+func paymentLookupIndexKeys(info *PaymentCreationInfo,
+	seqNum []byte) [][]byte {
+
+	var prefixes [][]byte
+	if info.Destination != (route.Vertex{}) {
+		prefixes = append(prefixes, paymentLookupPrefix(
+			paymentLookupDest, info.Destination[:],
+		))
+	}
+	if info.PaymentAddr != nil {
+		prefixes = append(prefixes, paymentLookupPrefix(
+			paymentLookupPaymentAddr, info.PaymentAddr[:],
+		))
+	}
+	if info.Description != "" {
+		prefixes = append(prefixes, paymentLookupPrefix(
+			paymentLookupDescription, []byte(info.Description),
+		))
+	}
+	if info.Label != "" {
+		prefixes = append(prefixes, paymentLookupPrefix(
+			paymentLookupLabel, []byte(info.Label),
+		))
+	}
+
+	keys := make([][]byte, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		keys = append(keys, append(prefix, seqNum...))
+	}
+
+	return keys
+}
+
+// paymentLookupRange returns the sequence numbers of all payments in the given
+// lookup index whose keys start with the given prefix, sorted in ascending
+// order.
+func paymentLookupRange(lookupIndex kvdb.RBucket, prefix []byte) []uint64 {
+	var seqNums []uint64
+	cursor := lookupIndex.ReadCursor()
+	for k, _ := cursor.Seek(prefix); bytes.HasPrefix(k, prefix); k, _ =
+		cursor.Next() {
+
+		if len(k) != len(prefix)+8 {
+			continue
+		}
+
+		seqNums = append(seqNums, byteOrder.Uint64(k[len(prefix):]))
+	}
+
+	return seqNums
+}
+
+// intersectSeqNums returns the sequence numbers that are part of both of the
+// given lists, which must be sorted in ascending order.
+func intersectSeqNums(a, b []uint64) []uint64 {
+	var seqNums []uint64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+
+		case a[i] > b[j]:
+			j++
+
+		default:
+			seqNums = append(seqNums, a[i])
+			i++
+			j++
+		}
+	}
+
+	return seqNums
+}
+
+// fetchPaymentLookupIndexKeys returns the lookup index keys of the payment
+// stored in the given bucket, or nil if the payment hasn't been initialized.
+// Duplicate payments predate the lookup index and are never indexed.
+func fetchPaymentLookupIndexKeys(paymentBucket kvdb.RBucket) ([][]byte,
+	error) {
+
+	seqNum := paymentBucket.Get(paymentSequenceKey)
+	infoBytes := paymentBucket.Get(paymentCreationInfoKey)
+	if seqNum == nil || infoBytes == nil {
+		return nil, nil
+	}
+
+	info, err := deserializePaymentCreationInfo(
+		bytes.NewReader(infoBytes),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return paymentLookupIndexKeys(info, seqNum), nil
+}
+
+// indexCursor is a read-only cursor over a subset of the entries of an index
+// bucket that is keyed by big-endian sequence numbers. It is used to paginate
+// over the payments selected through the lookup index in the same way as over
+// the index bucket itself.
+type indexCursor struct {
+	keys   [][]byte
+	values [][]byte
+	pos    int
+}
+
+// A compile-time check to ensure indexCursor implements the kvdb.RCursor
+// interface.
+var _ kvdb.RCursor = (*indexCursor)(nil)
+
+// newIndexCursor creates a cursor over the entries of the given index bucket
+// with the given sequence numbers, which must be sorted in ascending order.
+// Sequence numbers that aren't part of the index are skipped.
+func newIndexCursor(index kvdb.RBucket, seqNums []uint64) *indexCursor {
+	c := &indexCursor{}
+	for _, seqNum := range seqNums {
+		key := make([]byte, 8)
+		byteOrder.PutUint64(key, seqNum)
+
+		value := index.Get(key)
+		if value == nil {
+			continue
+		}
+
+		c.keys = append(c.keys, key)
+		c.values = append(c.values, value)
+	}
+
+	return c
+}
+
+// current returns the key/value pair at the current cursor position, or nil
+// if the cursor is positioned outside of its entries.
+func (c *indexCursor) current() ([]byte, []byte) {
+	if c.pos < 0 || c.pos >= len(c.keys) {
+		return nil, nil
+	}
+
+	return c.keys[c.pos], c.values[c.pos]
+}
+
+// First positions the cursor at the first key/value pair and returns the
+// pair.
+func (c *indexCursor) First() ([]byte, []byte) {
+	c.pos = 0
+	return c.current()
+}
+
+// Last positions the cursor at the last key/value pair and returns the pair.
+func (c *indexCursor) Last() ([]byte, []byte) {
+	c.pos = len(c.keys) - 1
+	return c.current()
+}
+
+// Next moves the cursor one key/value pair forward and returns the new pair.
+func (c *indexCursor) Next() ([]byte, []byte) {
+	if c.pos < len(c.keys) {
+		c.pos++
+	}
+	return c.current()
+}
+
+// Prev moves the cursor one key/value pair backward and returns the new pair.
+func (c *indexCursor) Prev() ([]byte, []byte) {
+	if c.pos >= 0 {
+		c.pos--
+	}
+	return c.current()
+}
+
+// Seek positions the cursor at the passed seek key. If the key does not exist,
+// the cursor is moved to the next key after seek. Returns the new pair.
+func (c *indexCursor) Seek(seek []byte) ([]byte, []byte) {
+	c.pos = sort.Search(len(c.keys), func(i int) bool {
+		return bytes.Compare(c.keys[i], seek) >= 0
+	})
+	return c.current()
+}
@@ -101,6 +101,35 @@ var (
 	// 	|--...
 	// 	|--<sequence-number>: <payment hash>
 	paymentsIndexBucket = []byte("payments-index-bucket")
+
+	// paymentsCreationTimeIndexBucket is the name of the top-level bucket
+	// within the database that indexes payments by their creation time.
+	// It allows querying payments by creation date without scanning all
+	// of them.
+	//
+	// maps: creationTime || sequenceNumber => nil
+	paymentsCreationTimeIndexBucket = []byte("payments-creation-time-index")
+
+	// paymentsLookupIndexBucket is the name of the top-level bucket within
+	// the database that indexes payments by their destination, payment
+	// address, invoice description and label. It allows looking up the
+	// payments with one of these properties without scanning all of them.
+	// Descriptions and labels are indexed by their SHA256 hash.
+	//
+	// maps: type || value || sequenceNumber => nil
+	paymentsLookupIndexBucket = []byte("payments-lookup-index")
+)
+
+const (
+	// The tlv types of the optional fields of the payment creation info,
+	// which are appended to its fixed fields.
+	creationInfoDestType        tlv.Type = 1
+	creationInfoPaymentAddrType tlv.Type = 3
+	creationInfoDescriptionType tlv.Type = 5
+	creationInfoLabelType       tlv.Type = 7
+
+	// MaxPaymentLabelLength is the maximum length of a payment label.
+	MaxPaymentLabelLength = 500
 )
 
 var (
@@ -228,6 +257,21 @@ type PaymentCreationInfo struct {
 
 	// PaymentRequest is the full payment request, if any.
 	PaymentRequest []byte
+
+	// Destination is the node that the payment is sent to. It is unset
+	// for payments that were created before the destination was stored.
+	Destination route.Vertex
+
+	// PaymentAddr is the payment address of the payment, if any.
+	PaymentAddr *[32]byte
+
+	// Description is the description of the invoice that is paid, if
+	// any.
+	Description string
+
+	// Label is an optional label that was supplied by the user when the
+	// payment was sent.
+	Label string
 }
 
 // FetchPayments returns all sent payments found in the DB.
@@ -458,6 +502,12 @@ func fetchHtlcFailInfo(bucket kvdb.RBucket) (*HTLCFailInfo, error) {
 
 // PaymentsQuery represents a query to the payments database starting or ending
 // at a certain offset index. The number of retrieved records can be limited.
+//
+// The creation date range and the destination, payment address, description
+// and label filters are served by indexes. If only the creation date range is
+// set, the payments are returned in the order of their creation time, and the
+// query continues after the payment at the offset in that order. The status
+// and value filters are checked against each payment that is read.
 type PaymentsQuery struct {
 	// IndexOffset determines the starting point of the payments query and
 	// is always exclusive. In normal order, the query starts at the next
@@ -482,6 +532,131 @@ type PaymentsQuery struct {
 	// fully completed. This means that pending payments, as well as failed
 	// payments will show up if this field is set to true.
 	IncludeIncomplete bool
+
+	// CreationDateStart, if set, only returns payments that were created
+	// at or after this time.
+	CreationDateStart time.Time
+
+	// CreationDateEnd, if set, only returns payments that were created at
+	// or before this time.
+	CreationDateEnd time.Time
+
+	// Statuses, if set, only returns payments with one of the given
+	// statuses. This takes precedence over IncludeIncomplete.
+	Statuses []PaymentStatus
+
+	// MinValue, if set, only returns payments with a value of at least
+	// this amount.
+	MinValue lnwire.MilliSatoshi
+
+	// MaxValue, if set, only returns payments with a value of at most this
+	// amount.
+	MaxValue lnwire.MilliSatoshi
+
+	// Destination, if set, only returns payments to this node.
+	Destination *route.Vertex
+
+	// PaymentAddr, if set, only returns payments with this payment
+	// address.
+	PaymentAddr *[32]byte
+
+	// Description, if set, only returns payments of invoices with exactly
+	// this description.
+	Description string
+
+	// Label, if set, only returns payments with exactly this label.
+	Label string
+}
+
+// lookupPrefixes returns the payments lookup index prefixes of the property
+// filters of the query.
+func (q *PaymentsQuery) lookupPrefixes() [][]byte {
+	var prefixes [][]byte
+	if q.Destination != nil {
+		prefixes = append(prefixes, paymentLookupPrefix(
+			paymentLookupDest, q.Destination[:],
+		))
+	}
+	if q.PaymentAddr != nil {
+		prefixes = append(prefixes, paymentLookupPrefix(
+			paymentLookupPaymentAddr, q.PaymentAddr[:],
+		))
+	}
+	if q.Description != "" {
+		prefixes = append(prefixes, paymentLookupPrefix(
+			paymentLookupDescription, []byte(q.Description),
+		))
+	}
+	if q.Label != "" {
+		prefixes = append(prefixes, paymentLookupPrefix(
+			paymentLookupLabel, []byte(q.Label),
+		))
+	}
+
+	return prefixes
+}
+
+// filterCreationDate returns true if the query filters payments by creation
+// date.
+func (q *PaymentsQuery) filterCreationDate() bool {
+	return !q.CreationDateStart.IsZero() || !q.CreationDateEnd.IsZero()
+}
+
+// matches returns true if the given payment matches all filters of the query.
+func (q *PaymentsQuery) matches(payment *MPPayment) bool {
+	switch {
+	// If the caller asked for specific statuses, only return payments
+	// with one of those.
+	case len(q.Statuses) > 0:
+		var statusMatches bool
+		for _, status := range q.Statuses {
+			if payment.Status == status {
+				statusMatches = true
+				break
+			}
+		}
+
+		if !statusMatches {
+			return false
+		}
+
+	// To keep compatibility with the old API, we only return
+	// non-succeeded payments if requested.
+	case payment.Status != StatusSucceeded && !q.IncludeIncomplete:
+		return false
+	}
+
+	if q.filterCreationDate() && !inTimeRange(
+		payment.Info.CreationTime, q.CreationDateStart,
+		q.CreationDateEnd,
+	) {
+		return false
+	}
+
+	if q.MinValue != 0 && payment.Info.Value < q.MinValue {
+		return false
+	}
+
+	if q.MaxValue != 0 && payment.Info.Value > q.MaxValue {
+		return false
+	}
+
+	info := payment.Info
+	if q.Destination != nil && info.Destination != *q.Destination {
+		return false
+	}
+
+	if q.PaymentAddr != nil && (info.PaymentAddr == nil ||
+		*info.PaymentAddr != *q.PaymentAddr) {
+
+		return false
+	}
+
+	if q.Description != "" && info.Description != q.Description {
+		return false
+	}
+
+	return q.Label == "" || info.Label == q.Label
 }
 
 // PaymentsResponse contains the result of a query to the payments database.
@@ -548,12 +723,10 @@ func (db *DB) QueryPayments(query PaymentsQuery) (PaymentsResponse, error) {
 				return false, err
 			}
 
-			// To keep compatibility with the old API, we only
-			// return non-succeeded payments if requested.
-			if payment.Status != StatusSucceeded &&
-				!query.IncludeIncomplete {
-
-				return false, err
+			// Skip any payments that don't match the filters of
+			// the query.
+			if !query.matches(payment) {
+				return false, nil
 			}
 
 			// At this point, we've exhausted the offset, so we'll
@@ -562,10 +735,62 @@ func (db *DB) QueryPayments(query PaymentsQuery) (PaymentsResponse, error) {
 			return true, nil
 		}
 
+		// If the query filters payments by destination, payment
+		// address, description or label, we only iterate over the
+		// payments found in the lookup index for all of them. Any date
+		// range is checked when the payments are fetched.
+		var (
+			cursor   kvdb.RCursor = indexes.ReadCursor()
+			seqNums  []uint64
+			filtered bool
+		)
+		lookupIndex := tx.ReadBucket(paymentsLookupIndexBucket)
+		for _, prefix := range query.lookupPrefixes() {
+			var matches []uint64
+			if lookupIndex != nil {
+				matches = paymentLookupRange(lookupIndex, prefix)
+			}
+
+			if filtered {
+				matches = intersectSeqNums(seqNums, matches)
+			}
+			seqNums = matches
+			filtered = true
+		}
+
+		switch {
+		case filtered:
+			cursor = newIndexCursor(indexes, seqNums)
+
+		// Otherwise, if the query filters payments by creation date,
+		// we only iterate over the payments within the date range,
+		// which we look up through the creation time index.
+		case query.filterCreationDate():
+			timeIndex := tx.ReadBucket(
+				paymentsCreationTimeIndexBucket,
+			)
+			if timeIndex == nil {
+				return nil
+			}
+
+			// To resume from an index offset, we look up the time
+			// index key of the payment at the offset.
+			timeKey := func(seqKey []byte) ([]byte, error) {
+				return fetchPaymentTimeIndexKey(
+					tx, indexes, seqKey,
+				)
+			}
+
+			cursor = newTimeIndexCursor(
+				timeIndex, indexes, query.CreationDateStart,
+				query.CreationDateEnd, timeKey,
+			)
+		}
+
 		// Create a paginator which reads from our sequence index bucket
 		// with the parameters provided by the payments query.
 		paginator := newPaginator(
-			indexes.ReadCursor(), query.Reversed, query.IndexOffset,
+			cursor, query.Reversed, query.IndexOffset,
 			query.MaxPayments,
 		)
 
@@ -677,31 +902,59 @@ func fetchPaymentWithSequenceNumber(tx kvdb.RTx, paymentHash lntypes.Hash,
 	return duplicatePayment, nil
 }
 
+// PaymentsDeleteQuery describes the payments that are removed by
+// DeleteFilteredPayments. In-flight payments are never deleted.
+type PaymentsDeleteQuery struct {
+	// FailedOnly, if set, restricts deletion to failed payments.
+	FailedOnly bool
+
+	// FailedHtlcsOnly, if set, causes only the failed htlc attempts of the
+	// selected payments to be deleted, rather than the payments
+	// themselves.
+	FailedHtlcsOnly bool
+
+	// CreatedBefore, if set, restricts deletion to payments that were
+	// created before this time.
+	CreatedBefore time.Time
+
+	// Archive, if set, is called with all payments that are about to be
+	// deleted or to lose their failed htlc attempts, in their state
+	// before the deletion. Legacy duplicate payments that are deleted
+	// along with a payment are passed as well. If Archive returns an
+	// error, no payments are deleted.
+	//
+	// NOTE: Archive is called with the payments read from a snapshot of
+	// the database, before the transaction that deletes them. Only the
+	// payments that are unchanged since are deleted. If the deletion
+	// fails, a later attempt may pass the same payments again.
+	Archive func([]*MPPayment) error
+}
+
 // DeletePayments deletes all completed and failed payments from the DB. If
 // failedOnly is set, only failed payments will be considered for deletion. If
 // failedHtlsOnly is set, the payment itself won't be deleted, only failed HTLC
 // attempts.
 func (db *DB) DeletePayments(failedOnly, failedHtlcsOnly bool) error {
-	return kvdb.Update(db, func(tx kvdb.RwTx) error {
-		payments := tx.ReadWriteBucket(paymentsRootBucket)
+	_, err := db.DeleteFilteredPayments(PaymentsDeleteQuery{
+		FailedOnly:      failedOnly,
+		FailedHtlcsOnly: failedHtlcsOnly,
+	})
+	return err
+}
+
+// DeleteFilteredPayments deletes all completed and failed payments that match
+// the given query, and returns the number of payments that were deleted or
+// that had their failed htlc attempts deleted.
+func (db *DB) DeleteFilteredPayments(q PaymentsDeleteQuery) (int, error) {
+	selectPayments := func(tx kvdb.RTx, deletion *paymentDeletion) error {
+		payments := tx.ReadBucket(paymentsRootBucket)
 		if payments == nil {
 			return nil
 		}
 
-		var (
-			// deleteBuckets is the set of payment buckets we need
-			// to delete.
-			deleteBuckets [][]byte
-
-			// deleteIndexes is the set of indexes pointing to these
-			// payments that need to be deleted.
-			deleteIndexes [][]byte
-
-			// deleteHtlcs maps a payment hash to the HTLC IDs we
-			// want to delete for that payment.
-			deleteHtlcs = make(map[lntypes.Hash][][]byte)
-		)
-		err := payments.ForEach(func(k, _ []byte) error {
+		// selectPayment adds the payment with the given hash to the
+		// deletion if it matches the query.
+		selectPayment := func(k []byte) error {
 			bucket := payments.NestedReadBucket(k)
 			if bucket == nil {
 				// We only expect sub-buckets to be found in
@@ -710,8 +963,6 @@ func (db *DB) DeletePayments(failedOnly, failedHtlcsOnly bool) error {
 					"payments bucket")
 			}
 
-			// If the status is InFlight, we cannot safely delete
-			// the payment information, so we return early.
 			paymentStatus, err := fetchPaymentStatus(bucket)
 			if err != nil {
 				return err
@@ -725,102 +976,446 @@ func (db *DB) DeletePayments(failedOnly, failedHtlcsOnly bool) error {
 
 			// If we requested to only delete failed payments, we
 			// can return if this one is not.
-			if failedOnly && paymentStatus != StatusFailed {
+			if q.FailedOnly && paymentStatus != StatusFailed {
 				return nil
 			}
 
-			// If we are only deleting failed HTLCs, fetch them.
-			if failedHtlcsOnly {
-				htlcsBucket := bucket.NestedReadBucket(
-					paymentHtlcsBucket,
-				)
+			if !q.CreatedBefore.IsZero() {
+				info, err := fetchCreationInfo(bucket)
+				if err != nil {
+					return err
+				}
 
-				var htlcs []HTLCAttempt
-				if htlcsBucket != nil {
-					htlcs, err = fetchHtlcAttempts(
-						htlcsBucket,
-					)
-					if err != nil {
-						return err
-					}
+				if !info.CreationTime.Before(q.CreatedBefore) {
+					return nil
 				}
+			}
 
-				// Now iterate though them and save the bucket
-				// keys for the failed HTLCs.
-				var toDelete [][]byte
-				for _, h := range htlcs {
-					if h.Failure == nil {
-						continue
-					}
+			return deletion.add(k, bucket)
+		}
 
-					htlcIDBytes := make([]byte, 8)
-					binary.BigEndian.PutUint64(
-						htlcIDBytes, h.AttemptID,
-					)
+		// Without a time bound, we need to consider all payments.
+		if q.CreatedBefore.IsZero() {
+			return payments.ForEach(func(k, _ []byte) error {
+				return selectPayment(k)
+			})
+		}
 
-					toDelete = append(toDelete, htlcIDBytes)
-				}
+		// Otherwise, we use the creation time index to only consider
+		// the payments that were created before the given time.
+		selectHash := func(hash lntypes.Hash) error {
+			return selectPayment(hash[:])
+		}
+		return forEachPaymentCreatedBefore(
+			tx, q.CreatedBefore, selectHash,
+		)
+	}
 
-				hash, err := lntypes.MakeHash(k)
-				if err != nil {
-					return err
-				}
+	return db.deletePayments(q.FailedHtlcsOnly, q.Archive, selectPayments)
+}
 
-				deleteHtlcs[hash] = toDelete
+// DeletePayment deletes the payment with the given hash from the DB. If
+// failedHtlcsOnly is set, the payment itself won't be deleted, only its failed
+// HTLC attempts. If archive is set, it is called with the payment and its
+// legacy duplicate payments before they are deleted, in the same way as the
+// Archive function of a PaymentsDeleteQuery. ErrPaymentInFlight is returned if
+// the payment is still in flight.
+func (db *DB) DeletePayment(paymentHash lntypes.Hash, failedHtlcsOnly bool,
+	archive func([]*MPPayment) error) error {
+
+	selectPayment := func(tx kvdb.RTx, deletion *paymentDeletion) error {
+		payments := tx.ReadBucket(paymentsRootBucket)
+		if payments == nil {
+			return ErrPaymentNotInitiated
+		}
 
-				// We return, we are only deleting attempts.
-				return nil
-			}
+		bucket := payments.NestedReadBucket(paymentHash[:])
+		if bucket == nil {
+			return ErrPaymentNotInitiated
+		}
 
-			// Add the bucket to the set of buckets we can delete.
-			deleteBuckets = append(deleteBuckets, k)
+		paymentStatus, err := fetchPaymentStatus(bucket)
+		if err != nil {
+			return err
+		}
 
-			// Get all the sequence number associated with the
-			// payment, including duplicates.
-			seqNrs, err := fetchSequenceNumbers(bucket)
-			if err != nil {
+		if paymentStatus == StatusInFlight {
+			return ErrPaymentInFlight
+		}
+
+		return deletion.add(paymentHash[:], bucket)
+	}
+
+	_, err := db.deletePayments(failedHtlcsOnly, archive, selectPayment)
+	return err
+}
+
+// deletePayments deletes the payments, or their failed htlc attempts, that
+// selectPayments adds to a deletion, and returns their number. If archive is
+// set, the payments are first read from a snapshot of the database and
+// archived outside of any transaction, as writing the archive may be slow.
+// The write transaction then only deletes the payments that were archived and
+// haven't been reinitialized since.
+func (db *DB) deletePayments(failedHtlcsOnly bool,
+	archive func([]*MPPayment) error,
+	selectPayments func(kvdb.RTx, *paymentDeletion) error) (int, error) {
+
+	var archived map[paymentKey]struct{}
+	if archive != nil {
+		var payments []*MPPayment
+		err := kvdb.View(db, func(tx kvdb.RTx) error {
+			deletion := newPaymentDeletion(failedHtlcsOnly, nil)
+			deletion.collectArchived = true
+
+			if err := selectPayments(tx, deletion); err != nil {
 				return err
 			}
 
-			deleteIndexes = append(deleteIndexes, seqNrs...)
+			payments = deletion.archived
 			return nil
+		}, func() {
+			payments = nil
 		})
 		if err != nil {
+			return 0, err
+		}
+
+		if len(payments) > 0 {
+			if err := archive(payments); err != nil {
+				return 0, err
+			}
+		}
+
+		archived = make(map[paymentKey]struct{}, len(payments))
+		for _, payment := range payments {
+			archived[paymentKey{
+				hash:   payment.Info.PaymentIdentifier,
+				seqNum: payment.SequenceNum,
+			}] = struct{}{}
+		}
+	}
+
+	var numDeleted int
+	err := kvdb.Update(db, func(tx kvdb.RwTx) error {
+		deletion := newPaymentDeletion(failedHtlcsOnly, archived)
+		if err := selectPayments(tx, deletion); err != nil {
 			return err
 		}
 
-		// Delete the failed HTLC attempts we found.
-		for hash, htlcIDs := range deleteHtlcs {
-			bucket := payments.NestedReadWriteBucket(hash[:])
-			htlcsBucket := bucket.NestedReadWriteBucket(
-				paymentHtlcsBucket,
-			)
+		numDeleted = deletion.numPayments
+		return deletion.apply(tx)
+	}, func() {
+		numDeleted = 0
+	})
+	if err != nil {
+		return 0, err
+	}
 
-			for _, aid := range htlcIDs {
-				err := htlcsBucket.DeleteNestedBucket(aid)
-				if err != nil {
-					return err
-				}
-			}
+	return numDeleted, nil
+}
+
+// forEachPaymentCreatedBefore calls the given function with the hash of every
+// payment that has a payment or duplicate payment that was created before the
+// given time, according to the payment creation time index. The time index is
+// read lazily, so the function may stage changes to the payments, as long as
+// it doesn't modify the index itself.
+func forEachPaymentCreatedBefore(tx kvdb.RTx, before time.Time,
+	cb func(lntypes.Hash) error) error {
+
+	timeIndex := tx.ReadBucket(paymentsCreationTimeIndexBucket)
+	indexes := tx.ReadBucket(paymentsIndexBucket)
+	if timeIndex == nil || indexes == nil {
+		return nil
+	}
+
+	cursor := newTimeIndexCursor(
+		timeIndex, indexes, time.Time{}, before.Add(-time.Nanosecond),
+		nil,
+	)
+
+	seen := make(map[lntypes.Hash]struct{})
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		hash, err := deserializePaymentIndex(bytes.NewReader(v))
+		if err != nil {
+			return err
+		}
+
+		// Duplicate payments point to the same payment hash.
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
+
+		if err := cb(hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchPaymentTimeIndexKey returns the creation time index key of the payment
+// or duplicate payment with the given sequence number key, or nil if there is
+// no such payment.
+func fetchPaymentTimeIndexKey(tx kvdb.RTx, indexes kvdb.RBucket,
+	seqKey []byte) ([]byte, error) {
+
+	indexValue := indexes.Get(seqKey)
+	if indexValue == nil {
+		return nil, nil
+	}
+
+	hash, err := deserializePaymentIndex(bytes.NewReader(indexValue))
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := fetchPaymentWithSequenceNumber(tx, hash, seqKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return timeIndexKey(payment.Info.CreationTime, payment.SequenceNum),
+		nil
+}
+
+// paymentDeletion collects the changes that are needed to delete a set of
+// payments, or their failed htlc attempts, within a single transaction.
+type paymentDeletion struct {
+	// failedHtlcsOnly is true if only the failed htlc attempts of the
+	// payments should be deleted.
+	failedHtlcsOnly bool
+
+	// collectArchived is true if the payments are read to be archived,
+	// in which case they are collected in archived.
+	collectArchived bool
+
+	// archived is the set of payments that need to be archived.
+	archived []*MPPayment
+
+	// onlyArchived, if set, restricts the deletion to the payments that
+	// were archived beforehand.
+	onlyArchived map[paymentKey]struct{}
+
+	// numPayments is the number of payments that are deleted or have
+	// their failed htlc attempts deleted.
+	numPayments int
+
+	// deleteBuckets is the set of payment buckets we need to delete.
+	deleteBuckets [][]byte
+
+	// deleteIndexes is the set of indexes pointing to these payments that
+	// need to be deleted.
+	deleteIndexes [][]byte
+
+	// deleteTimeIndexes is the set of creation time index entries of
+	// these payments that need to be deleted.
+	deleteTimeIndexes [][]byte
+
+	// deleteLookupIndexes is the set of lookup index entries of these
+	// payments that need to be deleted.
+	deleteLookupIndexes [][]byte
+
+	// deleteHtlcs maps a payment hash to the HTLC IDs we want to delete
+	// for that payment.
+	deleteHtlcs map[lntypes.Hash][][]byte
+}
+
+// paymentKey identifies a payment by its hash and sequence number. As a failed
+// payment can be initialized again with the same hash, which assigns it a new
+// sequence number, the hash alone doesn't identify a payment attempt.
+type paymentKey struct {
+	hash   lntypes.Hash
+	seqNum uint64
+}
+
+// newPaymentDeletion creates a new, empty payment deletion. If onlyArchived is
+// set, payments that aren't part of it are skipped.
+func newPaymentDeletion(failedHtlcsOnly bool,
+	onlyArchived map[paymentKey]struct{}) *paymentDeletion {
+
+	return &paymentDeletion{
+		failedHtlcsOnly: failedHtlcsOnly,
+		onlyArchived:    onlyArchived,
+		deleteHtlcs:     make(map[lntypes.Hash][][]byte),
+	}
+}
+
+// add schedules the deletion of the payment with the given hash, which is
+// stored in the given bucket.
+func (d *paymentDeletion) add(k []byte, bucket kvdb.RBucket) error {
+	// A payment that wasn't archived, because it was initialized again
+	// after the archive was written, is kept for now.
+	if d.onlyArchived != nil {
+		hash, err := lntypes.MakeHash(k)
+		if err != nil {
+			return err
+		}
+
+		seqNum := bucket.Get(paymentSequenceKey)
+		if seqNum == nil {
+			return errors.New("expected sequence number")
 		}
 
-		for _, k := range deleteBuckets {
-			if err := payments.DeleteNestedBucket(k); err != nil {
+		key := paymentKey{hash: hash, seqNum: byteOrder.Uint64(seqNum)}
+		if _, ok := d.onlyArchived[key]; !ok {
+			return nil
+		}
+	}
+
+	// If we are only deleting failed HTLCs, fetch them.
+	if d.failedHtlcsOnly {
+		htlcsBucket := bucket.NestedReadBucket(paymentHtlcsBucket)
+
+		var (
+			htlcs []HTLCAttempt
+			err   error
+		)
+		if htlcsBucket != nil {
+			htlcs, err = fetchHtlcAttempts(htlcsBucket)
+			if err != nil {
 				return err
 			}
 		}
 
-		// Get our index bucket and delete all indexes pointing to the
-		// payments we are deleting.
-		indexBucket := tx.ReadWriteBucket(paymentsIndexBucket)
-		for _, k := range deleteIndexes {
-			if err := indexBucket.Delete(k); err != nil {
+		// Now iterate though them and save the bucket keys for the
+		// failed HTLCs.
+		var toDelete [][]byte
+		for _, h := range htlcs {
+			if h.Failure == nil {
+				continue
+			}
+
+			htlcIDBytes := make([]byte, 8)
+			binary.BigEndian.PutUint64(htlcIDBytes, h.AttemptID)
+
+			toDelete = append(toDelete, htlcIDBytes)
+		}
+
+		// There is nothing to archive or delete for payments without
+		// failed attempts.
+		if len(toDelete) == 0 {
+			return nil
+		}
+
+		if d.collectArchived {
+			payment, err := fetchPayment(bucket)
+			if err != nil {
 				return err
 			}
+			d.archived = append(d.archived, payment)
+		}
+
+		hash, err := lntypes.MakeHash(k)
+		if err != nil {
+			return err
 		}
 
+		d.deleteHtlcs[hash] = toDelete
+		d.numPayments++
+
+		// We return, we are only deleting attempts.
 		return nil
-	}, func() {})
+	}
+
+	if d.collectArchived {
+		payment, err := fetchPayment(bucket)
+		if err != nil {
+			return err
+		}
+
+		duplicates, err := fetchDuplicatePayments(bucket)
+		if err != nil {
+			return err
+		}
+
+		d.archived = append(d.archived, duplicates...)
+		d.archived = append(d.archived, payment)
+	}
+
+	// Add the bucket to the set of buckets we can delete.
+	d.deleteBuckets = append(d.deleteBuckets, k)
+	d.numPayments++
+
+	// Get all the sequence number associated with the payment, including
+	// duplicates.
+	seqNrs, err := fetchSequenceNumbers(bucket)
+	if err != nil {
+		return err
+	}
+
+	d.deleteIndexes = append(d.deleteIndexes, seqNrs...)
+
+	// Also remove the payment from the creation time index.
+	timeKeys, err := fetchPaymentTimeIndexKeys(bucket)
+	if err != nil {
+		return err
+	}
+
+	d.deleteTimeIndexes = append(d.deleteTimeIndexes, timeKeys...)
+
+	// And from the lookup index.
+	lookupKeys, err := fetchPaymentLookupIndexKeys(bucket)
+	if err != nil {
+		return err
+	}
+
+	d.deleteLookupIndexes = append(d.deleteLookupIndexes, lookupKeys...)
+
+	return nil
+}
+
+// apply deletes all payments and htlc attempts that were added to the
+// deletion.
+func (d *paymentDeletion) apply(tx kvdb.RwTx) error {
+	payments := tx.ReadWriteBucket(paymentsRootBucket)
+
+	// Delete the failed HTLC attempts we found.
+	for hash, htlcIDs := range d.deleteHtlcs {
+		bucket := payments.NestedReadWriteBucket(hash[:])
+		htlcsBucket := bucket.NestedReadWriteBucket(paymentHtlcsBucket)
+
+		for _, aid := range htlcIDs {
+			err := htlcsBucket.DeleteNestedBucket(aid)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, k := range d.deleteBuckets {
+		if err := payments.DeleteNestedBucket(k); err != nil {
+			return err
+		}
+	}
+
+	// Get our index bucket and delete all indexes pointing to the payments
+	// we are deleting.
+	indexBucket := tx.ReadWriteBucket(paymentsIndexBucket)
+	for _, k := range d.deleteIndexes {
+		if err := indexBucket.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	timeIndex := tx.ReadWriteBucket(paymentsCreationTimeIndexBucket)
+	for _, k := range d.deleteTimeIndexes {
+		if err := timeIndex.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	lookupIndex := tx.ReadWriteBucket(paymentsLookupIndexBucket)
+	if lookupIndex == nil {
+		return errNoLookupIndex
+	}
+	for _, k := range d.deleteLookupIndexes {
+		if err := lookupIndex.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // fetchSequenceNumbers fetches all the sequence numbers associated with a
@@ -853,6 +1448,75 @@ func fetchSequenceNumbers(paymentBucket kvdb.RBucket) ([][]byte, error) {
 	return sequenceNumbers, nil
 }
 
+// paymentTimeIndexKey returns the creation time index key of the payment
+// stored in the given bucket, or nil if the payment hasn't been initialized.
+func paymentTimeIndexKey(paymentBucket kvdb.RBucket) ([]byte, error) {
+	seqNum := paymentBucket.Get(paymentSequenceKey)
+	infoBytes := paymentBucket.Get(paymentCreationInfoKey)
+	if seqNum == nil || infoBytes == nil {
+		return nil, nil
+	}
+
+	info, err := deserializePaymentCreationInfo(
+		bytes.NewReader(infoBytes),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return timeIndexKey(info.CreationTime, byteOrder.Uint64(seqNum)), nil
+}
+
+// fetchPaymentTimeIndexKeys fetches the creation time index keys of a
+// payment, including those belonging to any duplicate payments.
+func fetchPaymentTimeIndexKeys(paymentBucket kvdb.RBucket) ([][]byte, error) {
+	var keys [][]byte
+
+	key, err := paymentTimeIndexKey(paymentBucket)
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		keys = append(keys, key)
+	}
+
+	duplicates := paymentBucket.NestedReadBucket(duplicatePaymentsBucket)
+	if duplicates == nil {
+		return keys, nil
+	}
+
+	err = duplicates.ForEach(func(k, _ []byte) error {
+		subBucket := duplicates.NestedReadBucket(k)
+		if subBucket == nil {
+			return ErrNoDuplicateNestedBucket
+		}
+
+		seqNum := subBucket.Get(duplicatePaymentSequenceKey)
+		infoBytes := subBucket.Get(duplicatePaymentCreationInfoKey)
+		if seqNum == nil || infoBytes == nil {
+			return nil
+		}
+
+		info, err := deserializeDuplicatePaymentCreationInfo(
+			bytes.NewReader(infoBytes),
+		)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, timeIndexKey(
+			info.CreationTime, byteOrder.Uint64(seqNum),
+		))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
 // nolint: dupl
 func serializePaymentCreationInfo(w io.Writer, c *PaymentCreationInfo) error {
 	var scratch [8]byte
@@ -879,7 +1543,45 @@ func serializePaymentCreationInfo(w io.Writer, c *PaymentCreationInfo) error {
 		return err
 	}
 
-	return nil
+	// The fields that were added later on are appended as a tlv stream,
+	// which is empty for payments without any of them.
+	var (
+		records []tlv.Record
+		dest    = [33]byte(c.Destination)
+		desc    = []byte(c.Description)
+		label   = []byte(c.Label)
+	)
+	if c.Destination != (route.Vertex{}) {
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoDestType, &dest,
+		))
+	}
+	if c.PaymentAddr != nil {
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoPaymentAddrType, c.PaymentAddr,
+		))
+	}
+	if len(desc) > 0 {
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoDescriptionType, &desc,
+		))
+	}
+	if len(label) > 0 {
+		records = append(records, tlv.MakePrimitiveRecord(
+			creationInfoLabelType, &label,
+		))
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	tlvStream, err := tlv.NewStream(records...)
+	if err != nil {
+		return err
+	}
+
+	return tlvStream.Encode(w)
 }
 
 func deserializePaymentCreationInfo(r io.Reader) (*PaymentCreationInfo, error) {
@@ -915,6 +1617,35 @@ func deserializePaymentCreationInfo(r io.Reader) (*PaymentCreationInfo, error) {
 	}
 	c.PaymentRequest = payReq
 
+	var (
+		dest        [33]byte
+		paymentAddr [32]byte
+		desc, label []byte
+	)
+	tlvStream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(creationInfoDestType, &dest),
+		tlv.MakePrimitiveRecord(
+			creationInfoPaymentAddrType, &paymentAddr,
+		),
+		tlv.MakePrimitiveRecord(creationInfoDescriptionType, &desc),
+		tlv.MakePrimitiveRecord(creationInfoLabelType, &label),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedTypes, err := tlvStream.DecodeWithParsedTypes(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Destination = route.Vertex(dest)
+	if _, ok := parsedTypes[creationInfoPaymentAddrType]; ok {
+		c.PaymentAddr = &paymentAddr
+	}
+	c.Description = string(desc)
+	c.Label = string(label)
+
 	return c, nil
 }
 
@@ -1014,6 +1745,10 @@ func serializeHop(w io.Writer, h *route.Hop) error {
 		records = append(records, h.MPP.Record())
 	}
 
+	if h.Metadata != nil {
+		records = append(records, record.NewMetadataRecord(&h.Metadata))
+	}
+
 	// Final sanity check to absolutely rule out custom records that are not
 	// custom and write into the standard range.
 	if err := h.CustomRecords.Validate(); err != nil {
@@ -1128,6 +1863,15 @@ func deserializeHop(r io.Reader) (*route.Hop, error) {
 		h.MPP = mpp
 	}
 
+	// If the metadata type is present, remove it from the tlv map and
+	// populate directly on the hop.
+	metadataType := uint64(record.MetadataOnionType)
+	if metadata, ok := tlvMap[metadataType]; ok {
+		delete(tlvMap, metadataType)
+
+		h.Metadata = metadata
+	}
+
 	h.CustomRecords = tlvMap
 
 	return h, nil
@@ -3,7 +3,9 @@ package channeldb
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"os"
 	"reflect"
 	"testing"
 	"time"
@@ -13,6 +15,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/lightningnetwork/lnd/kvdb"
 	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/record"
 	"github.com/lightningnetwork/lnd/routing/route"
 	"github.com/stretchr/testify/require"
@@ -31,7 +34,8 @@ var (
 			65536: []byte{},
 			80001: []byte{},
 		},
-		MPP: record.NewMPP(32, [32]byte{0x42}),
+		MPP:      record.NewMPP(32, [32]byte{0x42}),
+		Metadata: []byte{0x01, 0x02},
 	}
 
 	testHop2 = &route.Hop{
@@ -97,6 +101,22 @@ func TestSentPaymentSerialization(t *testing.T) {
 		)
 	}
 
+	b.Reset()
+	// The optional fields of the creation info must survive the round
+	// trip as well.
+	withLookups := *c
+	withLookups.Destination = testPub
+	withLookups.PaymentAddr = &[32]byte{1, 2, 3}
+	withLookups.Description = "coffee"
+	withLookups.Label = "merchant x"
+	if err := serializePaymentCreationInfo(&b, &withLookups); err != nil {
+		t.Fatalf("unable to serialize creation info: %v", err)
+	}
+
+	newCreationInfo, err = deserializePaymentCreationInfo(&b)
+	require.NoError(t, err)
+	require.Equal(t, &withLookups, newCreationInfo)
+
 	b.Reset()
 	if err := serializeHTLCAttemptInfo(&b, s); err != nil {
 		t.Fatalf("unable to serialize info: %v", err)
@@ -510,6 +530,448 @@ func TestQueryPayments(t *testing.T) {
 	}
 }
 
+// TestQueryPaymentsFilters tests that payments can be queried by creation
+// date, status and value, and that date range queries can be paginated like
+// regular queries.
+func TestQueryPaymentsFilters(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	pControl := NewPaymentControl(db)
+	start := time.Unix(1000, 0)
+
+	// Create four payments that were created an hour apart from each
+	// other, with increasing values. The second and the last payment
+	// fail, after which the last one is retried much later.
+	const numPayments = 4
+	infos := make([]*PaymentCreationInfo, numPayments)
+	for i := 0; i < numPayments; i++ {
+		info, _, _, err := genInfo()
+		require.NoError(t, err)
+
+		info.CreationTime = start.Add(time.Duration(i) * time.Hour)
+		info.Value = lnwire.MilliSatoshi(i+1) * 1000
+		infos[i] = info
+
+		err = pControl.InitPayment(info.PaymentIdentifier, info)
+		require.NoError(t, err)
+	}
+
+	for _, i := range []int{1, 3} {
+		_, err := pControl.Fail(
+			infos[i].PaymentIdentifier, FailureReasonNoRoute,
+		)
+		require.NoError(t, err)
+	}
+
+	retryTime := start.Add(10 * time.Hour)
+	infos[3].CreationTime = retryTime
+	err = pControl.InitPayment(infos[3].PaymentIdentifier, infos[3])
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		query PaymentsQuery
+
+		// expectedSeqNrs contains the set of sequence numbers we expect
+		// our query to return.
+		expectedSeqNrs []uint64
+	}{
+		{
+			name: "creation date range",
+			query: PaymentsQuery{
+				IncludeIncomplete: true,
+				CreationDateStart: start,
+				CreationDateEnd:   start.Add(3 * time.Hour),
+			},
+			expectedSeqNrs: []uint64{1, 2, 3},
+		},
+		{
+			name: "creation date range of retried payment",
+			query: PaymentsQuery{
+				IncludeIncomplete: true,
+				CreationDateStart: start.Add(4 * time.Hour),
+			},
+			expectedSeqNrs: []uint64{5},
+		},
+		{
+			name: "creation date range reversed",
+			query: PaymentsQuery{
+				MaxPayments:       2,
+				Reversed:          true,
+				IncludeIncomplete: true,
+				CreationDateEnd:   start.Add(3 * time.Hour),
+			},
+			expectedSeqNrs: []uint64{2, 3},
+		},
+		{
+			name: "creation date range with offset",
+			query: PaymentsQuery{
+				IndexOffset:       1,
+				IncludeIncomplete: true,
+				CreationDateEnd:   start.Add(3 * time.Hour),
+			},
+			expectedSeqNrs: []uint64{2, 3},
+		},
+		{
+			name: "only succeeded payments",
+			query: PaymentsQuery{
+				CreationDateStart: start,
+			},
+		},
+		{
+			name: "failed payments",
+			query: PaymentsQuery{
+				Statuses: []PaymentStatus{StatusFailed},
+			},
+			expectedSeqNrs: []uint64{2},
+		},
+		{
+			name: "in flight payments with min value",
+			query: PaymentsQuery{
+				Statuses: []PaymentStatus{StatusInFlight},
+				MinValue: 2000,
+			},
+			expectedSeqNrs: []uint64{3, 5},
+		},
+		{
+			name: "max value",
+			query: PaymentsQuery{
+				IncludeIncomplete: true,
+				MaxValue:          1000,
+			},
+			expectedSeqNrs: []uint64{1},
+		},
+	}
+
+	querySeqNrs := func(query PaymentsQuery) []uint64 {
+		if query.MaxPayments == 0 {
+			query.MaxPayments = math.MaxUint64
+		}
+
+		resp, err := db.QueryPayments(query)
+		require.NoError(t, err)
+
+		var seqNrs []uint64
+		for _, payment := range resp.Payments {
+			seqNrs = append(seqNrs, payment.SequenceNum)
+		}
+
+		return seqNrs
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(
+				t, test.expectedSeqNrs, querySeqNrs(test.query),
+			)
+		})
+	}
+
+	// Deleting the failed payment also removes it from the creation time
+	// index.
+	require.NoError(t, db.DeletePayments(true, false))
+	require.Equal(t, []uint64{1, 3}, querySeqNrs(PaymentsQuery{
+		IncludeIncomplete: true,
+		CreationDateStart: start,
+		CreationDateEnd:   start.Add(3 * time.Hour),
+	}))
+
+	err = kvdb.View(db, func(tx kvdb.RTx) error {
+		timeIndex := tx.ReadBucket(paymentsCreationTimeIndexBucket)
+
+		var numEntries int
+		err := timeIndex.ForEach(func(_, _ []byte) error {
+			numEntries++
+			return nil
+		})
+		require.Equal(t, 3, numEntries)
+
+		return err
+	}, func() {})
+	require.NoError(t, err)
+}
+
+// TestQueryPaymentsLookup tests querying payments by destination, payment
+// address, description and label through the payments lookup index.
+func TestQueryPaymentsLookup(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	pControl := NewPaymentControl(db)
+
+	var (
+		merchant    = route.Vertex{1}
+		other       = route.Vertex{2}
+		paymentAddr = [32]byte{3}
+	)
+
+	// Create three payments to the merchant and one to another node. The
+	// first two payments pay the same invoice, and the first one fails.
+	infos := make([]*PaymentCreationInfo, 4)
+	for i := range infos {
+		info, _, _, err := genInfo()
+		require.NoError(t, err)
+
+		info.Destination = merchant
+		infos[i] = info
+	}
+	infos[0].PaymentAddr = &paymentAddr
+	infos[0].Description = "coffee"
+	infos[1].PaymentAddr = &paymentAddr
+	infos[1].Description = "coffee"
+	infos[1].Label = "breakfast"
+	infos[2].Label = "lunch"
+	infos[3].Destination = other
+	infos[3].Label = "breakfast"
+
+	for _, info := range infos {
+		err := pControl.InitPayment(info.PaymentIdentifier, info)
+		require.NoError(t, err)
+	}
+
+	_, err = pControl.Fail(infos[0].PaymentIdentifier, FailureReasonNoRoute)
+	require.NoError(t, err)
+
+	querySeqNrs := func(query PaymentsQuery) []uint64 {
+		query.IncludeIncomplete = true
+		query.MaxPayments = math.MaxUint64
+
+		resp, err := db.QueryPayments(query)
+		require.NoError(t, err)
+
+		var seqNrs []uint64
+		for _, payment := range resp.Payments {
+			seqNrs = append(seqNrs, payment.SequenceNum)
+		}
+
+		return seqNrs
+	}
+
+	unknown := route.Vertex{4}
+	tests := []struct {
+		name           string
+		query          PaymentsQuery
+		expectedSeqNrs []uint64
+	}{
+		{
+			name: "destination",
+			query: PaymentsQuery{
+				Destination: &merchant,
+			},
+			expectedSeqNrs: []uint64{1, 2, 3},
+		},
+		{
+			name: "unknown destination",
+			query: PaymentsQuery{
+				Destination: &unknown,
+			},
+		},
+		{
+			name: "payment address",
+			query: PaymentsQuery{
+				PaymentAddr: &paymentAddr,
+			},
+			expectedSeqNrs: []uint64{1, 2},
+		},
+		{
+			name: "description",
+			query: PaymentsQuery{
+				Description: "coffee",
+			},
+			expectedSeqNrs: []uint64{1, 2},
+		},
+		{
+			name: "label",
+			query: PaymentsQuery{
+				Label: "breakfast",
+			},
+			expectedSeqNrs: []uint64{2, 4},
+		},
+		{
+			name: "destination and label",
+			query: PaymentsQuery{
+				Destination: &merchant,
+				Label:       "breakfast",
+			},
+			expectedSeqNrs: []uint64{2},
+		},
+		{
+			name: "failed payments to destination",
+			query: PaymentsQuery{
+				Destination: &merchant,
+				Statuses:    []PaymentStatus{StatusFailed},
+			},
+			expectedSeqNrs: []uint64{1},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(
+				t, test.expectedSeqNrs, querySeqNrs(test.query),
+			)
+		})
+	}
+
+	// Retry the failed payment with a label. Its old index entries are
+	// replaced by entries for its new sequence number.
+	infos[0].Label = "breakfast"
+	err = pControl.InitPayment(infos[0].PaymentIdentifier, infos[0])
+	require.NoError(t, err)
+
+	require.Equal(t, []uint64{2, 4, 5}, querySeqNrs(PaymentsQuery{
+		Label: "breakfast",
+	}))
+	require.Equal(t, []uint64{2, 5}, querySeqNrs(PaymentsQuery{
+		PaymentAddr: &paymentAddr,
+	}))
+
+	// Deleting a payment also removes it from the lookup index.
+	_, err = pControl.Fail(infos[3].PaymentIdentifier, FailureReasonNoRoute)
+	require.NoError(t, err)
+
+	err = db.DeletePayment(infos[3].PaymentIdentifier, false, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, []uint64{2, 5}, querySeqNrs(PaymentsQuery{
+		Label: "breakfast",
+	}))
+
+	err = kvdb.View(db, func(tx kvdb.RTx) error {
+		lookupIndex := tx.ReadBucket(paymentsLookupIndexBucket)
+
+		var numEntries int
+		err := lookupIndex.ForEach(func(_, _ []byte) error {
+			numEntries++
+			return nil
+		})
+
+		// The three remaining payments to the merchant, two payment
+		// addresses, two descriptions and three labels.
+		require.Equal(t, 10, numEntries)
+
+		return err
+	}, func() {})
+	require.NoError(t, err)
+}
+
+// TestPaymentLookupIndexUpgrade asserts that payments of a database that was
+// created before the payments lookup index existed are indexed when it is
+// upgraded, and that they can be deleted and re-initialized afterwards.
+func TestPaymentLookupIndexUpgrade(t *testing.T) {
+	t.Parallel()
+
+	dbPath, err := ioutil.TempDir("", "channeldb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dbPath)
+
+	db, err := Open(dbPath)
+	require.NoError(t, err)
+
+	// Create a payment with an htlc attempt, whose route leads to the
+	// destination of the payment. Its creation info only has the fixed
+	// fields, as it did before the lookup index was added.
+	pControl := NewPaymentControl(db)
+	info, attempt, _, err := genInfo()
+	require.NoError(t, err)
+
+	err = pControl.InitPayment(info.PaymentIdentifier, info)
+	require.NoError(t, err)
+	_, err = pControl.RegisterAttempt(info.PaymentIdentifier, attempt)
+	require.NoError(t, err)
+
+	// Turn the database into one of version 22, which predates the
+	// payment time and lookup indexes, as well as the other top-level
+	// buckets that were added since.
+	err = kvdb.Update(db, func(tx kvdb.RwTx) error {
+		for _, bucket := range [][]byte{
+			htlcEventLogBucket,
+			invoiceArchiveBucket,
+			paymentsCreationTimeIndexBucket,
+			paymentsLookupIndexBucket,
+			lnurlUsersBucket,
+			recurringPaymentsBucket,
+		} {
+			if err := tx.DeleteTopLevelBucket(bucket); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func() {})
+	require.NoError(t, err)
+	require.NoError(t, db.PutMeta(&Meta{DbVersionNumber: 22}))
+	require.NoError(t, db.Close())
+
+	// Opening the database again migrates it to the latest version.
+	db, err = Open(dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	meta, err := db.FetchMeta(nil)
+	require.NoError(t, err)
+	require.Equal(t, getLatestDBVersion(dbVersions), meta.DbVersionNumber)
+
+	// The payment is indexed by the destination and payment address of
+	// the final hop of its route.
+	finalHop := testRoute.Hops[len(testRoute.Hops)-1]
+	paymentAddr := finalHop.MPP.PaymentAddr()
+
+	queryPayments := func(query PaymentsQuery) []*MPPayment {
+		query.IncludeIncomplete = true
+		query.MaxPayments = math.MaxUint64
+
+		resp, err := db.QueryPayments(query)
+		require.NoError(t, err)
+
+		return resp.Payments
+	}
+
+	payments := queryPayments(PaymentsQuery{
+		Destination: &finalHop.PubKeyBytes,
+		PaymentAddr: &paymentAddr,
+	})
+	require.Len(t, payments, 1)
+	require.Equal(t, finalHop.PubKeyBytes, payments[0].Info.Destination)
+	require.Equal(t, &paymentAddr, payments[0].Info.PaymentAddr)
+
+	// The payment can be deleted, which removes it from the lookup index,
+	// and a new payment is indexed as usual.
+	pControl = NewPaymentControl(db)
+	_, err = pControl.FailAttempt(
+		info.PaymentIdentifier, attempt.AttemptID,
+		&HTLCFailInfo{Reason: HTLCFailUnreadable},
+	)
+	require.NoError(t, err)
+	_, err = pControl.Fail(info.PaymentIdentifier, FailureReasonNoRoute)
+	require.NoError(t, err)
+
+	err = db.DeletePayment(info.PaymentIdentifier, false, nil)
+	require.NoError(t, err)
+	require.Empty(t, queryPayments(PaymentsQuery{
+		Destination: &finalHop.PubKeyBytes,
+	}))
+
+	info, _, _, err = genInfo()
+	require.NoError(t, err)
+	info.Destination = finalHop.PubKeyBytes
+
+	err = pControl.InitPayment(info.PaymentIdentifier, info)
+	require.NoError(t, err)
+	require.Len(t, queryPayments(PaymentsQuery{
+		Destination: &finalHop.PubKeyBytes,
+	}), 1)
+}
+
 // TestFetchPaymentWithSequenceNumber tests lookup of payments with their
 // sequence number. It sets up one payment with no duplicates, and another with
 // two duplicates in its duplicates bucket then uses these payments to test the
@@ -0,0 +1,621 @@
+package channeldb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+var (
+	// recurringPaymentsBucket is the name of the top level bucket that
+	// holds the recurring payments along with the history of their runs.
+	// Every recurring payment has its own sub bucket that is keyed by its
+	// ID.
+	//
+	// recurring-payments
+	//      |
+	//      |-- <id>
+	//      |      |
+	//      |      |-- recurring-payment-info: <serialized RecurringPayment>
+	//      |      |
+	//      |      |-- recurring-payment-runs
+	//      |             |
+	//      |             |-- <run number>: <serialized RecurringPaymentRun>
+	//      |             |
+	//      |             |-- <run number>: <serialized RecurringPaymentRun>
+	//      |
+	//      |-- <id>
+	//             |
+	//             ...
+	recurringPaymentsBucket = []byte("recurring-payments")
+
+	// recurringPaymentInfoKey is the key that the definition of a
+	// recurring payment is stored under in its sub bucket.
+	recurringPaymentInfoKey = []byte("recurring-payment-info")
+
+	// recurringPaymentRunsBucket is the name of the sub bucket that holds
+	// the runs of a recurring payment.
+	recurringPaymentRunsBucket = []byte("recurring-payment-runs")
+)
+
+var (
+	// ErrRecurringPaymentNotFound is returned when a recurring payment
+	// that is not known is requested.
+	ErrRecurringPaymentNotFound = errors.New("recurring payment not found")
+
+	// ErrRecurringPaymentRunNotFound is returned when a run of a recurring
+	// payment that is not known is updated.
+	ErrRecurringPaymentRunNotFound = errors.New("recurring payment run " +
+		"not found")
+)
+
+// RecurringPayment is the definition of a payment that is sent repeatedly at
+// a fixed interval. The payment is either a keysend payment to Dest, or an
+// AMP payment to the reusable AMP invoice AmpInvoice.
+type RecurringPayment struct {
+	// ID is the unique identifier of the recurring payment. It is assigned
+	// by the database when the payment is added.
+	ID uint64
+
+	// Label is an optional free form label of the recurring payment.
+	Label string
+
+	// Dest is the node that keysend payments are sent to. It is unset for
+	// AMP payments.
+	Dest route.Vertex
+
+	// AmpInvoice is the AMP invoice that is paid repeatedly. It is empty
+	// for keysend payments.
+	AmpInvoice string
+
+	// Amt is the amount that is paid in every run.
+	Amt lnwire.MilliSatoshi
+
+	// MaxFee is the maximum routing fee that is paid in every run.
+	MaxFee lnwire.MilliSatoshi
+
+	// Interval is the time between two consecutive runs.
+	Interval time.Duration
+
+	// StartTime is the time of the first run.
+	StartTime time.Time
+
+	// EndTime is the time after which no further runs are started. The
+	// zero value means that the payment recurs indefinitely.
+	EndTime time.Time
+
+	// NextRun is the scheduled time of the next run that is not completed
+	// yet.
+	NextRun time.Time
+
+	// CreationTime is the time the recurring payment was added.
+	CreationTime time.Time
+}
+
+// IsKeysend returns true if the recurring payment is paid through keysend
+// payments, and false if it is paid through an AMP invoice.
+func (p *RecurringPayment) IsKeysend() bool {
+	return p.AmpInvoice == ""
+}
+
+// Finished returns true if no further runs of the recurring payment are
+// started.
+func (p *RecurringPayment) Finished() bool {
+	return !p.EndTime.IsZero() && p.NextRun.After(p.EndTime)
+}
+
+// RecurringPaymentRunStatus is the status of a single run of a recurring
+// payment.
+type RecurringPaymentRunStatus uint8
+
+const (
+	// RecurringPaymentRunInFlight indicates that the run is not completed
+	// yet. Its latest attempt is either in flight, or will be retried.
+	RecurringPaymentRunInFlight RecurringPaymentRunStatus = 0
+
+	// RecurringPaymentRunSucceeded indicates that a payment attempt of
+	// the run succeeded.
+	RecurringPaymentRunSucceeded RecurringPaymentRunStatus = 1
+
+	// RecurringPaymentRunFailed indicates that all payment attempts of the
+	// run failed.
+	RecurringPaymentRunFailed RecurringPaymentRunStatus = 2
+)
+
+// String returns a human readable representation of the run status.
+func (s RecurringPaymentRunStatus) String() string {
+	switch s {
+	case RecurringPaymentRunInFlight:
+		return "In Flight"
+
+	case RecurringPaymentRunSucceeded:
+		return "Succeeded"
+
+	case RecurringPaymentRunFailed:
+		return "Failed"
+
+	default:
+		return "Unknown"
+	}
+}
+
+// RecurringPaymentRun is a single run of a recurring payment. A run may take
+// several payment attempts, of which only the latest one is recorded.
+type RecurringPaymentRun struct {
+	// Number is the number of the run, starting at one for the first run
+	// of the recurring payment.
+	Number uint64
+
+	// ScheduledTime is the time the run was scheduled for.
+	ScheduledTime time.Time
+
+	// Status is the status of the run.
+	Status RecurringPaymentRunStatus
+
+	// Attempts is the number of payment attempts that were made.
+	Attempts uint32
+
+	// LastAttemptTime is the time the latest payment attempt was made.
+	LastAttemptTime time.Time
+
+	// PaymentID is the identifier of the latest payment attempt in the
+	// payment database. It is the payment hash for keysend payments, and
+	// the set ID for AMP payments.
+	PaymentID lntypes.Hash
+
+	// FailureReason describes why the latest payment attempt failed.
+	FailureReason string
+}
+
+// AddRecurringPayment adds the given recurring payment to the database and
+// assigns its ID.
+func (d *DB) AddRecurringPayment(payment *RecurringPayment) error {
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		payments, err := tx.CreateTopLevelBucket(
+			recurringPaymentsBucket,
+		)
+		if err != nil {
+			return err
+		}
+
+		id, err := payments.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		paymentBucket, err := payments.CreateBucket(
+			recurringPaymentKey(id),
+		)
+		if err != nil {
+			return err
+		}
+
+		if _, err := paymentBucket.CreateBucket(
+			recurringPaymentRunsBucket,
+		); err != nil {
+			return err
+		}
+
+		payment.ID = id
+		return putRecurringPayment(paymentBucket, payment)
+	}, func() {})
+}
+
+// CompleteRecurringPaymentRun stores the given completed run of the recurring
+// payment with the given ID, and sets the scheduled time of the payment's next
+// run within the same transaction. ErrRecurringPaymentRunNotFound is returned
+// if the run does not exist.
+func (d *DB) CompleteRecurringPaymentRun(id uint64, run *RecurringPaymentRun,
+	nextRun time.Time) error {
+
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		paymentBucket, err := fetchRecurringPaymentBucket(tx, id)
+		if err != nil {
+			return err
+		}
+
+		payment, err := fetchRecurringPayment(paymentBucket, id)
+		if err != nil {
+			return err
+		}
+
+		runs, err := paymentBucket.CreateBucketIfNotExists(
+			recurringPaymentRunsBucket,
+		)
+		if err != nil {
+			return err
+		}
+
+		if runs.Get(recurringPaymentKey(run.Number)) == nil {
+			return ErrRecurringPaymentRunNotFound
+		}
+
+		if err := putRecurringPaymentRun(runs, run); err != nil {
+			return err
+		}
+
+		payment.NextRun = nextRun
+		return putRecurringPayment(paymentBucket, payment)
+	}, func() {})
+}
+
+// FetchRecurringPayment returns the recurring payment with the given ID.
+// ErrRecurringPaymentNotFound is returned if the payment does not exist.
+func (d *DB) FetchRecurringPayment(id uint64) (*RecurringPayment, error) {
+	var payment *RecurringPayment
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		payments := tx.ReadBucket(recurringPaymentsBucket)
+		if payments == nil {
+			return ErrRecurringPaymentNotFound
+		}
+
+		paymentBucket := payments.NestedReadBucket(
+			recurringPaymentKey(id),
+		)
+		if paymentBucket == nil {
+			return ErrRecurringPaymentNotFound
+		}
+
+		var err error
+		payment, err = fetchRecurringPayment(paymentBucket, id)
+		return err
+	}, func() {
+		payment = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// FetchRecurringPayments returns all recurring payments, ordered by their ID.
+func (d *DB) FetchRecurringPayments() ([]*RecurringPayment, error) {
+	var payments []*RecurringPayment
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		paymentsBucket := tx.ReadBucket(recurringPaymentsBucket)
+		if paymentsBucket == nil {
+			return nil
+		}
+
+		return paymentsBucket.ForEach(func(k, _ []byte) error {
+			paymentBucket := paymentsBucket.NestedReadBucket(k)
+			if paymentBucket == nil {
+				return nil
+			}
+
+			payment, err := fetchRecurringPayment(
+				paymentBucket, byteOrder.Uint64(k),
+			)
+			if err != nil {
+				return err
+			}
+
+			payments = append(payments, payment)
+			return nil
+		})
+	}, func() {
+		payments = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// DeleteRecurringPayment removes the recurring payment with the given ID and
+// the history of its runs from the database. ErrRecurringPaymentNotFound is
+// returned if the payment does not exist.
+func (d *DB) DeleteRecurringPayment(id uint64) error {
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		payments := tx.ReadWriteBucket(recurringPaymentsBucket)
+		if payments == nil {
+			return ErrRecurringPaymentNotFound
+		}
+
+		key := recurringPaymentKey(id)
+		if payments.NestedReadWriteBucket(key) == nil {
+			return ErrRecurringPaymentNotFound
+		}
+
+		return payments.DeleteNestedBucket(key)
+	}, func() {})
+}
+
+// AddRecurringPaymentRun adds a new run to the recurring payment with the
+// given ID and assigns the run's number.
+func (d *DB) AddRecurringPaymentRun(id uint64,
+	run *RecurringPaymentRun) error {
+
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		runs, err := fetchRecurringPaymentRunsBucket(tx, id)
+		if err != nil {
+			return err
+		}
+
+		number, err := runs.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		run.Number = number
+		return putRecurringPaymentRun(runs, run)
+	}, func() {})
+}
+
+// UpdateRecurringPaymentRun replaces the stored run of the recurring payment
+// with the given ID that has the same number as the given run.
+// ErrRecurringPaymentRunNotFound is returned if the run does not exist.
+func (d *DB) UpdateRecurringPaymentRun(id uint64,
+	run *RecurringPaymentRun) error {
+
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		runs, err := fetchRecurringPaymentRunsBucket(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if runs.Get(recurringPaymentKey(run.Number)) == nil {
+			return ErrRecurringPaymentRunNotFound
+		}
+
+		return putRecurringPaymentRun(runs, run)
+	}, func() {})
+}
+
+// FetchRecurringPaymentRuns returns the runs of the recurring payment with
+// the given ID, ordered by their number. ErrRecurringPaymentNotFound is
+// returned if the payment does not exist.
+func (d *DB) FetchRecurringPaymentRuns(id uint64) ([]*RecurringPaymentRun,
+	error) {
+
+	var runs []*RecurringPaymentRun
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		payments := tx.ReadBucket(recurringPaymentsBucket)
+		if payments == nil {
+			return ErrRecurringPaymentNotFound
+		}
+
+		paymentBucket := payments.NestedReadBucket(
+			recurringPaymentKey(id),
+		)
+		if paymentBucket == nil {
+			return ErrRecurringPaymentNotFound
+		}
+
+		runsBucket := paymentBucket.NestedReadBucket(
+			recurringPaymentRunsBucket,
+		)
+		if runsBucket == nil {
+			return nil
+		}
+
+		return runsBucket.ForEach(func(k, v []byte) error {
+			run, err := deserializeRecurringPaymentRun(
+				byteOrder.Uint64(k), bytes.NewReader(v),
+			)
+			if err != nil {
+				return err
+			}
+
+			runs = append(runs, run)
+			return nil
+		})
+	}, func() {
+		runs = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// recurringPaymentKey returns the key that the recurring payment or run with
+// the given ID or number is stored under.
+func recurringPaymentKey(id uint64) []byte {
+	var key [8]byte
+	byteOrder.PutUint64(key[:], id)
+	return key[:]
+}
+
+// fetchRecurringPaymentBucket returns the sub bucket of the recurring payment
+// with the given ID.
+func fetchRecurringPaymentBucket(tx kvdb.RwTx,
+	id uint64) (kvdb.RwBucket, error) {
+
+	payments := tx.ReadWriteBucket(recurringPaymentsBucket)
+	if payments == nil {
+		return nil, ErrRecurringPaymentNotFound
+	}
+
+	paymentBucket := payments.NestedReadWriteBucket(recurringPaymentKey(id))
+	if paymentBucket == nil {
+		return nil, ErrRecurringPaymentNotFound
+	}
+
+	return paymentBucket, nil
+}
+
+// fetchRecurringPaymentRunsBucket returns the bucket that holds the runs of
+// the recurring payment with the given ID.
+func fetchRecurringPaymentRunsBucket(tx kvdb.RwTx,
+	id uint64) (kvdb.RwBucket, error) {
+
+	paymentBucket, err := fetchRecurringPaymentBucket(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return paymentBucket.CreateBucketIfNotExists(recurringPaymentRunsBucket)
+}
+
+// fetchRecurringPayment reads the recurring payment with the given ID from its
+// sub bucket.
+func fetchRecurringPayment(paymentBucket kvdb.RBucket,
+	id uint64) (*RecurringPayment, error) {
+
+	paymentBytes := paymentBucket.Get(recurringPaymentInfoKey)
+	if paymentBytes == nil {
+		return nil, ErrRecurringPaymentNotFound
+	}
+
+	return deserializeRecurringPayment(id, bytes.NewReader(paymentBytes))
+}
+
+// putRecurringPayment writes the given recurring payment to its sub bucket.
+func putRecurringPayment(paymentBucket kvdb.RwBucket,
+	payment *RecurringPayment) error {
+
+	var b bytes.Buffer
+	if err := serializeRecurringPayment(&b, payment); err != nil {
+		return err
+	}
+
+	return paymentBucket.Put(recurringPaymentInfoKey, b.Bytes())
+}
+
+// putRecurringPaymentRun writes the given run to the runs bucket of its
+// recurring payment.
+func putRecurringPaymentRun(runs kvdb.RwBucket,
+	run *RecurringPaymentRun) error {
+
+	var b bytes.Buffer
+	if err := serializeRecurringPaymentRun(&b, run); err != nil {
+		return err
+	}
+
+	return runs.Put(recurringPaymentKey(run.Number), b.Bytes())
+}
+
+// serializeRecurringPayment writes the given recurring payment to w. The ID is
+// not serialized, as it is used as the key of the payment.
+func serializeRecurringPayment(w io.Writer, payment *RecurringPayment) error {
+	err := WriteElements(
+		w, []byte(payment.Label), payment.Dest[:],
+		[]byte(payment.AmpInvoice), payment.Amt, payment.MaxFee,
+		int64(payment.Interval),
+	)
+	if err != nil {
+		return err
+	}
+
+	times := []time.Time{
+		payment.StartTime, payment.EndTime, payment.NextRun,
+		payment.CreationTime,
+	}
+	for _, t := range times {
+		if err := serializeTime(w, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deserializeRecurringPayment reads the recurring payment with the given ID
+// from r.
+func deserializeRecurringPayment(id uint64, r io.Reader) (*RecurringPayment,
+	error) {
+
+	payment := &RecurringPayment{
+		ID: id,
+	}
+
+	var (
+		label, dest, ampInvoice []byte
+		interval                int64
+	)
+	err := ReadElements(
+		r, &label, &dest, &ampInvoice, &payment.Amt, &payment.MaxFee,
+		&interval,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dest) != len(payment.Dest) {
+		return nil, errors.New("invalid recurring payment destination")
+	}
+
+	payment.Label = string(label)
+	copy(payment.Dest[:], dest)
+	payment.AmpInvoice = string(ampInvoice)
+	payment.Interval = time.Duration(interval)
+
+	times := []*time.Time{
+		&payment.StartTime, &payment.EndTime, &payment.NextRun,
+		&payment.CreationTime,
+	}
+	for _, t := range times {
+		if *t, err = deserializeTime(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return payment, nil
+}
+
+// serializeRecurringPaymentRun writes the given run to w. The number is not
+// serialized, as it is used as the key of the run.
+func serializeRecurringPaymentRun(w io.Writer,
+	run *RecurringPaymentRun) error {
+
+	if err := serializeTime(w, run.ScheduledTime); err != nil {
+		return err
+	}
+
+	if err := serializeTime(w, run.LastAttemptTime); err != nil {
+		return err
+	}
+
+	return WriteElements(
+		w, uint8(run.Status), run.Attempts, [32]byte(run.PaymentID),
+		[]byte(run.FailureReason),
+	)
+}
+
+// deserializeRecurringPaymentRun reads the run with the given number from r.
+func deserializeRecurringPaymentRun(number uint64,
+	r io.Reader) (*RecurringPaymentRun, error) {
+
+	run := &RecurringPaymentRun{
+		Number: number,
+	}
+
+	var err error
+	run.ScheduledTime, err = deserializeTime(r)
+	if err != nil {
+		return nil, err
+	}
+
+	run.LastAttemptTime, err = deserializeTime(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		status        uint8
+		paymentID     [32]byte
+		failureReason []byte
+	)
+	err = ReadElements(
+		r, &status, &run.Attempts, &paymentID, &failureReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	run.Status = RecurringPaymentRunStatus(status)
+	run.PaymentID = lntypes.Hash(paymentID)
+	run.FailureReason = string(failureReason)
+
+	return run, nil
+}
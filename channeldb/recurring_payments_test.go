@@ -0,0 +1,130 @@
+package channeldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecurringPayments tests adding, updating, fetching and deleting
+// recurring payments and their runs.
+func TestRecurringPayments(t *testing.T) {
+	db, cleanup, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	// Without any recurring payments, lookups fail and the list of
+	// payments is empty.
+	_, err = db.FetchRecurringPayment(1)
+	require.Equal(t, ErrRecurringPaymentNotFound, err)
+
+	_, err = db.FetchRecurringPaymentRuns(1)
+	require.Equal(t, ErrRecurringPaymentNotFound, err)
+
+	payments, err := db.FetchRecurringPayments()
+	require.NoError(t, err)
+	require.Empty(t, payments)
+
+	start := time.Unix(1600000000, 0)
+	keysend := &RecurringPayment{
+		Label:        "payroll",
+		Dest:         testPub,
+		Amt:          100000,
+		MaxFee:       1000,
+		Interval:     24 * time.Hour,
+		StartTime:    start,
+		EndTime:      start.Add(30 * 24 * time.Hour),
+		NextRun:      start,
+		CreationTime: start.Add(-time.Hour),
+	}
+	amp := &RecurringPayment{
+		AmpInvoice:   "lnbcrt1amp",
+		Amt:          5000,
+		Interval:     time.Hour,
+		StartTime:    start,
+		NextRun:      start,
+		CreationTime: start,
+	}
+
+	require.NoError(t, db.AddRecurringPayment(keysend))
+	require.NoError(t, db.AddRecurringPayment(amp))
+	require.EqualValues(t, 1, keysend.ID)
+	require.EqualValues(t, 2, amp.ID)
+	require.True(t, keysend.IsKeysend())
+	require.False(t, amp.IsKeysend())
+
+	payment, err := db.FetchRecurringPayment(keysend.ID)
+	require.NoError(t, err)
+	require.Equal(t, keysend, payment)
+
+	payments, err = db.FetchRecurringPayments()
+	require.NoError(t, err)
+	require.Equal(t, []*RecurringPayment{keysend, amp}, payments)
+
+	// Add two runs to the AMP payment and update the first one.
+	first := &RecurringPaymentRun{
+		ScheduledTime: start,
+	}
+	second := &RecurringPaymentRun{
+		ScheduledTime: start.Add(time.Hour),
+	}
+	require.NoError(t, db.AddRecurringPaymentRun(amp.ID, first))
+	require.NoError(t, db.AddRecurringPaymentRun(amp.ID, second))
+	require.EqualValues(t, 1, first.Number)
+	require.EqualValues(t, 2, second.Number)
+
+	first.Status = RecurringPaymentRunSucceeded
+	first.Attempts = 2
+	first.LastAttemptTime = start.Add(time.Minute)
+	first.PaymentID = lntypes.Hash{1, 2, 3}
+	first.FailureReason = "no route"
+	require.NoError(t, db.UpdateRecurringPaymentRun(amp.ID, first))
+
+	// Complete the second run, which advances the next run of the AMP
+	// payment. Once it is past the end time, the payment is finished.
+	second.Status = RecurringPaymentRunFailed
+	err = db.CompleteRecurringPaymentRun(
+		amp.ID, second, start.Add(2*time.Hour),
+	)
+	require.NoError(t, err)
+
+	payment, err = db.FetchRecurringPayment(amp.ID)
+	require.NoError(t, err)
+	require.Equal(t, start.Add(2*time.Hour), payment.NextRun)
+	require.False(t, payment.Finished())
+
+	payment.EndTime = start.Add(time.Hour)
+	require.True(t, payment.Finished())
+
+	runs, err := db.FetchRecurringPaymentRuns(amp.ID)
+	require.NoError(t, err)
+	require.Equal(t, []*RecurringPaymentRun{first, second}, runs)
+
+	// Unknown runs can't be updated.
+	err = db.UpdateRecurringPaymentRun(
+		amp.ID, &RecurringPaymentRun{Number: 3},
+	)
+	require.Equal(t, ErrRecurringPaymentRunNotFound, err)
+
+	// The keysend payment has no runs.
+	runs, err = db.FetchRecurringPaymentRuns(keysend.ID)
+	require.NoError(t, err)
+	require.Empty(t, runs)
+
+	// Delete the AMP payment along with its runs.
+	require.NoError(t, db.DeleteRecurringPayment(amp.ID))
+	require.Equal(
+		t, ErrRecurringPaymentNotFound,
+		db.DeleteRecurringPayment(amp.ID),
+	)
+
+	_, err = db.FetchRecurringPaymentRuns(amp.ID)
+	require.Equal(t, ErrRecurringPaymentNotFound, err)
+
+	payments, err = db.FetchRecurringPayments()
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	require.Equal(t, keysend.ID, payments[0].ID)
+}
@@ -0,0 +1,262 @@
+package channeldb
+
+import (
+	"bytes"
+	"math"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+// timeIndexKeyLen is the length of the keys of a time index, which consist of
+// an 8 byte timestamp followed by an 8 byte sequence number.
+const timeIndexKeyLen = 16
+
+// timeIndexKey returns the key of an entry in a time index. Time indexes map
+// the timestamp of an item followed by its sequence number to an empty value.
+// Both parts are big-endian encoded, which orders the entries by time first.
+//
+// NOTE: The time indexes are created by migration23, which has its own copy
+// of this encoding. Both must always produce the same keys.
+func timeIndexKey(t time.Time, seqNum uint64) []byte {
+	// Calling UnixNano() on a zero time yields an undefined result, so we
+	// map zero and pre-epoch times to the start of the index.
+	var unixNano int64
+	if !t.IsZero() && t.UnixNano() > 0 {
+		unixNano = t.UnixNano()
+	}
+
+	key := make([]byte, timeIndexKeyLen)
+	byteOrder.PutUint64(key[:8], uint64(unixNano))
+	byteOrder.PutUint64(key[8:], seqNum)
+
+	return key
+}
+
+// inTimeRange returns true if the given time is within the inclusive range
+// [start, end]. A zero start or end time leaves that side of the range
+// unbounded.
+func inTimeRange(t, start, end time.Time) bool {
+	if !start.IsZero() && t.Before(start) {
+		return false
+	}
+
+	return end.IsZero() || !t.After(end)
+}
+
+// offsetSeeker is implemented by cursors whose entries aren't ordered by
+// their keys, and which thus can't be positioned relative to an index offset
+// by seeking to the key of the offset.
+type offsetSeeker interface {
+	// seekOffset positions the cursor at the entry after the one with the
+	// given index offset, or before it if reversed is set, and returns
+	// that entry. A zero offset positions the cursor at the first entry,
+	// or the last one if reversed is set.
+	seekOffset(indexOffset uint64, reversed bool) ([]byte, []byte, error)
+}
+
+// timeIndexCursor is a read-only cursor over the entries of an index bucket
+// that is keyed by big-endian sequence numbers, restricted to the items whose
+// timestamp in a time index is within a time range. Entries are visited in
+// the order of the time index, and are only read once the cursor reaches
+// them, so a query only reads as many entries as it needs to fill its page.
+type timeIndexCursor struct {
+	// timeIndex is the cursor of the time index that drives the iteration.
+	timeIndex kvdb.RCursor
+
+	// index is the bucket the sequence numbers of the time index refer
+	// to.
+	index kvdb.RBucket
+
+	// startKey and endKey are the first and last time index keys within
+	// the time range of the cursor.
+	startKey []byte
+	endKey   []byte
+
+	// timeKey returns the time index key of the item with the given
+	// sequence number key, or nil if the item doesn't exist. It is used to
+	// seek directly to the item at an index offset.
+	timeKey func(seqKey []byte) ([]byte, error)
+}
+
+// A compile-time check to ensure timeIndexCursor implements the kvdb.RCursor
+// and offsetSeeker interfaces.
+var _ kvdb.RCursor = (*timeIndexCursor)(nil)
+var _ offsetSeeker = (*timeIndexCursor)(nil)
+
+// newTimeIndexCursor creates a cursor over the entries of the index bucket
+// whose items have a timestamp within the inclusive range [start, end] in the
+// given time index. A zero start or end time leaves that side of the range
+// unbounded.
+func newTimeIndexCursor(timeIndex, index kvdb.RBucket, start, end time.Time,
+	timeKey func([]byte) ([]byte, error)) *timeIndexCursor {
+
+	endKey := bytes.Repeat([]byte{0xff}, timeIndexKeyLen)
+	if !end.IsZero() {
+		endKey = timeIndexKey(end, math.MaxUint64)
+	}
+
+	return &timeIndexCursor{
+		timeIndex: timeIndex.ReadCursor(),
+		index:     index,
+		startKey:  timeIndexKey(start, 0),
+		endKey:    endKey,
+		timeKey:   timeKey,
+	}
+}
+
+// inRange returns true if the given time index key is within the time range
+// of the cursor.
+func (c *timeIndexCursor) inRange(timeKey []byte) bool {
+	return bytes.Compare(timeKey, c.startKey) >= 0 &&
+		bytes.Compare(timeKey, c.endKey) <= 0
+}
+
+// forward returns the first entry of the index at or after the given time
+// index key, moving the time index cursor forward as needed.
+func (c *timeIndexCursor) forward(k []byte) ([]byte, []byte) {
+	for ; k != nil; k, _ = c.timeIndex.Next() {
+		if bytes.Compare(k, c.endKey) > 0 {
+			return nil, nil
+		}
+
+		if len(k) != timeIndexKeyLen {
+			continue
+		}
+
+		if v := c.index.Get(k[8:]); v != nil {
+			return k[8:], v
+		}
+	}
+
+	return nil, nil
+}
+
+// backward returns the last entry of the index at or before the given time
+// index key, moving the time index cursor backward as needed.
+func (c *timeIndexCursor) backward(k []byte) ([]byte, []byte) {
+	for ; k != nil; k, _ = c.timeIndex.Prev() {
+		if bytes.Compare(k, c.startKey) < 0 {
+			return nil, nil
+		}
+
+		if len(k) != timeIndexKeyLen {
+			continue
+		}
+
+		if v := c.index.Get(k[8:]); v != nil {
+			return k[8:], v
+		}
+	}
+
+	return nil, nil
+}
+
+// First positions the cursor at the first key/value pair and returns the
+// pair.
+func (c *timeIndexCursor) First() ([]byte, []byte) {
+	k, _ := c.timeIndex.Seek(c.startKey)
+	return c.forward(k)
+}
+
+// Last positions the cursor at the last key/value pair and returns the pair.
+func (c *timeIndexCursor) Last() ([]byte, []byte) {
+	k, _ := c.timeIndex.Seek(c.endKey)
+	switch {
+	case k == nil:
+		k, _ = c.timeIndex.Last()
+
+	case bytes.Compare(k, c.endKey) > 0:
+		k, _ = c.timeIndex.Prev()
+	}
+
+	return c.backward(k)
+}
+
+// Next moves the cursor one key/value pair forward and returns the new pair.
+func (c *timeIndexCursor) Next() ([]byte, []byte) {
+	k, _ := c.timeIndex.Next()
+	return c.forward(k)
+}
+
+// Prev moves the cursor one key/value pair backward and returns the new pair.
+func (c *timeIndexCursor) Prev() ([]byte, []byte) {
+	k, _ := c.timeIndex.Prev()
+	return c.backward(k)
+}
+
+// Seek positions the cursor at the first key/value pair, in the order of the
+// time index, whose key is equal to or greater than the seek key, and returns
+// the pair.
+//
+// NOTE: As the entries aren't ordered by their keys, this scans the entries
+// from the start of the time range. Use seekOffset to paginate instead.
+func (c *timeIndexCursor) Seek(seek []byte) ([]byte, []byte) {
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if bytes.Compare(k, seek) >= 0 {
+			return k, v
+		}
+	}
+
+	return nil, nil
+}
+
+// seekOffset positions the cursor next to the entry with the given index
+// offset. The time index key of that entry is looked up, so that we can seek
+// to it directly. If the entry isn't within the time range, for example
+// because it has been deleted since, we fall back to scanning for the first
+// entry with a greater sequence number, or the last one with a smaller
+// sequence number if reversed is set.
+//
+// NOTE: This is part of the offsetSeeker interface.
+func (c *timeIndexCursor) seekOffset(indexOffset uint64,
+	reversed bool) ([]byte, []byte, error) {
+
+	if indexOffset == 0 {
+		if reversed {
+			k, v := c.Last()
+			return k, v, nil
+		}
+
+		k, v := c.First()
+		return k, v, nil
+	}
+
+	var seqKey [8]byte
+	byteOrder.PutUint64(seqKey[:], indexOffset)
+
+	key, err := c.timeKey(seqKey[:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if key != nil && c.inRange(key) {
+		if k, _ := c.timeIndex.Seek(key); bytes.Equal(k, key) {
+			if reversed {
+				k, v := c.Prev()
+				return k, v, nil
+			}
+
+			k, v := c.Next()
+			return k, v, nil
+		}
+	}
+
+	if reversed {
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if byteOrder.Uint64(k) < indexOffset {
+				return k, v, nil
+			}
+		}
+
+		return nil, nil, nil
+	}
+
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if byteOrder.Uint64(k) > indexOffset {
+			return k, v, nil
+		}
+	}
+
+	return nil, nil, nil
+}
@@ -0,0 +1,175 @@
+package channeldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb/migration23"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimeIndexKeyMigration asserts that the time index keys of channeldb
+// match those of migration23, which created the time indexes.
+func TestTimeIndexKeyMigration(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, migration23.TimeIndexKeyLen, timeIndexKeyLen)
+
+	times := []time.Time{
+		{},
+		time.Unix(-1, 0),
+		time.Unix(0, 1),
+		time.Unix(1600000000, 123456789),
+		time.Unix(1600000000, 0).In(time.FixedZone("test", 3600)),
+	}
+	seqNums := []uint64{0, 1, 1 << 40, ^uint64(0)}
+
+	for _, ts := range times {
+		for _, seqNum := range seqNums {
+			require.Equal(
+				t, migration23.TimeIndexKey(ts, seqNum),
+				timeIndexKey(ts, seqNum),
+			)
+		}
+	}
+}
+
+// TestTimeIndexCursor asserts that a time index cursor visits the entries of
+// an index in the order of the time index, skips entries outside of its time
+// range and paginates relative to the index offsets of the entries.
+func TestTimeIndexCursor(t *testing.T) {
+	t.Parallel()
+
+	db, cleanUp, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanUp()
+
+	var (
+		indexBucket     = []byte("test-index")
+		timeIndexBucket = []byte("test-time-index")
+	)
+
+	// The items are added to the time index out of sequence number order,
+	// as it happens for the settle time of invoices. The item with
+	// sequence number 6 only has a time index entry.
+	itemTimes := map[uint64]time.Time{
+		3: time.Unix(10, 0),
+		1: time.Unix(20, 0),
+		5: time.Unix(30, 0),
+		6: time.Unix(35, 0),
+		2: time.Unix(40, 0),
+		4: time.Unix(50, 0),
+	}
+	seqKey := func(seqNum uint64) []byte {
+		var key [8]byte
+		byteOrder.PutUint64(key[:], seqNum)
+		return key[:]
+	}
+
+	err = kvdb.Update(db, func(tx kvdb.RwTx) error {
+		index, err := tx.CreateTopLevelBucket(indexBucket)
+		if err != nil {
+			return err
+		}
+		timeIndex, err := tx.CreateTopLevelBucket(timeIndexBucket)
+		if err != nil {
+			return err
+		}
+
+		for seqNum, ts := range itemTimes {
+			err := timeIndex.Put(timeIndexKey(ts, seqNum), nil)
+			if err != nil {
+				return err
+			}
+
+			if seqNum == 6 {
+				continue
+			}
+
+			err = index.Put(seqKey(seqNum), []byte{byte(seqNum)})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func() {})
+	require.NoError(t, err)
+
+	// query returns the sequence numbers of the items within [start, end]
+	// that a paginated query with the given parameters returns.
+	query := func(start, end time.Time, reversed bool, offset,
+		maxItems uint64) []uint64 {
+
+		var seqNums []uint64
+		err := kvdb.View(db, func(tx kvdb.RTx) error {
+			cursor := newTimeIndexCursor(
+				tx.ReadBucket(timeIndexBucket),
+				tx.ReadBucket(indexBucket), start, end,
+				func(k []byte) ([]byte, error) {
+					seqNum := byteOrder.Uint64(k)
+					ts, ok := itemTimes[seqNum]
+					if !ok || seqNum == 6 {
+						return nil, nil
+					}
+
+					return timeIndexKey(ts, seqNum), nil
+				},
+			)
+
+			p := newPaginator(cursor, reversed, offset, maxItems)
+			return p.query(func(k, v []byte) (bool, error) {
+				seqNum := byteOrder.Uint64(k)
+				require.Equal(t, seqNum, uint64(v[0]))
+
+				seqNums = append(seqNums, seqNum)
+				return true, nil
+			})
+		}, func() {
+			seqNums = nil
+		})
+		require.NoError(t, err)
+
+		return seqNums
+	}
+
+	var (
+		noTime = time.Time{}
+		start  = time.Unix(15, 0)
+		end    = time.Unix(45, 0)
+	)
+
+	// Without a time range, all items are returned in the order of the
+	// time index.
+	require.Equal(t, []uint64{3, 1, 5, 2, 4}, query(
+		noTime, noTime, false, 0, 10,
+	))
+	require.Equal(t, []uint64{4, 2, 5, 1, 3}, query(
+		noTime, noTime, true, 0, 10,
+	))
+
+	// Within the time range, pages continue right after the item at the
+	// offset in either direction.
+	require.Equal(t, []uint64{1, 5}, query(start, end, false, 0, 2))
+	require.Equal(t, []uint64{2}, query(start, end, false, 5, 2))
+	require.Equal(t, []uint64{2, 5}, query(start, end, true, 0, 2))
+	require.Equal(t, []uint64{1}, query(start, end, true, 5, 2))
+
+	// The range bounds are inclusive.
+	require.Equal(t, []uint64{1, 5, 2}, query(
+		time.Unix(20, 0), time.Unix(40, 0), false, 0, 10,
+	))
+
+	// If the item at the offset isn't within the time range or doesn't
+	// exist, the pages start at the first item with a greater sequence
+	// number, or the last item with a smaller one in reverse.
+	require.Equal(t, []uint64{5, 2}, query(start, end, false, 3, 10))
+	require.Equal(t, []uint64{2, 5, 1}, query(start, end, true, 4, 10))
+	require.Empty(t, query(start, end, false, 6, 10))
+
+	// An empty time range yields no items.
+	gapStart, gapEnd := time.Unix(41, 0), time.Unix(49, 0)
+	require.Empty(t, query(gapStart, gapEnd, false, 0, 10))
+	require.Empty(t, query(gapStart, gapEnd, true, 0, 10))
+}
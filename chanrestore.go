@@ -12,6 +12,7 @@ import (
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/contractcourt"
 	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lnwallet/rpcwallet"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/shachain"
 )
@@ -99,6 +100,17 @@ func (c *chanDBRestorer) openChannelShell(backup chanbackup.Single) (
 		privKey, err := c.secretKeys.DerivePrivKey(
 			backup.ShaChainRootDesc,
 		)
+
+		// The legacy root is the private key itself, which a remote
+		// signer never hands out. Such a backup can only be restored
+		// by a node that holds the private keys, for example the
+		// remote signer itself.
+		if err == rpcwallet.ErrRemoteSigningPrivKeyNotSupported {
+			return nil, fmt.Errorf("channel %v uses the legacy "+
+				"revocation root format, which can't be "+
+				"restored with a remote signer",
+				backup.FundingOutpoint)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("could not derive private key "+
 				"for legacy channel revocation root format: "+
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/urfave/cli"
+)
+
+var accountingReportCommand = cli.Command{
+	Name:     "accountingreport",
+	Category: "Payments",
+	Usage: "Create an accounting report of the funds that moved " +
+		"through the node.",
+	Description: `
+	Create an accounting report of the node's on-chain transactions,
+	channel opens and closes, sweeps, forwards, payments and invoices over
+	a particular time range (--start_time and --end_time). Every entry of
+	the report moves its amount from its credit account to its debit
+	account, so that the entries can be imported into a double-entry
+	ledger. If lnd was started with --accounting.pricecsv, every entry is
+	also valued in fiat at the time it took place.
+
+	The start and end times can be expressed in seconds since the Unix
+	epoch, or as negative time ranges, e.g. "-1M". If --start_time isn't
+	provided, the report starts with the first recorded event. If
+	--end_time isn't provided, then the current time is used.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "start_time",
+			Usage: "the starting time for the report " +
+				`as unix timestamp or relative e.g. "-1M"`,
+		},
+		cli.StringFlag{
+			Name: "end_time",
+			Usage: "the end time for the report " +
+				`as unix timestamp or relative e.g. "-1w"`,
+		},
+	},
+	Action: actionDecorator(accountingReport),
+}
+
+func accountingReport(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var (
+		req = &lnrpc.AccountingReportRequest{}
+		now = time.Now()
+		err error
+	)
+	if ctx.IsSet("start_time") {
+		req.StartTime, err = parseTime(ctx.String("start_time"), now)
+		if err != nil {
+			return fmt.Errorf("unable to decode start_time: %v",
+				err)
+		}
+	}
+
+	if ctx.IsSet("end_time") {
+		req.EndTime, err = parseTime(ctx.String("end_time"), now)
+		if err != nil {
+			return fmt.Errorf("unable to decode end_time: %v", err)
+		}
+	}
+
+	resp, err := client.AccountingReport(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/urfave/cli"
+)
+
+var deletePaymentsCommand = cli.Command{
+	Name:     "deletepayments",
+	Category: "Payments",
+	Usage:    "Delete completed outgoing payments from the database.",
+	Description: `
+	Delete all completed (succeeded or failed) outgoing payments from the
+	database. In-flight payments are never deleted.
+
+	The deletion can be restricted to failed payments (--failed_only) and
+	to payments that were created before a given time (--created_before).
+	The time can be expressed in seconds since the Unix epoch, or as a
+	negative time range, e.g. "-1M" to delete all payments older than a
+	month. If --failed_htlcs_only is set, only the failed HTLC attempts of
+	the payments are deleted, not the payments themselves.
+
+	If --archive is set, the payments are appended to the payments archive
+	file (--paymentsarchivefile) before they are deleted.
+	`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "failed_only",
+			Usage: "only delete failed payments",
+		},
+		cli.BoolFlag{
+			Name: "failed_htlcs_only",
+			Usage: "only delete the failed HTLC attempts of the " +
+				"payments, not the payments themselves",
+		},
+		cli.StringFlag{
+			Name: "created_before",
+			Usage: "only delete payments created before this " +
+				`time as unix timestamp or relative e.g. "-1M"`,
+		},
+		cli.BoolFlag{
+			Name: "archive",
+			Usage: "archive the payments to the payments archive " +
+				"file before deleting them",
+		},
+	},
+	Action: actionDecorator(deletePayments),
+}
+
+func deletePayments(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	req := &lnrpc.DeleteAllPaymentsRequest{
+		FailedPaymentsOnly: ctx.Bool("failed_only"),
+		FailedHtlcsOnly:    ctx.Bool("failed_htlcs_only"),
+		Archive:            ctx.Bool("archive"),
+	}
+
+	if ctx.IsSet("created_before") {
+		createdBefore, err := parseTime(
+			ctx.String("created_before"), time.Now(),
+		)
+		if err != nil {
+			return fmt.Errorf("unable to decode created_before: %v",
+				err)
+		}
+		req.CreatedBefore = int64(createdBefore)
+	}
+
+	resp, err := client.DeleteAllPayments(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var deletePaymentCommand = cli.Command{
+	Name:      "deletepayment",
+	Category:  "Payments",
+	Usage:     "Delete a single completed outgoing payment.",
+	ArgsUsage: "payment_hash",
+	Description: `
+	Delete the completed outgoing payment with the given payment hash from
+	the database. If --failed_htlcs_only is set, only the failed HTLC
+	attempts of the payment are deleted, not the payment itself.
+
+	If --archive is set, the payment is appended to the payments archive
+	file (--paymentsarchivefile) before it is deleted.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "payment_hash",
+			Usage: "the hash of the payment to delete",
+		},
+		cli.BoolFlag{
+			Name: "failed_htlcs_only",
+			Usage: "only delete the failed HTLC attempts of the " +
+				"payment, not the payment itself",
+		},
+		cli.BoolFlag{
+			Name: "archive",
+			Usage: "archive the payment to the payments archive " +
+				"file before deleting it",
+		},
+	},
+	Action: actionDecorator(deletePayment),
+}
+
+func deletePayment(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var hashStr string
+	switch {
+	case ctx.IsSet("payment_hash"):
+		hashStr = ctx.String("payment_hash")
+	case ctx.Args().Present():
+		hashStr = ctx.Args().First()
+	default:
+		return fmt.Errorf("payment_hash argument missing")
+	}
+
+	hash, err := hex.DecodeString(hashStr)
+	if err != nil {
+		return fmt.Errorf("unable to decode payment_hash: %v", err)
+	}
+
+	resp, err := client.DeletePayment(ctxc, &lnrpc.DeletePaymentRequest{
+		PaymentHash:     hash,
+		FailedHtlcsOnly: ctx.Bool("failed_htlcs_only"),
+		Archive:         ctx.Bool("archive"),
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/urfave/cli"
+)
+
+var feeAutopilotProposalsCommand = cli.Command{
+	Name:     "feeautopilotproposals",
+	Category: "Channels",
+	Usage: "Display the fee changes the fee autopilot proposed in its " +
+		"most recent evaluation.",
+	Description: `
+	Returns the fee changes the fee autopilot proposed in its most recent
+	evaluation of the node's channels, and whether they were applied. If
+	lnd was started with --feeautopilot.dry-run, the proposals are only
+	reported and never applied.`,
+	Action: actionDecorator(feeAutopilotProposals),
+}
+
+func feeAutopilotProposals(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	req := &lnrpc.FeeAutopilotProposalsRequest{}
+	resp, err := client.FeeAutopilotProposals(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/urfave/cli"
+)
+
+var htlcEventHistoryCommand = cli.Command{
+	Name:     "htlceventhistory",
+	Category: "Payments",
+	Usage:    "Query the outcome of all HTLCs we were asked to forward.",
+	Description: `
+	Query the persistent HTLC event log for the outcome of the HTLCs that
+	we were asked to forward over a particular time range (--start_time and
+	--end_time), including the forwards that failed and the reason they
+	failed. The log is only populated if lnd runs with the
+	--record-htlc-events option.
+
+	The start and end times can be expressed in seconds since the Unix
+	epoch, or as negative time ranges, e.g. "-3d". If --start_time isn't
+	provided, then 24 hours ago is used. If --end_time isn't provided, then
+	the current time is used.
+
+	The results can be restricted to HTLCs that arrived on or were
+	forwarded over particular channels using --incoming_chan_id and
+	--outgoing_chan_id, which may both be repeated. Each response contains
+	the offset index of the last entry, which can be passed as
+	--index_offset to page through the results.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "start_time",
+			Usage: "the starting time for the query " +
+				`as unix timestamp or relative e.g. "-1w"`,
+		},
+		cli.StringFlag{
+			Name: "end_time",
+			Usage: "the end time for the query " +
+				`as unix timestamp or relative e.g. "-1w"`,
+		},
+		cli.Int64SliceFlag{
+			Name: "incoming_chan_id",
+			Usage: "only return HTLCs that arrived on this " +
+				"channel, can be specified multiple times",
+		},
+		cli.Int64SliceFlag{
+			Name: "outgoing_chan_id",
+			Usage: "only return HTLCs that were forwarded over " +
+				"this channel, can be specified multiple times",
+		},
+		cli.Int64Flag{
+			Name:  "index_offset",
+			Usage: "the number of events to skip",
+		},
+		cli.Int64Flag{
+			Name:  "max_events",
+			Usage: "the max number of events to return",
+		},
+	},
+	Action: actionDecorator(htlcEventHistory),
+}
+
+func htlcEventHistory(ctx *cli.Context) error {
+	ctxc := getContext()
+	conn := getClientConn(ctx, false)
+	defer conn.Close()
+
+	now := time.Now()
+
+	startTime := uint64(now.Add(-time.Hour * 24).Unix())
+	if ctx.IsSet("start_time") {
+		var err error
+		startTime, err = parseTime(ctx.String("start_time"), now)
+		if err != nil {
+			return fmt.Errorf("unable to decode start_time: %v",
+				err)
+		}
+	}
+
+	endTime := uint64(now.Unix())
+	if ctx.IsSet("end_time") {
+		var err error
+		endTime, err = parseTime(ctx.String("end_time"), now)
+		if err != nil {
+			return fmt.Errorf("unable to decode end_time: %v", err)
+		}
+	}
+
+	req := &routerrpc.HtlcEventHistoryRequest{
+		StartTime:    startTime,
+		EndTime:      endTime,
+		IndexOffset:  uint32(ctx.Int64("index_offset")),
+		NumMaxEvents: uint32(ctx.Int64("max_events")),
+	}
+	for _, chanID := range ctx.Int64Slice("incoming_chan_id") {
+		req.IncomingChanIds = append(req.IncomingChanIds, uint64(chanID))
+	}
+	for _, chanID := range ctx.Int64Slice("outgoing_chan_id") {
+		req.OutgoingChanIds = append(req.OutgoingChanIds, uint64(chanID))
+	}
+
+	client := routerrpc.NewRouterClient(conn)
+	resp, err := client.HtlcEventHistory(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/urfave/cli"
@@ -71,6 +72,29 @@ var addInvoiceCommand = cli.Command{
 			Usage: "creates an AMP invoice. If true, preimage " +
 				"should not be set.",
 		},
+		cli.StringFlag{
+			Name: "payment_metadata",
+			Usage: "hex encoded opaque metadata to include in the " +
+				"payment request, which the payer will send " +
+				"back along with the payment",
+		},
+		cli.Uint64Flag{
+			Name: "max_payment_msat",
+			Usage: "the maximum amount in millisatoshis a single " +
+				"payment to the invoice may pay, which caps " +
+				"the overpayment of the invoice",
+		},
+		cli.BoolFlag{
+			Name: "accept_partial",
+			Usage: "accept incomplete multi-part payments once " +
+				"the htlc hold duration expires",
+		},
+		cli.Uint64Flag{
+			Name: "max_amt_paid_msat",
+			Usage: "make the AMP invoice reusable until the " +
+				"total amount paid to it reaches this amount " +
+				"in millisatoshis, requires --amp",
+		},
 	},
 	Action: actionDecorator(addInvoice),
 }
@@ -79,6 +103,7 @@ func addInvoice(ctx *cli.Context) error {
 	var (
 		preimage []byte
 		descHash []byte
+		metadata []byte
 		amt      int64
 		amtMsat  int64
 		err      error
@@ -115,6 +140,11 @@ func addInvoice(ctx *cli.Context) error {
 		return fmt.Errorf("unable to parse description_hash: %v", err)
 	}
 
+	metadata, err = hex.DecodeString(ctx.String("payment_metadata"))
+	if err != nil {
+		return fmt.Errorf("unable to parse payment_metadata: %v", err)
+	}
+
 	invoice := &lnrpc.Invoice{
 		Memo:            ctx.String("memo"),
 		RPreimage:       preimage,
@@ -125,6 +155,10 @@ func addInvoice(ctx *cli.Context) error {
 		Expiry:          ctx.Int64("expiry"),
 		Private:         ctx.Bool("private"),
 		IsAmp:           ctx.Bool("amp"),
+		PaymentMetadata: metadata,
+		MaxPaymentMsat:  ctx.Uint64("max_payment_msat"),
+		AcceptPartial:   ctx.Bool("accept_partial"),
+		MaxAmtPaidMsat:  ctx.Uint64("max_amt_paid_msat"),
 	}
 
 	resp, err := client.AddInvoice(ctxc, invoice)
@@ -231,6 +265,52 @@ var listInvoicesCommand = cli.Command{
 			Usage: "if set, invoices succeeding the " +
 				"index_offset will be returned",
 		},
+		cli.BoolFlag{
+			Name: "archived",
+			Usage: "if set, invoices are returned from the " +
+				"invoice archive instead of the live invoices",
+		},
+		cli.Uint64Flag{
+			Name: "creation_date_start",
+			Usage: "if set, only invoices created at or after " +
+				"this unix timestamp are returned",
+		},
+		cli.Uint64Flag{
+			Name: "creation_date_end",
+			Usage: "if set, only invoices created at or before " +
+				"this unix timestamp are returned",
+		},
+		cli.Uint64Flag{
+			Name: "settle_date_start",
+			Usage: "if set, only invoices settled at or after " +
+				"this unix timestamp are returned",
+		},
+		cli.Uint64Flag{
+			Name: "settle_date_end",
+			Usage: "if set, only invoices settled at or before " +
+				"this unix timestamp are returned",
+		},
+		cli.StringSliceFlag{
+			Name: "state",
+			Usage: "if set, only invoices in this state (open, " +
+				"settled, canceled or accepted) are " +
+				"returned, can be specified multiple times",
+		},
+		cli.Uint64Flag{
+			Name: "min_amt_msat",
+			Usage: "if set, only invoices with a value of at " +
+				"least this amount are returned",
+		},
+		cli.Uint64Flag{
+			Name: "max_amt_msat",
+			Usage: "if set, only invoices with a value of at " +
+				"most this amount are returned",
+		},
+		cli.StringFlag{
+			Name: "memo_contains",
+			Usage: "if set, only invoices whose memo contains " +
+				"this string are returned",
+		},
 	},
 	Action: actionDecorator(listInvoices),
 }
@@ -241,10 +321,30 @@ func listInvoices(ctx *cli.Context) error {
 	defer cleanUp()
 
 	req := &lnrpc.ListInvoiceRequest{
-		PendingOnly:    ctx.Bool("pending_only"),
-		IndexOffset:    ctx.Uint64("index_offset"),
-		NumMaxInvoices: ctx.Uint64("max_invoices"),
-		Reversed:       !ctx.Bool("paginate-forwards"),
+		PendingOnly:       ctx.Bool("pending_only"),
+		IndexOffset:       ctx.Uint64("index_offset"),
+		NumMaxInvoices:    ctx.Uint64("max_invoices"),
+		Reversed:          !ctx.Bool("paginate-forwards"),
+		Archived:          ctx.Bool("archived"),
+		CreationDateStart: ctx.Uint64("creation_date_start"),
+		CreationDateEnd:   ctx.Uint64("creation_date_end"),
+		SettleDateStart:   ctx.Uint64("settle_date_start"),
+		SettleDateEnd:     ctx.Uint64("settle_date_end"),
+		MinValueMsat:      ctx.Uint64("min_amt_msat"),
+		MaxValueMsat:      ctx.Uint64("max_amt_msat"),
+		MemoContains:      ctx.String("memo_contains"),
+	}
+
+	for _, state := range ctx.StringSlice("state") {
+		stateName := strings.ToUpper(state)
+		rpcState, ok := lnrpc.Invoice_InvoiceState_value[stateName]
+		if !ok {
+			return fmt.Errorf("unknown invoice state %v", state)
+		}
+
+		req.States = append(
+			req.States, lnrpc.Invoice_InvoiceState(rpcState),
+		)
 	}
 
 	invoices, err := client.ListInvoices(ctxc, req)
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/urfave/cli"
+)
+
+var lnurlCommand = cli.Command{
+	Name:     "lnurl",
+	Category: "Invoices",
+	Usage:    "Manage the users of the LNURL-pay server.",
+	Description: `
+	Manage the users of the LNURL-pay server. Every user can be paid
+	through the lightning address <username>@<domain> once the LNURL-pay
+	server is activated with the lnurl.active and lnurl.domain options.
+	`,
+	Subcommands: []cli.Command{
+		lnurlAddUserCommand,
+		lnurlListUsersCommand,
+		lnurlDeleteUserCommand,
+	},
+}
+
+var lnurlAddUserCommand = cli.Command{
+	Name:      "adduser",
+	Usage:     "Add or update a user of the LNURL-pay server.",
+	ArgsUsage: "username",
+	Description: `
+	Add a user to the LNURL-pay server. If a user with the same name
+	already exists, it is replaced. User names may only contain the
+	characters a-z, 0-9, '-', '_' and '.'.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "username",
+			Usage: "the name of the user, which is the local " +
+				"part of the user's lightning address",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "the description that is shown to payers",
+		},
+		cli.Uint64Flag{
+			Name: "min_sendable_msat",
+			Usage: "the minimum amount that can be paid to the " +
+				"user",
+			Value: 1000,
+		},
+		cli.Uint64Flag{
+			Name: "max_sendable_msat",
+			Usage: "the maximum amount that can be paid to the " +
+				"user",
+		},
+		cli.Uint64Flag{
+			Name: "comment_allowed",
+			Usage: "the maximum length of the comments payers " +
+				"can attach to payments, 0 disallows comments",
+		},
+	},
+	Action: actionDecorator(lnurlAddUser),
+}
+
+func lnurlAddUser(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var username string
+	switch {
+	case ctx.IsSet("username"):
+		username = ctx.String("username")
+	case ctx.Args().Present():
+		username = ctx.Args().First()
+	default:
+		return fmt.Errorf("username argument missing")
+	}
+
+	if !ctx.IsSet("max_sendable_msat") {
+		return fmt.Errorf("max_sendable_msat must be set")
+	}
+
+	resp, err := client.AddLnurlUser(ctxc, &lnrpc.LnurlUser{
+		Username:        username,
+		Description:     ctx.String("description"),
+		MinSendableMsat: ctx.Uint64("min_sendable_msat"),
+		MaxSendableMsat: ctx.Uint64("max_sendable_msat"),
+		CommentAllowed:  uint32(ctx.Uint64("comment_allowed")),
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var lnurlListUsersCommand = cli.Command{
+	Name:   "listusers",
+	Usage:  "List the users of the LNURL-pay server.",
+	Action: actionDecorator(lnurlListUsers),
+}
+
+func lnurlListUsers(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	resp, err := client.ListLnurlUsers(
+		ctxc, &lnrpc.ListLnurlUsersRequest{},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var lnurlDeleteUserCommand = cli.Command{
+	Name:      "deleteuser",
+	Usage:     "Remove a user from the LNURL-pay server.",
+	ArgsUsage: "username",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "username",
+			Usage: "the name of the user to remove",
+		},
+	},
+	Action: actionDecorator(lnurlDeleteUser),
+}
+
+func lnurlDeleteUser(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var username string
+	switch {
+	case ctx.IsSet("username"):
+		username = ctx.String("username")
+	case ctx.Args().Present():
+		username = ctx.Args().First()
+	default:
+		return fmt.Errorf("username argument missing")
+	}
+
+	resp, err := client.DeleteLnurlUser(ctxc, &lnrpc.DeleteLnurlUserRequest{
+		Username: username,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -200,6 +201,8 @@ var openChannelCommand = cli.Command{
 			Usage: "(optional) the maximum value in msat that " +
 				"can be pending within the channel at any given time",
 		},
+		utxoFlag,
+		coinSelectionStrategyFlag,
 	},
 	Action: actionDecorator(openChannel),
 }
@@ -228,6 +231,11 @@ func openChannel(ctx *cli.Context) error {
 		return err
 	}
 
+	utxos, strategy, err := parseCoinSelection(ctx)
+	if err != nil {
+		return err
+	}
+
 	minConfs := int32(ctx.Uint64("min_confs"))
 	req := &lnrpc.OpenChannelRequest{
 		TargetConf:                 int32(ctx.Int64("conf_target")),
@@ -239,6 +247,8 @@ func openChannel(ctx *cli.Context) error {
 		CloseAddress:               ctx.String("close_address"),
 		RemoteMaxValueInFlightMsat: ctx.Uint64("remote_max_value_in_flight_msat"),
 		MaxLocalCsv:                uint32(ctx.Uint64("max_local_csv")),
+		Utxos:                      utxos,
+		CoinSelectionStrategy:      strategy,
 	}
 
 	switch {
@@ -802,3 +812,156 @@ func finalizeMsgFromString(tx string,
 		},
 	}, nil
 }
+
+var batchOpenChannelCommand = cli.Command{
+	Name:     "batchopenchannel",
+	Category: "Channels",
+	Usage: "Open multiple channels to existing peers in a single " +
+		"transaction.",
+	Description: `
+	Attempt to open one or more new channels to existing peers with the
+	given node-keys.
+
+	Example:
+	lncli batchopenchannel --sat_per_vbyte=5 '[{
+	    "node_pubkey": "02abcdef...",
+	    "local_funding_amount": 500000,
+	    "private": true,
+	    "close_address": "bc1q..."
+	}, {
+	    "node_pubkey": "03fedcba...",
+	    "local_funding_amount": 200000,
+	    "remote_csv_delay": 288
+	}]'
+
+	All nodes listed must already be connected peers, otherwise funding will
+	fail. The channels are only opened if all peers accept them. If any of
+	them fails, all channels of the batch are aborted.
+
+	The optional pending_chan_id field of each channel can be set to a
+	hex-encoded, 32 byte random value that identifies the channel while it
+	is being negotiated.
+	`,
+	ArgsUsage: "channels-json",
+	Flags: []cli.Flag{
+		cli.Int64Flag{
+			Name: "conf_target",
+			Usage: "(optional) the number of blocks that the " +
+				"transaction *should* confirm in, will be " +
+				"used for fee estimation",
+		},
+		cli.Int64Flag{
+			Name: "sat_per_vbyte",
+			Usage: "(optional) a manual fee expressed in " +
+				"sat/vByte that should be used when crafting " +
+				"the transaction",
+		},
+		cli.Uint64Flag{
+			Name: "min_confs",
+			Usage: "(optional) the minimum number of " +
+				"confirmations each one of your outputs used " +
+				"for the funding transaction must satisfy",
+			Value: defaultUtxoMinConf,
+		},
+		txLabelFlag,
+		utxoFlag,
+	},
+	Action: actionDecorator(batchOpenChannel),
+}
+
+type batchChannelJSON struct {
+	NodePubkey         string `json:"node_pubkey,omitempty"`
+	LocalFundingAmount int64  `json:"local_funding_amount,omitempty"`
+	PushSat            int64  `json:"push_sat,omitempty"`
+	Private            bool   `json:"private,omitempty"`
+	MinHtlcMsat        int64  `json:"min_htlc_msat,omitempty"`
+	RemoteCsvDelay     uint32 `json:"remote_csv_delay,omitempty"`
+	CloseAddress       string `json:"close_address,omitempty"`
+	PendingChanID      string `json:"pending_chan_id,omitempty"`
+}
+
+func batchOpenChannel(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	args := ctx.Args()
+
+	// Show command help if no arguments provided
+	if ctx.NArg() == 0 && ctx.NumFlags() == 0 {
+		_ = cli.ShowCommandHelp(ctx, "batchopenchannel")
+		return nil
+	}
+
+	utxos, _, err := parseCoinSelection(ctx)
+	if err != nil {
+		return err
+	}
+
+	minConfs := int32(ctx.Uint64("min_confs"))
+	req := &lnrpc.BatchOpenChannelRequest{
+		TargetConf:       int32(ctx.Int64("conf_target")),
+		SatPerVbyte:      uint64(ctx.Int64("sat_per_vbyte")),
+		Label:            ctx.String(txLabelFlag.Name),
+		Utxos:            utxos,
+		MinConfs:         minConfs,
+		SpendUnconfirmed: minConfs == 0,
+	}
+
+	// The node pubkeys and pending channel IDs are given as hex strings,
+	// so we can't parse the JSON directly into the RPC struct.
+	var jsonChannels []*batchChannelJSON
+	err = json.Unmarshal([]byte(args.First()), &jsonChannels)
+	if err != nil {
+		return fmt.Errorf("error parsing channels JSON: %v", err)
+	}
+
+	req.Channels = make([]*lnrpc.BatchOpenChannel, len(jsonChannels))
+	for idx, jsonChannel := range jsonChannels {
+		pubKeyBytes, err := hex.DecodeString(jsonChannel.NodePubkey)
+		if err != nil {
+			return fmt.Errorf("error parsing node pubkey hex: %v",
+				err)
+		}
+		pendingChanBytes, err := hex.DecodeString(
+			jsonChannel.PendingChanID,
+		)
+		if err != nil {
+			return fmt.Errorf("error parsing pending chan ID: %v",
+				err)
+		}
+
+		req.Channels[idx] = &lnrpc.BatchOpenChannel{
+			NodePubkey:         pubKeyBytes,
+			LocalFundingAmount: jsonChannel.LocalFundingAmount,
+			PushSat:            jsonChannel.PushSat,
+			Private:            jsonChannel.Private,
+			MinHtlcMsat:        jsonChannel.MinHtlcMsat,
+			RemoteCsvDelay:     jsonChannel.RemoteCsvDelay,
+			CloseAddress:       jsonChannel.CloseAddress,
+			PendingChanId:      pendingChanBytes,
+		}
+	}
+
+	resp, err := client.BatchOpenChannel(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	for _, pending := range resp.PendingChannels {
+		txid, err := chainhash.NewHash(pending.Txid)
+		if err != nil {
+			return err
+		}
+
+		printJSON(struct {
+			FundingTxid        string `json:"funding_txid"`
+			FundingOutputIndex uint32 `json:"funding_output_index"`
+		}{
+			FundingTxid:        txid.String(),
+			FundingOutputIndex: pending.OutputIndex,
+		})
+	}
+
+	return nil
+}
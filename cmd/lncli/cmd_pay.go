@@ -93,6 +93,12 @@ var (
 		Usage: "if set to true, then AMP will be used to complete the " +
 			"payment",
 	}
+
+	paymentLabelFlag = cli.StringFlag{
+		Name: "label",
+		Usage: "(optional) a label that is stored with the payment, " +
+			"which can be used to look it up with listpayments",
+	}
 )
 
 // paymentFlags returns common flags for sendpayment and payinvoice.
@@ -138,6 +144,7 @@ func paymentFlags() []cli.Flag {
 		},
 		dataFlag, inflightUpdatesFlag, maxPartsFlag, jsonFlag,
 		maxShardSizeSatFlag, maxShardSizeMsatFlag, ampFlag,
+		paymentLabelFlag,
 	}
 }
 
@@ -398,6 +405,7 @@ func sendPaymentRequest(ctx *cli.Context,
 	}
 
 	req.CltvLimit = int32(ctx.Int(cltvLimitFlag.Name))
+	req.PaymentLabel = ctx.String(paymentLabelFlag.Name)
 
 	pmtTimeout := ctx.Duration("timeout")
 	if pmtTimeout <= 0 {
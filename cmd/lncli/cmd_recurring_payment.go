@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/urfave/cli"
+)
+
+var recurringPaymentCommand = cli.Command{
+	Name:     "recurringpayment",
+	Category: "Payments",
+	Usage:    "Manage payments that are sent repeatedly.",
+	Description: `
+	Manage payments that are sent repeatedly at a fixed interval, either
+	as keysend payments to a node or as AMP payments to a reusable AMP
+	invoice. The payments are sent by lnd itself, and failed runs are
+	retried with exponential backoff.
+	`,
+	Subcommands: []cli.Command{
+		addRecurringPaymentCommand,
+		listRecurringPaymentsCommand,
+		recurringPaymentHistoryCommand,
+		deleteRecurringPaymentCommand,
+	},
+}
+
+var addRecurringPaymentCommand = cli.Command{
+	Name:  "add",
+	Usage: "Add a recurring payment.",
+	Description: `
+	Add a payment that is sent every interval, starting at start_time. The
+	payment is either a keysend payment to dest, or an AMP payment to
+	amp_invoice.
+
+	Runs that are due while lnd is offline, or while an earlier run is
+	still being retried, are skipped.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "dest",
+			Usage: "the hex encoded public key of the node that " +
+				"keysend payments are sent to",
+		},
+		cli.StringFlag{
+			Name:  "amp_invoice",
+			Usage: "the reusable AMP invoice that is paid",
+		},
+		cli.Uint64Flag{
+			Name: "amt_msat",
+			Usage: "the amount in millisatoshis that is paid in " +
+				"every run, may be omitted if the AMP " +
+				"invoice specifies an amount",
+		},
+		cli.Uint64Flag{
+			Name: "max_fee_msat",
+			Usage: "the maximum routing fee in millisatoshis " +
+				"that is paid in every run",
+		},
+		cli.DurationFlag{
+			Name: "interval",
+			Usage: "the time between two consecutive runs, " +
+				"e.g. 24h",
+		},
+		cli.Int64Flag{
+			Name: "start_time",
+			Usage: "the unix timestamp in seconds of the first " +
+				"run, if not set the first run is started " +
+				"immediately",
+		},
+		cli.Int64Flag{
+			Name: "end_time",
+			Usage: "the unix timestamp in seconds after which " +
+				"no further runs are started",
+		},
+		cli.StringFlag{
+			Name:  "label",
+			Usage: "an optional label of the recurring payment",
+		},
+	},
+	Action: actionDecorator(addRecurringPayment),
+}
+
+func addRecurringPayment(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	if !ctx.IsSet("interval") {
+		return fmt.Errorf("interval must be set")
+	}
+
+	var dest []byte
+	if ctx.IsSet("dest") {
+		var err error
+		dest, err = hex.DecodeString(ctx.String("dest"))
+		if err != nil {
+			return fmt.Errorf("unable to decode dest: %v", err)
+		}
+	}
+
+	req := &lnrpc.AddRecurringPaymentRequest{
+		Label:           ctx.String("label"),
+		Dest:            dest,
+		AmpInvoice:      ctx.String("amp_invoice"),
+		AmtMsat:         ctx.Uint64("amt_msat"),
+		MaxFeeMsat:      ctx.Uint64("max_fee_msat"),
+		IntervalSeconds: uint64(ctx.Duration("interval") / time.Second),
+		StartTime:       ctx.Int64("start_time"),
+		EndTime:         ctx.Int64("end_time"),
+	}
+
+	resp, err := client.AddRecurringPayment(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var listRecurringPaymentsCommand = cli.Command{
+	Name:   "list",
+	Usage:  "List all recurring payments.",
+	Action: actionDecorator(listRecurringPayments),
+}
+
+func listRecurringPayments(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	resp, err := client.ListRecurringPayments(
+		ctxc, &lnrpc.ListRecurringPaymentsRequest{},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var recurringPaymentHistoryCommand = cli.Command{
+	Name:      "history",
+	Usage:     "List the runs of a recurring payment.",
+	ArgsUsage: "id",
+	Flags: []cli.Flag{
+		cli.Uint64Flag{
+			Name:  "id",
+			Usage: "the identifier of the recurring payment",
+		},
+	},
+	Action: actionDecorator(recurringPaymentHistory),
+}
+
+func recurringPaymentHistory(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	id, err := parseRecurringPaymentID(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.RecurringPaymentHistory(
+		ctxc, &lnrpc.RecurringPaymentHistoryRequest{
+			Id: id,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var deleteRecurringPaymentCommand = cli.Command{
+	Name:      "delete",
+	Usage:     "Stop and remove a recurring payment.",
+	ArgsUsage: "id",
+	Description: `
+	Stop a recurring payment and remove it along with the history of its
+	runs. A payment attempt that is in flight is not affected.
+	`,
+	Flags: []cli.Flag{
+		cli.Uint64Flag{
+			Name:  "id",
+			Usage: "the identifier of the recurring payment",
+		},
+	},
+	Action: actionDecorator(deleteRecurringPayment),
+}
+
+func deleteRecurringPayment(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	id, err := parseRecurringPaymentID(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.DeleteRecurringPayment(
+		ctxc, &lnrpc.DeleteRecurringPaymentRequest{
+			Id: id,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+// parseRecurringPaymentID returns the recurring payment identifier that is
+// passed either as the id flag or as the first argument.
+func parseRecurringPaymentID(ctx *cli.Context) (uint64, error) {
+	switch {
+	case ctx.IsSet("id"):
+		return ctx.Uint64("id"), nil
+
+	case ctx.Args().Present():
+		id, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to decode id: %v", err)
+		}
+
+		return id, nil
+
+	default:
+		return 0, fmt.Errorf("id argument missing")
+	}
+}
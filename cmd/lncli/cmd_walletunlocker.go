@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -641,6 +642,189 @@ func changePassword(ctx *cli.Context) error {
 	return nil
 }
 
+var createWatchOnlyCommand = cli.Command{
+	Name:      "createwatchonly",
+	Category:  "Startup",
+	ArgsUsage: "accounts-json-file",
+	Usage: "Initialize a watch-only wallet after starting lnd for the " +
+		"first time.",
+	Description: `
+	The createwatchonly command is used to initialize an lnd wallet that
+	only contains the account public keys of a remote signer instance. All
+	signing operations are then delegated to the remote signer that is
+	configured with the remotesigner.* options. This is an interactive
+	command with one required argument (the password) that is prompted for.
+
+	The first and only argument is the path to a JSON file that contains
+	the output of the 'lncli wallet accounts list' command, executed on
+	the remote signer instance.
+
+	The password is required and MUST be greater than 8 characters. This
+	will be used to encrypt the wallet within lnd. This MUST be remembered
+	as it will be required to fully start up the daemon.
+
+	The --birthday_timestamp flag can be set to the unix timestamp of the
+	creation of the remote signer's wallet to avoid scanning the chain
+	from the genesis block for relevant transactions.
+
+	If the --stateless_init flag is set, no macaroon files are created by
+	the daemon. Instead, the binary serialized admin macaroon is returned
+	in the answer. This answer MUST be stored somewhere, otherwise all
+	access to the RPC server will be lost and the wallet must be recreated
+	to re-gain access.
+	If the --save_to parameter is set, the macaroon is saved to this file,
+	otherwise it is printed to standard out.
+	`,
+	Flags: []cli.Flag{
+		cli.Uint64Flag{
+			Name: "birthday_timestamp",
+			Usage: "the unix timestamp at which the remote " +
+				"signer's wallet was created",
+		},
+		statelessInitFlag,
+		saveToFlag,
+	},
+	Action: actionDecorator(createWatchOnly),
+}
+
+// watchOnlyAccountsFile is the JSON structure of the output of the
+// 'lncli wallet accounts list' command. Only the fields we need for creating
+// the watch-only wallet are decoded.
+type watchOnlyAccountsFile struct {
+	Accounts []struct {
+		ExtendedPublicKey    string `json:"extended_public_key"`
+		MasterKeyFingerprint string `json:"master_key_fingerprint"`
+		DerivationPath       string `json:"derivation_path"`
+	} `json:"accounts"`
+}
+
+func createWatchOnly(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getWalletUnlockerClient(ctx)
+	defer cleanUp()
+
+	if ctx.NArg() != 1 {
+		return cli.ShowCommandHelp(ctx, "createwatchonly")
+	}
+
+	// Should the daemon be initialized stateless? Then we expect an answer
+	// with the admin macaroon later. Because the --save_to is related to
+	// stateless init, it doesn't make sense to be set on its own.
+	statelessInit := ctx.Bool(statelessInitFlag.Name)
+	if !statelessInit && ctx.IsSet(saveToFlag.Name) {
+		return fmt.Errorf("cannot set save_to parameter without " +
+			"stateless_init")
+	}
+
+	jsonFile := lncfg.CleanAndExpandPath(ctx.Args().First())
+	jsonBytes, err := ioutil.ReadFile(jsonFile)
+	if err != nil {
+		return fmt.Errorf("error reading JSON from file %v: %v",
+			jsonFile, err)
+	}
+
+	var accountsFile watchOnlyAccountsFile
+	if err := json.Unmarshal(jsonBytes, &accountsFile); err != nil {
+		return fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	watchOnly := &lnrpc.WatchOnly{
+		MasterKeyBirthdayTimestamp: ctx.Uint64("birthday_timestamp"),
+	}
+	for _, acct := range accountsFile.Accounts {
+		// The default imported account of each key scope doesn't have
+		// an extended public key and is skipped.
+		if acct.ExtendedPublicKey == "" {
+			continue
+		}
+
+		purpose, coinType, account, err := parseDerivationPath(
+			acct.DerivationPath,
+		)
+		if err != nil {
+			return fmt.Errorf("error parsing derivation path of "+
+				"account %v: %v", acct.ExtendedPublicKey, err)
+		}
+
+		// All accounts of the remote signer share the same master key
+		// fingerprint, if it is known.
+		if acct.MasterKeyFingerprint != "" &&
+			len(watchOnly.MasterKeyFingerprint) == 0 {
+
+			watchOnly.MasterKeyFingerprint, err = hex.DecodeString(
+				acct.MasterKeyFingerprint,
+			)
+			if err != nil {
+				return fmt.Errorf("error decoding master key "+
+					"fingerprint: %v", err)
+			}
+		}
+
+		watchOnly.Accounts = append(
+			watchOnly.Accounts, &lnrpc.WatchOnlyAccount{
+				Purpose:  purpose,
+				CoinType: coinType,
+				Account:  account,
+				Xpub:     acct.ExtendedPublicKey,
+			},
+		)
+	}
+
+	walletPassword, err := capturePassword(
+		"Input wallet password: ", false,
+		walletunlocker.ValidatePassword,
+	)
+	if err != nil {
+		return err
+	}
+
+	req := &lnrpc.InitWalletRequest{
+		WalletPassword: walletPassword,
+		WatchOnly:      watchOnly,
+		StatelessInit:  statelessInit,
+	}
+	response, err := client.InitWallet(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nlnd successfully initialized!")
+
+	if statelessInit {
+		return storeOrPrintAdminMac(ctx, response.AdminMacaroon)
+	}
+
+	return nil
+}
+
+// parseDerivationPath parses a path of the form m/purpose'/coin_type'/account'
+// as returned for the wallet accounts by the wallet kit RPC.
+func parseDerivationPath(path string) (uint32, uint32, uint32, error) {
+	elements := strings.Split(path, "/")
+	if len(elements) != 4 || elements[0] != "m" {
+		return 0, 0, 0, fmt.Errorf("invalid derivation path %v", path)
+	}
+
+	var indexes [3]uint32
+	for idx, element := range elements[1:] {
+		if !strings.HasSuffix(element, "'") {
+			return 0, 0, 0, fmt.Errorf("derivation path %v must "+
+				"be hardened", path)
+		}
+
+		index, err := strconv.ParseUint(
+			strings.TrimSuffix(element, "'"), 10, 31,
+		)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid derivation path "+
+				"%v: %v", path, err)
+		}
+		indexes[idx] = uint32(index)
+	}
+
+	return indexes[0], indexes[1], indexes[2], nil
+}
+
 // storeOrPrintAdminMac either stores the admin macaroon to a file specified or
 // prints it to standard out, depending on the user flags set.
 func storeOrPrintAdminMac(ctx *cli.Context, adminMac []byte) error {
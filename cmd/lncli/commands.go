@@ -133,6 +133,15 @@ var newAddressCommand = cli.Command{
 			Usage: "(optional) the name of the account to " +
 				"generate a new address for",
 		},
+		cli.StringFlag{
+			Name:  "label",
+			Usage: "(optional) a label to attach to the address",
+		},
+		cli.StringFlag{
+			Name: "tag",
+			Usage: "(optional) a tag to attach to the address, " +
+				"outputs paying to the address inherit it",
+		},
 	},
 	Description: `
 	Generate a wallet new address. Address-types has to be one of:
@@ -146,7 +155,7 @@ func newAddress(ctx *cli.Context) error {
 
 	// Display the command's help message if we do not have the expected
 	// number of arguments/flags.
-	if ctx.NArg() != 1 || ctx.NumFlags() > 1 {
+	if ctx.NArg() != 1 || ctx.NumFlags() > 3 {
 		return cli.ShowCommandHelp(ctx, "newaddress")
 	}
 
@@ -170,6 +179,8 @@ func newAddress(ctx *cli.Context) error {
 	addr, err := client.NewAddress(ctxc, &lnrpc.NewAddressRequest{
 		Type:    addrType,
 		Account: ctx.String("account"),
+		Label:   ctx.String("label"),
+		Tag:     ctx.String("tag"),
 	})
 	if err != nil {
 		return err
@@ -230,6 +241,56 @@ var txLabelFlag = cli.StringFlag{
 	Usage: "(optional) a label for the transaction",
 }
 
+var utxoFlag = cli.StringSliceFlag{
+	Name: "utxo",
+	Usage: "(optional) a wallet output to spend in the form " +
+		"txid:output_index; can be specified multiple times. If " +
+		"set, exactly these outputs are spent",
+}
+
+var coinSelectionStrategyFlag = cli.StringFlag{
+	Name: "coin_selection_strategy",
+	Usage: "(optional) the strategy to use for selecting coins: " +
+		"'largest', 'random' or 'privacy'. If not set, the " +
+		"strategy from the node's configuration is used",
+}
+
+// parseCoinSelection parses the utxo and coin selection strategy flags.
+func parseCoinSelection(ctx *cli.Context) ([]*lnrpc.OutPoint,
+	lnrpc.CoinSelectionStrategy, error) {
+
+	var utxos []*lnrpc.OutPoint
+	for _, utxo := range ctx.StringSlice(utxoFlag.Name) {
+		outpoint, err := NewProtoOutPoint(utxo)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to parse utxo %v: %v",
+				utxo, err)
+		}
+		utxos = append(utxos, outpoint)
+	}
+
+	var strategy lnrpc.CoinSelectionStrategy
+	switch ctx.String(coinSelectionStrategyFlag.Name) {
+	case "":
+		strategy = lnrpc.CoinSelectionStrategy_STRATEGY_USE_GLOBAL_CONFIG
+
+	case "largest":
+		strategy = lnrpc.CoinSelectionStrategy_STRATEGY_LARGEST
+
+	case "random":
+		strategy = lnrpc.CoinSelectionStrategy_STRATEGY_RANDOM
+
+	case "privacy":
+		strategy = lnrpc.CoinSelectionStrategy_STRATEGY_PRIVACY
+
+	default:
+		return nil, 0, fmt.Errorf("unknown coin selection strategy %v",
+			ctx.String(coinSelectionStrategyFlag.Name))
+	}
+
+	return utxos, strategy, nil
+}
+
 var sendCoinsCommand = cli.Command{
 	Name:      "sendcoins",
 	Category:  "On-chain",
@@ -285,6 +346,8 @@ var sendCoinsCommand = cli.Command{
 			Value: defaultUtxoMinConf,
 		},
 		txLabelFlag,
+		utxoFlag,
+		coinSelectionStrategyFlag,
 	},
 	Action: actionDecorator(sendCoins),
 }
@@ -346,6 +409,11 @@ func sendCoins(ctx *cli.Context) error {
 			"sweep all coins out of the wallet")
 	}
 
+	utxos, strategy, err := parseCoinSelection(ctx)
+	if err != nil {
+		return err
+	}
+
 	client, cleanUp := getClient(ctx)
 	defer cleanUp()
 
@@ -359,6 +427,9 @@ func sendCoins(ctx *cli.Context) error {
 		Label:            ctx.String(txLabelFlag.Name),
 		MinConfs:         minConfs,
 		SpendUnconfirmed: minConfs == 0,
+		Utxos:            utxos,
+
+		CoinSelectionStrategy: strategy,
 	}
 	txid, err := client.SendCoins(ctxc, req)
 	if err != nil {
@@ -528,6 +599,8 @@ var sendManyCommand = cli.Command{
 			Value: defaultUtxoMinConf,
 		},
 		txLabelFlag,
+		utxoFlag,
+		coinSelectionStrategyFlag,
 	},
 	Action: actionDecorator(sendMany),
 }
@@ -557,6 +630,11 @@ func sendMany(ctx *cli.Context) error {
 		return err
 	}
 
+	utxos, strategy, err := parseCoinSelection(ctx)
+	if err != nil {
+		return err
+	}
+
 	client, cleanUp := getClient(ctx)
 	defer cleanUp()
 
@@ -568,6 +646,9 @@ func sendMany(ctx *cli.Context) error {
 		Label:            ctx.String(txLabelFlag.Name),
 		MinConfs:         minConfs,
 		SpendUnconfirmed: minConfs == 0,
+		Utxos:            utxos,
+
+		CoinSelectionStrategy: strategy,
 	})
 	if err != nil {
 		return err
@@ -701,6 +782,12 @@ var closeChannelCommand = cli.Command{
 	--sat_per_vbyte arguments. This will be the starting value used during
 	fee negotiation. This is optional.
 
+	In the case of a unilateral closure of an anchor channel, the same
+	arguments can be used to set the package fee rate of the commitment
+	transaction and the transaction that sweeps its anchor. If neither is
+	set, the fee rate is chosen based on the deadline of the pending HTLCs
+	and bumped every block as the deadline approaches. This is optional.
+
 	In the case of a cooperative closure, one can manually set the address
 	to deliver funds to upon closure. This is optional, and may only be used
 	if an upfront shutdown address has not already been set. If neither are
@@ -1591,6 +1678,53 @@ var listPaymentsCommand = cli.Command{
 				"index_offset will be returned, allowing " +
 				"forwards pagination",
 		},
+		cli.Uint64Flag{
+			Name: "creation_date_start",
+			Usage: "if set, only payments created at or after " +
+				"this unix timestamp are returned",
+		},
+		cli.Uint64Flag{
+			Name: "creation_date_end",
+			Usage: "if set, only payments created at or before " +
+				"this unix timestamp are returned",
+		},
+		cli.StringSliceFlag{
+			Name: "status",
+			Usage: "if set, only payments with this status " +
+				"(in_flight, succeeded or failed) are " +
+				"returned regardless of include_incomplete, " +
+				"can be specified multiple times",
+		},
+		cli.Uint64Flag{
+			Name: "min_amt_msat",
+			Usage: "if set, only payments with a value of at " +
+				"least this amount are returned",
+		},
+		cli.Uint64Flag{
+			Name: "max_amt_msat",
+			Usage: "if set, only payments with a value of at " +
+				"most this amount are returned",
+		},
+		cli.StringFlag{
+			Name: "dest",
+			Usage: "if set, only payments to this destination " +
+				"node pubkey are returned",
+		},
+		cli.StringFlag{
+			Name: "payment_addr",
+			Usage: "if set, only payments with this hex encoded " +
+				"payment address are returned",
+		},
+		cli.StringFlag{
+			Name: "description",
+			Usage: "if set, only payments to invoices with " +
+				"exactly this description are returned",
+		},
+		cli.StringFlag{
+			Name: "label",
+			Usage: "if set, only payments with exactly this " +
+				"label are returned",
+		},
 	},
 	Action: actionDecorator(listPayments),
 }
@@ -1605,6 +1739,41 @@ func listPayments(ctx *cli.Context) error {
 		IndexOffset:       uint64(ctx.Uint("index_offset")),
 		MaxPayments:       uint64(ctx.Uint("max_payments")),
 		Reversed:          !ctx.Bool("paginate_forwards"),
+		CreationDateStart: ctx.Uint64("creation_date_start"),
+		CreationDateEnd:   ctx.Uint64("creation_date_end"),
+		MinValueMsat:      ctx.Uint64("min_amt_msat"),
+		MaxValueMsat:      ctx.Uint64("max_amt_msat"),
+		Description:       ctx.String("description"),
+		PaymentLabel:      ctx.String("label"),
+	}
+
+	if ctx.IsSet("dest") {
+		dest, err := hex.DecodeString(ctx.String("dest"))
+		if err != nil {
+			return fmt.Errorf("unable to decode dest: %v", err)
+		}
+		req.Dest = dest
+	}
+
+	if ctx.IsSet("payment_addr") {
+		payAddr, err := hex.DecodeString(ctx.String("payment_addr"))
+		if err != nil {
+			return fmt.Errorf("unable to decode payment_addr: %v",
+				err)
+		}
+		req.PaymentAddr = payAddr
+	}
+
+	for _, status := range ctx.StringSlice("status") {
+		statusName := strings.ToUpper(status)
+		rpcStatus, ok := lnrpc.Payment_PaymentStatus_value[statusName]
+		if !ok {
+			return fmt.Errorf("unknown payment status %v", status)
+		}
+
+		req.Statuses = append(
+			req.Statuses, lnrpc.Payment_PaymentStatus(rpcStatus),
+		)
 	}
 
 	payments, err := client.ListPayments(ctxc, req)
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"strconv"
+	"time"
 
 	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
 	"github.com/urfave/cli"
@@ -17,7 +18,10 @@ func invoicesCommands() []cli.Command {
 	return []cli.Command{
 		cancelInvoiceCommand,
 		addHoldInvoiceCommand,
+		addStatelessInvoiceCommand,
 		settleInvoiceCommand,
+		getSpontaneousPolicyCommand,
+		setSpontaneousPolicyCommand,
 	}
 }
 
@@ -190,6 +194,17 @@ var addHoldInvoiceCommand = cli.Command{
 				"private channels in order to assist the " +
 				"payer in reaching you",
 		},
+		cli.Uint64Flag{
+			Name: "max_payment_msat",
+			Usage: "the maximum amount in millisatoshis a single " +
+				"payment to the invoice may pay, which caps " +
+				"the overpayment of the invoice",
+		},
+		cli.BoolFlag{
+			Name: "accept_partial",
+			Usage: "accept incomplete multi-part payments once " +
+				"the htlc hold duration expires",
+		},
 	},
 	Action: actionDecorator(addHoldInvoice),
 }
@@ -245,6 +260,8 @@ func addHoldInvoice(ctx *cli.Context) error {
 		FallbackAddr:    ctx.String("fallback_addr"),
 		Expiry:          ctx.Int64("expiry"),
 		Private:         ctx.Bool("private"),
+		MaxPaymentMsat:  ctx.Uint64("max_payment_msat"),
+		AcceptPartial:   ctx.Bool("accept_partial"),
 	}
 
 	resp, err := client.AddHoldInvoice(ctxc, invoice)
@@ -260,3 +277,225 @@ func addHoldInvoice(ctx *cli.Context) error {
 
 	return nil
 }
+
+var addStatelessInvoiceCommand = cli.Command{
+	Name:     "addstatelessinvoice",
+	Category: "Invoices",
+	Usage:    "Add a new stateless invoice.",
+	Description: `
+	Add a new stateless invoice, expressing intent for a future payment.
+
+	Stateless invoices aren't stored in the database until they are paid.
+	Their preimage is derived from a node secret and the payment address,
+	amount and payment metadata of the invoice. Stateless invoices must
+	have an amount. Requires lnd to be started with
+	--accept-stateless-invoices.`,
+	ArgsUsage: "[amt]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "memo",
+			Usage: "a description of the payment to attach along " +
+				"with the invoice (default=\"\")",
+		},
+		cli.Int64Flag{
+			Name:  "amt",
+			Usage: "the amt of satoshis in this invoice",
+		},
+		cli.Int64Flag{
+			Name:  "amt_msat",
+			Usage: "the amt of millisatoshis in this invoice",
+		},
+		cli.StringFlag{
+			Name: "description_hash",
+			Usage: "SHA-256 hash of the description of the payment. " +
+				"Used if the purpose of payment cannot naturally " +
+				"fit within the memo. If provided this will be " +
+				"used instead of the description(memo) field in " +
+				"the encoded invoice.",
+		},
+		cli.StringFlag{
+			Name: "fallback_addr",
+			Usage: "fallback on-chain address that can be used in " +
+				"case the lightning payment fails",
+		},
+		cli.Int64Flag{
+			Name: "expiry",
+			Usage: "the invoice's expiry time in seconds. If not " +
+				"specified, an expiry of 86400 seconds (24 " +
+				"hours) is implied.",
+		},
+		cli.BoolTFlag{
+			Name: "private",
+			Usage: "encode routing hints in the invoice with " +
+				"private channels in order to assist the " +
+				"payer in reaching you",
+		},
+		cli.StringFlag{
+			Name: "payment_metadata",
+			Usage: "hex encoded opaque metadata to include in the " +
+				"payment request, which the payer will send " +
+				"back along with the payment",
+		},
+	},
+	Action: actionDecorator(addStatelessInvoice),
+}
+
+func addStatelessInvoice(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getInvoicesClient(ctx)
+	defer cleanUp()
+
+	var err error
+	args := ctx.Args()
+	amt := ctx.Int64("amt")
+	amtMsat := ctx.Int64("amt_msat")
+	if !ctx.IsSet("amt") && !ctx.IsSet("amt_msat") && args.Present() {
+		amt, err = strconv.ParseInt(args.First(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("unable to decode amt argument: %v", err)
+		}
+	}
+
+	descHash, err := hex.DecodeString(ctx.String("description_hash"))
+	if err != nil {
+		return fmt.Errorf("unable to parse description_hash: %v", err)
+	}
+
+	metadata, err := hex.DecodeString(ctx.String("payment_metadata"))
+	if err != nil {
+		return fmt.Errorf("unable to parse payment_metadata: %v", err)
+	}
+
+	resp, err := client.AddStatelessInvoice(
+		ctxc, &invoicesrpc.AddStatelessInvoiceRequest{
+			Memo:            ctx.String("memo"),
+			Value:           amt,
+			ValueMsat:       amtMsat,
+			DescriptionHash: descHash,
+			FallbackAddr:    ctx.String("fallback_addr"),
+			Expiry:          ctx.Int64("expiry"),
+			Private:         ctx.Bool("private"),
+			PaymentMetadata: metadata,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
+
+var getSpontaneousPolicyCommand = cli.Command{
+	Name:     "getspontaneouspolicy",
+	Category: "Invoices",
+	Usage: "Show the policy that spontaneous keysend and AMP payments " +
+		"must adhere to.",
+	Action: actionDecorator(getSpontaneousPolicy),
+}
+
+func getSpontaneousPolicy(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getInvoicesClient(ctx)
+	defer cleanUp()
+
+	resp, err := client.GetSpontaneousPolicy(
+		ctxc, &invoicesrpc.GetSpontaneousPolicyRequest{},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
+
+var setSpontaneousPolicyCommand = cli.Command{
+	Name:     "setspontaneouspolicy",
+	Category: "Invoices",
+	Usage: "Replace the policy that spontaneous keysend and AMP payments " +
+		"must adhere to.",
+	Description: `
+	Replace the policy that spontaneous keysend and AMP payments must
+	adhere to. Spontaneous payments that violate the policy are failed
+	back. The new policy replaces the current one as a whole, restrictions
+	that are not set are lifted. The policy is not persisted, on restart
+	the policy from the configuration is enforced again.
+	`,
+	Flags: []cli.Flag{
+		cli.Uint64Flag{
+			Name:  "min_amt_msat",
+			Usage: "the minimum amount of spontaneous payments",
+		},
+		cli.Uint64Flag{
+			Name: "max_amt_msat",
+			Usage: "the maximum amount of spontaneous payments, " +
+				"0 means no maximum",
+		},
+		cli.BoolFlag{
+			Name: "restrict_custom_records",
+			Usage: "only allow the custom records that are listed " +
+				"with --allowed_custom_record",
+		},
+		cli.Int64SliceFlag{
+			Name: "allowed_custom_record",
+			Usage: "a custom record type that is allowed if " +
+				"--restrict_custom_records is set, can be " +
+				"specified multiple times",
+		},
+		cli.UintFlag{
+			Name: "rate_limit",
+			Usage: "the maximum number of spontaneous payments " +
+				"per source route, told apart by the peer " +
+				"it reaches us through, within the rate " +
+				"limit interval, 0 disables rate limiting",
+		},
+		cli.DurationFlag{
+			Name:  "rate_limit_interval",
+			Usage: "the interval the rate limit applies to, e.g. 1m",
+		},
+		cli.StringFlag{
+			Name: "invoice_label",
+			Usage: "the memo of the invoices that are created for " +
+				"spontaneous payments",
+		},
+	},
+	Action: actionDecorator(setSpontaneousPolicy),
+}
+
+func setSpontaneousPolicy(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getInvoicesClient(ctx)
+	defer cleanUp()
+
+	var allowedRecords []uint64
+	for _, recordType := range ctx.Int64Slice("allowed_custom_record") {
+		if recordType < 0 {
+			return fmt.Errorf("invalid custom record type %v",
+				recordType)
+		}
+		allowedRecords = append(allowedRecords, uint64(recordType))
+	}
+
+	interval := ctx.Duration("rate_limit_interval")
+	policy := &invoicesrpc.SpontaneousPolicy{
+		MinAmtMsat:            ctx.Uint64("min_amt_msat"),
+		MaxAmtMsat:            ctx.Uint64("max_amt_msat"),
+		RestrictCustomRecords: ctx.Bool("restrict_custom_records"),
+		AllowedCustomRecords:  allowedRecords,
+		RateLimit:             uint32(ctx.Uint("rate_limit")),
+		RateLimitIntervalSec:  uint64(interval / time.Second),
+		InvoiceLabel:          ctx.String("invoice_label"),
+	}
+
+	resp, err := client.SetSpontaneousPolicy(ctxc, policy)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
@@ -324,6 +324,7 @@ func main() {
 	}
 	app.Commands = []cli.Command{
 		createCommand,
+		createWatchOnlyCommand,
 		unlockCommand,
 		changePasswordCommand,
 		newAddressCommand,
@@ -334,6 +335,7 @@ func main() {
 		connectCommand,
 		disconnectCommand,
 		openChannelCommand,
+		batchOpenChannelCommand,
 		closeChannelCommand,
 		closeAllChannelsCommand,
 		abandonChannelCommand,
@@ -349,9 +351,13 @@ func main() {
 		addInvoiceCommand,
 		lookupInvoiceCommand,
 		listInvoicesCommand,
+		lnurlCommand,
 		listChannelsCommand,
 		closedChannelsCommand,
 		listPaymentsCommand,
+		deletePaymentsCommand,
+		deletePaymentCommand,
+		recurringPaymentCommand,
 		describeGraphCommand,
 		getNodeMetricsCommand,
 		getChanInfoCommand,
@@ -366,7 +372,9 @@ func main() {
 		verifyMessageCommand,
 		feeReportCommand,
 		updateChannelPolicyCommand,
+		feeAutopilotProposalsCommand,
 		forwardingHistoryCommand,
+		accountingReportCommand,
 		exportChanBackupCommand,
 		verifyChanBackupCommand,
 		restoreChanBackupCommand,
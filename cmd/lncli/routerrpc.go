@@ -13,5 +13,6 @@ func routerCommands() []cli.Command {
 		getCfgCommand,
 		setCfgCommand,
 		updateChanStatusCommand,
+		htlcEventHistoryCommand,
 	}
 }
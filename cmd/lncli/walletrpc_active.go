@@ -55,10 +55,16 @@ func walletCommands() []cli.Command {
 				pendingSweepsCommand,
 				bumpFeeCommand,
 				bumpCloseFeeCommand,
+				bumpTxFeeCommand,
 				listSweepsCommand,
 				labelTxCommand,
 				releaseOutputCommand,
 				listLeasesCommand,
+				labelAddressCommand,
+				labelOutputCommand,
+				freezeOutputCommand,
+				unfreezeOutputCommand,
+				listFrozenCommand,
 				psbtCommand,
 				accountsCommand,
 			},
@@ -382,6 +388,78 @@ func getWaitingCloseCommitments(client lnrpc.LightningClient,
 	return nil, errors.New("channel not found")
 }
 
+var bumpTxFeeCommand = cli.Command{
+	Name:      "bumptxfee",
+	Usage:     "Bumps the fee of an unconfirmed wallet transaction.",
+	ArgsUsage: "txid",
+	Description: `
+	This command bumps the fee of an unconfirmed transaction created by
+	the wallet, for example by sendcoins or when funding a channel.
+
+	If all inputs of the transaction belong to the wallet, it is re-signed
+	with the new fee rate and published as a replacement (RBF). The higher
+	fee is taken from the wallet's change output.
+
+	If the transaction can't be replaced, for example because it funds a
+	channel, it has no change output, its change is too small or the
+	backend rejects the replacement, the largest wallet output is swept by
+	a child transaction that brings the package to the new fee rate (CPFP).
+	Channel funding transactions are never replaced, as that would change
+	the channel point.
+
+	Sweep transactions are refused, use bumpfee on their inputs instead.
+
+	A fee preference must be provided, either through the conf_target or
+	sat_per_vbyte parameters.
+	`,
+	Flags: []cli.Flag{
+		cli.Uint64Flag{
+			Name: "conf_target",
+			Usage: "the number of blocks that the transaction " +
+				"should confirm within",
+		},
+		cli.Uint64Flag{
+			Name: "sat_per_vbyte",
+			Usage: "a manual fee expressed in sat/vbyte that " +
+				"the transaction should pay",
+		},
+	},
+	Action: actionDecorator(bumpTxFee),
+}
+
+func bumpTxFee(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	// Display the command's help message if we do not have the expected
+	// number of arguments/flags.
+	if ctx.NArg() != 1 {
+		return cli.ShowCommandHelp(ctx, "bumptxfee")
+	}
+
+	hash, err := chainhash.NewHashFromStr(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	client, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	resp, err := client.BumpTransactionFee(
+		ctxc, &walletrpc.BumpTransactionFeeRequest{
+			Txid:        hash[:],
+			TargetConf:  uint32(ctx.Uint64("conf_target")),
+			SatPerVbyte: ctx.Uint64("sat_per_vbyte"),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
+
 var listSweepsCommand = cli.Command{
 	Name:  "listsweeps",
 	Usage: "Lists all sweeps that have been published by our node.",
@@ -1066,3 +1144,185 @@ func importPubKey(ctx *cli.Context) error {
 	printRespJSON(resp)
 	return nil
 }
+
+var labelAddressCommand = cli.Command{
+	Name:      "labeladdress",
+	Usage:     "Attach a label and a tag to a wallet address.",
+	ArgsUsage: "address",
+	Description: `
+	Attach a label and a tag to an address of the wallet. Outputs paying
+	to the address inherit its label and tag unless they have their own.
+	The balance of tagged outputs is broken down by tag in walletbalance.
+	Omitting the label or the tag removes it.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "label",
+			Usage: "the label of the address",
+		},
+		cli.StringFlag{
+			Name:  "tag",
+			Usage: "the tag of the address",
+		},
+	},
+	Action: actionDecorator(labelAddress),
+}
+
+func labelAddress(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	// Display the command's help message if we do not have the expected
+	// number of arguments/flags.
+	if ctx.NArg() != 1 {
+		return cli.ShowCommandHelp(ctx, "labeladdress")
+	}
+
+	walletClient, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	_, err := walletClient.LabelAddress(ctxc, &walletrpc.LabelAddressRequest{
+		Address: ctx.Args().First(),
+		Label:   ctx.String("label"),
+		Tag:     ctx.String("tag"),
+	})
+	return err
+}
+
+var labelOutputCommand = cli.Command{
+	Name:      "labeloutput",
+	Usage:     "Attach a label and a tag to a wallet output.",
+	ArgsUsage: "outpoint",
+	Description: `
+	Attach a label and a tag to an output of the wallet. They take
+	precedence over the label and tag of the output's address. Omitting
+	the label or the tag removes it.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "label",
+			Usage: "the label of the output",
+		},
+		cli.StringFlag{
+			Name:  "tag",
+			Usage: "the tag of the output",
+		},
+	},
+	Action: actionDecorator(labelOutput),
+}
+
+func labelOutput(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	// Display the command's help message if we do not have the expected
+	// number of arguments/flags.
+	if ctx.NArg() != 1 {
+		return cli.ShowCommandHelp(ctx, "labeloutput")
+	}
+
+	outpoint, err := NewProtoOutPoint(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("error parsing outpoint: %v", err)
+	}
+
+	walletClient, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	_, err = walletClient.LabelOutput(ctxc, &walletrpc.LabelOutputRequest{
+		Outpoint: outpoint,
+		Label:    ctx.String("label"),
+		Tag:      ctx.String("tag"),
+	})
+	return err
+}
+
+var freezeOutputCommand = cli.Command{
+	Name:      "freezeoutput",
+	Usage:     "Exclude a wallet output from coin selection.",
+	ArgsUsage: "outpoint",
+	Description: `
+	Freeze an unspent output of the wallet, so it is never spent
+	automatically. Unlike a lease, the freeze persists across restarts and
+	doesn't expire. Frozen outputs are still listed by listunspent, with
+	their frozen flag set.
+	`,
+	Action: actionDecorator(freezeOutput),
+}
+
+func freezeOutput(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	// Display the command's help message if we do not have the expected
+	// number of arguments/flags.
+	if ctx.NArg() != 1 {
+		return cli.ShowCommandHelp(ctx, "freezeoutput")
+	}
+
+	outpoint, err := NewProtoOutPoint(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("error parsing outpoint: %v", err)
+	}
+
+	walletClient, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	_, err = walletClient.FreezeOutput(ctxc, &walletrpc.FreezeOutputRequest{
+		Outpoint: outpoint,
+	})
+	return err
+}
+
+var unfreezeOutputCommand = cli.Command{
+	Name:      "unfreezeoutput",
+	Usage:     "Make a frozen wallet output available to coin selection.",
+	ArgsUsage: "outpoint",
+	Action:    actionDecorator(unfreezeOutput),
+}
+
+func unfreezeOutput(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	// Display the command's help message if we do not have the expected
+	// number of arguments/flags.
+	if ctx.NArg() != 1 {
+		return cli.ShowCommandHelp(ctx, "unfreezeoutput")
+	}
+
+	outpoint, err := NewProtoOutPoint(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("error parsing outpoint: %v", err)
+	}
+
+	walletClient, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	_, err = walletClient.UnfreezeOutput(
+		ctxc, &walletrpc.UnfreezeOutputRequest{
+			Outpoint: outpoint,
+		},
+	)
+	return err
+}
+
+var listFrozenCommand = cli.Command{
+	Name:   "listfrozen",
+	Usage:  "List all frozen wallet outputs.",
+	Action: actionDecorator(listFrozen),
+}
+
+func listFrozen(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	walletClient, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	response, err := walletClient.ListFrozenOutputs(
+		ctxc, &walletrpc.ListFrozenOutputsRequest{},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(response)
+
+	return nil
+}
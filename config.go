@@ -160,6 +160,17 @@ const (
 	// defaultCoinSelectionStrategy is the coin selection strategy that is
 	// used by default to fund transactions.
 	defaultCoinSelectionStrategy = "largest"
+
+	// defaultAnchorReserveCheckInterval is the default interval at which
+	// we warn if the wallet balance is below the value reserved for fee
+	// bumping anchor channels.
+	defaultAnchorReserveCheckInterval = time.Hour
+
+	// minArchiveSettledInvoicesAfter is the shortest time after which
+	// settled invoices may be archived. It leaves the htlcs that paid an
+	// invoice enough time to be resolved against it, even if they have to
+	// be resolved on chain.
+	minArchiveSettledInvoicesAfter = 24 * time.Hour
 )
 
 var (
@@ -275,6 +286,8 @@ type Config struct {
 	MaxPendingChannels int    `long:"maxpendingchannels" description:"The maximum number of incoming pending channels permitted per peer."`
 	BackupFilePath     string `long:"backupfilepath" description:"The target location of the channel backup file"`
 
+	PaymentsArchiveFile string `long:"paymentsarchivefile" description:"The location of the file that deleted payments are archived to, if archiving is requested when deleting them"`
+
 	FeeURL string `long:"feeurl" description:"Optional URL for external fee estimation. If no URL is specified, the method for fee estimation will depend on the chosen backend and network. Must be set for neutrino on mainnet."`
 
 	Bitcoin      *lncfg.Chain    `group:"Bitcoin" namespace:"bitcoin"`
@@ -304,7 +317,9 @@ type Config struct {
 
 	ResetWalletTransactions bool `long:"reset-wallet-transactions" description:"Removes all transaction history from the on-chain wallet on startup, forcing a full chain rescan starting at the wallet's birthday. Implements the same functionality as btcwallet's dropwtxmgr command. Should be set to false after successful execution to avoid rescanning on every restart of lnd."`
 
-	CoinSelectionStrategy string `long:"coin-selection-strategy" description:"The strategy to use for selecting coins for wallet transactions." choice:"largest" choice:"random"`
+	CoinSelectionStrategy string `long:"coin-selection-strategy" description:"The strategy to use for selecting coins for wallet transactions. The privacy strategy spends all coins of an address together to avoid linking re-used addresses across transactions." choice:"largest" choice:"random" choice:"privacy"`
+
+	AnchorReserveCheckInterval time.Duration `long:"anchor-reserve-check-interval" description:"The interval at which a warning is logged if the wallet balance is below the value reserved for fee bumping anchor channels. The check also runs on startup. Set to 0 to disable the warning."`
 
 	PaymentsExpirationGracePeriod time.Duration `long:"payments-expiration-grace-period" description:"A period to wait before force closing channels with outgoing htlcs that have timed-out and are a result of this node initiated payments."`
 	TrickleDelay                  int           `long:"trickledelay" description:"Time in milliseconds between each release of announcements to the network"`
@@ -332,6 +347,8 @@ type Config struct {
 
 	RejectHTLC bool `long:"rejecthtlc" description:"If true, lnd will not forward any HTLCs that are meant as onward payments. This option will still allow lnd to send HTLCs and receive HTLCs but lnd won't be used as a hop."`
 
+	RecordHtlcEvents bool `long:"record-htlc-events" description:"If true, lnd will persist the outcome of every HTLC it is asked to forward, including failed forwards and their failure reasons, in the HTLC event log."`
+
 	StaggerInitialReconnect bool `long:"stagger-initial-reconnect" description:"If true, will apply a randomized staggering between 0s and 30s when reconnecting to persistent peers on startup. The first 10 reconnections will be attempted instantly, regardless of the flag's value"`
 
 	MaxOutgoingCltvExpiry uint32 `long:"max-cltv-expiry" description:"The maximum number of blocks funds could be locked up for when forwarding payments."`
@@ -350,16 +367,32 @@ type Config struct {
 
 	AcceptAMP bool `long:"accept-amp" description:"If true, spontaneous payments via AMP will be accepted."`
 
+	AcceptStatelessInvoices bool `long:"accept-stateless-invoices" description:"If true, payments to stateless invoices will be accepted. Stateless invoices aren't stored in the database until they are paid, their preimage is derived from a node secret and the payment details instead."`
+
 	KeysendHoldTime time.Duration `long:"keysend-hold-time" description:"If non-zero, keysend payments are accepted but not immediately settled. If the payment isn't settled manually after the specified time, it is canceled automatically. [experimental]"`
 
 	GcCanceledInvoicesOnStartup bool `long:"gc-canceled-invoices-on-startup" description:"If true, we'll attempt to garbage collect canceled invoices upon start."`
 
 	GcCanceledInvoicesOnTheFly bool `long:"gc-canceled-invoices-on-the-fly" description:"If true, we'll delete newly canceled invoices on the fly."`
 
+	ArchiveSettledInvoicesAfter time.Duration `long:"archive-settled-invoices-after" description:"If non-zero, invoices that were settled longer ago than this duration are moved out of the live invoice indexes into the invoice archive, on startup and once a day. Must be at least 24h. Invoices are only archived once the htlcs that paid them are resolved. Archived invoices can still be retrieved with LookupInvoice and with ListInvoices by setting the archived flag. AMP invoices are never archived."`
+
 	Invoices *lncfg.Invoices `group:"invoices" namespace:"invoices"`
 
+	Spontaneous *lncfg.Spontaneous `group:"spontaneous" namespace:"spontaneous"`
+
 	Routing *lncfg.Routing `group:"routing" namespace:"routing"`
 
+	HtlcLimits *lncfg.HtlcLimits `group:"htlclimits" namespace:"htlclimits"`
+
+	FeeAutopilot *lncfg.FeeAutopilot `group:"feeautopilot" namespace:"feeautopilot"`
+
+	Accounting *lncfg.Accounting `group:"accounting" namespace:"accounting"`
+
+	Lnurl *lncfg.Lnurl `group:"lnurl" namespace:"lnurl"`
+
+	RemoteSigner *lncfg.RemoteSigner `group:"remotesigner" namespace:"remotesigner"`
+
 	Gossip *lncfg.Gossip `group:"gossip" namespace:"gossip"`
 
 	Workers *lncfg.Workers `group:"workers" namespace:"workers"`
@@ -546,6 +579,21 @@ func DefaultConfig() Config {
 		Invoices: &lncfg.Invoices{
 			HoldExpiryDelta: lncfg.DefaultHoldInvoiceExpiryDelta,
 		},
+		Spontaneous: &lncfg.Spontaneous{},
+		RemoteSigner: &lncfg.RemoteSigner{
+			Timeout: lncfg.DefaultRemoteSignerRPCTimeout,
+		},
+		Accounting: &lncfg.Accounting{
+			Currency: lncfg.DefaultAccountingCurrency,
+		},
+		Lnurl: &lncfg.Lnurl{
+			InvoiceExpiry:      lncfg.DefaultLnurlInvoiceExpiry,
+			MaxPendingInvoices: lncfg.DefaultLnurlMaxPendingInvoices,
+			RateLimit:          lncfg.DefaultLnurlRateLimit,
+			RateBurst:          lncfg.DefaultLnurlRateBurst,
+		},
+		HtlcLimits:              &lncfg.HtlcLimits{},
+		FeeAutopilot:            lncfg.DefaultFeeAutopilot(),
 		MaxOutgoingCltvExpiry:   htlcswitch.DefaultMaxOutgoingCltvExpiry,
 		MaxChannelFeeAllocation: htlcswitch.DefaultMaxLinkFeeAllocation,
 		MaxCommitFeeRateAnchors: lnwallet.DefaultAnchorsCommitMaxFeeRateSatPerVByte,
@@ -557,6 +605,8 @@ func DefaultConfig() Config {
 		ChannelCommitInterval:   defaultChannelCommitInterval,
 		ChannelCommitBatchSize:  defaultChannelCommitBatchSize,
 		CoinSelectionStrategy:   defaultCoinSelectionStrategy,
+
+		AnchorReserveCheckInterval: defaultAnchorReserveCheckInterval,
 	}
 }
 
@@ -693,6 +743,8 @@ func ValidateConfig(cfg Config, usageMessage string,
 	cfg.TLSCertPath = CleanAndExpandPath(cfg.TLSCertPath)
 	cfg.TLSKeyPath = CleanAndExpandPath(cfg.TLSKeyPath)
 	cfg.LetsEncryptDir = CleanAndExpandPath(cfg.LetsEncryptDir)
+	cfg.Lnurl.TLSCertPath = CleanAndExpandPath(cfg.Lnurl.TLSCertPath)
+	cfg.Lnurl.TLSKeyPath = CleanAndExpandPath(cfg.Lnurl.TLSKeyPath)
 	cfg.AdminMacPath = CleanAndExpandPath(cfg.AdminMacPath)
 	cfg.ReadMacPath = CleanAndExpandPath(cfg.ReadMacPath)
 	cfg.InvoiceMacPath = CleanAndExpandPath(cfg.InvoiceMacPath)
@@ -705,9 +757,11 @@ func ValidateConfig(cfg Config, usageMessage string,
 	cfg.Tor.WatchtowerKeyPath = CleanAndExpandPath(cfg.Tor.WatchtowerKeyPath)
 	cfg.Watchtower.TowerDir = CleanAndExpandPath(cfg.Watchtower.TowerDir)
 	cfg.BackupFilePath = CleanAndExpandPath(cfg.BackupFilePath)
+	cfg.PaymentsArchiveFile = CleanAndExpandPath(cfg.PaymentsArchiveFile)
 	cfg.WalletUnlockPasswordFile = CleanAndExpandPath(
 		cfg.WalletUnlockPasswordFile,
 	)
+	cfg.Accounting.PriceCSV = CleanAndExpandPath(cfg.Accounting.PriceCSV)
 
 	// Create the lnd directory and all other sub directories if they don't
 	// already exist. This makes sure that directory trees are also created
@@ -1255,6 +1309,14 @@ func ValidateConfig(cfg Config, usageMessage string,
 		)
 	}
 
+	// The payments archive is also kept in the network directory by
+	// default.
+	if cfg.PaymentsArchiveFile == "" {
+		cfg.PaymentsArchiveFile = filepath.Join(
+			cfg.networkDir, defaultPaymentsArchiveFilename,
+		)
+	}
+
 	// Append the network type to the log directory so it is "namespaced"
 	// per network in the same fashion as the data directory.
 	cfg.LogDir = filepath.Join(cfg.LogDir,
@@ -1353,6 +1415,18 @@ func ValidateConfig(cfg Config, usageMessage string,
 		return nil, fmt.Errorf("cannot set wallet-unlock-allow-create " +
 			"without wallet-unlock-password-file")
 
+	// The no seed backup mode creates a wallet with a seed, which can't be
+	// used with a remote signer.
+	case cfg.NoSeedBackup && cfg.RemoteSigner.Enable:
+		return nil, fmt.Errorf("cannot set noseedbackup and " +
+			"remotesigner.enable at the same time")
+
+	// The secret that stateless invoice preimages are derived from can't
+	// be derived without the private keys.
+	case cfg.AcceptStatelessInvoices && cfg.RemoteSigner.Enable:
+		return nil, fmt.Errorf("cannot set accept-stateless-invoices " +
+			"and remotesigner.enable at the same time")
+
 	// If a password file was specified, we need it to exist.
 	case cfg.WalletUnlockPasswordFile != "" &&
 		!lnrpc.FileExists(cfg.WalletUnlockPasswordFile):
@@ -1372,6 +1446,13 @@ func ValidateConfig(cfg Config, usageMessage string,
 		return nil, err
 	}
 
+	// Without a dedicated listener, the LNURL-pay server is served on the
+	// REST listeners, so it cannot be used without them.
+	if cfg.DisableRest && cfg.Lnurl.Active && cfg.Lnurl.Listen == "" {
+		return nil, fmt.Errorf("cannot set norest and lnurl.active " +
+			"at the same time without lnurl.listen")
+	}
+
 	if cfg.DisableRest {
 		ltndLog.Infof("REST API is disabled!")
 		cfg.RESTListeners = nil
@@ -1448,10 +1529,25 @@ func ValidateConfig(cfg Config, usageMessage string,
 			maxRemoteHtlcs)
 	}
 
+	if cfg.AnchorReserveCheckInterval < 0 {
+		return nil, fmt.Errorf("anchor-reserve-check-interval must not " +
+			"be negative")
+	}
+
+	archiveAfter := cfg.ArchiveSettledInvoicesAfter
+	if archiveAfter != 0 && archiveAfter < minArchiveSettledInvoicesAfter {
+		return nil, fmt.Errorf("archive-settled-invoices-after must be "+
+			"zero or at least %v", minArchiveSettledInvoicesAfter)
+	}
+
 	if err := cfg.Gossip.Parse(); err != nil {
 		return nil, err
 	}
 
+	if err := cfg.FeeAutopilot.Parse(); err != nil {
+		return nil, err
+	}
+
 	// Log a warning if our expiry delta is not greater than our incoming
 	// broadcast delta. We do not fail here because this value may be set
 	// to zero to intentionally keep lnd's behavior unchanged from when we
@@ -1472,6 +1568,11 @@ func ValidateConfig(cfg Config, usageMessage string,
 		cfg.DB,
 		cfg.Cluster,
 		cfg.HealthChecks,
+		cfg.HtlcLimits,
+		cfg.FeeAutopilot,
+		cfg.Accounting,
+		cfg.Lnurl,
+		cfg.RemoteSigner,
 	)
 	if err != nil {
 		return nil, err
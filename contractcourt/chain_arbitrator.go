@@ -20,6 +20,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/sweep"
 )
 
 // ErrChainArbExiting signals that the chain arbitrator is shutting down.
@@ -171,6 +172,12 @@ type ChainArbitratorConfig struct {
 	// htlcIndex, if it is a forwarded one.
 	IsForwardedHTLC func(chanID lnwire.ShortChannelID, htlcIndex uint64) bool
 
+	// FindIncomingHTLCExpiry returns the expiry height of the incoming
+	// htlc that the given outgoing htlc, identified by channel id and
+	// htlcIndex, forwards. The boolean is false if there is no such htlc.
+	FindIncomingHTLCExpiry func(chanID lnwire.ShortChannelID,
+		htlcIndex uint64) (uint32, bool)
+
 	// Clock is the clock implementation that ChannelArbitrator uses.
 	// It is useful for testing.
 	Clock clock.Clock
@@ -998,6 +1005,10 @@ type forceCloseReq struct {
 	// closeTx is a channel that carries the transaction which ultimately
 	// closed out the channel.
 	closeTx chan *wire.MsgTx
+
+	// anchorFeePref is an optional fee preference for sweeping the
+	// anchors of the commitment.
+	anchorFeePref *sweep.FeePreference
 }
 
 // ForceCloseContract attempts to force close the channel infield by the passed
@@ -1007,6 +1018,25 @@ type forceCloseReq struct {
 //
 // TODO(roasbeef): just return the summary itself?
 func (c *ChainArbitrator) ForceCloseContract(chanPoint wire.OutPoint) (*wire.MsgTx, error) {
+	return c.forceCloseContract(chanPoint, nil)
+}
+
+// ForceCloseContractWithAnchorFee force closes the channel identified by the
+// passed channel point like ForceCloseContract does. For anchor channels, the
+// anchors of the commitment are swept with the given fee preference, which
+// determines the package fee rate of the commitment and the anchor sweep.
+func (c *ChainArbitrator) ForceCloseContractWithAnchorFee(
+	chanPoint wire.OutPoint, feePref sweep.FeePreference) (*wire.MsgTx,
+	error) {
+
+	return c.forceCloseContract(chanPoint, &feePref)
+}
+
+// forceCloseContract force closes the channel identified by the passed channel
+// point, optionally sweeping its anchors with the given fee preference.
+func (c *ChainArbitrator) forceCloseContract(chanPoint wire.OutPoint,
+	anchorFeePref *sweep.FeePreference) (*wire.MsgTx, error) {
+
 	c.Lock()
 	arbitrator, ok := c.activeChannels[chanPoint]
 	c.Unlock()
@@ -1031,8 +1061,9 @@ func (c *ChainArbitrator) ForceCloseContract(chanPoint wire.OutPoint) (*wire.Msg
 	// force close request to the arbitrator that watches this channel.
 	select {
 	case arbitrator.forceCloseReqs <- &forceCloseReq{
-		errResp: errChan,
-		closeTx: respChan,
+		errResp:       errChan,
+		closeTx:       respChan,
+		anchorFeePref: anchorFeePref,
 	}:
 	case <-c.quit:
 		return nil, ErrChainArbExiting
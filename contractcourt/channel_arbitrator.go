@@ -356,6 +356,12 @@ type ChannelArbitrator struct {
 	// contract will be sent over.
 	forceCloseReqs chan *forceCloseReq
 
+	// anchorFeePref is the fee preference for sweeping the anchors of the
+	// commitment that the user specified when force closing the channel,
+	// if any. It isn't persisted, so after a restart the anchors are swept
+	// based on the deadline of the commitment again.
+	anchorFeePref *sweep.FeePreference
+
 	// state is the current state of the arbitrator. This state is examined
 	// upon start up to decide which actions to take.
 	state ArbitratorState
@@ -1087,9 +1093,14 @@ func (c *ChannelArbitrator) stateStep(
 	return nextState, closeTx, nil
 }
 
-// sweepAnchors offers all given anchor resolutions to the sweeper. It requests
-// sweeping at the minimum fee rate. This fee rate can be upped manually by the
-// user via the BumpFee rpc.
+// sweepAnchors offers all given anchor resolutions to the sweeper. The anchor
+// is swept with the package fee rate of the commitment and the anchor sweep
+// that is needed to confirm the commitment before the deadline of its HTLCs,
+// unless the user specified a fee preference for the force close. If the
+// commitment has time-sensitive HTLCs, the sweeper raises the fee rate every
+// block until it reaches the deadline, spending up to half of the value of
+// these HTLCs. The fee rate can also be upped manually by the user via the
+// BumpFee rpc.
 func (c *ChannelArbitrator) sweepAnchors(anchors *lnwallet.AnchorResolutions,
 	heightHint uint32) error {
 
@@ -1108,6 +1119,11 @@ func (c *ChannelArbitrator) sweepAnchors(anchors *lnwallet.AnchorResolutions,
 			return err
 		}
 
+		deadlineHeight, value, err := c.commitmentDeadlineHeight(htlcs)
+		if err != nil {
+			return err
+		}
+
 		log.Debugf("ChannelArbitrator(%v): pre-confirmation sweep of "+
 			"anchor of tx %v", c.cfg.ChanPoint, anchor.CommitAnchor)
 
@@ -1124,23 +1140,37 @@ func (c *ChannelArbitrator) sweepAnchors(anchors *lnwallet.AnchorResolutions,
 		)
 
 		// Sweep anchor output with a confirmation target fee
-		// preference. Because this is a cpfp-operation, the anchor will
-		// only be attempted to sweep when the current fee estimate for
-		// the confirmation target exceeds the commit fee rate.
+		// preference, or with the fee preference of the user. Because
+		// this is a cpfp-operation, the sweeper treats the resulting
+		// fee rate as the package fee rate of the commitment and the
+		// anchor sweep. The anchor will only be attempted to sweep
+		// when this fee rate exceeds the commit fee rate.
 		//
 		// Also signal that this is a force sweep, so that the anchor
 		// will be swept even if it isn't economical purely based on the
 		// anchor value.
-		_, err = c.cfg.Sweeper.SweepInput(
-			&anchorInput,
-			sweep.Params{
-				Fee: sweep.FeePreference{
-					ConfTarget: deadline,
-				},
-				Force:          true,
-				ExclusiveGroup: &exclusiveGroup,
+		params := sweep.Params{
+			Fee: sweep.FeePreference{
+				ConfTarget: deadline,
 			},
-		)
+			Force:          true,
+			ExclusiveGroup: &exclusiveGroup,
+		}
+
+		// If the commitment has time-sensitive HTLCs, we let the
+		// sweeper bump the fee as their deadline approaches, unless
+		// the user specified a fee preference, which is then used
+		// as is.
+		switch {
+		case c.anchorFeePref != nil:
+			params.Fee = *c.anchorFeePref
+
+		case deadlineHeight != math.MaxUint32:
+			params.DeadlineHeight = deadlineHeight
+			params.Budget = anchorSweepBudget(value)
+		}
+
+		_, err = c.cfg.Sweeper.SweepInput(&anchorInput, params)
 		if err != nil {
 			return err
 		}
@@ -1190,7 +1220,54 @@ func (c *ChannelArbitrator) sweepAnchors(anchors *lnwallet.AnchorResolutions,
 func (c *ChannelArbitrator) findCommitmentDeadline(heightHint uint32,
 	htlcs htlcSet) (uint32, error) {
 
-	deadlineMinHeight := uint32(math.MaxUint32)
+	deadlineMinHeight, _, err := c.commitmentDeadlineHeight(htlcs)
+	if err != nil {
+		return 0, err
+	}
+
+	// Calculate the deadline. There are two cases to be handled here,
+	//   - when the deadlineMinHeight never gets updated, which could
+	//     happen when we have no outgoing HTLCs, and, for incoming HTLCs,
+	//       * either we have none, or,
+	//       * none of the HTLCs are preimageAvailable.
+	//   - when our deadlineMinHeight is no greater than the heightHint,
+	//     which means we are behind our schedule.
+	deadline := deadlineMinHeight - heightHint
+	switch {
+	// When we couldn't find a deadline height from our HTLCs, we will fall
+	// back to the default value.
+	case deadlineMinHeight == math.MaxUint32:
+		deadline = anchorSweepConfTarget
+
+	// When the deadline is passed, we will fall back to the smallest conf
+	// target (1 block).
+	case deadlineMinHeight <= heightHint:
+		log.Warnf("ChannelArbitrator(%v): deadline is passed with "+
+			"deadlineMinHeight=%d, heightHint=%d",
+			c.cfg.ChanPoint, deadlineMinHeight, heightHint)
+		deadline = 1
+	}
+
+	log.Debugf("ChannelArbitrator(%v): calculated deadline: %d, "+
+		"using deadlineMinHeight=%d, heightHint=%d",
+		c.cfg.ChanPoint, deadline, deadlineMinHeight, heightHint)
+
+	return deadline, nil
+}
+
+// commitmentDeadlineHeight returns the absolute deadline height of a
+// commitment transaction, which is the least CLTV of its time-sensitive HTLCs.
+// These are the outgoing HTLCs, and the incoming HTLCs for which the preimage
+// is available. It also returns the total value of these HTLCs, which is at
+// stake if the commitment doesn't confirm before the deadline. If there are no
+// time-sensitive HTLCs, math.MaxUint32 is returned as deadline height.
+func (c *ChannelArbitrator) commitmentDeadlineHeight(htlcs htlcSet) (uint32,
+	btcutil.Amount, error) {
+
+	var (
+		deadlineMinHeight = uint32(math.MaxUint32)
+		value             btcutil.Amount
+	)
 
 	// First, iterate through the outgoingHTLCs to find the lowest CLTV
 	// value.
@@ -1207,6 +1284,7 @@ func (c *ChannelArbitrator) findCommitmentDeadline(heightHint uint32,
 		if htlc.RefundTimeout < deadlineMinHeight {
 			deadlineMinHeight = htlc.RefundTimeout
 		}
+		value += htlc.Amt.ToSatoshis()
 	}
 
 	// Then going through the incomingHTLCs, and update the minHeight when
@@ -1225,7 +1303,7 @@ func (c *ChannelArbitrator) findCommitmentDeadline(heightHint uint32,
 		// this HTLC.
 		preimageAvailable, err := c.isPreimageAvailable(htlc.RHash)
 		if err != nil {
-			return 0, err
+			return 0, 0, err
 		}
 
 		if !preimageAvailable {
@@ -1235,36 +1313,10 @@ func (c *ChannelArbitrator) findCommitmentDeadline(heightHint uint32,
 		if htlc.RefundTimeout < deadlineMinHeight {
 			deadlineMinHeight = htlc.RefundTimeout
 		}
+		value += htlc.Amt.ToSatoshis()
 	}
 
-	// Calculate the deadline. There are two cases to be handled here,
-	//   - when the deadlineMinHeight never gets updated, which could
-	//     happen when we have no outgoing HTLCs, and, for incoming HTLCs,
-	//       * either we have none, or,
-	//       * none of the HTLCs are preimageAvailable.
-	//   - when our deadlineMinHeight is no greater than the heightHint,
-	//     which means we are behind our schedule.
-	deadline := deadlineMinHeight - heightHint
-	switch {
-	// When we couldn't find a deadline height from our HTLCs, we will fall
-	// back to the default value.
-	case deadlineMinHeight == math.MaxUint32:
-		deadline = anchorSweepConfTarget
-
-	// When the deadline is passed, we will fall back to the smallest conf
-	// target (1 block).
-	case deadlineMinHeight <= heightHint:
-		log.Warnf("ChannelArbitrator(%v): deadline is passed with "+
-			"deadlineMinHeight=%d, heightHint=%d",
-			c.cfg.ChanPoint, deadlineMinHeight, heightHint)
-		deadline = 1
-	}
-
-	log.Debugf("ChannelArbitrator(%v): calculated deadline: %d, "+
-		"using deadlineMinHeight=%d, heightHint=%d",
-		c.cfg.ChanPoint, deadline, deadlineMinHeight, heightHint)
-
-	return deadline, nil
+	return deadlineMinHeight, value, nil
 }
 
 // launchResolvers updates the activeResolvers list and starts the resolvers.
@@ -2567,6 +2619,8 @@ func (c *ChannelArbitrator) channelAttendant(bestHeight int32) {
 				continue
 			}
 
+			c.anchorFeePref = closeReq.anchorFeePref
+
 			nextState, closeTx, err := c.advanceState(
 				uint32(bestHeight), userTrigger, nil,
 			)
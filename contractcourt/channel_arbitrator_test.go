@@ -24,6 +24,7 @@ import (
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/sweep"
 	"github.com/stretchr/testify/require"
 )
 
@@ -2259,10 +2260,12 @@ func TestSweepAnchors(t *testing.T) {
 		HtlcIndex:     htlcIndexBase + 2,
 		RefundTimeout: htlcExpiryBase + 2,
 		RHash:         rHash,
+		Amt:           100000000,
 	}
 	htlcSmallExipry := channeldb.HTLC{
 		HtlcIndex:     htlcIndexBase + 3,
 		RefundTimeout: htlcExpiryBase + 3,
+		Amt:           50000000,
 	}
 
 	// Setup our local HTLC set such that we will use the HTLC's CLTV from
@@ -2342,6 +2345,71 @@ func TestSweepAnchors(t *testing.T) {
 		"remote deadline not matched",
 	)
 
+	// The anchors of the commitments with time-sensitive HTLCs are bumped
+	// towards the deadline of these HTLCs, spending up to the budget that
+	// is derived from their value.
+	require.Equal(
+		t, map[uint32]btcutil.Amount{
+			htlcWithPreimage.RefundTimeout: anchorSweepBudget(
+				htlcWithPreimage.Amt.ToSatoshis(),
+			),
+			htlcSmallExipry.RefundTimeout: anchorSweepBudget(
+				htlcSmallExipry.Amt.ToSatoshis(),
+			),
+		}, chanArbCtx.sweeper.budgets,
+	)
+}
+
+// TestSweepAnchorsFeePreference checks that the anchors of a force closed
+// channel are swept with the fee preference of the user, if specified.
+func TestSweepAnchorsFeePreference(t *testing.T) {
+	log := &mockArbitratorLog{
+		state:     StateDefault,
+		newStates: make(chan ArbitratorState, 5),
+	}
+	chanArbCtx, err := createTestChannelArbitrator(t, log)
+	require.NoError(t, err, "unable to create ChannelArbitrator")
+
+	// The local commitment has a time-sensitive outgoing HTLC, the remote
+	// commitment has none.
+	heightHint := uint32(1000)
+	htlc := channeldb.HTLC{
+		HtlcIndex:     1,
+		RefundTimeout: heightHint + 20,
+		Amt:           100000000,
+	}
+
+	chanArb := chanArbCtx.chanArb
+	chanArb.anchorFeePref = &sweep.FeePreference{ConfTarget: 3}
+	chanArb.activeHTLCs[LocalHtlcSet] = htlcSet{
+		outgoingHTLCs: map[uint64]channeldb.HTLC{
+			htlc.HtlcIndex: htlc,
+		},
+	}
+	chanArb.activeHTLCs[RemoteHtlcSet] = htlcSet{}
+
+	anchors := &lnwallet.AnchorResolutions{
+		Local: &lnwallet.AnchorResolution{
+			AnchorSignDescriptor: input.SignDescriptor{
+				Output: &wire.TxOut{Value: 1},
+			},
+		},
+		Remote: &lnwallet.AnchorResolution{
+			AnchorSignDescriptor: input.SignDescriptor{
+				Output: &wire.TxOut{Value: 1},
+			},
+		},
+	}
+
+	err = chanArb.sweepAnchors(anchors, heightHint)
+	require.NoError(t, err)
+
+	// Both anchors are swept with the fee preference of the user. Even
+	// the anchor of the commitment with the time-sensitive HTLC isn't
+	// bumped towards its deadline, as that would override the fee
+	// preference.
+	require.Equal(t, []int{3, 3}, chanArbCtx.sweeper.deadlines)
+	require.Empty(t, chanArbCtx.sweeper.budgets)
 }
 
 // TestChannelArbitratorAnchors asserts that the commitment tx anchor is swept.
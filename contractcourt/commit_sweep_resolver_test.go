@@ -110,6 +110,10 @@ type mockSweeper struct {
 	createSweepTxChan chan *wire.MsgTx
 
 	deadlines []int
+
+	// budgets maps the deadline heights of the swept inputs to their
+	// budgets.
+	budgets map[uint32]btcutil.Amount
 }
 
 func newMockSweeper() *mockSweeper {
@@ -119,18 +123,22 @@ func newMockSweeper() *mockSweeper {
 		sweepTx:           &wire.MsgTx{},
 		createSweepTxChan: make(chan *wire.MsgTx),
 		deadlines:         []int{},
+		budgets:           make(map[uint32]btcutil.Amount),
 	}
 }
 
 func (s *mockSweeper) SweepInput(input input.Input, params sweep.Params) (
 	chan sweep.Result, error) {
 
-	s.sweptInputs <- input
-
 	// Update the deadlines used if it's set.
 	if params.Fee.ConfTarget != 0 {
 		s.deadlines = append(s.deadlines, int(params.Fee.ConfTarget))
 	}
+	if params.DeadlineHeight != 0 {
+		s.budgets[params.DeadlineHeight] = params.Budget
+	}
+
+	s.sweptInputs <- input
 
 	result := make(chan sweep.Result, 1)
 	result <- sweep.Result{
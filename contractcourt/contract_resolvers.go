@@ -8,6 +8,7 @@ import (
 
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/btcutil"
 	"github.com/lightningnetwork/lnd/build"
 	"github.com/lightningnetwork/lnd/channeldb"
 )
@@ -24,8 +25,40 @@ const (
 	// secondLevelConfTarget is the confirmation target we'll use when
 	// adding fees to our second-level HTLC transactions.
 	secondLevelConfTarget = 6
+
+	// htlcTimeoutDeadlineDelta is the number of blocks after the expiry of
+	// an outgoing HTLC by which its timeout transaction should confirm, if
+	// the HTLC wasn't forwarded. Until then, the remote party can still
+	// claim the HTLC using the preimage. Forwarded HTLCs use the expiry of
+	// the incoming HTLC as their deadline instead.
+	htlcTimeoutDeadlineDelta = 8
+
+	// htlcSweepBudgetRatio is the share of the value of an HTLC that we're
+	// willing to spend on fees to claim it before its deadline.
+	htlcSweepBudgetRatio = 2
 )
 
+// htlcSweepBudget returns the maximum fee that we're willing to pay to claim
+// an HTLC of the given value before its deadline. For anchor channels, this
+// budget is shared by the anchor sweep confirming the commitment and the sweep
+// of the second-level transaction, which each get half of it.
+func htlcSweepBudget(amt btcutil.Amount) btcutil.Amount {
+	return amt / htlcSweepBudgetRatio
+}
+
+// anchorSweepBudget returns the share of the budget of HTLCs of the given total
+// value that the anchor sweep may spend on getting the commitment confirmed.
+func anchorSweepBudget(amt btcutil.Amount) btcutil.Amount {
+	return htlcSweepBudget(amt) / 2
+}
+
+// secondLevelSweepBudget returns the share of the budget of an HTLC of the
+// given value that is left for sweeping its second-level transaction after the
+// anchor sweep took its share.
+func secondLevelSweepBudget(amt btcutil.Amount) btcutil.Amount {
+	return htlcSweepBudget(amt) - anchorSweepBudget(amt)
+}
+
 // ContractResolver is an interface which packages a state machine which is
 // able to carry out the necessary steps required to fully resolve a Bitcoin
 // contract on-chain. Resolvers are fully encodable to ensure callers are able
@@ -168,7 +168,9 @@ func (h *htlcSuccessResolver) broadcastSuccessTx() (*wire.OutPoint, error) {
 	// If we have non-nil SignDetails, this means that have a 2nd level
 	// HTLC transaction that is signed using sighash SINGLE|ANYONECANPAY
 	// (the case for anchor type channels). In this case we can re-sign it
-	// and attach fees at will. We let the sweeper handle this job.
+	// and attach fees at will. We let the sweeper handle this job. It
+	// aggregates the success transactions of all HTLCs into a single
+	// transaction, paying for the fees with wallet inputs.
 	// We use the checkpointed outputIncubating field to determine if we
 	// already swept the HTLC output into the second level transaction.
 	if h.htlcResolution.SignDetails != nil {
@@ -240,12 +242,18 @@ func (h *htlcSuccessResolver) broadcastReSignedSuccessTx() (
 			h.htlcResolution.SignDetails, h.htlcResolution.Preimage,
 			h.broadcastHeight,
 		)
+		// The success transaction needs to confirm before the HTLC
+		// expires, as the remote party can time it out afterwards.
 		_, err := h.Sweeper.SweepInput(
 			&secondLevelInput,
 			sweep.Params{
 				Fee: sweep.FeePreference{
 					ConfTarget: secondLevelConfTarget,
 				},
+				DeadlineHeight: h.htlc.RefundTimeout,
+				Budget: secondLevelSweepBudget(
+					h.htlc.Amt.ToSatoshis(),
+				),
 			},
 		)
 		if err != nil {
@@ -21,6 +21,10 @@ import (
 
 var testHtlcAmt = lnwire.MilliSatoshi(200000)
 
+// testIncomingHtlcExpiry is the expiry of the incoming htlc that the outgoing
+// htlcs of the resolver tests forward.
+const testIncomingHtlcExpiry = 144
+
 type htlcResolverTestContext struct {
 	resolver ContractResolver
 
@@ -60,6 +64,11 @@ func newHtlcResolverTestContext(t *testing.T,
 				return nil
 			},
 			Sweeper: newMockSweeper(),
+			FindIncomingHTLCExpiry: func(lnwire.ShortChannelID,
+				uint64) (uint32, bool) {
+
+				return testIncomingHtlcExpiry, true
+			},
 			IncubateOutputs: func(wire.OutPoint, *lnwallet.OutgoingHtlcResolution,
 				*lnwallet.IncomingHtlcResolution, uint32) error {
 				return nil
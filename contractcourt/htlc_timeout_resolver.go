@@ -319,7 +319,11 @@ func (h *htlcTimeoutResolver) spendHtlcOutput() (*chainntnfs.SpendDetail, error)
 	// If we have non-nil SignDetails, this means that have a 2nd level
 	// HTLC transaction that is signed using sighash SINGLE|ANYONECANPAY
 	// (the case for anchor type channels). In this case we can re-sign it
-	// and attach fees at will. We let the sweeper handle this job.
+	// and attach fees at will. We let the sweeper handle this job. It
+	// aggregates the timeout transactions of all HTLCs that expire at the
+	// same height into a single transaction, paying for the fees with
+	// wallet inputs. Timeout transactions with different expiries can't
+	// be aggregated, as the signature of our peer commits to the locktime.
 	case h.htlcResolution.SignDetails != nil && !h.outputIncubating:
 		log.Infof("%T(%x): offering second-layer timeout tx to "+
 			"sweeper: %v", h, h.htlc.RHash[:],
@@ -330,12 +334,29 @@ func (h *htlcTimeoutResolver) spendHtlcOutput() (*chainntnfs.SpendDetail, error)
 			h.htlcResolution.SignDetails,
 			h.broadcastHeight,
 		)
+		// If the HTLC was forwarded, the timeout transaction needs to
+		// confirm before the incoming HTLC expires, as the upstream
+		// peer can time it out from then on. Otherwise we only race
+		// the remote party claiming the HTLC with the preimage. We let
+		// the sweeper raise its fee as the deadline approaches.
+		deadline := h.htlc.RefundTimeout + htlcTimeoutDeadlineDelta
+		expiry, ok := h.FindIncomingHTLCExpiry(
+			h.ShortChanID, h.htlc.HtlcIndex,
+		)
+		if ok {
+			deadline = expiry
+		}
+
 		_, err := h.Sweeper.SweepInput(
 			&inp,
 			sweep.Params{
 				Fee: sweep.FeePreference{
 					ConfTarget: secondLevelConfTarget,
 				},
+				DeadlineHeight: deadline,
+				Budget: secondLevelSweepBudget(
+					h.htlc.Amt.ToSatoshis(),
+				),
 			},
 		)
 		if err != nil {
@@ -1020,6 +1020,21 @@ func TestHtlcTimeoutSecondStageSweeper(t *testing.T) {
 						commitOutpoint)
 				}
 
+				// The timeout tx must confirm before the
+				// incoming htlc expires, spending the share of
+				// the htlc budget that the anchor sweep left.
+				budgets := resolver.Sweeper.(*mockSweeper).budgets
+				budget := budgets[testIncomingHtlcExpiry]
+				expBudget := secondLevelSweepBudget(
+					testHtlcAmt.ToSatoshis(),
+				)
+				if budget != expBudget {
+					return fmt.Errorf("expected budget %v "+
+						"at deadline %v, got %v",
+						expBudget,
+						testIncomingHtlcExpiry, budgets)
+				}
+
 				// Emulat the sweeper spending using the
 				// re-signed timeout tx.
 				ctx.notifier.SpendChan <- &chainntnfs.SpendDetail{
@@ -61,6 +61,9 @@ var deps = depDesc{
 	lnwire.AMPOptional: {
 		lnwire.PaymentAddrOptional: {},
 	},
+	lnwire.PaymentMetadataOptional: {
+		lnwire.TLVOnionPayloadOptional: {},
+	},
 }
 
 // ValidateDeps asserts that a feature vector sets all features and their
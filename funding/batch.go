@@ -0,0 +1,559 @@
+package funding
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/lightningnetwork/lnd/labels"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwallet/chanfunding"
+)
+
+var (
+	// errShuttingDown is the error that is returned if a signal on the
+	// quit channel is received which means the whole server is shutting
+	// down.
+	errShuttingDown = errors.New("shutting down")
+
+	// emptyChannelID is a channel ID that consists of all zeros.
+	emptyChannelID = [32]byte{}
+
+	// batchCleanupTimeout is the maximum time we wait for the outcome of
+	// a channel negotiation that was already handed the signed funding
+	// transaction when rolling back a failed batch.
+	batchCleanupTimeout = 30 * time.Second
+)
+
+// batchChannel is a struct that keeps track of a single channel's state within
+// the batch funding process.
+type batchChannel struct {
+	fundingReq    *InitFundingMsg
+	pendingChanID [32]byte
+	updateChan    chan *lnrpc.OpenStatusUpdate
+	errChan       chan error
+	fundingAddr   string
+	fundingAmt    btcutil.Amount
+	chanPoint     *wire.OutPoint
+	isFinalized   bool
+	isPending     bool
+	hasFailed     bool
+}
+
+// processPsbtUpdate processes the first channel update message that is sent
+// once the initial part of the negotiation has completed and the funding output
+// (and therefore address) is known.
+func (c *batchChannel) processPsbtUpdate(u *lnrpc.OpenStatusUpdate) error {
+	psbtUpdate := u.GetPsbtFund()
+	if psbtUpdate == nil {
+		return fmt.Errorf("got unexpected channel update %v", u.Update)
+	}
+
+	if psbtUpdate.FundingAmount != int64(c.fundingReq.LocalFundingAmt) {
+		return fmt.Errorf("got unexpected funding amount %d, wanted "+
+			"%d", psbtUpdate.FundingAmount,
+			c.fundingReq.LocalFundingAmt)
+	}
+
+	c.fundingAddr = psbtUpdate.FundingAddress
+	c.fundingAmt = btcutil.Amount(psbtUpdate.FundingAmount)
+
+	return nil
+}
+
+// processPendingUpdate is the second channel update message that is sent once
+// the negotiation with the peer has completed and the channel is now pending.
+func (c *batchChannel) processPendingUpdate(u *lnrpc.OpenStatusUpdate) error {
+	pendingUpd := u.GetChanPending()
+	if pendingUpd == nil {
+		return fmt.Errorf("got unexpected channel update %v", u.Update)
+	}
+
+	hash, err := chainhash.NewHash(pendingUpd.Txid)
+	if err != nil {
+		return fmt.Errorf("could not parse outpoint TX hash: %v", err)
+	}
+
+	c.chanPoint = &wire.OutPoint{
+		Index: pendingUpd.OutputIndex,
+		Hash:  *hash,
+	}
+	c.isPending = true
+
+	return nil
+}
+
+// RequestParser is a function that parses an incoming RPC request into the
+// internal funding initialization message.
+type RequestParser func(*lnrpc.OpenChannelRequest) (*InitFundingMsg, error)
+
+// ChannelOpener is a function that kicks off the initial channel open
+// negotiation with the peer.
+type ChannelOpener func(*InitFundingMsg) (chan *lnrpc.OpenStatusUpdate,
+	chan error)
+
+// ChannelAbandoner is a function that can abandon a channel in the local
+// database, graph, arbitrator state and subsequently the channel backup file.
+type ChannelAbandoner func(*wire.OutPoint) error
+
+// Wallet is an interface that abstracts the wallet operations the batcher
+// needs to fund, sign and publish the batch transaction. It is implemented by
+// the lnwallet.LightningWallet.
+type Wallet interface {
+	// WithCoinSelectLock will execute the passed function closure in a
+	// synchronized manner preventing any coin selection operations from
+	// proceeding while the closure is executing.
+	WithCoinSelectLock(func() error) error
+
+	// FundPsbt adds inputs with at least minConfs confirmations and a
+	// change output to the given packet so it pays for all of its outputs
+	// at the given fee rate.
+	FundPsbt(packet *psbt.Packet, minConfs int32,
+		feeRate chainfee.SatPerKWeight, account string) (int32, error)
+
+	// FinalizePsbt signs and finalizes all inputs of the packet that
+	// belong to the given account.
+	FinalizePsbt(packet *psbt.Packet, account string) error
+
+	// LockOutpoint locks a target outpoint, rendering it unusable for coin
+	// selection.
+	LockOutpoint(o wire.OutPoint)
+
+	// UnlockOutpoint unlocks a target outpoint, allowing it to be used for
+	// coin selection once again.
+	UnlockOutpoint(o wire.OutPoint)
+
+	// PsbtFundingVerify looks up a previously registered funding intent
+	// by its pending channel ID and tries to advance the state machine by
+	// verifying the passed PSBT.
+	PsbtFundingVerify(pendingChanID [32]byte, packet *psbt.Packet) error
+
+	// PsbtFundingFinalize looks up a previously registered funding intent
+	// by its pending channel ID and tries to advance the state machine by
+	// finalizing the passed PSBT.
+	PsbtFundingFinalize(pendingChanID [32]byte, packet *psbt.Packet,
+		rawTx *wire.MsgTx) error
+
+	// PublishTransaction performs cursory validation (dust checks, etc),
+	// then finally broadcasts the passed transaction to the Bitcoin
+	// network.
+	PublishTransaction(tx *wire.MsgTx, label string) error
+
+	// CancelFundingIntent allows a caller to cancel a previously
+	// registered funding intent. If no intent was found, then an error
+	// will be returned.
+	CancelFundingIntent(pendingChanID [32]byte) error
+}
+
+// A compile-time check to ensure the LightningWallet meets the Wallet
+// interface.
+var _ Wallet = (*lnwallet.LightningWallet)(nil)
+
+// BatchConfig is the configuration for executing a single batch transaction
+// for opening multiple channels atomically.
+type BatchConfig struct {
+	// RequestParser is the function that parses an incoming RPC request
+	// into the internal funding initialization message.
+	RequestParser RequestParser
+
+	// ChannelOpener is the function that kicks off the initial channel
+	// open negotiation with the peer.
+	ChannelOpener ChannelOpener
+
+	// ChannelAbandoner is the function that can abandon a channel in the
+	// local database, graph, arbitrator state and subsequently the channel
+	// backup file.
+	ChannelAbandoner ChannelAbandoner
+
+	// Wallet is used to fund, sign and publish the batch transaction.
+	Wallet Wallet
+
+	// NetParams contains the current bitcoin network parameters.
+	NetParams *chaincfg.Params
+
+	// Quit is the channel that is selected on to recognize if the main
+	// server is shutting down.
+	Quit <-chan struct{}
+}
+
+// Batcher is a type that can be used to perform an atomic funding of multiple
+// channels within a single on-chain transaction.
+type Batcher struct {
+	cfg *BatchConfig
+
+	channels    []*batchChannel
+	lockedUTXOs []wire.OutPoint
+
+	didPublish bool
+}
+
+// NewBatcher returns a new batch channel funding helper.
+func NewBatcher(cfg *BatchConfig) *Batcher {
+	return &Batcher{
+		cfg: cfg,
+	}
+}
+
+// BatchFund starts the atomic batch channel funding process.
+//
+// NOTE: This method should only be called once per instance.
+func (b *Batcher) BatchFund(ctx context.Context,
+	req *lnrpc.BatchOpenChannelRequest) (_ []*lnrpc.PendingUpdate,
+	retErr error) {
+
+	if len(req.Channels) == 0 {
+		return nil, fmt.Errorf("at least one channel must be " +
+			"specified")
+	}
+
+	label, err := labels.ValidateAPI(req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []wire.OutPoint
+	for _, utxo := range req.Utxos {
+		outpoint, err := lnrpc.UnmarshallOutPoint(utxo)
+		if err != nil {
+			return nil, err
+		}
+
+		inputs = append(inputs, *outpoint)
+	}
+
+	// Parse and validate each individual channel.
+	b.channels = make([]*batchChannel, 0, len(req.Channels))
+	for idx, rpcChannel := range req.Channels {
+		// If the user specifies a channel ID, it must be exactly 32
+		// bytes long.
+		if len(rpcChannel.PendingChanId) > 0 &&
+			len(rpcChannel.PendingChanId) != 32 {
+
+			return nil, fmt.Errorf("invalid temp chan ID %x",
+				rpcChannel.PendingChanId)
+		}
+
+		var pendingChanID [32]byte
+		if len(rpcChannel.PendingChanId) == 32 {
+			copy(pendingChanID[:], rpcChannel.PendingChanId)
+
+			// Don't allow the user to be clever by just setting an
+			// all zero channel ID, we need a "real" value here.
+			if pendingChanID == emptyChannelID {
+				return nil, fmt.Errorf("invalid empty temp " +
+					"chan ID")
+			}
+		} else if _, err := rand.Read(pendingChanID[:]); err != nil {
+			return nil, fmt.Errorf("error making temp chan ID: %v",
+				err)
+		}
+
+		openReq := &lnrpc.OpenChannelRequest{
+			SatPerVbyte:        req.SatPerVbyte,
+			NodePubkey:         rpcChannel.NodePubkey,
+			LocalFundingAmount: rpcChannel.LocalFundingAmount,
+			PushSat:            rpcChannel.PushSat,
+			TargetConf:         req.TargetConf,
+			Private:            rpcChannel.Private,
+			MinHtlcMsat:        rpcChannel.MinHtlcMsat,
+			RemoteCsvDelay:     rpcChannel.RemoteCsvDelay,
+			CloseAddress:       rpcChannel.CloseAddress,
+			MinConfs:           req.MinConfs,
+			SpendUnconfirmed:   req.SpendUnconfirmed,
+		}
+		fundingReq, err := b.cfg.RequestParser(openReq)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing channel %d: %v",
+				idx, err)
+		}
+
+		// Prepare the stuff that we'll need for the internal PSBT
+		// funding. The funding manager must not publish the batch
+		// transaction, we'll do that ourselves once all channels are
+		// pending.
+		fundingReq.PendingChanID = pendingChanID
+		fundingReq.ChanFunder = chanfunding.NewPsbtAssembler(
+			btcutil.Amount(rpcChannel.LocalFundingAmount), nil,
+			b.cfg.NetParams, false,
+		)
+
+		b.channels = append(b.channels, &batchChannel{
+			pendingChanID: pendingChanID,
+			fundingReq:    fundingReq,
+		})
+	}
+
+	// From this point on we can fail for any of the channels and for any
+	// number of reasons. This deferred function makes sure that the full
+	// operation is actually atomic: We either succeed and publish a
+	// transaction for the full batch or we clean up everything.
+	defer func() {
+		// Only if we can't publish the transaction we need to clean up
+		// the state of all channels.
+		if retErr != nil && !b.didPublish {
+			b.cleanup(retErr)
+		}
+	}()
+
+	// Now that we know the user input is sane, we need to kick off the
+	// channel funding negotiation with the peers. Because we specified a
+	// PSBT assembler, we'll get a special response in the channel once the
+	// funding output script is known (which we need to craft the TX). We
+	// start all negotiations first, so a slow peer doesn't hold up the
+	// others.
+	for _, channel := range b.channels {
+		channel.updateChan, channel.errChan = b.cfg.ChannelOpener(
+			channel.fundingReq,
+		)
+	}
+
+	for _, channel := range b.channels {
+		// Block until we receive the response with the funding output
+		// script.
+		select {
+		case update := <-channel.updateChan:
+			err := channel.processPsbtUpdate(update)
+			if err != nil {
+				return nil, fmt.Errorf("error processing PSBT "+
+					"update: %v", err)
+			}
+
+		case err := <-channel.errChan:
+			channel.hasFailed = true
+			return nil, fmt.Errorf("error opening channel: %v",
+				err)
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		case <-b.cfg.Quit:
+			return nil, errShuttingDown
+		}
+	}
+
+	// We can now assemble all outputs that we're going to give to the
+	// wallet to fund the batch transaction.
+	tx := wire.NewMsgTx(2)
+	for _, input := range inputs {
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: input,
+		})
+	}
+	for _, channel := range b.channels {
+		addr, err := btcutil.DecodeAddress(
+			channel.fundingAddr, b.cfg.NetParams,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing funding "+
+				"address: %v", err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, fmt.Errorf("error creating funding "+
+				"script: %v", err)
+		}
+
+		tx.AddTxOut(&wire.TxOut{
+			Value:    int64(channel.fundingAmt),
+			PkScript: pkScript,
+		})
+	}
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("error creating PSBT: %v", err)
+	}
+
+	// All channels use the same fee rate and number of confirmations as
+	// they were all parsed from the same arguments.
+	feeRate := b.channels[0].fundingReq.FundingFeePerKw
+	minConfs := b.channels[0].fundingReq.MinConfs
+
+	// Fund the batch transaction and lock the selected inputs under the
+	// coin selection lock, so no other funding attempt can select them
+	// while we're still negotiating with the peers.
+	err = b.cfg.Wallet.WithCoinSelectLock(func() error {
+		_, err := b.cfg.Wallet.FundPsbt(
+			packet, minConfs, feeRate, lnwallet.DefaultAccountName,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, txIn := range packet.UnsignedTx.TxIn {
+			b.cfg.Wallet.LockOutpoint(txIn.PreviousOutPoint)
+			b.lockedUTXOs = append(
+				b.lockedUTXOs, txIn.PreviousOutPoint,
+			)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error funding PSBT for batch channel "+
+			"open: %v", err)
+	}
+
+	// Now we have a fully funded PSBT. We need to verify it against all
+	// channels, so each funding intent knows the final inputs and outputs.
+	for _, channel := range b.channels {
+		err := b.cfg.Wallet.PsbtFundingVerify(
+			channel.pendingChanID, packet,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error verifying PSBT: %v", err)
+		}
+	}
+
+	// The funded PSBT was accepted by each of the assemblers, let's now
+	// sign/finalize it.
+	err = b.cfg.Wallet.FinalizePsbt(packet, lnwallet.DefaultAccountName)
+	if err != nil {
+		return nil, fmt.Errorf("error finalizing PSBT: %v", err)
+	}
+	finalTx, err := psbt.Extract(packet)
+	if err != nil {
+		return nil, fmt.Errorf("error extracting final TX: %v", err)
+	}
+
+	// We've signed the transaction, so we can now hand it to each of the
+	// funding intents. This continues the negotiation with each of the
+	// peers, which will give us the pending channel updates.
+	for _, channel := range b.channels {
+		err := b.cfg.Wallet.PsbtFundingFinalize(
+			channel.pendingChanID, packet, nil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error finalizing PSBT: %v", err)
+		}
+		channel.isFinalized = true
+	}
+
+	// Now every channel should be ready for the funding transaction to be
+	// broadcast. Let's wait for the updates that actually confirm this
+	// state.
+	pendingUpdates := make([]*lnrpc.PendingUpdate, 0, len(b.channels))
+	for _, channel := range b.channels {
+		select {
+		case update := <-channel.updateChan:
+			err := channel.processPendingUpdate(update)
+			if err != nil {
+				return nil, fmt.Errorf("error processing "+
+					"pending update: %v", err)
+			}
+
+			pendingUpdates = append(
+				pendingUpdates, update.GetChanPending(),
+			)
+
+		case err := <-channel.errChan:
+			channel.hasFailed = true
+			return nil, fmt.Errorf("error opening channel: %v",
+				err)
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		case <-b.cfg.Quit:
+			return nil, errShuttingDown
+		}
+	}
+
+	// All channels are pending now, so we can publish the batch
+	// transaction.
+	log.Infof("Publishing batch funding transaction %v for %d channels",
+		finalTx.TxHash(), len(b.channels))
+
+	err = b.cfg.Wallet.PublishTransaction(finalTx, label)
+	if err != nil {
+		return nil, fmt.Errorf("error publishing final batch "+
+			"transaction: %v", err)
+	}
+	b.didPublish = true
+
+	return pendingUpdates, nil
+}
+
+// cleanup tries to remove any pending state or UTXO locks in case we had to
+// abort before finalizing and publishing the funding transaction.
+func (b *Batcher) cleanup(fundingErr error) {
+	log.Warnf("Aborting batch channel open: %v", fundingErr)
+
+	for _, channel := range b.channels {
+		// If we never started the negotiation for this channel, there
+		// is nothing to clean up.
+		if channel.updateChan == nil {
+			continue
+		}
+
+		// A channel that was handed the signed funding transaction
+		// might become pending at any moment, even if we didn't wait
+		// for it. We need to know its outcome to clean it up properly.
+		if channel.isFinalized && !channel.isPending &&
+			!channel.hasFailed {
+
+			b.waitForOutcome(channel)
+		}
+
+		// Channels that already made it to the pending state were
+		// persisted in our database, so we need to abandon them.
+		if channel.isPending {
+			err := b.cfg.ChannelAbandoner(channel.chanPoint)
+			if err != nil {
+				log.Errorf("Unable to abandon channel %v: %v",
+					channel.chanPoint, err)
+			}
+
+			continue
+		}
+
+		// All other channels are still in the negotiation phase, so
+		// cancelling their funding intent also fails the flow with the
+		// peer. If the negotiation has already failed, there's no
+		// intent left to cancel.
+		err := b.cfg.Wallet.CancelFundingIntent(channel.pendingChanID)
+		if err != nil {
+			log.Debugf("Unable to cancel funding intent for "+
+				"pending channel %x: %v", channel.pendingChanID,
+				err)
+		}
+	}
+
+	for _, outpoint := range b.lockedUTXOs {
+		b.cfg.Wallet.UnlockOutpoint(outpoint)
+	}
+}
+
+// waitForOutcome waits for a channel that was handed the signed funding
+// transaction to either become pending or fail.
+func (b *Batcher) waitForOutcome(channel *batchChannel) {
+	select {
+	case update := <-channel.updateChan:
+		err := channel.processPendingUpdate(update)
+		if err != nil {
+			log.Errorf("Error processing pending update for "+
+				"pending channel %x: %v", channel.pendingChanID,
+				err)
+		}
+
+	case err := <-channel.errChan:
+		log.Debugf("Pending channel %x failed during cleanup: %v",
+			channel.pendingChanID, err)
+
+	case <-time.After(batchCleanupTimeout):
+		log.Warnf("Timed out waiting for outcome of pending channel "+
+			"%x, it might need to be abandoned manually",
+			channel.pendingChanID)
+
+	case <-b.cfg.Quit:
+	}
+}
@@ -0,0 +1,444 @@
+package funding
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errFundingFailed = errors.New("funding failed")
+
+	batchTestParams = &chaincfg.RegressionNetParams
+)
+
+// failStage describes at which point of the negotiation a channel of the
+// batch fails.
+type failStage uint8
+
+const (
+	// failNever means the channel open succeeds.
+	failNever failStage = iota
+
+	// failBeforePsbt means the channel open fails before the funding
+	// output is known.
+	failBeforePsbt
+
+	// failAfterFinalize means the channel open fails after the funding
+	// transaction was handed to the funding intent.
+	failAfterFinalize
+)
+
+// batchTestChannel is the state the test harness keeps for each channel that
+// is opened by the batcher.
+type batchTestChannel struct {
+	req         *InitFundingMsg
+	updateChan  chan *lnrpc.OpenStatusUpdate
+	errChan     chan error
+	pkScript    []byte
+	fundingAddr string
+	fail        failStage
+}
+
+// batchTestHarness implements all dependencies of the Batcher and records
+// the calls made to them.
+type batchTestHarness struct {
+	t *testing.T
+
+	failures map[btcutil.Amount]failStage
+	channels map[[32]byte]*batchTestChannel
+
+	// holdPsbt is the number of channels that must be opened before any
+	// of them sends its PSBT funding update.
+	holdPsbt int
+	held     []*batchTestChannel
+
+	input       wire.OutPoint
+	finalTx     *wire.MsgTx
+	locked      map[wire.OutPoint]bool
+	verified    map[[32]byte]bool
+	cancelled   map[[32]byte]bool
+	abandoned   map[wire.OutPoint]bool
+	published   *wire.MsgTx
+	publishErr  error
+	fundPsbtErr error
+	minConfs    int32
+}
+
+func newBatchTestHarness(t *testing.T) *batchTestHarness {
+	return &batchTestHarness{
+		t:         t,
+		failures:  make(map[btcutil.Amount]failStage),
+		channels:  make(map[[32]byte]*batchTestChannel),
+		input:     wire.OutPoint{Index: 7},
+		locked:    make(map[wire.OutPoint]bool),
+		verified:  make(map[[32]byte]bool),
+		cancelled: make(map[[32]byte]bool),
+		abandoned: make(map[wire.OutPoint]bool),
+	}
+}
+
+func (h *batchTestHarness) batcher() *Batcher {
+	return NewBatcher(&BatchConfig{
+		RequestParser:    h.parseRequest,
+		ChannelOpener:    h.openChannel,
+		ChannelAbandoner: h.abandonChannel,
+		Wallet:           h,
+		NetParams:        batchTestParams,
+		Quit:             make(chan struct{}),
+	})
+}
+
+func (h *batchTestHarness) parseRequest(
+	req *lnrpc.OpenChannelRequest) (*InitFundingMsg, error) {
+
+	minConfs, err := lnrpc.ExtractMinConfs(
+		req.MinConfs, req.SpendUnconfirmed,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InitFundingMsg{
+		LocalFundingAmt: btcutil.Amount(req.LocalFundingAmount),
+		FundingFeePerKw: chainfee.SatPerKWeight(253),
+		MinConfs:        minConfs,
+	}, nil
+}
+
+func (h *batchTestHarness) openChannel(
+	req *InitFundingMsg) (chan *lnrpc.OpenStatusUpdate, chan error) {
+
+	channel := &batchTestChannel{
+		req:        req,
+		updateChan: make(chan *lnrpc.OpenStatusUpdate, 2),
+		errChan:    make(chan error, 1),
+		fail:       h.failures[req.LocalFundingAmt],
+	}
+	h.channels[req.PendingChanID] = channel
+
+	if channel.fail == failBeforePsbt {
+		channel.errChan <- errFundingFailed
+		return channel.updateChan, channel.errChan
+	}
+
+	scriptHash := sha256.Sum256(req.PendingChanID[:])
+	addr, err := btcutil.NewAddressWitnessScriptHash(
+		scriptHash[:], batchTestParams,
+	)
+	require.NoError(h.t, err)
+	channel.pkScript = append([]byte{0x00, 0x20}, scriptHash[:]...)
+	channel.fundingAddr = addr.EncodeAddress()
+
+	// Hold back the PSBT funding updates until enough channels were
+	// opened, like slow peers would.
+	h.held = append(h.held, channel)
+	if len(h.held) < h.holdPsbt {
+		return channel.updateChan, channel.errChan
+	}
+
+	for _, held := range h.held {
+		held.updateChan <- &lnrpc.OpenStatusUpdate{
+			PendingChanId: held.req.PendingChanID[:],
+			Update: &lnrpc.OpenStatusUpdate_PsbtFund{
+				PsbtFund: &lnrpc.ReadyForPsbtFunding{
+					FundingAddress: held.fundingAddr,
+					FundingAmount: int64(
+						held.req.LocalFundingAmt,
+					),
+				},
+			},
+		}
+	}
+	h.held = nil
+
+	return channel.updateChan, channel.errChan
+}
+
+func (h *batchTestHarness) abandonChannel(op *wire.OutPoint) error {
+	h.abandoned[*op] = true
+	return nil
+}
+
+func (h *batchTestHarness) WithCoinSelectLock(f func() error) error {
+	return f()
+}
+
+func (h *batchTestHarness) FundPsbt(packet *psbt.Packet, minConfs int32,
+	_ chainfee.SatPerKWeight, _ string) (int32, error) {
+
+	if h.fundPsbtErr != nil {
+		return 0, h.fundPsbtErr
+	}
+
+	h.minConfs = minConfs
+
+	var outputSum int64
+	for _, out := range packet.UnsignedTx.TxOut {
+		outputSum += out.Value
+	}
+
+	packet.UnsignedTx.TxIn = append(packet.UnsignedTx.TxIn, &wire.TxIn{
+		PreviousOutPoint: h.input,
+	})
+	packet.Inputs = append(packet.Inputs, psbt.PInput{
+		WitnessUtxo: &wire.TxOut{
+			Value:    outputSum + 10000,
+			PkScript: []byte{0x00, 0x14},
+		},
+	})
+
+	return -1, nil
+}
+
+func (h *batchTestHarness) FinalizePsbt(packet *psbt.Packet, _ string) error {
+	for idx := range packet.Inputs {
+		packet.Inputs[idx].FinalScriptWitness = []byte{0x01, 0x01, 0x01}
+	}
+
+	return nil
+}
+
+func (h *batchTestHarness) LockOutpoint(o wire.OutPoint) {
+	h.locked[o] = true
+}
+
+func (h *batchTestHarness) UnlockOutpoint(o wire.OutPoint) {
+	delete(h.locked, o)
+}
+
+func (h *batchTestHarness) PsbtFundingVerify(pendingChanID [32]byte,
+	packet *psbt.Packet) error {
+
+	channel, ok := h.channels[pendingChanID]
+	require.True(h.t, ok)
+
+	found := false
+	for _, out := range packet.UnsignedTx.TxOut {
+		if out.Value == int64(channel.req.LocalFundingAmt) &&
+			string(out.PkScript) == string(channel.pkScript) {
+
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("funding output not found")
+	}
+
+	h.verified[pendingChanID] = true
+
+	return nil
+}
+
+func (h *batchTestHarness) PsbtFundingFinalize(pendingChanID [32]byte,
+	packet *psbt.Packet, _ *wire.MsgTx) error {
+
+	channel, ok := h.channels[pendingChanID]
+	require.True(h.t, ok)
+	require.True(h.t, h.verified[pendingChanID])
+
+	if channel.fail == failAfterFinalize {
+		channel.errChan <- errFundingFailed
+		return nil
+	}
+
+	finalTx, err := psbt.Extract(packet)
+	require.NoError(h.t, err)
+	h.finalTx = finalTx
+
+	txHash := finalTx.TxHash()
+	for idx, out := range finalTx.TxOut {
+		if string(out.PkScript) != string(channel.pkScript) {
+			continue
+		}
+
+		channel.updateChan <- &lnrpc.OpenStatusUpdate{
+			PendingChanId: pendingChanID[:],
+			Update: &lnrpc.OpenStatusUpdate_ChanPending{
+				ChanPending: &lnrpc.PendingUpdate{
+					Txid:        txHash[:],
+					OutputIndex: uint32(idx),
+				},
+			},
+		}
+	}
+
+	return nil
+}
+
+func (h *batchTestHarness) PublishTransaction(tx *wire.MsgTx, _ string) error {
+	if h.publishErr != nil {
+		return h.publishErr
+	}
+
+	h.published = tx
+
+	return nil
+}
+
+func (h *batchTestHarness) CancelFundingIntent(pendingChanID [32]byte) error {
+	h.cancelled[pendingChanID] = true
+	return nil
+}
+
+// batchRequest creates a batch open request for channels with the given
+// amounts.
+func batchRequest(amounts ...int64) *lnrpc.BatchOpenChannelRequest {
+	req := &lnrpc.BatchOpenChannelRequest{
+		Label: "batch",
+	}
+	for _, amt := range amounts {
+		req.Channels = append(req.Channels, &lnrpc.BatchOpenChannel{
+			LocalFundingAmount: amt,
+		})
+	}
+
+	return req
+}
+
+// TestBatchFund tests that a batch of channels is funded with a single
+// transaction that pays to all funding outputs.
+func TestBatchFund(t *testing.T) {
+	t.Parallel()
+
+	h := newBatchTestHarness(t)
+	pending, err := h.batcher().BatchFund(
+		context.Background(), batchRequest(100000, 200000, 300000),
+	)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+
+	// A single transaction paying to all three channels was published.
+	require.NotNil(t, h.published)
+	require.Len(t, h.published.TxOut, 3)
+	require.Len(t, h.published.TxIn, 1)
+
+	txHash := h.published.TxHash()
+	for _, update := range pending {
+		require.Equal(t, txHash[:], update.Txid)
+	}
+
+	// Nothing was rolled back and the input stays locked.
+	require.Empty(t, h.cancelled)
+	require.Empty(t, h.abandoned)
+	require.True(t, h.locked[h.input])
+
+	// Coin selection uses the default number of confirmations.
+	require.EqualValues(t, 1, h.minConfs)
+}
+
+// TestBatchFundParallel tests that the negotiations with all peers are started
+// before waiting for the funding output of any of them, and that the number
+// of confirmations of the batch request is used for coin selection.
+func TestBatchFundParallel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	h := newBatchTestHarness(t)
+	h.holdPsbt = 3
+
+	req := batchRequest(100000, 200000, 300000)
+	req.SpendUnconfirmed = true
+
+	pending, err := h.batcher().BatchFund(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+	require.NotNil(t, h.published)
+	require.EqualValues(t, 0, h.minConfs)
+}
+
+// TestBatchFundRollback tests that all channels of a batch are rolled back if
+// any of them fails.
+func TestBatchFundRollback(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		failures      map[btcutil.Amount]failStage
+		publishErr    error
+		fundPsbtErr   error
+		expectPending int
+		expectCancel  int
+	}{{
+		name: "peer fails before psbt",
+		failures: map[btcutil.Amount]failStage{
+			200000: failBeforePsbt,
+		},
+		expectCancel: 3,
+	}, {
+		name:         "funding fails",
+		fundPsbtErr:  errFundingFailed,
+		expectCancel: 3,
+	}, {
+		name: "peer fails after finalize",
+		failures: map[btcutil.Amount]failStage{
+			200000: failAfterFinalize,
+		},
+		expectPending: 2,
+		expectCancel:  1,
+	}, {
+		name:          "publish fails",
+		publishErr:    errFundingFailed,
+		expectPending: 3,
+	}}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := newBatchTestHarness(t)
+			h.failures = tc.failures
+			h.publishErr = tc.publishErr
+			h.fundPsbtErr = tc.fundPsbtErr
+
+			_, err := h.batcher().BatchFund(
+				context.Background(),
+				batchRequest(100000, 200000, 300000),
+			)
+			require.Error(t, err)
+
+			// No transaction was published, every channel that
+			// became pending was abandoned and the intents of all
+			// others were cancelled.
+			require.Nil(t, h.published)
+			require.Len(t, h.abandoned, tc.expectPending)
+			require.Len(t, h.cancelled, tc.expectCancel)
+			require.Empty(t, h.locked)
+		})
+	}
+}
+
+// TestBatchFundInvalidRequest tests that invalid batch requests are rejected
+// before any channel negotiation is started.
+func TestBatchFundInvalidRequest(t *testing.T) {
+	t.Parallel()
+
+	h := newBatchTestHarness(t)
+	_, err := h.batcher().BatchFund(context.Background(), batchRequest())
+	require.Error(t, err)
+
+	req := batchRequest(100000)
+	req.Channels[0].PendingChanId = make([]byte, 32)
+	_, err = h.batcher().BatchFund(context.Background(), req)
+	require.Error(t, err)
+
+	req.Channels[0].PendingChanId = []byte{1, 2, 3}
+	_, err = h.batcher().BatchFund(context.Background(), req)
+	require.Error(t, err)
+
+	require.Empty(t, h.channels)
+}
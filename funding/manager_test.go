@@ -355,8 +355,8 @@ func createTestFundingManager(t *testing.T, privKey *btcec.PrivateKey,
 		Wallet:       lnw,
 		Notifier:     chainNotifier,
 		FeeEstimator: estimator,
-		SignMessage: func(pubKey *btcec.PublicKey,
-			msg []byte) (input.Signature, error) {
+		SignMessage: func(keyLoc keychain.KeyLocator,
+			msg []byte) (*btcec.Signature, error) {
 
 			return testSig, nil
 		},
@@ -502,8 +502,8 @@ func recreateAliceFundingManager(t *testing.T, alice *testNode) {
 		Wallet:       oldCfg.Wallet,
 		Notifier:     oldCfg.Notifier,
 		FeeEstimator: oldCfg.FeeEstimator,
-		SignMessage: func(pubKey *btcec.PublicKey,
-			msg []byte) (input.Signature, error) {
+		SignMessage: func(keyLoc keychain.KeyLocator,
+			msg []byte) (*btcec.Signature, error) {
 			return testSig, nil
 		},
 		SendAnnouncement: func(msg lnwire.Message,
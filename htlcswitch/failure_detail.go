@@ -54,6 +54,16 @@ const (
 	// OutgoingFailureForwardsDisabled is returned when the switch is
 	// configured to disallow forwards.
 	OutgoingFailureForwardsDisabled
+
+	// OutgoingFailureHtlcSlotsReserved is returned when forwarding a htlc
+	// would use one of the outgoing channel's htlc slots that we reserve
+	// for our own payments.
+	OutgoingFailureHtlcSlotsReserved
+
+	// OutgoingFailureHtlcValueReserved is returned when forwarding a htlc
+	// would use some of the outgoing channel's value in flight that we
+	// reserve for our own payments.
+	OutgoingFailureHtlcValueReserved
 )
 
 // FailureString returns the string representation of a failure detail.
@@ -91,6 +101,44 @@ func (fd OutgoingFailure) FailureString() string {
 	case OutgoingFailureForwardsDisabled:
 		return "node configured to disallow forwards"
 
+	case OutgoingFailureHtlcSlotsReserved:
+		return "remaining htlc slots are reserved for local payments"
+
+	case OutgoingFailureHtlcValueReserved:
+		return "remaining value in flight is reserved for local payments"
+
+	default:
+		return "unknown failure detail"
+	}
+}
+
+// IncomingFailure is an enum which is used to enrich failures which occur on
+// our incoming link, before the htlc reaches the switch, with additional
+// metadata.
+type IncomingFailure int
+
+const (
+	// IncomingFailureNone is returned when the wire message contains
+	// sufficient information.
+	IncomingFailureNone IncomingFailure = iota
+
+	// IncomingFailureRateLimited is returned when the htlc was offered
+	// while either the peer or the channel exceeded its rate limit for
+	// adding htlcs to be forwarded.
+	IncomingFailureRateLimited
+)
+
+// FailureString returns the string representation of a failure detail.
+//
+// Note: it is part of the FailureDetail interface.
+func (fd IncomingFailure) FailureString() string {
+	switch fd {
+	case IncomingFailureNone:
+		return "no failure detail"
+
+	case IncomingFailureRateLimited:
+		return "htlc add rate limit exceeded"
+
 	default:
 		return "unknown failure detail"
 	}
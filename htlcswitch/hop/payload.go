@@ -90,6 +90,10 @@ type Payload struct {
 	// a TLV onion payload.
 	AMP *record.AMP
 
+	// metadata is additional data that is sent along with the payment to
+	// the payee.
+	metadata []byte
+
 	// customRecords are user-defined records in the custom type range that
 	// were included in the payload.
 	customRecords record.CustomSet
@@ -115,11 +119,12 @@ func NewLegacyPayload(f *sphinx.HopData) *Payload {
 // should correspond to the bytes encapsulated in a TLV onion payload.
 func NewPayloadFromReader(r io.Reader) (*Payload, error) {
 	var (
-		cid  uint64
-		amt  uint64
-		cltv uint32
-		mpp  = &record.MPP{}
-		amp  = &record.AMP{}
+		cid      uint64
+		amt      uint64
+		cltv     uint32
+		mpp      = &record.MPP{}
+		amp      = &record.AMP{}
+		metadata []byte
 	)
 
 	tlvStream, err := tlv.NewStream(
@@ -128,6 +133,7 @@ func NewPayloadFromReader(r io.Reader) (*Payload, error) {
 		record.NewNextHopIDRecord(&cid),
 		mpp.Record(),
 		amp.Record(),
+		record.NewMetadataRecord(&metadata),
 	)
 	if err != nil {
 		return nil, err
@@ -168,6 +174,12 @@ func NewPayloadFromReader(r io.Reader) (*Payload, error) {
 		amp = nil
 	}
 
+	// If no metadata field was parsed, set the metadata field on the
+	// resulting payload to nil.
+	if _, ok := parsedTypes[record.MetadataOnionType]; !ok {
+		metadata = nil
+	}
+
 	// Filter out the custom records.
 	customRecords := NewCustomRecords(parsedTypes)
 
@@ -180,6 +192,7 @@ func NewPayloadFromReader(r io.Reader) (*Payload, error) {
 		},
 		MPP:           mpp,
 		AMP:           amp,
+		metadata:      metadata,
 		customRecords: customRecords,
 	}, nil
 }
@@ -217,6 +230,7 @@ func ValidateParsedPayloadTypes(parsedTypes tlv.TypeMap,
 	_, hasNextHop := parsedTypes[record.NextHopOnionType]
 	_, hasMPP := parsedTypes[record.MPPOnionType]
 	_, hasAMP := parsedTypes[record.AMPOnionType]
+	_, hasMetadata := parsedTypes[record.MetadataOnionType]
 
 	switch {
 
@@ -261,6 +275,14 @@ func ValidateParsedPayloadTypes(parsedTypes tlv.TypeMap,
 			Violation: IncludedViolation,
 			FinalHop:  isFinalHop,
 		}
+
+	// Intermediate nodes should never receive payment metadata.
+	case !isFinalHop && hasMetadata:
+		return ErrInvalidPayload{
+			Type:      record.MetadataOnionType,
+			Violation: IncludedViolation,
+			FinalHop:  isFinalHop,
+		}
 	}
 
 	return nil
@@ -278,6 +300,12 @@ func (h *Payload) AMPRecord() *record.AMP {
 	return h.AMP
 }
 
+// Metadata returns the additional data that is sent along with the
+// payment to the payee.
+func (h *Payload) Metadata() []byte {
+	return h.metadata
+}
+
 // CustomRecords returns the custom tlv type records that were parsed from the
 // payload.
 func (h *Payload) CustomRecords() record.CustomSet {
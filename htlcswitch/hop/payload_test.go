@@ -11,7 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-const testUnknownRequiredType = 0x10
+const testUnknownRequiredType = 0x12
 
 type decodePayloadTest struct {
 	name             string
@@ -20,6 +20,7 @@ type decodePayloadTest struct {
 	expCustomRecords map[uint64][]byte
 	shouldHaveMPP    bool
 	shouldHaveAMP    bool
+	expMetadata      []byte
 }
 
 var decodePayloadTests = []decodePayloadTest{
@@ -149,6 +150,18 @@ var decodePayloadTests = []decodePayloadTest{
 			65536: {0x10, 0x11},
 		},
 	},
+	{
+		name: "intermediate hop with custom records",
+		payload: []byte{0x02, 0x00, 0x04, 0x00, 0x06, 0x08, 0x01, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0xfe, 0x00, 0x01, 0x00, 0x00, 0x02, 0x10, 0x11,
+			0xfe, 0x00, 0x01, 0x00, 0x01, 0x01, 0x12,
+		},
+		expCustomRecords: map[uint64][]byte{
+			65536: {0x10, 0x11},
+			65537: {0x12},
+		},
+	},
 	{
 		name: "valid intermediate hop",
 		payload: []byte{0x02, 0x00, 0x04, 0x00, 0x06, 0x08, 0x01, 0x00,
@@ -258,6 +271,37 @@ var decodePayloadTests = []decodePayloadTest{
 		},
 		shouldHaveAMP: true,
 	},
+	{
+		name: "intermediate hop with metadata",
+		payload: []byte{
+			// amount
+			0x02, 0x00,
+			// cltv
+			0x04, 0x00,
+			// next hop id
+			0x06, 0x08,
+			0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			// metadata
+			0x10, 0x03, 0x01, 0x02, 0x03,
+		},
+		expErr: hop.ErrInvalidPayload{
+			Type:      record.MetadataOnionType,
+			Violation: hop.IncludedViolation,
+			FinalHop:  false,
+		},
+	},
+	{
+		name: "final hop with metadata",
+		payload: []byte{
+			// amount
+			0x02, 0x00,
+			// cltv
+			0x04, 0x00,
+			// metadata
+			0x10, 0x03, 0x01, 0x02, 0x03,
+		},
+		expMetadata: []byte{0x01, 0x02, 0x03},
+	},
 }
 
 // TestDecodeHopPayloadRecordValidation asserts that parsing the payloads in the
@@ -331,6 +375,8 @@ func testDecodeHopPayloadValidation(t *testing.T, test decodePayloadTest) {
 		t.Fatalf("unexpected AMP payload")
 	}
 
+	require.Equal(t, test.expMetadata, p.Metadata())
+
 	// Convert expected nil map to empty map, because we always expect an
 	// initiated map from the payload.
 	expCustomRecords := make(record.CustomSet)
@@ -10,6 +10,7 @@ import (
 	"github.com/lightningnetwork/lnd/htlcswitch/hop"
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
 	"github.com/lightningnetwork/lnd/subscribe"
 )
 
@@ -144,6 +145,11 @@ type HtlcInfo struct {
 
 	// OutgoingAmt is the amount of the htlc on our outgoing channel.
 	OutgoingAmt lnwire.MilliSatoshi
+
+	// CustomRecords holds the custom records in the custom type range
+	// that the sender included in the onion payload for our hop. It is
+	// only populated for forwarded htlcs.
+	CustomRecords record.CustomSet
 }
 
 // String returns a string representation of a htlc.
@@ -417,6 +423,7 @@ func newHtlcInfo(pkt *htlcPacket) HtlcInfo {
 		OutgoingTimeLock: pkt.outgoingTimeout,
 		IncomingAmt:      pkt.incomingAmount,
 		OutgoingAmt:      pkt.amount,
+		CustomRecords:    pkt.customRecords,
 	}
 }
 
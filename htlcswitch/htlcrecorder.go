@@ -0,0 +1,356 @@
+package htlcswitch
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/subscribe"
+	"github.com/lightningnetwork/lnd/ticker"
+)
+
+// DefaultMaxInFlightForwards is the default number of unresolved forwards
+// that the htlc event recorder keeps track of. A forward whose resolution we
+// never see, for example because it was resolved on chain, would otherwise be
+// tracked forever.
+const DefaultMaxInFlightForwards = 50000
+
+// errRecorderShuttingDown is returned when a flush is requested while the
+// htlc event recorder is shutting down.
+var errRecorderShuttingDown = errors.New("htlc event recorder shutting down")
+
+// HtlcEventRecorderConfig holds the configuration for the HtlcEventRecorder.
+type HtlcEventRecorderConfig struct {
+	// SubscribeHtlcEvents returns a subscription to the htlc events that
+	// the switch emits.
+	SubscribeHtlcEvents func() (*subscribe.Client, error)
+
+	// AddHtlcEvents persists a batch of resolved htlc events.
+	AddHtlcEvents func([]channeldb.HtlcEvent) error
+
+	// FlushTicker signals the recorder to write any pending htlc events
+	// to disk.
+	FlushTicker ticker.Ticker
+
+	// MaxInFlight is the maximum number of unresolved forwards that the
+	// recorder keeps track of. Once it is reached, the oldest forward is
+	// evicted and recorded without its amounts and hold time when it
+	// resolves, just like forwards that were in flight on startup.
+	MaxInFlight int
+}
+
+// inFlightForward tracks a forward that has been added to our outgoing link
+// but has not yet been resolved.
+type inFlightForward struct {
+	// key identifies the forward.
+	key HtlcKey
+
+	// info contains the amounts and timelocks of the forward.
+	info HtlcInfo
+
+	// event is the forwarding event that was emitted when the forward was
+	// added to the outgoing link.
+	event *ForwardingEvent
+}
+
+// HtlcEventRecorder consumes the htlc events emitted by the HtlcNotifier and
+// records the resolution of every forward in the htlc event log. Forwarding
+// events are matched with their subsequent settle or failure to determine how
+// long the htlc was held. Forwards that were in flight when the recorder was
+// started are still recorded once they resolve, but without their amounts and
+// hold time because those are only known from the original forwarding event.
+type HtlcEventRecorder struct {
+	started sync.Once
+	stopped sync.Once
+
+	cfg *HtlcEventRecorderConfig
+
+	// inFlight maps the htlc keys of in flight forwards to their element
+	// in inFlightOrder.
+	inFlight map[HtlcKey]*list.Element
+
+	// inFlightOrder holds the in flight forwards in the order they were
+	// added, so that the oldest one can be evicted once MaxInFlight is
+	// reached.
+	inFlightOrder *list.List
+
+	// pending holds the htlc events that have been resolved but have not
+	// yet been flushed to disk.
+	pending []channeldb.HtlcEvent
+
+	// flushRequests is used to request an immediate flush of the pending
+	// htlc events.
+	flushRequests chan chan error
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// NewHtlcEventRecorder creates a new htlc event recorder.
+func NewHtlcEventRecorder(cfg *HtlcEventRecorderConfig) *HtlcEventRecorder {
+	return &HtlcEventRecorder{
+		cfg:           cfg,
+		inFlight:      make(map[HtlcKey]*list.Element),
+		inFlightOrder: list.New(),
+		flushRequests: make(chan chan error),
+		quit:          make(chan struct{}),
+	}
+}
+
+// Start subscribes to htlc events and starts recording them.
+func (r *HtlcEventRecorder) Start() error {
+	var err error
+	r.started.Do(func() {
+		log.Info("HtlcEventRecorder starting")
+
+		var client *subscribe.Client
+		client, err = r.cfg.SubscribeHtlcEvents()
+		if err != nil {
+			return
+		}
+
+		r.cfg.FlushTicker.Resume()
+
+		r.wg.Add(1)
+		go r.recordEvents(client)
+	})
+
+	return err
+}
+
+// Stop signals the recorder to flush its pending events and shut down.
+func (r *HtlcEventRecorder) Stop() error {
+	r.stopped.Do(func() {
+		log.Info("HtlcEventRecorder shutting down")
+
+		close(r.quit)
+		r.wg.Wait()
+
+		r.cfg.FlushTicker.Stop()
+	})
+
+	return nil
+}
+
+// FlushHtlcEvents writes all htlc events that have been resolved so far to
+// disk, so that a subsequent query of the htlc event log includes them.
+func (r *HtlcEventRecorder) FlushHtlcEvents() error {
+	errChan := make(chan error, 1)
+
+	select {
+	case r.flushRequests <- errChan:
+	case <-r.quit:
+		return errRecorderShuttingDown
+	}
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-r.quit:
+		return errRecorderShuttingDown
+	}
+}
+
+// recordEvents is the main loop of the recorder. It consumes htlc events and
+// periodically flushes the resolved forwards to disk.
+//
+// NOTE: This MUST be run as a goroutine.
+func (r *HtlcEventRecorder) recordEvents(client *subscribe.Client) {
+	defer r.wg.Done()
+	defer client.Cancel()
+
+	for {
+		select {
+		case update := <-client.Updates():
+			r.handleEvent(update)
+
+		case <-r.cfg.FlushTicker.Ticks():
+			if err := r.flush(); err != nil {
+				log.Errorf("Unable to write htlc events: %v",
+					err)
+			}
+
+		case errChan := <-r.flushRequests:
+			errChan <- r.flush()
+
+		case <-client.Quit():
+			if err := r.flush(); err != nil {
+				log.Errorf("Unable to write htlc events: %v",
+					err)
+			}
+			return
+
+		case <-r.quit:
+			if err := r.flush(); err != nil {
+				log.Errorf("Unable to write htlc events: %v",
+					err)
+			}
+			return
+		}
+	}
+}
+
+// handleEvent processes a single htlc event. Only events that belong to
+// forwards are of interest to the recorder.
+func (r *HtlcEventRecorder) handleEvent(update interface{}) {
+	switch event := update.(type) {
+	case *ForwardingEvent:
+		if event.HtlcEventType != HtlcEventTypeForward {
+			return
+		}
+
+		r.track(&inFlightForward{
+			key:   event.HtlcKey,
+			info:  event.HtlcInfo,
+			event: event,
+		})
+
+	case *SettleEvent:
+		if event.HtlcEventType != HtlcEventTypeForward {
+			return
+		}
+
+		htlcEvent := r.resolve(event.HtlcKey, event.Timestamp)
+		htlcEvent.Outcome = channeldb.HtlcOutcomeSettled
+		r.pending = append(r.pending, htlcEvent)
+
+	case *ForwardingFailEvent:
+		if event.HtlcEventType != HtlcEventTypeForward {
+			return
+		}
+
+		htlcEvent := r.resolve(event.HtlcKey, event.Timestamp)
+		htlcEvent.Outcome = channeldb.HtlcOutcomeForwardFailed
+		r.pending = append(r.pending, htlcEvent)
+
+	case *LinkFailEvent:
+		if event.HtlcEventType != HtlcEventTypeForward {
+			return
+		}
+
+		htlcEvent := r.resolve(event.HtlcKey, event.Timestamp)
+		htlcEvent.Outcome = channeldb.HtlcOutcomeLinkFailed
+		htlcEvent.FailedIncoming = event.Incoming
+
+		// Link failures carry the htlc's details themselves, so we
+		// use them when the forward was never added to the outgoing
+		// link.
+		if htlcEvent.AmtIn == 0 {
+			htlcEvent.AmtIn = event.IncomingAmt
+			htlcEvent.AmtOut = event.OutgoingAmt
+		}
+
+		if event.LinkError != nil {
+			msg := event.LinkError.WireMessage()
+			if msg != nil {
+				htlcEvent.FailureCode = msg.Code()
+			}
+
+			htlcEvent.FailureDetail = failureDetailCode(
+				event.LinkError.FailureDetail,
+			)
+		}
+
+		r.pending = append(r.pending, htlcEvent)
+	}
+}
+
+// track adds a forward to the set of in flight forwards, evicting the oldest
+// one if the set is full.
+func (r *HtlcEventRecorder) track(fwd *inFlightForward) {
+	if elem, ok := r.inFlight[fwd.key]; ok {
+		r.inFlightOrder.Remove(elem)
+	}
+
+	for r.cfg.MaxInFlight > 0 &&
+		r.inFlightOrder.Len() >= r.cfg.MaxInFlight {
+
+		oldest := r.inFlightOrder.Front()
+		evicted := r.inFlightOrder.Remove(oldest).(*inFlightForward)
+		delete(r.inFlight, evicted.key)
+
+		log.Debugf("Evicted in flight forward %v from htlc event "+
+			"recorder", evicted.key)
+	}
+
+	r.inFlight[fwd.key] = r.inFlightOrder.PushBack(fwd)
+}
+
+// resolve removes the forward identified by the given key from the set of in
+// flight forwards and returns a htlc event that resolves it at the given
+// time.
+func (r *HtlcEventRecorder) resolve(key HtlcKey,
+	resolvedAt time.Time) channeldb.HtlcEvent {
+
+	htlcEvent := channeldb.HtlcEvent{
+		Timestamp:      resolvedAt,
+		IncomingChanID: key.IncomingCircuit.ChanID,
+		OutgoingChanID: key.OutgoingCircuit.ChanID,
+		IncomingHtlcID: key.IncomingCircuit.HtlcID,
+		OutgoingHtlcID: key.OutgoingCircuit.HtlcID,
+	}
+
+	elem, ok := r.inFlight[key]
+	if !ok {
+		return htlcEvent
+	}
+	delete(r.inFlight, key)
+	fwd := r.inFlightOrder.Remove(elem).(*inFlightForward)
+
+	htlcEvent.AmtIn = fwd.info.IncomingAmt
+	htlcEvent.AmtOut = fwd.info.OutgoingAmt
+	htlcEvent.HoldTime = resolvedAt.Sub(fwd.event.Timestamp)
+
+	return htlcEvent
+}
+
+// flush writes all pending htlc events to disk. If the write fails, the events
+// are kept so that they can be written on the next attempt.
+func (r *HtlcEventRecorder) flush() error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	if err := r.cfg.AddHtlcEvents(r.pending); err != nil {
+		return err
+	}
+
+	r.pending = nil
+
+	return nil
+}
+
+// failureDetailCode returns the code under which a failure detail is stored
+// in the htlc event log. Whether the code refers to an IncomingFailure or an
+// OutgoingFailure is determined by the side of the link the htlc failed on.
+func failureDetailCode(detail FailureDetail) uint16 {
+	switch d := detail.(type) {
+	case OutgoingFailure:
+		return uint16(d)
+
+	case IncomingFailure:
+		return uint16(d)
+
+	default:
+		return 0
+	}
+}
+
+// HtlcEventFailureDetail returns the failure detail of a htlc that was failed
+// on one of our links, as recorded in the htlc event log. It returns nil if no
+// detail was recorded.
+func HtlcEventFailureDetail(event *channeldb.HtlcEvent) FailureDetail {
+	if event.Outcome != channeldb.HtlcOutcomeLinkFailed ||
+		event.FailureDetail == 0 {
+
+		return nil
+	}
+
+	if event.FailedIncoming {
+		return IncomingFailure(event.FailureDetail)
+	}
+
+	return OutgoingFailure(event.FailureDetail)
+}
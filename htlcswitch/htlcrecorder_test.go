@@ -0,0 +1,243 @@
+package htlcswitch
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/subscribe"
+	"github.com/lightningnetwork/lnd/ticker"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHtlcEventRecorder tests that the htlc event recorder matches forwarding
+// events with their resolution and records the outcome of forwards only.
+func TestHtlcEventRecorder(t *testing.T) {
+	t.Parallel()
+
+	ntfnServer := subscribe.NewServer()
+	require.NoError(t, ntfnServer.Start())
+	defer func() {
+		require.NoError(t, ntfnServer.Stop())
+	}()
+
+	var (
+		mu       sync.Mutex
+		recorded []channeldb.HtlcEvent
+	)
+	recorder := NewHtlcEventRecorder(&HtlcEventRecorderConfig{
+		SubscribeHtlcEvents: ntfnServer.Subscribe,
+		AddHtlcEvents: func(events []channeldb.HtlcEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			recorded = append(recorded, events...)
+			return nil
+		},
+		FlushTicker: ticker.NewForce(time.Hour),
+	})
+	require.NoError(t, recorder.Start())
+	defer func() {
+		require.NoError(t, recorder.Stop())
+	}()
+
+	inChan := lnwire.NewShortChanIDFromInt(1)
+	outChan := lnwire.NewShortChanIDFromInt(2)
+
+	settledKey := HtlcKey{
+		IncomingCircuit: channeldb.CircuitKey{ChanID: inChan, HtlcID: 1},
+		OutgoingCircuit: channeldb.CircuitKey{ChanID: outChan, HtlcID: 1},
+	}
+	failedKey := HtlcKey{
+		IncomingCircuit: channeldb.CircuitKey{ChanID: inChan, HtlcID: 2},
+		OutgoingCircuit: channeldb.CircuitKey{ChanID: outChan, HtlcID: 2},
+	}
+	info := HtlcInfo{
+		IncomingAmt: 1010,
+		OutgoingAmt: 1000,
+	}
+
+	addTime := time.Unix(1000, 0)
+	resolveTime := addTime.Add(time.Second)
+
+	updates := []interface{}{
+		// A local send should be ignored.
+		&ForwardingEvent{
+			HtlcKey: HtlcKey{
+				OutgoingCircuit: channeldb.CircuitKey{
+					ChanID: outChan, HtlcID: 3,
+				},
+			},
+			HtlcEventType: HtlcEventTypeSend,
+			Timestamp:     addTime,
+		},
+
+		// A forward which is settled after a second.
+		&ForwardingEvent{
+			HtlcKey:       settledKey,
+			HtlcInfo:      info,
+			HtlcEventType: HtlcEventTypeForward,
+			Timestamp:     addTime,
+		},
+		&SettleEvent{
+			HtlcKey:       settledKey,
+			HtlcEventType: HtlcEventTypeForward,
+			Timestamp:     resolveTime,
+		},
+
+		// A forward which fails downstream.
+		&ForwardingEvent{
+			HtlcKey:       failedKey,
+			HtlcInfo:      info,
+			HtlcEventType: HtlcEventTypeForward,
+			Timestamp:     addTime,
+		},
+		&ForwardingFailEvent{
+			HtlcKey:       failedKey,
+			HtlcEventType: HtlcEventTypeForward,
+			Timestamp:     resolveTime,
+		},
+
+		// A forward which we rate limited on our incoming link. It
+		// never made it to the outgoing link, so only the outgoing
+		// channel the sender asked for is known.
+		&LinkFailEvent{
+			HtlcKey: HtlcKey{
+				IncomingCircuit: channeldb.CircuitKey{
+					ChanID: inChan, HtlcID: 3,
+				},
+				OutgoingCircuit: channeldb.CircuitKey{
+					ChanID: outChan,
+				},
+			},
+			HtlcInfo:      info,
+			HtlcEventType: HtlcEventTypeForward,
+			LinkError: NewDetailedLinkError(
+				&lnwire.FailTemporaryChannelFailure{},
+				IncomingFailureRateLimited,
+			),
+			Incoming:  true,
+			Timestamp: resolveTime,
+		},
+	}
+	for _, update := range updates {
+		require.NoError(t, ntfnServer.SendUpdate(update))
+	}
+
+	// The events are delivered asynchronously, so we flush until all
+	// resolved forwards have been recorded.
+	require.Eventually(t, func() bool {
+		if err := recorder.FlushHtlcEvents(); err != nil {
+			return false
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(recorded) == 3
+	}, time.Second*5, time.Millisecond*10)
+
+	expected := []channeldb.HtlcEvent{
+		{
+			Timestamp:      resolveTime,
+			IncomingChanID: inChan,
+			OutgoingChanID: outChan,
+			IncomingHtlcID: 1,
+			OutgoingHtlcID: 1,
+			AmtIn:          1010,
+			AmtOut:         1000,
+			Outcome:        channeldb.HtlcOutcomeSettled,
+			HoldTime:       time.Second,
+		},
+		{
+			Timestamp:      resolveTime,
+			IncomingChanID: inChan,
+			OutgoingChanID: outChan,
+			IncomingHtlcID: 2,
+			OutgoingHtlcID: 2,
+			AmtIn:          1010,
+			AmtOut:         1000,
+			Outcome:        channeldb.HtlcOutcomeForwardFailed,
+			HoldTime:       time.Second,
+		},
+		{
+			Timestamp:      resolveTime,
+			IncomingChanID: inChan,
+			OutgoingChanID: outChan,
+			IncomingHtlcID: 3,
+			AmtIn:          1010,
+			AmtOut:         1000,
+			Outcome:        channeldb.HtlcOutcomeLinkFailed,
+			FailedIncoming: true,
+			FailureCode:    lnwire.CodeTemporaryChannelFailure,
+			FailureDetail:  uint16(IncomingFailureRateLimited),
+		},
+	}
+
+	mu.Lock()
+	require.Equal(t, expected, recorded)
+	mu.Unlock()
+
+	// The recorded failure detail should map back to the detail we failed
+	// the htlc with.
+	require.Equal(
+		t, IncomingFailureRateLimited,
+		HtlcEventFailureDetail(&expected[2]),
+	)
+}
+
+// TestHtlcEventRecorderEviction tests that the recorder evicts the oldest in
+// flight forward once the maximum number of in flight forwards is reached.
+func TestHtlcEventRecorderEviction(t *testing.T) {
+	t.Parallel()
+
+	recorder := NewHtlcEventRecorder(&HtlcEventRecorderConfig{
+		MaxInFlight: 2,
+	})
+
+	addTime := time.Unix(1000, 0)
+	resolveTime := addTime.Add(time.Second)
+
+	keys := make([]HtlcKey, 3)
+	for i := range keys {
+		keys[i] = HtlcKey{
+			IncomingCircuit: channeldb.CircuitKey{
+				ChanID: lnwire.NewShortChanIDFromInt(1),
+				HtlcID: uint64(i),
+			},
+			OutgoingCircuit: channeldb.CircuitKey{
+				ChanID: lnwire.NewShortChanIDFromInt(2),
+				HtlcID: uint64(i),
+			},
+		}
+
+		recorder.handleEvent(&ForwardingEvent{
+			HtlcKey: keys[i],
+			HtlcInfo: HtlcInfo{
+				IncomingAmt: 1010,
+				OutgoingAmt: 1000,
+			},
+			HtlcEventType: HtlcEventTypeForward,
+			Timestamp:     addTime,
+		})
+	}
+
+	// Only the two most recent forwards should still be tracked.
+	require.Len(t, recorder.inFlight, 2)
+	require.Equal(t, 2, recorder.inFlightOrder.Len())
+
+	// The evicted forward is still recorded once it resolves, but without
+	// its amounts and hold time.
+	evicted := recorder.resolve(keys[0], resolveTime)
+	require.Zero(t, evicted.AmtIn)
+	require.Zero(t, evicted.HoldTime)
+
+	resolved := recorder.resolve(keys[2], resolveTime)
+	require.Equal(t, lnwire.MilliSatoshi(1010), resolved.AmtIn)
+	require.Equal(t, time.Second, resolved.HoldTime)
+
+	require.Len(t, recorder.inFlight, 1)
+	require.Equal(t, 1, recorder.inFlightOrder.Len())
+}
@@ -288,6 +288,27 @@ type ChannelLinkConfig struct {
 	// HtlcNotifier is an instance of a htlcNotifier which we will pipe htlc
 	// events through.
 	HtlcNotifier htlcNotifier
+
+	// PeerAddLimiter is an optional token bucket rate limiter that is
+	// shared between all links with the same remote peer. Every htlc the
+	// peer asks us to forward consumes a token, and htlcs that arrive
+	// while the bucket is empty are failed back.
+	PeerAddLimiter *AddLimiter
+
+	// ChannelAddLimiters is an optional set of token bucket rate limiters
+	// from which the link acquires the limiter of its channel. It applies
+	// to the htlcs the remote peer asks us to forward over this channel.
+	ChannelAddLimiters *ChannelAddLimiters
+
+	// ReservedSlotFraction is the fraction of this channel's outgoing htlc
+	// slots that are reserved for our own payments. Forwarded htlcs are
+	// rejected once they would use a reserved slot.
+	ReservedSlotFraction float64
+
+	// ReservedValueFraction is the fraction of this channel's outgoing max
+	// value in flight that is reserved for our own payments. Forwarded
+	// htlcs are rejected once they would use some of the reserved value.
+	ReservedValueFraction float64
 }
 
 // localUpdateAddMsg contains a locally initiated htlc and a channel that will
@@ -378,6 +399,15 @@ type channelLink struct {
 
 	sync.RWMutex
 
+	// chanAddLimiter is the token bucket rate limiter of the channel that
+	// is acquired from ChannelAddLimiters when the link is started. It is
+	// nil if the limit is disabled.
+	chanAddLimiter *AddLimiter
+
+	// chanAddLimiterID is the short channel ID under which chanAddLimiter
+	// was acquired, and under which it must be released.
+	chanAddLimiterID lnwire.ShortChannelID
+
 	// hodlQueue is used to receive exit hop htlc resolutions from invoice
 	// registry.
 	hodlQueue *queue.ConcurrentQueue
@@ -492,6 +522,16 @@ func (l *channelLink) Start() error {
 		}()
 	}
 
+	// The channel's rate limiter is shared with the previous links of
+	// the channel, so that the peer can't replenish the channel's
+	// allowance by reconnecting.
+	if l.cfg.ChannelAddLimiters != nil {
+		l.chanAddLimiterID = l.ShortChanID()
+		l.chanAddLimiter = l.cfg.ChannelAddLimiters.Acquire(
+			l.chanAddLimiterID,
+		)
+	}
+
 	l.updateFeeTimer = time.NewTimer(l.randomFeeUpdateTimeout())
 
 	l.wg.Add(1)
@@ -533,6 +573,10 @@ func (l *channelLink) Stop() {
 	close(l.quit)
 	l.wg.Wait()
 
+	if l.cfg.ChannelAddLimiters != nil {
+		l.cfg.ChannelAddLimiters.Release(l.chanAddLimiterID)
+	}
+
 	// Now that the htlcManager has completely exited, reset the packet
 	// courier. This allows the mailbox to revaluate any lingering Adds that
 	// were delivered but didn't make it on a commitment to be failed back
@@ -1251,7 +1295,7 @@ func (l *channelLink) processHtlcResolution(resolution invoices.HtlcResolution,
 		failure := getResolutionFailure(res, htlc.pd.Amount)
 
 		l.sendHTLCError(
-			htlc.pd, failure, htlc.obfuscator, true,
+			htlc.pd, failure, htlc.obfuscator, true, hop.Exit,
 		)
 		return nil
 
@@ -1367,6 +1411,7 @@ func (l *channelLink) handleDownstreamUpdateAdd(pkt *htlcPacket) error {
 			IncomingAmt:      pkt.incomingAmount,
 			OutgoingTimeLock: htlc.Expiry,
 			OutgoingAmt:      htlc.Amount,
+			CustomRecords:    pkt.customRecords,
 		},
 		getEventType(pkt),
 	)
@@ -2212,6 +2257,12 @@ func (l *channelLink) CheckHtlcForward(payHash [32]byte,
 		return err
 	}
 
+	// Make sure that forwarding the htlc won't use any of the htlc slots
+	// or value in flight we keep available for our own payments.
+	if err := l.checkReservedCapacity(payHash, amtToForward); err != nil {
+		return err
+	}
+
 	// Next, using the amount of the incoming HTLC, we'll calculate the
 	// expected fee this incoming HTLC must carry in order to satisfy the
 	// constraints of the outgoing link.
@@ -2283,6 +2334,67 @@ func (l *channelLink) CheckHtlcTransit(payHash [32]byte,
 	)
 }
 
+// checkReservedCapacity checks that adding a forwarded htlc of the given amount
+// leaves the configured fraction of this channel's outgoing htlc slots and max
+// value in flight available for locally initiated payments.
+func (l *channelLink) checkReservedCapacity(payHash [32]byte,
+	amt lnwire.MilliSatoshi) *LinkError {
+
+	if l.cfg.ReservedSlotFraction == 0 && l.cfg.ReservedValueFraction == 0 {
+		return nil
+	}
+
+	numHtlcs, valueInFlight, err := l.channel.OutgoingHtlcUsage()
+	if err != nil {
+		l.log.Errorf("unable to fetch outgoing htlc usage: %v", err)
+
+		return NewLinkError(&lnwire.FailTemporaryNodeFailure{})
+	}
+
+	constraints := l.channel.State().LocalChanCfg
+
+	maxSlots := uint16(
+		float64(constraints.MaxAcceptedHtlcs) *
+			(1 - l.cfg.ReservedSlotFraction),
+	)
+	if numHtlcs >= maxSlots {
+		l.log.Warnf("outgoing htlc(%x) would use a reserved htlc slot: "+
+			"num_htlcs=%v, max_forward_slots=%v", payHash[:],
+			numHtlcs, maxSlots)
+
+		failure := l.createFailureWithUpdate(
+			func(upd *lnwire.ChannelUpdate) lnwire.FailureMessage {
+				return lnwire.NewTemporaryChannelFailure(upd)
+			},
+		)
+		return NewDetailedLinkError(
+			failure, OutgoingFailureHtlcSlotsReserved,
+		)
+	}
+
+	maxValue := lnwire.MilliSatoshi(
+		float64(constraints.MaxPendingAmount) *
+			(1 - l.cfg.ReservedValueFraction),
+	)
+	if valueInFlight+amt > maxValue {
+		l.log.Warnf("outgoing htlc(%x) would use reserved value in "+
+			"flight: value_in_flight=%v, htlc_value=%v, "+
+			"max_forward_value=%v", payHash[:], valueInFlight, amt,
+			maxValue)
+
+		failure := l.createFailureWithUpdate(
+			func(upd *lnwire.ChannelUpdate) lnwire.FailureMessage {
+				return lnwire.NewTemporaryChannelFailure(upd)
+			},
+		)
+		return NewDetailedLinkError(
+			failure, OutgoingFailureHtlcValueReserved,
+		)
+	}
+
+	return nil
+}
+
 // canSendHtlc checks whether the given htlc parameters satisfy
 // the channel's amount and time lock constraints.
 func (l *channelLink) canSendHtlc(policy ForwardingPolicy,
@@ -2699,6 +2811,7 @@ func (l *channelLink) processRemoteAdds(fwdPkg *channeldb.FwdPkg,
 			failure := lnwire.NewInvalidOnionPayload(failedType, 0)
 			l.sendHTLCError(
 				pd, NewLinkError(failure), obfuscator, false,
+				lnwire.ShortChannelID{},
 			)
 
 			l.log.Errorf("unable to decode forwarding "+
@@ -2812,7 +2925,34 @@ func (l *channelLink) processRemoteAdds(fwdPkg *channeldb.FwdPkg,
 				)
 
 				l.sendHTLCError(
-					pd, NewLinkError(failure), obfuscator, false,
+					pd, NewLinkError(failure), obfuscator,
+					false, fwdInfo.NextHop,
+				)
+				continue
+			}
+
+			// Before handing a new add off to the switch, we'll
+			// make sure the peer hasn't exceeded the rate at which
+			// it may ask us to forward htlcs. An add of a processed
+			// package that passed all of the above checks but
+			// didn't make it into the forward filter must have been
+			// rate limited the first time around, so we reproduce
+			// that failure without consuming any tokens.
+			if fwdPkg.State == channeldb.FwdStateProcessed ||
+				!l.allowForwardAdd(pd) {
+
+				failure := l.createFailureWithUpdate(
+					func(upd *lnwire.ChannelUpdate) lnwire.FailureMessage {
+						return lnwire.NewTemporaryChannelFailure(
+							upd,
+						)
+					},
+				)
+
+				l.sendHTLCError(
+					pd, NewDetailedLinkError(
+						failure, IncomingFailureRateLimited,
+					), obfuscator, false, fwdInfo.NextHop,
 				)
 				continue
 			}
@@ -2872,6 +3012,26 @@ func (l *channelLink) processRemoteAdds(fwdPkg *channeldb.FwdPkg,
 	l.forwardBatch(switchPackets...)
 }
 
+// allowForwardAdd consumes a token from both the peer's and the channel's
+// add rate limiter, returning false if either of them is exhausted.
+func (l *channelLink) allowForwardAdd(pd *lnwallet.PaymentDescriptor) bool {
+	if l.cfg.PeerAddLimiter != nil && !l.cfg.PeerAddLimiter.Allow() {
+		l.log.Warnf("rate limiting htlc(%x): peer exceeded its add "+
+			"rate limit", pd.RHash[:])
+
+		return false
+	}
+
+	if l.chanAddLimiter != nil && !l.chanAddLimiter.Allow() {
+		l.log.Warnf("rate limiting htlc(%x): channel exceeded its "+
+			"add rate limit", pd.RHash[:])
+
+		return false
+	}
+
+	return true
+}
+
 // processExitHop handles an htlc for which this link is the exit hop. It
 // returns a boolean indicating whether the commitment tx needs an update.
 func (l *channelLink) processExitHop(pd *lnwallet.PaymentDescriptor,
@@ -2899,7 +3059,7 @@ func (l *channelLink) processExitHop(pd *lnwallet.PaymentDescriptor,
 		failure := NewLinkError(
 			lnwire.NewFinalIncorrectHtlcAmount(pd.Amount),
 		)
-		l.sendHTLCError(pd, failure, obfuscator, true)
+		l.sendHTLCError(pd, failure, obfuscator, true, hop.Exit)
 
 		return nil
 	}
@@ -2914,7 +3074,7 @@ func (l *channelLink) processExitHop(pd *lnwallet.PaymentDescriptor,
 		failure := NewLinkError(
 			lnwire.NewFinalIncorrectCltvExpiry(pd.Timeout),
 		)
-		l.sendHTLCError(pd, failure, obfuscator, true)
+		l.sendHTLCError(pd, failure, obfuscator, true, hop.Exit)
 
 		return nil
 	}
@@ -3023,9 +3183,11 @@ func (l *channelLink) forwardBatch(packets ...*htlcPacket) {
 }
 
 // sendHTLCError functions cancels HTLC and send cancel message back to the
-// peer from which HTLC was received.
+// peer from which HTLC was received. The outgoing channel is the channel the
+// onion asked us to forward the HTLC over, or zero if it isn't known.
 func (l *channelLink) sendHTLCError(pd *lnwallet.PaymentDescriptor,
-	failure *LinkError, e hop.ErrorEncrypter, isReceive bool) {
+	failure *LinkError, e hop.ErrorEncrypter, isReceive bool,
+	outgoingChanID lnwire.ShortChannelID) {
 
 	reason, err := e.EncryptFirstHop(failure.WireMessage())
 	if err != nil {
@@ -3046,8 +3208,9 @@ func (l *channelLink) sendHTLCError(pd *lnwallet.PaymentDescriptor,
 	})
 
 	// Notify a link failure on our incoming link. Outgoing htlc information
-	// is not available at this point, because we have not decrypted the
-	// onion, so it is excluded.
+	// is not available at this point, because the htlc never made it to
+	// the outgoing link, so only the requested outgoing channel is
+	// included.
 	var eventType HtlcEventType
 	if isReceive {
 		eventType = HtlcEventTypeReceive
@@ -3061,6 +3224,9 @@ func (l *channelLink) sendHTLCError(pd *lnwallet.PaymentDescriptor,
 				ChanID: l.ShortChanID(),
 				HtlcID: pd.HtlcIndex,
 			},
+			OutgoingCircuit: channeldb.CircuitKey{
+				ChanID: outgoingChanID,
+			},
 		},
 		HtlcInfo{
 			IncomingTimeLock: pd.Timeout,
@@ -23,6 +23,7 @@ import (
 	sphinx "github.com/lightningnetwork/lightning-onion"
 	"github.com/lightningnetwork/lnd/build"
 	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/contractcourt"
 	"github.com/lightningnetwork/lnd/htlcswitch/hodl"
 	"github.com/lightningnetwork/lnd/htlcswitch/hop"
@@ -36,6 +37,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/ticker"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -6543,3 +6545,250 @@ func assertFailureCode(t *testing.T, err error, code lnwire.FailCode) {
 			code, rtErr.WireMessage().Code())
 	}
 }
+
+// TestChannelLinkForwardRateLimit asserts that htlcs which arrive after the
+// peer or the channel exhausted its add rate limit are failed back instead of
+// being forwarded, and that the failure is recorded in the htlc event log
+// under the channel the htlc was meant to be forwarded over.
+func TestChannelLinkForwardRateLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := AddRateLimit{
+		Rate:  rate.Every(time.Hour),
+		Burst: 1,
+	}
+
+	tests := []struct {
+		name         string
+		setupLimiter func(link *channelLink)
+	}{
+		{
+			name: "peer limit",
+			setupLimiter: func(link *channelLink) {
+				limiters := NewPeerAddLimiters(
+					limit, clock.NewDefaultClock(),
+				)
+				link.cfg.PeerAddLimiter = limiters.Acquire(
+					link.cfg.Peer.PubKey(),
+				)
+			},
+		},
+		{
+			name: "channel limit",
+			setupLimiter: func(link *channelLink) {
+				limiters := NewChannelAddLimiters(
+					limit, clock.NewDefaultClock(),
+				)
+				link.chanAddLimiter = limiters.Acquire(
+					link.ShortChanID(),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			channels, cleanUp, _, err := createClusterChannels(
+				btcutil.SatoshiPerBitcoin*3,
+				btcutil.SatoshiPerBitcoin*5,
+			)
+			require.NoError(t, err)
+			defer cleanUp()
+
+			bobNotifier := NewHtlcNotifier(time.Now)
+			require.NoError(t, bobNotifier.Start())
+			defer func() {
+				require.NoError(t, bobNotifier.Stop())
+			}()
+
+			n := newThreeHopNetwork(
+				t, channels.aliceToBob, channels.bobToAlice,
+				channels.bobToCarol, channels.carolToBob,
+				testStartingHeight,
+				func(_, bob, _ *mockServer) {
+					bob.htlcSwitch.cfg.HtlcNotifier =
+						bobNotifier
+				},
+			)
+
+			// Limit the rate at which Alice may ask Bob to
+			// forward htlcs to a single htlc per hour.
+			test.setupLimiter(n.firstBobChannelLink)
+
+			require.NoError(t, n.start())
+			defer n.stop()
+
+			htlcLog := channels.bobToAlice.State().Db.HtlcEventLog()
+			recorder := NewHtlcEventRecorder(
+				&HtlcEventRecorderConfig{
+					SubscribeHtlcEvents: bobNotifier.
+						SubscribeHtlcEvents,
+					AddHtlcEvents: htlcLog.AddHtlcEvents,
+					FlushTicker: ticker.NewForce(
+						time.Hour,
+					),
+				},
+			)
+			require.NoError(t, recorder.Start())
+			defer func() {
+				require.NoError(t, recorder.Stop())
+			}()
+
+			amount := lnwire.NewMSatFromSatoshis(10000)
+			firstHop := n.firstBobChannelLink.ShortChanID()
+
+			// The first payment consumes the only token in the
+			// bucket, and should make it to Carol.
+			htlcAmt, totalTimelock, hops := generateHops(
+				amount, testStartingHeight,
+				n.firstBobChannelLink, n.carolChannelLink,
+			)
+			_, err = makePayment(
+				n.aliceServer, n.carolServer, firstHop, hops,
+				amount, htlcAmt, totalTimelock,
+			).Wait(30 * time.Second)
+			require.NoError(t, err)
+
+			// The second payment arrives while the bucket is
+			// empty, so Bob should fail it back to Alice.
+			htlcAmt, totalTimelock, hops = generateHops(
+				amount, testStartingHeight,
+				n.firstBobChannelLink, n.carolChannelLink,
+			)
+			_, err = makePayment(
+				n.aliceServer, n.carolServer, firstHop, hops,
+				amount, htlcAmt, totalTimelock,
+			).Wait(30 * time.Second)
+			require.Error(t, err)
+			assertFailureCode(
+				t, err, lnwire.CodeTemporaryChannelFailure,
+			)
+
+			// Both htlcs should be recorded under the channel to
+			// Carol, including the one Bob failed on the incoming
+			// link.
+			query := channeldb.HtlcEventQuery{
+				StartTime: time.Unix(0, 0),
+				EndTime:   time.Now().Add(time.Hour),
+				OutgoingChanIDs: []lnwire.ShortChannelID{
+					n.secondBobChannelLink.ShortChanID(),
+				},
+				NumMaxEvents: 10,
+			}
+
+			var events []channeldb.HtlcEvent
+			require.Eventually(t, func() bool {
+				err := recorder.FlushHtlcEvents()
+				if err != nil {
+					return false
+				}
+
+				slice, err := htlcLog.Query(query)
+				require.NoError(t, err)
+				events = slice.HtlcEvents
+
+				return len(events) == 2
+			}, 5*time.Second, 10*time.Millisecond)
+
+			require.Equal(
+				t, channeldb.HtlcOutcomeSettled,
+				events[0].Outcome,
+			)
+			require.Equal(
+				t, channeldb.HtlcOutcomeLinkFailed,
+				events[1].Outcome,
+			)
+			require.True(t, events[1].FailedIncoming)
+			require.Equal(t, firstHop, events[1].IncomingChanID)
+		})
+	}
+}
+
+// TestChannelLinkReservedCapacity asserts that forwarded htlcs can't use the
+// htlc slots and value in flight that are reserved for our own payments, while
+// locally initiated payments still can.
+func TestChannelLinkReservedCapacity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		slotFraction  float64
+		valueFraction float64
+	}{
+		{
+			name:         "reserved slots",
+			slotFraction: 0.999,
+		},
+		{
+			name:          "reserved value",
+			valueFraction: 0.999,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			channels, cleanUp, _, err := createClusterChannels(
+				btcutil.SatoshiPerBitcoin*3,
+				btcutil.SatoshiPerBitcoin*5,
+			)
+			require.NoError(t, err)
+			defer cleanUp()
+
+			n := newThreeHopNetwork(
+				t, channels.aliceToBob, channels.bobToAlice,
+				channels.bobToCarol, channels.carolToBob,
+				testStartingHeight,
+			)
+
+			// Reserve nearly all of the Bob->Carol channel's
+			// outgoing capacity for Bob's own payments.
+			n.secondBobChannelLink.cfg.ReservedSlotFraction =
+				test.slotFraction
+			n.secondBobChannelLink.cfg.ReservedValueFraction =
+				test.valueFraction
+
+			require.NoError(t, n.start())
+			defer n.stop()
+
+			amount := lnwire.NewMSatFromSatoshis(
+				btcutil.SatoshiPerBitcoin,
+			)
+
+			// Alice's payment would need to be forwarded over the
+			// Bob->Carol channel, so it should be rejected.
+			htlcAmt, totalTimelock, hops := generateHops(
+				amount, testStartingHeight,
+				n.firstBobChannelLink, n.carolChannelLink,
+			)
+			_, err = makePayment(
+				n.aliceServer, n.carolServer,
+				n.firstBobChannelLink.ShortChanID(), hops,
+				amount, htlcAmt, totalTimelock,
+			).Wait(30 * time.Second)
+			require.Error(t, err)
+			assertFailureCode(
+				t, err, lnwire.CodeTemporaryChannelFailure,
+			)
+
+			// Bob's own payment to Carol is allowed to use the
+			// reserved capacity.
+			htlcAmt, totalTimelock, hops = generateHops(
+				amount, testStartingHeight, n.carolChannelLink,
+			)
+			_, err = makePayment(
+				n.bobServer, n.carolServer,
+				n.secondBobChannelLink.ShortChanID(), hops,
+				amount, htlcAmt, totalTimelock,
+			).Wait(30 * time.Second)
+			require.NoError(t, err)
+		})
+	}
+}
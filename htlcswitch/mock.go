@@ -29,7 +29,10 @@ import (
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
+	"github.com/lightningnetwork/lnd/routing/route"
 	"github.com/lightningnetwork/lnd/ticker"
+	"github.com/lightningnetwork/lnd/tlv"
 )
 
 type mockPreimageCache struct {
@@ -315,11 +318,98 @@ func (r *mockHopIterator) EncodeNextHop(w io.Writer) error {
 		if err := encodeFwdInfo(w, &fwdInfo); err != nil {
 			return err
 		}
+
+		err := encodeCustomRecords(w, hop.CustomRecords())
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// encodeCustomRecords writes the custom records of a hop payload, so that they
+// are delivered to the hop by the mock onion.
+func encodeCustomRecords(w io.Writer, records record.CustomSet) error {
+	var b bytes.Buffer
+	tlvStream, err := tlv.NewStream(tlv.MapToRecords(records)...)
+	if err != nil {
+		return err
+	}
+	if err := tlvStream.Encode(&b); err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, uint16(b.Len()))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// decodeCustomRecords reads the custom records of a hop payload written by
+// encodeCustomRecords.
+func decodeCustomRecords(r io.Reader) (record.CustomSet, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	tlvStream, err := tlv.NewStream()
+	if err != nil {
+		return nil, err
+	}
+	parsedTypes, err := tlvStream.DecodeWithParsedTypes(
+		bytes.NewReader(b),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return hop.NewCustomRecords(parsedTypes), nil
+}
+
+// newMockHopPayload creates the payload of a hop with the given forwarding
+// info. If custom records are given, the payload is created from a tlv
+// payload, otherwise from a legacy payload.
+func newMockHopPayload(f hop.ForwardingInfo,
+	customRecords record.CustomSet) (*hop.Payload, error) {
+
+	if len(customRecords) == 0 {
+		var nextHopBytes [8]byte
+		binary.BigEndian.PutUint64(
+			nextHopBytes[:], f.NextHop.ToUint64(),
+		)
+
+		return hop.NewLegacyPayload(&sphinx.HopData{
+			Realm:         [1]byte{}, // hop.BitcoinNetwork
+			NextAddress:   nextHopBytes,
+			ForwardAmount: uint64(f.AmountToForward),
+			OutgoingCltv:  f.OutgoingCTLV,
+		}), nil
+	}
+
+	routeHop := &route.Hop{
+		AmtToForward:     f.AmountToForward,
+		OutgoingTimeLock: f.OutgoingCTLV,
+		CustomRecords:    customRecords,
+	}
+
+	var b bytes.Buffer
+	if err := routeHop.PackHopPayload(&b, f.NextHop.ToUint64()); err != nil {
+		return nil, err
+	}
+
+	return hop.NewPayloadFromReader(&b)
+}
+
 func encodeFwdInfo(w io.Writer, f *hop.ForwardingInfo) error {
 	if _, err := w.Write([]byte{byte(f.Network)}); err != nil {
 		return err
@@ -450,15 +540,15 @@ func (p *mockIteratorDecoder) DecodeHopIterator(r io.Reader, rHash []byte,
 			return nil, lnwire.CodeTemporaryChannelFailure
 		}
 
-		var nextHopBytes [8]byte
-		binary.BigEndian.PutUint64(nextHopBytes[:], f.NextHop.ToUint64())
+		customRecords, err := decodeCustomRecords(r)
+		if err != nil {
+			return nil, lnwire.CodeTemporaryChannelFailure
+		}
 
-		hops[i] = hop.NewLegacyPayload(&sphinx.HopData{
-			Realm:         [1]byte{}, // hop.BitcoinNetwork
-			NextAddress:   nextHopBytes,
-			ForwardAmount: uint64(f.AmountToForward),
-			OutgoingCltv:  f.OutgoingCTLV,
-		})
+		hops[i], err = newMockHopPayload(f, customRecords)
+		if err != nil {
+			return nil, lnwire.CodeInvalidOnionPayload
+		}
 	}
 
 	return newMockHopIterator(hops...), lnwire.CodeNone
@@ -0,0 +1,231 @@
+package htlcswitch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"golang.org/x/time/rate"
+)
+
+// AddRateLimit describes a token bucket that bounds the rate at which a remote
+// party may add HTLCs that we're asked to forward. A zero Rate disables the
+// limit.
+type AddRateLimit struct {
+	// Rate is the number of tokens that are added to the bucket every
+	// second. Every forwarded HTLC consumes a single token.
+	Rate rate.Limit
+
+	// Burst is the maximum number of tokens the bucket can hold, and thus
+	// the maximum number of HTLCs that can be added in quick succession.
+	Burst int
+}
+
+// Enabled returns true if the rate limit should be enforced.
+func (a AddRateLimit) Enabled() bool {
+	return a.Rate > 0
+}
+
+// refillTime returns how long it takes an empty bucket to fill up completely.
+func (a AddRateLimit) refillTime() time.Duration {
+	if a.Rate == rate.Inf {
+		return 0
+	}
+
+	return time.Duration(float64(a.Burst) / float64(a.Rate) *
+		float64(time.Second))
+}
+
+// AddLimiter is a token bucket rate limiter that bounds how quickly a remote
+// party may ask us to forward htlcs.
+type AddLimiter struct {
+	limit   AddRateLimit
+	clock   clock.Clock
+	limiter *rate.Limiter
+
+	// refs is the number of users of the limiter. It is guarded by the
+	// mutex of the addLimiterSet that owns the limiter.
+	refs int
+
+	mu sync.Mutex
+
+	// lastAllow is the last time a token was taken from the bucket.
+	lastAllow time.Time
+}
+
+// newAddLimiter creates a new limiter with a full bucket.
+func newAddLimiter(limit AddRateLimit, clock clock.Clock) *AddLimiter {
+	return &AddLimiter{
+		limit:   limit,
+		clock:   clock,
+		limiter: rate.NewLimiter(limit.Rate, limit.Burst),
+	}
+}
+
+// Allow takes a token from the bucket, returning false if the bucket is empty.
+func (a *AddLimiter) Allow() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.clock.Now()
+	if !a.limiter.AllowN(now, 1) {
+		return false
+	}
+	a.lastAllow = now
+
+	return true
+}
+
+// isFull returns true if the bucket has been refilled completely since a
+// token was last taken from it. A full bucket can be discarded, as a new
+// limiter starts out in the exact same state.
+func (a *AddLimiter) isFull(now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return now.Sub(a.lastAllow) >= a.limit.refillTime()
+}
+
+// addLimiterSet hands out a limiter per key. Limiters are kept around after
+// their last user released them until their bucket is full again, so that a
+// remote party can't replenish its allowance by reconnecting.
+type addLimiterSet struct {
+	limit AddRateLimit
+	clock clock.Clock
+
+	mu       sync.Mutex
+	limiters map[interface{}]*AddLimiter
+}
+
+// newAddLimiterSet creates a new set of limiters that enforce the passed
+// limit.
+func newAddLimiterSet(limit AddRateLimit, clock clock.Clock) addLimiterSet {
+	return addLimiterSet{
+		limit:    limit,
+		clock:    clock,
+		limiters: make(map[interface{}]*AddLimiter),
+	}
+}
+
+// acquire returns the limiter for the key, creating it if it doesn't exist
+// yet. Every call must be paired with a call to release once the limiter is
+// no longer used. If the limit is disabled, nil is returned.
+func (s *addLimiterSet) acquire(key interface{}) *AddLimiter {
+	if !s.limit.Enabled() {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneIdle()
+
+	limiter, ok := s.limiters[key]
+	if !ok {
+		limiter = newAddLimiter(s.limit, s.clock)
+		s.limiters[key] = limiter
+	}
+	limiter.refs++
+
+	return limiter
+}
+
+// release signals that a user of the limiter for the key is done with it.
+func (s *addLimiterSet) release(key interface{}) {
+	if !s.limit.Enabled() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limiter, ok := s.limiters[key]; ok && limiter.refs > 0 {
+		limiter.refs--
+	}
+
+	s.pruneIdle()
+}
+
+// pruneIdle removes all limiters that have no users left and a full bucket.
+//
+// NOTE: The mutex must be held when calling this method.
+func (s *addLimiterSet) pruneIdle() {
+	now := s.clock.Now()
+	for key, limiter := range s.limiters {
+		if limiter.refs == 0 && limiter.isFull(now) {
+			delete(s.limiters, key)
+		}
+	}
+}
+
+// numLimiters returns the number of limiters that are currently tracked.
+func (s *addLimiterSet) numLimiters() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.limiters)
+}
+
+// PeerAddLimiters hands out the token bucket rate limiters that are shared by
+// all links we have with a particular peer. Limiters outlive the peer
+// connection until their bucket is full again, so a peer can't replenish its
+// allowance by reconnecting.
+type PeerAddLimiters struct {
+	set addLimiterSet
+}
+
+// NewPeerAddLimiters creates a new set of per-peer rate limiters, each of
+// which enforces the passed limit.
+func NewPeerAddLimiters(limit AddRateLimit,
+	clock clock.Clock) *PeerAddLimiters {
+
+	return &PeerAddLimiters{
+		set: newAddLimiterSet(limit, clock),
+	}
+}
+
+// Acquire returns the rate limiter for the peer identified by the passed
+// serialized public key, creating it if needed. Each call must be paired with
+// a call to Release once the peer disconnects. If the per-peer limit is
+// disabled, nil is returned.
+func (p *PeerAddLimiters) Acquire(pubKey [33]byte) *AddLimiter {
+	return p.set.acquire(pubKey)
+}
+
+// Release signals that a connection to the peer that acquired its limiter is
+// gone.
+func (p *PeerAddLimiters) Release(pubKey [33]byte) {
+	p.set.release(pubKey)
+}
+
+// ChannelAddLimiters hands out the token bucket rate limiters of our channels.
+// Limiters are keyed by short channel ID and outlive the link until their
+// bucket is full again, so a peer can't replenish the allowance of a channel
+// by reconnecting.
+type ChannelAddLimiters struct {
+	set addLimiterSet
+}
+
+// NewChannelAddLimiters creates a new set of per-channel rate limiters, each
+// of which enforces the passed limit.
+func NewChannelAddLimiters(limit AddRateLimit,
+	clock clock.Clock) *ChannelAddLimiters {
+
+	return &ChannelAddLimiters{
+		set: newAddLimiterSet(limit, clock),
+	}
+}
+
+// Acquire returns the rate limiter for the channel, creating it if needed.
+// Each call must be paired with a call to Release once the link of the channel
+// is stopped. If the per-channel limit is disabled, nil is returned.
+func (c *ChannelAddLimiters) Acquire(scid lnwire.ShortChannelID) *AddLimiter {
+	return c.set.acquire(scid)
+}
+
+// Release signals that the link that acquired the limiter of the channel is
+// stopped.
+func (c *ChannelAddLimiters) Release(scid lnwire.ShortChannelID) {
+	c.set.release(scid)
+}
@@ -0,0 +1,139 @@
+package htlcswitch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// TestPeerAddLimiters asserts that all links with the same peer share a single
+// rate limiter, and that no limiter is handed out if the limit is disabled.
+func TestPeerAddLimiters(t *testing.T) {
+	t.Parallel()
+
+	var peerA, peerB [33]byte
+	peerA[0] = 0x02
+	peerB[0] = 0x03
+
+	// With the limit disabled, no limiters should be returned.
+	disabled := NewPeerAddLimiters(AddRateLimit{}, clock.NewDefaultClock())
+	require.Nil(t, disabled.Acquire(peerA))
+	disabled.Release(peerA)
+
+	limiters := NewPeerAddLimiters(AddRateLimit{
+		Rate:  rate.Every(time.Hour),
+		Burst: 2,
+	}, clock.NewDefaultClock())
+
+	// Requesting the limiter for the same peer twice should return the
+	// same limiter, so that its links draw from the same bucket.
+	limiterA := limiters.Acquire(peerA)
+	require.NotNil(t, limiterA)
+	require.Same(t, limiterA, limiters.Acquire(peerA))
+
+	require.True(t, limiterA.Allow())
+	require.True(t, limiterA.Allow())
+	require.False(t, limiterA.Allow())
+
+	// Another peer has its own bucket that is unaffected by the first
+	// peer's usage.
+	limiterB := limiters.Acquire(peerB)
+	require.NotSame(t, limiterA, limiterB)
+	require.True(t, limiterB.Allow())
+}
+
+// TestAddLimitersPrune asserts that limiters are only discarded once they
+// have no users left and their bucket is full again, so that reconnecting
+// doesn't replenish a depleted bucket.
+func TestAddLimitersPrune(t *testing.T) {
+	t.Parallel()
+
+	testClock := clock.NewTestClock(time.Unix(1, 0))
+	limiters := NewPeerAddLimiters(AddRateLimit{
+		Rate:  rate.Every(time.Minute),
+		Burst: 2,
+	}, testClock)
+
+	var peerA, peerB [33]byte
+	peerA[0] = 0x02
+	peerB[0] = 0x03
+
+	// A peer that connects and disconnects without using its limiter
+	// leaves nothing behind.
+	limiters.Acquire(peerB)
+	limiters.Release(peerB)
+	require.Equal(t, 0, limiters.set.numLimiters())
+
+	// Deplete the bucket of the first peer, and let it disconnect.
+	limiter := limiters.Acquire(peerA)
+	require.True(t, limiter.Allow())
+	require.True(t, limiter.Allow())
+	limiters.Release(peerA)
+	require.Equal(t, 1, limiters.set.numLimiters())
+
+	// When the peer reconnects, it gets its depleted bucket back.
+	require.Same(t, limiter, limiters.Acquire(peerA))
+	require.False(t, limiter.Allow())
+
+	// A token is added after a minute, but the bucket is only full again
+	// after two minutes.
+	testClock.SetTime(testClock.Now().Add(time.Minute))
+	require.True(t, limiter.Allow())
+	limiters.Release(peerA)
+
+	// Idle limiters are pruned whenever the set is used, which releasing
+	// an unused key triggers as well.
+	testClock.SetTime(testClock.Now().Add(time.Minute))
+	limiters.Release(peerB)
+	require.Equal(t, 1, limiters.set.numLimiters())
+
+	// Once the bucket is full, the limiter is discarded. A new limiter
+	// starts out with a full bucket as well.
+	testClock.SetTime(testClock.Now().Add(time.Minute))
+	limiters.Release(peerB)
+	require.Equal(t, 0, limiters.set.numLimiters())
+
+	limiter = limiters.Acquire(peerA)
+	require.True(t, limiter.Allow())
+	require.True(t, limiter.Allow())
+	require.False(t, limiter.Allow())
+}
+
+// TestChannelAddLimiters asserts that the limiter of a channel is handed to
+// every link of the channel, so that restarting the link doesn't replenish
+// its bucket.
+func TestChannelAddLimiters(t *testing.T) {
+	t.Parallel()
+
+	testClock := clock.NewTestClock(time.Unix(1, 0))
+	limiters := NewChannelAddLimiters(AddRateLimit{
+		Rate:  rate.Every(time.Hour),
+		Burst: 1,
+	}, testClock)
+
+	chanA := lnwire.NewShortChanIDFromInt(1)
+	chanB := lnwire.NewShortChanIDFromInt(2)
+
+	limiter := limiters.Acquire(chanA)
+	require.True(t, limiter.Allow())
+	limiters.Release(chanA)
+
+	// The restarted link of the channel gets the depleted bucket, while
+	// another channel has its own.
+	require.Same(t, limiter, limiters.Acquire(chanA))
+	require.False(t, limiter.Allow())
+	require.True(t, limiters.Acquire(chanB).Allow())
+
+	limiters.Release(chanA)
+	limiters.Release(chanB)
+	require.Equal(t, 2, limiters.set.numLimiters())
+
+	// After an hour, both buckets are full and discarded.
+	testClock.SetTime(testClock.Now().Add(time.Hour))
+	limiters.Release(chanA)
+	require.Equal(t, 0, limiters.set.numLimiters())
+}
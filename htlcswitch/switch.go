@@ -556,6 +556,45 @@ func (s *Switch) IsForwardedHTLC(chanID lnwire.ShortChannelID,
 	return circuit != nil && circuit.Incoming.ChanID != hop.Source
 }
 
+// FindIncomingHTLCExpiry returns the expiry height of the incoming htlc that
+// was forwarded as the given outgoing htlc. The boolean is false if the htlc
+// wasn't forwarded, or if the incoming htlc can no longer be found.
+func (s *Switch) FindIncomingHTLCExpiry(chanID lnwire.ShortChannelID,
+	htlcIndex uint64) (uint32, bool) {
+
+	circuit := s.circuits.LookupOpenCircuit(channeldb.CircuitKey{
+		ChanID: chanID,
+		HtlcID: htlcIndex,
+	})
+	if circuit == nil || circuit.Incoming.ChanID == hop.Source {
+		return 0, false
+	}
+
+	// The incoming channel may be waiting to be closed itself, so we also
+	// search the channels that aren't fully open anymore.
+	channels, err := s.cfg.DB.FetchAllChannels()
+	if err != nil {
+		log.Errorf("Unable to fetch channels: %v", err)
+		return 0, false
+	}
+
+	for _, channel := range channels {
+		if channel.ShortChanID() != circuit.Incoming.ChanID {
+			continue
+		}
+
+		for _, htlc := range channel.LocalCommitment.Htlcs {
+			if htlc.Incoming &&
+				htlc.HtlcIndex == circuit.Incoming.HtlcID {
+
+				return htlc.RefundTimeout, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
 // ForwardPackets adds a list of packets to the switch for processing. Fails
 // and settles are added on a first past, simultaneously constructing circuits
 // for any adds. After persisting the circuits, another pass of the adds is
@@ -1267,6 +1306,7 @@ func (s *Switch) failAddPacket(packet *htlcPacket, failure *LinkError) error {
 		amount:          packet.amount,
 		incomingTimeout: packet.incomingTimeout,
 		outgoingTimeout: packet.outgoingTimeout,
+		customRecords:   packet.customRecords,
 		circuit:         packet.circuit,
 		linkFailure:     failure,
 		htlc: &lnwire.UpdateFailHTLC{
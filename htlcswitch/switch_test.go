@@ -16,6 +16,7 @@ import (
 	"github.com/lightningnetwork/lnd/htlcswitch/hop"
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
 	"github.com/lightningnetwork/lnd/ticker"
 )
 
@@ -2778,6 +2779,10 @@ func TestHtlcNotifier(t *testing.T) {
 		// network's servers.
 		options []serverOption
 
+		// customRecords are the custom records that Alice places in
+		// Bob's hop payload.
+		customRecords record.CustomSet
+
 		// expectedEvents is a function which returns an expected set
 		// of events for the test.
 		expectedEvents htlcNotifierEvents
@@ -2799,11 +2804,44 @@ func TestHtlcNotifier(t *testing.T) {
 				[]interface{}, []interface{}) {
 
 				return getThreeHopEvents(
-					channels, htlcID, ts, htlc, hops, nil, preimage,
+					channels, htlcID, ts, htlc, hops, nil,
+					make(record.CustomSet), preimage,
 				)
 			},
 			iterations: 2,
 		},
+		{
+			name:    "three hop payment with custom records",
+			options: nil,
+			customRecords: record.CustomSet{
+				record.CustomTypeStart:     []byte{1, 2, 3},
+				record.CustomTypeStart + 1: []byte("bob"),
+			},
+			expectedEvents: func(channels *clusterChannels,
+				htlcID uint64, ts time.Time,
+				htlc *lnwire.UpdateAddHTLC,
+				hops []*hop.Payload,
+				preimage *lntypes.Preimage) ([]interface{},
+				[]interface{}, []interface{}) {
+
+				// The custom records that Alice placed in
+				// Bob's hop payload should be reported in
+				// Bob's forwarding event.
+				return getThreeHopEvents(
+					channels, htlcID, ts, htlc, hops, nil,
+					record.CustomSet{
+						record.CustomTypeStart: []byte{
+							1, 2, 3,
+						},
+						record.CustomTypeStart + 1: []byte(
+							"bob",
+						),
+					},
+					preimage,
+				)
+			},
+			iterations: 1,
+		},
 		{
 			name: "failed at forwarding link",
 			// Set a functional option which disables bob as a
@@ -2824,7 +2862,7 @@ func TestHtlcNotifier(t *testing.T) {
 						msg:           &lnwire.FailChannelDisabled{},
 						FailureDetail: OutgoingFailureForwardsDisabled,
 					},
-					preimage,
+					make(record.CustomSet), preimage,
 				)
 			},
 			iterations: 1,
@@ -2837,7 +2875,7 @@ func TestHtlcNotifier(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			testHtcNotifier(
 				t, test.options, test.iterations,
-				test.expectedEvents,
+				test.customRecords, test.expectedEvents,
 			)
 		})
 	}
@@ -2845,7 +2883,7 @@ func TestHtlcNotifier(t *testing.T) {
 
 // testHtcNotifier runs a htlc notifier test.
 func testHtcNotifier(t *testing.T, testOpts []serverOption, iterations int,
-	getEvents htlcNotifierEvents) {
+	customRecords record.CustomSet, getEvents htlcNotifierEvents) {
 
 	t.Parallel()
 
@@ -2948,7 +2986,9 @@ func testHtcNotifier(t *testing.T, testOpts []serverOption, iterations int,
 		// We'll start off by making a payment from
 		// Alice -> Bob -> Carol. The preimage, generated
 		// by Carol's Invoice is expected in the Settle events
-		htlc, hops, preimage := n.sendThreeHopPayment(t)
+		htlc, hops, preimage := n.sendThreeHopPayment(
+			t, customRecords,
+		)
 
 		alice, bob, carol := getEvents(
 			channels, uint64(i), now, htlc, hops, preimage,
@@ -2984,14 +3024,26 @@ func checkHtlcEvents(t *testing.T, events <-chan interface{},
 
 // sendThreeHopPayment is a helper function which sends a payment over
 // Alice -> Bob -> Carol in a three hop network and returns Alice's first htlc
-// and the remainder of the hops.
-func (n *threeHopNetwork) sendThreeHopPayment(t *testing.T) (*lnwire.UpdateAddHTLC,
+// and the remainder of the hops. If custom records are given, they are placed
+// in Bob's hop payload.
+func (n *threeHopNetwork) sendThreeHopPayment(t *testing.T,
+	customRecords record.CustomSet) (*lnwire.UpdateAddHTLC,
 	[]*hop.Payload, *lntypes.Preimage) {
 
 	amount := lnwire.NewMSatFromSatoshis(btcutil.SatoshiPerBitcoin)
 
 	htlcAmt, totalTimelock, hops := generateHops(amount, testStartingHeight,
 		n.firstBobChannelLink, n.carolChannelLink)
+
+	if len(customRecords) > 0 {
+		bobHop, err := newMockHopPayload(
+			hops[0].ForwardingInfo(), customRecords,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hops[0] = bobHop
+	}
 	blob, err := generateRoute(hops...)
 	if err != nil {
 		t.Fatal(err)
@@ -3019,10 +3071,11 @@ func (n *threeHopNetwork) sendThreeHopPayment(t *testing.T) (*lnwire.UpdateAddHT
 
 // getThreeHopEvents gets the set of htlc events that we expect for a payment
 // from Alice -> Bob -> Carol. If a non-nil link error is provided, the set
-// of events will fail on Bob's outgoing link.
+// of events will fail on Bob's outgoing link. The custom records are the ones
+// we expect Bob to report for the forward.
 func getThreeHopEvents(channels *clusterChannels, htlcID uint64,
 	ts time.Time, htlc *lnwire.UpdateAddHTLC, hops []*hop.Payload,
-	linkError *LinkError,
+	linkError *LinkError, customRecords record.CustomSet,
 	preimage *lntypes.Preimage) ([]interface{}, []interface{}, []interface{}) {
 
 	aliceKey := HtlcKey{
@@ -3063,6 +3116,7 @@ func getThreeHopEvents(channels *clusterChannels, htlcID uint64,
 		IncomingAmt:      htlc.Amount,
 		OutgoingTimeLock: hops[1].FwdInfo.OutgoingCTLV,
 		OutgoingAmt:      hops[1].FwdInfo.AmountToForward,
+		CustomRecords:    customRecords,
 	}
 
 	// If we expect the payment to fail, we add failures for alice and
@@ -18,4 +18,8 @@ type Payload interface {
 	// CustomRecords returns the custom tlv type records that were parsed
 	// from the payload.
 	CustomRecords() record.CustomSet
+
+	// Metadata returns the payment metadata that was parsed from the
+	// payload.
+	Metadata() []byte
 }
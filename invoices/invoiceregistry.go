@@ -33,6 +33,14 @@ const (
 	// DefaultHtlcHoldDuration defines the default for how long mpp htlcs
 	// are held while waiting for the other set members to arrive.
 	DefaultHtlcHoldDuration = 120 * time.Second
+
+	// invoiceArchiveInterval is the interval at which settled invoices are
+	// moved to the invoice archive if archiving is enabled.
+	invoiceArchiveInterval = 24 * time.Hour
+
+	// invoiceArchiveBatchSize is the maximum number of invoices that are
+	// archived in a single database transaction.
+	invoiceArchiveBatchSize = 1000
 )
 
 // RegistryConfig contains the configuration parameters for invoice registry.
@@ -72,6 +80,37 @@ type RegistryConfig struct {
 	// KeysendHoldTime indicates for how long we want to accept and hold
 	// spontaneous keysend payments.
 	KeysendHoldTime time.Duration
+
+	// ArchiveSettledInvoicesAfter if non-zero, moves invoices that were
+	// settled longer ago than this duration to the invoice archive on
+	// startup and periodically afterwards.
+	ArchiveSettledInvoicesAfter time.Duration
+
+	// UnresolvedHtlcHashes returns the payment hashes of the incoming
+	// htlcs that are still unresolved on one of our channels. Settled
+	// invoices paid by such htlcs are not archived, as the htlcs may still
+	// need to be resolved against the invoice. If nil, no htlcs are
+	// considered unresolved.
+	UnresolvedHtlcHashes func() (map[lntypes.Hash]struct{}, error)
+
+	// AcceptStatelessInvoices indicates whether we want to accept
+	// payments to stateless invoices, which are only inserted into the
+	// database once they are paid.
+	AcceptStatelessInvoices bool
+
+	// StatelessInvoiceSecret is the secret from which the preimages of
+	// stateless invoices are derived.
+	StatelessInvoiceSecret [32]byte
+
+	// SpontaneousPolicy is the initial policy that spontaneous keysend
+	// and AMP payments must adhere to. It can be replaced at runtime with
+	// SetSpontaneousPolicy.
+	SpontaneousPolicy SpontaneousPolicy
+
+	// IncomingPeer returns the public key of the peer that we have the
+	// given channel with. It identifies the source route of spontaneous
+	// payments for their rate limit.
+	IncomingPeer func(chanID lnwire.ShortChannelID) ([33]byte, error)
 }
 
 // htlcReleaseEvent describes an htlc auto-release event. It is used to release
@@ -132,6 +171,18 @@ type InvoiceRegistry struct {
 
 	expiryWatcher *InvoiceExpiryWatcher
 
+	// statelessAcceptor is the currently registered acceptor that
+	// approves payments to stateless invoices, if any.
+	statelessAcceptor *StatelessInvoiceAcceptor
+
+	// pendingStateless holds the payments to stateless invoices that have
+	// been fully accepted and await approval.
+	pendingStateless map[lntypes.Hash]*StatelessInvoiceRequest
+
+	// spontaneousPolicy enforces the policy of spontaneous keysend and
+	// AMP payments.
+	spontaneousPolicy *spontaneousPolicyEnforcer
+
 	wg   sync.WaitGroup
 	quit chan struct{}
 }
@@ -155,6 +206,8 @@ func NewRegistry(cdb *channeldb.DB, expiryWatcher *InvoiceExpiryWatcher,
 		cfg:                       cfg,
 		htlcAutoReleaseChan:       make(chan *htlcReleaseEvent),
 		expiryWatcher:             expiryWatcher,
+		pendingStateless:          make(map[lntypes.Hash]*StatelessInvoiceRequest),
+		spontaneousPolicy:         newSpontaneousPolicyEnforcer(cfg.SpontaneousPolicy, cfg.Clock),
 		quit:                      make(chan struct{}),
 	}
 }
@@ -166,6 +219,7 @@ func (i *InvoiceRegistry) scanInvoicesOnStart() error {
 	var (
 		pending   []invoiceExpiry
 		removable []channeldb.InvoiceDeleteRef
+		stateless []*StatelessInvoiceRequest
 	)
 
 	reset := func() {
@@ -176,6 +230,7 @@ func (i *InvoiceRegistry) scanInvoicesOnStart() error {
 		// to retry for serializability).
 		pending = nil
 		removable = make([]channeldb.InvoiceDeleteRef, 0)
+		stateless = nil
 	}
 
 	scanFunc := func(
@@ -186,6 +241,14 @@ func (i *InvoiceRegistry) scanInvoicesOnStart() error {
 			if expiryRef != nil {
 				pending = append(pending, expiryRef)
 			}
+
+			// Payments to stateless invoices that were awaiting
+			// approval before the restart still need to be
+			// approved.
+			req := i.statelessRequest(paymentHash, invoice)
+			if req != nil {
+				stateless = append(stateless, req)
+			}
 		} else if i.cfg.GcCanceledInvoicesOnStartup &&
 			invoice.State == channeldb.ContractCanceled {
 
@@ -217,6 +280,12 @@ func (i *InvoiceRegistry) scanInvoicesOnStart() error {
 		len(pending))
 	i.expiryWatcher.AddInvoices(pending...)
 
+	i.Lock()
+	for _, req := range stateless {
+		i.pendingStateless[req.PaymentHash] = req
+	}
+	i.Unlock()
+
 	if len(removable) > 0 {
 		log.Infof("Attempting to delete %v canceled invoices",
 			len(removable))
@@ -244,6 +313,18 @@ func (i *InvoiceRegistry) Start() error {
 	i.wg.Add(1)
 	go i.invoiceEventLoop()
 
+	// Archive old settled invoices before scanning, so that they don't
+	// need to be scanned anymore.
+	if i.cfg.ArchiveSettledInvoicesAfter > 0 {
+		if err := i.archiveSettledInvoices(); err != nil {
+			_ = i.Stop()
+			return err
+		}
+
+		i.wg.Add(1)
+		go i.invoiceArchiver()
+	}
+
 	// Now scan all pending and removable invoices to the expiry watcher or
 	// delete them.
 	err = i.scanInvoicesOnStart()
@@ -255,6 +336,72 @@ func (i *InvoiceRegistry) Start() error {
 	return nil
 }
 
+// archiveSettledInvoices moves all invoices that were settled longer ago than
+// the configured retention period to the invoice archive, in batches.
+func (i *InvoiceRegistry) archiveSettledInvoices() error {
+	settledBefore := i.cfg.Clock.Now().Add(
+		-i.cfg.ArchiveSettledInvoicesAfter,
+	)
+
+	var unresolved map[lntypes.Hash]struct{}
+	if i.cfg.UnresolvedHtlcHashes != nil {
+		var err error
+		unresolved, err = i.cfg.UnresolvedHtlcHashes()
+		if err != nil {
+			return fmt.Errorf("unable to fetch unresolved htlcs: "+
+				"%v", err)
+		}
+	}
+
+	var total uint64
+	for {
+		numArchived, err := i.cdb.ArchiveSettledInvoices(
+			settledBefore, invoiceArchiveBatchSize, unresolved,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to archive invoices: %v", err)
+		}
+
+		total += numArchived
+		if numArchived < invoiceArchiveBatchSize {
+			break
+		}
+
+		select {
+		case <-i.quit:
+			return ErrShuttingDown
+		default:
+		}
+	}
+
+	if total > 0 {
+		log.Infof("Archived %v invoices settled before %v", total,
+			settledBefore)
+	}
+
+	return nil
+}
+
+// invoiceArchiver periodically moves old settled invoices to the invoice
+// archive.
+//
+// NOTE: This MUST be run as a goroutine.
+func (i *InvoiceRegistry) invoiceArchiver() {
+	defer i.wg.Done()
+
+	for {
+		select {
+		case <-i.cfg.Clock.TickAfter(invoiceArchiveInterval):
+			if err := i.archiveSettledInvoices(); err != nil {
+				log.Errorf("Invoice archiving failed: %v", err)
+			}
+
+		case <-i.quit:
+			return
+		}
+	}
+}
+
 // Stop signals the registry for a graceful shutdown.
 func (i *InvoiceRegistry) Stop() error {
 	i.expiryWatcher.Stop()
@@ -371,9 +518,7 @@ func (i *InvoiceRegistry) invoiceEventLoop() {
 		// The htlc at the top of the heap needs to be auto-released.
 		case <-nextReleaseTick:
 			event := autoReleaseHeap.Pop().(*htlcReleaseEvent)
-			err := i.cancelSingleHtlc(
-				event.invoiceRef, event.key, ResultMppTimeout,
-			)
+			err := i.releaseHtlc(event.invoiceRef, event.key)
 			if err != nil {
 				log.Errorf("HTLC timer: %v", err)
 			}
@@ -601,7 +746,21 @@ func (i *InvoiceRegistry) LookupInvoice(rHash lntypes.Hash) (channeldb.Invoice,
 	// We'll check the database to see if there's an existing matching
 	// invoice.
 	ref := channeldb.InvoiceRefByHash(rHash)
-	return i.cdb.LookupInvoice(ref)
+	invoice, err := i.cdb.LookupInvoice(ref)
+	if err != channeldb.ErrInvoiceNotFound &&
+		err != channeldb.ErrNoInvoicesCreated {
+
+		return invoice, err
+	}
+
+	// If the invoice isn't among the live invoices, it may have been
+	// archived already.
+	archived, archiveErr := i.cdb.LookupArchivedInvoice(rHash)
+	if archiveErr == channeldb.ErrInvoiceNotFound {
+		return invoice, err
+	}
+
+	return archived, archiveErr
 }
 
 // startHtlcTimer starts a new timer via the invoice registry main loop that
@@ -637,8 +796,12 @@ func (i *InvoiceRegistry) cancelSingleHtlc(invoiceRef channeldb.InvoiceRef,
 	updateInvoice := func(invoice *channeldb.Invoice) (
 		*channeldb.InvoiceUpdateDesc, error) {
 
-		// Only allow individual htlc cancelation on open invoices.
-		if invoice.State != channeldb.ContractOpen {
+		// Only allow individual htlc cancelation on open invoices, or on
+		// reusable invoices that were settled before.
+		reusableSettled := invoice.Terms.MaxAmtPaid > 0 &&
+			invoice.State == channeldb.ContractSettled
+		if invoice.State != channeldb.ContractOpen && !reusableSettled {
+
 			log.Debugf("cancelSingleHtlc: invoice %v no longer "+
 				"open", invoiceRef)
 
@@ -714,6 +877,89 @@ func (i *InvoiceRegistry) cancelSingleHtlc(invoiceRef channeldb.InvoiceRef,
 	return nil
 }
 
+// releaseHtlc releases an mpp htlc for which the complete set didn't arrive
+// in time. If the invoice accepts partial payments, the htlcs of the set that
+// did arrive are settled, or accepted in case of a hold invoice. Otherwise,
+// the htlc is canceled.
+func (i *InvoiceRegistry) releaseHtlc(invoiceRef channeldb.InvoiceRef,
+	key channeldb.CircuitKey) error {
+
+	accepted, err := i.acceptPartialSet(invoiceRef, key)
+	if err != nil || accepted {
+		return err
+	}
+
+	return i.cancelSingleHtlc(invoiceRef, key, ResultMppTimeout)
+}
+
+// acceptPartialSet settles or accepts the incomplete mpp set of the given
+// htlc if the invoice accepts partial payments. It returns true if the set
+// was accepted.
+func (i *InvoiceRegistry) acceptPartialSet(invoiceRef channeldb.InvoiceRef,
+	key channeldb.CircuitKey) (bool, error) {
+
+	i.Lock()
+	defer i.Unlock()
+
+	var updated bool
+	invoice, err := i.cdb.UpdateInvoice(invoiceRef,
+		func(invoice *channeldb.Invoice) (
+			*channeldb.InvoiceUpdateDesc, error) {
+
+			updateDesc := updatePartialSet(invoice, key)
+			updated = updateDesc != nil
+
+			return updateDesc, nil
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	if !updated {
+		return false, nil
+	}
+
+	log.Debugf("Invoice%v: accepted partial payment after htlc %v timed "+
+		"out, state=%v", invoiceRef, key, invoice.State)
+
+	// Mpp htlcs always reference the invoice by its payment hash.
+	payHash := invoiceRef.PayHash()
+	if payHash == nil {
+		return true, fmt.Errorf("no payment hash for %v", invoiceRef)
+	}
+	hash := *payHash
+
+	switch invoice.State {
+
+	// A hold invoice is now accepted and waits for the preimage, like it
+	// would have with a complete set.
+	case channeldb.ContractAccepted:
+		expiry := makeInvoiceExpiry(hash, invoice)
+		i.expiryWatcher.AddInvoices(expiry)
+
+	// Otherwise, the htlcs of the set are settled now. Notify the links
+	// that are waiting for their resolution. Any htlcs that were settled
+	// before are notified again, which doesn't hurt.
+	case channeldb.ContractSettled:
+		for key, htlc := range invoice.Htlcs {
+			if htlc.State != channeldb.HtlcStateSettled {
+				continue
+			}
+
+			resolution := NewSettleResolution(
+				*invoice.Terms.PaymentPreimage, key,
+				int32(htlc.AcceptHeight), ResultPartialSettled,
+			)
+
+			i.notifyHodlSubscribers(resolution)
+		}
+	}
+
+	i.notifyClients(hash, invoice)
+
+	return true, nil
+}
+
 // processKeySend just-in-time inserts an invoice if this htlc is a keysend
 // htlc.
 func (i *InvoiceRegistry) processKeySend(ctx invoiceUpdateCtx) error {
@@ -757,6 +1003,22 @@ func (i *InvoiceRegistry) processKeySend(ctx invoiceUpdateCtx) error {
 		return errors.New("final expiry too soon")
 	}
 
+	// Enforce the spontaneous payment policy, unless the invoice exists
+	// already. In that case this htlc is a replay that passed the policy
+	// before.
+	exists, err := i.invoiceExists(channeldb.InvoiceRefByHash(ctx.hash))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err := i.spontaneousPolicy.check(
+			amt, ctx.customRecords, i.sourceRoute(&ctx),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	// The invoice database indexes all invoices by payment address, however
 	// legacy keysend payment do not have one. In order to avoid a new
 	// payment type on-disk wrt. to indexing, we'll continue to insert a
@@ -769,6 +1031,7 @@ func (i *InvoiceRegistry) processKeySend(ctx invoiceUpdateCtx) error {
 	// Create placeholder invoice.
 	invoice := &channeldb.Invoice{
 		CreationDate: i.cfg.Clock.Now(),
+		Memo:         []byte(i.spontaneousPolicy.invoiceLabel()),
 		Terms: channeldb.ContractTerm{
 			FinalCltvDelta:  finalCltvDelta,
 			Value:           amt,
@@ -793,6 +1056,76 @@ func (i *InvoiceRegistry) processKeySend(ctx invoiceUpdateCtx) error {
 	return nil
 }
 
+// invoiceExists returns true if the invoice with the given reference exists.
+func (i *InvoiceRegistry) invoiceExists(ref channeldb.InvoiceRef) (bool,
+	error) {
+
+	_, err := i.cdb.LookupInvoice(ref)
+	switch err {
+	case nil:
+		return true, nil
+
+	case channeldb.ErrInvoiceNotFound, channeldb.ErrNoInvoicesCreated:
+		return false, nil
+
+	default:
+		return false, err
+	}
+}
+
+// spontaneousFailResult returns the fail result for a spontaneous payment that
+// failed with the given error. Payments that were rejected by the spontaneous
+// payment policy are failed with ResultSpontaneousRejected, all others with
+// the given default result.
+func spontaneousFailResult(err error,
+	defaultResult FailResolutionResult) FailResolutionResult {
+
+	if _, ok := err.(spontaneousPolicyViolation); ok {
+		return ResultSpontaneousRejected
+	}
+
+	return defaultResult
+}
+
+// sourceRoute returns the peer that the source route of the given htlc reaches
+// us through. If the peer can't be determined, all such htlcs share the zero
+// key, so that they are still rate limited together.
+func (i *InvoiceRegistry) sourceRoute(ctx *invoiceUpdateCtx) [33]byte {
+	if i.cfg.IncomingPeer == nil {
+		return [33]byte{}
+	}
+
+	peer, err := i.cfg.IncomingPeer(ctx.circuitKey.ChanID)
+	if err != nil {
+		ctx.log(fmt.Sprintf("unable to determine source route: %v",
+			err))
+
+		return [33]byte{}
+	}
+
+	return peer
+}
+
+// SpontaneousPolicy returns the policy that spontaneous keysend and AMP
+// payments must currently adhere to.
+func (i *InvoiceRegistry) SpontaneousPolicy() SpontaneousPolicy {
+	return i.spontaneousPolicy.getPolicy()
+}
+
+// SetSpontaneousPolicy replaces the policy that spontaneous keysend and AMP
+// payments must adhere to. The rate limits of the previous policy are reset.
+func (i *InvoiceRegistry) SetSpontaneousPolicy(policy SpontaneousPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	i.spontaneousPolicy.setPolicy(policy)
+
+	log.Infof("Spontaneous payment policy updated")
+
+	return nil
+}
+
 // processAMP just-in-time inserts an invoice if this htlc is a keysend
 // htlc.
 func (i *InvoiceRegistry) processAMP(ctx invoiceUpdateCtx) error {
@@ -828,9 +1161,26 @@ func (i *InvoiceRegistry) processAMP(ctx invoiceUpdateCtx) error {
 	// to create our AMP invoice.
 	payAddr := ctx.mpp.PaymentAddr()
 
+	// Enforce the spontaneous payment policy, unless the invoice exists
+	// already. In that case this htlc is another shard or a replay of a
+	// payment that passed the policy before.
+	exists, err := i.invoiceExists(channeldb.InvoiceRefByAddr(payAddr))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err := i.spontaneousPolicy.check(
+			amt, ctx.customRecords, i.sourceRoute(&ctx),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create placeholder invoice.
 	invoice := &channeldb.Invoice{
 		CreationDate: i.cfg.Clock.Now(),
+		Memo:         []byte(i.spontaneousPolicy.invoiceLabel()),
 		Terms: channeldb.ContractTerm{
 			FinalCltvDelta:  finalCltvDelta,
 			Value:           amt,
@@ -843,7 +1193,7 @@ func (i *InvoiceRegistry) processAMP(ctx invoiceUpdateCtx) error {
 	// Insert invoice into database. Ignore duplicates payment hashes and
 	// payment addrs, this may be a replay or a different HTLC for the AMP
 	// invoice.
-	_, err := i.AddInvoice(invoice, ctx.hash)
+	_, err = i.AddInvoice(invoice, ctx.hash)
 	switch {
 	case err == channeldb.ErrDuplicateInvoice:
 		return nil
@@ -886,6 +1236,7 @@ func (i *InvoiceRegistry) NotifyExitHopHtlc(rHash lntypes.Hash,
 		customRecords:        payload.CustomRecords(),
 		mpp:                  payload.MultiPath(),
 		amp:                  payload.AMPRecord(),
+		metadata:             payload.Metadata(),
 	}
 
 	switch {
@@ -899,7 +1250,8 @@ func (i *InvoiceRegistry) NotifyExitHopHtlc(rHash lntypes.Hash,
 			ctx.log(fmt.Sprintf("amp error: %v", err))
 
 			return NewFailResolution(
-				circuitKey, currentHeight, ResultAmpError,
+				circuitKey, currentHeight,
+				spontaneousFailResult(err, ResultAmpError),
 			), nil
 		}
 
@@ -913,7 +1265,21 @@ func (i *InvoiceRegistry) NotifyExitHopHtlc(rHash lntypes.Hash,
 			ctx.log(fmt.Sprintf("keysend error: %v", err))
 
 			return NewFailResolution(
-				circuitKey, currentHeight, ResultKeySendError,
+				circuitKey, currentHeight,
+				spontaneousFailResult(err, ResultKeySendError),
+			), nil
+		}
+	}
+
+	// If we are accepting stateless invoices, check whether this htlc
+	// pays to one of our stateless invoices and insert it if so.
+	if i.cfg.AcceptStatelessInvoices && ctx.amp == nil {
+		err := i.processStateless(ctx)
+		if err != nil {
+			ctx.log(fmt.Sprintf("stateless invoice error: %v", err))
+
+			return NewFailResolution(
+				circuitKey, currentHeight, ResultStatelessError,
 			), nil
 		}
 	}
@@ -1111,8 +1477,12 @@ func (i *InvoiceRegistry) notifyExitHopHtlcLocked(
 
 		// Auto-release the htlc if the invoice is still open. It can
 		// only happen for mpp payments that there are htlcs in state
-		// Accepted while the invoice is Open.
-		if invoice.State == channeldb.ContractOpen {
+		// Accepted while the invoice is Open, or Settled in case of a
+		// reusable invoice.
+		reusableSettled := invoice.Terms.MaxAmtPaid > 0 &&
+			invoice.State == channeldb.ContractSettled
+		if invoice.State == channeldb.ContractOpen || reusableSettled {
+
 			res.acceptTime = invoiceHtlc.AcceptTime
 			res.autoRelease = true
 
@@ -1126,6 +1496,10 @@ func (i *InvoiceRegistry) notifyExitHopHtlcLocked(
 		if res.outcome == resultAccepted {
 			expiry := makeInvoiceExpiry(ctx.hash, invoice)
 			i.expiryWatcher.AddInvoices(expiry)
+
+			// Payments to stateless invoices may need to be
+			// approved before they can be settled.
+			i.queueStatelessApproval(ctx.hash, invoice)
 		}
 
 		i.hodlSubscribe(hodlChan, ctx.circuitKey)
@@ -1182,6 +1556,9 @@ func (i *InvoiceRegistry) SettleHodlInvoice(preimage lntypes.Preimage) error {
 	log.Debugf("Invoice%v: settled with preimage %v", invoiceRef,
 		invoice.Terms.PaymentPreimage)
 
+	// A settled payment to a stateless invoice no longer awaits approval.
+	delete(i.pendingStateless, hash)
+
 	// In the callback, we marked the invoice as settled. UpdateInvoice will
 	// have seen this and should have moved all htlcs that were accepted to
 	// the settled state. In the loop below, we go through all of these and
@@ -1276,6 +1653,9 @@ func (i *InvoiceRegistry) cancelInvoiceImpl(payHash lntypes.Hash,
 
 	log.Debugf("Invoice%v: canceled", ref)
 
+	// A canceled payment to a stateless invoice no longer awaits approval.
+	delete(i.pendingStateless, payHash)
+
 	// In the callback, some htlcs may have been moved to the canceled
 	// state. We now go through all of these and notify links and resolvers
 	// that are waiting for resolution. Any htlcs that were already canceled
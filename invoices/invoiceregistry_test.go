@@ -1119,6 +1119,143 @@ func TestOldInvoiceRemovalOnStart(t *testing.T) {
 	require.Equal(t, expected, response.Invoices)
 }
 
+// TestSettledInvoiceArchivingOnStart tests that old settled invoices are moved
+// to the invoice archive upon start and can still be looked up afterwards.
+func TestSettledInvoiceArchivingOnStart(t *testing.T) {
+	t.Parallel()
+
+	ctx := newTestContext(t)
+	defer ctx.cleanup()
+
+	// Add and settle an invoice, and add a second one that remains open.
+	var preimage lntypes.Preimage
+	preimage[0] = 1
+	settled := newTestInvoice(t, preimage, testTime, 0)
+	_, err := ctx.registry.AddInvoice(settled, preimage.Hash())
+	require.NoError(t, err)
+
+	resolution, err := ctx.registry.NotifyExitHopHtlc(
+		preimage.Hash(), settled.Terms.Value,
+		uint32(testCurrentHeight)+testInvoiceCltvDelta,
+		testCurrentHeight, getCircuitKey(0), nil, testPayload,
+	)
+	require.NoError(t, err)
+	checkSettleResolution(t, resolution, preimage)
+
+	var openPreimage lntypes.Preimage
+	openPreimage[0] = 2
+	open := newTestInvoice(t, openPreimage, testTime, 24*time.Hour)
+	_, err = ctx.registry.AddInvoice(open, openPreimage.Hash())
+	require.NoError(t, err)
+
+	// Start a registry that archives invoices settled more than an hour
+	// ago, once two hours have passed.
+	ctx.clock.SetTime(testTime.Add(2 * time.Hour))
+
+	cfg := RegistryConfig{
+		FinalCltvRejectDelta:        testFinalCltvRejectDelta,
+		Clock:                       ctx.clock,
+		ArchiveSettledInvoicesAfter: time.Hour,
+	}
+	expiryWatcher := NewInvoiceExpiryWatcher(
+		cfg.Clock, 0, uint32(testCurrentHeight), nil, newMockNotifier(),
+	)
+	registry := NewRegistry(ctx.cdb, expiryWatcher, &cfg)
+	require.NoError(t, registry.Start())
+	defer func() {
+		require.NoError(t, registry.Stop())
+	}()
+
+	// Only the settled invoice should have been archived.
+	_, err = ctx.cdb.LookupInvoice(
+		channeldb.InvoiceRefByHash(preimage.Hash()),
+	)
+	require.Equal(t, channeldb.ErrInvoiceNotFound, err)
+
+	resp, err := ctx.cdb.QueryInvoices(channeldb.InvoiceQuery{
+		NumMaxInvoices: math.MaxUint64,
+		Archived:       true,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Invoices, 1)
+
+	// The registry should still find both invoices.
+	invoice, err := registry.LookupInvoice(preimage.Hash())
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractSettled, invoice.State)
+
+	invoice, err = registry.LookupInvoice(openPreimage.Hash())
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractOpen, invoice.State)
+
+	// Unknown invoices are still reported as not found.
+	_, err = registry.LookupInvoice(lntypes.Hash{3})
+	require.Equal(t, channeldb.ErrInvoiceNotFound, err)
+}
+
+// TestSettledInvoiceArchivingUnresolvedHtlc tests that a settled invoice isn't
+// archived while the htlc that paid it is still unresolved on one of our
+// channels, so that the htlc can still be resolved against it.
+func TestSettledInvoiceArchivingUnresolvedHtlc(t *testing.T) {
+	t.Parallel()
+
+	ctx := newTestContext(t)
+	defer ctx.cleanup()
+
+	var preimage lntypes.Preimage
+	preimage[0] = 1
+	settled := newTestInvoice(t, preimage, testTime, 0)
+	_, err := ctx.registry.AddInvoice(settled, preimage.Hash())
+	require.NoError(t, err)
+
+	expiry := uint32(testCurrentHeight) + testInvoiceCltvDelta
+	resolution, err := ctx.registry.NotifyExitHopHtlc(
+		preimage.Hash(), settled.Terms.Value, expiry,
+		testCurrentHeight, getCircuitKey(0), nil, testPayload,
+	)
+	require.NoError(t, err)
+	checkSettleResolution(t, resolution, preimage)
+
+	// Start a registry that archives invoices settled more than an hour
+	// ago, while the htlc is still on our channel.
+	ctx.clock.SetTime(testTime.Add(2 * time.Hour))
+
+	cfg := RegistryConfig{
+		FinalCltvRejectDelta:        testFinalCltvRejectDelta,
+		Clock:                       ctx.clock,
+		ArchiveSettledInvoicesAfter: time.Hour,
+		UnresolvedHtlcHashes: func() (map[lntypes.Hash]struct{},
+			error) {
+
+			return map[lntypes.Hash]struct{}{
+				preimage.Hash(): {},
+			}, nil
+		},
+	}
+	expiryWatcher := NewInvoiceExpiryWatcher(
+		cfg.Clock, 0, uint32(testCurrentHeight), nil, newMockNotifier(),
+	)
+	registry := NewRegistry(ctx.cdb, expiryWatcher, &cfg)
+	require.NoError(t, registry.Start())
+	defer func() {
+		require.NoError(t, registry.Stop())
+	}()
+
+	// The invoice should still be live, and a replay of the htlc, for
+	// example after a restart, should still be settled.
+	_, err = ctx.cdb.LookupInvoice(
+		channeldb.InvoiceRefByHash(preimage.Hash()),
+	)
+	require.NoError(t, err)
+
+	resolution, err = registry.NotifyExitHopHtlc(
+		preimage.Hash(), settled.Terms.Value, expiry,
+		testCurrentHeight, getCircuitKey(0), nil, testPayload,
+	)
+	require.NoError(t, err)
+	checkSettleResolution(t, resolution, preimage)
+}
+
 // TestHeightExpiryWithRegistry tests our height-based invoice expiry for
 // invoices paid with single and multiple htlcs, testing the case where the
 // invoice is settled before expiry (and thus not canceled), and the case
@@ -1760,3 +1897,634 @@ func testSpontaneousAmpPayment(
 		}
 	}
 }
+
+// TestStatelessInvoice tests that payments to stateless invoices are held
+// until they are approved by a stateless invoice acceptor, and that they are
+// settled using the derived preimage.
+func TestStatelessInvoice(t *testing.T) {
+	defer timeout()()
+
+	ctx := newTestContext(t)
+	defer ctx.cleanup()
+
+	// Stateless invoices are rejected as long as they aren't enabled.
+	_, err := ctx.registry.RegisterStatelessAcceptor()
+	require.Equal(t, ErrStatelessInvoicesDisabled, err)
+
+	ctx.registry.cfg.AcceptStatelessInvoices = true
+	ctx.registry.cfg.StatelessInvoiceSecret = [32]byte{1, 2, 3}
+
+	_, err = ctx.registry.StatelessPreimage([32]byte{}, 0, nil)
+	require.Equal(t, ErrZeroAmtStatelessInvoice, err)
+
+	const (
+		amt    = lnwire.MilliSatoshi(100000)
+		expiry = uint32(testCurrentHeight + 20)
+	)
+	metadata := []byte{4, 5, 6}
+
+	var htlcID uint64
+	newPaymentWithExpiry := func(invoiceExpiry time.Time) (
+		lntypes.Preimage, *mockPayload) {
+
+		payAddr, err := StatelessPaymentAddr(invoiceExpiry)
+		require.NoError(t, err)
+		require.Equal(
+			t, invoiceExpiry.Unix(),
+			StatelessInvoiceExpiry(payAddr).Unix(),
+		)
+
+		preimage, err := ctx.registry.StatelessPreimage(
+			payAddr, amt, metadata,
+		)
+		require.NoError(t, err)
+
+		return preimage, &mockPayload{
+			mpp:      record.NewMPP(amt, payAddr),
+			metadata: metadata,
+		}
+	}
+	newPayment := func() (lntypes.Preimage, *mockPayload) {
+		return newPaymentWithExpiry(testTime.Add(time.Hour))
+	}
+	notify := func(hash lntypes.Hash, htlcAmt lnwire.MilliSatoshi,
+		payload *mockPayload,
+		hodlChan chan interface{}) HtlcResolution {
+
+		htlcID++
+		resolution, err := ctx.registry.NotifyExitHopHtlc(
+			hash, htlcAmt, expiry, testCurrentHeight,
+			getCircuitKey(htlcID), hodlChan, payload,
+		)
+		require.NoError(t, err)
+
+		return resolution
+	}
+
+	// Without an acceptor, a multi-part payment to a stateless invoice is
+	// held once the full amount has arrived.
+	preimage, payload := newPayment()
+	hash := preimage.Hash()
+	hodlChan := make(chan interface{}, 2)
+
+	resolution := notify(hash, amt/2, payload, hodlChan)
+	require.Nil(t, resolution)
+
+	resolution = notify(hash, amt/2, payload, hodlChan)
+	require.Nil(t, resolution)
+
+	invoice, err := ctx.registry.LookupInvoice(hash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractAccepted, invoice.State)
+	require.Equal(t, amt, invoice.AmtPaid)
+	require.Len(t, invoice.Htlcs, 2)
+	for _, htlc := range invoice.Htlcs {
+		require.Equal(t, metadata, htlc.Metadata)
+	}
+
+	// A payment with a different amount or metadata doesn't match the
+	// payment hash and therefore isn't recognized as a stateless invoice.
+	preimage2, payload2 := newPayment()
+	payload2.mpp = record.NewMPP(amt-1, payload2.mpp.PaymentAddr())
+	resolution = notify(preimage2.Hash(), amt-1, payload2, hodlChan)
+	checkFailResolution(t, resolution, ResultInvoiceNotFound)
+
+	preimage2, payload2 = newPayment()
+	payload2.metadata = []byte{7}
+	resolution = notify(preimage2.Hash(), amt, payload2, hodlChan)
+	checkFailResolution(t, resolution, ResultInvoiceNotFound)
+
+	// Payments to expired stateless invoices are rejected.
+	preimage2, payload2 = newPaymentWithExpiry(testTime)
+	resolution = notify(preimage2.Hash(), amt, payload2, hodlChan)
+	checkFailResolution(t, resolution, ResultStatelessError)
+
+	_, err = ctx.registry.LookupInvoice(preimage2.Hash())
+	require.Equal(t, channeldb.ErrInvoiceNotFound, err)
+
+	// Register an acceptor. The payment that is already held is delivered
+	// to it and settled once it is approved.
+	acceptor, err := ctx.registry.RegisterStatelessAcceptor()
+	require.NoError(t, err)
+	defer acceptor.Cancel()
+
+	req := <-acceptor.Requests
+	require.Equal(t, hash, req.PaymentHash)
+
+	require.NoError(t, acceptor.Resolve(hash, true))
+	checkSettleResolution(t, (<-hodlChan).(HtlcResolution), preimage)
+	checkSettleResolution(t, (<-hodlChan).(HtlcResolution), preimage)
+
+	invoice, err = ctx.registry.LookupInvoice(hash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractSettled, invoice.State)
+
+	// Only a single acceptor can be active at a time.
+	_, err = ctx.registry.RegisterStatelessAcceptor()
+	require.Equal(t, ErrStatelessAcceptorActive, err)
+
+	// New payments are handed to the acceptor right away.
+	preimage, payload = newPayment()
+	hash = preimage.Hash()
+	resolution = notify(hash, amt, payload, hodlChan)
+	require.Nil(t, resolution)
+
+	req = <-acceptor.Requests
+	require.Equal(t, hash, req.PaymentHash)
+	require.Equal(t, payload.mpp.PaymentAddr(), req.PaymentAddr)
+	require.Equal(t, metadata, req.Metadata)
+	require.Equal(t, amt, req.Value)
+	require.Equal(t, amt, req.AmtPaid)
+
+	// If settling the invoice fails, which we simulate by corrupting the
+	// derived preimage, the payment keeps awaiting approval, so that it
+	// can be resolved again.
+	ctx.registry.Lock()
+	ctx.registry.pendingStateless[hash].preimage = lntypes.Preimage{}
+	ctx.registry.Unlock()
+
+	require.Error(t, acceptor.Resolve(hash, true))
+
+	invoice, err = ctx.registry.LookupInvoice(hash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractAccepted, invoice.State)
+
+	ctx.registry.Lock()
+	require.Contains(t, ctx.registry.pendingStateless, hash)
+	ctx.registry.pendingStateless[hash].preimage = preimage
+	ctx.registry.Unlock()
+
+	require.NoError(t, acceptor.Resolve(hash, true))
+	checkSettleResolution(t, (<-hodlChan).(HtlcResolution), preimage)
+
+	invoice, err = ctx.registry.LookupInvoice(hash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractSettled, invoice.State)
+
+	// Payments can only be resolved once.
+	require.Equal(
+		t, ErrStatelessInvoiceNotPending, acceptor.Resolve(hash, true),
+	)
+
+	// Rejected payments are canceled back to the payer.
+	preimage, payload = newPayment()
+	hash = preimage.Hash()
+	resolution = notify(hash, amt, payload, hodlChan)
+	require.Nil(t, resolution)
+
+	req = <-acceptor.Requests
+	require.Equal(t, hash, req.PaymentHash)
+
+	require.NoError(t, acceptor.Resolve(hash, false))
+	checkFailResolution(
+		t, (<-hodlChan).(HtlcResolution), ResultCanceled,
+	)
+
+	invoice, err = ctx.registry.LookupInvoice(hash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractCanceled, invoice.State)
+}
+
+// TestInvoiceMaxPaymentAmt tests that payments exceeding the maximum payment
+// amount of an invoice are rejected.
+func TestInvoiceMaxPaymentAmt(t *testing.T) {
+	defer timeout()()
+
+	ctx := newTestContext(t)
+	defer ctx.cleanup()
+
+	invoice := *testInvoice
+	invoice.Terms.MaxPaymentAmt = testInvoiceAmt + 1000
+
+	_, err := ctx.registry.AddInvoice(&invoice, testInvoicePaymentHash)
+	require.NoError(t, err)
+
+	hodlChan := make(chan interface{}, 1)
+	notify := func(htlcID uint64, amt lnwire.MilliSatoshi,
+		payload Payload) HtlcResolution {
+
+		resolution, err := ctx.registry.NotifyExitHopHtlc(
+			testInvoicePaymentHash, amt, testHtlcExpiry,
+			testCurrentHeight, getCircuitKey(htlcID), hodlChan,
+			payload,
+		)
+		require.NoError(t, err)
+
+		return resolution
+	}
+
+	// A single htlc that pays more than the maximum payment amount is
+	// rejected, while overpaying by up to the maximum is accepted.
+	tooHigh := invoice.Terms.MaxPaymentAmt + 1
+	resolution := notify(10, tooHigh, testPayload)
+	checkFailResolution(t, resolution, ResultAmountTooHigh)
+
+	resolution = notify(11, invoice.Terms.MaxPaymentAmt, testPayload)
+	checkSettleResolution(t, resolution, testInvoicePreimage)
+
+	// Duplicate htlcs to the settled invoice count towards the maximum as
+	// well, so they are rejected once they would exceed it.
+	resolution = notify(12, invoice.Terms.Value, testPayload)
+	checkFailResolution(t, resolution, ResultAmountTooHigh)
+
+	inv, err := ctx.registry.LookupInvoice(testInvoicePaymentHash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractSettled, inv.State)
+	require.Equal(t, invoice.Terms.MaxPaymentAmt, inv.AmtPaid)
+
+	// The total of an mpp set is only claimed by the sender, so the parts
+	// of a set are accepted until they actually exceed the maximum.
+	mppPreimage := lntypes.Preimage{2}
+	mppInvoice := newTestInvoice(t, mppPreimage, testTime, 0)
+	mppInvoice.Terms.MaxPaymentAmt = invoice.Terms.MaxPaymentAmt
+
+	mppHash := mppPreimage.Hash()
+	_, err = ctx.registry.AddInvoice(mppInvoice, mppHash)
+	require.NoError(t, err)
+
+	mppPayload := &mockPayload{
+		mpp: record.NewMPP(tooHigh, mppInvoice.Terms.PaymentAddr),
+	}
+	notifyMpp := func(htlcID uint64,
+		amt lnwire.MilliSatoshi) HtlcResolution {
+
+		resolution, err := ctx.registry.NotifyExitHopHtlc(
+			mppHash, amt, testHtlcExpiry, testCurrentHeight,
+			getCircuitKey(htlcID), hodlChan, mppPayload,
+		)
+		require.NoError(t, err)
+
+		return resolution
+	}
+
+	resolution = notifyMpp(20, invoice.Terms.MaxPaymentAmt)
+	require.Nil(t, resolution)
+
+	resolution = notifyMpp(21, 1)
+	checkFailResolution(t, resolution, ResultAmountTooHigh)
+
+	inv, err = ctx.registry.LookupInvoice(mppHash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractOpen, inv.State)
+	require.Len(t, inv.HTLCSet(nil, channeldb.HtlcStateAccepted), 1)
+}
+
+// TestInvoiceAcceptPartial tests that incomplete mpp sets to invoices that
+// accept partial payments are settled, or accepted in case of a hold invoice,
+// once they time out.
+func TestInvoiceAcceptPartial(t *testing.T) {
+	defer timeout()()
+
+	ctx := newTestContext(t)
+	defer ctx.cleanup()
+
+	invoice := *testInvoice
+	invoice.Terms.AcceptPartial = true
+
+	_, err := ctx.registry.AddInvoice(&invoice, testInvoicePaymentHash)
+	require.NoError(t, err)
+
+	mppPayload := &mockPayload{
+		mpp: record.NewMPP(testInvoiceAmt, [32]byte{}),
+	}
+
+	// Send two htlcs that pay a third of the invoice each.
+	hodlChan1 := make(chan interface{}, 1)
+	hodlChan2 := make(chan interface{}, 1)
+	for i, hodlChan := range []chan interface{}{hodlChan1, hodlChan2} {
+		resolution, err := ctx.registry.NotifyExitHopHtlc(
+			testInvoicePaymentHash, testInvoiceAmt/3,
+			testHtlcExpiry, testCurrentHeight,
+			getCircuitKey(uint64(10+i)), hodlChan, mppPayload,
+		)
+		require.NoError(t, err)
+		require.Nil(t, resolution)
+	}
+
+	// Once the set times out, both htlcs are settled.
+	ctx.clock.SetTime(testTime.Add(30 * time.Second))
+
+	for _, hodlChan := range []chan interface{}{hodlChan1, hodlChan2} {
+		resolution := checkSettleResolution(
+			t, (<-hodlChan).(HtlcResolution), testInvoicePreimage,
+		)
+		require.Equal(t, ResultPartialSettled, resolution.Outcome)
+	}
+
+	inv, err := ctx.registry.LookupInvoice(testInvoicePaymentHash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractSettled, inv.State)
+	require.Equal(t, 2*(testInvoiceAmt/3), inv.AmtPaid)
+
+	// A partial payment to a hold invoice moves it to the accepted state,
+	// where it waits for the preimage.
+	preimage := lntypes.Preimage{2}
+	hodlInvoice := *testHodlInvoice
+	hodlInvoice.Terms.AcceptPartial = true
+
+	_, err = ctx.registry.AddInvoice(&hodlInvoice, preimage.Hash())
+	require.NoError(t, err)
+
+	hodlChan := make(chan interface{}, 1)
+	resolution, err := ctx.registry.NotifyExitHopHtlc(
+		preimage.Hash(), testInvoiceAmt/3, testHtlcExpiry,
+		testCurrentHeight, getCircuitKey(12), hodlChan, mppPayload,
+	)
+	require.NoError(t, err)
+	require.Nil(t, resolution)
+
+	ctx.clock.SetTime(testTime.Add(60 * time.Second))
+
+	require.Eventually(t, func() bool {
+		inv, err := ctx.registry.LookupInvoice(preimage.Hash())
+		require.NoError(t, err)
+
+		return inv.State == channeldb.ContractAccepted
+	}, testTimeout, 10*time.Millisecond)
+
+	require.NoError(t, ctx.registry.SettleHodlInvoice(preimage))
+	checkSettleResolution(t, (<-hodlChan).(HtlcResolution), preimage)
+}
+
+// TestReusableInvoice tests that a reusable AMP invoice accepts new htlc sets
+// after it was settled, that every set is settled on its own and that the
+// cap is enforced across all sets that are in flight.
+func TestReusableInvoice(t *testing.T) {
+	defer timeout()()
+
+	ctx := newTestContext(t)
+	defer ctx.cleanup()
+
+	var (
+		payAddr [32]byte
+		payHash lntypes.Hash
+	)
+	_, err := rand.Read(payAddr[:])
+	require.NoError(t, err)
+	_, err = rand.Read(payHash[:])
+	require.NoError(t, err)
+
+	invoice := &channeldb.Invoice{
+		Terms: channeldb.ContractTerm{
+			PaymentAddr: payAddr,
+			Value:       testInvoiceAmt,
+			Expiry:      time.Hour,
+			Features: lnwire.NewFeatureVector(
+				lnwire.NewRawFeatureVector(
+					lnwire.TLVOnionPayloadRequired,
+					lnwire.PaymentAddrOptional,
+					lnwire.AMPRequired,
+				),
+				lnwire.Features,
+			),
+			MaxAmtPaid: 3 * testInvoiceAmt,
+		},
+		CreationDate: testInvoiceCreationDate,
+	}
+	_, err = ctx.registry.AddInvoice(invoice, payHash)
+	require.NoError(t, err)
+
+	// newSet returns the two child preimages of a new AMP set, each paying
+	// half of the invoice value.
+	newSet := func() ([32]byte, []*amp.Child) {
+		var setID [32]byte
+		_, err := rand.Read(setID[:])
+		require.NoError(t, err)
+
+		sharer, err := amp.NewSeedSharer()
+		require.NoError(t, err)
+		left, right, err := sharer.Split()
+		require.NoError(t, err)
+
+		return setID, []*amp.Child{left.Child(0), right.Child(1)}
+	}
+
+	var htlcID uint64
+	sendShard := func(setID [32]byte, child *amp.Child,
+		hodlChan chan interface{}) HtlcResolution {
+
+		htlcID++
+		resolution, err := ctx.registry.NotifyExitHopHtlc(
+			child.Hash, testInvoiceAmt/2, testHtlcExpiry,
+			testCurrentHeight, getCircuitKey(htlcID), hodlChan,
+			&mockPayload{
+				mpp: record.NewMPP(testInvoiceAmt, payAddr),
+				amp: record.NewAMP(
+					child.Share, setID, child.Index,
+				),
+			},
+		)
+		require.NoError(t, err)
+
+		return resolution
+	}
+
+	// paySet pays a complete set and asserts that it is settled.
+	paySet := func() {
+		t.Helper()
+
+		setID, children := newSet()
+		hodlChan := make(chan interface{}, 1)
+		resolution := sendShard(setID, children[0], hodlChan)
+		require.Nil(t, resolution)
+
+		resolution = sendShard(setID, children[1], hodlChan)
+		res := checkSettleResolution(t, resolution, children[1].Preimage)
+		require.Equal(t, ResultSettled, res.Outcome)
+		checkSettleResolution(
+			t, (<-hodlChan).(HtlcResolution), children[0].Preimage,
+		)
+	}
+
+	assertAmtPaid := func(amt lnwire.MilliSatoshi) {
+		t.Helper()
+
+		inv, err := ctx.registry.LookupInvoice(payHash)
+		require.NoError(t, err)
+		require.Equal(t, channeldb.ContractSettled, inv.State)
+		require.Equal(t, amt, inv.AmtPaid)
+	}
+
+	// The first set settles the invoice.
+	paySet()
+	assertAmtPaid(testInvoiceAmt)
+
+	// Start two more sets. Together with the settled set, they reserve
+	// the full cap of the invoice, so another set is rejected.
+	setB, childrenB := newSet()
+	hodlChanB := make(chan interface{}, 1)
+	require.Nil(t, sendShard(setB, childrenB[0], hodlChanB))
+
+	setC, childrenC := newSet()
+	hodlChanC := make(chan interface{}, 1)
+	require.Nil(t, sendShard(setC, childrenC[0], hodlChanC))
+
+	setD, childrenD := newSet()
+	resolution := sendShard(setD, childrenD[0], nil)
+	checkFailResolution(t, resolution, ResultInvoiceCapReached)
+
+	// Completing the second set only settles that set. The third set is
+	// still incomplete, so it must neither be settled nor counted as paid.
+	resolution = sendShard(setB, childrenB[1], hodlChanB)
+	checkSettleResolution(t, resolution, childrenB[1].Preimage)
+	checkSettleResolution(
+		t, (<-hodlChanB).(HtlcResolution), childrenB[0].Preimage,
+	)
+	assertAmtPaid(2 * testInvoiceAmt)
+
+	select {
+	case <-hodlChanC:
+		t.Fatal("incomplete set resolved")
+	default:
+	}
+
+	// The incomplete set times out like it would on an open invoice.
+	ctx.clock.SetTime(testTime.Add(30 * time.Second))
+	checkFailResolution(
+		t, (<-hodlChanC).(HtlcResolution), ResultMppTimeout,
+	)
+	assertAmtPaid(2 * testInvoiceAmt)
+
+	// Another set reaches the cap, after which further sets are rejected.
+	paySet()
+	assertAmtPaid(3 * testInvoiceAmt)
+
+	setE, childrenE := newSet()
+	resolution = sendShard(setE, childrenE[0], nil)
+	checkFailResolution(t, resolution, ResultInvoiceCapReached)
+	assertAmtPaid(3 * testInvoiceAmt)
+}
+
+// TestSpontaneousPolicy tests that spontaneous keysend payments that violate
+// the spontaneous payment policy are rejected, and that the invoices of
+// accepted payments are labeled.
+func TestSpontaneousPolicy(t *testing.T) {
+	defer timeout()()
+
+	ctx := newTestContext(t)
+	defer ctx.cleanup()
+
+	ctx.registry.cfg.AcceptKeySend = true
+
+	// Channels 1 and 3 are with the same peer, channel 2 with another
+	// one.
+	ctx.registry.cfg.IncomingPeer = func(
+		chanID lnwire.ShortChannelID) ([33]byte, error) {
+
+		if chanID.ToUint64() == 2 {
+			return [33]byte{2}, nil
+		}
+
+		return [33]byte{1}, nil
+	}
+
+	const allowedRecord = 70000
+	err := ctx.registry.SetSpontaneousPolicy(SpontaneousPolicy{
+		MinAmt:                1000,
+		MaxAmt:                10000,
+		RestrictCustomRecords: true,
+		AllowedCustomRecords:  []uint64{allowedRecord},
+		RateLimit:             1,
+		RateLimitInterval:     time.Minute,
+		InvoiceLabel:          "keysend",
+	})
+	require.NoError(t, err)
+
+	// Policies with a maximum below the minimum are rejected.
+	err = ctx.registry.SetSpontaneousPolicy(SpontaneousPolicy{
+		MinAmt: 1000,
+		MaxAmt: 999,
+	})
+	require.Error(t, err)
+	require.EqualValues(t, 1000, ctx.registry.SpontaneousPolicy().MinAmt)
+
+	hodlChan := make(chan interface{}, 1)
+	expiry := uint32(testCurrentHeight + 20)
+
+	// sendKeySend sends a keysend htlc with a unique preimage through the
+	// channel with the given id.
+	var nextPreimage byte
+	sendKeySend := func(amt lnwire.MilliSatoshi, chanID uint64,
+		records map[uint64][]byte) (HtlcResolution, lntypes.Preimage) {
+
+		nextPreimage++
+		preimage := lntypes.Preimage{nextPreimage}
+
+		customRecords := map[uint64][]byte{
+			record.KeySendType: preimage[:],
+		}
+		for recordType, value := range records {
+			customRecords[recordType] = value
+		}
+
+		circuitKey := channeldb.CircuitKey{
+			ChanID: lnwire.NewShortChanIDFromInt(chanID),
+			HtlcID: uint64(nextPreimage),
+		}
+
+		resolution, err := ctx.registry.NotifyExitHopHtlc(
+			preimage.Hash(), amt, expiry, testCurrentHeight,
+			circuitKey, hodlChan,
+			&mockPayload{customRecords: customRecords},
+		)
+		require.NoError(t, err)
+
+		return resolution, preimage
+	}
+
+	// Payments outside of the amount range are rejected.
+	resolution, _ := sendKeySend(999, 1, nil)
+	checkFailResolution(t, resolution, ResultSpontaneousRejected)
+
+	resolution, _ = sendKeySend(10001, 1, nil)
+	checkFailResolution(t, resolution, ResultSpontaneousRejected)
+
+	// Custom records that are not allowed are rejected.
+	resolution, _ = sendKeySend(5000, 1, map[uint64][]byte{70001: {1}})
+	checkFailResolution(t, resolution, ResultSpontaneousRejected)
+
+	// A payment with an allowed custom record is settled, and its invoice
+	// is labeled.
+	resolution, preimage := sendKeySend(
+		5000, 1, map[uint64][]byte{allowedRecord: {1}},
+	)
+	checkSettleResolution(t, resolution, preimage)
+
+	invoice, err := ctx.registry.LookupInvoice(preimage.Hash())
+	require.NoError(t, err)
+	require.Equal(t, []byte("keysend"), invoice.Memo)
+
+	// The rejected payments did not count towards the rate limit, but the
+	// settled one did. A second payment through a source route via the
+	// same peer is rejected, even if it uses another channel, while
+	// payments through source routes via other peers are accepted.
+	resolution, _ = sendKeySend(5000, 1, nil)
+	checkFailResolution(t, resolution, ResultSpontaneousRejected)
+
+	resolution, _ = sendKeySend(5000, 3, nil)
+	checkFailResolution(t, resolution, ResultSpontaneousRejected)
+
+	resolution, preimage = sendKeySend(5000, 2, nil)
+	checkSettleResolution(t, resolution, preimage)
+
+	// Once the rate limit interval passed, the source route can be used
+	// again.
+	ctx.clock.SetTime(testTime.Add(time.Minute))
+
+	resolution, preimage = sendKeySend(5000, 1, nil)
+	checkSettleResolution(t, resolution, preimage)
+
+	// Replays of accepted payments are not subject to the policy.
+	circuitKey := channeldb.CircuitKey{
+		ChanID: lnwire.NewShortChanIDFromInt(1),
+		HtlcID: uint64(nextPreimage),
+	}
+	resolution, err = ctx.registry.NotifyExitHopHtlc(
+		preimage.Hash(), 5000, expiry, testCurrentHeight, circuitKey,
+		hodlChan, &mockPayload{customRecords: map[uint64][]byte{
+			record.KeySendType: preimage[:],
+		}},
+	)
+	require.NoError(t, err)
+	checkSettleResolution(t, resolution, preimage)
+}
@@ -120,6 +120,22 @@ const (
 	// ResultAmpReconstruction is returned when the derived child
 	// hash/preimage pairs were invalid for at least one HTLC in the set.
 	ResultAmpReconstruction
+
+	// ResultStatelessError is returned when we fail to insert an invoice
+	// for a htlc that pays to a stateless invoice.
+	ResultStatelessError
+
+	// ResultAmountTooHigh is returned when a payment exceeds the maximum
+	// payment amount of an invoice.
+	ResultAmountTooHigh
+
+	// ResultInvoiceCapReached is returned when a payment would take the
+	// total amount paid to a reusable invoice above its cap.
+	ResultInvoiceCapReached
+
+	// ResultSpontaneousRejected is returned when a spontaneous keysend or
+	// AMP payment violates the spontaneous payment policy.
+	ResultSpontaneousRejected
 )
 
 // String returns a string representation of the result.
@@ -189,6 +205,18 @@ func (f FailResolutionResult) FailureString() string {
 	case ResultAmpReconstruction:
 		return "amp reconstruction failed"
 
+	case ResultStatelessError:
+		return "stateless invoice error"
+
+	case ResultAmountTooHigh:
+		return "amount too high"
+
+	case ResultInvoiceCapReached:
+		return "invoice cap reached"
+
+	case ResultSpontaneousRejected:
+		return "spontaneous payment rejected by policy"
+
 	default:
 		return "unknown failure resolution result"
 	}
@@ -228,6 +256,11 @@ const (
 	// ResultDuplicateToSettled is returned when we settle an invoice which
 	// has already been settled at least once.
 	ResultDuplicateToSettled
+
+	// ResultPartialSettled is returned when we settle an incomplete mpp
+	// set after it timed out, because the invoice accepts partial
+	// payments.
+	ResultPartialSettled
 )
 
 // String returns a string representation of the result.
@@ -245,6 +278,9 @@ func (s SettleResolutionResult) String() string {
 	case ResultDuplicateToSettled:
 		return "accepting duplicate payment to settled invoice"
 
+	case ResultPartialSettled:
+		return "settled partial payment"
+
 	default:
 		return "unknown settle resolution result"
 	}
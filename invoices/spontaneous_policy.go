@@ -0,0 +1,218 @@
+package invoices
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
+)
+
+// SpontaneousPolicy restricts the spontaneous keysend and AMP payments that
+// are accepted. The zero value accepts all spontaneous payments.
+type SpontaneousPolicy struct {
+	// MinAmt is the minimum amount of a spontaneous payment. For AMP
+	// payments, the total amount of the payment is checked. Zero means
+	// that there is no minimum.
+	MinAmt lnwire.MilliSatoshi
+
+	// MaxAmt is the maximum amount of a spontaneous payment. Zero means
+	// that there is no maximum.
+	MaxAmt lnwire.MilliSatoshi
+
+	// RestrictCustomRecords indicates whether the custom records of
+	// spontaneous payments are restricted to AllowedCustomRecords. The
+	// keysend record is always allowed.
+	RestrictCustomRecords bool
+
+	// AllowedCustomRecords are the custom record types that spontaneous
+	// payments may carry if RestrictCustomRecords is set.
+	AllowedCustomRecords []uint64
+
+	// RateLimit is the maximum number of spontaneous payments that are
+	// accepted through a single source route within RateLimitInterval.
+	// Onion routing hides all hops of a source route but the last one, so
+	// routes are told apart by the peer that they reach us through. Zero
+	// disables rate limiting.
+	RateLimit uint32
+
+	// RateLimitInterval is the interval that RateLimit applies to.
+	RateLimitInterval time.Duration
+
+	// InvoiceLabel is stored as the memo of the invoices that are created
+	// for spontaneous payments.
+	InvoiceLabel string
+}
+
+// Validate checks that the policy is sane.
+func (p *SpontaneousPolicy) Validate() error {
+	if p.MaxAmt != 0 && p.MaxAmt < p.MinAmt {
+		return errors.New("maximum amount of spontaneous payments " +
+			"must not be below the minimum amount")
+	}
+
+	if p.RateLimit != 0 && p.RateLimitInterval <= 0 {
+		return errors.New("rate limit interval of spontaneous " +
+			"payments must be positive")
+	}
+
+	for _, recordType := range p.AllowedCustomRecords {
+		if recordType < record.CustomTypeStart {
+			return fmt.Errorf("allowed custom record type %v is "+
+				"not a custom record type", recordType)
+		}
+	}
+
+	return nil
+}
+
+// spontaneousPolicyViolation is returned when a spontaneous payment is
+// rejected by the spontaneous payment policy.
+type spontaneousPolicyViolation string
+
+// Error returns the reason the payment was rejected.
+func (v spontaneousPolicyViolation) Error() string {
+	return string(v)
+}
+
+// rateWindow counts the spontaneous payments that were accepted through a
+// source route within the current rate limit interval.
+type rateWindow struct {
+	// start is the time the interval started.
+	start time.Time
+
+	// count is the number of payments accepted within the interval.
+	count uint32
+}
+
+// spontaneousPolicyEnforcer enforces the spontaneous payment policy of the
+// registry. The policy can be replaced at runtime.
+type spontaneousPolicyEnforcer struct {
+	clock clock.Clock
+
+	mu sync.Mutex
+
+	// policy is the policy that is currently enforced.
+	policy SpontaneousPolicy
+
+	// allowedRecords is the set of AllowedCustomRecords of the policy.
+	allowedRecords map[uint64]struct{}
+
+	// windows holds the rate limit window of every source route that
+	// spontaneous payments were received through, keyed by the peer that
+	// the route reaches us through.
+	windows map[[33]byte]*rateWindow
+}
+
+// newSpontaneousPolicyEnforcer creates a new enforcer for the given policy.
+func newSpontaneousPolicyEnforcer(policy SpontaneousPolicy,
+	clock clock.Clock) *spontaneousPolicyEnforcer {
+
+	e := &spontaneousPolicyEnforcer{
+		clock: clock,
+	}
+	e.setPolicy(policy)
+
+	return e
+}
+
+// setPolicy replaces the enforced policy. The rate limits start anew.
+func (e *spontaneousPolicyEnforcer) setPolicy(policy SpontaneousPolicy) {
+	allowedRecords := make(map[uint64]struct{})
+	for _, recordType := range policy.AllowedCustomRecords {
+		allowedRecords[recordType] = struct{}{}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.policy = policy
+	e.policy.AllowedCustomRecords = append(
+		[]uint64(nil), policy.AllowedCustomRecords...,
+	)
+	e.allowedRecords = allowedRecords
+	e.windows = make(map[[33]byte]*rateWindow)
+}
+
+// getPolicy returns a copy of the enforced policy.
+func (e *spontaneousPolicyEnforcer) getPolicy() SpontaneousPolicy {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	policy := e.policy
+	policy.AllowedCustomRecords = append(
+		[]uint64(nil), e.policy.AllowedCustomRecords...,
+	)
+
+	return policy
+}
+
+// invoiceLabel returns the memo of the invoices that are created for
+// spontaneous payments.
+func (e *spontaneousPolicyEnforcer) invoiceLabel() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.policy.InvoiceLabel
+}
+
+// check returns a spontaneousPolicyViolation if a spontaneous payment over
+// the given amount that carries the given custom records and was received
+// through a source route that reaches us through the given peer violates the
+// policy. If the payment is accepted, it is counted towards the rate limit of
+// the source route.
+func (e *spontaneousPolicyEnforcer) check(amt lnwire.MilliSatoshi,
+	customRecords record.CustomSet, source [33]byte) error {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if amt < e.policy.MinAmt {
+		return spontaneousPolicyViolation(fmt.Sprintf("amount %v "+
+			"below minimum %v", amt, e.policy.MinAmt))
+	}
+
+	if e.policy.MaxAmt != 0 && amt > e.policy.MaxAmt {
+		return spontaneousPolicyViolation(fmt.Sprintf("amount %v "+
+			"above maximum %v", amt, e.policy.MaxAmt))
+	}
+
+	if e.policy.RestrictCustomRecords {
+		for recordType := range customRecords {
+			if recordType == record.KeySendType {
+				continue
+			}
+
+			if _, ok := e.allowedRecords[recordType]; !ok {
+				return spontaneousPolicyViolation(fmt.Sprintf(
+					"custom record type %v not allowed",
+					recordType,
+				))
+			}
+		}
+	}
+
+	if e.policy.RateLimit == 0 {
+		return nil
+	}
+
+	now := e.clock.Now()
+	window, ok := e.windows[source]
+	if !ok || now.Sub(window.start) >= e.policy.RateLimitInterval {
+		window = &rateWindow{
+			start: now,
+		}
+		e.windows[source] = window
+	}
+
+	if window.count >= e.policy.RateLimit {
+		return spontaneousPolicyViolation(fmt.Sprintf("rate limit "+
+			"of source route via %x exceeded", source))
+	}
+	window.count++
+
+	return nil
+}
@@ -0,0 +1,402 @@
+package invoices
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/queue"
+)
+
+var (
+	// ErrStatelessInvoicesDisabled is returned when a stateless invoice is
+	// requested while stateless invoices aren't accepted.
+	ErrStatelessInvoicesDisabled = errors.New("stateless invoices are " +
+		"disabled")
+
+	// ErrZeroAmtStatelessInvoice is returned when a stateless invoice
+	// without an amount is requested. The amount of a stateless invoice
+	// is committed to in its preimage, so it must be known upfront.
+	ErrZeroAmtStatelessInvoice = errors.New("stateless invoices must " +
+		"have an amount")
+
+	// ErrStatelessAcceptorActive is returned when a stateless invoice
+	// acceptor is registered while another one is still active.
+	ErrStatelessAcceptorActive = errors.New("a stateless invoice " +
+		"acceptor is already registered")
+
+	// ErrStatelessInvoiceExpired is returned when a payment to a
+	// stateless invoice arrives after the invoice has expired.
+	ErrStatelessInvoiceExpired = errors.New("stateless invoice expired")
+
+	// ErrStatelessInvoiceNotPending is returned when a payment to a
+	// stateless invoice is resolved that isn't awaiting approval.
+	ErrStatelessInvoiceNotPending = errors.New("no payment to stateless " +
+		"invoice awaiting approval")
+)
+
+// StatelessPaymentAddr generates the payment address of a new stateless
+// invoice that expires at the given time. The first 8 bytes of the payment
+// address hold the expiry as a big endian unix timestamp, the remaining bytes
+// are random. As the preimage is derived from the payment address, the expiry
+// can't be changed by the payer.
+func StatelessPaymentAddr(expiry time.Time) ([32]byte, error) {
+	var payAddr [32]byte
+	if _, err := rand.Read(payAddr[8:]); err != nil {
+		return payAddr, err
+	}
+	binary.BigEndian.PutUint64(payAddr[:8], uint64(expiry.Unix()))
+
+	return payAddr, nil
+}
+
+// StatelessInvoiceExpiry returns the expiry that is encoded in the payment
+// address of a stateless invoice.
+func StatelessInvoiceExpiry(payAddr [32]byte) time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint64(payAddr[:8])), 0)
+}
+
+// StatelessInvoicePreimage derives the preimage of a stateless invoice from
+// the node's stateless invoice secret and the payment address, amount and
+// payment metadata of the invoice. As the payer includes all of these in the
+// final hop payload, we can re-derive the preimage when the invoice is paid,
+// without ever storing the invoice itself. Committing to the amount prevents
+// payers from paying less than the amount of the invoice, and committing to
+// the payment address commits to the expiry encoded in it.
+func StatelessInvoicePreimage(secret, payAddr [32]byte,
+	amt lnwire.MilliSatoshi, metadata []byte) lntypes.Preimage {
+
+	var amtBytes [8]byte
+	binary.BigEndian.PutUint64(amtBytes[:], uint64(amt))
+
+	mac := hmac.New(sha256.New, secret[:])
+	_, _ = mac.Write(payAddr[:])
+	_, _ = mac.Write(amtBytes[:])
+	_, _ = mac.Write(metadata)
+
+	var preimage lntypes.Preimage
+	copy(preimage[:], mac.Sum(nil))
+
+	return preimage
+}
+
+// StatelessInvoiceRequest describes a payment to a stateless invoice for which
+// all htlcs have been accepted, and which awaits approval by the stateless
+// invoice acceptor.
+type StatelessInvoiceRequest struct {
+	// PaymentHash is the payment hash of the stateless invoice.
+	PaymentHash lntypes.Hash
+
+	// PaymentAddr is the payment address of the stateless invoice.
+	PaymentAddr [32]byte
+
+	// Metadata is the payment metadata of the stateless invoice, as echoed
+	// back by the payer.
+	Metadata []byte
+
+	// Value is the amount of the stateless invoice.
+	Value lnwire.MilliSatoshi
+
+	// AmtPaid is the total amount of the accepted htlcs.
+	AmtPaid lnwire.MilliSatoshi
+
+	// preimage is the derived preimage that is used to settle the invoice
+	// once the payment is approved.
+	preimage lntypes.Preimage
+}
+
+// StatelessInvoiceAcceptor is a client of the invoice registry that approves
+// or rejects payments to stateless invoices. Payments to stateless invoices are
+// always held until they are resolved by an acceptor. If no acceptor resolves
+// them in time, they are canceled before their htlcs expire.
+type StatelessInvoiceAcceptor struct {
+	// Requests is a channel over which payments to stateless invoices
+	// that await approval are sent.
+	Requests chan *StatelessInvoiceRequest
+
+	inv *InvoiceRegistry
+
+	ntfnQueue *queue.ConcurrentQueue
+
+	canceled   uint32 // To be used atomically.
+	cancelChan chan struct{}
+	wg         sync.WaitGroup
+}
+
+// RegisterStatelessAcceptor registers a new stateless invoice acceptor. Any
+// payments to stateless invoices that are already awaiting approval are
+// delivered to the new acceptor. Only a single acceptor can be registered at
+// a time.
+func (i *InvoiceRegistry) RegisterStatelessAcceptor() (
+	*StatelessInvoiceAcceptor, error) {
+
+	if !i.cfg.AcceptStatelessInvoices {
+		return nil, ErrStatelessInvoicesDisabled
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	if i.statelessAcceptor != nil {
+		return nil, ErrStatelessAcceptorActive
+	}
+
+	acceptor := &StatelessInvoiceAcceptor{
+		Requests:   make(chan *StatelessInvoiceRequest),
+		inv:        i,
+		ntfnQueue:  queue.NewConcurrentQueue(20),
+		cancelChan: make(chan struct{}),
+	}
+	acceptor.ntfnQueue.Start()
+
+	acceptor.wg.Add(1)
+	go acceptor.forwardRequests()
+
+	for _, req := range i.pendingStateless {
+		acceptor.notify(req)
+	}
+	i.statelessAcceptor = acceptor
+
+	return acceptor, nil
+}
+
+// forwardRequests proxies all requests appended to the end of the concurrent
+// queue to the Requests channel.
+//
+// NOTE: This MUST be run as a goroutine.
+func (a *StatelessInvoiceAcceptor) forwardRequests() {
+	defer a.wg.Done()
+
+	for {
+		select {
+		case ntfn := <-a.ntfnQueue.ChanOut():
+			select {
+			case a.Requests <- ntfn.(*StatelessInvoiceRequest):
+
+			case <-a.cancelChan:
+				return
+
+			case <-a.inv.quit:
+				return
+			}
+
+		case <-a.cancelChan:
+			return
+
+		case <-a.inv.quit:
+			return
+		}
+	}
+}
+
+// notify queues a request for delivery to the acceptor.
+func (a *StatelessInvoiceAcceptor) notify(req *StatelessInvoiceRequest) {
+	select {
+	case a.ntfnQueue.ChanIn() <- req:
+	case <-a.cancelChan:
+	case <-a.inv.quit:
+	}
+}
+
+// Resolve approves or rejects the payment to the stateless invoice with the
+// given payment hash. Approved payments are settled, rejected payments are
+// canceled back to the payer. The payment keeps awaiting approval until it
+// was settled or canceled successfully, so that a failed resolution can be
+// retried.
+func (a *StatelessInvoiceAcceptor) Resolve(hash lntypes.Hash,
+	approve bool) error {
+
+	a.inv.Lock()
+	req, ok := a.inv.pendingStateless[hash]
+	a.inv.Unlock()
+
+	if !ok {
+		return ErrStatelessInvoiceNotPending
+	}
+
+	if approve {
+		return a.inv.SettleHodlInvoice(req.preimage)
+	}
+
+	return a.inv.CancelInvoice(hash)
+}
+
+// Cancel unregisters the acceptor. Payments that haven't been resolved yet
+// remain pending and are delivered to the next acceptor that registers.
+func (a *StatelessInvoiceAcceptor) Cancel() {
+	if !atomic.CompareAndSwapUint32(&a.canceled, 0, 1) {
+		return
+	}
+
+	a.inv.Lock()
+	if a.inv.statelessAcceptor == a {
+		a.inv.statelessAcceptor = nil
+	}
+	a.inv.Unlock()
+
+	close(a.cancelChan)
+	a.wg.Wait()
+
+	a.ntfnQueue.Stop()
+}
+
+// StatelessPreimage derives the preimage of a new stateless invoice with the
+// given payment address, amount and payment metadata.
+func (i *InvoiceRegistry) StatelessPreimage(payAddr [32]byte,
+	amt lnwire.MilliSatoshi, metadata []byte) (lntypes.Preimage, error) {
+
+	if !i.cfg.AcceptStatelessInvoices {
+		return lntypes.Preimage{}, ErrStatelessInvoicesDisabled
+	}
+
+	if amt == 0 {
+		return lntypes.Preimage{}, ErrZeroAmtStatelessInvoice
+	}
+
+	return StatelessInvoicePreimage(
+		i.cfg.StatelessInvoiceSecret, payAddr, amt, metadata,
+	), nil
+}
+
+// processStateless just-in-time inserts an invoice if this htlc pays to a
+// stateless invoice that we created.
+func (i *InvoiceRegistry) processStateless(ctx invoiceUpdateCtx) error {
+	// Stateless invoices always require a payment address, which is
+	// carried in the MPP record.
+	if ctx.mpp == nil {
+		return nil
+	}
+
+	// If the preimage derived from the payment address, the total amount
+	// and the metadata doesn't match the payment hash, this htlc doesn't
+	// pay to a stateless invoice of ours.
+	payAddr := ctx.mpp.PaymentAddr()
+	amt := ctx.mpp.TotalMsat()
+	preimage := StatelessInvoicePreimage(
+		i.cfg.StatelessInvoiceSecret, payAddr, amt, ctx.metadata,
+	)
+	if preimage.Hash() != ctx.hash {
+		return nil
+	}
+
+	// The expiry is committed to in the payment address, so it can be
+	// trusted once the preimage matches.
+	if !i.cfg.Clock.Now().Before(StatelessInvoiceExpiry(payAddr)) {
+		return ErrStatelessInvoiceExpired
+	}
+
+	// Use the minimum block delta that we require for settling htlcs.
+	finalCltvDelta := i.cfg.FinalCltvRejectDelta
+
+	// Pre-check expiry here to prevent inserting an invoice that will not
+	// be settled.
+	if ctx.expiry < uint32(ctx.currentHeight+finalCltvDelta) {
+		return errors.New("final expiry too soon")
+	}
+
+	rawFeatures := lnwire.NewRawFeatureVector(
+		lnwire.TLVOnionPayloadOptional,
+		lnwire.PaymentAddrOptional,
+		lnwire.MPPOptional,
+	)
+	if ctx.metadata != nil {
+		rawFeatures.Set(lnwire.PaymentMetadataOptional)
+	}
+	features := lnwire.NewFeatureVector(rawFeatures, lnwire.Features)
+
+	// Payments to stateless invoices need to be approved by the acceptor
+	// before they can be settled. We insert a hodl invoice without the
+	// preimage, which is only revealed once the payment is approved. If
+	// no acceptor is registered, the payment is held until one registers
+	// or the htlcs are about to expire.
+	invoice := &channeldb.Invoice{
+		CreationDate: i.cfg.Clock.Now(),
+		Terms: channeldb.ContractTerm{
+			FinalCltvDelta: finalCltvDelta,
+			Value:          amt,
+			PaymentAddr:    payAddr,
+			Features:       features,
+		},
+		HodlInvoice: true,
+	}
+
+	// Insert invoice into database. Ignore duplicates, because this may be
+	// a replay or a different htlc of the same payment.
+	_, err := i.AddInvoice(invoice, ctx.hash)
+	switch err {
+	case channeldb.ErrDuplicateInvoice, channeldb.ErrDuplicatePayAddr:
+		return nil
+	default:
+		return err
+	}
+}
+
+// statelessRequest returns the approval request for the given invoice if it
+// is a fully accepted stateless invoice that awaits approval, and nil
+// otherwise.
+func (i *InvoiceRegistry) statelessRequest(hash lntypes.Hash,
+	invoice *channeldb.Invoice) *StatelessInvoiceRequest {
+
+	if !i.cfg.AcceptStatelessInvoices || !invoice.HodlInvoice ||
+		invoice.State != channeldb.ContractAccepted ||
+		invoice.Terms.PaymentPreimage != nil ||
+		len(invoice.PaymentRequest) != 0 {
+
+		return nil
+	}
+
+	// Re-derive the preimage using the metadata of the accepted htlcs to
+	// make sure this is a stateless invoice of ours.
+	for _, htlc := range invoice.Htlcs {
+		if htlc.State != channeldb.HtlcStateAccepted {
+			continue
+		}
+
+		preimage := StatelessInvoicePreimage(
+			i.cfg.StatelessInvoiceSecret, invoice.Terms.PaymentAddr,
+			invoice.Terms.Value, htlc.Metadata,
+		)
+		if preimage.Hash() != hash {
+			continue
+		}
+
+		return &StatelessInvoiceRequest{
+			PaymentHash: hash,
+			PaymentAddr: invoice.Terms.PaymentAddr,
+			Metadata:    htlc.Metadata,
+			Value:       invoice.Terms.Value,
+			AmtPaid:     invoice.AmtPaid,
+			preimage:    preimage,
+		}
+	}
+
+	return nil
+}
+
+// queueStatelessApproval queues a fully accepted stateless invoice for
+// approval and hands it to the acceptor if one is registered.
+//
+// NOTE: This method MUST be called with the registry lock held.
+func (i *InvoiceRegistry) queueStatelessApproval(hash lntypes.Hash,
+	invoice *channeldb.Invoice) {
+
+	req := i.statelessRequest(hash, invoice)
+	if req == nil {
+		return
+	}
+
+	log.Debugf("Invoice(%v): stateless payment awaiting approval", hash)
+
+	i.pendingStateless[hash] = req
+	if i.statelessAcceptor != nil {
+		i.statelessAcceptor.notify(req)
+	}
+}
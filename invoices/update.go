@@ -22,6 +22,7 @@ type invoiceUpdateCtx struct {
 	customRecords        record.CustomSet
 	mpp                  *record.MPP
 	amp                  *record.AMP
+	metadata             []byte
 }
 
 // invoiceRef returns an identifier that can be used to lookup or update the
@@ -149,6 +150,7 @@ func updateMpp(ctx *invoiceUpdateCtx,
 		AcceptHeight:  ctx.currentHeight,
 		MppTotalAmt:   ctx.mpp.TotalMsat(),
 		CustomRecords: ctx.customRecords,
+		Metadata:      ctx.metadata,
 	}
 
 	if ctx.amp != nil {
@@ -162,8 +164,14 @@ func updateMpp(ctx *invoiceUpdateCtx,
 	// Only accept payments to open invoices. This behaviour differs from
 	// non-mpp payments that are accepted even after the invoice is settled.
 	// Because non-mpp payments don't have a payment address, this is needed
-	// to thwart probing.
-	if inv.State != channeldb.ContractOpen {
+	// to thwart probing. Reusable invoices are the exception, they accept
+	// new htlc sets after they were settled until their cap is reached.
+	// Reusable invoices are always AMP invoices, so every set pays to fresh
+	// payment hashes and reveals fresh preimages.
+	reusable := inv.Terms.MaxAmtPaid > 0
+	if inv.State != channeldb.ContractOpen &&
+		!(reusable && inv.State == channeldb.ContractSettled) {
+
 		return nil, ctx.failRes(ResultInvoiceNotOpen), nil
 	}
 
@@ -183,6 +191,15 @@ func updateMpp(ctx *invoiceUpdateCtx,
 		return nil, ctx.failRes(ResultHtlcSetTotalTooLow), nil
 	}
 
+	// Make sure that paying the set doesn't take the total amount paid to
+	// a reusable invoice above its cap. Other sets that are still in flight
+	// may complete as well, so their totals count against the cap too.
+	if reusable && reservedAmt(inv, setID)+ctx.mpp.TotalMsat() >
+		inv.Terms.MaxAmtPaid {
+
+		return nil, ctx.failRes(ResultInvoiceCapReached), nil
+	}
+
 	htlcSet := inv.HTLCSet(setID, channeldb.HtlcStateAccepted)
 
 	// Check whether total amt matches other htlcs in the set.
@@ -210,6 +227,14 @@ func updateMpp(ctx *invoiceUpdateCtx,
 		return nil, ctx.failRes(ResultHtlcSetOverpayment), nil
 	}
 
+	// Check that the htlcs accepted so far don't exceed the maximum
+	// payment amount of the invoice. The set total is only claimed by the
+	// sender, so we cap the amount that is actually paid instead.
+	maxPaymentAmt := inv.Terms.MaxPaymentAmt
+	if maxPaymentAmt > 0 && newSetTotal > maxPaymentAmt {
+		return nil, ctx.failRes(ResultAmountTooHigh), nil
+	}
+
 	// The invoice is still open. Check the expiry.
 	if ctx.expiry < uint32(ctx.currentHeight+ctx.finalCltvRejectDelta) {
 		return nil, ctx.failRes(ResultExpiryTooSoon), nil
@@ -276,6 +301,80 @@ func updateMpp(ctx *invoiceUpdateCtx,
 	return &update, ctx.settleRes(htlcPreimage, ResultSettled), nil
 }
 
+// reservedAmt returns the amount that counts against the cap of a reusable
+// invoice, apart from the htlc set with the given set id. This is the amount
+// of all settled htlcs, plus the total amount of every other htlc set that
+// still has accepted htlcs.
+func reservedAmt(inv *channeldb.Invoice,
+	setID *[32]byte) lnwire.MilliSatoshi {
+
+	var (
+		amt      lnwire.MilliSatoshi
+		inFlight = make(map[[32]byte]lnwire.MilliSatoshi)
+	)
+	for _, htlc := range inv.Htlcs {
+		switch {
+		case htlc.State == channeldb.HtlcStateSettled:
+			amt += htlc.Amt
+
+		case htlc.State == channeldb.HtlcStateAccepted &&
+			htlc.AMP != nil && !htlc.IsInHTLCSet(setID):
+
+			inFlight[htlc.AMP.Record.SetID()] = htlc.MppTotalAmt
+		}
+	}
+
+	for _, total := range inFlight {
+		amt += total
+	}
+
+	return amt
+}
+
+// updatePartialSet is a callback for DB.UpdateInvoice that contains the logic
+// to accept an incomplete mpp set once the given htlc of the set timed out.
+// Invoices that accept partial payments settle the htlcs that arrived so far,
+// or accept them in case of a hold invoice. A nil update is returned if the
+// set can't be accepted, in which case the htlc should be canceled.
+func updatePartialSet(inv *channeldb.Invoice,
+	key channeldb.CircuitKey) *channeldb.InvoiceUpdateDesc {
+
+	if !inv.Terms.AcceptPartial {
+		return nil
+	}
+
+	// Only open invoices are still waiting for their set to complete.
+	if inv.State != channeldb.ContractOpen {
+		return nil
+	}
+
+	// The htlc must still be part of an incomplete mpp set. We can't
+	// settle partial AMP sets, as the preimages can only be reconstructed
+	// from the complete set. An invoice without AMP only has a single mpp
+	// set, so settling it only settles the htlcs of this set.
+	htlc, ok := inv.Htlcs[key]
+	if !ok || htlc.State != channeldb.HtlcStateAccepted ||
+		htlc.MppTotalAmt == 0 || htlc.AMP != nil {
+
+		return nil
+	}
+
+	if inv.HodlInvoice {
+		return &channeldb.InvoiceUpdateDesc{
+			State: &channeldb.InvoiceStateUpdateDesc{
+				NewState: channeldb.ContractAccepted,
+			},
+		}
+	}
+
+	return &channeldb.InvoiceUpdateDesc{
+		State: &channeldb.InvoiceStateUpdateDesc{
+			NewState: channeldb.ContractSettled,
+			Preimage: inv.Terms.PaymentPreimage,
+		},
+	}
+}
+
 // HTLCSet is a map of CircuitKey to InvoiceHTLC.
 type HTLCSet = map[channeldb.CircuitKey]*channeldb.InvoiceHTLC
 
@@ -370,6 +469,23 @@ func updateLegacy(ctx *invoiceUpdateCtx,
 		return nil, ctx.failRes(ResultAmountTooLow), nil
 	}
 
+	// Check that the invoice isn't overpaid by more than it allows. Legacy
+	// htlcs are accepted even after the invoice is settled, so all htlcs
+	// that weren't canceled count towards the maximum payment amount.
+	maxPaymentAmt := inv.Terms.MaxPaymentAmt
+	if maxPaymentAmt > 0 {
+		paidAmt := ctx.amtPaid
+		for _, htlc := range inv.Htlcs {
+			if htlc.State != channeldb.HtlcStateCanceled {
+				paidAmt += htlc.Amt
+			}
+		}
+
+		if paidAmt > maxPaymentAmt {
+			return nil, ctx.failRes(ResultAmountTooHigh), nil
+		}
+	}
+
 	// If the invoice had the required feature bit set at this point, then
 	// if we're in this method it means that the remote party didn't supply
 	// the expected payload. However if this is a keysend payment, then
@@ -410,6 +526,7 @@ func updateLegacy(ctx *invoiceUpdateCtx,
 			Expiry:        ctx.expiry,
 			AcceptHeight:  ctx.currentHeight,
 			CustomRecords: ctx.customRecords,
+			Metadata:      ctx.metadata,
 		},
 	}
 
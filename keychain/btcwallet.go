@@ -62,8 +62,8 @@ type BtcWalletKeyRing struct {
 // NewBtcWalletKeyRing creates a new implementation of the
 // keychain.SecretKeyRing interface backed by btcwallet.
 //
-// NOTE: The passed waddrmgr.Manager MUST be unlocked in order for the keychain
-// to function.
+// NOTE: The passed waddrmgr.Manager MUST be unlocked or watch-only in order for
+// the keychain to function. A watch-only keychain can only derive public keys.
 func NewBtcWalletKeyRing(w *wallet.Wallet, coinType uint32) SecretKeyRing {
 	// Construct the key scope that will be used within the waddrmgr to
 	// create an HD chain for deriving all of our required keys. A different
@@ -91,8 +91,10 @@ func (b *BtcWalletKeyRing) keyScope() (*waddrmgr.ScopedKeyManager, error) {
 	}
 
 	// Otherwise, we'll first do a check to ensure that the root manager
-	// isn't locked, as otherwise we won't be able to *use* the scope.
-	if b.wallet.Manager.IsLocked() {
+	// isn't locked, as otherwise we won't be able to *use* the scope. A
+	// watch-only manager is always locked, but can still derive the
+	// public keys of its accounts.
+	if b.wallet.Manager.IsLocked() && !b.wallet.Manager.WatchOnly() {
 		return nil, fmt.Errorf("cannot create BtcWalletKeyRing with " +
 			"locked waddrmgr.Manager")
 	}
@@ -128,6 +130,14 @@ func (b *BtcWalletKeyRing) createAccountIfNotExists(
 		return nil
 	}
 
+	// A watch-only wallet can't create accounts, so the account public
+	// keys of all key families must have been imported when the wallet was
+	// created.
+	if b.wallet.Manager.WatchOnly() {
+		return fmt.Errorf("account of key family %d not found in "+
+			"watch-only wallet", keyFam)
+	}
+
 	// If we reach this point, then the account hasn't yet been created, so
 	// we'll need to create it before we can proceed.
 	return scope.NewRawAccount(addrmgrNs, uint32(keyFam))
@@ -374,31 +384,39 @@ func (b *BtcWalletKeyRing) ECDH(keyDesc KeyDescriptor,
 	return h, nil
 }
 
-// SignDigest signs the given SHA256 message digest with the private key
-// described in the key descriptor.
+// SignMessage signs the given message, single or double SHA256 hashing it
+// first, with the private key described in the key locator.
 //
-// NOTE: This is part of the keychain.DigestSignerRing interface.
-func (b *BtcWalletKeyRing) SignDigest(keyDesc KeyDescriptor,
-	digest [32]byte) (*btcec.Signature, error) {
+// NOTE: This is part of the keychain.MessageSignerRing interface.
+func (b *BtcWalletKeyRing) SignMessage(keyLoc KeyLocator,
+	msg []byte, doubleHash bool) (*btcec.Signature, error) {
 
-	privKey, err := b.DerivePrivKey(keyDesc)
+	privKey, err := b.DerivePrivKey(KeyDescriptor{
+		KeyLocator: keyLoc,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return privKey.Sign(digest[:])
+
+	return privKey.Sign(messageDigest(msg, doubleHash))
 }
 
-// SignDigestCompact signs the given SHA256 message digest with the private key
-// described in the key descriptor and returns the signature in the compact,
-// public key recoverable format.
+// SignMessageCompact signs the given message, single or double SHA256 hashing
+// it first, with the private key described in the key locator and returns the
+// signature in the compact, public key recoverable format.
 //
-// NOTE: This is part of the keychain.DigestSignerRing interface.
-func (b *BtcWalletKeyRing) SignDigestCompact(keyDesc KeyDescriptor,
-	digest [32]byte) ([]byte, error) {
+// NOTE: This is part of the keychain.MessageSignerRing interface.
+func (b *BtcWalletKeyRing) SignMessageCompact(keyLoc KeyLocator,
+	msg []byte, doubleHash bool) ([]byte, error) {
 
-	privKey, err := b.DerivePrivKey(keyDesc)
+	privKey, err := b.DerivePrivKey(KeyDescriptor{
+		KeyLocator: keyLoc,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return btcec.SignCompact(btcec.S256(), privKey, digest[:], true)
+
+	return btcec.SignCompact(
+		btcec.S256(), privKey, messageDigest(msg, doubleHash), true,
+	)
 }
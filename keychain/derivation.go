@@ -103,8 +103,32 @@ const (
 	// preventing others from having full access to the tower just as a
 	// result of knowing the node key.
 	KeyFamilyTowerID KeyFamily = 9
+
+	// KeyFamilyStatelessInvoice is the family of keys used to derive the
+	// secret from which the preimages of stateless invoices are derived.
+	// Stateless invoices aren't stored until they are paid, so the
+	// preimage must be reproducible from the secret and the details the
+	// payer includes in the final hop payload.
+	KeyFamilyStatelessInvoice KeyFamily = 10
 )
 
+// VersionZeroKeyFamilies is the list of all key families of version 0 of our
+// key derivation schema. A watch-only wallet needs the account public keys of
+// all of these families to derive the public keys lnd uses.
+var VersionZeroKeyFamilies = []KeyFamily{
+	KeyFamilyMultiSig,
+	KeyFamilyRevocationBase,
+	KeyFamilyHtlcBase,
+	KeyFamilyPaymentBase,
+	KeyFamilyDelayBase,
+	KeyFamilyRevocationRoot,
+	KeyFamilyNodeKey,
+	KeyFamilyStaticBackup,
+	KeyFamilyTowerSession,
+	KeyFamilyTowerID,
+	KeyFamilyStatelessInvoice,
+}
+
 // KeyLocator is a two-tuple that can be used to derive *any* key that has ever
 // been used under the key derivation mechanisms described in this file.
 // Version 0 of our key derivation schema uses the following BIP43-like
@@ -178,7 +202,7 @@ type SecretKeyRing interface {
 
 	ECDHRing
 
-	DigestSignerRing
+	MessageSignerRing
 
 	// DerivePrivKey attempts to derive the private key that corresponds to
 	// the passed key descriptor.  If the public key is set, then this
@@ -188,35 +212,37 @@ type SecretKeyRing interface {
 	DerivePrivKey(keyDesc KeyDescriptor) (*btcec.PrivateKey, error)
 }
 
-// DigestSignerRing is an interface that abstracts away basic low-level ECDSA
+// MessageSignerRing is an interface that abstracts away basic low-level ECDSA
 // signing on keys within a key ring.
-type DigestSignerRing interface {
-	// SignDigest signs the given SHA256 message digest with the private key
-	// described in the key descriptor.
-	SignDigest(keyDesc KeyDescriptor, digest [32]byte) (*btcec.Signature,
-		error)
-
-	// SignDigestCompact signs the given SHA256 message digest with the
-	// private key described in the key descriptor and returns the signature
-	// in the compact, public key recoverable format.
-	SignDigestCompact(keyDesc KeyDescriptor, digest [32]byte) ([]byte, error)
+type MessageSignerRing interface {
+	// SignMessage signs the given message, single or double SHA256 hashing
+	// it first, with the private key described in the key locator.
+	SignMessage(keyLoc KeyLocator, msg []byte,
+		doubleHash bool) (*btcec.Signature, error)
+
+	// SignMessageCompact signs the given message, single or double SHA256
+	// hashing it first, with the private key described in the key locator
+	// and returns the signature in the compact, public key recoverable
+	// format.
+	SignMessageCompact(keyLoc KeyLocator, msg []byte,
+		doubleHash bool) ([]byte, error)
 }
 
-// SingleKeyDigestSigner is an abstraction interface that hides the
+// SingleKeyMessageSigner is an abstraction interface that hides the
 // implementation of the low-level ECDSA signing operations by wrapping a
 // single, specific private key.
-type SingleKeyDigestSigner interface {
+type SingleKeyMessageSigner interface {
 	// PubKey returns the public key of the wrapped private key.
 	PubKey() *btcec.PublicKey
 
-	// SignDigest signs the given SHA256 message digest with the wrapped
-	// private key.
-	SignDigest(digest [32]byte) (*btcec.Signature, error)
+	// SignMessage signs the given message, single or double SHA256 hashing
+	// it first, with the wrapped private key.
+	SignMessage(message []byte, doubleHash bool) (*btcec.Signature, error)
 
-	// SignDigestCompact signs the given SHA256 message digest with the
-	// wrapped private key and returns the signature in the compact, public
-	// key recoverable format.
-	SignDigestCompact(digest [32]byte) ([]byte, error)
+	// SignMessageCompact signs the given message, single or double SHA256
+	// hashing it first, with the wrapped private key and returns the
+	// signature in the compact, public key recoverable format.
+	SignMessageCompact(message []byte, doubleHash bool) ([]byte, error)
 }
 
 // ECDHRing is an interface that abstracts away basic low-level ECDH shared key
@@ -33,6 +33,7 @@ var versionZeroKeyFamilies = []KeyFamily{
 	KeyFamilyStaticBackup,
 	KeyFamilyTowerSession,
 	KeyFamilyTowerID,
+	KeyFamilyStatelessInvoice,
 }
 
 var (
@@ -1,56 +1,73 @@
 package keychain
 
-import "github.com/btcsuite/btcd/btcec"
-
-func NewPubKeyDigestSigner(keyDesc KeyDescriptor,
-	signer DigestSignerRing) *PubKeyDigestSigner {
-
-	return &PubKeyDigestSigner{
-		keyDesc:      keyDesc,
-		digestSigner: signer,
+import (
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+func NewPubKeyMessageSigner(pubKey *btcec.PublicKey, keyLoc KeyLocator,
+	signer MessageSignerRing) *PubKeyMessageSigner {
+
+	return &PubKeyMessageSigner{
+		pubKey:        pubKey,
+		keyLoc:        keyLoc,
+		messageSigner: signer,
 	}
 }
 
-type PubKeyDigestSigner struct {
-	keyDesc      KeyDescriptor
-	digestSigner DigestSignerRing
+type PubKeyMessageSigner struct {
+	pubKey        *btcec.PublicKey
+	keyLoc        KeyLocator
+	messageSigner MessageSignerRing
 }
 
-func (p *PubKeyDigestSigner) PubKey() *btcec.PublicKey {
-	return p.keyDesc.PubKey
+func (p *PubKeyMessageSigner) PubKey() *btcec.PublicKey {
+	return p.pubKey
 }
 
-func (p *PubKeyDigestSigner) SignDigest(digest [32]byte) (*btcec.Signature,
-	error) {
+func (p *PubKeyMessageSigner) SignMessage(message []byte,
+	doubleHash bool) (*btcec.Signature, error) {
 
-	return p.digestSigner.SignDigest(p.keyDesc, digest)
+	return p.messageSigner.SignMessage(p.keyLoc, message, doubleHash)
 }
 
-func (p *PubKeyDigestSigner) SignDigestCompact(digest [32]byte) ([]byte,
-	error) {
+func (p *PubKeyMessageSigner) SignMessageCompact(msg []byte,
+	doubleHash bool) ([]byte, error) {
 
-	return p.digestSigner.SignDigestCompact(p.keyDesc, digest)
+	return p.messageSigner.SignMessageCompact(p.keyLoc, msg, doubleHash)
 }
 
-type PrivKeyDigestSigner struct {
+type PrivKeyMessageSigner struct {
 	PrivKey *btcec.PrivateKey
 }
 
-func (p *PrivKeyDigestSigner) PubKey() *btcec.PublicKey {
+func (p *PrivKeyMessageSigner) PubKey() *btcec.PublicKey {
 	return p.PrivKey.PubKey()
 }
 
-func (p *PrivKeyDigestSigner) SignDigest(digest [32]byte) (*btcec.Signature,
-	error) {
+func (p *PrivKeyMessageSigner) SignMessage(msg []byte,
+	doubleHash bool) (*btcec.Signature, error) {
+
+	return p.PrivKey.Sign(messageDigest(msg, doubleHash))
+}
+
+func (p *PrivKeyMessageSigner) SignMessageCompact(msg []byte,
+	doubleHash bool) ([]byte, error) {
 
-	return p.PrivKey.Sign(digest[:])
+	return btcec.SignCompact(
+		btcec.S256(), p.PrivKey, messageDigest(msg, doubleHash), true,
+	)
 }
 
-func (p *PrivKeyDigestSigner) SignDigestCompact(digest [32]byte) ([]byte,
-	error) {
+// messageDigest returns the single or double SHA256 digest of the given
+// message.
+func messageDigest(msg []byte, doubleHash bool) []byte {
+	if doubleHash {
+		return chainhash.DoubleHashB(msg)
+	}
 
-	return btcec.SignCompact(btcec.S256(), p.PrivKey, digest[:], true)
+	return chainhash.HashB(msg)
 }
 
-var _ SingleKeyDigestSigner = (*PubKeyDigestSigner)(nil)
-var _ SingleKeyDigestSigner = (*PrivKeyDigestSigner)(nil)
+var _ SingleKeyMessageSigner = (*PubKeyMessageSigner)(nil)
+var _ SingleKeyMessageSigner = (*PrivKeyMessageSigner)(nil)
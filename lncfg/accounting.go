@@ -0,0 +1,24 @@
+package lncfg
+
+import "errors"
+
+// DefaultAccountingCurrency is the fiat currency that the prices of the
+// accounting price source are assumed to be in.
+const DefaultAccountingCurrency = "USD"
+
+// Accounting holds the configuration options for accounting reports.
+type Accounting struct {
+	PriceCSV string `long:"pricecsv" description:"The path to a CSV file with historical bitcoin prices that are used to value the entries of accounting reports in fiat. Each line holds a unix timestamp in seconds and the price of one bitcoin at that time, e.g. 1609459200,28990.08. The file is read anew for every report."`
+
+	Currency string `long:"currency" description:"The fiat currency of the prices in the price CSV file."`
+}
+
+// Validate checks that the accounting configuration is sane.
+func (a *Accounting) Validate() error {
+	if a.PriceCSV != "" && a.Currency == "" {
+		return errors.New("accounting.currency must be set if a " +
+			"price CSV file is configured")
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package lncfg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// ChannelFeeRateBounds holds the proportional fee bounds of a single channel,
+// in parts per million.
+type ChannelFeeRateBounds struct {
+	// MinFeeRate is the lowest proportional fee of the channel.
+	MinFeeRate uint32
+
+	// MaxFeeRate is the highest proportional fee of the channel.
+	MaxFeeRate uint32
+}
+
+// FeeAutopilot holds the configuration options for the fee autopilot, which
+// periodically adjusts the fees of our channels based on their liquidity and
+// forwarding volume.
+type FeeAutopilot struct {
+	Active bool `long:"active" description:"If true, lnd will periodically adjust the fees of its channels based on their local balance and recent forwarding volume."`
+
+	DryRun bool `long:"dry-run" description:"If true, the fee autopilot only reports the fee changes it would make instead of applying them. The proposed changes can be queried with the FeeAutopilotProposals RPC."`
+
+	Interval time.Duration `long:"interval" description:"The interval at which the fees of all channels are evaluated."`
+
+	ForwardingWindow time.Duration `long:"forwarding-window" description:"The period over which the forwarding volume of a channel is considered. Balanced channels that did not forward anything within this period have their fees lowered."`
+
+	MinUpdateInterval time.Duration `long:"min-update-interval" description:"The minimum time between two fee updates of the same channel, to avoid flooding the network with channel updates."`
+
+	LowLiquidityRatio float64 `long:"low-liquidity-ratio" description:"The ratio of local balance to capacity below which the fees of a channel are raised."`
+
+	HighLiquidityRatio float64 `long:"high-liquidity-ratio" description:"The ratio of local balance to capacity above which the fees of a channel are lowered."`
+
+	MinBaseFee uint64 `long:"min-base-fee" description:"The lowest base fee in millisatoshi that the fee autopilot will set."`
+
+	MaxBaseFee uint64 `long:"max-base-fee" description:"The highest base fee in millisatoshi that the fee autopilot will set."`
+
+	BaseFeeStep uint64 `long:"base-fee-step" description:"The amount in millisatoshi by which the base fee is raised or lowered in a single adjustment."`
+
+	MinFeeRate uint32 `long:"min-fee-rate" description:"The lowest proportional fee in parts per million that the fee autopilot will set."`
+
+	MaxFeeRate uint32 `long:"max-fee-rate" description:"The highest proportional fee in parts per million that the fee autopilot will set."`
+
+	FeeRateStep uint32 `long:"fee-rate-step" description:"The amount in parts per million by which the proportional fee is raised or lowered in a single adjustment."`
+
+	ChannelBoundsRaw []string `long:"channel-bounds" description:"Overrides the proportional fee bounds for a single channel, formatted as <chan_id>:<min_fee_rate>:<max_fee_rate>. Setting both bounds to the same value pins the fee rate of the channel. Can be specified multiple times."`
+
+	SkipChannelsRaw []uint64 `long:"skip-channel" description:"The short channel id of a channel whose fees should never be changed by the fee autopilot. Can be specified multiple times."`
+
+	// ChannelBounds holds the parsed per channel fee rate bounds.
+	ChannelBounds map[lnwire.ShortChannelID]ChannelFeeRateBounds
+
+	// SkipChannels holds the parsed set of skipped channels.
+	SkipChannels map[lnwire.ShortChannelID]struct{}
+}
+
+// DefaultFeeAutopilot returns the default fee autopilot options.
+func DefaultFeeAutopilot() *FeeAutopilot {
+	return &FeeAutopilot{
+		Interval:           time.Hour,
+		ForwardingWindow:   24 * time.Hour,
+		MinUpdateInterval:  6 * time.Hour,
+		LowLiquidityRatio:  0.2,
+		HighLiquidityRatio: 0.8,
+		MinBaseFee:         0,
+		MaxBaseFee:         1000,
+		BaseFeeStep:        100,
+		MinFeeRate:         1,
+		MaxFeeRate:         2500,
+		FeeRateStep:        25,
+	}
+}
+
+// Parse parses the per channel options of the fee autopilot.
+func (f *FeeAutopilot) Parse() error {
+	f.ChannelBounds = make(map[lnwire.ShortChannelID]ChannelFeeRateBounds)
+	for _, raw := range f.ChannelBoundsRaw {
+		parts := strings.Split(raw, ":")
+		if len(parts) != 3 {
+			return fmt.Errorf("invalid channel bounds %q, expected "+
+				"<chan_id>:<min_fee_rate>:<max_fee_rate>", raw)
+		}
+
+		chanID, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid channel id in channel "+
+				"bounds %q: %v", raw, err)
+		}
+		minFeeRate, err := strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid min fee rate in channel "+
+				"bounds %q: %v", raw, err)
+		}
+		maxFeeRate, err := strconv.ParseUint(parts[2], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid max fee rate in channel "+
+				"bounds %q: %v", raw, err)
+		}
+		if minFeeRate > maxFeeRate {
+			return fmt.Errorf("min fee rate exceeds max fee rate "+
+				"in channel bounds %q", raw)
+		}
+
+		scid := lnwire.NewShortChanIDFromInt(chanID)
+		f.ChannelBounds[scid] = ChannelFeeRateBounds{
+			MinFeeRate: uint32(minFeeRate),
+			MaxFeeRate: uint32(maxFeeRate),
+		}
+	}
+
+	f.SkipChannels = make(map[lnwire.ShortChannelID]struct{})
+	for _, chanID := range f.SkipChannelsRaw {
+		f.SkipChannels[lnwire.NewShortChanIDFromInt(chanID)] = struct{}{}
+	}
+
+	return nil
+}
+
+// Validate checks that the fee autopilot options are sane.
+func (f *FeeAutopilot) Validate() error {
+	if !f.Active {
+		return nil
+	}
+
+	if f.Interval <= 0 {
+		return fmt.Errorf("fee autopilot interval must be positive")
+	}
+
+	if f.ForwardingWindow <= 0 {
+		return fmt.Errorf("fee autopilot forwarding window must be " +
+			"positive")
+	}
+
+	if f.MinUpdateInterval <= 0 {
+		return fmt.Errorf("fee autopilot min update interval must be " +
+			"positive")
+	}
+
+	if f.BaseFeeStep == 0 {
+		return fmt.Errorf("fee autopilot base fee step must be " +
+			"positive")
+	}
+
+	if f.FeeRateStep == 0 {
+		return fmt.Errorf("fee autopilot fee rate step must be " +
+			"positive")
+	}
+
+	if f.LowLiquidityRatio < 0 || f.HighLiquidityRatio > 1 ||
+		f.LowLiquidityRatio >= f.HighLiquidityRatio {
+
+		return fmt.Errorf("fee autopilot liquidity ratios must "+
+			"satisfy 0 <= low < high <= 1, got low=%v high=%v",
+			f.LowLiquidityRatio, f.HighLiquidityRatio)
+	}
+
+	if f.MinBaseFee > f.MaxBaseFee {
+		return fmt.Errorf("fee autopilot min base fee %v exceeds max "+
+			"base fee %v", f.MinBaseFee, f.MaxBaseFee)
+	}
+
+	if f.MinFeeRate > f.MaxFeeRate {
+		return fmt.Errorf("fee autopilot min fee rate %v exceeds max "+
+			"fee rate %v", f.MinFeeRate, f.MaxFeeRate)
+	}
+
+	return nil
+}
+
+// Compile-time constraint to ensure FeeAutopilot implements the Validator
+// interface.
+var _ Validator = (*FeeAutopilot)(nil)
@@ -0,0 +1,86 @@
+package lncfg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lncfg"
+)
+
+// TestValidateFeeAutopilot asserts that validating the fee autopilot config
+// rejects non-positive intervals and step sizes as well as inverted bounds.
+func TestValidateFeeAutopilot(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *lncfg.FeeAutopilot)
+		valid  bool
+	}{
+		{
+			name:   "default valid",
+			modify: func(cfg *lncfg.FeeAutopilot) {},
+			valid:  true,
+		},
+		{
+			name: "inactive not validated",
+			modify: func(cfg *lncfg.FeeAutopilot) {
+				cfg.Active = false
+				cfg.Interval = 0
+			},
+			valid: true,
+		},
+		{
+			name: "zero interval invalid",
+			modify: func(cfg *lncfg.FeeAutopilot) {
+				cfg.Interval = 0
+			},
+		},
+		{
+			name: "negative forwarding window invalid",
+			modify: func(cfg *lncfg.FeeAutopilot) {
+				cfg.ForwardingWindow = -time.Hour
+			},
+		},
+		{
+			name: "zero min update interval invalid",
+			modify: func(cfg *lncfg.FeeAutopilot) {
+				cfg.MinUpdateInterval = 0
+			},
+		},
+		{
+			name: "zero base fee step invalid",
+			modify: func(cfg *lncfg.FeeAutopilot) {
+				cfg.BaseFeeStep = 0
+			},
+		},
+		{
+			name: "zero fee rate step invalid",
+			modify: func(cfg *lncfg.FeeAutopilot) {
+				cfg.FeeRateStep = 0
+			},
+		},
+		{
+			name: "inverted fee rate bounds invalid",
+			modify: func(cfg *lncfg.FeeAutopilot) {
+				cfg.MinFeeRate = cfg.MaxFeeRate + 1
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cfg := lncfg.DefaultFeeAutopilot()
+			cfg.Active = true
+			test.modify(cfg)
+
+			err := cfg.Validate()
+			switch {
+			case test.valid && err != nil:
+				t.Fatalf("valid config was invalid: %v", err)
+			case !test.valid && err == nil:
+				t.Fatalf("invalid config was valid")
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package lncfg
+
+import "fmt"
+
+// HtlcLimits holds the configuration options for the local policy that limits
+// how peers may use our channels for forwarding htlcs.
+type HtlcLimits struct {
+	PeerAddRate float64 `long:"peer-add-rate" description:"The sustained number of htlcs per second a single peer may ask us to forward across all of its channels. Set to 0 to disable the per-peer rate limit."`
+
+	PeerAddBurst int `long:"peer-add-burst" description:"The maximum number of htlcs a single peer may ask us to forward in a burst before the per-peer rate limit kicks in."`
+
+	ChannelAddRate float64 `long:"channel-add-rate" description:"The sustained number of htlcs per second a peer may ask us to forward over a single channel. Set to 0 to disable the per-channel rate limit."`
+
+	ChannelAddBurst int `long:"channel-add-burst" description:"The maximum number of htlcs a peer may ask us to forward over a single channel in a burst before the per-channel rate limit kicks in."`
+
+	ReservedSlotFraction float64 `long:"reserved-slot-fraction" description:"The fraction of each channel's outgoing htlc slots that forwarded htlcs may not use, keeping them available for our own payments. Valid values are within [0, 1)."`
+
+	ReservedValueFraction float64 `long:"reserved-value-fraction" description:"The fraction of each channel's outgoing max value in flight that forwarded htlcs may not use, keeping it available for our own payments. Valid values are within [0, 1)."`
+}
+
+// Validate checks that the htlc limits are sane.
+func (h *HtlcLimits) Validate() error {
+	if h.PeerAddRate < 0 {
+		return fmt.Errorf("peer add rate must be non-negative, got %v",
+			h.PeerAddRate)
+	}
+	if h.PeerAddRate > 0 && h.PeerAddBurst < 1 {
+		return fmt.Errorf("peer add burst must be at least 1 when "+
+			"the peer add rate is set, got %v", h.PeerAddBurst)
+	}
+
+	if h.ChannelAddRate < 0 {
+		return fmt.Errorf("channel add rate must be non-negative, "+
+			"got %v", h.ChannelAddRate)
+	}
+	if h.ChannelAddRate > 0 && h.ChannelAddBurst < 1 {
+		return fmt.Errorf("channel add burst must be at least 1 when "+
+			"the channel add rate is set, got %v",
+			h.ChannelAddBurst)
+	}
+
+	if h.ReservedSlotFraction < 0 || h.ReservedSlotFraction >= 1 {
+		return fmt.Errorf("reserved slot fraction must be within "+
+			"[0, 1), got %v", h.ReservedSlotFraction)
+	}
+	if h.ReservedValueFraction < 0 || h.ReservedValueFraction >= 1 {
+		return fmt.Errorf("reserved value fraction must be within "+
+			"[0, 1), got %v", h.ReservedValueFraction)
+	}
+
+	return nil
+}
+
+// Compile-time constraint to ensure HtlcLimits implements the Validator
+// interface.
+var _ Validator = (*HtlcLimits)(nil)
@@ -0,0 +1,77 @@
+package lncfg
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultLnurlInvoiceExpiry is the default expiry of the invoices that
+	// are created by the LNURL-pay server.
+	DefaultLnurlInvoiceExpiry = 10 * time.Minute
+
+	// DefaultLnurlMaxPendingInvoices is the default maximum number of
+	// invoices of the LNURL-pay server that haven't expired yet.
+	DefaultLnurlMaxPendingInvoices = 1000
+
+	// DefaultLnurlRateLimit is the default number of invoices per second
+	// that the LNURL-pay server creates at most.
+	DefaultLnurlRateLimit = 1.0
+
+	// DefaultLnurlRateBurst is the default number of invoices that the
+	// LNURL-pay server creates in a burst above its rate limit.
+	DefaultLnurlRateBurst = 10
+)
+
+// Lnurl holds the configuration options for the LNURL-pay server.
+type Lnurl struct {
+	Active bool `long:"active" description:"Serve the LNURL-pay and lightning address endpoints of the users configured through the LNURL RPCs. The endpoints are served on the REST listeners, unless lnurl.listen is set."`
+
+	Domain string `long:"domain" description:"The domain that the LNURL-pay endpoints are reachable at from the outside. The lightning addresses of the users are <username>@<domain>. A port can be appended, which is only useful for testing, as lightning addresses cannot carry a port."`
+
+	Listen string `long:"listen" description:"Serve the LNURL-pay endpoints on a dedicated interface/port instead of the REST listeners."`
+
+	TLSCertPath string `long:"tlscertpath" description:"Path to the TLS certificate of the dedicated LNURL-pay listener. Wallets only accept certificates that are signed by a public certificate authority. If not set, the listener serves plain HTTP, which is meant for a reverse proxy that terminates TLS or for onion services."`
+
+	TLSKeyPath string `long:"tlskeypath" description:"Path to the TLS private key of the dedicated LNURL-pay listener."`
+
+	InvoiceExpiry time.Duration `long:"invoiceexpiry" description:"The expiry of the invoices created by the LNURL-pay server. Expired invoices that weren't paid are deleted."`
+
+	MaxPendingInvoices int `long:"maxpendinginvoices" description:"The maximum number of invoices created by the LNURL-pay server that haven't expired yet. Further invoice requests are rejected until some of them expire."`
+
+	RateLimit float64 `long:"ratelimit" description:"The maximum number of invoices per second that the LNURL-pay server creates."`
+
+	RateBurst int `long:"rateburst" description:"The number of invoices that the LNURL-pay server creates in a burst above its rate limit."`
+}
+
+// Validate checks that the LNURL-pay configuration is sane.
+func (l *Lnurl) Validate() error {
+	if !l.Active {
+		return nil
+	}
+
+	switch {
+	case l.Domain == "":
+		return errors.New("lnurl.domain must be set if the LNURL-pay " +
+			"server is active")
+
+	case (l.TLSCertPath == "") != (l.TLSKeyPath == ""):
+		return errors.New("lnurl.tlscertpath and lnurl.tlskeypath " +
+			"must be set together")
+
+	case l.TLSCertPath != "" && l.Listen == "":
+		return errors.New("lnurl.tlscertpath requires lnurl.listen")
+
+	case l.InvoiceExpiry <= 0:
+		return errors.New("lnurl.invoiceexpiry must be positive")
+
+	case l.MaxPendingInvoices <= 0:
+		return errors.New("lnurl.maxpendinginvoices must be positive")
+
+	case l.RateLimit <= 0 || l.RateBurst <= 0:
+		return errors.New("lnurl.ratelimit and lnurl.rateburst must " +
+			"be positive")
+	}
+
+	return nil
+}
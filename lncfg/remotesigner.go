@@ -0,0 +1,50 @@
+package lncfg
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultRemoteSignerRPCTimeout is the default timeout of the RPC calls to
+// the remote signer.
+const DefaultRemoteSignerRPCTimeout = 5 * time.Second
+
+// RemoteSigner holds the configuration options for a remote RPC signer.
+type RemoteSigner struct {
+	Enable bool `long:"enable" description:"Use a remote signer for signing any on-chain related transactions or messages. Only recommended if local wallet is initialized as watch-only. Remote signer must use the same seed/root key as the local watch-only wallet but must have private keys."`
+
+	RPCHost string `long:"rpchost" description:"The remote signer's RPC host:port"`
+
+	MacaroonPath string `long:"macaroonpath" description:"The macaroon to use for authenticating with the remote signer"`
+
+	TLSCertPath string `long:"tlscertpath" description:"The TLS certificate to use for establishing the remote signer's identity"`
+
+	Timeout time.Duration `long:"timeout" description:"The timeout for connecting to and signing requests with the remote signer. Valid time units are {s, m, h}."`
+}
+
+// Validate checks the values configured for our remote RPC signer.
+func (r *RemoteSigner) Validate() error {
+	if !r.Enable {
+		return nil
+	}
+
+	switch {
+	case r.RPCHost == "":
+		return errors.New("remotesigner.rpchost must be set if the " +
+			"remote signer is enabled")
+
+	case r.MacaroonPath == "":
+		return errors.New("remotesigner.macaroonpath must be set if " +
+			"the remote signer is enabled")
+
+	case r.TLSCertPath == "":
+		return errors.New("remotesigner.tlscertpath must be set if " +
+			"the remote signer is enabled")
+
+	case r.Timeout < time.Millisecond:
+		return errors.New("remotesigner.timeout must be at least 1 " +
+			"millisecond")
+	}
+
+	return nil
+}
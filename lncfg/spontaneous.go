@@ -0,0 +1,21 @@
+package lncfg
+
+import "time"
+
+// Spontaneous holds the configuration options for the policy of spontaneous
+// keysend and AMP payments.
+type Spontaneous struct {
+	MinAmtMsat uint64 `long:"min-amt-msat" description:"The minimum amount in millisatoshis of spontaneous payments. For AMP payments, the total amount of the payment is checked."`
+
+	MaxAmtMsat uint64 `long:"max-amt-msat" description:"The maximum amount in millisatoshis of spontaneous payments. Zero means that there is no maximum."`
+
+	RestrictCustomRecords bool `long:"restrict-custom-records" description:"If true, spontaneous payments may only carry the custom records that are listed with allowed-custom-record. The keysend record is always allowed."`
+
+	AllowedCustomRecords []uint64 `long:"allowed-custom-record" description:"A custom record type that spontaneous payments may carry if restrict-custom-records is set. Can be specified multiple times."`
+
+	RateLimit uint32 `long:"rate-limit" description:"The maximum number of spontaneous payments that are accepted through a single source route within rate-limit-interval. Source routes are told apart by the peer that they reach us through, as onion routing hides all other hops. Zero disables rate limiting."`
+
+	RateLimitInterval time.Duration `long:"rate-limit-interval" description:"The interval that rate-limit applies to."`
+
+	InvoiceLabel string `long:"invoice-label" description:"The memo of the invoices that are created for spontaneous payments."`
+}
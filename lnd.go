@@ -23,6 +23,8 @@ import (
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/btcsuite/btcwallet/walletdb"
 	proxy "github.com/grpc-ecosystem/grpc-gateway/runtime"
@@ -44,8 +46,10 @@ import (
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lncfg"
 	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnurl"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/btcwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chanfunding"
 	"github.com/lightningnetwork/lnd/macaroons"
 	"github.com/lightningnetwork/lnd/monitoring"
 	"github.com/lightningnetwork/lnd/rpcperms"
@@ -384,6 +388,16 @@ func Main(cfg *Config, lisCfg ListenerCfg, interceptor signal.Interceptor) error
 	}
 	defer stopProxy()
 
+	// If the LNURL-pay server has a dedicated listener, we'll start
+	// serving its endpoints there.
+	if rpcServer.lnurlServer != nil && cfg.Lnurl.Listen != "" {
+		stopLnurl, err := startLnurlListener(cfg, rpcServer.lnurlServer)
+		if err != nil {
+			return err
+		}
+		defer stopLnurl()
+	}
+
 	// Start leader election if we're running on etcd. Continuation will be
 	// blocked until this instance is elected as the current leader or
 	// shutting down.
@@ -706,15 +720,22 @@ func Main(cfg *Config, lisCfg ListenerCfg, interceptor signal.Interceptor) error
 		LoaderOptions: []btcwallet.LoaderOption{
 			loaderOpt,
 		},
+		RemoteSigner: cfg.RemoteSigner,
 	}
 
 	// Parse coin selection strategy.
 	switch cfg.CoinSelectionStrategy {
 	case "largest":
-		chainControlCfg.CoinSelectionStrategy = wallet.CoinSelectionLargest
+		chainControlCfg.CoinSelectionStrategy =
+			chanfunding.CoinSelectionLargest
 
 	case "random":
-		chainControlCfg.CoinSelectionStrategy = wallet.CoinSelectionRandom
+		chainControlCfg.CoinSelectionStrategy =
+			chanfunding.CoinSelectionRandom
+
+	case "privacy":
+		chainControlCfg.CoinSelectionStrategy =
+			chanfunding.CoinSelectionPrivacy
 
 	default:
 		return fmt.Errorf("unknown coin selection strategy %v",
@@ -1446,6 +1467,12 @@ func startRestProxy(cfg *Config, rpcServer *rpcServer, restDialOpts []grpc.DialO
 		lnrpc.LndClientStreamingURIs,
 	)
 
+	// If the LNURL-pay server is active and doesn't have a dedicated
+	// listener, its endpoints are served next to the REST proxy.
+	if rpcServer.lnurlServer != nil && cfg.Lnurl.Listen == "" {
+		restHandler = rpcServer.lnurlServer.Handler(restHandler)
+	}
+
 	// Use a WaitGroup so we can be sure the instructions on how to input the
 	// password is the last thing to be printed to the console.
 	var wg sync.WaitGroup
@@ -1476,6 +1503,9 @@ func startRestProxy(cfg *Config, rpcServer *rpcServer, restDialOpts []grpc.DialO
 			// through the following chain:
 			// req ---> CORS handler --> WS proxy --->
 			//   REST proxy --> gRPC endpoint
+			//
+			// Requests to the LNURL-pay endpoints are passed from
+			// the CORS handler to the LNURL-pay server instead.
 			corsHandler := allowCORS(restHandler, cfg.RestCORS)
 
 			wg.Done()
@@ -1492,6 +1522,59 @@ func startRestProxy(cfg *Config, rpcServer *rpcServer, restDialOpts []grpc.DialO
 	return shutdown, nil
 }
 
+// startLnurlListener serves the endpoints of the LNURL-pay server on its
+// dedicated listener. If a TLS certificate is configured for the listener, it
+// serves https, otherwise plain http.
+func startLnurlListener(cfg *Config, lnurlServer *lnurl.Server) (func(),
+	error) {
+
+	lis, err := net.Listen("tcp", cfg.Lnurl.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen on %v for LNURL-pay "+
+			"requests: %v", cfg.Lnurl.Listen, err)
+	}
+
+	if cfg.Lnurl.TLSCertPath != "" {
+		certData, err := tls.LoadX509KeyPair(
+			cfg.Lnurl.TLSCertPath, cfg.Lnurl.TLSKeyPath,
+		)
+		if err != nil {
+			lis.Close()
+			return nil, fmt.Errorf("unable to load LNURL-pay TLS "+
+				"certificate: %v", err)
+		}
+
+		lis = tls.NewListener(lis, &tls.Config{
+			Certificates: []tls.Certificate{certData},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
+
+	// The listener is meant to be reachable from the internet, so requests
+	// that take too long are cut off.
+	httpServer := &http.Server{
+		Handler:      lnurlServer.Handler(http.NotFoundHandler()),
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	go func() {
+		rpcsLog.Infof("LNURL-pay server listening on %s", lis.Addr())
+
+		err := httpServer.Serve(lis)
+		if err != nil && err != http.ErrServerClosed {
+			rpcsLog.Errorf("LNURL-pay listener failed: %v", err)
+		}
+	}()
+
+	return func() {
+		if err := httpServer.Close(); err != nil {
+			rpcsLog.Errorf("Error closing LNURL-pay listener: %v",
+				err)
+		}
+	}, nil
+}
+
 // waitForWalletPassword blocks until a password is provided by the user to
 // this RPC server.
 func waitForWalletPassword(cfg *Config,
@@ -1518,11 +1601,23 @@ func waitForWalletPassword(cfg *Config,
 		cipherSeed := initMsg.WalletSeed
 		recoveryWindow := initMsg.RecoveryWindow
 
+		// A watch-only wallet can only be used with a remote signer
+		// and a remote signer requires a watch-only wallet, as the
+		// hot node must never hold the seed.
+		watchOnly := initMsg.WatchOnlyAccounts != nil
+		if watchOnly != cfg.RemoteSigner.Enable {
+			return nil, fmt.Errorf("a watch-only wallet must be "+
+				"created if and only if a remote signer is "+
+				"used (remotesigner.enable=%v)",
+				cfg.RemoteSigner.Enable)
+		}
+
 		// Before we proceed, we'll check the internal version of the
 		// seed. If it's greater than the current key derivation
 		// version, then we'll return an error as we don't understand
 		// this.
-		if cipherSeed.InternalVersion != keychain.KeyDerivationVersion {
+		keyVersion := uint8(keychain.KeyDerivationVersion)
+		if !watchOnly && cipherSeed.InternalVersion != keyVersion {
 			return nil, fmt.Errorf("invalid internal "+
 				"seed version %v, current version is %v",
 				cipherSeed.InternalVersion,
@@ -1539,10 +1634,27 @@ func waitForWalletPassword(cfg *Config,
 
 		// With the seed, we can now use the wallet loader to create
 		// the wallet, then pass it back to avoid unlocking it again.
-		birthday := cipherSeed.BirthdayTime()
-		newWallet, err := loader.CreateNewWallet(
-			password, password, cipherSeed.Entropy[:], birthday,
+		// A watch-only wallet is created from the imported account
+		// public keys instead.
+		var (
+			birthday  time.Time
+			newWallet *wallet.Wallet
 		)
+		if watchOnly {
+			birthday = initMsg.WatchOnlyBirthday
+			newWallet, err = createWatchOnlyWallet(
+				loader, password, birthday,
+				cfg.ActiveNetParams.CoinType,
+				initMsg.WatchOnlyMasterFingerprint,
+				initMsg.WatchOnlyAccounts,
+			)
+		} else {
+			birthday = cipherSeed.BirthdayTime()
+			newWallet, err = loader.CreateNewWallet(
+				password, password, cipherSeed.Entropy[:],
+				birthday,
+			)
+		}
 		if err != nil {
 			// Don't leave the file open in case the new wallet
 			// could not be created for whatever reason.
@@ -1584,6 +1696,19 @@ func waitForWalletPassword(cfg *Config,
 				"start of lnd")
 		}
 
+		// The wallet must have been created for the same signing mode
+		// that is configured now.
+		watchOnly := unlockMsg.Wallet.Manager.WatchOnly()
+		if watchOnly != cfg.RemoteSigner.Enable {
+			if err := unlockMsg.UnloadWallet(); err != nil {
+				ltndLog.Errorf("Could not unload wallet: %v",
+					err)
+			}
+			return nil, fmt.Errorf("wallet watch-only state (%v) "+
+				"doesn't match remotesigner.enable=%v",
+				watchOnly, cfg.RemoteSigner.Enable)
+		}
+
 		return &WalletUnlockParams{
 			Password:        unlockMsg.Passphrase,
 			RecoveryWindow:  unlockMsg.RecoveryWindow,
@@ -1600,6 +1725,36 @@ func waitForWalletPassword(cfg *Config,
 	}
 }
 
+// createWatchOnlyWallet creates a new watch-only wallet with the given loader
+// and imports the given account public keys into it.
+func createWatchOnlyWallet(loader *wallet.Loader, password []byte,
+	birthday time.Time, coinType, masterKeyFingerprint uint32,
+	accounts map[waddrmgr.ScopedIndex]*hdkeychain.ExtendedKey) (
+	*wallet.Wallet, error) {
+
+	// Validate the accounts first, so we don't leave a wallet behind that
+	// can't be used.
+	err := btcwallet.ValidateWatchOnlyAccounts(coinType, accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	newWallet, err := loader.CreateNewWatchingOnlyWallet(password, birthday)
+	if err != nil {
+		return nil, err
+	}
+
+	err = btcwallet.ImportWatchOnlyAccounts(
+		newWallet, coinType, masterKeyFingerprint, accounts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to import watch-only "+
+			"accounts: %v", err)
+	}
+
+	return newWallet, nil
+}
+
 // initializeDatabases extracts the current databases that we'll use for normal
 // operation in the daemon. Two databases are returned: one remote and one
 // local. However, only if the replicated database is active will the remote
@@ -10,12 +10,12 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
-	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/davecgh/go-spew/spew"
 
 	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/invoices"
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/netann"
@@ -31,6 +31,12 @@ const (
 	// DefaultAMPInvoiceExpiry is the default invoice expiry for new AMP
 	// invoices.
 	DefaultAMPInvoiceExpiry = 30 * 24 * time.Hour
+
+	// MaxPaymentMetadataSize is the maximum size of the payment metadata
+	// that can be included in an invoice. The metadata is echoed back in
+	// the final hop payload, so it needs to leave room for the other
+	// fields of the onion.
+	MaxPaymentMetadataSize = 512
 )
 
 // AddInvoiceConfig contains dependencies for invoice creation.
@@ -68,6 +74,12 @@ type AddInvoiceConfig struct {
 	// GenAmpInvoiceFeatures returns a feature containing feature bits that
 	// should be advertised on freshly generated AMP invoices.
 	GenAmpInvoiceFeatures func() *lnwire.FeatureVector
+
+	// GenStatelessPreimage derives the preimage of a stateless invoice
+	// from its payment address, amount and payment metadata. It is only
+	// required for creating stateless invoices.
+	GenStatelessPreimage func(payAddr [32]byte, amt lnwire.MilliSatoshi,
+		metadata []byte) (lntypes.Preimage, error)
 }
 
 // AddInvoiceData contains the required data to create a new invoice.
@@ -121,6 +133,31 @@ type AddInvoiceData struct {
 	// RouteHints are optional route hints that can each be individually used
 	// to assist in reaching the invoice's destination.
 	RouteHints [][]zpay32.HopHint
+
+	// Metadata is optional opaque data that is included in the payment
+	// request and echoed back by the payer in the final hop payload.
+	Metadata []byte
+
+	// Stateless signals whether or not to create a stateless invoice. The
+	// preimage of a stateless invoice is derived from its payment address,
+	// amount and metadata, and the invoice isn't stored in the database.
+	//
+	// NOTE: Preimage and Hash should always be set to nil when this value
+	// is true.
+	Stateless bool
+
+	// MaxPaymentAmt is the maximum amount a single payment to the invoice
+	// may pay. If zero, any overpayment is accepted.
+	MaxPaymentAmt lnwire.MilliSatoshi
+
+	// AcceptPartial signals that an incomplete mpp payment to the invoice
+	// should be accepted once the htlc hold duration expires.
+	AcceptPartial bool
+
+	// MaxAmtPaid, if non-zero, makes the invoice reusable until the total
+	// amount paid to it reaches this value. Only AMP invoices can be
+	// reusable.
+	MaxAmtPaid lnwire.MilliSatoshi
 }
 
 // paymentHashAndPreimage returns the payment hash and preimage for this invoice
@@ -213,13 +250,106 @@ func (d *AddInvoiceData) mppPaymentHashAndPreimage() (*lntypes.Preimage, lntypes
 	return paymentPreimage, paymentHash, nil
 }
 
+// statelessHashAndPreimage returns the payment hash and preimage to use
+// for a stateless invoice, which are derived from the payment address, amount
+// and payment metadata of the invoice.
+func (d *AddInvoiceData) statelessHashAndPreimage(cfg *AddInvoiceConfig,
+	payAddr [32]byte) (*lntypes.Preimage, lntypes.Hash, error) {
+
+	switch {
+	case d.Amp:
+		return nil, lntypes.Hash{},
+			errors.New("AMP invoices can't be stateless")
+
+	case d.Preimage != nil || d.Hash != nil:
+		return nil, lntypes.Hash{}, errors.New("preimage or hash set " +
+			"on stateless invoice")
+
+	case cfg.GenStatelessPreimage == nil:
+		return nil, lntypes.Hash{}, errors.New("stateless invoices " +
+			"not supported")
+	}
+
+	preimage, err := cfg.GenStatelessPreimage(payAddr, d.Value, d.Metadata)
+	if err != nil {
+		return nil, lntypes.Hash{}, err
+	}
+
+	return &preimage, preimage.Hash(), nil
+}
+
+// validateTolerances checks that the payment tolerances of the invoice are
+// consistent with its value and type.
+func (d *AddInvoiceData) validateTolerances() error {
+	hasTolerances := d.MaxPaymentAmt > 0 || d.AcceptPartial
+
+	switch {
+	case hasTolerances && d.Amp:
+		return errors.New("payment tolerances aren't supported for " +
+			"AMP invoices")
+
+	// Every payment to a reusable invoice needs its own payment hash and
+	// preimage, which only AMP provides.
+	case d.MaxAmtPaid > 0 && !d.Amp:
+		return errors.New("reusable invoices must be AMP invoices")
+
+	case hasTolerances && d.Stateless:
+		return errors.New("payment tolerances aren't supported for " +
+			"stateless invoices")
+
+	case d.MaxPaymentAmt > 0 && d.MaxPaymentAmt < d.Value:
+		return fmt.Errorf("max payment amount %v is below the "+
+			"invoice value %v", d.MaxPaymentAmt, d.Value)
+
+	case d.MaxAmtPaid > 0 && d.MaxAmtPaid < d.Value:
+		return fmt.Errorf("max amount paid %v is below the invoice "+
+			"value %v", d.MaxAmtPaid, d.Value)
+	}
+
+	return nil
+}
+
 // AddInvoice attempts to add a new invoice to the invoice database. Any
 // duplicated invoices are rejected, therefore all invoices *must* have a
 // unique payment preimage.
 func AddInvoice(ctx context.Context, cfg *AddInvoiceConfig,
 	invoice *AddInvoiceData) (*lntypes.Hash, *channeldb.Invoice, error) {
 
-	paymentPreimage, paymentHash, err := invoice.paymentHashAndPreimage()
+	creationDate := time.Now()
+
+	// Generate a random payment address for this invoice. If the sender
+	// understands payment addresses, this can be used to avoid
+	// intermediaries probing the receiver. The payment address of a
+	// stateless invoice also carries its expiry.
+	var (
+		paymentAddr [32]byte
+		err         error
+	)
+	if invoice.Stateless {
+		expiry := DefaultInvoiceExpiry
+		if invoice.Expiry > 0 {
+			expiry = time.Duration(invoice.Expiry) * time.Second
+		}
+		paymentAddr, err = invoices.StatelessPaymentAddr(
+			creationDate.Add(expiry),
+		)
+	} else {
+		_, err = rand.Read(paymentAddr[:])
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		paymentPreimage *lntypes.Preimage
+		paymentHash     lntypes.Hash
+	)
+	if invoice.Stateless {
+		paymentPreimage, paymentHash, err =
+			invoice.statelessHashAndPreimage(cfg, paymentAddr)
+	} else {
+		paymentPreimage, paymentHash, err = invoice.paymentHashAndPreimage()
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -253,6 +383,10 @@ func AddInvoice(ctx context.Context, cfg *AddInvoiceConfig,
 			maxInvoiceAmt)
 	}
 
+	if err := invoice.validateTolerances(); err != nil {
+		return nil, nil, err
+	}
+
 	amtMSat := invoice.Value
 
 	// We also create an encoded payment request which allows the
@@ -406,19 +540,26 @@ func AddInvoice(ctx context.Context, cfg *AddInvoiceConfig,
 	} else {
 		invoiceFeatures = cfg.GenInvoiceFeatures()
 	}
-	options = append(options, zpay32.Features(invoiceFeatures))
 
-	// Generate and set a random payment address for this invoice. If the
-	// sender understands payment addresses, this can be used to avoid
-	// intermediaries probing the receiver.
-	var paymentAddr [32]byte
-	if _, err := rand.Read(paymentAddr[:]); err != nil {
-		return nil, nil, err
+	// If the invoice carries payment metadata, we'll signal it in the
+	// invoice features so that the payer knows it needs to include it in
+	// the final hop payload.
+	if len(invoice.Metadata) > 0 {
+		if len(invoice.Metadata) > MaxPaymentMetadataSize {
+			return nil, nil, fmt.Errorf("payment metadata too "+
+				"large: max length is %v, got %v",
+				MaxPaymentMetadataSize, len(invoice.Metadata))
+		}
+
+		invoiceFeatures = invoiceFeatures.Clone()
+		invoiceFeatures.Set(lnwire.PaymentMetadataOptional)
+
+		options = append(options, zpay32.Metadata(invoice.Metadata))
 	}
+	options = append(options, zpay32.Features(invoiceFeatures))
 	options = append(options, zpay32.PaymentAddr(paymentAddr))
 
 	// Create and encode the payment request as a bech32 (zpay32) string.
-	creationDate := time.Now()
 	payReq, err := zpay32.NewInvoice(
 		cfg.ChainParams, paymentHash, creationDate, options...,
 	)
@@ -429,8 +570,9 @@ func AddInvoice(ctx context.Context, cfg *AddInvoiceConfig,
 	payReqString, err := payReq.Encode(
 		zpay32.MessageSigner{
 			SignCompact: func(msg []byte) ([]byte, error) {
-				hash := chainhash.HashB(msg)
-				return cfg.NodeSigner.SignDigestCompact(hash)
+				return cfg.NodeSigner.SignMessageCompact(
+					msg, false,
+				)
 			},
 		},
 	)
@@ -449,10 +591,19 @@ func AddInvoice(ctx context.Context, cfg *AddInvoiceConfig,
 			PaymentPreimage: paymentPreimage,
 			PaymentAddr:     paymentAddr,
 			Features:        invoiceFeatures,
+			MaxPaymentAmt:   invoice.MaxPaymentAmt,
+			AcceptPartial:   invoice.AcceptPartial,
+			MaxAmtPaid:      invoice.MaxAmtPaid,
 		},
 		HodlInvoice: invoice.HodlInvoice,
 	}
 
+	// Stateless invoices aren't stored, they are only inserted once they
+	// are paid.
+	if invoice.Stateless {
+		return &paymentHash, newInvoice, nil
+	}
+
 	log.Tracef("[addinvoice] adding new invoice %v",
 		newLogClosure(func() string {
 			return spew.Sdump(newInvoice)
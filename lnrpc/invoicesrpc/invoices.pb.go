@@ -156,6 +156,15 @@ type AddHoldInvoiceRequest struct {
 	RouteHints []*lnrpc.RouteHint `protobuf:"bytes,8,rep,name=route_hints,json=routeHints,proto3" json:"route_hints,omitempty"`
 	// Whether this invoice should include routing hints for private channels.
 	Private bool `protobuf:"varint,9,opt,name=private,proto3" json:"private,omitempty"`
+	//
+	//The maximum amount in millisatoshis that a single payment to this invoice
+	//may pay, which caps the overpayment of the invoice. If zero, payments of
+	//any amount above the invoice value are accepted.
+	MaxPaymentMsat uint64 `protobuf:"varint,11,opt,name=max_payment_msat,json=maxPaymentMsat,proto3" json:"max_payment_msat,omitempty"`
+	//
+	//If set, an incomplete multi-part payment to this invoice is accepted once
+	//the htlc hold duration expires, rather than canceled.
+	AcceptPartial bool `protobuf:"varint,12,opt,name=accept_partial,json=acceptPartial,proto3" json:"accept_partial,omitempty"`
 }
 
 func (x *AddHoldInvoiceRequest) Reset() {
@@ -260,6 +269,20 @@ func (x *AddHoldInvoiceRequest) GetPrivate() bool {
 	return false
 }
 
+func (x *AddHoldInvoiceRequest) GetMaxPaymentMsat() uint64 {
+	if x != nil {
+		return x.MaxPaymentMsat
+	}
+	return 0
+}
+
+func (x *AddHoldInvoiceRequest) GetAcceptPartial() bool {
+	if x != nil {
+		return x.AcceptPartial
+	}
+	return false
+}
+
 type AddHoldInvoiceResp struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -336,8 +359,560 @@ func (x *SettleInvoiceMsg) String() string {
 
 func (*SettleInvoiceMsg) ProtoMessage() {}
 
-func (x *SettleInvoiceMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_invoicesrpc_invoices_proto_msgTypes[4]
+func (x *SettleInvoiceMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SettleInvoiceMsg.ProtoReflect.Descriptor instead.
+func (*SettleInvoiceMsg) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SettleInvoiceMsg) GetPreimage() []byte {
+	if x != nil {
+		return x.Preimage
+	}
+	return nil
+}
+
+type SettleInvoiceResp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SettleInvoiceResp) Reset() {
+	*x = SettleInvoiceResp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_invoicesrpc_invoices_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SettleInvoiceResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SettleInvoiceResp) ProtoMessage() {}
+
+func (x *SettleInvoiceResp) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SettleInvoiceResp.ProtoReflect.Descriptor instead.
+func (*SettleInvoiceResp) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{5}
+}
+
+type AddStatelessInvoiceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	//
+	//An optional memo to attach along with the invoice. It will be set in the
+	//description field of the encoded payment request if the description_hash
+	//field is not being used.
+	Memo string `protobuf:"bytes,1,opt,name=memo,proto3" json:"memo,omitempty"`
+	//
+	//The value of this invoice in satoshis. Stateless invoices must have a
+	//value, as it is committed to in the preimage.
+	//
+	//The fields value and value_msat are mutually exclusive.
+	Value int64 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	//
+	//The value of this invoice in millisatoshis.
+	//
+	//The fields value and value_msat are mutually exclusive.
+	ValueMsat int64 `protobuf:"varint,3,opt,name=value_msat,json=valueMsat,proto3" json:"value_msat,omitempty"`
+	//
+	//Hash (SHA-256) of a description of the payment. Used if the description of
+	//payment (memo) is too long to naturally fit within the description field
+	//of an encoded payment request.
+	DescriptionHash []byte `protobuf:"bytes,4,opt,name=description_hash,json=descriptionHash,proto3" json:"description_hash,omitempty"`
+	// Payment request expiry time in seconds. Default is 86400 (24 hours).
+	Expiry int64 `protobuf:"varint,5,opt,name=expiry,proto3" json:"expiry,omitempty"`
+	// Fallback on-chain address.
+	FallbackAddr string `protobuf:"bytes,6,opt,name=fallback_addr,json=fallbackAddr,proto3" json:"fallback_addr,omitempty"`
+	// Delta to use for the time-lock of the CLTV extended to the final hop.
+	CltvExpiry uint64 `protobuf:"varint,7,opt,name=cltv_expiry,json=cltvExpiry,proto3" json:"cltv_expiry,omitempty"`
+	//
+	//Route hints that can each be individually used to assist in reaching the
+	//invoice's destination.
+	RouteHints []*lnrpc.RouteHint `protobuf:"bytes,8,rep,name=route_hints,json=routeHints,proto3" json:"route_hints,omitempty"`
+	// Whether this invoice should include routing hints for private channels.
+	Private bool `protobuf:"varint,9,opt,name=private,proto3" json:"private,omitempty"`
+	//
+	//Opaque payment metadata that is included in the payment request and echoed
+	//back by the payer. It can be used to reconstruct the context of the payment
+	//once it arrives.
+	PaymentMetadata []byte `protobuf:"bytes,10,opt,name=payment_metadata,json=paymentMetadata,proto3" json:"payment_metadata,omitempty"`
+}
+
+func (x *AddStatelessInvoiceRequest) Reset() {
+	*x = AddStatelessInvoiceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_invoicesrpc_invoices_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddStatelessInvoiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddStatelessInvoiceRequest) ProtoMessage() {}
+
+func (x *AddStatelessInvoiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddStatelessInvoiceRequest.ProtoReflect.Descriptor instead.
+func (*AddStatelessInvoiceRequest) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AddStatelessInvoiceRequest) GetMemo() string {
+	if x != nil {
+		return x.Memo
+	}
+	return ""
+}
+
+func (x *AddStatelessInvoiceRequest) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *AddStatelessInvoiceRequest) GetValueMsat() int64 {
+	if x != nil {
+		return x.ValueMsat
+	}
+	return 0
+}
+
+func (x *AddStatelessInvoiceRequest) GetDescriptionHash() []byte {
+	if x != nil {
+		return x.DescriptionHash
+	}
+	return nil
+}
+
+func (x *AddStatelessInvoiceRequest) GetExpiry() int64 {
+	if x != nil {
+		return x.Expiry
+	}
+	return 0
+}
+
+func (x *AddStatelessInvoiceRequest) GetFallbackAddr() string {
+	if x != nil {
+		return x.FallbackAddr
+	}
+	return ""
+}
+
+func (x *AddStatelessInvoiceRequest) GetCltvExpiry() uint64 {
+	if x != nil {
+		return x.CltvExpiry
+	}
+	return 0
+}
+
+func (x *AddStatelessInvoiceRequest) GetRouteHints() []*lnrpc.RouteHint {
+	if x != nil {
+		return x.RouteHints
+	}
+	return nil
+}
+
+func (x *AddStatelessInvoiceRequest) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+func (x *AddStatelessInvoiceRequest) GetPaymentMetadata() []byte {
+	if x != nil {
+		return x.PaymentMetadata
+	}
+	return nil
+}
+
+type AddStatelessInvoiceResp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The encoded payment request of the stateless invoice.
+	PaymentRequest string `protobuf:"bytes,1,opt,name=payment_request,json=paymentRequest,proto3" json:"payment_request,omitempty"`
+	// The payment hash of the stateless invoice.
+	RHash []byte `protobuf:"bytes,2,opt,name=r_hash,json=rHash,proto3" json:"r_hash,omitempty"`
+	// The payment address of the stateless invoice.
+	PaymentAddr []byte `protobuf:"bytes,3,opt,name=payment_addr,json=paymentAddr,proto3" json:"payment_addr,omitempty"`
+}
+
+func (x *AddStatelessInvoiceResp) Reset() {
+	*x = AddStatelessInvoiceResp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_invoicesrpc_invoices_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddStatelessInvoiceResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddStatelessInvoiceResp) ProtoMessage() {}
+
+func (x *AddStatelessInvoiceResp) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddStatelessInvoiceResp.ProtoReflect.Descriptor instead.
+func (*AddStatelessInvoiceResp) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AddStatelessInvoiceResp) GetPaymentRequest() string {
+	if x != nil {
+		return x.PaymentRequest
+	}
+	return ""
+}
+
+func (x *AddStatelessInvoiceResp) GetRHash() []byte {
+	if x != nil {
+		return x.RHash
+	}
+	return nil
+}
+
+func (x *AddStatelessInvoiceResp) GetPaymentAddr() []byte {
+	if x != nil {
+		return x.PaymentAddr
+	}
+	return nil
+}
+
+type StatelessInvoiceAcceptRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The payment hash of the stateless invoice.
+	RHash []byte `protobuf:"bytes,1,opt,name=r_hash,json=rHash,proto3" json:"r_hash,omitempty"`
+	// The payment address of the stateless invoice.
+	PaymentAddr []byte `protobuf:"bytes,2,opt,name=payment_addr,json=paymentAddr,proto3" json:"payment_addr,omitempty"`
+	// The payment metadata of the stateless invoice, as echoed by the payer.
+	PaymentMetadata []byte `protobuf:"bytes,3,opt,name=payment_metadata,json=paymentMetadata,proto3" json:"payment_metadata,omitempty"`
+	// The value of the stateless invoice in millisatoshis.
+	ValueMsat uint64 `protobuf:"varint,4,opt,name=value_msat,json=valueMsat,proto3" json:"value_msat,omitempty"`
+	// The total amount of the accepted htlcs in millisatoshis.
+	AmtPaidMsat uint64 `protobuf:"varint,5,opt,name=amt_paid_msat,json=amtPaidMsat,proto3" json:"amt_paid_msat,omitempty"`
+}
+
+func (x *StatelessInvoiceAcceptRequest) Reset() {
+	*x = StatelessInvoiceAcceptRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_invoicesrpc_invoices_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatelessInvoiceAcceptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatelessInvoiceAcceptRequest) ProtoMessage() {}
+
+func (x *StatelessInvoiceAcceptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatelessInvoiceAcceptRequest.ProtoReflect.Descriptor instead.
+func (*StatelessInvoiceAcceptRequest) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StatelessInvoiceAcceptRequest) GetRHash() []byte {
+	if x != nil {
+		return x.RHash
+	}
+	return nil
+}
+
+func (x *StatelessInvoiceAcceptRequest) GetPaymentAddr() []byte {
+	if x != nil {
+		return x.PaymentAddr
+	}
+	return nil
+}
+
+func (x *StatelessInvoiceAcceptRequest) GetPaymentMetadata() []byte {
+	if x != nil {
+		return x.PaymentMetadata
+	}
+	return nil
+}
+
+func (x *StatelessInvoiceAcceptRequest) GetValueMsat() uint64 {
+	if x != nil {
+		return x.ValueMsat
+	}
+	return 0
+}
+
+func (x *StatelessInvoiceAcceptRequest) GetAmtPaidMsat() uint64 {
+	if x != nil {
+		return x.AmtPaidMsat
+	}
+	return 0
+}
+
+type StatelessInvoiceAcceptResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The payment hash of the stateless invoice to resolve.
+	RHash []byte `protobuf:"bytes,1,opt,name=r_hash,json=rHash,proto3" json:"r_hash,omitempty"`
+	//
+	//Whether to settle the payment. If false, the payment is canceled back to
+	//the payer.
+	Approve bool `protobuf:"varint,2,opt,name=approve,proto3" json:"approve,omitempty"`
+}
+
+func (x *StatelessInvoiceAcceptResponse) Reset() {
+	*x = StatelessInvoiceAcceptResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_invoicesrpc_invoices_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatelessInvoiceAcceptResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatelessInvoiceAcceptResponse) ProtoMessage() {}
+
+func (x *StatelessInvoiceAcceptResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatelessInvoiceAcceptResponse.ProtoReflect.Descriptor instead.
+func (*StatelessInvoiceAcceptResponse) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StatelessInvoiceAcceptResponse) GetRHash() []byte {
+	if x != nil {
+		return x.RHash
+	}
+	return nil
+}
+
+func (x *StatelessInvoiceAcceptResponse) GetApprove() bool {
+	if x != nil {
+		return x.Approve
+	}
+	return false
+}
+
+type SubscribeSingleInvoiceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Hash corresponding to the (hold) invoice to subscribe to.
+	RHash []byte `protobuf:"bytes,2,opt,name=r_hash,json=rHash,proto3" json:"r_hash,omitempty"`
+}
+
+func (x *SubscribeSingleInvoiceRequest) Reset() {
+	*x = SubscribeSingleInvoiceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_invoicesrpc_invoices_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscribeSingleInvoiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeSingleInvoiceRequest) ProtoMessage() {}
+
+func (x *SubscribeSingleInvoiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeSingleInvoiceRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeSingleInvoiceRequest) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SubscribeSingleInvoiceRequest) GetRHash() []byte {
+	if x != nil {
+		return x.RHash
+	}
+	return nil
+}
+
+type GetSpontaneousPolicyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetSpontaneousPolicyRequest) Reset() {
+	*x = GetSpontaneousPolicyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_invoicesrpc_invoices_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSpontaneousPolicyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSpontaneousPolicyRequest) ProtoMessage() {}
+
+func (x *GetSpontaneousPolicyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSpontaneousPolicyRequest.ProtoReflect.Descriptor instead.
+func (*GetSpontaneousPolicyRequest) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{11}
+}
+
+type SpontaneousPolicy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	//
+	//The minimum amount in millisatoshis of spontaneous payments. For AMP
+	//payments, the total amount of the payment is checked.
+	MinAmtMsat uint64 `protobuf:"varint,1,opt,name=min_amt_msat,json=minAmtMsat,proto3" json:"min_amt_msat,omitempty"`
+	//
+	//The maximum amount in millisatoshis of spontaneous payments. Zero means
+	//that there is no maximum.
+	MaxAmtMsat uint64 `protobuf:"varint,2,opt,name=max_amt_msat,json=maxAmtMsat,proto3" json:"max_amt_msat,omitempty"`
+	//
+	//Whether spontaneous payments may only carry the custom records that are
+	//listed in allowed_custom_records. The keysend record is always allowed.
+	RestrictCustomRecords bool `protobuf:"varint,3,opt,name=restrict_custom_records,json=restrictCustomRecords,proto3" json:"restrict_custom_records,omitempty"`
+	//
+	//The custom record types that spontaneous payments may carry if
+	//restrict_custom_records is set.
+	AllowedCustomRecords []uint64 `protobuf:"varint,4,rep,packed,name=allowed_custom_records,json=allowedCustomRecords,proto3" json:"allowed_custom_records,omitempty"`
+	//
+	//The maximum number of spontaneous payments that are accepted through a
+	//single source route within rate_limit_interval_sec. Source routes are told
+	//apart by the peer that they reach us through, as onion routing hides all
+	//other hops. Zero disables rate limiting.
+	RateLimit uint32 `protobuf:"varint,5,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	// The interval in seconds that the rate limit applies to.
+	RateLimitIntervalSec uint64 `protobuf:"varint,6,opt,name=rate_limit_interval_sec,json=rateLimitIntervalSec,proto3" json:"rate_limit_interval_sec,omitempty"`
+	// The memo of the invoices that are created for spontaneous payments.
+	InvoiceLabel string `protobuf:"bytes,7,opt,name=invoice_label,json=invoiceLabel,proto3" json:"invoice_label,omitempty"`
+}
+
+func (x *SpontaneousPolicy) Reset() {
+	*x = SpontaneousPolicy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_invoicesrpc_invoices_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpontaneousPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpontaneousPolicy) ProtoMessage() {}
+
+func (x *SpontaneousPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -348,82 +923,83 @@ func (x *SettleInvoiceMsg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SettleInvoiceMsg.ProtoReflect.Descriptor instead.
-func (*SettleInvoiceMsg) Descriptor() ([]byte, []int) {
-	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use SpontaneousPolicy.ProtoReflect.Descriptor instead.
+func (*SpontaneousPolicy) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *SettleInvoiceMsg) GetPreimage() []byte {
+func (x *SpontaneousPolicy) GetMinAmtMsat() uint64 {
 	if x != nil {
-		return x.Preimage
+		return x.MinAmtMsat
 	}
-	return nil
+	return 0
 }
 
-type SettleInvoiceResp struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
+func (x *SpontaneousPolicy) GetMaxAmtMsat() uint64 {
+	if x != nil {
+		return x.MaxAmtMsat
+	}
+	return 0
 }
 
-func (x *SettleInvoiceResp) Reset() {
-	*x = SettleInvoiceResp{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_invoicesrpc_invoices_proto_msgTypes[5]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *SpontaneousPolicy) GetRestrictCustomRecords() bool {
+	if x != nil {
+		return x.RestrictCustomRecords
 	}
+	return false
 }
 
-func (x *SettleInvoiceResp) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *SpontaneousPolicy) GetAllowedCustomRecords() []uint64 {
+	if x != nil {
+		return x.AllowedCustomRecords
+	}
+	return nil
 }
 
-func (*SettleInvoiceResp) ProtoMessage() {}
+func (x *SpontaneousPolicy) GetRateLimit() uint32 {
+	if x != nil {
+		return x.RateLimit
+	}
+	return 0
+}
 
-func (x *SettleInvoiceResp) ProtoReflect() protoreflect.Message {
-	mi := &file_invoicesrpc_invoices_proto_msgTypes[5]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *SpontaneousPolicy) GetRateLimitIntervalSec() uint64 {
+	if x != nil {
+		return x.RateLimitIntervalSec
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use SettleInvoiceResp.ProtoReflect.Descriptor instead.
-func (*SettleInvoiceResp) Descriptor() ([]byte, []int) {
-	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{5}
+func (x *SpontaneousPolicy) GetInvoiceLabel() string {
+	if x != nil {
+		return x.InvoiceLabel
+	}
+	return ""
 }
 
-type SubscribeSingleInvoiceRequest struct {
+type SetSpontaneousPolicyResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
-
-	// Hash corresponding to the (hold) invoice to subscribe to.
-	RHash []byte `protobuf:"bytes,2,opt,name=r_hash,json=rHash,proto3" json:"r_hash,omitempty"`
 }
 
-func (x *SubscribeSingleInvoiceRequest) Reset() {
-	*x = SubscribeSingleInvoiceRequest{}
+func (x *SetSpontaneousPolicyResponse) Reset() {
+	*x = SetSpontaneousPolicyResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_invoicesrpc_invoices_proto_msgTypes[6]
+		mi := &file_invoicesrpc_invoices_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SubscribeSingleInvoiceRequest) String() string {
+func (x *SetSpontaneousPolicyResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubscribeSingleInvoiceRequest) ProtoMessage() {}
+func (*SetSpontaneousPolicyResponse) ProtoMessage() {}
 
-func (x *SubscribeSingleInvoiceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_invoicesrpc_invoices_proto_msgTypes[6]
+func (x *SetSpontaneousPolicyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_invoicesrpc_invoices_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -434,16 +1010,9 @@ func (x *SubscribeSingleInvoiceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubscribeSingleInvoiceRequest.ProtoReflect.Descriptor instead.
-func (*SubscribeSingleInvoiceRequest) Descriptor() ([]byte, []int) {
-	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{6}
-}
-
-func (x *SubscribeSingleInvoiceRequest) GetRHash() []byte {
-	if x != nil {
-		return x.RHash
-	}
-	return nil
+// Deprecated: Use SetSpontaneousPolicyResponse.ProtoReflect.Descriptor instead.
+func (*SetSpontaneousPolicyResponse) Descriptor() ([]byte, []int) {
+	return file_invoicesrpc_invoices_proto_rawDescGZIP(), []int{13}
 }
 
 var File_invoicesrpc_invoices_proto protoreflect.FileDescriptor
@@ -457,7 +1026,7 @@ var file_invoicesrpc_invoices_proto_rawDesc = []byte{
 	0x65, 0x6e, 0x74, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b,
 	0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x61, 0x73, 0x68, 0x22, 0x13, 0x0a, 0x11, 0x43,
 	0x61, 0x6e, 0x63, 0x65, 0x6c, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70,
-	0x22, 0xca, 0x02, 0x0a, 0x15, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64, 0x49, 0x6e, 0x76, 0x6f,
+	0x22, 0xa1, 0x03, 0x0a, 0x15, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64, 0x49, 0x6e, 0x76, 0x6f,
 	0x69, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x65,
 	0x6d, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x65, 0x6d, 0x6f, 0x12, 0x12,
 	0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61,
@@ -477,45 +1046,149 @@ var file_invoicesrpc_invoices_proto_rawDesc = []byte{
 	0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6c, 0x6e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x6f,
 	0x75, 0x74, 0x65, 0x48, 0x69, 0x6e, 0x74, 0x52, 0x0a, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x48, 0x69,
 	0x6e, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x18, 0x09,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x22, 0x3d, 0x0a,
-	0x12, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52,
-	0x65, 0x73, 0x70, 0x12, 0x27, 0x0a, 0x0f, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x72,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x61,
-	0x79, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2e, 0x0a, 0x10,
-	0x53, 0x65, 0x74, 0x74, 0x6c, 0x65, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x4d, 0x73, 0x67,
-	0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x22, 0x13, 0x0a, 0x11,
-	0x53, 0x65, 0x74, 0x74, 0x6c, 0x65, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73,
-	0x70, 0x22, 0x3c, 0x0a, 0x1d, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x53, 0x69,
-	0x6e, 0x67, 0x6c, 0x65, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x72, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x05, 0x72, 0x48, 0x61, 0x73, 0x68, 0x4a, 0x04, 0x08, 0x01, 0x10, 0x02, 0x32,
-	0xd9, 0x02, 0x0a, 0x08, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x12, 0x56, 0x0a, 0x16,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x12, 0x28, 0x0a,
+	0x10, 0x6d, 0x61, 0x78, 0x5f, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x6d, 0x73, 0x61,
+	0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x6d, 0x61, 0x78, 0x50, 0x61, 0x79, 0x6d,
+	0x65, 0x6e, 0x74, 0x4d, 0x73, 0x61, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x63, 0x63, 0x65, 0x70,
+	0x74, 0x5f, 0x70, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0d, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x50, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x4a, 0x04,
+	0x08, 0x0d, 0x10, 0x0e, 0x22, 0x3d, 0x0a, 0x12, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64, 0x49,
+	0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x12, 0x27, 0x0a, 0x0f, 0x70, 0x61,
+	0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x2e, 0x0a, 0x10, 0x53, 0x65, 0x74, 0x74, 0x6c, 0x65, 0x49, 0x6e, 0x76,
+	0x6f, 0x69, 0x63, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x69, 0x6d,
+	0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x69, 0x6d,
+	0x61, 0x67, 0x65, 0x22, 0x13, 0x0a, 0x11, 0x53, 0x65, 0x74, 0x74, 0x6c, 0x65, 0x49, 0x6e, 0x76,
+	0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x22, 0xe6, 0x02, 0x0a, 0x1a, 0x41, 0x64, 0x64,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x6c, 0x65, 0x73, 0x73, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x65, 0x6d, 0x6f, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x65, 0x6d, 0x6f, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x5f, 0x6d, 0x73, 0x61, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x4d, 0x73, 0x61, 0x74,
+	0x12, 0x29, 0x0a, 0x10, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x68, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0f, 0x64, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x61, 0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x79, 0x12, 0x23, 0x0a, 0x0d, 0x66, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f,
+	0x61, 0x64, 0x64, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x66, 0x61, 0x6c, 0x6c,
+	0x62, 0x61, 0x63, 0x6b, 0x41, 0x64, 0x64, 0x72, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6c, 0x74, 0x76,
+	0x5f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x63,
+	0x6c, 0x74, 0x76, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x12, 0x31, 0x0a, 0x0b, 0x72, 0x6f, 0x75,
+	0x74, 0x65, 0x5f, 0x68, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x6c, 0x6e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x48, 0x69, 0x6e, 0x74,
+	0x52, 0x0a, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x48, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07,
+	0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70,
+	0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e,
+	0x74, 0x5f, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x0f, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x22, 0x7c, 0x0a, 0x17, 0x41, 0x64, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x6c, 0x65, 0x73,
+	0x73, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x12, 0x27, 0x0a, 0x0f,
+	0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x72, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x72, 0x48, 0x61, 0x73, 0x68, 0x12, 0x21, 0x0a, 0x0c,
+	0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x0b, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x41, 0x64, 0x64, 0x72, 0x22,
+	0xc7, 0x01, 0x0a, 0x1d, 0x53, 0x74, 0x61, 0x74, 0x65, 0x6c, 0x65, 0x73, 0x73, 0x49, 0x6e, 0x76,
+	0x6f, 0x69, 0x63, 0x65, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x15, 0x0a, 0x06, 0x72, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x05, 0x72, 0x48, 0x61, 0x73, 0x68, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x61, 0x79, 0x6d,
+	0x65, 0x6e, 0x74, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b,
+	0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x41, 0x64, 0x64, 0x72, 0x12, 0x29, 0x0a, 0x10, 0x70,
+	0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0f, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1d, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x5f,
+	0x6d, 0x73, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x4d, 0x73, 0x61, 0x74, 0x12, 0x22, 0x0a, 0x0d, 0x61, 0x6d, 0x74, 0x5f, 0x70, 0x61, 0x69,
+	0x64, 0x5f, 0x6d, 0x73, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x61, 0x6d,
+	0x74, 0x50, 0x61, 0x69, 0x64, 0x4d, 0x73, 0x61, 0x74, 0x22, 0x51, 0x0a, 0x1e, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x6c, 0x65, 0x73, 0x73, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x41, 0x63, 0x63,
+	0x65, 0x70, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x15, 0x0a, 0x06, 0x72,
+	0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x72, 0x48, 0x61,
+	0x73, 0x68, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x07, 0x61, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x22, 0x3c, 0x0a, 0x1d,
 	0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x53, 0x69, 0x6e, 0x67, 0x6c, 0x65, 0x49,
-	0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x12, 0x2a, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65,
-	0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x53, 0x69,
-	0x6e, 0x67, 0x6c, 0x65, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x6c, 0x6e, 0x72, 0x70, 0x63, 0x2e, 0x49, 0x6e, 0x76, 0x6f, 0x69,
-	0x63, 0x65, 0x30, 0x01, 0x12, 0x4e, 0x0a, 0x0d, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x49, 0x6e,
-	0x76, 0x6f, 0x69, 0x63, 0x65, 0x12, 0x1d, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73,
-	0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63,
-	0x65, 0x4d, 0x73, 0x67, 0x1a, 0x1e, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72,
-	0x70, 0x63, 0x2e, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65,
-	0x52, 0x65, 0x73, 0x70, 0x12, 0x55, 0x0a, 0x0e, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64, 0x49,
-	0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x12, 0x22, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65,
-	0x73, 0x72, 0x70, 0x63, 0x2e, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64, 0x49, 0x6e, 0x76, 0x6f,
-	0x69, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x69, 0x6e, 0x76,
-	0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64,
-	0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x12, 0x4e, 0x0a, 0x0d, 0x53,
-	0x65, 0x74, 0x74, 0x6c, 0x65, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x12, 0x1d, 0x2e, 0x69,
-	0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x65, 0x74, 0x74, 0x6c,
-	0x65, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x4d, 0x73, 0x67, 0x1a, 0x1e, 0x2e, 0x69, 0x6e,
-	0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x65, 0x74, 0x74, 0x6c, 0x65,
-	0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x42, 0x33, 0x5a, 0x31, 0x67,
-	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x6e,
-	0x69, 0x6e, 0x67, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2f, 0x6c, 0x6e, 0x64, 0x2f, 0x6c,
-	0x6e, 0x72, 0x70, 0x63, 0x2f, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a,
+	0x06, 0x72, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x72,
+	0x48, 0x61, 0x73, 0x68, 0x4a, 0x04, 0x08, 0x01, 0x10, 0x02, 0x22, 0x1d, 0x0a, 0x1b, 0x47, 0x65,
+	0x74, 0x53, 0x70, 0x6f, 0x6e, 0x74, 0x61, 0x6e, 0x65, 0x6f, 0x75, 0x73, 0x50, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xc0, 0x02, 0x0a, 0x11, 0x53, 0x70,
+	0x6f, 0x6e, 0x74, 0x61, 0x6e, 0x65, 0x6f, 0x75, 0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12,
+	0x20, 0x0a, 0x0c, 0x6d, 0x69, 0x6e, 0x5f, 0x61, 0x6d, 0x74, 0x5f, 0x6d, 0x73, 0x61, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x6d, 0x69, 0x6e, 0x41, 0x6d, 0x74, 0x4d, 0x73, 0x61,
+	0x74, 0x12, 0x20, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x61, 0x6d, 0x74, 0x5f, 0x6d, 0x73, 0x61,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x41, 0x6d, 0x74, 0x4d,
+	0x73, 0x61, 0x74, 0x12, 0x36, 0x0a, 0x17, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x5f,
+	0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x15, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x43, 0x75,
+	0x73, 0x74, 0x6f, 0x6d, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x34, 0x0a, 0x16, 0x61,
+	0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x5f, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x5f, 0x72, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x04, 0x52, 0x14, 0x61, 0x6c, 0x6c,
+	0x6f, 0x77, 0x65, 0x64, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
+	0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x72, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x12, 0x35, 0x0a, 0x17, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x5f, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x73, 0x65, 0x63, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x14, 0x72, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x49, 0x6e, 0x74, 0x65,
+	0x72, 0x76, 0x61, 0x6c, 0x53, 0x65, 0x63, 0x12, 0x23, 0x0a, 0x0d, 0x69, 0x6e, 0x76, 0x6f, 0x69,
+	0x63, 0x65, 0x5f, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x22, 0x1e, 0x0a, 0x1c,
+	0x53, 0x65, 0x74, 0x53, 0x70, 0x6f, 0x6e, 0x74, 0x61, 0x6e, 0x65, 0x6f, 0x75, 0x73, 0x50, 0x6f,
+	0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xfd, 0x05, 0x0a,
+	0x08, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x12, 0x56, 0x0a, 0x16, 0x53, 0x75, 0x62,
+	0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x53, 0x69, 0x6e, 0x67, 0x6c, 0x65, 0x49, 0x6e, 0x76, 0x6f,
+	0x69, 0x63, 0x65, 0x12, 0x2a, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70,
+	0x63, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x53, 0x69, 0x6e, 0x67, 0x6c,
+	0x65, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x0e, 0x2e, 0x6c, 0x6e, 0x72, 0x70, 0x63, 0x2e, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x30,
+	0x01, 0x12, 0x4e, 0x0a, 0x0d, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x49, 0x6e, 0x76, 0x6f, 0x69,
+	0x63, 0x65, 0x12, 0x1d, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63,
+	0x2e, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x4d, 0x73,
+	0x67, 0x1a, 0x1e, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e,
+	0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x12, 0x55, 0x0a, 0x0e, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64, 0x49, 0x6e, 0x76, 0x6f,
+	0x69, 0x63, 0x65, 0x12, 0x22, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70,
+	0x63, 0x2e, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63,
+	0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x41, 0x64, 0x64, 0x48, 0x6f, 0x6c, 0x64, 0x49, 0x6e, 0x76,
+	0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x12, 0x4e, 0x0a, 0x0d, 0x53, 0x65, 0x74, 0x74,
+	0x6c, 0x65, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x12, 0x1d, 0x2e, 0x69, 0x6e, 0x76, 0x6f,
+	0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x65, 0x74, 0x74, 0x6c, 0x65, 0x49, 0x6e,
+	0x76, 0x6f, 0x69, 0x63, 0x65, 0x4d, 0x73, 0x67, 0x1a, 0x1e, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69,
+	0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x65, 0x74, 0x74, 0x6c, 0x65, 0x49, 0x6e, 0x76,
+	0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x12, 0x64, 0x0a, 0x13, 0x41, 0x64, 0x64, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x6c, 0x65, 0x73, 0x73, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x12,
+	0x27, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x41, 0x64,
+	0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x6c, 0x65, 0x73, 0x73, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69,
+	0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x41, 0x64, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x6c,
+	0x65, 0x73, 0x73, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x12, 0x77,
+	0x0a, 0x18, 0x53, 0x74, 0x61, 0x74, 0x65, 0x6c, 0x65, 0x73, 0x73, 0x49, 0x6e, 0x76, 0x6f, 0x69,
+	0x63, 0x65, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x12, 0x2b, 0x2e, 0x69, 0x6e, 0x76,
+	0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x6c, 0x65,
+	0x73, 0x73, 0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x1a, 0x2a, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63,
+	0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x6c, 0x65, 0x73, 0x73, 0x49,
+	0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x28, 0x01, 0x30, 0x01, 0x12, 0x60, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x53, 0x70,
+	0x6f, 0x6e, 0x74, 0x61, 0x6e, 0x65, 0x6f, 0x75, 0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12,
+	0x28, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65,
+	0x74, 0x53, 0x70, 0x6f, 0x6e, 0x74, 0x61, 0x6e, 0x65, 0x6f, 0x75, 0x73, 0x50, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x69, 0x6e, 0x76, 0x6f,
+	0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x70, 0x6f, 0x6e, 0x74, 0x61, 0x6e, 0x65,
+	0x6f, 0x75, 0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x61, 0x0a, 0x14, 0x53, 0x65, 0x74,
+	0x53, 0x70, 0x6f, 0x6e, 0x74, 0x61, 0x6e, 0x65, 0x6f, 0x75, 0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x12, 0x1e, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e,
+	0x53, 0x70, 0x6f, 0x6e, 0x74, 0x61, 0x6e, 0x65, 0x6f, 0x75, 0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x1a, 0x29, 0x2e, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70, 0x63, 0x2e,
+	0x53, 0x65, 0x74, 0x53, 0x70, 0x6f, 0x6e, 0x74, 0x61, 0x6e, 0x65, 0x6f, 0x75, 0x73, 0x50, 0x6f,
+	0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x33, 0x5a, 0x31,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x69, 0x67, 0x68, 0x74,
+	0x6e, 0x69, 0x6e, 0x67, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2f, 0x6c, 0x6e, 0x64, 0x2f,
+	0x6c, 0x6e, 0x72, 0x70, 0x63, 0x2f, 0x69, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x73, 0x72, 0x70,
+	0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -530,33 +1203,49 @@ func file_invoicesrpc_invoices_proto_rawDescGZIP() []byte {
 	return file_invoicesrpc_invoices_proto_rawDescData
 }
 
-var file_invoicesrpc_invoices_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_invoicesrpc_invoices_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_invoicesrpc_invoices_proto_goTypes = []interface{}{
-	(*CancelInvoiceMsg)(nil),              // 0: invoicesrpc.CancelInvoiceMsg
-	(*CancelInvoiceResp)(nil),             // 1: invoicesrpc.CancelInvoiceResp
-	(*AddHoldInvoiceRequest)(nil),         // 2: invoicesrpc.AddHoldInvoiceRequest
-	(*AddHoldInvoiceResp)(nil),            // 3: invoicesrpc.AddHoldInvoiceResp
-	(*SettleInvoiceMsg)(nil),              // 4: invoicesrpc.SettleInvoiceMsg
-	(*SettleInvoiceResp)(nil),             // 5: invoicesrpc.SettleInvoiceResp
-	(*SubscribeSingleInvoiceRequest)(nil), // 6: invoicesrpc.SubscribeSingleInvoiceRequest
-	(*lnrpc.RouteHint)(nil),               // 7: lnrpc.RouteHint
-	(*lnrpc.Invoice)(nil),                 // 8: lnrpc.Invoice
+	(*CancelInvoiceMsg)(nil),               // 0: invoicesrpc.CancelInvoiceMsg
+	(*CancelInvoiceResp)(nil),              // 1: invoicesrpc.CancelInvoiceResp
+	(*AddHoldInvoiceRequest)(nil),          // 2: invoicesrpc.AddHoldInvoiceRequest
+	(*AddHoldInvoiceResp)(nil),             // 3: invoicesrpc.AddHoldInvoiceResp
+	(*SettleInvoiceMsg)(nil),               // 4: invoicesrpc.SettleInvoiceMsg
+	(*SettleInvoiceResp)(nil),              // 5: invoicesrpc.SettleInvoiceResp
+	(*AddStatelessInvoiceRequest)(nil),     // 6: invoicesrpc.AddStatelessInvoiceRequest
+	(*AddStatelessInvoiceResp)(nil),        // 7: invoicesrpc.AddStatelessInvoiceResp
+	(*StatelessInvoiceAcceptRequest)(nil),  // 8: invoicesrpc.StatelessInvoiceAcceptRequest
+	(*StatelessInvoiceAcceptResponse)(nil), // 9: invoicesrpc.StatelessInvoiceAcceptResponse
+	(*SubscribeSingleInvoiceRequest)(nil),  // 10: invoicesrpc.SubscribeSingleInvoiceRequest
+	(*GetSpontaneousPolicyRequest)(nil),    // 11: invoicesrpc.GetSpontaneousPolicyRequest
+	(*SpontaneousPolicy)(nil),              // 12: invoicesrpc.SpontaneousPolicy
+	(*SetSpontaneousPolicyResponse)(nil),   // 13: invoicesrpc.SetSpontaneousPolicyResponse
+	(*lnrpc.RouteHint)(nil),                // 14: lnrpc.RouteHint
+	(*lnrpc.Invoice)(nil),                  // 15: lnrpc.Invoice
 }
 var file_invoicesrpc_invoices_proto_depIdxs = []int32{
-	7, // 0: invoicesrpc.AddHoldInvoiceRequest.route_hints:type_name -> lnrpc.RouteHint
-	6, // 1: invoicesrpc.Invoices.SubscribeSingleInvoice:input_type -> invoicesrpc.SubscribeSingleInvoiceRequest
-	0, // 2: invoicesrpc.Invoices.CancelInvoice:input_type -> invoicesrpc.CancelInvoiceMsg
-	2, // 3: invoicesrpc.Invoices.AddHoldInvoice:input_type -> invoicesrpc.AddHoldInvoiceRequest
-	4, // 4: invoicesrpc.Invoices.SettleInvoice:input_type -> invoicesrpc.SettleInvoiceMsg
-	8, // 5: invoicesrpc.Invoices.SubscribeSingleInvoice:output_type -> lnrpc.Invoice
-	1, // 6: invoicesrpc.Invoices.CancelInvoice:output_type -> invoicesrpc.CancelInvoiceResp
-	3, // 7: invoicesrpc.Invoices.AddHoldInvoice:output_type -> invoicesrpc.AddHoldInvoiceResp
-	5, // 8: invoicesrpc.Invoices.SettleInvoice:output_type -> invoicesrpc.SettleInvoiceResp
-	5, // [5:9] is the sub-list for method output_type
-	1, // [1:5] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	14, // 0: invoicesrpc.AddHoldInvoiceRequest.route_hints:type_name -> lnrpc.RouteHint
+	14, // 1: invoicesrpc.AddStatelessInvoiceRequest.route_hints:type_name -> lnrpc.RouteHint
+	10, // 2: invoicesrpc.Invoices.SubscribeSingleInvoice:input_type -> invoicesrpc.SubscribeSingleInvoiceRequest
+	0,  // 3: invoicesrpc.Invoices.CancelInvoice:input_type -> invoicesrpc.CancelInvoiceMsg
+	2,  // 4: invoicesrpc.Invoices.AddHoldInvoice:input_type -> invoicesrpc.AddHoldInvoiceRequest
+	4,  // 5: invoicesrpc.Invoices.SettleInvoice:input_type -> invoicesrpc.SettleInvoiceMsg
+	6,  // 6: invoicesrpc.Invoices.AddStatelessInvoice:input_type -> invoicesrpc.AddStatelessInvoiceRequest
+	9,  // 7: invoicesrpc.Invoices.StatelessInvoiceAcceptor:input_type -> invoicesrpc.StatelessInvoiceAcceptResponse
+	11, // 8: invoicesrpc.Invoices.GetSpontaneousPolicy:input_type -> invoicesrpc.GetSpontaneousPolicyRequest
+	12, // 9: invoicesrpc.Invoices.SetSpontaneousPolicy:input_type -> invoicesrpc.SpontaneousPolicy
+	15, // 10: invoicesrpc.Invoices.SubscribeSingleInvoice:output_type -> lnrpc.Invoice
+	1,  // 11: invoicesrpc.Invoices.CancelInvoice:output_type -> invoicesrpc.CancelInvoiceResp
+	3,  // 12: invoicesrpc.Invoices.AddHoldInvoice:output_type -> invoicesrpc.AddHoldInvoiceResp
+	5,  // 13: invoicesrpc.Invoices.SettleInvoice:output_type -> invoicesrpc.SettleInvoiceResp
+	7,  // 14: invoicesrpc.Invoices.AddStatelessInvoice:output_type -> invoicesrpc.AddStatelessInvoiceResp
+	8,  // 15: invoicesrpc.Invoices.StatelessInvoiceAcceptor:output_type -> invoicesrpc.StatelessInvoiceAcceptRequest
+	12, // 16: invoicesrpc.Invoices.GetSpontaneousPolicy:output_type -> invoicesrpc.SpontaneousPolicy
+	13, // 17: invoicesrpc.Invoices.SetSpontaneousPolicy:output_type -> invoicesrpc.SetSpontaneousPolicyResponse
+	10, // [10:18] is the sub-list for method output_type
+	2,  // [2:10] is the sub-list for method input_type
+	2,  // [2:2] is the sub-list for extension type_name
+	2,  // [2:2] is the sub-list for extension extendee
+	0,  // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_invoicesrpc_invoices_proto_init() }
@@ -638,6 +1327,54 @@ func file_invoicesrpc_invoices_proto_init() {
 			}
 		}
 		file_invoicesrpc_invoices_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddStatelessInvoiceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_invoicesrpc_invoices_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddStatelessInvoiceResp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_invoicesrpc_invoices_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatelessInvoiceAcceptRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_invoicesrpc_invoices_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatelessInvoiceAcceptResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_invoicesrpc_invoices_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SubscribeSingleInvoiceRequest); i {
 			case 0:
 				return &v.state
@@ -649,6 +1386,42 @@ func file_invoicesrpc_invoices_proto_init() {
 				return nil
 			}
 		}
+		file_invoicesrpc_invoices_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetSpontaneousPolicyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_invoicesrpc_invoices_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpontaneousPolicy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_invoicesrpc_invoices_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetSpontaneousPolicyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -656,7 +1429,7 @@ func file_invoicesrpc_invoices_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_invoicesrpc_invoices_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -700,6 +1473,36 @@ type InvoicesClient interface {
 	//SettleInvoice settles an accepted invoice. If the invoice is already
 	//settled, this call will succeed.
 	SettleInvoice(ctx context.Context, in *SettleInvoiceMsg, opts ...grpc.CallOption) (*SettleInvoiceResp, error)
+	//
+	//AddStatelessInvoice creates a stateless invoice. The preimage of a
+	//stateless invoice is derived from a node secret and the payment address,
+	//amount and payment metadata of the invoice, which allows lnd to settle
+	//payments to it without storing the invoice. The payment address commits to
+	//the expiry of the invoice, and payments arriving after it are rejected.
+	//The invoice is only inserted into the database once it is paid, and
+	//payments to it must be approved through the StatelessInvoiceAcceptor
+	//stream. Requires lnd to be started with --accept-stateless-invoices.
+	AddStatelessInvoice(ctx context.Context, in *AddStatelessInvoiceRequest, opts ...grpc.CallOption) (*AddStatelessInvoiceResp, error)
+	//
+	//StatelessInvoiceAcceptor dispatches a bi-directional streaming RPC in which
+	//payments to stateless invoices are sent to the client for approval once
+	//all of their htlcs have been accepted. The client responds with whether to
+	//settle the payment or to cancel it back to the payer. While no acceptor is
+	//connected, payments to stateless invoices are held and delivered to the
+	//next acceptor that connects. Held payments that aren't resolved in time are
+	//canceled before their htlcs expire. Only a single acceptor can be
+	//connected at a time.
+	StatelessInvoiceAcceptor(ctx context.Context, opts ...grpc.CallOption) (Invoices_StatelessInvoiceAcceptorClient, error)
+	//
+	//GetSpontaneousPolicy returns the policy that spontaneous keysend and AMP
+	//payments must currently adhere to.
+	GetSpontaneousPolicy(ctx context.Context, in *GetSpontaneousPolicyRequest, opts ...grpc.CallOption) (*SpontaneousPolicy, error)
+	//
+	//SetSpontaneousPolicy replaces the policy that spontaneous keysend and AMP
+	//payments must adhere to. Spontaneous payments that violate the policy are
+	//failed back. The policy is not persisted, on restart the policy from the
+	//configuration is enforced again.
+	SetSpontaneousPolicy(ctx context.Context, in *SpontaneousPolicy, opts ...grpc.CallOption) (*SetSpontaneousPolicyResponse, error)
 }
 
 type invoicesClient struct {
@@ -769,6 +1572,64 @@ func (c *invoicesClient) SettleInvoice(ctx context.Context, in *SettleInvoiceMsg
 	return out, nil
 }
 
+func (c *invoicesClient) AddStatelessInvoice(ctx context.Context, in *AddStatelessInvoiceRequest, opts ...grpc.CallOption) (*AddStatelessInvoiceResp, error) {
+	out := new(AddStatelessInvoiceResp)
+	err := c.cc.Invoke(ctx, "/invoicesrpc.Invoices/AddStatelessInvoice", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *invoicesClient) StatelessInvoiceAcceptor(ctx context.Context, opts ...grpc.CallOption) (Invoices_StatelessInvoiceAcceptorClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Invoices_serviceDesc.Streams[1], "/invoicesrpc.Invoices/StatelessInvoiceAcceptor", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &invoicesStatelessInvoiceAcceptorClient{stream}
+	return x, nil
+}
+
+type Invoices_StatelessInvoiceAcceptorClient interface {
+	Send(*StatelessInvoiceAcceptResponse) error
+	Recv() (*StatelessInvoiceAcceptRequest, error)
+	grpc.ClientStream
+}
+
+type invoicesStatelessInvoiceAcceptorClient struct {
+	grpc.ClientStream
+}
+
+func (x *invoicesStatelessInvoiceAcceptorClient) Send(m *StatelessInvoiceAcceptResponse) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *invoicesStatelessInvoiceAcceptorClient) Recv() (*StatelessInvoiceAcceptRequest, error) {
+	m := new(StatelessInvoiceAcceptRequest)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *invoicesClient) GetSpontaneousPolicy(ctx context.Context, in *GetSpontaneousPolicyRequest, opts ...grpc.CallOption) (*SpontaneousPolicy, error) {
+	out := new(SpontaneousPolicy)
+	err := c.cc.Invoke(ctx, "/invoicesrpc.Invoices/GetSpontaneousPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *invoicesClient) SetSpontaneousPolicy(ctx context.Context, in *SpontaneousPolicy, opts ...grpc.CallOption) (*SetSpontaneousPolicyResponse, error) {
+	out := new(SetSpontaneousPolicyResponse)
+	err := c.cc.Invoke(ctx, "/invoicesrpc.Invoices/SetSpontaneousPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // InvoicesServer is the server API for Invoices service.
 type InvoicesServer interface {
 	//
@@ -789,6 +1650,36 @@ type InvoicesServer interface {
 	//SettleInvoice settles an accepted invoice. If the invoice is already
 	//settled, this call will succeed.
 	SettleInvoice(context.Context, *SettleInvoiceMsg) (*SettleInvoiceResp, error)
+	//
+	//AddStatelessInvoice creates a stateless invoice. The preimage of a
+	//stateless invoice is derived from a node secret and the payment address,
+	//amount and payment metadata of the invoice, which allows lnd to settle
+	//payments to it without storing the invoice. The payment address commits to
+	//the expiry of the invoice, and payments arriving after it are rejected.
+	//The invoice is only inserted into the database once it is paid, and
+	//payments to it must be approved through the StatelessInvoiceAcceptor
+	//stream. Requires lnd to be started with --accept-stateless-invoices.
+	AddStatelessInvoice(context.Context, *AddStatelessInvoiceRequest) (*AddStatelessInvoiceResp, error)
+	//
+	//StatelessInvoiceAcceptor dispatches a bi-directional streaming RPC in which
+	//payments to stateless invoices are sent to the client for approval once
+	//all of their htlcs have been accepted. The client responds with whether to
+	//settle the payment or to cancel it back to the payer. While no acceptor is
+	//connected, payments to stateless invoices are held and delivered to the
+	//next acceptor that connects. Held payments that aren't resolved in time are
+	//canceled before their htlcs expire. Only a single acceptor can be
+	//connected at a time.
+	StatelessInvoiceAcceptor(Invoices_StatelessInvoiceAcceptorServer) error
+	//
+	//GetSpontaneousPolicy returns the policy that spontaneous keysend and AMP
+	//payments must currently adhere to.
+	GetSpontaneousPolicy(context.Context, *GetSpontaneousPolicyRequest) (*SpontaneousPolicy, error)
+	//
+	//SetSpontaneousPolicy replaces the policy that spontaneous keysend and AMP
+	//payments must adhere to. Spontaneous payments that violate the policy are
+	//failed back. The policy is not persisted, on restart the policy from the
+	//configuration is enforced again.
+	SetSpontaneousPolicy(context.Context, *SpontaneousPolicy) (*SetSpontaneousPolicyResponse, error)
 }
 
 // UnimplementedInvoicesServer can be embedded to have forward compatible implementations.
@@ -807,6 +1698,18 @@ func (*UnimplementedInvoicesServer) AddHoldInvoice(context.Context, *AddHoldInvo
 func (*UnimplementedInvoicesServer) SettleInvoice(context.Context, *SettleInvoiceMsg) (*SettleInvoiceResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SettleInvoice not implemented")
 }
+func (*UnimplementedInvoicesServer) AddStatelessInvoice(context.Context, *AddStatelessInvoiceRequest) (*AddStatelessInvoiceResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddStatelessInvoice not implemented")
+}
+func (*UnimplementedInvoicesServer) StatelessInvoiceAcceptor(Invoices_StatelessInvoiceAcceptorServer) error {
+	return status.Errorf(codes.Unimplemented, "method StatelessInvoiceAcceptor not implemented")
+}
+func (*UnimplementedInvoicesServer) GetSpontaneousPolicy(context.Context, *GetSpontaneousPolicyRequest) (*SpontaneousPolicy, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpontaneousPolicy not implemented")
+}
+func (*UnimplementedInvoicesServer) SetSpontaneousPolicy(context.Context, *SpontaneousPolicy) (*SetSpontaneousPolicyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSpontaneousPolicy not implemented")
+}
 
 func RegisterInvoicesServer(s *grpc.Server, srv InvoicesServer) {
 	s.RegisterService(&_Invoices_serviceDesc, srv)
@@ -887,6 +1790,86 @@ func _Invoices_SettleInvoice_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Invoices_AddStatelessInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddStatelessInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoicesServer).AddStatelessInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/invoicesrpc.Invoices/AddStatelessInvoice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoicesServer).AddStatelessInvoice(ctx, req.(*AddStatelessInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Invoices_StatelessInvoiceAcceptor_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(InvoicesServer).StatelessInvoiceAcceptor(&invoicesStatelessInvoiceAcceptorServer{stream})
+}
+
+type Invoices_StatelessInvoiceAcceptorServer interface {
+	Send(*StatelessInvoiceAcceptRequest) error
+	Recv() (*StatelessInvoiceAcceptResponse, error)
+	grpc.ServerStream
+}
+
+type invoicesStatelessInvoiceAcceptorServer struct {
+	grpc.ServerStream
+}
+
+func (x *invoicesStatelessInvoiceAcceptorServer) Send(m *StatelessInvoiceAcceptRequest) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *invoicesStatelessInvoiceAcceptorServer) Recv() (*StatelessInvoiceAcceptResponse, error) {
+	m := new(StatelessInvoiceAcceptResponse)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Invoices_GetSpontaneousPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSpontaneousPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoicesServer).GetSpontaneousPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/invoicesrpc.Invoices/GetSpontaneousPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoicesServer).GetSpontaneousPolicy(ctx, req.(*GetSpontaneousPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Invoices_SetSpontaneousPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SpontaneousPolicy)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoicesServer).SetSpontaneousPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/invoicesrpc.Invoices/SetSpontaneousPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoicesServer).SetSpontaneousPolicy(ctx, req.(*SpontaneousPolicy))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Invoices_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "invoicesrpc.Invoices",
 	HandlerType: (*InvoicesServer)(nil),
@@ -903,6 +1886,18 @@ var _Invoices_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SettleInvoice",
 			Handler:    _Invoices_SettleInvoice_Handler,
 		},
+		{
+			MethodName: "AddStatelessInvoice",
+			Handler:    _Invoices_AddStatelessInvoice_Handler,
+		},
+		{
+			MethodName: "GetSpontaneousPolicy",
+			Handler:    _Invoices_GetSpontaneousPolicy_Handler,
+		},
+		{
+			MethodName: "SetSpontaneousPolicy",
+			Handler:    _Invoices_SetSpontaneousPolicy_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -910,6 +1905,12 @@ var _Invoices_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Invoices_SubscribeSingleInvoice_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StatelessInvoiceAcceptor",
+			Handler:       _Invoices_StatelessInvoiceAcceptor_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "invoicesrpc/invoices.proto",
 }
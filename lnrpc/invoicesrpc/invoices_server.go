@@ -7,14 +7,17 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"google.golang.org/grpc"
 	"gopkg.in/macaroon-bakery.v2/bakery"
 
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/invoices"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/macaroons"
 )
 
@@ -58,6 +61,22 @@ var (
 			Entity: "invoices",
 			Action: "write",
 		}},
+		"/invoicesrpc.Invoices/AddStatelessInvoice": {{
+			Entity: "invoices",
+			Action: "write",
+		}},
+		"/invoicesrpc.Invoices/StatelessInvoiceAcceptor": {{
+			Entity: "invoices",
+			Action: "write",
+		}},
+		"/invoicesrpc.Invoices/GetSpontaneousPolicy": {{
+			Entity: "invoices",
+			Action: "read",
+		}},
+		"/invoicesrpc.Invoices/SetSpontaneousPolicy": {{
+			Entity: "invoices",
+			Action: "write",
+		}},
 	}
 
 	// DefaultInvoicesMacFilename is the default name of the invoices
@@ -346,6 +365,8 @@ func (s *Server) AddHoldInvoice(ctx context.Context,
 		HodlInvoice:     true,
 		Preimage:        nil,
 		RouteHints:      routeHints,
+		MaxPaymentAmt:   lnwire.MilliSatoshi(invoice.MaxPaymentMsat),
+		AcceptPartial:   invoice.AcceptPartial,
 	}
 
 	_, dbInvoice, err := AddInvoice(ctx, addInvoiceCfg, addInvoiceData)
@@ -357,3 +378,176 @@ func (s *Server) AddHoldInvoice(ctx context.Context,
 		PaymentRequest: string(dbInvoice.PaymentRequest),
 	}, nil
 }
+
+// AddStatelessInvoice creates a stateless invoice. Stateless invoices aren't
+// stored in the database until they are paid, their preimage is derived from
+// the payment address, amount and payment metadata of the invoice instead.
+func (s *Server) AddStatelessInvoice(ctx context.Context,
+	invoice *AddStatelessInvoiceRequest) (*AddStatelessInvoiceResp, error) {
+
+	addInvoiceCfg := &AddInvoiceConfig{
+		AddInvoice:            s.cfg.InvoiceRegistry.AddInvoice,
+		IsChannelActive:       s.cfg.IsChannelActive,
+		ChainParams:           s.cfg.ChainParams,
+		NodeSigner:            s.cfg.NodeSigner,
+		DefaultCLTVExpiry:     s.cfg.DefaultCLTVExpiry,
+		ChanDB:                s.cfg.RemoteChanDB,
+		Graph:                 s.cfg.LocalChanDB.ChannelGraph(),
+		GenInvoiceFeatures:    s.cfg.GenInvoiceFeatures,
+		GenAmpInvoiceFeatures: s.cfg.GenAmpInvoiceFeatures,
+		GenStatelessPreimage:  s.cfg.InvoiceRegistry.StatelessPreimage,
+	}
+
+	value, err := lnrpc.UnmarshallAmt(invoice.Value, invoice.ValueMsat)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the passed routing hints to the required format.
+	routeHints, err := CreateZpay32HopHints(invoice.RouteHints)
+	if err != nil {
+		return nil, err
+	}
+	addInvoiceData := &AddInvoiceData{
+		Memo:            invoice.Memo,
+		Value:           value,
+		DescriptionHash: invoice.DescriptionHash,
+		Expiry:          invoice.Expiry,
+		FallbackAddr:    invoice.FallbackAddr,
+		CltvExpiry:      invoice.CltvExpiry,
+		Private:         invoice.Private,
+		RouteHints:      routeHints,
+		Metadata:        invoice.PaymentMetadata,
+		Stateless:       true,
+	}
+
+	hash, newInvoice, err := AddInvoice(ctx, addInvoiceCfg, addInvoiceData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddStatelessInvoiceResp{
+		PaymentRequest: string(newInvoice.PaymentRequest),
+		RHash:          hash[:],
+		PaymentAddr:    newInvoice.Terms.PaymentAddr[:],
+	}, nil
+}
+
+// StatelessInvoiceAcceptor dispatches a bi-directional streaming RPC in which
+// payments to stateless invoices are sent to the client for approval once all
+// of their htlcs have been accepted.
+func (s *Server) StatelessInvoiceAcceptor(
+	stream Invoices_StatelessInvoiceAcceptorServer) error {
+
+	acceptor, err := s.cfg.InvoiceRegistry.RegisterStatelessAcceptor()
+	if err != nil {
+		return err
+	}
+	defer acceptor.Cancel()
+
+	// Read the responses of the client in a separate goroutine, so that
+	// we can keep sending requests in the meantime.
+	errChan := make(chan error, 1)
+	responses := make(chan *StatelessInvoiceAcceptResponse)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				errChan <- err
+				return
+			}
+
+			select {
+			case responses <- resp:
+			case <-stream.Context().Done():
+				return
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case req := <-acceptor.Requests:
+			err := stream.Send(&StatelessInvoiceAcceptRequest{
+				RHash:           req.PaymentHash[:],
+				PaymentAddr:     req.PaymentAddr[:],
+				PaymentMetadata: req.Metadata,
+				ValueMsat:       uint64(req.Value),
+				AmtPaidMsat:     uint64(req.AmtPaid),
+			})
+			if err != nil {
+				return err
+			}
+
+		case resp := <-responses:
+			hash, err := lntypes.MakeHash(resp.RHash)
+			if err != nil {
+				log.Warnf("Invalid stateless invoice acceptor "+
+					"response: %v", err)
+				continue
+			}
+
+			// A failed resolution doesn't indicate a problem with
+			// the stream, so we only log it.
+			err = acceptor.Resolve(hash, resp.Approve)
+			if err != nil {
+				log.Warnf("Unable to resolve payment to "+
+					"stateless invoice %v: %v", hash, err)
+			}
+
+		case err := <-errChan:
+			return err
+
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+
+		case <-s.quit:
+			return nil
+		}
+	}
+}
+
+// GetSpontaneousPolicy returns the policy that spontaneous keysend and AMP
+// payments must currently adhere to.
+func (s *Server) GetSpontaneousPolicy(ctx context.Context,
+	_ *GetSpontaneousPolicyRequest) (*SpontaneousPolicy, error) {
+
+	policy := s.cfg.InvoiceRegistry.SpontaneousPolicy()
+	interval := uint64(policy.RateLimitInterval / time.Second)
+
+	return &SpontaneousPolicy{
+		MinAmtMsat:            uint64(policy.MinAmt),
+		MaxAmtMsat:            uint64(policy.MaxAmt),
+		RestrictCustomRecords: policy.RestrictCustomRecords,
+		AllowedCustomRecords:  policy.AllowedCustomRecords,
+		RateLimit:             policy.RateLimit,
+		RateLimitIntervalSec:  interval,
+		InvoiceLabel:          policy.InvoiceLabel,
+	}, nil
+}
+
+// SetSpontaneousPolicy replaces the policy that spontaneous keysend and AMP
+// payments must adhere to.
+func (s *Server) SetSpontaneousPolicy(ctx context.Context,
+	req *SpontaneousPolicy) (*SetSpontaneousPolicyResponse, error) {
+
+	policy := invoices.SpontaneousPolicy{
+		MinAmt:                lnwire.MilliSatoshi(req.MinAmtMsat),
+		MaxAmt:                lnwire.MilliSatoshi(req.MaxAmtMsat),
+		RestrictCustomRecords: req.RestrictCustomRecords,
+		AllowedCustomRecords:  req.AllowedCustomRecords,
+		RateLimit:             req.RateLimit,
+		RateLimitInterval: time.Duration(req.RateLimitIntervalSec) *
+			time.Second,
+		InvoiceLabel: req.InvoiceLabel,
+	}
+
+	err := s.cfg.InvoiceRegistry.SetSpontaneousPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetSpontaneousPolicyResponse{}, nil
+}
@@ -118,6 +118,7 @@ func CreateRPCInvoice(invoice *channeldb.Invoice,
 			State:           state,
 			CustomRecords:   htlc.CustomRecords,
 			MppTotalAmtMsat: uint64(htlc.MppTotalAmt),
+			Metadata:        htlc.Metadata,
 		}
 
 		// Populate any fields relevant to AMP payments.
@@ -175,6 +176,10 @@ func CreateRPCInvoice(invoice *channeldb.Invoice,
 		IsKeysend:       len(invoice.PaymentRequest) == 0 && !isAmp,
 		PaymentAddr:     invoice.Terms.PaymentAddr[:],
 		IsAmp:           isAmp,
+		PaymentMetadata: decoded.Metadata,
+		MaxPaymentMsat:  uint64(invoice.Terms.MaxPaymentAmt),
+		AcceptPartial:   invoice.Terms.AcceptPartial,
+		MaxAmtPaidMsat:  uint64(invoice.Terms.MaxAmtPaid),
 	}
 
 	if preimage != nil {
@@ -184,6 +189,25 @@ func CreateRPCInvoice(invoice *channeldb.Invoice,
 	return rpcInvoice, nil
 }
 
+// UnmarshallInvoiceState converts an rpc invoice state into the corresponding
+// channeldb.ContractState.
+func UnmarshallInvoiceState(state lnrpc.Invoice_InvoiceState) (
+	channeldb.ContractState, error) {
+
+	switch state {
+	case lnrpc.Invoice_OPEN:
+		return channeldb.ContractOpen, nil
+	case lnrpc.Invoice_SETTLED:
+		return channeldb.ContractSettled, nil
+	case lnrpc.Invoice_CANCELED:
+		return channeldb.ContractCanceled, nil
+	case lnrpc.Invoice_ACCEPTED:
+		return channeldb.ContractAccepted, nil
+	default:
+		return 0, fmt.Errorf("unknown invoice state %v", state)
+	}
+}
+
 // CreateRPCFeatures maps a feature vector into a list of lnrpc.Features.
 func CreateRPCFeatures(fv *lnwire.FeatureVector) map[uint32]*lnrpc.Feature {
 	if fv == nil {
@@ -6,9 +6,12 @@ import (
 	fmt "fmt"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chanfunding"
 	"github.com/lightningnetwork/lnd/lnwire"
 )
 
@@ -118,6 +121,9 @@ func MarshalUtxos(utxos []*lnwallet.Utxo, activeNetParams *chaincfg.Params) (
 			PkScript:      hex.EncodeToString(utxo.PkScript),
 			Outpoint:      outpoint,
 			Confirmations: utxo.Confirmations,
+			Label:         utxo.Label,
+			Tag:           utxo.Tag,
+			Frozen:        utxo.Frozen,
 		}
 
 		// Finally, we'll attempt to extract the raw address from the
@@ -144,3 +150,80 @@ func MarshalUtxos(utxos []*lnwallet.Utxo, activeNetParams *chaincfg.Params) (
 
 	return res, nil
 }
+
+// UnmarshallOutPoint converts an outpoint from its lnrpc type to its canonical
+// type.
+func UnmarshallOutPoint(op *OutPoint) (*wire.OutPoint, error) {
+	if op == nil {
+		return nil, fmt.Errorf("empty outpoint provided")
+	}
+
+	var hash chainhash.Hash
+	switch {
+	case len(op.TxidBytes) == 0 && len(op.TxidStr) == 0:
+		fallthrough
+
+	case len(op.TxidBytes) != 0 && len(op.TxidStr) != 0:
+		return nil, fmt.Errorf("either TxidBytes or TxidStr must be " +
+			"specified, but not both")
+
+	// The hash was provided as raw bytes.
+	case len(op.TxidBytes) != 0:
+		copy(hash[:], op.TxidBytes)
+
+	// The hash was provided as a hex-encoded string.
+	case len(op.TxidStr) != 0:
+		h, err := chainhash.NewHashFromStr(op.TxidStr)
+		if err != nil {
+			return nil, err
+		}
+		hash = *h
+	}
+
+	return &wire.OutPoint{
+		Hash:  hash,
+		Index: op.OutputIndex,
+	}, nil
+}
+
+// UnmarshallCoinSelection converts the coin control fields of an RPC request
+// into the outpoints to spend and the coin selection strategy to use. Explicit
+// outpoints and an explicit strategy are mutually exclusive.
+func UnmarshallCoinSelection(utxos []*OutPoint,
+	strategy CoinSelectionStrategy) ([]wire.OutPoint,
+	chanfunding.CoinSelectionStrategy, error) {
+
+	useGlobal := strategy == CoinSelectionStrategy_STRATEGY_USE_GLOBAL_CONFIG
+	if len(utxos) > 0 && !useGlobal {
+		return nil, 0, fmt.Errorf("utxos and coin selection strategy " +
+			"are mutually exclusive")
+	}
+
+	var outpoints []wire.OutPoint
+	for _, utxo := range utxos {
+		outpoint, err := UnmarshallOutPoint(utxo)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		outpoints = append(outpoints, *outpoint)
+	}
+
+	switch strategy {
+	case CoinSelectionStrategy_STRATEGY_USE_GLOBAL_CONFIG:
+		return outpoints, chanfunding.CoinSelectionDefault, nil
+
+	case CoinSelectionStrategy_STRATEGY_LARGEST:
+		return nil, chanfunding.CoinSelectionLargest, nil
+
+	case CoinSelectionStrategy_STRATEGY_RANDOM:
+		return nil, chanfunding.CoinSelectionRandom, nil
+
+	case CoinSelectionStrategy_STRATEGY_PRIVACY:
+		return nil, chanfunding.CoinSelectionPrivacy, nil
+
+	default:
+		return nil, 0, fmt.Errorf("unknown coin selection strategy %v",
+			strategy)
+	}
+}
@@ -0,0 +1,96 @@
+package routerrpc
+
+import (
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/htlcswitch"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// wireFailureCodes maps the wire failure codes that we may fail a htlc with
+// to their rpc counterparts.
+var wireFailureCodes = map[lnwire.FailCode]lnrpc.Failure_FailureCode{
+	lnwire.CodeIncorrectOrUnknownPaymentDetails: lnrpc.Failure_INCORRECT_OR_UNKNOWN_PAYMENT_DETAILS,
+	lnwire.CodeIncorrectPaymentAmount:           lnrpc.Failure_INCORRECT_PAYMENT_AMOUNT,
+	lnwire.CodeFinalIncorrectCltvExpiry:         lnrpc.Failure_FINAL_INCORRECT_CLTV_EXPIRY,
+	lnwire.CodeFinalIncorrectHtlcAmount:         lnrpc.Failure_FINAL_INCORRECT_HTLC_AMOUNT,
+	lnwire.CodeFinalExpiryTooSoon:               lnrpc.Failure_FINAL_EXPIRY_TOO_SOON,
+	lnwire.CodeInvalidRealm:                     lnrpc.Failure_INVALID_REALM,
+	lnwire.CodeExpiryTooSoon:                    lnrpc.Failure_EXPIRY_TOO_SOON,
+	lnwire.CodeInvalidOnionVersion:              lnrpc.Failure_INVALID_ONION_VERSION,
+	lnwire.CodeInvalidOnionHmac:                 lnrpc.Failure_INVALID_ONION_HMAC,
+	lnwire.CodeInvalidOnionKey:                  lnrpc.Failure_INVALID_ONION_KEY,
+	lnwire.CodeAmountBelowMinimum:               lnrpc.Failure_AMOUNT_BELOW_MINIMUM,
+	lnwire.CodeFeeInsufficient:                  lnrpc.Failure_FEE_INSUFFICIENT,
+	lnwire.CodeIncorrectCltvExpiry:              lnrpc.Failure_INCORRECT_CLTV_EXPIRY,
+	lnwire.CodeChannelDisabled:                  lnrpc.Failure_CHANNEL_DISABLED,
+	lnwire.CodeTemporaryChannelFailure:          lnrpc.Failure_TEMPORARY_CHANNEL_FAILURE,
+	lnwire.CodeRequiredNodeFeatureMissing:       lnrpc.Failure_REQUIRED_NODE_FEATURE_MISSING,
+	lnwire.CodeRequiredChannelFeatureMissing:    lnrpc.Failure_REQUIRED_CHANNEL_FEATURE_MISSING,
+	lnwire.CodeUnknownNextPeer:                  lnrpc.Failure_UNKNOWN_NEXT_PEER,
+	lnwire.CodeTemporaryNodeFailure:             lnrpc.Failure_TEMPORARY_NODE_FAILURE,
+	lnwire.CodePermanentNodeFailure:             lnrpc.Failure_PERMANENT_NODE_FAILURE,
+	lnwire.CodePermanentChannelFailure:          lnrpc.Failure_PERMANENT_CHANNEL_FAILURE,
+	lnwire.CodeExpiryTooFar:                     lnrpc.Failure_EXPIRY_TOO_FAR,
+	lnwire.CodeMPPTimeout:                       lnrpc.Failure_MPP_TIMEOUT,
+	lnwire.CodeInvalidOnionPayload:              lnrpc.Failure_INVALID_ONION_PAYLOAD,
+}
+
+// rpcHtlcResolution converts a htlc event from the htlc event log into its
+// rpc representation. Outcomes and failure details that this version of lnd
+// does not know about, for example because they were written by a newer
+// version, are reported as UNKNOWN rather than failing the whole query.
+func rpcHtlcResolution(event *channeldb.HtlcEvent) *HtlcResolution {
+	resolution := &HtlcResolution{
+		TimestampNs:    uint64(event.Timestamp.UnixNano()),
+		IncomingChanId: event.IncomingChanID.ToUint64(),
+		OutgoingChanId: event.OutgoingChanID.ToUint64(),
+		IncomingHtlcId: event.IncomingHtlcID,
+		OutgoingHtlcId: event.OutgoingHtlcID,
+		AmtInMsat:      uint64(event.AmtIn),
+		AmtOutMsat:     uint64(event.AmtOut),
+		HoldTimeNs:     uint64(event.HoldTime),
+	}
+
+	switch event.Outcome {
+	case channeldb.HtlcOutcomeSettled:
+		resolution.Outcome = HtlcResolution_SETTLED
+		return resolution
+
+	case channeldb.HtlcOutcomeForwardFailed:
+		resolution.Outcome = HtlcResolution_FORWARD_FAILED
+		return resolution
+
+	case channeldb.HtlcOutcomeLinkFailed:
+		resolution.Outcome = HtlcResolution_LINK_FAILED
+
+	default:
+		resolution.Outcome = HtlcResolution_UNKNOWN
+		return resolution
+	}
+
+	resolution.FailedIncoming = event.FailedIncoming
+
+	wireFailure, ok := wireFailureCodes[event.FailureCode]
+	if !ok {
+		wireFailure = lnrpc.Failure_UNKNOWN_FAILURE
+	}
+	resolution.WireFailure = wireFailure
+
+	var err error
+	switch detail := htlcswitch.HtlcEventFailureDetail(event).(type) {
+	case nil:
+		resolution.FailureDetail = FailureDetail_NO_DETAIL
+
+	case htlcswitch.IncomingFailure:
+		resolution.FailureDetail, err = rpcIncomingFailure(detail)
+
+	case htlcswitch.OutgoingFailure:
+		resolution.FailureDetail, err = rpcOutgoingFailure(detail)
+	}
+	if err != nil {
+		resolution.FailureDetail = FailureDetail_UNKNOWN
+	}
+
+	return resolution
+}
@@ -0,0 +1,81 @@
+package routerrpc
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/htlcswitch"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRpcHtlcResolution tests the conversion of htlc events from the htlc
+// event log, including events with values that we don't know about.
+func TestRpcHtlcResolution(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		event         channeldb.HtlcEvent
+		outcome       HtlcResolution_Outcome
+		wireFailure   lnrpc.Failure_FailureCode
+		failureDetail FailureDetail
+	}{
+		{
+			name: "settled",
+			event: channeldb.HtlcEvent{
+				Outcome: channeldb.HtlcOutcomeSettled,
+			},
+			outcome: HtlcResolution_SETTLED,
+		},
+		{
+			name: "rate limited",
+			event: channeldb.HtlcEvent{
+				Outcome:        channeldb.HtlcOutcomeLinkFailed,
+				FailedIncoming: true,
+				FailureCode:    lnwire.CodeTemporaryChannelFailure,
+				FailureDetail: uint16(
+					htlcswitch.IncomingFailureRateLimited,
+				),
+			},
+			outcome:       HtlcResolution_LINK_FAILED,
+			wireFailure:   lnrpc.Failure_TEMPORARY_CHANNEL_FAILURE,
+			failureDetail: FailureDetail_RATE_LIMITED,
+		},
+		{
+			name: "unknown outcome",
+			event: channeldb.HtlcEvent{
+				Outcome: channeldb.HtlcEventOutcome(99),
+			},
+			outcome: HtlcResolution_UNKNOWN,
+		},
+		{
+			name: "unknown failure detail",
+			event: channeldb.HtlcEvent{
+				Outcome:        channeldb.HtlcOutcomeLinkFailed,
+				FailedIncoming: true,
+				FailureCode:    lnwire.CodeTemporaryChannelFailure,
+				FailureDetail:  999,
+			},
+			outcome:       HtlcResolution_LINK_FAILED,
+			wireFailure:   lnrpc.Failure_TEMPORARY_CHANNEL_FAILURE,
+			failureDetail: FailureDetail_UNKNOWN,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			resolution := rpcHtlcResolution(&test.event)
+			require.Equal(t, test.outcome, resolution.Outcome)
+			require.Equal(
+				t, test.wireFailure, resolution.WireFailure,
+			)
+			require.Equal(
+				t, test.failureDetail, resolution.FailureDetail,
+			)
+		})
+	}
+}
@@ -82,6 +82,11 @@ type RouterBackend struct {
 	// htlc events.
 	SubscribeHtlcEvents func() (*subscribe.Client, error)
 
+	// QueryHtlcEvents queries the persistent htlc event log for resolved
+	// forwards.
+	QueryHtlcEvents func(channeldb.HtlcEventQuery) (
+		channeldb.HtlcEventTimeSlice, error)
+
 	// InterceptableForwarder exposes the ability to intercept forward events
 	// by letting the router register a ForwardInterceptor.
 	InterceptableForwarder htlcswitch.InterceptableHtlcForwarder
@@ -443,6 +448,7 @@ func (r *RouterBackend) MarshallRoute(route *route.Route) (*lnrpc.Route, error)
 			CustomRecords: hop.CustomRecords,
 			TlvPayload:    !hop.LegacyPayload,
 			MppRecord:     mpp,
+			Metadata:      hop.Metadata,
 		}
 		incomingAmt = hop.AmtToForward
 	}
@@ -479,6 +485,7 @@ func UnmarshallHopWithPubkey(rpcHop *lnrpc.Hop, pubkey route.Vertex) (*route.Hop
 		LegacyPayload:    !rpcHop.TlvPayload,
 		MPP:              mpp,
 		AMP:              amp,
+		Metadata:         rpcHop.Metadata,
 	}, nil
 }
 
@@ -611,6 +618,14 @@ func (r *RouterBackend) extractIntentFromSendRequest(
 		payIntent.MaxShardAmt = &shardAmtMsat
 	}
 
+	// Store the label of the payment, so it can be looked up later on.
+	if len(rpcPayReq.PaymentLabel) > channeldb.MaxPaymentLabelLength {
+		return nil, fmt.Errorf("payment label length %v exceeds "+
+			"limit of %v", len(rpcPayReq.PaymentLabel),
+			channeldb.MaxPaymentLabelLength)
+	}
+	payIntent.Label = rpcPayReq.PaymentLabel
+
 	// Take fee limit from request.
 	payIntent.FeeLimit, err = lnrpc.UnmarshallAmt(
 		rpcPayReq.FeeLimitSat, rpcPayReq.FeeLimitMsat,
@@ -758,7 +773,11 @@ func (r *RouterBackend) extractIntentFromSendRequest(
 		)
 		payIntent.DestFeatures = payReq.Features
 		payIntent.PaymentAddr = payAddr
+		payIntent.Metadata = payReq.Metadata
 		payIntent.PaymentRequest = []byte(rpcPayReq.PaymentRequest)
+		if payReq.Description != nil {
+			payIntent.Description = *payReq.Description
+		}
 	} else {
 		// Otherwise, If the payment request field was not specified
 		// (and a custom route wasn't specified), construct the payment
@@ -1359,6 +1378,16 @@ func (r *RouterBackend) MarshallPayment(payment *channeldb.MPPayment) (
 		return nil, err
 	}
 
+	var dest string
+	if payment.Info.Destination != (route.Vertex{}) {
+		dest = payment.Info.Destination.String()
+	}
+
+	var paymentAddr []byte
+	if payment.Info.PaymentAddr != nil {
+		paymentAddr = payment.Info.PaymentAddr[:]
+	}
+
 	return &lnrpc.Payment{
 		// TODO: set this to setID for AMP-payments?
 		PaymentHash:     hex.EncodeToString(paymentID[:]),
@@ -1376,6 +1405,10 @@ func (r *RouterBackend) MarshallPayment(payment *channeldb.MPPayment) (
 		Htlcs:           htlcs,
 		PaymentIndex:    payment.SequenceNum,
 		FailureReason:   failureReason,
+		Dest:            dest,
+		PaymentAddr:     paymentAddr,
+		Description:     payment.Info.Description,
+		PaymentLabel:    payment.Info.Label,
 	}, nil
 }
 
@@ -1402,6 +1435,29 @@ func convertPaymentStatus(dbStatus channeldb.PaymentStatus) (
 	}
 }
 
+// UnmarshallPaymentStatus converts an rpc payment status into the
+// corresponding channeldb.PaymentStatus.
+func UnmarshallPaymentStatus(status lnrpc.Payment_PaymentStatus) (
+	channeldb.PaymentStatus, error) {
+
+	switch status {
+	case lnrpc.Payment_UNKNOWN:
+		return channeldb.StatusUnknown, nil
+
+	case lnrpc.Payment_IN_FLIGHT:
+		return channeldb.StatusInFlight, nil
+
+	case lnrpc.Payment_SUCCEEDED:
+		return channeldb.StatusSucceeded, nil
+
+	case lnrpc.Payment_FAILED:
+		return channeldb.StatusFailed, nil
+
+	default:
+		return 0, fmt.Errorf("unknown payment status %v", status)
+	}
+}
+
 // marshallPaymentFailureReason marshalls the failure reason to the corresponding rpc
 // type.
 func marshallPaymentFailureReason(reason *channeldb.FailureReason) (
@@ -109,6 +109,10 @@ var (
 			Entity: "offchain",
 			Action: "read",
 		}},
+		"/routerrpc.Router/HtlcEventHistory": {{
+			Entity: "offchain",
+			Action: "read",
+		}},
 		"/routerrpc.Router/SendPayment": {{
 			Entity: "offchain",
 			Action: "write",
@@ -868,6 +872,65 @@ func (s *Server) SubscribeHtlcEvents(req *SubscribeHtlcEventsRequest,
 	}
 }
 
+// HtlcEventHistory queries the persistent htlc event log for the outcome of
+// the htlcs that we were asked to forward.
+func (s *Server) HtlcEventHistory(ctx context.Context,
+	req *HtlcEventHistoryRequest) (*HtlcEventHistoryResponse, error) {
+
+	// If the end time wasn't specified, assume a default end time of now.
+	endTime := time.Now()
+	if req.EndTime != 0 {
+		endTime = time.Unix(int64(req.EndTime), 0)
+	}
+
+	// If the number of events wasn't specified, then we'll default to
+	// returning 100 events.
+	numEvents := req.NumMaxEvents
+	if numEvents == 0 {
+		numEvents = 100
+	}
+
+	query := channeldb.HtlcEventQuery{
+		StartTime:    time.Unix(int64(req.StartTime), 0),
+		EndTime:      endTime,
+		IndexOffset:  req.IndexOffset,
+		NumMaxEvents: numEvents,
+	}
+	for _, chanID := range req.IncomingChanIds {
+		query.IncomingChanIDs = append(
+			query.IncomingChanIDs,
+			lnwire.NewShortChanIDFromInt(chanID),
+		)
+	}
+	for _, chanID := range req.OutgoingChanIds {
+		query.OutgoingChanIDs = append(
+			query.OutgoingChanIDs,
+			lnwire.NewShortChanIDFromInt(chanID),
+		)
+	}
+
+	timeSlice, err := s.cfg.RouterBackend.QueryHtlcEvents(query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query htlc event log: %v",
+			err)
+	}
+
+	resp := &HtlcEventHistoryResponse{
+		Resolutions: make(
+			[]*HtlcResolution, 0, len(timeSlice.HtlcEvents),
+		),
+		LastOffsetIndex: timeSlice.LastIndexOffset,
+	}
+	for i := range timeSlice.HtlcEvents {
+		resp.Resolutions = append(
+			resp.Resolutions,
+			rpcHtlcResolution(&timeSlice.HtlcEvents[i]),
+		)
+	}
+
+	return resp, nil
+}
+
 // HtlcInterceptor is a bidirectional stream for streaming interception
 // requests to the caller.
 // Upon connection it does the following:
@@ -110,6 +110,7 @@ func rpcInfo(info htlcswitch.HtlcInfo) *HtlcInfo {
 		OutgoingTimelock: info.OutgoingTimeLock,
 		IncomingAmtMsat:  uint64(info.IncomingAmt),
 		OutgoingAmtMsat:  uint64(info.OutgoingAmt),
+		CustomRecords:    info.CustomRecords,
 	}
 }
 
@@ -138,6 +139,10 @@ func rpcFailReason(linkErr *htlcswitch.LinkError) (lnrpc.Failure_FailureCode,
 		fd, err := rpcOutgoingFailure(failureDetail)
 		return wireCode, fd, err
 
+	case htlcswitch.IncomingFailure:
+		fd, err := rpcIncomingFailure(failureDetail)
+		return wireCode, fd, err
+
 	default:
 		return 0, 0, fmt.Errorf("unknown failure "+
 			"detail type: %T", linkErr.FailureDetail)
@@ -240,8 +245,31 @@ func rpcOutgoingFailure(failureDetail htlcswitch.OutgoingFailure) (
 	case htlcswitch.OutgoingFailureForwardsDisabled:
 		return FailureDetail_FORWARDS_DISABLED, nil
 
+	case htlcswitch.OutgoingFailureHtlcSlotsReserved:
+		return FailureDetail_HTLC_SLOTS_RESERVED, nil
+
+	case htlcswitch.OutgoingFailureHtlcValueReserved:
+		return FailureDetail_HTLC_VALUE_RESERVED, nil
+
 	default:
 		return 0, fmt.Errorf("unknown outgoing failure "+
 			"detail: %v", failureDetail.FailureString())
 	}
 }
+
+// rpcIncomingFailure maps an incoming failure to a rpc FailureDetail.
+func rpcIncomingFailure(failureDetail htlcswitch.IncomingFailure) (
+	FailureDetail, error) {
+
+	switch failureDetail {
+	case htlcswitch.IncomingFailureNone:
+		return FailureDetail_NO_DETAIL, nil
+
+	case htlcswitch.IncomingFailureRateLimited:
+		return FailureDetail_RATE_LIMITED, nil
+
+	default:
+		return 0, fmt.Errorf("unknown incoming failure "+
+			"detail: %v", failureDetail.FailureString())
+	}
+}
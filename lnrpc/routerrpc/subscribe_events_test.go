@@ -0,0 +1,63 @@
+package routerrpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/htlcswitch"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRpcHtlcEventCustomRecords tests that the custom records of a forward are
+// passed on unchanged to subscribers of htlc events.
+func TestRpcHtlcEventCustomRecords(t *testing.T) {
+	t.Parallel()
+
+	customRecords := record.CustomSet{
+		record.CustomTypeStart:     []byte{1, 2, 3},
+		record.CustomTypeStart + 1: []byte("bob"),
+	}
+
+	event := &htlcswitch.ForwardingEvent{
+		HtlcKey: htlcswitch.HtlcKey{
+			IncomingCircuit: channeldb.CircuitKey{
+				ChanID: lnwire.NewShortChanIDFromInt(1),
+				HtlcID: 2,
+			},
+			OutgoingCircuit: channeldb.CircuitKey{
+				ChanID: lnwire.NewShortChanIDFromInt(3),
+				HtlcID: 4,
+			},
+		},
+		HtlcInfo: htlcswitch.HtlcInfo{
+			IncomingTimeLock: 110,
+			OutgoingTimeLock: 100,
+			IncomingAmt:      1010,
+			OutgoingAmt:      1000,
+			CustomRecords:    customRecords,
+		},
+		HtlcEventType: htlcswitch.HtlcEventTypeForward,
+		Timestamp:     time.Unix(1000, 0),
+	}
+
+	rpcEvent, err := rpcHtlcEvent(event)
+	require.NoError(t, err)
+
+	// The records should survive the conversion as well as the encoding
+	// that is sent to subscribers.
+	b, err := proto.Marshal(rpcEvent)
+	require.NoError(t, err)
+
+	var received HtlcEvent
+	require.NoError(t, proto.Unmarshal(b, &received))
+
+	info := received.GetForwardEvent().GetInfo()
+	require.NotNil(t, info)
+	require.Equal(
+		t, map[uint64][]byte(customRecords), info.CustomRecords,
+	)
+}
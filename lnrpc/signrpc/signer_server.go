@@ -453,20 +453,30 @@ func (s *Server) SignMessage(ctx context.Context,
 	}
 
 	// Describe the private key we'll be using for signing.
-	keyDescriptor := keychain.KeyDescriptor{
-		KeyLocator: keychain.KeyLocator{
-			Family: keychain.KeyFamily(in.KeyLoc.KeyFamily),
-			Index:  uint32(in.KeyLoc.KeyIndex),
-		},
+	keyLocator := keychain.KeyLocator{
+		Family: keychain.KeyFamily(in.KeyLoc.KeyFamily),
+		Index:  uint32(in.KeyLoc.KeyIndex),
 	}
 
-	// The signature is over the sha256 hash of the message.
-	var digest [32]byte
-	copy(digest[:], chainhash.HashB(in.Msg))
+	// The compact format encodes the signature together with the
+	// information needed to recover the public key.
+	if in.CompactSig {
+		sig, err := s.cfg.KeyRing.SignMessageCompact(
+			keyLocator, in.Msg, in.DoubleHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("can't sign the hash: %v", err)
+		}
+
+		return &SignMessageResp{
+			Signature: sig,
+		}, nil
+	}
 
-	// Create the raw ECDSA signature first and convert it to the final wire
-	// format after.
-	sig, err := s.cfg.KeyRing.SignDigest(keyDescriptor, digest)
+	// The signature is over the single or double sha256 hash of the
+	// message. Create the raw ECDSA signature first and convert it to the
+	// final wire format after.
+	sig, err := s.cfg.KeyRing.SignMessage(keyLocator, in.Msg, in.DoubleHash)
 	if err != nil {
 		return nil, fmt.Errorf("can't sign the hash: %v", err)
 	}
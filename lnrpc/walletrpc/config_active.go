@@ -4,6 +4,7 @@ package walletrpc
 
 import (
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
@@ -59,4 +60,12 @@ type Config struct {
 
 	// ChainParams are the parameters of the wallet's backing chain.
 	ChainParams *chaincfg.Params
+
+	// IsChannelFundingTx returns whether the transaction with the given
+	// txid funds one of our pending channels.
+	IsChannelFundingTx func(txid chainhash.Hash) (bool, error)
+
+	// CoinType is the coin type of the key scope that lnd derives the keys
+	// of its internal key families from.
+	CoinType uint32
 }
@@ -23,12 +23,17 @@ var (
 )
 
 // verifyInputsUnspent checks that all inputs are contained in the list of
-// known, non-locked UTXOs given.
+// known, non-locked and non-frozen UTXOs given.
 func verifyInputsUnspent(inputs []*wire.TxIn, utxos []*lnwallet.Utxo) error {
 	// TODO(guggero): Pass in UTXOs as a map to make lookup more efficient.
 	for idx, txIn := range inputs {
 		found := false
 		for _, u := range utxos {
+			// Frozen outputs must not be spent.
+			if u.Frozen {
+				continue
+			}
+
 			if u.OutPoint == txIn.PreviousOutPoint {
 				found = true
 				break
@@ -37,7 +42,7 @@ func verifyInputsUnspent(inputs []*wire.TxIn, utxos []*lnwallet.Utxo) error {
 
 		if !found {
 			return fmt.Errorf("input %d not found in list of non-"+
-				"locked UTXO", idx)
+				"locked, non-frozen UTXO", idx)
 		}
 	}
 
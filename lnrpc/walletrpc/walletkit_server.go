@@ -13,8 +13,10 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
@@ -100,6 +102,10 @@ var (
 			Entity: "onchain",
 			Action: "write",
 		}},
+		"/walletrpc.WalletKit/BumpTransactionFee": {{
+			Entity: "onchain",
+			Action: "write",
+		}},
 		"/walletrpc.WalletKit/ListSweeps": {{
 			Entity: "onchain",
 			Action: "read",
@@ -120,6 +126,26 @@ var (
 			Entity: "onchain",
 			Action: "read",
 		}},
+		"/walletrpc.WalletKit/LabelAddress": {{
+			Entity: "address",
+			Action: "write",
+		}},
+		"/walletrpc.WalletKit/LabelOutput": {{
+			Entity: "onchain",
+			Action: "write",
+		}},
+		"/walletrpc.WalletKit/FreezeOutput": {{
+			Entity: "onchain",
+			Action: "write",
+		}},
+		"/walletrpc.WalletKit/UnfreezeOutput": {{
+			Entity: "onchain",
+			Action: "write",
+		}},
+		"/walletrpc.WalletKit/ListFrozenOutputs": {{
+			Entity: "onchain",
+			Action: "read",
+		}},
 		"/walletrpc.WalletKit/ListUnspent": {{
 			Entity: "onchain",
 			Action: "read",
@@ -132,6 +158,10 @@ var (
 			Entity: "onchain",
 			Action: "write",
 		}},
+		"/walletrpc.WalletKit/SignPsbt": {{
+			Entity: "onchain",
+			Action: "write",
+		}},
 		"/walletrpc.WalletKit/ListAccounts": {{
 			Entity: "onchain",
 			Action: "read",
@@ -168,6 +198,24 @@ var (
 // an empty label.
 var ErrZeroLabel = errors.New("cannot label transaction with empty label")
 
+// errRbfNotPossible is returned when a wallet transaction can't be replaced by
+// one paying a higher fee.
+var errRbfNotPossible = errors.New("transaction can't be replaced")
+
+// ErrFundingTxNotReplaceable is returned when the fee of a channel funding
+// transaction can only be bumped by replacing it. Replacing a funding
+// transaction changes the channel point that the commitment transactions
+// signed by the channel peer spend from, so it is not supported.
+var ErrFundingTxNotReplaceable = errors.New("channel funding transactions " +
+	"can't be replaced, their fee can only be bumped through a wallet " +
+	"output")
+
+// ErrSweepTxNotBumpable is returned when the fee of a transaction published
+// by the sweeper is bumped. The sweeper replaces its own transactions, so their
+// fee must be bumped through BumpFee on the swept inputs instead.
+var ErrSweepTxNotBumpable = errors.New("sweep transactions can only be " +
+	"bumped through BumpFee on their inputs")
+
 // ServerShell is a shell struct holding a reference to the actual sub-server.
 // It is used to register the gRPC sub-server with the root server before we
 // have the necessary dependencies to populate the actual sub-server.
@@ -385,7 +433,7 @@ func (w *WalletKit) LeaseOutput(ctx context.Context,
 		return nil, errors.New("reserved id cannot be used")
 	}
 
-	op, err := unmarshallOutPoint(req.Outpoint)
+	op, err := lnrpc.UnmarshallOutPoint(req.Outpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -426,7 +474,7 @@ func (w *WalletKit) ReleaseOutput(ctx context.Context,
 	var lockID wtxmgr.LockID
 	copy(lockID[:], req.Id)
 
-	op, err := unmarshallOutPoint(req.Outpoint)
+	op, err := lnrpc.UnmarshallOutPoint(req.Outpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -457,6 +505,119 @@ func (w *WalletKit) ListLeases(ctx context.Context,
 	}, nil
 }
 
+// LabelAddress attaches a label and a tag to an address of the wallet. Outputs
+// paying to the address inherit its label and tag unless they have their own.
+func (w *WalletKit) LabelAddress(ctx context.Context,
+	req *LabelAddressRequest) (*LabelAddressResponse, error) {
+
+	addr, err := btcutil.DecodeAddress(req.Address, w.cfg.ChainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.cfg.Wallet.LabelAddress(addr, req.Label, req.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LabelAddressResponse{}, nil
+}
+
+// LabelOutput attaches a label and a tag to an output of the wallet.
+func (w *WalletKit) LabelOutput(ctx context.Context,
+	req *LabelOutputRequest) (*LabelOutputResponse, error) {
+
+	op, err := lnrpc.UnmarshallOutPoint(req.Outpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.cfg.Wallet.LabelOutput(*op, req.Label, req.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LabelOutputResponse{}, nil
+}
+
+// FreezeOutput excludes an unspent output of the wallet from coin selection
+// until it is unfrozen.
+func (w *WalletKit) FreezeOutput(ctx context.Context,
+	req *FreezeOutputRequest) (*FreezeOutputResponse, error) {
+
+	op, err := lnrpc.UnmarshallOutPoint(req.Outpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// Acquire the global coin selection lock to make sure the output isn't
+	// picked by a concurrent coin selection while we freeze it.
+	err = w.cfg.CoinSelectionLocker.WithCoinSelectLock(func() error {
+		return w.cfg.Wallet.FreezeOutput(*op)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FreezeOutputResponse{}, nil
+}
+
+// UnfreezeOutput makes a frozen output available to coin selection again.
+func (w *WalletKit) UnfreezeOutput(ctx context.Context,
+	req *UnfreezeOutputRequest) (*UnfreezeOutputResponse, error) {
+
+	op, err := lnrpc.UnmarshallOutPoint(req.Outpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.cfg.CoinSelectionLocker.WithCoinSelectLock(func() error {
+		return w.cfg.Wallet.UnfreezeOutput(*op)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UnfreezeOutputResponse{}, nil
+}
+
+// ListFrozenOutputs lists all unspent outputs of the wallet that are frozen.
+// It is a shortcut for filtering the outputs returned by ListUnspent by their
+// frozen flag.
+func (w *WalletKit) ListFrozenOutputs(ctx context.Context,
+	req *ListFrozenOutputsRequest) (*ListFrozenOutputsResponse, error) {
+
+	var utxos []*lnwallet.Utxo
+	err := w.cfg.CoinSelectionLocker.WithCoinSelectLock(func() error {
+		unspent, err := w.cfg.Wallet.ListUnspentWitness(
+			0, defaultMaxConf, "",
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, utxo := range unspent {
+			if utxo.Frozen {
+				utxos = append(utxos, utxo)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rpcUtxos, err := lnrpc.MarshalUtxos(utxos, w.cfg.ChainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListFrozenOutputsResponse{
+		Utxos: rpcUtxos,
+	}, nil
+}
+
 // DeriveNextKey attempts to derive the *next* key within the key family
 // (account in BIP43) specified. This method should return the next external
 // child within this branch.
@@ -718,41 +879,6 @@ func (w *WalletKit) PendingSweeps(ctx context.Context,
 	}, nil
 }
 
-// unmarshallOutPoint converts an outpoint from its lnrpc type to its canonical
-// type.
-func unmarshallOutPoint(op *lnrpc.OutPoint) (*wire.OutPoint, error) {
-	if op == nil {
-		return nil, fmt.Errorf("empty outpoint provided")
-	}
-
-	var hash chainhash.Hash
-	switch {
-	case len(op.TxidBytes) == 0 && len(op.TxidStr) == 0:
-		fallthrough
-
-	case len(op.TxidBytes) != 0 && len(op.TxidStr) != 0:
-		return nil, fmt.Errorf("either TxidBytes or TxidStr must be " +
-			"specified, but not both")
-
-	// The hash was provided as raw bytes.
-	case len(op.TxidBytes) != 0:
-		copy(hash[:], op.TxidBytes)
-
-	// The hash was provided as a hex-encoded string.
-	case len(op.TxidStr) != 0:
-		h, err := chainhash.NewHashFromStr(op.TxidStr)
-		if err != nil {
-			return nil, err
-		}
-		hash = *h
-	}
-
-	return &wire.OutPoint{
-		Hash:  hash,
-		Index: op.OutputIndex,
-	}, nil
-}
-
 // BumpFee allows bumping the fee rate of an arbitrary input. A fee preference
 // can be expressed either as a specific fee rate or a delta of blocks in which
 // the output should be swept on-chain within. If a fee preference is not
@@ -762,7 +888,7 @@ func (w *WalletKit) BumpFee(ctx context.Context,
 	in *BumpFeeRequest) (*BumpFeeResponse, error) {
 
 	// Parse the outpoint from the request.
-	op, err := unmarshallOutPoint(in.Outpoint)
+	op, err := lnrpc.UnmarshallOutPoint(in.Outpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -812,17 +938,31 @@ func (w *WalletKit) BumpFee(ctx context.Context,
 	// sweeping an output within it under control of the wallet with a
 	// higher fee rate, essentially performing a Child-Pays-For-Parent
 	// (CPFP).
-	//
+	if err := w.sweepWalletOutput(op, feePreference, nil); err != nil {
+		return nil, err
+	}
+
+	return &BumpFeeResponse{}, nil
+}
+
+// sweepWalletOutput hands an output of an unconfirmed transaction that is
+// under control of the wallet to the UtxoSweeper, which bumps the fee of the
+// transaction by sweeping the output with the given fee preference (CPFP). If
+// the fee and weight of the unconfirmed transaction are given, the fee
+// preference applies to the package of the transaction and the sweep.
+func (w *WalletKit) sweepWalletOutput(op *wire.OutPoint,
+	feePreference sweep.FeePreference, parent *input.TxInfo) error {
+
 	// We'll gather all of the information required by the UtxoSweeper in
 	// order to sweep the output.
 	utxo, err := w.cfg.Wallet.FetchInputInfo(op)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// We're only able to bump the fee of unconfirmed transactions.
 	if utxo.Confirmations > 0 {
-		return nil, errors.New("unable to bump fee of a confirmed " +
+		return errors.New("unable to bump fee of a confirmed " +
 			"transaction")
 	}
 
@@ -833,7 +973,7 @@ func (w *WalletKit) BumpFee(ctx context.Context,
 	case lnwallet.NestedWitnessPubKey:
 		witnessType = input.NestedWitnessKeyHash
 	default:
-		return nil, fmt.Errorf("unknown input witness %v", op)
+		return fmt.Errorf("unknown input witness %v", op)
 	}
 
 	signDesc := &input.SignDescriptor{
@@ -848,16 +988,381 @@ func (w *WalletKit) BumpFee(ctx context.Context,
 	// with an unconfirmed transaction.
 	_, currentHeight, err := w.cfg.Chain.GetBestBlock()
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve current height: %v",
+		return fmt.Errorf("unable to retrieve current height: %v",
 			err)
 	}
 
-	input := input.NewBaseInput(op, witnessType, signDesc, uint32(currentHeight))
-	if _, err = w.cfg.Sweeper.SweepInput(input, sweep.Params{Fee: feePreference}); err != nil {
+	inp := input.MakeBaseInput(
+		op, witnessType, signDesc, uint32(currentHeight), parent,
+	)
+	_, err = w.cfg.Sweeper.SweepInput(&inp, sweep.Params{
+		Fee: feePreference,
+	})
+
+	return err
+}
+
+// BumpTransactionFee bumps the fee of an unconfirmed wallet transaction. If
+// possible, the transaction is replaced by one paying the requested fee rate
+// (RBF). Otherwise, a wallet output of the transaction is swept by a child
+// that brings the package of both to the requested fee rate (CPFP).
+func (w *WalletKit) BumpTransactionFee(ctx context.Context,
+	in *BumpTransactionFeeRequest) (*BumpTransactionFeeResponse, error) {
+
+	txid, err := chainhash.NewHash(in.Txid)
+	if err != nil {
 		return nil, err
 	}
 
-	return &BumpFeeResponse{}, nil
+	if in.TargetConf == 0 && in.SatPerVbyte == 0 {
+		return nil, errors.New("either target_conf or sat_per_vbyte " +
+			"must be set")
+	}
+	feePreference := sweep.FeePreference{
+		ConfTarget: in.TargetConf,
+		FeeRate: chainfee.SatPerKVByte(
+			in.SatPerVbyte * 1000,
+		).FeePerKWeight(),
+	}
+	feeRate, err := sweep.DetermineFeePerKw(
+		w.cfg.FeeEstimator, feePreference,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only unconfirmed transactions of our wallet can be bumped.
+	txDetails, err := w.cfg.Wallet.ListTransactionDetails(
+		btcwallet.UnconfirmedHeight, btcwallet.UnconfirmedHeight, "",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var txDetail *lnwallet.TransactionDetail
+	for _, detail := range txDetails {
+		if detail.Hash == *txid {
+			txDetail = detail
+			break
+		}
+	}
+	if txDetail == nil {
+		return nil, fmt.Errorf("transaction %v is not an unconfirmed "+
+			"wallet transaction", txid)
+	}
+
+	tx := &wire.MsgTx{}
+	if err := tx.Deserialize(bytes.NewReader(txDetail.RawTx)); err != nil {
+		return nil, err
+	}
+
+	// The sweeper keeps track of the transactions it publishes and
+	// replaces them itself, so we leave those to it.
+	isSweepTx, err := w.cfg.Sweeper.IsSweepTx(*txid)
+	if err != nil {
+		return nil, err
+	}
+	if isSweepTx {
+		return nil, fmt.Errorf("unable to bump fee of transaction %v: "+
+			"%w", txid, ErrSweepTxNotBumpable)
+	}
+
+	// Replacing a channel funding transaction would change the channel
+	// point our commitment transactions spend from, so we can only bump
+	// those through CPFP. The peer's signatures for our commitment
+	// transactions would be invalidated by the replacement, so it can't
+	// be done without renegotiating the channel.
+	isFundingTx, err := w.cfg.IsChannelFundingTx(*txid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isFundingTx {
+		var replacementTxid *chainhash.Hash
+		replace := func() error {
+			var err error
+			replacementTxid, err = w.replaceByFee(
+				tx, feeRate, txDetail.Label,
+			)
+			return err
+		}
+
+		err := w.cfg.CoinSelectionLocker.WithCoinSelectLock(replace)
+		switch {
+		case err == errRbfNotPossible:
+			log.Debugf("Unable to replace transaction %v, "+
+				"attempting to CPFP", txid)
+
+		case err != nil:
+			return nil, err
+
+		default:
+			return &BumpTransactionFeeResponse{
+				Method:          FeeBumpMethod_REPLACE_BY_FEE,
+				ReplacementTxid: replacementTxid.String(),
+			}, nil
+		}
+	}
+
+	// The transaction can't be replaced, so we'll sweep its largest
+	// wallet output instead. Outputs that are already spent by another
+	// unconfirmed transaction can't be swept.
+	spentOutputs, err := unconfirmedSpends(*txid, txDetails)
+	if err != nil {
+		return nil, err
+	}
+	changeIndex := w.largestWalletOutput(tx, spentOutputs, false)
+	if changeIndex < 0 && isFundingTx {
+		return nil, fmt.Errorf("unable to bump fee of transaction %v: "+
+			"%w", txid, ErrFundingTxNotReplaceable)
+	}
+	if changeIndex < 0 {
+		return nil, fmt.Errorf("unable to bump fee of transaction %v: "+
+			"it can't be replaced and has no unspent wallet "+
+			"output", txid)
+	}
+	op := &wire.OutPoint{
+		Hash:  *txid,
+		Index: uint32(changeIndex),
+	}
+
+	// The fee of the transaction is only known if all its inputs belong
+	// to the wallet. If it isn't, the sweep alone pays the fee rate.
+	var parent *input.TxInfo
+	if txDetail.TotalFees > 0 {
+		parent = &input.TxInfo{
+			Fee: btcutil.Amount(txDetail.TotalFees),
+			Weight: blockchain.GetTransactionWeight(
+				btcutil.NewTx(tx),
+			),
+		}
+	}
+
+	err = w.sweepWalletOutput(
+		op, sweep.FeePreference{FeeRate: feeRate}, parent,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BumpTransactionFeeResponse{
+		Method: FeeBumpMethod_CHILD_PAYS_FOR_PARENT,
+		CpfpOutpoint: &lnrpc.OutPoint{
+			TxidBytes:   txid[:],
+			TxidStr:     txid.String(),
+			OutputIndex: uint32(changeIndex),
+		},
+	}, nil
+}
+
+// replaceByFee re-signs the given wallet transaction with the given fee rate
+// and publishes it as a replacement. The higher fee is taken from the largest
+// change output of the transaction. Other wallet outputs, like a payment to one
+// of our own addresses, are left untouched. errRbfNotPossible is returned if
+// the transaction can't be replaced.
+//
+// NOTE: This must be called while holding the coin selection lock.
+func (w *WalletKit) replaceByFee(tx *wire.MsgTx,
+	feeRate chainfee.SatPerKWeight, label string) (*chainhash.Hash, error) {
+
+	// Replacing the transaction would also evict all unconfirmed wallet
+	// transactions spending its outputs, for example one that funds a
+	// channel. As new spends are only created under the coin selection
+	// lock, this check can't race with them.
+	txDetails, err := w.cfg.Wallet.ListTransactionDetails(
+		btcwallet.UnconfirmedHeight, btcwallet.UnconfirmedHeight, "",
+	)
+	if err != nil {
+		return nil, err
+	}
+	spentOutputs, err := unconfirmedSpends(tx.TxHash(), txDetails)
+	if err != nil {
+		return nil, err
+	}
+	if len(spentOutputs) > 0 {
+		log.Debugf("Transaction %v has unconfirmed descendants",
+			tx.TxHash())
+		return nil, errRbfNotPossible
+	}
+
+	// We can only re-sign the transaction if we own all of its inputs.
+	var (
+		weightEstimate input.TxWeightEstimator
+		inputSum       int64
+		utxos          = make([]*wire.TxOut, len(tx.TxIn))
+	)
+	for idx, txIn := range tx.TxIn {
+		utxo, err := w.cfg.Wallet.FetchInputInfo(
+			&txIn.PreviousOutPoint,
+		)
+		if err != nil {
+			log.Debugf("Unable to fetch input %v: %v",
+				txIn.PreviousOutPoint, err)
+			return nil, errRbfNotPossible
+		}
+
+		switch utxo.AddressType {
+		case lnwallet.WitnessPubKey:
+			weightEstimate.AddP2WKHInput()
+		case lnwallet.NestedWitnessPubKey:
+			weightEstimate.AddNestedP2WKHInput()
+		default:
+			return nil, errRbfNotPossible
+		}
+
+		inputSum += int64(utxo.Value)
+		utxos[idx] = &wire.TxOut{
+			Value:    int64(utxo.Value),
+			PkScript: utxo.PkScript,
+		}
+	}
+
+	var outputSum int64
+	for _, txOut := range tx.TxOut {
+		weightEstimate.AddTxOutput(txOut)
+		outputSum += txOut.Value
+	}
+
+	changeIndex := w.largestWalletOutput(tx, nil, true)
+	if changeIndex < 0 {
+		return nil, errRbfNotPossible
+	}
+
+	// The replacement must pay more than the original transaction, and
+	// the increase must at least pay for relaying the replacement.
+	weight := int64(weightEstimate.Weight())
+	oldFee := btcutil.Amount(inputSum - outputSum)
+	newFee := feeRate.FeeForWeight(weight)
+	minFee := oldFee + chainfee.FeePerKwFloor.FeeForWeight(weight)
+	if newFee < minFee {
+		return nil, fmt.Errorf("fee rate of %v is too low to replace "+
+			"the transaction, it must pay a fee of at least %v",
+			feeRate, minFee)
+	}
+
+	// The change output pays for the fee increase and must not become
+	// dust.
+	replacement := tx.Copy()
+	change := replacement.TxOut[changeIndex]
+	change.Value -= int64(newFee - oldFee)
+	if btcutil.Amount(change.Value) < lnwallet.DefaultDustLimit() {
+		return nil, errRbfNotPossible
+	}
+
+	// We'll signal replaceability, so the replacement can be bumped
+	// again, and clear the signatures of the original transaction.
+	for _, txIn := range replacement.TxIn {
+		if txIn.Sequence > mempool.MaxRBFSequence {
+			txIn.Sequence = mempool.MaxRBFSequence
+		}
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+
+	packet, err := psbt.NewFromUnsignedTx(replacement)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range packet.Inputs {
+		packet.Inputs[idx].WitnessUtxo = utxos[idx]
+	}
+
+	err = w.cfg.Wallet.FinalizePsbt(packet, lnwallet.DefaultAccountName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign replacement: %v", err)
+	}
+	replacement, err = psbt.Extract(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	// The backend rejects the replacement if the original transaction
+	// doesn't signal replaceability or it doesn't support RBF at all.
+	err = w.cfg.Wallet.PublishTransaction(replacement, label)
+	switch {
+	case err == lnwallet.ErrDoubleSpend:
+		return nil, errRbfNotPossible
+
+	case err != nil:
+		return nil, err
+	}
+
+	// Now that the replacement was accepted, the original transaction and
+	// anything spending its outputs won't confirm anymore.
+	if err := w.cfg.Wallet.RemoveUnminedTx(tx); err != nil {
+		return nil, fmt.Errorf("unable to remove replaced "+
+			"transaction: %v", err)
+	}
+
+	replacementTxid := replacement.TxHash()
+	log.Infof("Replaced transaction %v with %v paying a fee of %v",
+		tx.TxHash(), replacementTxid, newFee)
+
+	return &replacementTxid, nil
+}
+
+// largestWalletOutput returns the index of the largest output of the given
+// transaction that pays to the wallet and isn't in the skip set, or -1 if there
+// is none. If changeOnly is set, only outputs paying to a change address of
+// the wallet are considered.
+func (w *WalletKit) largestWalletOutput(tx *wire.MsgTx,
+	skip map[uint32]struct{}, changeOnly bool) int {
+
+	isOurs := w.cfg.Wallet.IsOurAddress
+	if changeOnly {
+		isOurs = w.cfg.Wallet.IsChangeAddress
+	}
+
+	index := -1
+	for idx, txOut := range tx.TxOut {
+		if _, ok := skip[uint32(idx)]; ok {
+			continue
+		}
+
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			txOut.PkScript, w.cfg.ChainParams,
+		)
+		if err != nil || len(addrs) != 1 {
+			continue
+		}
+		if !isOurs(addrs[0]) {
+			continue
+		}
+
+		if index < 0 || txOut.Value > tx.TxOut[index].Value {
+			index = idx
+		}
+	}
+
+	return index
+}
+
+// unconfirmedSpends returns the indexes of the outputs of the transaction with
+// the given txid that are spent by any of the given unconfirmed wallet
+// transactions.
+func unconfirmedSpends(txid chainhash.Hash,
+	txDetails []*lnwallet.TransactionDetail) (map[uint32]struct{}, error) {
+
+	spent := make(map[uint32]struct{})
+	for _, detail := range txDetails {
+		if detail.Hash == txid {
+			continue
+		}
+
+		tx := &wire.MsgTx{}
+		err := tx.Deserialize(bytes.NewReader(detail.RawTx))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, txIn := range tx.TxIn {
+			if txIn.PreviousOutPoint.Hash == txid {
+				spent[txIn.PreviousOutPoint.Index] = struct{}{}
+			}
+		}
+	}
+
+	return spent, nil
 }
 
 // ListSweeps returns a list of the sweeps that our node has published.
@@ -1022,7 +1527,7 @@ func (w *WalletKit) FundPsbt(_ context.Context,
 
 		txIn := make([]*wire.OutPoint, len(tpl.Inputs))
 		for idx, in := range tpl.Inputs {
-			op, err := unmarshallOutPoint(in)
+			op, err := lnrpc.UnmarshallOutPoint(in)
 			if err != nil {
 				return nil, fmt.Errorf("error parsing "+
 					"outpoint: %v", err)
@@ -1116,7 +1621,7 @@ func (w *WalletKit) FundPsbt(_ context.Context,
 		// lock any coins but might still change the wallet DB by
 		// generating a new change address.
 		changeIndex, err = w.cfg.Wallet.FundPsbt(
-			packet, feeSatPerKW, account,
+			packet, minConfs, feeSatPerKW, account,
 		)
 		if err != nil {
 			return fmt.Errorf("wallet couldn't fund PSBT: %v", err)
@@ -1241,6 +1746,38 @@ func (w *WalletKit) FinalizePsbt(_ context.Context,
 	}, nil
 }
 
+// SignPsbt expects a partial transaction with all inputs and outputs fully
+// declared and adds a partial signature to all inputs that have the BIP32
+// derivation path of one of the wallet's keys attached. Inputs without
+// derivation information or with final witness data are skipped.
+//
+// NOTE: This method does NOT finalize the inputs it signed.
+func (w *WalletKit) SignPsbt(_ context.Context,
+	req *SignPsbtRequest) (*SignPsbtResponse, error) {
+
+	packet, err := psbt.NewFromRawBytes(
+		bytes.NewReader(req.FundedPsbt), false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PSBT: %v", err)
+	}
+
+	err = w.cfg.Wallet.SignPsbt(packet)
+	if err != nil {
+		return nil, fmt.Errorf("error signing PSBT: %v", err)
+	}
+
+	var signedPsbtBytes bytes.Buffer
+	err = packet.Serialize(&signedPsbtBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing PSBT: %v", err)
+	}
+
+	return &SignPsbtResponse{
+		SignedPsbt: signedPsbtBytes.Bytes(),
+	}, nil
+}
+
 // marshalWalletAccount converts the properties of an account into its RPC
 // representation.
 func marshalWalletAccount(account *waddrmgr.AccountProperties) (*Account, error) {
@@ -1266,8 +1803,14 @@ func marshalWalletAccount(account *waddrmgr.AccountProperties) (*Account, error)
 		addrType = AddressType_WITNESS_PUBKEY_HASH
 
 	default:
-		return nil, fmt.Errorf("account %v has unsupported "+
-			"key scope %v", account.AccountName, account.KeyScope)
+		// The accounts of lnd's internal key families all use witness
+		// pubkey hash addresses.
+		if account.KeyScope.Purpose != keychain.BIP0043Purpose {
+			return nil, fmt.Errorf("account %v has unsupported "+
+				"key scope %v", account.AccountName,
+				account.KeyScope)
+		}
+		addrType = AddressType_WITNESS_PUBKEY_HASH
 	}
 
 	rpcAccount := &Account{
@@ -1329,6 +1872,21 @@ func (w *WalletKit) ListAccounts(ctx context.Context,
 		return nil, err
 	}
 
+	// Without any filter, we'll also list the accounts of lnd's internal
+	// key families, which a watch-only lnd needs to derive its keys.
+	if req.Name == "" && keyScopeFilter == nil {
+		lnAccounts, err := w.cfg.Wallet.ListAccounts(
+			"", &waddrmgr.KeyScope{
+				Purpose: keychain.BIP0043Purpose,
+				Coin:    w.cfg.CoinType,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, lnAccounts...)
+	}
+
 	rpcAccounts := make([]*Account, 0, len(accounts))
 	for _, account := range accounts {
 		// Don't include the default imported accounts created by the
@@ -0,0 +1,625 @@
+// +build walletrpc
+
+package walletrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lntest/mock"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/sweep"
+	"github.com/stretchr/testify/require"
+)
+
+var testNetParams = &chaincfg.RegressionNetParams
+
+// mockWallet is a wallet that owns a fixed set of addresses and outputs and
+// records the transactions it publishes and removes.
+type mockWallet struct {
+	*mock.WalletController
+
+	ours        map[string]bool
+	change      map[string]bool
+	utxos       map[wire.OutPoint]*lnwallet.Utxo
+	unconfirmed []*lnwallet.TransactionDetail
+	publishErr  error
+
+	fetched   []wire.OutPoint
+	published []*wire.MsgTx
+	removed   []*wire.MsgTx
+}
+
+func newMockWallet() *mockWallet {
+	return &mockWallet{
+		WalletController: &mock.WalletController{},
+		ours:             make(map[string]bool),
+		change:           make(map[string]bool),
+		utxos:            make(map[wire.OutPoint]*lnwallet.Utxo),
+	}
+}
+
+// walletScript returns a new P2WKH script that pays to the wallet.
+func (w *mockWallet) walletScript(t *testing.T) []byte {
+	pkScript := p2wkhScript(t, 0x01, byte(len(w.ours)))
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, testNetParams,
+	)
+	require.NoError(t, err)
+	w.ours[addrs[0].String()] = true
+
+	return pkScript
+}
+
+// changeScript returns a new P2WKH script that pays to a change address of
+// the wallet.
+func (w *mockWallet) changeScript(t *testing.T) []byte {
+	pkScript := w.walletScript(t)
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, testNetParams,
+	)
+	require.NoError(t, err)
+	w.change[addrs[0].String()] = true
+
+	return pkScript
+}
+
+// foreignScript returns a P2WKH script that doesn't pay to the wallet.
+func foreignScript(t *testing.T, tag byte) []byte {
+	return p2wkhScript(t, 0xff, tag)
+}
+
+// p2wkhScript returns a P2WKH script with a key hash that starts with the
+// given prefix and tag.
+func p2wkhScript(t *testing.T, prefix, tag byte) []byte {
+	var keyHash [20]byte
+	keyHash[0] = prefix
+	keyHash[1] = tag
+
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(
+		keyHash[:], testNetParams,
+	)
+	require.NoError(t, err)
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	return pkScript
+}
+
+// addTx adds the transaction as an unconfirmed wallet transaction and makes
+// all of its wallet outputs available as unconfirmed outputs.
+func (w *mockWallet) addTx(t *testing.T, tx *wire.MsgTx) {
+	var buf bytes.Buffer
+	require.NoError(t, tx.Serialize(&buf))
+
+	w.unconfirmed = append(w.unconfirmed, &lnwallet.TransactionDetail{
+		Hash:  tx.TxHash(),
+		RawTx: buf.Bytes(),
+	})
+
+	for idx, txOut := range tx.TxOut {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			txOut.PkScript, testNetParams,
+		)
+		require.NoError(t, err)
+		if !w.ours[addrs[0].String()] {
+			continue
+		}
+
+		op := wire.OutPoint{Hash: tx.TxHash(), Index: uint32(idx)}
+		w.utxos[op] = &lnwallet.Utxo{
+			AddressType: lnwallet.WitnessPubKey,
+			Value:       btcutil.Amount(txOut.Value),
+			PkScript:    txOut.PkScript,
+			OutPoint:    op,
+		}
+	}
+}
+
+// addUtxo adds a confirmed wallet output with the given value.
+func (w *mockWallet) addUtxo(t *testing.T, index uint32,
+	value btcutil.Amount) wire.OutPoint {
+
+	op := wire.OutPoint{Index: index}
+	w.utxos[op] = &lnwallet.Utxo{
+		AddressType:   lnwallet.WitnessPubKey,
+		Value:         value,
+		PkScript:      w.walletScript(t),
+		Confirmations: 6,
+		OutPoint:      op,
+	}
+
+	return op
+}
+
+func (w *mockWallet) FetchInputInfo(
+	prevOut *wire.OutPoint) (*lnwallet.Utxo, error) {
+
+	w.fetched = append(w.fetched, *prevOut)
+
+	utxo, ok := w.utxos[*prevOut]
+	if !ok {
+		return nil, fmt.Errorf("output %v not found", prevOut)
+	}
+
+	return utxo, nil
+}
+
+func (w *mockWallet) IsOurAddress(a btcutil.Address) bool {
+	return w.ours[a.String()]
+}
+
+func (w *mockWallet) IsChangeAddress(a btcutil.Address) bool {
+	return w.change[a.String()]
+}
+
+func (w *mockWallet) ListTransactionDetails(_, _ int32,
+	_ string) ([]*lnwallet.TransactionDetail, error) {
+
+	return w.unconfirmed, nil
+}
+
+func (w *mockWallet) FinalizePsbt(packet *psbt.Packet, _ string) error {
+	for idx := range packet.Inputs {
+		packet.Inputs[idx].FinalScriptWitness = []byte{0x01, 0x01, 0x01}
+	}
+
+	return nil
+}
+
+func (w *mockWallet) PublishTransaction(tx *wire.MsgTx, _ string) error {
+	if w.publishErr != nil {
+		return w.publishErr
+	}
+
+	w.published = append(w.published, tx)
+
+	return nil
+}
+
+func (w *mockWallet) RemoveUnminedTx(tx *wire.MsgTx) error {
+	w.removed = append(w.removed, tx)
+	return nil
+}
+
+// mockCoinSelectionLocker runs the closure without any locking.
+type mockCoinSelectionLocker struct{}
+
+func (m *mockCoinSelectionLocker) WithCoinSelectLock(f func() error) error {
+	return f()
+}
+
+// newTestWalletKit creates a wallet kit around the given wallet. Its sweeper
+// is stopped, so every sweep attempt fails with ErrSweeperShuttingDown.
+func newTestWalletKit(t *testing.T, w *mockWallet,
+	fundingTxs ...chainhash.Hash) *WalletKit {
+
+	return newTestWalletKitWithStore(
+		t, w, sweep.NewMockSweeperStore(), fundingTxs...,
+	)
+}
+
+// newTestWalletKitWithStore creates a wallet kit around the given wallet,
+// whose sweeper uses the given store to track its sweep transactions.
+func newTestWalletKitWithStore(t *testing.T, w *mockWallet,
+	store sweep.SweeperStore, fundingTxs ...chainhash.Hash) *WalletKit {
+
+	estimator := chainfee.NewStaticEstimator(chainfee.FeePerKwFloor, 0)
+	sweeper := sweep.New(&sweep.UtxoSweeperConfig{
+		FeeEstimator: estimator,
+		MaxFeeRate:   sweep.DefaultMaxFeeRate,
+		Store:        store,
+	})
+	require.NoError(t, sweeper.Stop())
+
+	return &WalletKit{
+		cfg: &Config{
+			FeeEstimator:        estimator,
+			Wallet:              w,
+			CoinSelectionLocker: &mockCoinSelectionLocker{},
+			Sweeper:             sweeper,
+			Chain:               &mock.ChainIO{},
+			ChainParams:         testNetParams,
+			IsChannelFundingTx: func(txid chainhash.Hash) (bool,
+				error) {
+
+				for _, fundingTx := range fundingTxs {
+					if fundingTx == txid {
+						return true, nil
+					}
+				}
+
+				return false, nil
+			},
+		},
+	}
+}
+
+// newTestTx creates an unconfirmed transaction that spends the given wallet
+// output to a foreign output and a change output, paying the given fee.
+func newTestTx(t *testing.T, w *mockWallet, op wire.OutPoint,
+	foreignValue, fee btcutil.Amount) *wire.MsgTx {
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: op,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	tx.AddTxOut(&wire.TxOut{
+		Value:    int64(foreignValue),
+		PkScript: foreignScript(t, 0),
+	})
+	tx.AddTxOut(&wire.TxOut{
+		Value:    int64(w.utxos[op].Value - foreignValue - fee),
+		PkScript: w.changeScript(t),
+	})
+	w.addTx(t, tx)
+
+	return tx
+}
+
+// replacementFee returns the fee the replacement of the given test
+// transaction pays at the given fee rate.
+func replacementFee(tx *wire.MsgTx,
+	feeRate chainfee.SatPerKWeight) btcutil.Amount {
+
+	var weightEstimate input.TxWeightEstimator
+	weightEstimate.AddP2WKHInput()
+	for _, txOut := range tx.TxOut {
+		weightEstimate.AddTxOutput(txOut)
+	}
+
+	return feeRate.FeeForWeight(int64(weightEstimate.Weight()))
+}
+
+// bumpFee bumps the fee of the given transaction to the given fee rate in
+// sat/vByte.
+func bumpFee(w *WalletKit, tx *wire.MsgTx,
+	satPerVbyte uint64) (*BumpTransactionFeeResponse, error) {
+
+	txid := tx.TxHash()
+	return w.BumpTransactionFee(
+		context.Background(), &BumpTransactionFeeRequest{
+			Txid:        txid[:],
+			SatPerVbyte: satPerVbyte,
+		},
+	)
+}
+
+// TestBumpTransactionFeeReplace tests that a wallet transaction is replaced by
+// one that pays the requested fee rate out of its change output.
+func TestBumpTransactionFeeReplace(t *testing.T) {
+	t.Parallel()
+
+	w := newMockWallet()
+	op := w.addUtxo(t, 0, 100000)
+	tx := newTestTx(t, w, op, 50000, 1000)
+	kit := newTestWalletKit(t, w)
+
+	resp, err := bumpFee(kit, tx, 20)
+	require.NoError(t, err)
+	require.Equal(t, FeeBumpMethod_REPLACE_BY_FEE, resp.Method)
+
+	// The replacement pays the new fee out of the change output and
+	// signals replaceability.
+	require.Len(t, w.published, 1)
+	replacement := w.published[0]
+	require.Equal(t, replacement.TxHash().String(), resp.ReplacementTxid)
+
+	newFee := replacementFee(tx, chainfee.SatPerKWeight(20*250))
+	require.Equal(t, tx.TxOut[0].Value, replacement.TxOut[0].Value)
+	require.Equal(
+		t, tx.TxOut[1].Value-int64(newFee-1000),
+		replacement.TxOut[1].Value,
+	)
+	require.LessOrEqual(
+		t, replacement.TxIn[0].Sequence, uint32(mempool.MaxRBFSequence),
+	)
+
+	// The original transaction was removed from the wallet.
+	require.Len(t, w.removed, 1)
+	require.Equal(t, tx.TxHash(), w.removed[0].TxHash())
+}
+
+// TestReplaceByFeeMinRelayIncrement tests that a replacement must increase the
+// fee by at least the minimum relay fee of the replacement.
+func TestReplaceByFeeMinRelayIncrement(t *testing.T) {
+	t.Parallel()
+
+	feeRate := chainfee.SatPerKWeight(20 * 250)
+
+	w := newMockWallet()
+	op := w.addUtxo(t, 0, 100000)
+	tx := newTestTx(t, w, op, 50000, 0)
+	newFee := replacementFee(tx, feeRate)
+	relayFee := replacementFee(tx, chainfee.FeePerKwFloor)
+
+	// Paying less than the original fee is rejected.
+	w = newMockWallet()
+	op = w.addUtxo(t, 0, 100000)
+	tx = newTestTx(t, w, op, 50000, newFee+1)
+	_, err := newTestWalletKit(t, w).replaceByFee(tx, feeRate, "")
+	require.Error(t, err)
+	require.NotEqual(t, errRbfNotPossible, err)
+
+	// So is an increase that doesn't pay for relaying the replacement.
+	w = newMockWallet()
+	op = w.addUtxo(t, 0, 100000)
+	tx = newTestTx(t, w, op, 50000, newFee-relayFee+1)
+	_, err = newTestWalletKit(t, w).replaceByFee(tx, feeRate, "")
+	require.Error(t, err)
+	require.NotEqual(t, errRbfNotPossible, err)
+	require.Empty(t, w.published)
+
+	// An increase of exactly the relay fee is accepted.
+	w = newMockWallet()
+	op = w.addUtxo(t, 0, 100000)
+	tx = newTestTx(t, w, op, 50000, newFee-relayFee)
+	_, err = newTestWalletKit(t, w).replaceByFee(tx, feeRate, "")
+	require.NoError(t, err)
+	require.Len(t, w.published, 1)
+}
+
+// TestReplaceByFeeDustChange tests that a transaction isn't replaced if paying
+// the higher fee would turn its change output into dust.
+func TestReplaceByFeeDustChange(t *testing.T) {
+	t.Parallel()
+
+	feeRate := chainfee.SatPerKWeight(20 * 250)
+
+	w := newMockWallet()
+	op := w.addUtxo(t, 0, 100000)
+	tx := newTestTx(t, w, op, 50000, 0)
+	newFee := replacementFee(tx, feeRate)
+
+	// Only 100 satoshis of change would be left after paying the new
+	// fee.
+	w = newMockWallet()
+	op = w.addUtxo(t, 0, 100000)
+	tx = newTestTx(t, w, op, 100000-newFee-100, 1000)
+
+	_, err := newTestWalletKit(t, w).replaceByFee(tx, feeRate, "")
+	require.Equal(t, errRbfNotPossible, err)
+	require.Empty(t, w.published)
+	require.Empty(t, w.removed)
+}
+
+// TestReplaceByFeeChangeOnly tests that the higher fee of a replacement is
+// only taken from a change output, and never from a payment to one of our own
+// addresses.
+func TestReplaceByFeeChangeOnly(t *testing.T) {
+	t.Parallel()
+
+	feeRate := chainfee.SatPerKWeight(20 * 250)
+
+	// The payment goes to a regular wallet address and is larger than
+	// the change, but the fee is still taken from the change.
+	w := newMockWallet()
+	op := w.addUtxo(t, 0, 100000)
+	tx := newTestTx(t, w, op, 80000, 1000)
+	tx.TxOut[0].PkScript = w.walletScript(t)
+	w.unconfirmed = nil
+	w.addTx(t, tx)
+
+	_, err := newTestWalletKit(t, w).replaceByFee(tx, feeRate, "")
+	require.NoError(t, err)
+	require.Len(t, w.published, 1)
+
+	newFee := replacementFee(tx, feeRate)
+	replacement := w.published[0]
+	require.Equal(t, tx.TxOut[0].Value, replacement.TxOut[0].Value)
+	require.Equal(
+		t, tx.TxOut[1].Value-int64(newFee-1000),
+		replacement.TxOut[1].Value,
+	)
+
+	// Without a change output, the transaction isn't replaced.
+	w = newMockWallet()
+	op = w.addUtxo(t, 0, 100000)
+	tx = newTestTx(t, w, op, 50000, 1000)
+	tx.TxOut[1].PkScript = w.walletScript(t)
+	w.unconfirmed = nil
+	w.addTx(t, tx)
+
+	_, err = newTestWalletKit(t, w).replaceByFee(tx, feeRate, "")
+	require.Equal(t, errRbfNotPossible, err)
+	require.Empty(t, w.published)
+	require.Empty(t, w.removed)
+}
+
+// TestBumpTransactionFeeSweepTx tests that transactions published by the
+// sweeper are neither replaced nor bumped through CPFP, as the sweeper bumps
+// them itself.
+func TestBumpTransactionFeeSweepTx(t *testing.T) {
+	t.Parallel()
+
+	w := newMockWallet()
+	op := w.addUtxo(t, 0, 100000)
+	tx := newTestTx(t, w, op, 50000, 1000)
+
+	store := sweep.NewMockSweeperStore()
+	require.NoError(t, store.NotifyPublishTx(tx))
+	kit := newTestWalletKitWithStore(t, w, store)
+
+	_, err := bumpFee(kit, tx, 20)
+	require.True(t, errors.Is(err, ErrSweepTxNotBumpable))
+	require.Empty(t, w.fetched)
+	require.Empty(t, w.published)
+	require.Empty(t, w.removed)
+}
+
+// TestBumpTransactionFeeCpfpFallback tests that the largest wallet output of
+// a transaction is swept instead if the transaction can't be replaced.
+func TestBumpTransactionFeeCpfpFallback(t *testing.T) {
+	t.Parallel()
+
+	w := newMockWallet()
+	op := w.addUtxo(t, 0, 100000)
+	tx := newTestTx(t, w, op, 50000, 1000)
+	change := wire.OutPoint{Hash: tx.TxHash(), Index: 1}
+
+	// The backend refuses the replacement, for example because the
+	// original transaction doesn't signal replaceability.
+	w.publishErr = lnwallet.ErrDoubleSpend
+	kit := newTestWalletKit(t, w)
+
+	_, err := bumpFee(kit, tx, 20)
+	require.True(t, errors.Is(err, sweep.ErrSweeperShuttingDown))
+	require.Equal(t, change, w.fetched[len(w.fetched)-1])
+	require.Empty(t, w.removed)
+
+	// Without a wallet output there is nothing to sweep either.
+	tx.TxOut[1].PkScript = foreignScript(t, 1)
+	w = newMockWallet()
+	w.addUtxo(t, 0, 100000)
+	w.addTx(t, tx)
+
+	_, err = bumpFee(newTestWalletKit(t, w), tx, 20)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, sweep.ErrSweeperShuttingDown))
+	require.Empty(t, w.published)
+}
+
+// TestBumpTransactionFeeFundingTx tests that channel funding transactions are
+// never replaced, as that would change the channel point, and that bumping
+// them fails if they have no wallet output to sweep.
+func TestBumpTransactionFeeFundingTx(t *testing.T) {
+	t.Parallel()
+
+	w := newMockWallet()
+	op := w.addUtxo(t, 0, 100000)
+	tx := newTestTx(t, w, op, 50000, 1000)
+	kit := newTestWalletKit(t, w, tx.TxHash())
+
+	_, err := bumpFee(kit, tx, 20)
+	require.True(t, errors.Is(err, sweep.ErrSweeperShuttingDown))
+	require.Equal(
+		t, wire.OutPoint{Hash: tx.TxHash(), Index: 1},
+		w.fetched[len(w.fetched)-1],
+	)
+	require.Empty(t, w.published)
+	require.Empty(t, w.removed)
+
+	// Without a wallet output, the funding transaction can only be bumped
+	// by replacing it, which is rejected explicitly.
+	tx.TxOut[1].PkScript = foreignScript(t, 1)
+	w = newMockWallet()
+	w.addUtxo(t, 0, 100000)
+	w.addTx(t, tx)
+
+	_, err = bumpFee(newTestWalletKit(t, w, tx.TxHash()), tx, 20)
+	require.True(t, errors.Is(err, ErrFundingTxNotReplaceable))
+	require.Empty(t, w.published)
+	require.Empty(t, w.removed)
+}
+
+// TestBumpTransactionFeeUnconfirmedSpender tests that a transaction isn't
+// replaced if one of its outputs is spent by another unconfirmed wallet
+// transaction, and that the spent output isn't swept.
+func TestBumpTransactionFeeUnconfirmedSpender(t *testing.T) {
+	t.Parallel()
+
+	w := newMockWallet()
+	op := w.addUtxo(t, 0, 100000)
+	tx := newTestTx(t, w, op, 10000, 1000)
+
+	// The foreign output is turned into a smaller wallet output, and the
+	// larger change output funds a channel.
+	tx.TxOut[0].PkScript = w.walletScript(t)
+	w.unconfirmed = nil
+	w.addTx(t, tx)
+
+	child := wire.NewMsgTx(2)
+	child.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: tx.TxHash(), Index: 1},
+	})
+	child.AddTxOut(&wire.TxOut{
+		Value:    tx.TxOut[1].Value - 1000,
+		PkScript: foreignScript(t, 2),
+	})
+	w.addTx(t, child)
+
+	kit := newTestWalletKit(t, w)
+	_, err := kit.replaceByFee(tx, chainfee.SatPerKWeight(20*250), "")
+	require.Equal(t, errRbfNotPossible, err)
+
+	_, err = bumpFee(kit, tx, 20)
+	require.True(t, errors.Is(err, sweep.ErrSweeperShuttingDown))
+	require.Equal(
+		t, wire.OutPoint{Hash: tx.TxHash(), Index: 0},
+		w.fetched[len(w.fetched)-1],
+	)
+	require.Empty(t, w.published)
+	require.Empty(t, w.removed)
+}
+
+// TestLargestWalletOutput tests that the largest output paying to the wallet,
+// or only to one of its change addresses, is found.
+func TestLargestWalletOutput(t *testing.T) {
+	t.Parallel()
+
+	w := newMockWallet()
+	kit := newTestWalletKit(t, w)
+
+	tx := wire.NewMsgTx(2)
+	require.Equal(t, -1, kit.largestWalletOutput(tx, nil, false))
+	require.Equal(t, -1, kit.largestWalletOutput(tx, nil, true))
+
+	for _, out := range []struct {
+		value  int64
+		ours   bool
+		change bool
+	}{
+		{value: 1000, ours: true, change: true},
+		{value: 9000, ours: false},
+		{value: 5000, ours: true},
+		{value: 3000, ours: true, change: true},
+	} {
+		pkScript := foreignScript(t, byte(len(tx.TxOut)+10))
+		switch {
+		case out.change:
+			pkScript = w.changeScript(t)
+		case out.ours:
+			pkScript = w.walletScript(t)
+		}
+		tx.AddTxOut(&wire.TxOut{Value: out.value, PkScript: pkScript})
+	}
+
+	// Outputs we can't parse an address from are skipped.
+	tx.AddTxOut(&wire.TxOut{Value: 20000, PkScript: []byte{0x6a}})
+
+	require.Equal(t, 2, kit.largestWalletOutput(tx, nil, false))
+	require.Equal(
+		t, 3, kit.largestWalletOutput(tx, map[uint32]struct{}{
+			2: {},
+		}, false),
+	)
+	require.Equal(
+		t, -1, kit.largestWalletOutput(tx, map[uint32]struct{}{
+			0: {}, 2: {}, 3: {},
+		}, false),
+	)
+
+	// The larger output paying to a regular wallet address isn't change.
+	require.Equal(t, 3, kit.largestWalletOutput(tx, nil, true))
+	require.Equal(
+		t, 0, kit.largestWalletOutput(tx, map[uint32]struct{}{
+			3: {},
+		}, true),
+	)
+}
@@ -80,6 +80,11 @@ func (w *WalletController) IsOurAddress(a btcutil.Address) bool {
 	return false
 }
 
+// IsChangeAddress currently returns a dummy value.
+func (w *WalletController) IsChangeAddress(a btcutil.Address) bool {
+	return false
+}
+
 // ListAccounts currently returns a dummy value.
 func (w *WalletController) ListAccounts(_ string,
 	_ *waddrmgr.KeyScope) ([]*waddrmgr.AccountProperties, error) {
@@ -169,7 +174,7 @@ func (w *WalletController) ListLeasedOutputs() ([]*wtxmgr.LockedOutput, error) {
 }
 
 // FundPsbt currently does nothing.
-func (w *WalletController) FundPsbt(_ *psbt.Packet,
+func (w *WalletController) FundPsbt(_ *psbt.Packet, _ int32,
 	_ chainfee.SatPerKWeight, _ string) (int32, error) {
 
 	return 0, nil
@@ -180,6 +185,11 @@ func (w *WalletController) FinalizePsbt(_ *psbt.Packet, _ string) error {
 	return nil
 }
 
+// SignPsbt currently does nothing.
+func (w *WalletController) SignPsbt(_ *psbt.Packet) error {
+	return nil
+}
+
 // PublishTransaction sends a transaction to the PublishedTransactions chan.
 func (w *WalletController) PublishTransaction(tx *wire.MsgTx, _ string) error {
 	w.PublishedTransactions <- tx
@@ -193,6 +203,33 @@ func (w *WalletController) LabelTransaction(_ chainhash.Hash, _ string,
 	return nil
 }
 
+// LabelAddress currently does nothing.
+func (w *WalletController) LabelAddress(btcutil.Address, string,
+	string) error {
+
+	return nil
+}
+
+// LabelOutput currently does nothing.
+func (w *WalletController) LabelOutput(wire.OutPoint, string, string) error {
+	return nil
+}
+
+// FreezeOutput currently does nothing.
+func (w *WalletController) FreezeOutput(wire.OutPoint) error {
+	return nil
+}
+
+// UnfreezeOutput currently does nothing.
+func (w *WalletController) UnfreezeOutput(wire.OutPoint) error {
+	return nil
+}
+
+// RemoveUnminedTx currently does nothing.
+func (w *WalletController) RemoveUnminedTx(*wire.MsgTx) error {
+	return nil
+}
+
 // SubscribeTransactions currently does nothing.
 func (w *WalletController) SubscribeTransactions() (lnwallet.TransactionSubscription,
 	error) {
@@ -0,0 +1,549 @@
+package lnurl
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/ticker"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// WellKnownPathPrefix is the path prefix of the endpoints that
+	// lightning addresses resolve to. The first request of a payer is sent
+	// to WellKnownPathPrefix + <username>.
+	WellKnownPathPrefix = "/.well-known/lnurlp/"
+
+	// CallbackPathPrefix is the path prefix of the callback endpoints that
+	// payers request invoices from. The callback of a user is located at
+	// CallbackPathPrefix + <username> + CallbackPathSuffix.
+	CallbackPathPrefix = "/lnurlp/"
+
+	// CallbackPathSuffix is the path suffix of the callback endpoints.
+	CallbackPathSuffix = "/callback"
+
+	// MaxCommentLength is the maximum comment length that can be allowed
+	// for a user. Comments are stored in the memo of the invoices, which
+	// is limited in size.
+	MaxCommentLength = 512
+
+	// GcInterval is the interval at which the server removes the expired
+	// invoices it created.
+	GcInterval = time.Minute
+
+	// InvoiceMemoPrefix is the prefix of the memo of all invoices that are
+	// created by the server. It identifies these invoices in the invoice
+	// database after a restart.
+	InvoiceMemoPrefix = "LNURL-pay to "
+
+	// payRequestTag is the tag that identifies the response of the first
+	// request as a LNURL-pay request.
+	payRequestTag = "payRequest"
+)
+
+var (
+	// ErrInvalidUsername is returned when a user name contains characters
+	// that are not allowed in lightning addresses.
+	ErrInvalidUsername = errors.New("user names may only contain the " +
+		"characters a-z, 0-9, '-', '_' and '.'")
+
+	// usernameRegex matches the user names that are allowed as the local
+	// part of a lightning address.
+	usernameRegex = regexp.MustCompile(`^[a-z0-9\-_.]+$`)
+)
+
+// ValidateUsername checks that the given user name can be used as the local
+// part of a lightning address.
+func ValidateUsername(username string) error {
+	if !usernameRegex.MatchString(username) {
+		return ErrInvalidUsername
+	}
+
+	return nil
+}
+
+// Config holds the dependencies of the LNURL-pay server.
+type Config struct {
+	// Domain is the domain that the server is reachable at. The lightning
+	// addresses of the users are <username>@<domain>, and the callback
+	// URLs are located under this domain.
+	Domain string
+
+	// FetchUser returns the user with the given name. It must return
+	// channeldb.ErrLnurlUserNotFound if the user is not known.
+	FetchUser func(username string) (*channeldb.LnurlUser, error)
+
+	// AddInvoice creates an invoice over the given amount that commits to
+	// the given description hash, stores the given memo with it and
+	// expires after the given duration. The payment request and the
+	// payment hash of the invoice are returned.
+	AddInvoice func(amt lnwire.MilliSatoshi, descHash [32]byte,
+		memo string, expiry time.Duration) (string, lntypes.Hash, error)
+
+	// RemoveExpiredInvoice is called for every invoice created by the
+	// server once it has expired. It deletes the invoice if it wasn't paid
+	// and returns true if the invoice no longer needs to be tracked,
+	// because it was either paid or deleted.
+	RemoveExpiredInvoice func(hash lntypes.Hash) (bool, error)
+
+	// FetchPendingInvoices returns the payment hashes and expiry times of
+	// the invoices that were created by the server before it was last
+	// started and that haven't been removed yet. It is called on startup,
+	// so that these invoices are still removed once they expire and count
+	// towards MaxPendingInvoices.
+	FetchPendingInvoices func() (map[lntypes.Hash]time.Time, error)
+
+	// InvoiceExpiry is the expiry of the invoices created by the server.
+	InvoiceExpiry time.Duration
+
+	// MaxPendingInvoices is the maximum number of invoices created by the
+	// server that haven't been removed yet. Further callback requests are
+	// rejected until some of them expire.
+	MaxPendingInvoices int
+
+	// RateLimit is the number of invoices per second that the server
+	// creates at most.
+	RateLimit rate.Limit
+
+	// RateBurst is the number of invoices that the server creates in a
+	// burst above its rate limit.
+	RateBurst int
+
+	// Clock is used to determine when invoices expire.
+	Clock clock.Clock
+
+	// GcTicker signals the server to remove the expired invoices.
+	GcTicker ticker.Ticker
+}
+
+// Server serves the LNURL-pay endpoints (LUD-06) of the users that are
+// configured in the database, including the lightning address endpoints
+// (LUD-16) and comments (LUD-12).
+type Server struct {
+	started int32 // To be used atomically.
+	stopped int32 // To be used atomically.
+
+	cfg *Config
+
+	// limiter throttles the creation of invoices.
+	limiter *rate.Limiter
+
+	// pending maps the payment hashes of the invoices that were created by
+	// the server to their expiry time, until they are removed. creating is
+	// the number of invoices that are being created.
+	pending    map[lntypes.Hash]time.Time
+	creating   int
+	pendingMtx sync.Mutex
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// New creates a new LNURL-pay server. Requests are only served once the server
+// is started.
+func New(cfg *Config) *Server {
+	return &Server{
+		cfg:     cfg,
+		limiter: rate.NewLimiter(cfg.RateLimit, cfg.RateBurst),
+		pending: make(map[lntypes.Hash]time.Time),
+		quit:    make(chan struct{}),
+	}
+}
+
+// Start starts serving requests.
+func (s *Server) Start() error {
+	if !atomic.CompareAndSwapInt32(&s.started, 0, 1) {
+		return nil
+	}
+
+	pending, err := s.cfg.FetchPendingInvoices()
+	if err != nil {
+		return fmt.Errorf("unable to fetch pending invoices: %v", err)
+	}
+
+	s.pendingMtx.Lock()
+	for hash, expiry := range pending {
+		s.pending[hash] = expiry
+	}
+	s.pendingMtx.Unlock()
+
+	s.cfg.GcTicker.Resume()
+
+	s.wg.Add(1)
+	go s.gcInvoices()
+
+	log.Infof("LNURL-pay server started for domain %v with %d pending "+
+		"invoices", s.cfg.Domain, len(pending))
+
+	return nil
+}
+
+// Stop stops serving requests.
+func (s *Server) Stop() error {
+	if !atomic.CompareAndSwapInt32(&s.stopped, 0, 1) {
+		return nil
+	}
+
+	close(s.quit)
+	s.wg.Wait()
+
+	s.cfg.GcTicker.Stop()
+
+	log.Info("LNURL-pay server stopped")
+
+	return nil
+}
+
+// gcInvoices removes the expired invoices that were created by the server
+// whenever the gc ticker fires.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *Server) gcInvoices() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.cfg.GcTicker.Ticks():
+			s.removeExpiredInvoices()
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// removeExpiredInvoices removes all invoices that were created by the server
+// and have expired. Invoices that can't be removed yet, for example because
+// they are still being paid, are retried on the next run.
+func (s *Server) removeExpiredInvoices() {
+	now := s.cfg.Clock.Now()
+
+	s.pendingMtx.Lock()
+	var expired []lntypes.Hash
+	for hash, expiry := range s.pending {
+		if now.After(expiry) {
+			expired = append(expired, hash)
+		}
+	}
+	s.pendingMtx.Unlock()
+
+	for _, hash := range expired {
+		removed, err := s.cfg.RemoveExpiredInvoice(hash)
+		if err != nil {
+			log.Errorf("Unable to remove expired invoice %v: %v",
+				hash, err)
+			continue
+		}
+
+		if !removed {
+			continue
+		}
+
+		s.pendingMtx.Lock()
+		delete(s.pending, hash)
+		s.pendingMtx.Unlock()
+	}
+}
+
+// Address returns the lightning address of the user with the given name.
+func (s *Server) Address(username string) string {
+	return fmt.Sprintf("%v@%v", username, s.cfg.Domain)
+}
+
+// IsLnurlPath returns true if the given URL path is served by the LNURL-pay
+// server.
+func IsLnurlPath(path string) bool {
+	return strings.HasPrefix(path, WellKnownPathPrefix) ||
+		strings.HasPrefix(path, CallbackPathPrefix)
+}
+
+// Handler returns an http.Handler that passes the LNURL-pay requests to the
+// server and all other requests to next.
+func (s *Server) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsLnurlPath(r.URL.Path) {
+			s.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// payRequest is the response to the first request of a payer, which describes
+// the payments that the user accepts.
+type payRequest struct {
+	Callback       string `json:"callback"`
+	MaxSendable    uint64 `json:"maxSendable"`
+	MinSendable    uint64 `json:"minSendable"`
+	Metadata       string `json:"metadata"`
+	CommentAllowed uint16 `json:"commentAllowed,omitempty"`
+	Tag            string `json:"tag"`
+}
+
+// invoiceResponse is the response to a callback request, which holds the
+// invoice that the payer is expected to pay.
+type invoiceResponse struct {
+	PR     string        `json:"pr"`
+	Routes []interface{} `json:"routes"`
+}
+
+// errorResponse is the response to requests that failed.
+type errorResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// ServeHTTP serves the LNURL-pay endpoints.
+//
+// NOTE: This is part of the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// LNURL responses are read by wallets from any origin.
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if atomic.LoadInt32(&s.started) == 0 ||
+		atomic.LoadInt32(&s.stopped) == 1 {
+
+		writeError(
+			w, http.StatusServiceUnavailable,
+			"server not ready",
+		)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, WellKnownPathPrefix):
+		username := strings.TrimPrefix(path, WellKnownPathPrefix)
+		s.servePayRequest(w, strings.ToLower(username))
+
+	case strings.HasPrefix(path, CallbackPathPrefix) &&
+		strings.HasSuffix(path, CallbackPathSuffix):
+
+		username := strings.TrimSuffix(
+			strings.TrimPrefix(path, CallbackPathPrefix),
+			CallbackPathSuffix,
+		)
+		s.serveCallback(w, r, strings.ToLower(username))
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// servePayRequest answers the first request of a payer to the given user.
+func (s *Server) servePayRequest(w http.ResponseWriter, username string) {
+	user, ok := s.fetchUser(w, username)
+	if !ok {
+		return
+	}
+
+	metadata, err := s.metadata(user)
+	if err != nil {
+		log.Errorf("Unable to create metadata for %v: %v", username,
+			err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &payRequest{
+		Callback:       s.callbackURL(username),
+		MaxSendable:    uint64(user.MaxSendable),
+		MinSendable:    uint64(user.MinSendable),
+		Metadata:       metadata,
+		CommentAllowed: user.CommentAllowed,
+		Tag:            payRequestTag,
+	})
+}
+
+// serveCallback answers a callback request by creating an invoice for the
+// given user over the requested amount.
+func (s *Server) serveCallback(w http.ResponseWriter, r *http.Request,
+	username string) {
+
+	user, ok := s.fetchUser(w, username)
+	if !ok {
+		return
+	}
+
+	// Every callback request creates an invoice, so they are throttled to
+	// prevent anyone from filling up the database.
+	if !s.limiter.Allow() {
+		writeError(w, http.StatusTooManyRequests, "too many requests")
+		return
+	}
+
+	query := r.URL.Query()
+	amtMsat, err := strconv.ParseUint(query.Get("amount"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid amount")
+		return
+	}
+
+	amt := lnwire.MilliSatoshi(amtMsat)
+	if amt < user.MinSendable || amt > user.MaxSendable {
+		reason := fmt.Sprintf("amount must be between %v and %v msat",
+			uint64(user.MinSendable), uint64(user.MaxSendable))
+		writeError(w, http.StatusBadRequest, reason)
+		return
+	}
+
+	comment := query.Get("comment")
+	if len(comment) > int(user.CommentAllowed) {
+		writeError(w, http.StatusBadRequest, "comment too long")
+		return
+	}
+
+	metadata, err := s.metadata(user)
+	if err != nil {
+		log.Errorf("Unable to create metadata for %v: %v", username,
+			err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	// The memo is only stored locally, as the invoice commits to the hash
+	// of the metadata instead of a description.
+	memo := InvoiceMemoPrefix + s.Address(username)
+	if comment != "" {
+		memo = fmt.Sprintf("%v: %v", memo, comment)
+	}
+
+	// The pending slot is reserved before the invoice is created, so that
+	// concurrent requests can't exceed the limit.
+	s.pendingMtx.Lock()
+	if len(s.pending)+s.creating >= s.cfg.MaxPendingInvoices {
+		s.pendingMtx.Unlock()
+
+		writeError(
+			w, http.StatusTooManyRequests,
+			"too many pending invoices",
+		)
+		return
+	}
+	s.creating++
+	s.pendingMtx.Unlock()
+
+	payReq, hash, err := s.cfg.AddInvoice(
+		amt, sha256.Sum256([]byte(metadata)), memo,
+		s.cfg.InvoiceExpiry,
+	)
+
+	s.pendingMtx.Lock()
+	s.creating--
+	if err == nil {
+		s.pending[hash] = s.cfg.Clock.Now().Add(s.cfg.InvoiceExpiry)
+	}
+	s.pendingMtx.Unlock()
+
+	if err != nil {
+		log.Errorf("Unable to add invoice for %v: %v", username, err)
+		writeError(
+			w, http.StatusInternalServerError,
+			"unable to create invoice",
+		)
+		return
+	}
+
+	log.Debugf("Created invoice over %v for %v", amt, username)
+
+	writeJSON(w, http.StatusOK, &invoiceResponse{
+		PR:     payReq,
+		Routes: []interface{}{},
+	})
+}
+
+// fetchUser looks up the user with the given name. If the user cannot be
+// returned, an error is written to w and false is returned.
+func (s *Server) fetchUser(w http.ResponseWriter,
+	username string) (*channeldb.LnurlUser, bool) {
+
+	user, err := s.cfg.FetchUser(username)
+	switch {
+	case err == channeldb.ErrLnurlUserNotFound:
+		writeError(w, http.StatusNotFound, "unknown user")
+		return nil, false
+
+	case err != nil:
+		log.Errorf("Unable to fetch user %v: %v", username, err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+
+	return user, true
+}
+
+// metadata returns the metadata of the given user. Invoices of the user
+// commit to the SHA256 hash of the metadata.
+func (s *Server) metadata(user *channeldb.LnurlUser) (string, error) {
+	address := s.Address(user.Username)
+
+	description := user.Description
+	if description == "" {
+		description = fmt.Sprintf("Payment to %v", address)
+	}
+
+	metadata, err := json.Marshal([][]string{
+		{"text/plain", description},
+		{"text/identifier", address},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(metadata), nil
+}
+
+// callbackURL returns the callback URL of the user with the given name.
+func (s *Server) callbackURL(username string) string {
+	// LUD-16 requires https, except for onion services.
+	host := s.cfg.Domain
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	scheme := "https"
+	if strings.HasSuffix(host, ".onion") {
+		scheme = "http"
+	}
+
+	return fmt.Sprintf("%v://%v%v%v%v", scheme, s.cfg.Domain,
+		CallbackPathPrefix, username, CallbackPathSuffix)
+}
+
+// writeJSON writes the JSON encoding of resp to w.
+func writeJSON(w http.ResponseWriter, status int, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorf("Unable to write response: %v", err)
+	}
+}
+
+// writeError writes a LNURL error response with the given reason to w.
+func writeError(w http.ResponseWriter, status int, reason string) {
+	writeJSON(w, status, &errorResponse{
+		Status: "ERROR",
+		Reason: reason,
+	})
+}
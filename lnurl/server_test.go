@@ -0,0 +1,377 @@
+package lnurl
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/ticker"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+var testTime = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// addedInvoice is an invoice that was created by the server under test.
+type addedInvoice struct {
+	amt      lnwire.MilliSatoshi
+	descHash [32]byte
+	memo     string
+	expiry   time.Duration
+	hash     lntypes.Hash
+}
+
+// newTestServer creates a started LNURL-pay server that knows the user alice,
+// along with an HTTP server that serves its endpoints and a channel that
+// receives the invoices the server creates.
+func newTestServer(t *testing.T) (*Server, *httptest.Server,
+	chan *addedInvoice) {
+
+	alice := &channeldb.LnurlUser{
+		Username:       "alice",
+		Description:    "Tips for alice",
+		MinSendable:    1000,
+		MaxSendable:    1000000,
+		CommentAllowed: 10,
+	}
+
+	var numInvoices byte
+	invoices := make(chan *addedInvoice, 10)
+	server := New(&Config{
+		Domain: "example.com",
+		FetchUser: func(username string) (*channeldb.LnurlUser,
+			error) {
+
+			if username != alice.Username {
+				return nil, channeldb.ErrLnurlUserNotFound
+			}
+
+			return alice, nil
+		},
+		AddInvoice: func(amt lnwire.MilliSatoshi, descHash [32]byte,
+			memo string, expiry time.Duration) (string,
+			lntypes.Hash, error) {
+
+			numInvoices++
+			hash := lntypes.Hash{numInvoices}
+			invoices <- &addedInvoice{
+				amt:      amt,
+				descHash: descHash,
+				memo:     memo,
+				expiry:   expiry,
+				hash:     hash,
+			}
+
+			return "lnbc1test", hash, nil
+		},
+		RemoveExpiredInvoice: func(lntypes.Hash) (bool, error) {
+			return true, nil
+		},
+		FetchPendingInvoices: func() (map[lntypes.Hash]time.Time,
+			error) {
+
+			return nil, nil
+		},
+		InvoiceExpiry:      10 * time.Minute,
+		MaxPendingInvoices: 10,
+		RateLimit:          rate.Inf,
+		RateBurst:          1,
+		Clock:              clock.NewTestClock(testTime),
+		GcTicker:           ticker.NewForce(GcInterval),
+	})
+
+	notFound := http.HandlerFunc(func(w http.ResponseWriter,
+		_ *http.Request) {
+
+		w.WriteHeader(http.StatusTeapot)
+	})
+	httpServer := httptest.NewServer(server.Handler(notFound))
+	t.Cleanup(httpServer.Close)
+
+	return server, httpServer, invoices
+}
+
+// get sends a GET request to the given path of the server and decodes the
+// JSON response into resp. The HTTP status code is returned.
+func get(t *testing.T, server *httptest.Server, path string,
+	resp interface{}) int {
+
+	httpResp, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	defer httpResp.Body.Close()
+
+	if resp != nil {
+		require.NoError(t, json.NewDecoder(httpResp.Body).Decode(resp))
+	}
+
+	return httpResp.StatusCode
+}
+
+// TestServerNotStarted tests that requests are rejected until the server is
+// started, and after it is stopped.
+func TestServerNotStarted(t *testing.T) {
+	server, httpServer, _ := newTestServer(t)
+
+	var errResp errorResponse
+	status := get(t, httpServer, "/.well-known/lnurlp/alice", &errResp)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, "ERROR", errResp.Status)
+
+	require.NoError(t, server.Start())
+	status = get(t, httpServer, "/.well-known/lnurlp/alice", nil)
+	require.Equal(t, http.StatusOK, status)
+
+	require.NoError(t, server.Stop())
+	status = get(t, httpServer, "/.well-known/lnurlp/alice", nil)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+}
+
+// TestPayFlow tests the LNURL-pay flow of a lightning address payment, from
+// resolving the address to requesting an invoice from the callback.
+func TestPayFlow(t *testing.T) {
+	server, httpServer, invoices := newTestServer(t)
+	require.NoError(t, server.Start())
+
+	// Requests to other paths are passed on to the next handler.
+	status := get(t, httpServer, "/v1/getinfo", nil)
+	require.Equal(t, http.StatusTeapot, status)
+
+	// Unknown users are rejected.
+	var errResp errorResponse
+	status = get(t, httpServer, "/.well-known/lnurlp/bob", &errResp)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, "ERROR", errResp.Status)
+
+	// Resolve alice's lightning address. User names are not case
+	// sensitive.
+	var payReq payRequest
+	status = get(t, httpServer, "/.well-known/lnurlp/Alice", &payReq)
+	require.Equal(t, http.StatusOK, status)
+
+	require.Equal(t, payRequestTag, payReq.Tag)
+	require.Equal(
+		t, "https://example.com/lnurlp/alice/callback", payReq.Callback,
+	)
+	require.EqualValues(t, 1000, payReq.MinSendable)
+	require.EqualValues(t, 1000000, payReq.MaxSendable)
+	require.EqualValues(t, 10, payReq.CommentAllowed)
+
+	var metadata [][]string
+	require.NoError(t, json.Unmarshal([]byte(payReq.Metadata), &metadata))
+	require.Equal(t, [][]string{
+		{"text/plain", "Tips for alice"},
+		{"text/identifier", "alice@example.com"},
+	}, metadata)
+
+	callback, err := url.Parse(payReq.Callback)
+	require.NoError(t, err)
+
+	callbackPath := func(amt uint64, comment string) string {
+		query := url.Values{}
+		query.Set("amount", fmt.Sprintf("%d", amt))
+		if comment != "" {
+			query.Set("comment", comment)
+		}
+
+		return callback.Path + "?" + query.Encode()
+	}
+
+	// Amounts outside of the user's limits and too long comments are
+	// rejected.
+	status = get(t, httpServer, callbackPath(999, ""), &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status = get(t, httpServer, callbackPath(1000001, ""), &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status = get(
+		t, httpServer, callbackPath(5000, "a long comment"), &errResp,
+	)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	// Finally, request a valid invoice. It must commit to the hash of the
+	// metadata, and the comment must be stored in its memo.
+	var invResp invoiceResponse
+	status = get(t, httpServer, callbackPath(5000, "thanks"), &invResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "lnbc1test", invResp.PR)
+	require.Empty(t, invResp.Routes)
+
+	invoice := <-invoices
+	require.EqualValues(t, 5000, invoice.amt)
+	require.Equal(t, sha256.Sum256([]byte(payReq.Metadata)),
+		invoice.descHash)
+	require.Equal(t, "LNURL-pay to alice@example.com: thanks",
+		invoice.memo)
+	require.Equal(t, 10*time.Minute, invoice.expiry)
+}
+
+// callbackPath returns the path of alice's callback that requests an invoice
+// over the given amount.
+func callbackPath(amt uint64) string {
+	return fmt.Sprintf("%valice%v?amount=%d", CallbackPathPrefix,
+		CallbackPathSuffix, amt)
+}
+
+// TestCallbackRateLimit tests that the creation of invoices is throttled.
+func TestCallbackRateLimit(t *testing.T) {
+	server, httpServer, _ := newTestServer(t)
+	server.limiter = rate.NewLimiter(rate.Every(time.Hour), 2)
+	require.NoError(t, server.Start())
+
+	// The first two requests use up the burst, further requests are
+	// rejected.
+	for i := 0; i < 2; i++ {
+		status := get(t, httpServer, callbackPath(5000), nil)
+		require.Equal(t, http.StatusOK, status)
+	}
+
+	var errResp errorResponse
+	status := get(t, httpServer, callbackPath(5000), &errResp)
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.Equal(t, "too many requests", errResp.Reason)
+
+	// The first request only resolves the address, so it is not
+	// throttled.
+	status = get(t, httpServer, "/.well-known/lnurlp/alice", nil)
+	require.Equal(t, http.StatusOK, status)
+}
+
+// TestInvoiceGc tests that the number of pending invoices is limited, and that
+// expired invoices are removed.
+func TestInvoiceGc(t *testing.T) {
+	server, httpServer, invoices := newTestServer(t)
+	server.cfg.MaxPendingInvoices = 2
+
+	removeCalls := make(chan lntypes.Hash, 10)
+	server.cfg.RemoveExpiredInvoice = func(hash lntypes.Hash) (bool,
+		error) {
+
+		removeCalls <- hash
+
+		// The first invoice is still being paid, so it can't be
+		// removed yet.
+		return hash != lntypes.Hash{1}, nil
+	}
+
+	require.NoError(t, server.Start())
+	defer func() {
+		require.NoError(t, server.Stop())
+	}()
+
+	gcTicker := server.cfg.GcTicker.(*ticker.Force)
+	testClock := server.cfg.Clock.(*clock.TestClock)
+
+	// Create two invoices, which is the maximum number of pending
+	// invoices.
+	for i := 0; i < 2; i++ {
+		status := get(t, httpServer, callbackPath(5000), nil)
+		require.Equal(t, http.StatusOK, status)
+		<-invoices
+	}
+
+	var errResp errorResponse
+	status := get(t, httpServer, callbackPath(5000), &errResp)
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.Equal(t, "too many pending invoices", errResp.Reason)
+
+	// Invoices that haven't expired yet aren't removed. The second tick
+	// can only be delivered once the first one was handled.
+	gcTicker.Force <- testTime
+	gcTicker.Force <- testTime
+	require.Empty(t, removeCalls)
+
+	// Once the invoices expired, they are removed, except for the one
+	// that is still being paid.
+	testClock.SetTime(testTime.Add(11 * time.Minute))
+	gcTicker.Force <- testTime
+	<-removeCalls
+	<-removeCalls
+
+	status = get(t, httpServer, callbackPath(5000), nil)
+	require.Equal(t, http.StatusOK, status)
+	<-invoices
+
+	status = get(t, httpServer, callbackPath(5000), &errResp)
+	require.Equal(t, http.StatusTooManyRequests, status)
+
+	// The first invoice is retried and removed on the next run.
+	gcTicker.Force <- testTime
+	require.Equal(t, lntypes.Hash{1}, <-removeCalls)
+}
+
+// TestPendingInvoicesRestored tests that the invoices that were created before
+// the server was started are removed once they expire, and that they count
+// towards the maximum number of pending invoices.
+func TestPendingInvoicesRestored(t *testing.T) {
+	server, httpServer, _ := newTestServer(t)
+	server.cfg.MaxPendingInvoices = 2
+
+	restored := map[lntypes.Hash]time.Time{
+		{0xa}: testTime.Add(time.Minute),
+		{0xb}: testTime.Add(5 * time.Minute),
+	}
+	server.cfg.FetchPendingInvoices = func() (map[lntypes.Hash]time.Time,
+		error) {
+
+		return restored, nil
+	}
+
+	removeCalls := make(chan lntypes.Hash, 10)
+	server.cfg.RemoveExpiredInvoice = func(hash lntypes.Hash) (bool,
+		error) {
+
+		removeCalls <- hash
+		return true, nil
+	}
+
+	require.NoError(t, server.Start())
+	defer func() {
+		require.NoError(t, server.Stop())
+	}()
+
+	gcTicker := server.cfg.GcTicker.(*ticker.Force)
+	testClock := server.cfg.Clock.(*clock.TestClock)
+
+	// The restored invoices already use up all pending slots.
+	var errResp errorResponse
+	status := get(t, httpServer, callbackPath(5000), &errResp)
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.Equal(t, "too many pending invoices", errResp.Reason)
+
+	// Only the first invoice has expired after two minutes. The second
+	// tick can only be delivered once the first one was handled.
+	testClock.SetTime(testTime.Add(2 * time.Minute))
+	gcTicker.Force <- testTime
+	gcTicker.Force <- testTime
+	require.Equal(t, lntypes.Hash{0xa}, <-removeCalls)
+	require.Empty(t, removeCalls)
+
+	status = get(t, httpServer, callbackPath(5000), nil)
+	require.Equal(t, http.StatusOK, status)
+}
+
+// TestValidateUsername tests the validation of user names.
+func TestValidateUsername(t *testing.T) {
+	valid := []string{"alice", "bob-2", "tips_and.more"}
+	for _, username := range valid {
+		require.NoError(t, ValidateUsername(username), username)
+	}
+
+	invalid := []string{"", "Alice", "alice@example.com", "a b", "a/b"}
+	for _, username := range invalid {
+		require.Equal(
+			t, ErrInvalidUsername, ValidateUsername(username),
+			username,
+		)
+	}
+}
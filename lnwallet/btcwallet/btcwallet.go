@@ -58,6 +58,10 @@ var (
 	// stored within the top-level waleltdb buckets of btcwallet.
 	waddrmgrNamespaceKey = []byte("waddrmgr")
 
+	// wtxmgrNamespaceKey is the namespace key that the wtxmgr state is
+	// stored within the top-level walletdb buckets of btcwallet.
+	wtxmgrNamespaceKey = []byte("wtxmgr")
+
 	// lightningAddrSchema is the scope addr schema for all keys that we
 	// derive. We'll treat them all as p2wkh addresses, as atm we must
 	// specify a particular type.
@@ -96,6 +100,12 @@ type BtcWallet struct {
 	chainKeyScope waddrmgr.KeyScope
 
 	blockCache *blockcache.BlockCache
+
+	// frozenOutputs is the set of outputs that are frozen through
+	// FreezeOutput. They stay locked in the wallet, so they're never
+	// picked by coin selection.
+	frozenOutputs    map[wire.OutPoint]struct{}
+	frozenOutputsMtx sync.Mutex
 }
 
 // A compile time check to ensure that BtcWallet implements the
@@ -165,6 +175,7 @@ func New(cfg Config, blockCache *blockcache.BlockCache) (*BtcWallet, error) {
 		netParams:     cfg.NetParams,
 		chainKeyScope: chainKeyScope,
 		blockCache:    blockCache,
+		frozenOutputs: make(map[wire.OutPoint]struct{}),
 	}, nil
 }
 
@@ -291,26 +302,30 @@ func (b *BtcWallet) Start() error {
 	// We'll start by unlocking the wallet and ensuring that the KeyScope:
 	// (1017, 1) exists within the internal waddrmgr. We'll need this in
 	// order to properly generate the keys required for signing various
-	// contracts.
-	if err := b.wallet.Unlock(b.cfg.PrivatePass, nil); err != nil {
-		return err
-	}
-	_, err := b.wallet.Manager.FetchScopedKeyManager(b.chainKeyScope)
-	if err != nil {
-		// If the scope hasn't yet been created (it wouldn't been
-		// loaded by default if it was), then we'll manually create the
-		// scope for the first time ourselves.
-		err := walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
-			addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
-
-			_, err := b.wallet.Manager.NewScopedKeyManager(
-				addrmgrNs, b.chainKeyScope, lightningAddrSchema,
-			)
-			return err
-		})
+	// contracts. A watch-only wallet has no private keys to unlock, its
+	// key scopes and accounts were all imported when it was created.
+	if b.wallet.Manager.WatchOnly() {
+		_, err := b.wallet.Manager.FetchScopedKeyManager(
+			b.chainKeyScope,
+		)
 		if err != nil {
+			return fmt.Errorf("watch-only wallet is missing the "+
+				"lightning key scope: %v", err)
+		}
+	} else {
+		if err := b.wallet.Unlock(b.cfg.PrivatePass, nil); err != nil {
 			return err
 		}
+
+		if err := b.initLightningAccounts(); err != nil {
+			return err
+		}
+	}
+
+	// Lock all frozen outputs again, so they stay excluded from coin
+	// selection.
+	if err := b.initWalletLabels(); err != nil {
+		return err
 	}
 
 	// Establish an RPC connection in addition to starting the goroutines
@@ -329,6 +344,51 @@ func (b *BtcWallet) Start() error {
 	return nil
 }
 
+// initLightningAccounts creates the lightning key scope if it doesn't exist yet
+// and the accounts of all of lnd's key families within it. Creating all the
+// accounts up front allows them to be exported to a watch-only wallet.
+func (b *BtcWallet) initLightningAccounts() error {
+	return walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+
+		scope, err := b.wallet.Manager.FetchScopedKeyManager(
+			b.chainKeyScope,
+		)
+		if err != nil {
+			// If the scope hasn't yet been created (it wouldn't
+			// been loaded by default if it was), then we'll
+			// manually create the scope for the first time
+			// ourselves.
+			scope, err = b.wallet.Manager.NewScopedKeyManager(
+				addrmgrNs, b.chainKeyScope, lightningAddrSchema,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, keyFam := range keychain.VersionZeroKeyFamilies {
+			// The account of the multi-sig key family is the
+			// default account that is created with the scope.
+			if keyFam == keychain.KeyFamilyMultiSig {
+				continue
+			}
+
+			_, err := scope.AccountName(addrmgrNs, uint32(keyFam))
+			if err == nil {
+				continue
+			}
+
+			err = scope.NewRawAccount(addrmgrNs, uint32(keyFam))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
 // Stop signals the wallet for shutdown. Shutdown may entail closing
 // any active sockets, database handles, stopping goroutines, etc.
 //
@@ -364,6 +424,12 @@ func (b *BtcWallet) ConfirmedBalance(confs int32,
 	}
 
 	for _, witnessOutput := range witnessOutputs {
+		// Frozen outputs can't be spent, so they don't count towards
+		// the balance.
+		if witnessOutput.Frozen {
+			continue
+		}
+
 		balance += witnessOutput.Value
 	}
 
@@ -464,6 +530,20 @@ func (b *BtcWallet) IsOurAddress(a btcutil.Address) bool {
 	return result && (err == nil)
 }
 
+// IsChangeAddress checks if the passed address belongs to this wallet and was
+// derived from the internal branch of its account, which is used for change
+// outputs.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) IsChangeAddress(a btcutil.Address) bool {
+	addr, err := b.wallet.AddressInfo(a)
+	if err != nil {
+		return false
+	}
+
+	return addr.Internal()
+}
+
 // ListAccounts retrieves all accounts belonging to the wallet by default. A
 // name and key scope filter can be provided to filter through all of the wallet
 // accounts and return only those matching.
@@ -730,6 +810,14 @@ func (b *BtcWallet) LockOutpoint(o wire.OutPoint) {
 //
 // This is a part of the WalletController interface.
 func (b *BtcWallet) UnlockOutpoint(o wire.OutPoint) {
+	// Frozen outputs stay locked until they're unfrozen.
+	b.frozenOutputsMtx.Lock()
+	_, frozen := b.frozenOutputs[o]
+	b.frozenOutputsMtx.Unlock()
+	if frozen {
+		return
+	}
+
 	b.wallet.UnlockOutpoint(o)
 }
 
@@ -777,7 +865,9 @@ func (b *BtcWallet) ReleaseOutput(id wtxmgr.LockID, op wire.OutPoint) error {
 // outputs should be returned. Using MaxInt32 as 'maxConfs' implies returning
 // all outputs with at least 'minConfs'. The account parameter serves as a
 // filter to retrieve the unspent outputs for a specific account.  When empty,
-// the unspent outputs of all wallet accounts are returned.
+// the unspent outputs of all wallet accounts are returned. Locked outputs are
+// never returned. Frozen outputs stay locked, but they are returned with their
+// Frozen flag set.
 //
 // NOTE: This method requires the global coin selection lock to be held.
 //
@@ -842,6 +932,21 @@ func (b *BtcWallet) ListUnspentWitness(minConfs, maxConfs int32,
 
 	}
 
+	// Frozen outputs are locked, so the wallet doesn't list them. We add
+	// them ourselves, so that they are reported with their flag set.
+	frozenOutputs, err := b.listFrozenOutputs(
+		minConfs, maxConfs, accountFilter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	witnessOutputs = append(witnessOutputs, frozenOutputs...)
+
+	// Finally, we'll attach the labels and tags of the outputs.
+	if err := b.attachLabels(witnessOutputs); err != nil {
+		return nil, err
+	}
+
 	return witnessOutputs, nil
 }
 
@@ -886,6 +991,24 @@ func (b *BtcWallet) LabelTransaction(hash chainhash.Hash, label string,
 	return b.wallet.LabelTransaction(hash, label, overwrite)
 }
 
+// RemoveUnminedTx removes an unconfirmed transaction and all unconfirmed
+// transactions spending its outputs from the wallet. This is used once a
+// transaction was replaced by one paying a higher fee, so its outputs are no
+// longer considered spendable.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) RemoveUnminedTx(tx *wire.MsgTx) error {
+	txRec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return walletdb.Update(b.db, func(dbTx walletdb.ReadWriteTx) error {
+		txmgrNs := dbTx.ReadWriteBucket(wtxmgrNamespaceKey)
+		return b.wallet.TxStore.RemoveUnminedTx(txmgrNs, txRec)
+	})
+}
+
 // extractBalanceDelta extracts the net balance delta from the PoV of the
 // wallet given a TransactionSummary.
 func extractBalanceDelta(
@@ -1067,8 +1190,9 @@ func (b *BtcWallet) ListTransactionDetails(startHeight, endHeight int32,
 // output is created and the index -1 is returned.
 //
 // NOTE: If the packet doesn't contain any inputs, coin selection is performed
-// automatically. The account parameter must be non-empty as it determines which
-// set of coins are eligible for coin selection. If the packet does contain any
+// automatically, only selecting outputs with at least minConfs confirmations.
+// The account parameter must be non-empty as it determines which set of coins
+// are eligible for coin selection. If the packet does contain any
 // inputs, it is assumed that full coin selection happened externally and no
 // additional inputs are added. If the specified inputs aren't enough to fund
 // the outputs with the given fee rate, an error is returned. No lock lease is
@@ -1076,7 +1200,7 @@ func (b *BtcWallet) ListTransactionDetails(startHeight, endHeight int32,
 // responsibility to lock the inputs before handing them out.
 //
 // This is a part of the WalletController interface.
-func (b *BtcWallet) FundPsbt(packet *psbt.Packet,
+func (b *BtcWallet) FundPsbt(packet *psbt.Packet, minConfs int32,
 	feeRate chainfee.SatPerKWeight, accountName string) (int32, error) {
 
 	// The fee rate is passed in using units of sat/kw, so we'll convert
@@ -1108,8 +1232,29 @@ func (b *BtcWallet) FundPsbt(packet *psbt.Packet,
 		accountNum = account
 	}
 
-	// Let the wallet handle coin selection and/or fee estimation based on
-	// the partial TX information in the packet.
+	// The wallet's own coin selection for PSBTs only considers confirmed
+	// outputs. To honor the requested number of confirmations, we select
+	// the coins with a dry run instead and let the wallet only verify them
+	// and add the change output below.
+	if len(packet.UnsignedTx.TxIn) == 0 {
+		tx, err := b.wallet.CreateSimpleTx(
+			keyScope, accountNum, packet.UnsignedTx.TxOut, minConfs,
+			feeSatPerKB, b.cfg.CoinSelectionStrategy, true,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("error selecting coins: %v", err)
+		}
+
+		for _, txIn := range tx.Tx.TxIn {
+			packet.UnsignedTx.AddTxIn(&wire.TxIn{
+				PreviousOutPoint: txIn.PreviousOutPoint,
+				Sequence:         txIn.Sequence,
+			})
+		}
+	}
+
+	// Let the wallet handle fee estimation based on the partial TX
+	// information in the packet.
 	return b.wallet.FundPsbt(
 		packet, keyScope, accountNum, feeSatPerKB,
 		b.cfg.CoinSelectionStrategy,
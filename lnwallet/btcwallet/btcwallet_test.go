@@ -0,0 +1,124 @@
+package btcwallet
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/snacl"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	base "github.com/btcsuite/btcwallet/wallet"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testNetParams = &chaincfg.SimNetParams
+
+	testSeed = chainhash.Hash{
+		0xb7, 0x94, 0x38, 0x5f, 0x2d, 0x1e, 0xf7, 0xab,
+		0x4d, 0x92, 0x73, 0xd1, 0x90, 0x63, 0x81, 0xb4,
+		0x4f, 0x2f, 0x6f, 0x25, 0x98, 0xa3, 0xef, 0xb9,
+		0x69, 0x49, 0x18, 0x83, 0x31, 0x98, 0x47, 0x53,
+	}
+
+	testPassphrase = []byte("test")
+
+	testLightningScope = waddrmgr.KeyScope{
+		Purpose: keychain.BIP0043Purpose,
+		Coin:    keychain.CoinTypeTestnet,
+	}
+
+	// testDBTimeout is the wallet db timeout value used in the tests.
+	testDBTimeout = time.Second * 10
+)
+
+func init() {
+	// Use the cranked down scrypt parameters when creating new wallet
+	// encryption keys, so the tests don't take long.
+	fastScrypt := waddrmgr.FastScryptOptions
+	waddrmgr.SetSecretKeyGen(func(passphrase *[]byte,
+		config *waddrmgr.ScryptOptions) (*snacl.SecretKey, error) {
+
+		return snacl.NewSecretKey(
+			passphrase, fastScrypt.N, fastScrypt.R, fastScrypt.P,
+		)
+	})
+}
+
+// newTestBaseWallet creates a new, unlocked base wallet from the test seed in
+// a temporary directory, or a watch-only wallet without any accounts.
+func newTestBaseWallet(t *testing.T, watchOnly bool) *base.Wallet {
+	tempDir, err := ioutil.TempDir("", "btcwallet")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	loader := base.NewLoader(testNetParams, tempDir, true, testDBTimeout, 0)
+
+	var w *base.Wallet
+	if watchOnly {
+		w, err = loader.CreateNewWatchingOnlyWallet(
+			testPassphrase, time.Time{},
+		)
+		require.NoError(t, err)
+	} else {
+		w, err = loader.CreateNewWallet(
+			testPassphrase, testPassphrase, testSeed[:],
+			time.Time{},
+		)
+		require.NoError(t, err)
+		require.NoError(t, w.Unlock(testPassphrase, nil))
+	}
+
+	t.Cleanup(func() {
+		require.NoError(t, loader.UnloadWallet())
+	})
+
+	return w
+}
+
+// newTestWallet creates a new BtcWallet around a base wallet that is created
+// from the test seed. It has no chain backend, so only the functionality that
+// works on the wallet database can be tested with it.
+func newTestWallet(t *testing.T) *BtcWallet {
+	w := newTestBaseWallet(t, false)
+
+	b := &BtcWallet{
+		wallet:        w,
+		db:            w.Database(),
+		netParams:     testNetParams,
+		chainKeyScope: testLightningScope,
+		frozenOutputs: make(map[wire.OutPoint]struct{}),
+	}
+	require.NoError(t, b.initLightningAccounts())
+
+	return b
+}
+
+// testAccountKey derives the extended private key of the given account within
+// the given key scope from the test seed.
+func testAccountKey(t *testing.T, scope waddrmgr.KeyScope,
+	account uint32) *hdkeychain.ExtendedKey {
+
+	key, err := hdkeychain.NewMaster(testSeed[:], testNetParams)
+	require.NoError(t, err)
+
+	path := []uint32{
+		scope.Purpose + hdkeychain.HardenedKeyStart,
+		scope.Coin + hdkeychain.HardenedKeyStart,
+		account + hdkeychain.HardenedKeyStart,
+	}
+	for _, index := range path {
+		key, err = key.Derive(index)
+		require.NoError(t, err)
+	}
+
+	return key
+}
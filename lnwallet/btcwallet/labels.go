@@ -0,0 +1,507 @@
+package btcwallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/lightningnetwork/lnd/lnwallet"
+)
+
+var (
+	// walletLabelsBucket is the top-level bucket of the wallet database
+	// that holds the labels, tags and freeze state lnd attaches to wallet
+	// addresses and outputs.
+	walletLabelsBucket = []byte("lnd-wallet-labels")
+
+	// addrLabelsBucket is a sub-bucket of walletLabelsBucket that maps the
+	// pkScript of an address to its label record.
+	addrLabelsBucket = []byte("addr-labels")
+
+	// outputLabelsBucket is a sub-bucket of walletLabelsBucket that maps
+	// an outpoint to its label record.
+	outputLabelsBucket = []byte("output-labels")
+)
+
+// labelRecord is the label, tag and freeze state stored for an address or an
+// output of the wallet. Addresses are never frozen.
+type labelRecord struct {
+	label  string
+	tag    string
+	frozen bool
+}
+
+// isEmpty returns true if the record holds no information and can be removed.
+func (r *labelRecord) isEmpty() bool {
+	return r.label == "" && r.tag == "" && !r.frozen
+}
+
+// encode serializes the record to the given writer.
+func (r *labelRecord) encode(w io.Writer) error {
+	if err := wire.WriteVarString(w, 0, r.label); err != nil {
+		return err
+	}
+	if err := wire.WriteVarString(w, 0, r.tag); err != nil {
+		return err
+	}
+
+	var frozen uint8
+	if r.frozen {
+		frozen = 1
+	}
+
+	return binary.Write(w, binary.BigEndian, frozen)
+}
+
+// decode deserializes the record from the given reader.
+func (r *labelRecord) decode(rd io.Reader) error {
+	var err error
+	r.label, err = wire.ReadVarString(rd, 0)
+	if err != nil {
+		return err
+	}
+	r.tag, err = wire.ReadVarString(rd, 0)
+	if err != nil {
+		return err
+	}
+
+	var frozen uint8
+	if err := binary.Read(rd, binary.BigEndian, &frozen); err != nil {
+		return err
+	}
+	r.frozen = frozen == 1
+
+	return nil
+}
+
+// outpointKey returns the key an outpoint is stored under.
+func outpointKey(op wire.OutPoint) []byte {
+	var key [36]byte
+	copy(key[:32], op.Hash[:])
+	binary.BigEndian.PutUint32(key[32:], op.Index)
+
+	return key[:]
+}
+
+// fetchLabel returns the record stored under the given key of the sub-bucket.
+// An empty record is returned if there is none.
+func fetchLabel(tx walletdb.ReadTx, subBucket, key []byte) (*labelRecord,
+	error) {
+
+	record := &labelRecord{}
+
+	labels := tx.ReadBucket(walletLabelsBucket)
+	if labels == nil {
+		return record, nil
+	}
+	bucket := labels.NestedReadBucket(subBucket)
+	if bucket == nil {
+		return record, nil
+	}
+
+	recordBytes := bucket.Get(key)
+	if recordBytes == nil {
+		return record, nil
+	}
+
+	if err := record.decode(bytes.NewReader(recordBytes)); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// putLabel stores the record under the given key of the sub-bucket. An empty
+// record removes the key.
+func putLabel(tx walletdb.ReadWriteTx, subBucket, key []byte,
+	record *labelRecord) error {
+
+	labels := tx.ReadWriteBucket(walletLabelsBucket)
+	if labels == nil {
+		return fmt.Errorf("wallet labels bucket not found")
+	}
+	bucket := labels.NestedReadWriteBucket(subBucket)
+	if bucket == nil {
+		return fmt.Errorf("wallet labels sub-bucket not found")
+	}
+
+	if record.isEmpty() {
+		return bucket.Delete(key)
+	}
+
+	var b bytes.Buffer
+	if err := record.encode(&b); err != nil {
+		return err
+	}
+
+	return bucket.Put(key, b.Bytes())
+}
+
+// validateLabel checks that a label or tag doesn't exceed the limit we also
+// apply to transaction labels.
+func validateLabel(label string) error {
+	if len(label) > wtxmgr.TxLabelLimit {
+		return fmt.Errorf("label length: %v exceeds limit of %v",
+			len(label), wtxmgr.TxLabelLimit)
+	}
+
+	return nil
+}
+
+// initWalletLabels creates the buckets for the wallet labels if they don't
+// exist yet and locks all frozen outputs, so they are excluded from coin
+// selection.
+func (b *BtcWallet) initWalletLabels() error {
+	var frozen []wire.OutPoint
+	err := walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
+		labels, err := tx.CreateTopLevelBucket(walletLabelsBucket)
+		if err != nil {
+			return err
+		}
+
+		_, err = labels.CreateBucketIfNotExists(addrLabelsBucket)
+		if err != nil {
+			return err
+		}
+		outputs, err := labels.CreateBucketIfNotExists(
+			outputLabelsBucket,
+		)
+		if err != nil {
+			return err
+		}
+
+		return outputs.ForEach(func(k, v []byte) error {
+			var record labelRecord
+			err := record.decode(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+			if !record.frozen {
+				return nil
+			}
+
+			var op wire.OutPoint
+			copy(op.Hash[:], k[:32])
+			op.Index = binary.BigEndian.Uint32(k[32:])
+			frozen = append(frozen, op)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	b.frozenOutputsMtx.Lock()
+	defer b.frozenOutputsMtx.Unlock()
+
+	for _, op := range frozen {
+		b.frozenOutputs[op] = struct{}{}
+		b.wallet.LockOutpoint(op)
+	}
+
+	return nil
+}
+
+// attachLabels sets the label and tag of the given outputs. An output without
+// its own label or tag inherits the one of its address.
+func (b *BtcWallet) attachLabels(utxos []*lnwallet.Utxo) error {
+	return walletdb.View(b.db, func(tx walletdb.ReadTx) error {
+		for _, utxo := range utxos {
+			outputRecord, err := fetchLabel(
+				tx, outputLabelsBucket,
+				outpointKey(utxo.OutPoint),
+			)
+			if err != nil {
+				return err
+			}
+			addrRecord, err := fetchLabel(
+				tx, addrLabelsBucket, utxo.PkScript,
+			)
+			if err != nil {
+				return err
+			}
+
+			utxo.Label = outputRecord.label
+			if utxo.Label == "" {
+				utxo.Label = addrRecord.label
+			}
+			utxo.Tag = outputRecord.tag
+			if utxo.Tag == "" {
+				utxo.Tag = addrRecord.tag
+			}
+		}
+
+		return nil
+	})
+}
+
+// LabelAddress attaches a label and a tag to an address of the wallet.
+// Outputs paying to the address inherit its label and tag unless they have
+// their own. Empty values remove the label or the tag.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) LabelAddress(addr btcutil.Address, label,
+	tag string) error {
+
+	if err := validateLabel(label); err != nil {
+		return err
+	}
+	if err := validateLabel(tag); err != nil {
+		return err
+	}
+
+	if !b.IsOurAddress(addr) {
+		return fmt.Errorf("address %v is not a wallet address", addr)
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return err
+	}
+
+	return walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
+		return putLabel(tx, addrLabelsBucket, pkScript, &labelRecord{
+			label: label,
+			tag:   tag,
+		})
+	})
+}
+
+// LabelOutput attaches a label and a tag to an output of the wallet. Empty
+// values remove the label or the tag.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) LabelOutput(op wire.OutPoint, label, tag string) error {
+	if err := validateLabel(label); err != nil {
+		return err
+	}
+	if err := validateLabel(tag); err != nil {
+		return err
+	}
+
+	if _, err := b.FetchInputInfo(&op); err != nil {
+		return err
+	}
+
+	return walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
+		key := outpointKey(op)
+		record, err := fetchLabel(tx, outputLabelsBucket, key)
+		if err != nil {
+			return err
+		}
+
+		record.label = label
+		record.tag = tag
+
+		return putLabel(tx, outputLabelsBucket, key, record)
+	})
+}
+
+// FreezeOutput excludes an unspent output of the wallet from coin selection
+// until it is unfrozen. Unlike a lease, the freeze persists across restarts and
+// doesn't expire.
+//
+// NOTE: This method requires the global coin selection lock to be held.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) FreezeOutput(op wire.OutPoint) error {
+	b.frozenOutputsMtx.Lock()
+	defer b.frozenOutputsMtx.Unlock()
+
+	if _, ok := b.frozenOutputs[op]; ok {
+		return nil
+	}
+
+	// An output that is already locked is about to be spent, so we can't
+	// freeze it anymore.
+	if b.wallet.LockedOutpoint(op) {
+		return wtxmgr.ErrOutputAlreadyLocked
+	}
+
+	err := walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		credits, err := b.wallet.TxStore.UnspentOutputs(txmgrNs)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, credit := range credits {
+			if credit.OutPoint == op {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("output %v is not an unspent wallet "+
+				"output", op)
+		}
+
+		key := outpointKey(op)
+		record, err := fetchLabel(tx, outputLabelsBucket, key)
+		if err != nil {
+			return err
+		}
+		record.frozen = true
+
+		return putLabel(tx, outputLabelsBucket, key, record)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.frozenOutputs[op] = struct{}{}
+	b.wallet.LockOutpoint(op)
+
+	return nil
+}
+
+// UnfreezeOutput makes a frozen output available to coin selection again.
+//
+// NOTE: This method requires the global coin selection lock to be held.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) UnfreezeOutput(op wire.OutPoint) error {
+	b.frozenOutputsMtx.Lock()
+	defer b.frozenOutputsMtx.Unlock()
+
+	if _, ok := b.frozenOutputs[op]; !ok {
+		return fmt.Errorf("output %v is not frozen", op)
+	}
+
+	err := walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
+		key := outpointKey(op)
+		record, err := fetchLabel(tx, outputLabelsBucket, key)
+		if err != nil {
+			return err
+		}
+		record.frozen = false
+
+		return putLabel(tx, outputLabelsBucket, key, record)
+	})
+	if err != nil {
+		return err
+	}
+
+	delete(b.frozenOutputs, op)
+	b.wallet.UnlockOutpoint(op)
+
+	return nil
+}
+
+// listFrozenOutputs returns the frozen unspent outputs of the wallet that are
+// version 0 witness programs and have a number of confirmations between
+// minConfs and maxConfs. If accountFilter is set, only the outputs of that
+// account are returned. Labels aren't attached to the outputs.
+func (b *BtcWallet) listFrozenOutputs(minConfs, maxConfs int32,
+	accountFilter string) ([]*lnwallet.Utxo, error) {
+
+	b.frozenOutputsMtx.Lock()
+	frozen := make(map[wire.OutPoint]struct{}, len(b.frozenOutputs))
+	for op := range b.frozenOutputs {
+		frozen[op] = struct{}{}
+	}
+	b.frozenOutputsMtx.Unlock()
+
+	if len(frozen) == 0 {
+		return nil, nil
+	}
+
+	currentHeight := b.wallet.Manager.SyncedTo().Height
+
+	var utxos []*lnwallet.Utxo
+	err := walletdb.View(b.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+
+		credits, err := b.wallet.TxStore.UnspentOutputs(txmgrNs)
+		if err != nil {
+			return err
+		}
+
+		for _, credit := range credits {
+			if _, ok := frozen[credit.OutPoint]; !ok {
+				continue
+			}
+
+			var confs int32
+			if credit.Height != -1 {
+				confs = currentHeight - credit.Height + 1
+			}
+			if confs < minConfs || confs > maxConfs {
+				continue
+			}
+
+			var addressType lnwallet.AddressType
+			switch {
+			case txscript.IsPayToWitnessPubKeyHash(credit.PkScript):
+				addressType = lnwallet.WitnessPubKey
+			case txscript.IsPayToScriptHash(credit.PkScript):
+				addressType = lnwallet.NestedWitnessPubKey
+			default:
+				continue
+			}
+
+			// The account is looked up the same way the wallet
+			// does it when listing unspent outputs.
+			if accountFilter != "" {
+				account := b.outputAccount(
+					addrmgrNs, credit.PkScript,
+				)
+				if account != accountFilter {
+					continue
+				}
+			}
+
+			utxos = append(utxos, &lnwallet.Utxo{
+				AddressType:   addressType,
+				Value:         credit.Amount,
+				Confirmations: int64(confs),
+				PkScript:      credit.PkScript,
+				OutPoint:      credit.OutPoint,
+				Frozen:        true,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return utxos, nil
+}
+
+// outputAccount returns the name of the account that the address of the given
+// output script belongs to, or the default account if it can't be determined.
+func (b *BtcWallet) outputAccount(addrmgrNs walletdb.ReadBucket,
+	pkScript []byte) string {
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, b.netParams,
+	)
+	if err != nil || len(addrs) == 0 {
+		return lnwallet.DefaultAccountName
+	}
+
+	scopedMgr, account, err := b.wallet.Manager.AddrAccount(
+		addrmgrNs, addrs[0],
+	)
+	if err != nil {
+		return lnwallet.DefaultAccountName
+	}
+
+	name, err := scopedMgr.AccountName(addrmgrNs, account)
+	if err != nil {
+		return lnwallet.DefaultAccountName
+	}
+
+	return name
+}
@@ -0,0 +1,320 @@
+package btcwallet
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAddress creates a new external address of the default BIP0084
+// account of the wallet.
+func newTestAddress(t *testing.T, b *BtcWallet) btcutil.Address {
+	scopedMgr, err := b.wallet.Manager.FetchScopedKeyManager(
+		waddrmgr.KeyScopeBIP0084,
+	)
+	require.NoError(t, err)
+
+	var addr btcutil.Address
+	err = walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+
+		addrs, err := scopedMgr.NextExternalAddresses(
+			addrmgrNs, defaultAccount, 1,
+		)
+		if err != nil {
+			return err
+		}
+		addr = addrs[0].Address()
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return addr
+}
+
+// addTestOutput adds an unconfirmed output paying the given amount to the
+// given address to the wallet.
+func addTestOutput(t *testing.T, b *BtcWallet, addr btcutil.Address,
+	amt btcutil.Amount) wire.OutPoint {
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	// The input only makes the transaction unique.
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: uint32(amt)},
+	})
+	tx.AddTxOut(&wire.TxOut{Value: int64(amt), PkScript: pkScript})
+
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+	require.NoError(t, err)
+
+	err = walletdb.Update(b.db, func(dbTx walletdb.ReadWriteTx) error {
+		txmgrNs := dbTx.ReadWriteBucket(wtxmgrNamespaceKey)
+
+		err := b.wallet.TxStore.InsertTx(txmgrNs, rec, nil)
+		if err != nil {
+			return err
+		}
+
+		return b.wallet.TxStore.AddCredit(txmgrNs, rec, nil, 0, false)
+	})
+	require.NoError(t, err)
+
+	return wire.OutPoint{Hash: tx.TxHash(), Index: 0}
+}
+
+// TestLabelRecordEncoding tests that label records survive an encode/decode
+// round trip.
+func TestLabelRecordEncoding(t *testing.T) {
+	t.Parallel()
+
+	records := []labelRecord{
+		{},
+		{label: "label"},
+		{tag: "tag"},
+		{frozen: true},
+		{
+			label:  strings.Repeat("l", wtxmgr.TxLabelLimit),
+			tag:    strings.Repeat("t", wtxmgr.TxLabelLimit),
+			frozen: true,
+		},
+	}
+	for _, record := range records {
+		var b bytes.Buffer
+		require.NoError(t, record.encode(&b))
+		encoded := b.Bytes()
+
+		var decoded labelRecord
+		require.NoError(t, decoded.decode(bytes.NewReader(encoded)))
+		require.Equal(t, record, decoded)
+
+		// A truncated record can't be decoded.
+		var truncated labelRecord
+		err := truncated.decode(
+			bytes.NewReader(encoded[:len(encoded)-1]),
+		)
+		require.Error(t, err)
+	}
+
+	require.True(t, (&labelRecord{}).isEmpty())
+	require.False(t, (&labelRecord{frozen: true}).isEmpty())
+}
+
+// TestAttachLabels tests that outputs inherit the label and tag of their
+// address unless they have their own.
+func TestAttachLabels(t *testing.T) {
+	t.Parallel()
+
+	b := newTestWallet(t)
+	require.NoError(t, b.initWalletLabels())
+
+	addr := newTestAddress(t, b)
+	labelled := addTestOutput(t, b, addr, 10000)
+	unlabelled := addTestOutput(t, b, addr, 20000)
+
+	// Only wallet addresses can be labelled.
+	foreignAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		make([]byte, 20), testNetParams,
+	)
+	require.NoError(t, err)
+	require.Error(t, b.LabelAddress(foreignAddr, "foreign", ""))
+
+	// Labels that exceed the limit are rejected.
+	require.Error(t, b.LabelAddress(
+		addr, strings.Repeat("l", wtxmgr.TxLabelLimit+1), "",
+	))
+
+	require.NoError(t, b.LabelAddress(addr, "address", "address tag"))
+	err = walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
+		return putLabel(
+			tx, outputLabelsBucket, outpointKey(labelled),
+			&labelRecord{label: "output"},
+		)
+	})
+	require.NoError(t, err)
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	utxos := []*lnwallet.Utxo{
+		{OutPoint: labelled, PkScript: pkScript},
+		{OutPoint: unlabelled, PkScript: pkScript},
+	}
+	require.NoError(t, b.attachLabels(utxos))
+
+	require.Equal(t, "output", utxos[0].Label)
+	require.Equal(t, "address tag", utxos[0].Tag)
+	require.Equal(t, "address", utxos[1].Label)
+	require.Equal(t, "address tag", utxos[1].Tag)
+
+	// Removing the labels of the address removes its record, and the
+	// outputs only keep their own labels.
+	require.NoError(t, b.LabelAddress(addr, "", ""))
+	err = walletdb.View(b.db, func(tx walletdb.ReadTx) error {
+		bucket := tx.ReadBucket(walletLabelsBucket).NestedReadBucket(
+			addrLabelsBucket,
+		)
+		require.Nil(t, bucket.Get(pkScript))
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, b.attachLabels(utxos))
+	require.Equal(t, "output", utxos[0].Label)
+	require.Empty(t, utxos[0].Tag)
+	require.Empty(t, utxos[1].Label)
+	require.Empty(t, utxos[1].Tag)
+}
+
+// TestFreezeOutputRestart tests that frozen outputs are locked again when the
+// wallet is restarted.
+func TestFreezeOutputRestart(t *testing.T) {
+	t.Parallel()
+
+	b := newTestWallet(t)
+	require.NoError(t, b.initWalletLabels())
+
+	addr := newTestAddress(t, b)
+	frozen := addTestOutput(t, b, addr, 10000)
+	unfrozen := addTestOutput(t, b, addr, 20000)
+
+	// Only unspent wallet outputs can be frozen.
+	require.Error(t, b.FreezeOutput(wire.OutPoint{Index: 1}))
+
+	require.NoError(t, b.FreezeOutput(frozen))
+	require.NoError(t, b.FreezeOutput(unfrozen))
+	require.NoError(t, b.UnfreezeOutput(unfrozen))
+	require.Error(t, b.UnfreezeOutput(unfrozen))
+
+	// Outputs that are locked for a spend can't be frozen.
+	locked := addTestOutput(t, b, addr, 30000)
+	b.LockOutpoint(locked)
+	require.Equal(t, wtxmgr.ErrOutputAlreadyLocked, b.FreezeOutput(locked))
+
+	// Locks only live in memory, so after a restart only the freeze state
+	// stored in the database is left.
+	b.wallet.ResetLockedOutpoints()
+	restarted := &BtcWallet{
+		wallet:        b.wallet,
+		db:            b.db,
+		netParams:     b.netParams,
+		chainKeyScope: b.chainKeyScope,
+		frozenOutputs: make(map[wire.OutPoint]struct{}),
+	}
+	require.NoError(t, restarted.initWalletLabels())
+
+	require.True(t, restarted.wallet.LockedOutpoint(frozen))
+	require.False(t, restarted.wallet.LockedOutpoint(unfrozen))
+	require.False(t, restarted.wallet.LockedOutpoint(locked))
+
+	utxos, err := restarted.listFrozenOutputs(0, math.MaxInt32, "")
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	require.Equal(t, frozen, utxos[0].OutPoint)
+	require.Equal(t, btcutil.Amount(10000), utxos[0].Value)
+}
+
+// TestListUnspentFrozen tests that frozen outputs are listed with their frozen
+// flag set and count towards their tag, but are neither part of the balance nor
+// handed to coin selection.
+func TestListUnspentFrozen(t *testing.T) {
+	t.Parallel()
+
+	b := newTestWallet(t)
+	require.NoError(t, b.initWalletLabels())
+
+	addr := newTestAddress(t, b)
+	require.NoError(t, b.LabelAddress(addr, "", "savings"))
+	frozen := addTestOutput(t, b, addr, 10000)
+	spendable := addTestOutput(t, b, addr, 20000)
+	require.NoError(t, b.FreezeOutput(frozen))
+
+	utxos, err := b.ListUnspentWitness(0, math.MaxInt32, "")
+	require.NoError(t, err)
+	require.Len(t, utxos, 2)
+
+	listed := make(map[wire.OutPoint]*lnwallet.Utxo)
+	for _, utxo := range utxos {
+		listed[utxo.OutPoint] = utxo
+	}
+	require.Contains(t, listed, frozen)
+	require.Contains(t, listed, spendable)
+	require.True(t, listed[frozen].Frozen)
+	require.False(t, listed[spendable].Frozen)
+	require.Equal(t, "savings", listed[frozen].Tag)
+	require.Equal(t, lnwallet.WitnessPubKey, listed[frozen].AddressType)
+
+	// The frozen output belongs to the default account, so it is only
+	// listed when filtering by that account.
+	utxos, err = b.ListUnspentWitness(
+		0, math.MaxInt32, lnwallet.DefaultAccountName,
+	)
+	require.NoError(t, err)
+	require.Len(t, utxos, 2)
+
+	utxos, err = b.listFrozenOutputs(0, math.MaxInt32, "other")
+	require.NoError(t, err)
+	require.Empty(t, utxos)
+
+	// Frozen outputs are not part of the balance.
+	balance, err := b.ConfirmedBalance(0, "")
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(20000), balance)
+
+	// Coin selection never sees the frozen output.
+	wallet := &lnwallet.LightningWallet{WalletController: b}
+	coins, err := wallet.ListUnspentWitnessFromDefaultAccount(
+		0, math.MaxInt32,
+	)
+	require.NoError(t, err)
+	require.Len(t, coins, 1)
+	require.Equal(t, spendable, coins[0].OutPoint)
+
+	// Once unfrozen, the output is listed without its flag again.
+	require.NoError(t, b.UnfreezeOutput(frozen))
+	utxos, err = b.ListUnspentWitness(0, math.MaxInt32, "")
+	require.NoError(t, err)
+	require.Len(t, utxos, 2)
+	for _, utxo := range utxos {
+		require.False(t, utxo.Frozen)
+	}
+}
+
+// TestUnlockFrozenOutput tests that unlocking a frozen output, for example
+// when a funding attempt that selected it is aborted, keeps it locked.
+func TestUnlockFrozenOutput(t *testing.T) {
+	t.Parallel()
+
+	b := newTestWallet(t)
+	require.NoError(t, b.initWalletLabels())
+
+	addr := newTestAddress(t, b)
+	frozen := addTestOutput(t, b, addr, 10000)
+	locked := addTestOutput(t, b, addr, 20000)
+
+	require.NoError(t, b.FreezeOutput(frozen))
+	b.LockOutpoint(locked)
+
+	b.UnlockOutpoint(frozen)
+	b.UnlockOutpoint(locked)
+	require.True(t, b.wallet.LockedOutpoint(frozen))
+	require.False(t, b.wallet.LockedOutpoint(locked))
+
+	// Once unfrozen, the output is available to coin selection again.
+	require.NoError(t, b.UnfreezeOutput(frozen))
+	require.False(t, b.wallet.LockedOutpoint(frozen))
+}
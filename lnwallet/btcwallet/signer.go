@@ -1,11 +1,16 @@
 package btcwallet
 
 import (
+	"bytes"
+	"fmt"
+
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcutil/psbt"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/go-errors/errors"
@@ -240,6 +245,149 @@ func (b *BtcWallet) ComputeInputScript(tx *wire.MsgTx,
 	}, nil
 }
 
+// SignPsbt expects a partial transaction with all inputs and outputs fully
+// declared and adds a partial signature to all inputs that have the BIP32
+// derivation path of one of the wallet's keys attached. Inputs without
+// derivation information or with final witness data are skipped. As the key
+// is found through the derivation path alone, the wallet doesn't need to know
+// the UTXO or the address that is spent.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) SignPsbt(packet *psbt.Packet) error {
+	// Let's check that this is actually something we can and want to sign.
+	// We need at least one input and one output.
+	err := psbt.VerifyInputOutputLen(packet, true, true)
+	if err != nil {
+		return err
+	}
+
+	tx := packet.UnsignedTx
+	sigHashes := txscript.NewTxSigHashes(tx)
+	for idx := range tx.TxIn {
+		in := &packet.Inputs[idx]
+
+		// Skip inputs that are already final or that we don't know how
+		// to derive the key for.
+		if len(in.FinalScriptWitness) > 0 ||
+			len(in.Bip32Derivation) == 0 {
+
+			continue
+		}
+
+		// We only sign segwit v0 inputs, so the spent output must be
+		// attached as the witness UTXO.
+		if in.WitnessUtxo == nil {
+			return fmt.Errorf("input %d has no witness UTXO", idx)
+		}
+
+		// The script that is signed is the witness script if there is
+		// one. Otherwise this is a (nested) p2wkh input, which signs
+		// the witness program.
+		var script []byte
+		switch {
+		case len(in.WitnessScript) > 0:
+			script = in.WitnessScript
+
+		case len(in.RedeemScript) > 0:
+			script = in.RedeemScript
+
+		default:
+			script = in.WitnessUtxo.PkScript
+		}
+
+		sigHashType := in.SighashType
+		if sigHashType == 0 {
+			sigHashType = txscript.SigHashAll
+		}
+
+		for _, derivation := range in.Bip32Derivation {
+			privKey, err := b.derivePrivKeyFromPath(
+				derivation.Bip32Path,
+			)
+			if err != nil {
+				return fmt.Errorf("error deriving key for "+
+					"input %d: %v", idx, err)
+			}
+
+			pubKey := privKey.PubKey().SerializeCompressed()
+			if !bytes.Equal(pubKey, derivation.PubKey) {
+				return fmt.Errorf("derived key for input %d "+
+					"doesn't match public key %x", idx,
+					derivation.PubKey)
+			}
+
+			sig, err := txscript.RawTxInWitnessSignature(
+				tx, sigHashes, idx, in.WitnessUtxo.Value,
+				script, sigHashType, privKey,
+			)
+			if err != nil {
+				return fmt.Errorf("error signing input %d: %v",
+					idx, err)
+			}
+
+			in.PartialSigs = append(in.PartialSigs, &psbt.PartialSig{
+				PubKey:    pubKey,
+				Signature: sig,
+			})
+		}
+	}
+
+	return nil
+}
+
+// derivePrivKeyFromPath derives the private key at the given BIP32 path. The
+// path must consist of the hardened purpose, coin type and account, followed
+// by the branch and the index, as used by all key scopes of the wallet.
+func (b *BtcWallet) derivePrivKeyFromPath(
+	path []uint32) (*btcec.PrivateKey, error) {
+
+	if len(path) != 5 {
+		return nil, fmt.Errorf("invalid derivation path length %d",
+			len(path))
+	}
+	for _, element := range path[:3] {
+		if element < hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("derivation path %v must be "+
+				"hardened up to the account", path)
+		}
+	}
+
+	scope := waddrmgr.KeyScope{
+		Purpose: path[0] - hdkeychain.HardenedKeyStart,
+		Coin:    path[1] - hdkeychain.HardenedKeyStart,
+	}
+	scopedMgr, err := b.wallet.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var key *btcec.PrivateKey
+	err = walletdb.View(b.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+
+		addr, err := scopedMgr.DeriveFromKeyPath(
+			addrmgrNs, waddrmgr.DerivationPath{
+				InternalAccount: path[2] -
+					hdkeychain.HardenedKeyStart,
+				Account: path[2],
+				Branch:  path[3],
+				Index:   path[4],
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		key, err = addr.(waddrmgr.ManagedPubKeyAddress).PrivKey()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
 // A compile time check to ensure that BtcWallet implements the Signer
 // interface.
 var _ input.Signer = (*BtcWallet)(nil)
@@ -0,0 +1,199 @@
+package btcwallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// testSpend describes an output of the test wallet that is spent in a PSBT.
+type testSpend struct {
+	scope  waddrmgr.KeyScope
+	branch uint32
+	index  uint32
+	nested bool
+}
+
+// bip32Path returns the BIP32 derivation path of the key of the output.
+func (s testSpend) bip32Path() []uint32 {
+	return []uint32{
+		s.scope.Purpose + hdkeychain.HardenedKeyStart,
+		s.scope.Coin + hdkeychain.HardenedKeyStart,
+		hdkeychain.HardenedKeyStart, s.branch, s.index,
+	}
+}
+
+// pInput returns the PSBT input that spends the output, along with the public
+// key of the output.
+func (s testSpend) pInput(t *testing.T) (psbt.PInput, []byte) {
+	key := testAccountKey(t, s.scope, 0)
+	key, err := key.Derive(s.branch)
+	require.NoError(t, err)
+	key, err = key.Derive(s.index)
+	require.NoError(t, err)
+
+	pubKey, err := key.ECPubKey()
+	require.NoError(t, err)
+	pubKeyBytes := pubKey.SerializeCompressed()
+
+	witnessProgram, err := txscript.NewScriptBuilder().AddOp(
+		txscript.OP_0,
+	).AddData(btcutil.Hash160(pubKeyBytes)).Script()
+	require.NoError(t, err)
+
+	in := psbt.PInput{
+		WitnessUtxo: &wire.TxOut{
+			Value:    100000,
+			PkScript: witnessProgram,
+		},
+		Bip32Derivation: []*psbt.Bip32Derivation{{
+			PubKey:    pubKeyBytes,
+			Bip32Path: s.bip32Path(),
+		}},
+	}
+
+	if s.nested {
+		addr, err := btcutil.NewAddressScriptHash(
+			witnessProgram, testNetParams,
+		)
+		require.NoError(t, err)
+
+		in.WitnessUtxo.PkScript, err = txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+		in.RedeemScript = witnessProgram
+	}
+
+	return in, pubKeyBytes
+}
+
+// newTestPacket creates a PSBT that spends the given inputs.
+func newTestPacket(t *testing.T, inputs ...psbt.PInput) *psbt.Packet {
+	tx := wire.NewMsgTx(2)
+	for i := range inputs {
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: uint32(i)},
+		})
+	}
+	tx.AddTxOut(&wire.TxOut{
+		Value:    50000,
+		PkScript: []byte{txscript.OP_TRUE},
+	})
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+	copy(packet.Inputs, inputs)
+
+	return packet
+}
+
+// assertValidSpend checks that the partial signature of the given input of
+// the PSBT is valid for the output that it spends.
+func assertValidSpend(t *testing.T, packet *psbt.Packet, idx int) {
+	in := packet.Inputs[idx]
+	require.Len(t, in.PartialSigs, 1)
+
+	tx := packet.UnsignedTx.Copy()
+	tx.TxIn[idx].Witness = wire.TxWitness{
+		in.PartialSigs[0].Signature, in.PartialSigs[0].PubKey,
+	}
+	if len(in.RedeemScript) > 0 {
+		sigScript, err := txscript.NewScriptBuilder().AddData(
+			in.RedeemScript,
+		).Script()
+		require.NoError(t, err)
+		tx.TxIn[idx].SignatureScript = sigScript
+	}
+
+	vm, err := txscript.NewEngine(
+		in.WitnessUtxo.PkScript, tx, idx, txscript.StandardVerifyFlags,
+		nil, nil, in.WitnessUtxo.Value,
+	)
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+}
+
+// TestSignPsbt tests that the wallet signs the inputs of a PSBT that carry the
+// derivation path of one of its keys, without knowing the outputs they spend.
+func TestSignPsbt(t *testing.T) {
+	w := newTestWallet(t)
+
+	p2wkh, _ := testSpend{
+		scope: waddrmgr.KeyScopeBIP0084,
+		index: 3,
+	}.pInput(t)
+	np2wkh, _ := testSpend{
+		scope:  waddrmgr.KeyScopeBIP0049Plus,
+		branch: 1,
+		index:  7,
+		nested: true,
+	}.pInput(t)
+
+	// An input without derivation info isn't ours to sign.
+	foreign, _ := testSpend{scope: waddrmgr.KeyScopeBIP0084}.pInput(t)
+	foreign.Bip32Derivation = nil
+
+	packet := newTestPacket(t, p2wkh, np2wkh, foreign)
+	require.NoError(t, w.SignPsbt(packet))
+
+	assertValidSpend(t, packet, 0)
+	assertValidSpend(t, packet, 1)
+	require.Empty(t, packet.Inputs[2].PartialSigs)
+}
+
+// TestSignPsbtInvalid tests that inputs the wallet can't sign correctly are
+// rejected.
+func TestSignPsbtInvalid(t *testing.T) {
+	w := newTestWallet(t)
+
+	spend := testSpend{scope: waddrmgr.KeyScopeBIP0084}
+	_, otherPubKey := testSpend{
+		scope: waddrmgr.KeyScopeBIP0084,
+		index: 1,
+	}.pInput(t)
+
+	tests := []struct {
+		name   string
+		modify func(in *psbt.PInput)
+	}{{
+		name: "public key mismatch",
+		modify: func(in *psbt.PInput) {
+			in.Bip32Derivation[0].PubKey = otherPubKey
+		},
+	}, {
+		name: "path too short",
+		modify: func(in *psbt.PInput) {
+			path := in.Bip32Derivation[0].Bip32Path
+			in.Bip32Derivation[0].Bip32Path = path[:4]
+		},
+	}, {
+		name: "account not hardened",
+		modify: func(in *psbt.PInput) {
+			in.Bip32Derivation[0].Bip32Path[2] = 0
+		},
+	}, {
+		name: "unknown key scope",
+		modify: func(in *psbt.PInput) {
+			in.Bip32Derivation[0].Bip32Path[0] =
+				hdkeychain.HardenedKeyStart + 99
+		},
+	}, {
+		name: "no witness utxo",
+		modify: func(in *psbt.PInput) {
+			in.WitnessUtxo = nil
+		},
+	}}
+
+	for _, test := range tests {
+		in, _ := spend.pInput(t)
+		test.modify(&in)
+
+		packet := newTestPacket(t, in)
+		require.Error(t, w.SignPsbt(packet), test.name)
+	}
+}
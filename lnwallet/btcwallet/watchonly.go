@@ -0,0 +1,142 @@
+package btcwallet
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	base "github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lnwallet"
+)
+
+// ValidateWatchOnlyAccounts makes sure that the given account public keys
+// contain all the accounts lnd needs, which are the default accounts of the
+// BIP-0049 and BIP-0084 key scopes and the accounts of all of lnd's key
+// families within the lightning key scope of the given coin type.
+func ValidateWatchOnlyAccounts(coinType uint32,
+	accounts map[waddrmgr.ScopedIndex]*hdkeychain.ExtendedKey) error {
+
+	lightningScope := waddrmgr.KeyScope{
+		Purpose: keychain.BIP0043Purpose,
+		Coin:    coinType,
+	}
+
+	required := []waddrmgr.ScopedIndex{{
+		Scope: waddrmgr.KeyScopeBIP0049Plus,
+	}, {
+		Scope: waddrmgr.KeyScopeBIP0084,
+	}}
+	for _, keyFam := range keychain.VersionZeroKeyFamilies {
+		required = append(required, waddrmgr.ScopedIndex{
+			Scope: lightningScope,
+			Index: uint32(keyFam),
+		})
+	}
+	for _, index := range required {
+		if _, ok := accounts[index]; !ok {
+			return fmt.Errorf("account %v/%d' is missing",
+				index.Scope, index.Index)
+		}
+	}
+
+	for index := range accounts {
+		_, ok := waddrmgr.ScopeAddrMap[index.Scope]
+		if !ok && index.Scope != lightningScope {
+			return fmt.Errorf("unsupported key scope %v",
+				index.Scope)
+		}
+	}
+
+	return nil
+}
+
+// ImportWatchOnlyAccounts imports the given account public keys into a newly
+// created watch-only wallet. The accounts must pass ValidateWatchOnlyAccounts.
+// Account 0 of every key scope is imported as the default account, all
+// other accounts are imported as raw accounts under their account number.
+func ImportWatchOnlyAccounts(w *base.Wallet, coinType uint32,
+	masterKeyFingerprint uint32,
+	accounts map[waddrmgr.ScopedIndex]*hdkeychain.ExtendedKey) error {
+
+	if !w.Manager.WatchOnly() {
+		return fmt.Errorf("cannot import watch-only accounts into " +
+			"a wallet with private keys")
+	}
+
+	if err := ValidateWatchOnlyAccounts(coinType, accounts); err != nil {
+		return err
+	}
+
+	lightningScope := waddrmgr.KeyScope{
+		Purpose: keychain.BIP0043Purpose,
+		Coin:    coinType,
+	}
+
+	// The accounts are imported in ascending order, so that account 0 is
+	// the first account of its scope and can become the default account.
+	indexes := make([]waddrmgr.ScopedIndex, 0, len(accounts))
+	for index := range accounts {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		a, b := indexes[i], indexes[j]
+		switch {
+		case a.Scope.Purpose != b.Scope.Purpose:
+			return a.Scope.Purpose < b.Scope.Purpose
+
+		case a.Scope.Coin != b.Scope.Coin:
+			return a.Scope.Coin < b.Scope.Coin
+
+		default:
+			return a.Index < b.Index
+		}
+	})
+
+	db := w.Database()
+	return walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+
+		for _, index := range indexes {
+			addrSchema := waddrmgr.ScopeAddrMap[index.Scope]
+			if index.Scope == lightningScope {
+				addrSchema = lightningAddrSchema
+			}
+
+			scope, err := w.Manager.FetchScopedKeyManager(
+				index.Scope,
+			)
+			if err != nil {
+				scope, err = w.Manager.NewScopedKeyManager(
+					addrmgrNs, index.Scope, addrSchema,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			accountPubKey := accounts[index]
+			if index.Index == waddrmgr.DefaultAccountNum {
+				_, err = scope.NewAccountWatchingOnly(
+					addrmgrNs, lnwallet.DefaultAccountName,
+					accountPubKey, masterKeyFingerprint,
+					nil,
+				)
+			} else {
+				err = scope.NewRawAccountWatchingOnly(
+					addrmgrNs, index.Index, accountPubKey,
+					masterKeyFingerprint, nil,
+				)
+			}
+			if err != nil {
+				return fmt.Errorf("error importing account "+
+					"%v/%d': %v", index.Scope, index.Index,
+					err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,168 @@
+package btcwallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	base "github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/stretchr/testify/require"
+)
+
+// testWatchOnlyAccounts returns the account public keys of all accounts that
+// a watch-only wallet needs, derived from the test seed.
+func testWatchOnlyAccounts(
+	t *testing.T) map[waddrmgr.ScopedIndex]*hdkeychain.ExtendedKey {
+
+	indexes := []waddrmgr.ScopedIndex{{
+		Scope: waddrmgr.KeyScopeBIP0049Plus,
+	}, {
+		Scope: waddrmgr.KeyScopeBIP0084,
+	}}
+	for _, keyFam := range keychain.VersionZeroKeyFamilies {
+		indexes = append(indexes, waddrmgr.ScopedIndex{
+			Scope: testLightningScope,
+			Index: uint32(keyFam),
+		})
+	}
+
+	accounts := make(map[waddrmgr.ScopedIndex]*hdkeychain.ExtendedKey)
+	for _, index := range indexes {
+		key := testAccountKey(t, index.Scope, index.Index)
+		pubKey, err := key.Neuter()
+		require.NoError(t, err)
+
+		accounts[index] = pubKey
+	}
+
+	return accounts
+}
+
+// TestValidateWatchOnlyAccounts tests that the accounts of a watch-only wallet
+// must be complete and only use supported key scopes.
+func TestValidateWatchOnlyAccounts(t *testing.T) {
+	coinType := testLightningScope.Coin
+
+	accounts := testWatchOnlyAccounts(t)
+	require.NoError(t, ValidateWatchOnlyAccounts(coinType, accounts))
+
+	// Further accounts of supported key scopes are fine.
+	extra := waddrmgr.ScopedIndex{
+		Scope: waddrmgr.KeyScopeBIP0084,
+		Index: 1,
+	}
+	accounts[extra] = accounts[waddrmgr.ScopedIndex{
+		Scope: waddrmgr.KeyScopeBIP0084,
+	}]
+	require.NoError(t, ValidateWatchOnlyAccounts(coinType, accounts))
+
+	// The lightning key scope of a different coin type isn't supported.
+	otherCoin := waddrmgr.ScopedIndex{
+		Scope: waddrmgr.KeyScopeBIP0084,
+	}
+	otherCoin.Scope.Purpose = keychain.BIP0043Purpose
+	otherCoin.Scope.Coin = keychain.CoinTypeBitcoin
+	accounts[otherCoin] = accounts[extra]
+	require.Error(t, ValidateWatchOnlyAccounts(coinType, accounts))
+	delete(accounts, otherCoin)
+
+	// Every required account must be present.
+	for index := range testWatchOnlyAccounts(t) {
+		key := accounts[index]
+		delete(accounts, index)
+
+		require.Error(
+			t, ValidateWatchOnlyAccounts(coinType, accounts),
+			"account %v/%d'", index.Scope, index.Index,
+		)
+
+		accounts[index] = key
+	}
+}
+
+// TestImportWatchOnlyAccounts tests that a watch-only wallet with imported
+// accounts derives the same addresses and lightning keys as the wallet that
+// holds the private keys.
+func TestImportWatchOnlyAccounts(t *testing.T) {
+	coinType := testLightningScope.Coin
+	accounts := testWatchOnlyAccounts(t)
+
+	// Accounts can only be imported into a watch-only wallet.
+	privWallet := newTestWallet(t).wallet
+	err := ImportWatchOnlyAccounts(privWallet, coinType, 0, accounts)
+	require.Error(t, err)
+
+	// Incomplete accounts are rejected before anything is imported.
+	watchOnlyWallet := newTestBaseWallet(t, true)
+	incomplete := testWatchOnlyAccounts(t)
+	delete(incomplete, waddrmgr.ScopedIndex{
+		Scope: waddrmgr.KeyScopeBIP0084,
+	})
+	err = ImportWatchOnlyAccounts(
+		watchOnlyWallet, coinType, 0, incomplete,
+	)
+	require.Error(t, err)
+
+	err = ImportWatchOnlyAccounts(watchOnlyWallet, coinType, 0, accounts)
+	require.NoError(t, err)
+
+	// The default accounts derive the same addresses.
+	for _, scope := range []waddrmgr.KeyScope{
+		waddrmgr.KeyScopeBIP0049Plus, waddrmgr.KeyScopeBIP0084,
+	} {
+		expected := defaultAccountAddr(t, privWallet, scope, 2)
+		addr := defaultAccountAddr(t, watchOnlyWallet, scope, 2)
+		require.Equal(t, expected, addr)
+	}
+
+	// The key families derive the same lightning keys.
+	privKeyRing := keychain.NewBtcWalletKeyRing(privWallet, coinType)
+	watchOnlyKeyRing := keychain.NewBtcWalletKeyRing(
+		watchOnlyWallet, coinType,
+	)
+	for _, keyFam := range keychain.VersionZeroKeyFamilies {
+		keyLoc := keychain.KeyLocator{Family: keyFam, Index: 5}
+
+		expected, err := privKeyRing.DeriveKey(keyLoc)
+		require.NoError(t, err)
+
+		keyDesc, err := watchOnlyKeyRing.DeriveKey(keyLoc)
+		require.NoError(t, err)
+		require.True(t, expected.PubKey.IsEqual(keyDesc.PubKey))
+	}
+}
+
+// defaultAccountAddr derives the external address with the given index of the
+// default account of the given key scope.
+func defaultAccountAddr(t *testing.T, w *base.Wallet, scope waddrmgr.KeyScope,
+	index uint32) string {
+
+	scopedMgr, err := w.Manager.FetchScopedKeyManager(scope)
+	require.NoError(t, err)
+
+	var addr waddrmgr.ManagedAddress
+	err = walletdb.View(w.Database(), func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+
+		account, err := scopedMgr.LookupAccount(
+			addrmgrNs, lnwallet.DefaultAccountName,
+		)
+		if err != nil {
+			return err
+		}
+
+		addr, err = scopedMgr.DeriveFromKeyPath(
+			addrmgrNs, waddrmgr.DerivationPath{
+				InternalAccount: account,
+				Index:           index,
+			},
+		)
+		return err
+	})
+	require.NoError(t, err)
+
+	return addr.Address().String()
+}
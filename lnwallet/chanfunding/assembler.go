@@ -78,6 +78,22 @@ type Request struct {
 	// ChangeAddr is a closure that will provide the Assembler with a
 	// change address for the funding transaction if needed.
 	ChangeAddr func() (btcutil.Address, error)
+
+	// ReservedAmt is the value that must remain in the wallet after the
+	// funding transaction is published, as it's reserved for fee bumping
+	// anchor channels. If SubtractFees is set, the local amount is lowered
+	// such that the reserved value stays in the wallet.
+	ReservedAmt btcutil.Amount
+
+	// Outpoints is an optional list of wallet outputs that must be used
+	// to fund the channel. If set, all of these outputs are spent and no
+	// other coins are selected.
+	Outpoints []wire.OutPoint
+
+	// CoinSelectionStrategy is the strategy used to pick coins if no
+	// explicit outpoints are given. If set to CoinSelectionDefault, the
+	// assembler's default strategy is used.
+	CoinSelectionStrategy CoinSelectionStrategy
 }
 
 // Intent is returned by an Assembler and represents the base functionality the
@@ -2,6 +2,8 @@ package chanfunding
 
 import (
 	"fmt"
+	"math/rand"
+	"sort"
 
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
@@ -47,17 +49,164 @@ type Coin struct {
 	wire.OutPoint
 }
 
+// CoinSelectionStrategy describes how coins are ordered and grouped before
+// the coin selection algorithm picks them.
+type CoinSelectionStrategy uint8
+
+const (
+	// CoinSelectionDefault instructs the assembler to use its own default
+	// coin selection strategy.
+	CoinSelectionDefault CoinSelectionStrategy = iota
+
+	// CoinSelectionLargest always picks the largest available coins
+	// first.
+	CoinSelectionLargest
+
+	// CoinSelectionRandom picks the available coins in a random order.
+	CoinSelectionRandom
+
+	// CoinSelectionPrivacy groups the available coins by their address
+	// and picks whole groups in a random order. All coins sent to the same
+	// address are spent together, so a re-used address is never linked
+	// to more than one transaction.
+	CoinSelectionPrivacy
+
+	// CoinSelectionManual spends all of the given coins. It is used when
+	// the coins were explicitly picked by the user.
+	CoinSelectionManual
+)
+
+// String returns a human readable name of the coin selection strategy.
+func (s CoinSelectionStrategy) String() string {
+	switch s {
+	case CoinSelectionDefault:
+		return "default"
+
+	case CoinSelectionLargest:
+		return "largest"
+
+	case CoinSelectionRandom:
+		return "random"
+
+	case CoinSelectionPrivacy:
+		return "privacy"
+
+	case CoinSelectionManual:
+		return "manual"
+
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+// arrangeCoins splits the coins into the groups the coin selection algorithm
+// picks from, in the order mandated by the strategy. A group is always
+// selected as a whole. The passed slice is not modified.
+func arrangeCoins(coins []Coin, strategy CoinSelectionStrategy) [][]Coin {
+	sorted := make([]Coin, len(coins))
+	copy(sorted, coins)
+
+	switch strategy {
+	case CoinSelectionRandom:
+		rand.Shuffle(len(sorted), func(i, j int) {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		})
+
+	case CoinSelectionPrivacy:
+		groups := make(map[string][]Coin)
+		var scripts []string
+		for _, coin := range sorted {
+			script := string(coin.PkScript)
+			if _, ok := groups[script]; !ok {
+				scripts = append(scripts, script)
+			}
+			groups[script] = append(groups[script], coin)
+		}
+
+		rand.Shuffle(len(scripts), func(i, j int) {
+			scripts[i], scripts[j] = scripts[j], scripts[i]
+		})
+
+		arranged := make([][]Coin, 0, len(scripts))
+		for _, script := range scripts {
+			arranged = append(arranged, groups[script])
+		}
+
+		return arranged
+
+	case CoinSelectionManual:
+		if len(sorted) == 0 {
+			return nil
+		}
+
+		return [][]Coin{sorted}
+
+	// The largest coins are picked first by default.
+	default:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Value > sorted[j].Value
+		})
+	}
+
+	arranged := make([][]Coin, 0, len(sorted))
+	for _, coin := range sorted {
+		arranged = append(arranged, []Coin{coin})
+	}
+
+	return arranged
+}
+
+// FilterCoins returns the coins that match the given outpoints, in the order
+// of the outpoints. An error is returned if an outpoint is not among the
+// coins, for example because it is locked or has too few confirmations.
+func FilterCoins(coins []Coin, outpoints []wire.OutPoint) ([]Coin, error) {
+	coinIndex := make(map[wire.OutPoint]Coin, len(coins))
+	for _, coin := range coins {
+		coinIndex[coin.OutPoint] = coin
+	}
+
+	filtered := make([]Coin, 0, len(outpoints))
+	seen := make(map[wire.OutPoint]struct{}, len(outpoints))
+	for _, outpoint := range outpoints {
+		if _, ok := seen[outpoint]; ok {
+			return nil, fmt.Errorf("outpoint %v specified more "+
+				"than once", outpoint)
+		}
+		seen[outpoint] = struct{}{}
+
+		coin, ok := coinIndex[outpoint]
+		if !ok {
+			return nil, fmt.Errorf("outpoint %v is not an "+
+				"unlocked wallet output with enough "+
+				"confirmations", outpoint)
+		}
+
+		filtered = append(filtered, coin)
+	}
+
+	return filtered, nil
+}
+
 // selectInputs selects a slice of inputs necessary to meet the specified
-// selection amount. If input selection is unable to succeed due to insufficient
-// funds, a non-nil error is returned. Additionally, the total amount of the
-// selected coins are returned in order for the caller to properly handle
-// change+fees.
-func selectInputs(amt btcutil.Amount, coins []Coin) (btcutil.Amount, []Coin, error) {
-	satSelected := btcutil.Amount(0)
-	for i, coin := range coins {
-		satSelected += btcutil.Amount(coin.Value)
+// selection amount. Coins are picked group by group in the given order. If
+// input selection is unable to succeed due to insufficient funds, a non-nil
+// error is returned. Additionally, the total amount of the selected coins are
+// returned in order for the caller to properly handle change+fees.
+func selectInputs(amt btcutil.Amount, groups [][]Coin) (btcutil.Amount,
+	[]Coin, error) {
+
+	var (
+		satSelected btcutil.Amount
+		selected    []Coin
+	)
+	for _, group := range groups {
+		for _, coin := range group {
+			satSelected += btcutil.Amount(coin.Value)
+		}
+		selected = append(selected, group...)
+
 		if satSelected >= amt {
-			return satSelected, coins[:i+1], nil
+			return satSelected, selected, nil
 		}
 	}
 
@@ -66,11 +215,12 @@ func selectInputs(amt btcutil.Amount, coins []Coin) (btcutil.Amount, []Coin, err
 
 // calculateFees returns for the specified utxos and fee rate two fee
 // estimates, one calculated using a change output and one without. The weight
-// added to the estimator from a change output is for a P2WKH output.
-func calculateFees(utxos []Coin, feeRate chainfee.SatPerKWeight) (btcutil.Amount,
+// added to the estimator from a change output is for a P2WKH output. The
+// passed estimator must already account for all non-change outputs.
+func calculateFees(utxos []Coin, feeRate chainfee.SatPerKWeight,
+	weightEstimate input.TxWeightEstimator) (btcutil.Amount,
 	btcutil.Amount, error) {
 
-	var weightEstimate input.TxWeightEstimator
 	for _, utxo := range utxos {
 		switch {
 
@@ -85,9 +235,6 @@ func calculateFees(utxos []Coin, feeRate chainfee.SatPerKWeight) (btcutil.Amount
 		}
 	}
 
-	// Channel funding multisig output is P2WSH.
-	weightEstimate.AddP2WSHOutput()
-
 	// Estimate the fee required for a transaction without a change
 	// output.
 	totalWeight := int64(weightEstimate.Weight())
@@ -105,6 +252,17 @@ func calculateFees(utxos []Coin, feeRate chainfee.SatPerKWeight) (btcutil.Amount
 	return requiredFeeNoChange, requiredFeeWithChange, nil
 }
 
+// fundingOutputEstimate returns a weight estimator that accounts for the
+// funding output of a channel.
+func fundingOutputEstimate() input.TxWeightEstimator {
+	var weightEstimate input.TxWeightEstimator
+
+	// Channel funding multisig output is P2WSH.
+	weightEstimate.AddP2WSHOutput()
+
+	return weightEstimate
+}
+
 // sanityCheckFee checks if the specified fee amounts to over 20% of the total
 // output amount and raises an error.
 func sanityCheckFee(totalOut, fee btcutil.Amount) error {
@@ -121,15 +279,50 @@ func sanityCheckFee(totalOut, fee btcutil.Amount) error {
 // CoinSelect attempts to select a sufficient amount of coins, including a
 // change output to fund amt satoshis, adhering to the specified fee rate. The
 // specified fee rate should be expressed in sat/kw for coin selection to
-// function properly.
+// function properly. The coins are picked according to the given strategy.
 func CoinSelect(feeRate chainfee.SatPerKWeight, amt, dustLimit btcutil.Amount,
-	coins []Coin) ([]Coin, btcutil.Amount, error) {
+	coins []Coin, strategy CoinSelectionStrategy) ([]Coin, btcutil.Amount,
+	error) {
+
+	return coinSelect(
+		feeRate, amt, dustLimit, arrangeCoins(coins, strategy),
+		fundingOutputEstimate(),
+	)
+}
+
+// CoinSelectOutputs attempts to select a sufficient amount of coins, including
+// a change output, to pay to the given outputs while adhering to the specified
+// fee rate. The coins are picked according to the given strategy.
+func CoinSelectOutputs(feeRate chainfee.SatPerKWeight, outputs []*wire.TxOut,
+	dustLimit btcutil.Amount, coins []Coin,
+	strategy CoinSelectionStrategy) ([]Coin, btcutil.Amount, error) {
+
+	var (
+		weightEstimate input.TxWeightEstimator
+		amt            btcutil.Amount
+	)
+	for _, output := range outputs {
+		weightEstimate.AddTxOutput(output)
+		amt += btcutil.Amount(output.Value)
+	}
+
+	return coinSelect(
+		feeRate, amt, dustLimit, arrangeCoins(coins, strategy),
+		weightEstimate,
+	)
+}
+
+// coinSelect selects coins from the given groups to fund amt satoshis plus
+// fees. The estimator must account for all outputs except the change output.
+func coinSelect(feeRate chainfee.SatPerKWeight, amt, dustLimit btcutil.Amount,
+	groups [][]Coin, outputEstimate input.TxWeightEstimator) ([]Coin,
+	btcutil.Amount, error) {
 
 	amtNeeded := amt
 	for {
 		// First perform an initial round of coin selection to estimate
 		// the required fee.
-		totalSat, selectedUtxos, err := selectInputs(amtNeeded, coins)
+		totalSat, selectedUtxos, err := selectInputs(amtNeeded, groups)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -137,7 +330,7 @@ func CoinSelect(feeRate chainfee.SatPerKWeight, amt, dustLimit btcutil.Amount,
 		// Obtain fee estimates both with and without using a change
 		// output.
 		requiredFeeNoChange, requiredFeeWithChange, err := calculateFees(
-			selectedUtxos, feeRate,
+			selectedUtxos, feeRate, outputEstimate,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -192,14 +385,18 @@ func CoinSelect(feeRate chainfee.SatPerKWeight, amt, dustLimit btcutil.Amount,
 
 // CoinSelectSubtractFees attempts to select coins such that we'll spend up to
 // amt in total after fees, adhering to the specified fee rate. The selected
-// coins, the final output and change values are returned.
+// coins, the final output and change values are returned. The coins are picked
+// according to the given strategy.
 func CoinSelectSubtractFees(feeRate chainfee.SatPerKWeight, amt,
-	dustLimit btcutil.Amount, coins []Coin) ([]Coin, btcutil.Amount,
+	dustLimit btcutil.Amount, coins []Coin,
+	strategy CoinSelectionStrategy) ([]Coin, btcutil.Amount,
 	btcutil.Amount, error) {
 
 	// First perform an initial round of coin selection to estimate
 	// the required fee.
-	totalSat, selectedUtxos, err := selectInputs(amt, coins)
+	totalSat, selectedUtxos, err := selectInputs(
+		amt, arrangeCoins(coins, strategy),
+	)
 	if err != nil {
 		return nil, 0, 0, err
 	}
@@ -207,7 +404,8 @@ func CoinSelectSubtractFees(feeRate chainfee.SatPerKWeight, amt,
 	// Obtain fee estimates both with and without using a change
 	// output.
 	requiredFeeNoChange, requiredFeeWithChange, err := calculateFees(
-		selectedUtxos, feeRate)
+		selectedUtxos, feeRate, fundingOutputEstimate(),
+	)
 	if err != nil {
 		return nil, 0, 0, err
 	}
@@ -120,7 +120,7 @@ func TestCalculateFees(t *testing.T) {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			feeNoChange, feeWithChange, err := calculateFees(
-				test.utxos, feeRate,
+				test.utxos, feeRate, fundingOutputEstimate(),
 			)
 			require.Equal(t, test.expectedErr, err)
 
@@ -265,6 +265,7 @@ func TestCoinSelect(t *testing.T) {
 
 			selected, changeAmt, err := CoinSelect(
 				feeRate, test.outputValue, dustLimit, test.coins,
+				CoinSelectionLargest,
 			)
 			if !test.expectErr && err != nil {
 				t.Fatalf(err.Error())
@@ -481,6 +482,7 @@ func TestCoinSelectSubtractFees(t *testing.T) {
 
 			selected, localFundingAmt, changeAmt, err := CoinSelectSubtractFees(
 				feeRate, test.spendValue, dustLimit, test.coins,
+				CoinSelectionLargest,
 			)
 			if err != nil {
 				switch {
@@ -530,3 +532,109 @@ func TestCoinSelectSubtractFees(t *testing.T) {
 		})
 	}
 }
+
+// TestCoinSelectStrategies tests that the coins are picked in the order
+// mandated by the coin selection strategy.
+func TestCoinSelectStrategies(t *testing.T) {
+	t.Parallel()
+
+	const (
+		feeRate   = chainfee.SatPerKWeight(100)
+		dustLimit = btcutil.Amount(1000)
+	)
+
+	otherScript, _ := hex.DecodeString(
+		"0014e7a9b7d2c1f4a0b3e5d6c7b8a9f0e1d2c3b4a5f6",
+	)
+
+	coin := func(value int64, script []byte, index uint32) Coin {
+		return Coin{
+			TxOut: wire.TxOut{
+				PkScript: script,
+				Value:    value,
+			},
+			OutPoint: wire.OutPoint{Index: index},
+		}
+	}
+
+	coins := []Coin{
+		coin(1*btcutil.SatoshiPerBitcoin, p2wkhScript, 0),
+		coin(3*btcutil.SatoshiPerBitcoin, otherScript, 1),
+		coin(2*btcutil.SatoshiPerBitcoin, p2wkhScript, 2),
+	}
+
+	// The largest coin alone is enough to fund the amount.
+	selected, _, err := CoinSelect(
+		feeRate, btcutil.SatoshiPerBitcoin, dustLimit, coins,
+		CoinSelectionLargest,
+	)
+	require.NoError(t, err)
+	require.Len(t, selected, 1)
+	require.Equal(t, coins[1], selected[0])
+
+	// With the privacy strategy, coins sent to the same address are
+	// always spent together.
+	for i := 0; i < 20; i++ {
+		selected, _, err = CoinSelect(
+			feeRate, btcutil.SatoshiPerBitcoin, dustLimit, coins,
+			CoinSelectionPrivacy,
+		)
+		require.NoError(t, err)
+
+		switch len(selected) {
+		case 1:
+			require.Equal(t, coins[1], selected[0])
+
+		case 2:
+			require.ElementsMatch(
+				t, []Coin{coins[0], coins[2]}, selected,
+			)
+
+		default:
+			t.Fatalf("unexpected selection: %v", selected)
+		}
+	}
+
+	// The manual strategy spends all coins even if fewer would suffice.
+	selected, changeAmt, err := CoinSelect(
+		feeRate, btcutil.SatoshiPerBitcoin, dustLimit, coins,
+		CoinSelectionManual,
+	)
+	require.NoError(t, err)
+	require.Equal(t, coins, selected)
+	require.Equal(
+		t, btcutil.Amount(5*btcutil.SatoshiPerBitcoin)-
+			fundingFee(feeRate, 3, true),
+		changeAmt,
+	)
+
+	// Manual selection fails if the coins aren't sufficient.
+	_, _, err = CoinSelect(
+		feeRate, 10*btcutil.SatoshiPerBitcoin, dustLimit, coins,
+		CoinSelectionManual,
+	)
+	require.IsType(t, &ErrInsufficientFunds{}, err)
+}
+
+// TestFilterCoins tests that coins are restricted to the given outpoints.
+func TestFilterCoins(t *testing.T) {
+	t.Parallel()
+
+	coins := []Coin{
+		{OutPoint: wire.OutPoint{Index: 0}},
+		{OutPoint: wire.OutPoint{Index: 1}},
+		{OutPoint: wire.OutPoint{Index: 2}},
+	}
+
+	filtered, err := FilterCoins(coins, []wire.OutPoint{
+		{Index: 2}, {Index: 0},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Coin{coins[2], coins[0]}, filtered)
+
+	_, err = FilterCoins(coins, []wire.OutPoint{{Index: 3}})
+	require.Error(t, err)
+
+	_, err = FilterCoins(coins, []wire.OutPoint{{Index: 1}, {Index: 1}})
+	require.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package chanfunding
 
 import (
+	"errors"
 	"fmt"
 	"math"
 
@@ -13,6 +14,12 @@ import (
 	"github.com/lightningnetwork/lnd/keychain"
 )
 
+// ErrReservedValueInvalidated is returned if funding a channel would take the
+// wallet balance below the value that is reserved for fee bumping anchor
+// channels.
+var ErrReservedValueInvalidated = errors.New("reserved wallet balance " +
+	"invalidated")
+
 // FullIntent is an intent that is fully backed by the internal wallet. This
 // intent differs from the ShimIntent, in that the funding transaction will be
 // constructed internally, and will consist of only inputs we wholly control.
@@ -211,6 +218,10 @@ type WalletConfig struct {
 	// DustLimit is the current dust limit. We'll use this to ensure that
 	// we don't make dust outputs on the funding transaction.
 	DustLimit btcutil.Amount
+
+	// CoinSelectionStrategy is the strategy used to pick coins for
+	// requests that don't specify their own.
+	CoinSelectionStrategy CoinSelectionStrategy
 }
 
 // WalletAssembler is an instance of the Assembler interface that is backed by
@@ -250,9 +261,7 @@ func (w *WalletAssembler) ProvisionChannel(r *Request) (Intent, error) {
 			"sat/kw as fee rate", int64(r.FeeRate))
 
 		// Find all unlocked unspent witness outputs that satisfy the
-		// minimum number of confirmations required. Coin selection in
-		// this function currently ignores the configured coin selection
-		// strategy.
+		// minimum number of confirmations required.
 		coins, err := w.cfg.CoinSource.ListCoins(
 			r.MinConfs, math.MaxInt32,
 		)
@@ -266,6 +275,30 @@ func (w *WalletAssembler) ProvisionChannel(r *Request) (Intent, error) {
 			changeAmt            btcutil.Amount
 		)
 
+		// Determine how much of our coins we can spend without dipping
+		// into the value reserved for fee bumping anchor channels.
+		var totalAmt btcutil.Amount
+		for _, coin := range coins {
+			totalAmt += btcutil.Amount(coin.Value)
+		}
+		spendableAmt := totalAmt - r.ReservedAmt
+
+		// If the caller picked the coins to use, we'll restrict coin
+		// selection to exactly those coins. Otherwise we'll pick them
+		// with the requested strategy.
+		strategy := r.CoinSelectionStrategy
+		if strategy == CoinSelectionDefault {
+			strategy = w.cfg.CoinSelectionStrategy
+		}
+		if len(r.Outpoints) > 0 {
+			coins, err = FilterCoins(coins, r.Outpoints)
+			if err != nil {
+				return err
+			}
+
+			strategy = CoinSelectionManual
+		}
+
 		// Perform coin selection over our available, unlocked unspent
 		// outputs in order to find enough coins to meet the funding
 		// amount requirements.
@@ -281,9 +314,30 @@ func (w *WalletAssembler) ProvisionChannel(r *Request) (Intent, error) {
 		// ensures that we won't deduct more that the specified balance
 		// from our wallet.
 		case r.SubtractFees:
+			// If spending the full amount would take the reserved
+			// value out of our wallet, we'll lower the amount. If
+			// we don't have the full amount at all, coin selection
+			// will fail below.
+			localAmt := r.LocalAmt
+			if r.ReservedAmt > 0 && localAmt > spendableAmt &&
+				localAmt <= totalAmt {
+
+				if spendableAmt <= 0 {
+					return ErrReservedValueInvalidated
+				}
+
+				log.Infof("Lowering funding amount from %v to "+
+					"%v to keep %v reserved for anchor "+
+					"channels", localAmt, spendableAmt,
+					r.ReservedAmt)
+
+				localAmt = spendableAmt
+			}
+
 			dustLimit := w.cfg.DustLimit
 			selectedCoins, localContributionAmt, changeAmt, err = CoinSelectSubtractFees(
-				r.FeeRate, r.LocalAmt, dustLimit, coins,
+				r.FeeRate, localAmt, dustLimit, coins,
+				strategy,
 			)
 			if err != nil {
 				return err
@@ -292,10 +346,18 @@ func (w *WalletAssembler) ProvisionChannel(r *Request) (Intent, error) {
 		// Otherwise do a normal coin selection where we target a given
 		// funding amount.
 		default:
+			// The fees are paid on top of the local amount, so the
+			// local amount alone must not exceed what we can
+			// spend.
+			if r.ReservedAmt > 0 && r.LocalAmt > spendableAmt {
+				return ErrReservedValueInvalidated
+			}
+
 			dustLimit := w.cfg.DustLimit
 			localContributionAmt = r.LocalAmt
 			selectedCoins, changeAmt, err = CoinSelect(
 				r.FeeRate, r.LocalAmt, dustLimit, coins,
+				strategy,
 			)
 			if err != nil {
 				return err
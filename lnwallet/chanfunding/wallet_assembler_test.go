@@ -0,0 +1,124 @@
+package chanfunding
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCoinSource is a CoinSource, CoinSelectionLocker and OutpointLocker that
+// returns a static set of coins.
+type mockCoinSource struct {
+	coins  []Coin
+	locked map[wire.OutPoint]struct{}
+}
+
+func (m *mockCoinSource) ListCoins(_, _ int32) ([]Coin, error) {
+	return m.coins, nil
+}
+
+func (m *mockCoinSource) CoinFromOutPoint(op wire.OutPoint) (*Coin, error) {
+	for _, coin := range m.coins {
+		if coin.OutPoint == op {
+			coin := coin
+			return &coin, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *mockCoinSource) WithCoinSelectLock(f func() error) error {
+	return f()
+}
+
+func (m *mockCoinSource) LockOutpoint(o wire.OutPoint) {
+	m.locked[o] = struct{}{}
+}
+
+func (m *mockCoinSource) UnlockOutpoint(o wire.OutPoint) {
+	delete(m.locked, o)
+}
+
+// TestWalletAssemblerReservedAmt tests that the wallet assembler leaves the
+// value reserved for fee bumping anchor channels in the wallet.
+func TestWalletAssemblerReservedAmt(t *testing.T) {
+	t.Parallel()
+
+	const (
+		feeRate     = chainfee.SatPerKWeight(1000)
+		dustLimit   = btcutil.Amount(573)
+		reservedAmt = btcutil.Amount(50000)
+	)
+
+	coinSource := &mockCoinSource{
+		coins: []Coin{{
+			TxOut: wire.TxOut{
+				PkScript: p2wkhScript,
+				Value:    1000000,
+			},
+		}},
+		locked: make(map[wire.OutPoint]struct{}),
+	}
+	assembler := NewWalletAssembler(WalletConfig{
+		CoinSource:       coinSource,
+		CoinSelectLocker: coinSource,
+		CoinLocker:       coinSource,
+		DustLimit:        dustLimit,
+	})
+
+	changeAddr := func() (btcutil.Address, error) {
+		return btcutil.NewAddressWitnessPubKeyHash(
+			p2wkhScript[2:], &params,
+		)
+	}
+
+	// Funding the whole wallet balance while subtracting the fees lowers
+	// the local amount, such that the reserved value is kept as change.
+	intent, err := assembler.ProvisionChannel(&Request{
+		LocalAmt:     1000000,
+		SubtractFees: true,
+		FeeRate:      feeRate,
+		ChangeAddr:   changeAddr,
+		ReservedAmt:  reservedAmt,
+	})
+	require.NoError(t, err)
+
+	fullIntent := intent.(*FullIntent)
+	require.Len(t, fullIntent.ChangeOutputs, 1)
+	require.EqualValues(
+		t, reservedAmt, fullIntent.ChangeOutputs[0].Value,
+	)
+	require.Equal(
+		t, 1000000-reservedAmt-fundingFee(feeRate, 1, true),
+		intent.LocalFundingAmt(),
+	)
+	intent.Cancel()
+
+	// Without a reserved value, the whole balance is used.
+	intent, err = assembler.ProvisionChannel(&Request{
+		LocalAmt:     1000000,
+		SubtractFees: true,
+		FeeRate:      feeRate,
+		ChangeAddr:   changeAddr,
+	})
+	require.NoError(t, err)
+	require.Equal(
+		t, 1000000-fundingFee(feeRate, 1, false),
+		intent.LocalFundingAmt(),
+	)
+	intent.Cancel()
+
+	// Funding an amount that would take the reserved value out of the
+	// wallet fails if the fees aren't subtracted.
+	_, err = assembler.ProvisionChannel(&Request{
+		LocalAmt:    1000000 - reservedAmt + 1,
+		FeeRate:     feeRate,
+		ChangeAddr:  changeAddr,
+		ReservedAmt: reservedAmt,
+	})
+	require.Equal(t, ErrReservedValueInvalidated, err)
+}
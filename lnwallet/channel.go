@@ -4968,6 +4968,40 @@ func (lc *LightningChannel) MayAddOutgoingHtlc() error {
 	return nil
 }
 
+// OutgoingHtlcUsage returns the number and total value of the outgoing htlcs
+// that would be present on the remote party's next commitment, taking into
+// account all of our pending updates. The returned values are the quantities
+// constrained by our channel config's MaxAcceptedHtlcs and MaxPendingAmount.
+func (lc *LightningChannel) OutgoingHtlcUsage() (uint16, lnwire.MilliSatoshi,
+	error) {
+
+	lc.RLock()
+	defer lc.RUnlock()
+
+	remoteACKedIndex := lc.localCommitChain.tail().theirMessageIndex
+	view := lc.fetchHTLCView(remoteACKedIndex, lc.localUpdateLog.logIndex)
+
+	_, _, _, filteredView, err := lc.computeView(view, true, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		numInFlight uint16
+		amtInFlight lnwire.MilliSatoshi
+	)
+	for _, entry := range filteredView.ourUpdates {
+		if entry.EntryType != Add {
+			continue
+		}
+
+		numInFlight++
+		amtInFlight += entry.Amount
+	}
+
+	return numInFlight, amtInFlight, nil
+}
+
 // htlcAddDescriptor returns a payment descriptor for the htlc and open key
 // provided to add to our local update log.
 func (lc *LightningChannel) htlcAddDescriptor(htlc *lnwire.UpdateAddHTLC,
@@ -7,6 +7,7 @@ import (
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwallet/chanfunding"
 )
 
 // Config is a struct which houses configuration parameters which modify the
@@ -56,4 +57,9 @@ type Config struct {
 	// NetParams is the set of parameters that tells the wallet which chain
 	// it will be operating on.
 	NetParams chaincfg.Params
+
+	// CoinSelectionStrategy is the strategy used to pick wallet coins
+	// for funding transactions and coin control sends if the request
+	// doesn't specify one.
+	CoinSelectionStrategy chanfunding.CoinSelectionStrategy
 }
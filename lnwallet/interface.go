@@ -78,6 +78,18 @@ type Utxo struct {
 	Confirmations int64
 	PkScript      []byte
 	wire.OutPoint
+
+	// Label is the label of the output, or of its address if the output
+	// has none.
+	Label string
+
+	// Tag is the tag of the output, or of its address if the output has
+	// none.
+	Tag string
+
+	// Frozen indicates that the output is excluded from coin selection
+	// until it is unfrozen.
+	Frozen bool
 }
 
 // TransactionDetail describes a transaction with either inputs which belong to
@@ -194,6 +206,11 @@ type WalletController interface {
 	// IsOurAddress checks if the passed address belongs to this wallet
 	IsOurAddress(a btcutil.Address) bool
 
+	// IsChangeAddress checks if the passed address belongs to this wallet
+	// and was derived from the internal branch of its account, which is
+	// used for change outputs.
+	IsChangeAddress(a btcutil.Address) bool
+
 	// ListAccounts retrieves all accounts belonging to the wallet by
 	// default. A name and key scope filter can be provided to filter
 	// through all of the wallet accounts and return only those matching.
@@ -264,6 +281,8 @@ type WalletController interface {
 	// outputs with at least 'minConfs'. The account parameter serves as
 	// a filter to retrieve the unspent outputs for a specific account.
 	// When empty, the unspent outputs of all wallet accounts are returned.
+	// Locked outputs are never returned. Frozen outputs are returned with
+	// their Frozen flag set, and must not be used for coin selection.
 	//
 	// NOTE: This method requires the global coin selection lock to be held.
 	ListUnspentWitness(minConfs, maxConfs int32,
@@ -334,6 +353,36 @@ type WalletController interface {
 	// is set. Labels must not be empty, and they are limited to 500 chars.
 	LabelTransaction(hash chainhash.Hash, label string, overwrite bool) error
 
+	// LabelAddress attaches a label and a tag to an address of the wallet.
+	// Outputs paying to the address inherit its label and tag unless they
+	// have their own. Empty values remove the label or the tag.
+	LabelAddress(addr btcutil.Address, label, tag string) error
+
+	// LabelOutput attaches a label and a tag to an output of the wallet.
+	// Empty values remove the label or the tag.
+	LabelOutput(op wire.OutPoint, label, tag string) error
+
+	// FreezeOutput excludes an unspent output of the wallet from coin
+	// selection until it is unfrozen. Unlike a lease, the freeze persists
+	// across restarts and doesn't expire.
+	//
+	// NOTE: This method requires the global coin selection lock to be
+	// held.
+	FreezeOutput(op wire.OutPoint) error
+
+	// UnfreezeOutput makes a frozen output available to coin selection
+	// again.
+	//
+	// NOTE: This method requires the global coin selection lock to be
+	// held.
+	UnfreezeOutput(op wire.OutPoint) error
+
+	// RemoveUnminedTx removes an unconfirmed transaction and all unconfirmed
+	// transactions spending its outputs from the wallet. This is used once
+	// a transaction was replaced by one paying a higher fee, so its outputs
+	// are no longer considered spendable.
+	RemoveUnminedTx(tx *wire.MsgTx) error
+
 	// FundPsbt creates a fully populated PSBT packet that contains enough
 	// inputs to fund the outputs specified in the passed in packet with the
 	// specified fee rate. If there is change left, a change output from the
@@ -342,7 +391,8 @@ type WalletController interface {
 	// is returned.
 	//
 	// NOTE: If the packet doesn't contain any inputs, coin selection is
-	// performed automatically. The account parameter must be non-empty as
+	// performed automatically, only selecting outputs with at least
+	// minConfs confirmations. The account parameter must be non-empty as
 	// it determines which set of coins are eligible for coin selection. If
 	// the packet does contain any inputs, it is assumed that full coin
 	// selection happened externally and no additional inputs are added. If
@@ -350,8 +400,8 @@ type WalletController interface {
 	// fee rate, an error is returned. No lock lease is acquired for any of
 	// the selected/validated inputs. It is in the caller's responsibility
 	// to lock the inputs before handing them out.
-	FundPsbt(packet *psbt.Packet, feeRate chainfee.SatPerKWeight,
-		account string) (int32, error)
+	FundPsbt(packet *psbt.Packet, minConfs int32,
+		feeRate chainfee.SatPerKWeight, account string) (int32, error)
 
 	// FinalizePsbt expects a partial transaction with all inputs and
 	// outputs fully declared and tries to sign all inputs that belong to
@@ -366,6 +416,15 @@ type WalletController interface {
 	// finalized successfully.
 	FinalizePsbt(packet *psbt.Packet, account string) error
 
+	// SignPsbt expects a partial transaction with all inputs and outputs
+	// fully declared and adds a partial signature to all inputs that have
+	// the BIP32 derivation path of one of the wallet's keys attached.
+	// Inputs without derivation information or with final witness data
+	// are skipped.
+	//
+	// NOTE: This method does NOT finalize the inputs it signed.
+	SignPsbt(packet *psbt.Packet) error
+
 	// SubscribeTransactions returns a TransactionSubscription client which
 	// is capable of receiving async notifications as new transactions
 	// related to the wallet are seen within the network, or found in
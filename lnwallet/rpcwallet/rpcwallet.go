@@ -0,0 +1,595 @@
+package rpcwallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	base "github.com/btcsuite/btcwallet/wallet"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lncfg"
+	"github.com/lightningnetwork/lnd/lnrpc/signrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/macaroons"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"gopkg.in/macaroon.v2"
+)
+
+var (
+	// ErrRemoteSigningPrivKeyNotSupported is returned when a private key
+	// is requested from a remote signing key ring, as the private keys
+	// never leave the remote signer.
+	ErrRemoteSigningPrivKeyNotSupported = errors.New("deriving private " +
+		"keys is not supported with a remote signer")
+)
+
+// RPCKeyRing is an implementation of the SecretKeyRing interface that uses a
+// local watch-only wallet for keeping track of addresses and transactions but
+// delegates any signing or ECDH operations to a remote node through RPC.
+type RPCKeyRing struct {
+	// WalletController is the embedded wallet controller of the watch-only
+	// base wallet. We need to overwrite/shadow certain of the implemented
+	// methods to make sure we can mirror them to the remote wallet.
+	lnwallet.WalletController
+
+	watchOnlyKeyRing keychain.SecretKeyRing
+	watchOnlyWallet  *base.Wallet
+
+	rpcTimeout time.Duration
+
+	signerClient signrpc.SignerClient
+	walletClient walletrpc.WalletKitClient
+}
+
+var _ keychain.SecretKeyRing = (*RPCKeyRing)(nil)
+var _ input.Signer = (*RPCKeyRing)(nil)
+var _ lnwallet.WalletController = (*RPCKeyRing)(nil)
+
+// NewRPCKeyRing creates a new remote signing secret key ring that uses the
+// given watch-only base wallet to keep track of addresses and transactions but
+// delegates any signing or ECDH operations to the remote signer through RPC.
+func NewRPCKeyRing(watchOnlyKeyRing keychain.SecretKeyRing,
+	watchOnlyWalletController lnwallet.WalletController,
+	watchOnlyWallet *base.Wallet,
+	remoteSigner *lncfg.RemoteSigner) (*RPCKeyRing, error) {
+
+	rpcConn, err := connectRPC(
+		remoteSigner.RPCHost, remoteSigner.TLSCertPath,
+		remoteSigner.MacaroonPath,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the remote "+
+			"signing node through RPC: %v", err)
+	}
+
+	return &RPCKeyRing{
+		WalletController: watchOnlyWalletController,
+		watchOnlyKeyRing: watchOnlyKeyRing,
+		watchOnlyWallet:  watchOnlyWallet,
+		rpcTimeout:       remoteSigner.Timeout,
+		signerClient:     signrpc.NewSignerClient(rpcConn),
+		walletClient:     walletrpc.NewWalletKitClient(rpcConn),
+	}, nil
+}
+
+// SendOutputs funds, signs, and broadcasts a Bitcoin transaction paying out to
+// the specified outputs. In the case the wallet has insufficient funds, or the
+// outputs are non-standard, a non-nil error will be returned.
+//
+// NOTE: This method requires the global coin selection lock to be held.
+//
+// NOTE: This is a part of the WalletController interface.
+func (r *RPCKeyRing) SendOutputs(outputs []*wire.TxOut,
+	feeRate chainfee.SatPerKWeight, minConfs int32,
+	label string) (*wire.MsgTx, error) {
+
+	// The watch-only wallet only creates the transaction, it can't sign
+	// any of the inputs. So we create it without broadcasting it first.
+	authoredTx, err := r.WalletController.CreateSimpleTx(
+		outputs, feeRate, minConfs, false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// All inputs of the transaction are spending outputs of our default
+	// account, so we can have the remote signer sign each of them.
+	tx := authoredTx.Tx
+	sigHashes := txscript.NewTxSigHashes(tx)
+	scripts := make([]*input.Script, len(tx.TxIn))
+	for idx := range tx.TxIn {
+		prevValue := authoredTx.PrevInputValues[idx]
+		signDesc := &input.SignDescriptor{
+			Output: &wire.TxOut{
+				Value:    int64(prevValue),
+				PkScript: authoredTx.PrevScripts[idx],
+			},
+			HashType:   txscript.SigHashAll,
+			SigHashes:  sigHashes,
+			InputIndex: idx,
+		}
+		scripts[idx], err = r.ComputeInputScript(tx, signDesc)
+		if err != nil {
+			return nil, fmt.Errorf("error signing input %d: %v",
+				idx, err)
+		}
+	}
+	for idx, script := range scripts {
+		tx.TxIn[idx].Witness = script.Witness
+		tx.TxIn[idx].SignatureScript = script.SigScript
+	}
+
+	if err := r.WalletController.PublishTransaction(tx, label); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// FinalizePsbt expects a partial transaction with all inputs and outputs fully
+// declared and tries to sign all inputs that belong to the specified account.
+// Lnd must be the last signer of the transaction. That means, if there are any
+// unsigned non-witness inputs or inputs without UTXO information attached or
+// inputs without witness data that do not belong to lnd's wallet, this method
+// will fail. If no error is returned, the PSBT is ready to be extracted and the
+// final TX within to be broadcast.
+//
+// NOTE: Only the default account can be signed for by the remote signer.
+//
+// NOTE: This is a part of the WalletController interface.
+func (r *RPCKeyRing) FinalizePsbt(packet *psbt.Packet, account string) error {
+	if account != lnwallet.DefaultAccountName {
+		return fmt.Errorf("remote signing is only supported for the "+
+			"default account, not for account %s", account)
+	}
+
+	err := psbt.VerifyInputOutputLen(packet, true, true)
+	if err != nil {
+		return err
+	}
+
+	tx := packet.UnsignedTx
+	sigHashes := txscript.NewTxSigHashes(tx)
+	for idx, txIn := range tx.TxIn {
+		in := &packet.Inputs[idx]
+
+		// Skip inputs that are already final.
+		if len(in.FinalScriptWitness) > 0 {
+			continue
+		}
+
+		// We can only sign this input if it's ours, so we try to map it
+		// to a coin we own. If we can't, then we'll continue as it
+		// isn't our input and a later step will fail.
+		utxo, err := r.WalletController.FetchInputInfo(
+			&txIn.PreviousOutPoint,
+		)
+		if err != nil {
+			continue
+		}
+
+		sigHashType := in.SighashType
+		if sigHashType == 0 {
+			sigHashType = txscript.SigHashAll
+		}
+
+		script, err := r.ComputeInputScript(tx, &input.SignDescriptor{
+			Output: &wire.TxOut{
+				Value:    int64(utxo.Value),
+				PkScript: utxo.PkScript,
+			},
+			HashType:   sigHashType,
+			SigHashes:  sigHashes,
+			InputIndex: idx,
+		})
+		if err != nil {
+			return fmt.Errorf("error computing input script for "+
+				"input %d: %v", idx, err)
+		}
+
+		var witnessBytes bytes.Buffer
+		err = psbt.WriteTxWitness(&witnessBytes, script.Witness)
+		if err != nil {
+			return fmt.Errorf("error serializing witness: %v", err)
+		}
+		in.FinalScriptWitness = witnessBytes.Bytes()
+		in.FinalScriptSig = script.SigScript
+	}
+
+	// Make sure the PSBT itself thinks it's finalized and ready to be
+	// broadcast.
+	if err := psbt.MaybeFinalizeAll(packet); err != nil {
+		return fmt.Errorf("error finalizing PSBT: %v", err)
+	}
+
+	return nil
+}
+
+// SignPsbt expects a partial transaction with all inputs and outputs fully
+// declared and lets the remote signer add a partial signature to all inputs
+// that have the BIP32 derivation path of one of its keys attached.
+//
+// NOTE: This is a part of the WalletController interface.
+func (r *RPCKeyRing) SignPsbt(packet *psbt.Packet) error {
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return fmt.Errorf("error serializing PSBT: %v", err)
+	}
+
+	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
+	defer cancel()
+
+	resp, err := r.walletClient.SignPsbt(ctxt, &walletrpc.SignPsbtRequest{
+		FundedPsbt: buf.Bytes(),
+	})
+	if err != nil {
+		return fmt.Errorf("error signing PSBT in remote signer "+
+			"instance: %v", err)
+	}
+
+	signedPacket, err := psbt.NewFromRawBytes(
+		bytes.NewReader(resp.SignedPsbt), false,
+	)
+	if err != nil {
+		return fmt.Errorf("error parsing signed PSBT: %v", err)
+	}
+
+	*packet = *signedPacket
+
+	return nil
+}
+
+// DeriveNextKey attempts to derive the *next* key within the key family
+// (account in BIP43) specified. This method should return the next external
+// child within this branch.
+//
+// NOTE: This method is part of the keychain.KeyRing interface.
+func (r *RPCKeyRing) DeriveNextKey(
+	keyFam keychain.KeyFamily) (keychain.KeyDescriptor, error) {
+
+	// The public keys of all lightning key families are known to the
+	// watch-only wallet, so there's no need to ask the remote signer.
+	return r.watchOnlyKeyRing.DeriveNextKey(keyFam)
+}
+
+// DeriveKey attempts to derive an arbitrary key specified by the passed
+// KeyLocator. This may be used in several recovery scenarios, or when manually
+// rotating something like our current default node key.
+//
+// NOTE: This method is part of the keychain.KeyRing interface.
+func (r *RPCKeyRing) DeriveKey(
+	keyLoc keychain.KeyLocator) (keychain.KeyDescriptor, error) {
+
+	return r.watchOnlyKeyRing.DeriveKey(keyLoc)
+}
+
+// ECDH performs a scalar multiplication (ECDH-like operation) between the
+// target key descriptor and remote public key. The output returned will be the
+// sha256 of the resulting shared point serialized in compressed format. If k
+// is our private key, and P is the public key, we perform the following
+// operation:
+//
+//  sx := k*P
+//  s := sha256(sx.SerializeCompressed())
+//
+// NOTE: This method is part of the keychain.ECDHRing interface.
+func (r *RPCKeyRing) ECDH(keyDesc keychain.KeyDescriptor,
+	pubKey *btcec.PublicKey) ([32]byte, error) {
+
+	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
+	defer cancel()
+
+	key := [32]byte{}
+	req := &signrpc.SharedKeyRequest{
+		EphemeralPubkey: pubKey.SerializeCompressed(),
+		KeyDesc: &signrpc.KeyDescriptor{
+			KeyLoc: &signrpc.KeyLocator{
+				KeyFamily: int32(keyDesc.Family),
+				KeyIndex:  int32(keyDesc.Index),
+			},
+		},
+	}
+
+	// The remote signer only accepts the raw public key together with an
+	// empty key index, in which case it uses the public key to find the
+	// correct index.
+	if keyDesc.Index == 0 && keyDesc.PubKey != nil {
+		req.KeyDesc.RawKeyBytes = keyDesc.PubKey.SerializeCompressed()
+	}
+
+	resp, err := r.signerClient.DeriveSharedKey(ctxt, req)
+	if err != nil {
+		return key, fmt.Errorf("error deriving shared key in remote "+
+			"signer instance: %v", err)
+	}
+
+	copy(key[:], resp.SharedKey)
+	return key, nil
+}
+
+// SignMessage attempts to sign a target message with the private key described
+// in the key locator. If the target private key is unable to be found, then an
+// error will be returned. The actual digest signed is the single or double
+// SHA-256 of the passed message.
+//
+// NOTE: This method is part of the keychain.MessageSignerRing interface.
+func (r *RPCKeyRing) SignMessage(keyLoc keychain.KeyLocator,
+	msg []byte, doubleHash bool) (*btcec.Signature, error) {
+
+	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
+	defer cancel()
+
+	resp, err := r.signerClient.SignMessage(ctxt, &signrpc.SignMessageReq{
+		Msg: msg,
+		KeyLoc: &signrpc.KeyLocator{
+			KeyFamily: int32(keyLoc.Family),
+			KeyIndex:  int32(keyLoc.Index),
+		},
+		DoubleHash: doubleHash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error signing message in remote "+
+			"signer instance: %v", err)
+	}
+
+	// The remote signer returns the signature in the fixed size wire
+	// format, so we need to convert it back.
+	wireSig, err := lnwire.NewSigFromRawSignature(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing raw signature: %v", err)
+	}
+	return wireSig.ToSignature()
+}
+
+// SignMessageCompact signs the given message, single or double SHA256 hashing
+// it first, with the private key described in the key locator and returns the
+// signature in the compact, public key recoverable format.
+//
+// NOTE: This method is part of the keychain.MessageSignerRing interface.
+func (r *RPCKeyRing) SignMessageCompact(keyLoc keychain.KeyLocator,
+	msg []byte, doubleHash bool) ([]byte, error) {
+
+	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
+	defer cancel()
+
+	resp, err := r.signerClient.SignMessage(ctxt, &signrpc.SignMessageReq{
+		Msg: msg,
+		KeyLoc: &signrpc.KeyLocator{
+			KeyFamily: int32(keyLoc.Family),
+			KeyIndex:  int32(keyLoc.Index),
+		},
+		DoubleHash: doubleHash,
+		CompactSig: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error signing message in remote "+
+			"signer instance: %v", err)
+	}
+
+	return resp.Signature, nil
+}
+
+// DerivePrivKey attempts to derive the private key that corresponds to the
+// passed key descriptor. The private keys never leave the remote signer, so
+// this always returns ErrRemoteSigningPrivKeyNotSupported.
+//
+// NOTE: This method is part of the keychain.SecretKeyRing interface.
+func (r *RPCKeyRing) DerivePrivKey(_ keychain.KeyDescriptor) (*btcec.PrivateKey,
+	error) {
+
+	return nil, ErrRemoteSigningPrivKeyNotSupported
+}
+
+// SignOutputRaw generates a signature for the passed transaction according to
+// the data within the passed SignDescriptor.
+//
+// NOTE: This method is part of the input.Signer interface.
+func (r *RPCKeyRing) SignOutputRaw(tx *wire.MsgTx,
+	signDesc *input.SignDescriptor) (input.Signature, error) {
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return nil, err
+	}
+
+	keyDesc := &signrpc.KeyDescriptor{
+		KeyLoc: &signrpc.KeyLocator{
+			KeyFamily: int32(signDesc.KeyDesc.Family),
+			KeyIndex:  int32(signDesc.KeyDesc.Index),
+		},
+	}
+	if signDesc.KeyDesc.PubKey != nil {
+		pubKey := signDesc.KeyDesc.PubKey
+		keyDesc.RawKeyBytes = pubKey.SerializeCompressed()
+	}
+
+	rpcSignDesc := &signrpc.SignDescriptor{
+		KeyDesc:       keyDesc,
+		SingleTweak:   signDesc.SingleTweak,
+		WitnessScript: signDesc.WitnessScript,
+		Output: &signrpc.TxOut{
+			Value:    signDesc.Output.Value,
+			PkScript: signDesc.Output.PkScript,
+		},
+		Sighash:    uint32(signDesc.HashType),
+		InputIndex: int32(signDesc.InputIndex),
+	}
+	if signDesc.DoubleTweak != nil {
+		rpcSignDesc.DoubleTweak = signDesc.DoubleTweak.Serialize()
+	}
+
+	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
+	defer cancel()
+
+	resp, err := r.signerClient.SignOutputRaw(ctxt, &signrpc.SignReq{
+		RawTxBytes: buf.Bytes(),
+		SignDescs:  []*signrpc.SignDescriptor{rpcSignDesc},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error signing output in remote "+
+			"signer instance: %v", err)
+	}
+	if len(resp.RawSigs) != 1 {
+		return nil, fmt.Errorf("unexpected number of signatures %d",
+			len(resp.RawSigs))
+	}
+
+	return btcec.ParseDERSignature(resp.RawSigs[0], btcec.S256())
+}
+
+// ComputeInputScript generates a complete InputScript for the passed
+// transaction with the signature as defined within the passed SignDescriptor.
+// This method should be capable of generating the proper input script for both
+// regular p2wkh output and p2wkh outputs nested within a regular p2sh output.
+//
+// NOTE: The output being spent must belong to the default account of the
+// wallet, as the signature is created by the remote signer through a PSBT
+// with the key's BIP32 derivation path attached.
+//
+// NOTE: This method is part of the input.Signer interface.
+func (r *RPCKeyRing) ComputeInputScript(tx *wire.MsgTx,
+	signDesc *input.SignDescriptor) (*input.Script, error) {
+
+	// Tweaked keys are only ever used for outputs that are spent through
+	// SignOutputRaw, so we don't support them here.
+	if signDesc.SingleTweak != nil || signDesc.DoubleTweak != nil {
+		return nil, fmt.Errorf("tweaks are not supported when " +
+			"computing an input script with a remote signer")
+	}
+
+	// We need the BIP32 derivation path of the key that's being spent, so
+	// the remote signer knows what key to sign with.
+	outPoint := tx.TxIn[signDesc.InputIndex].PreviousOutPoint
+	_, txOut, derivation, _, err := r.watchOnlyWallet.FetchInputInfo(
+		&outPoint,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching input info for %v: %v",
+			outPoint, err)
+	}
+	if derivation == nil {
+		return nil, fmt.Errorf("no derivation info for %v", outPoint)
+	}
+	if !bytes.Equal(txOut.PkScript, signDesc.Output.PkScript) {
+		return nil, fmt.Errorf("output script of %v doesn't match "+
+			"sign descriptor", outPoint)
+	}
+
+	// A nested p2wkh output needs the witness program as the redeem
+	// script, both for signing and for the final signature script.
+	var (
+		redeemScript []byte
+		sigScript    []byte
+	)
+	if txscript.IsPayToScriptHash(signDesc.Output.PkScript) {
+		pubKeyHash := btcutil.Hash160(derivation.PubKey)
+		redeemScript, err = txscript.NewScriptBuilder().AddOp(
+			txscript.OP_0,
+		).AddData(pubKeyHash).Script()
+		if err != nil {
+			return nil, err
+		}
+
+		sigScript, err = txscript.NewScriptBuilder().AddData(
+			redeemScript,
+		).Script()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create a PSBT that only contains the information of the input we
+	// want the remote signer to sign. Any signatures already present in
+	// the transaction need to be removed as the PSBT requires an unsigned
+	// transaction.
+	unsignedTx := tx.Copy()
+	for _, txIn := range unsignedTx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	packet, err := psbt.NewFromUnsignedTx(unsignedTx)
+	if err != nil {
+		return nil, fmt.Errorf("error creating PSBT: %v", err)
+	}
+	packet.Inputs[signDesc.InputIndex] = psbt.PInput{
+		WitnessUtxo:     signDesc.Output,
+		RedeemScript:    redeemScript,
+		SighashType:     signDesc.HashType,
+		Bip32Derivation: []*psbt.Bip32Derivation{derivation},
+	}
+
+	if err := r.SignPsbt(packet); err != nil {
+		return nil, err
+	}
+
+	signedInput := packet.Inputs[signDesc.InputIndex]
+	if len(signedInput.PartialSigs) != 1 {
+		return nil, fmt.Errorf("remote signer returned %d signatures "+
+			"for input %d", len(signedInput.PartialSigs),
+			signDesc.InputIndex)
+	}
+	sig := signedInput.PartialSigs[0]
+
+	return &input.Script{
+		Witness:   wire.TxWitness{sig.Signature, sig.PubKey},
+		SigScript: sigScript,
+	}, nil
+}
+
+// connectRPC tries to establish an RPC connection to the given host:port with
+// the supplied certificate and macaroon.
+func connectRPC(hostPort, tlsCertPath, macaroonPath string) (*grpc.ClientConn,
+	error) {
+
+	certBytes, err := ioutil.ReadFile(tlsCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading TLS cert file %v: %v",
+			tlsCertPath, err)
+	}
+
+	cp := x509.NewCertPool()
+	if !cp.AppendCertsFromPEM(certBytes) {
+		return nil, fmt.Errorf("credentials: failed to append " +
+			"certificate")
+	}
+
+	macBytes, err := ioutil.ReadFile(macaroonPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading macaroon file %v: %v",
+			macaroonPath, err)
+	}
+	mac := &macaroon.Macaroon{}
+	if err := mac.UnmarshalBinary(macBytes); err != nil {
+		return nil, fmt.Errorf("error decoding macaroon: %v", err)
+	}
+
+	macCred := macaroons.NewMacaroonCredential(mac)
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(
+			credentials.NewClientTLSFromCert(cp, ""),
+		),
+		grpc.WithPerRPCCredentials(macCred),
+	}
+
+	conn, err := grpc.Dial(hostPort, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to RPC server: %v",
+			err)
+	}
+
+	return conn, nil
+}
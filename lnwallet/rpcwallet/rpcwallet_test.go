@@ -0,0 +1,398 @@
+package rpcwallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lnrpc/signrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+var (
+	testPrivKey, testPubKey = btcec.PrivKeyFromBytes(
+		btcec.S256(), bytes.Repeat([]byte{0x11}, 32),
+	)
+
+	_, testRemotePubKey = btcec.PrivKeyFromBytes(
+		btcec.S256(), bytes.Repeat([]byte{0x22}, 32),
+	)
+
+	testKeyLoc = keychain.KeyLocator{
+		Family: keychain.KeyFamilyNodeKey,
+		Index:  3,
+	}
+
+	errRemote = errors.New("remote signer unavailable")
+)
+
+// mockSignerClient is a signer client that records the requests it receives
+// and answers them with the test private key, like a remote signer would.
+type mockSignerClient struct {
+	signrpc.SignerClient
+
+	err error
+
+	signReq       *signrpc.SignReq
+	signMsgReq    *signrpc.SignMessageReq
+	sharedKeyReq  *signrpc.SharedKeyRequest
+	numSignatures int
+}
+
+func (m *mockSignerClient) SignOutputRaw(_ context.Context,
+	in *signrpc.SignReq, _ ...grpc.CallOption) (*signrpc.SignResp, error) {
+
+	m.signReq = in
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	sig, err := testPrivKey.Sign(chainhash.DoubleHashB(in.RawTxBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &signrpc.SignResp{}
+	for i := 0; i < m.numSignatures; i++ {
+		resp.RawSigs = append(resp.RawSigs, sig.Serialize())
+	}
+
+	return resp, nil
+}
+
+func (m *mockSignerClient) SignMessage(_ context.Context,
+	in *signrpc.SignMessageReq, _ ...grpc.CallOption) (
+	*signrpc.SignMessageResp, error) {
+
+	m.signMsgReq = in
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	digest := chainhash.HashB(in.Msg)
+	if in.DoubleHash {
+		digest = chainhash.DoubleHashB(in.Msg)
+	}
+
+	if in.CompactSig {
+		sig, err := btcec.SignCompact(
+			btcec.S256(), testPrivKey, digest, true,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return &signrpc.SignMessageResp{Signature: sig}, nil
+	}
+
+	sig, err := testPrivKey.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signrpc.SignMessageResp{Signature: sig.Serialize()}, nil
+}
+
+func (m *mockSignerClient) DeriveSharedKey(_ context.Context,
+	in *signrpc.SharedKeyRequest, _ ...grpc.CallOption) (
+	*signrpc.SharedKeyResponse, error) {
+
+	m.sharedKeyReq = in
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	ecdh := keychain.PrivKeyECDH{PrivKey: testPrivKey}
+	key, err := ecdh.ECDH(testRemotePubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signrpc.SharedKeyResponse{SharedKey: key[:]}, nil
+}
+
+// mockWalletClient is a wallet kit client that adds a partial signature of
+// the test private key to the first input of the PSBTs it signs.
+type mockWalletClient struct {
+	walletrpc.WalletKitClient
+
+	err error
+}
+
+func (m *mockWalletClient) SignPsbt(_ context.Context,
+	in *walletrpc.SignPsbtRequest, _ ...grpc.CallOption) (
+	*walletrpc.SignPsbtResponse, error) {
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	packet, err := psbt.NewFromRawBytes(
+		bytes.NewReader(in.FundedPsbt), false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := testPrivKey.Sign(chainhash.DoubleHashB(in.FundedPsbt))
+	if err != nil {
+		return nil, err
+	}
+
+	packet.Inputs[0].PartialSigs = append(
+		packet.Inputs[0].PartialSigs, &psbt.PartialSig{
+			PubKey: testPubKey.SerializeCompressed(),
+			Signature: append(
+				sig.Serialize(), byte(txscript.SigHashAll),
+			),
+		},
+	)
+
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return nil, err
+	}
+
+	return &walletrpc.SignPsbtResponse{SignedPsbt: buf.Bytes()}, nil
+}
+
+// newTestKeyRing creates a remote signing key ring that uses the given mock
+// clients.
+func newTestKeyRing(signer *mockSignerClient,
+	wallet *mockWalletClient) *RPCKeyRing {
+
+	return &RPCKeyRing{
+		rpcTimeout:   time.Second,
+		signerClient: signer,
+		walletClient: wallet,
+	}
+}
+
+// newTestTx creates a transaction with a single input and output.
+func newTestTx() *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 1},
+	})
+	tx.AddTxOut(&wire.TxOut{
+		Value:    100000,
+		PkScript: []byte{txscript.OP_0, 0x14},
+	})
+
+	return tx
+}
+
+// TestSignMessage tests that messages are signed by the remote signer and
+// that its signatures are converted back.
+func TestSignMessage(t *testing.T) {
+	signer := &mockSignerClient{}
+	keyRing := newTestKeyRing(signer, nil)
+
+	msg := []byte("message")
+	sig, err := keyRing.SignMessage(testKeyLoc, msg, true)
+	require.NoError(t, err)
+	require.True(t, sig.Verify(chainhash.DoubleHashB(msg), testPubKey))
+
+	require.Equal(t, msg, signer.signMsgReq.Msg)
+	require.True(t, signer.signMsgReq.DoubleHash)
+	require.False(t, signer.signMsgReq.CompactSig)
+	require.EqualValues(
+		t, testKeyLoc.Family, signer.signMsgReq.KeyLoc.KeyFamily,
+	)
+	require.EqualValues(
+		t, testKeyLoc.Index, signer.signMsgReq.KeyLoc.KeyIndex,
+	)
+
+	// Compact signatures are passed through as they are, so the public
+	// key can be recovered from them.
+	compactSig, err := keyRing.SignMessageCompact(testKeyLoc, msg, false)
+	require.NoError(t, err)
+	require.True(t, signer.signMsgReq.CompactSig)
+	require.False(t, signer.signMsgReq.DoubleHash)
+
+	pubKey, _, err := btcec.RecoverCompact(
+		btcec.S256(), compactSig, chainhash.HashB(msg),
+	)
+	require.NoError(t, err)
+	require.True(t, pubKey.IsEqual(testPubKey))
+
+	// Errors of the remote signer are returned.
+	signer.err = errRemote
+	_, err = keyRing.SignMessage(testKeyLoc, msg, true)
+	require.Error(t, err)
+	_, err = keyRing.SignMessageCompact(testKeyLoc, msg, true)
+	require.Error(t, err)
+}
+
+// TestECDH tests that shared keys are derived by the remote signer, which
+// identifies keys with an empty index by their public key.
+func TestECDH(t *testing.T) {
+	signer := &mockSignerClient{}
+	keyRing := newTestKeyRing(signer, nil)
+
+	ecdh := keychain.PrivKeyECDH{PrivKey: testPrivKey}
+	expectedKey, err := ecdh.ECDH(testRemotePubKey)
+	require.NoError(t, err)
+
+	// A key with an index is identified by its locator only.
+	key, err := keyRing.ECDH(keychain.KeyDescriptor{
+		KeyLocator: testKeyLoc,
+		PubKey:     testPubKey,
+	}, testRemotePubKey)
+	require.NoError(t, err)
+	require.Equal(t, expectedKey, key)
+
+	req := signer.sharedKeyReq
+	require.Equal(
+		t, testRemotePubKey.SerializeCompressed(), req.EphemeralPubkey,
+	)
+	require.EqualValues(t, testKeyLoc.Family, req.KeyDesc.KeyLoc.KeyFamily)
+	require.EqualValues(t, testKeyLoc.Index, req.KeyDesc.KeyLoc.KeyIndex)
+	require.Empty(t, req.KeyDesc.RawKeyBytes)
+
+	// A key with an empty index is identified by its public key.
+	_, err = keyRing.ECDH(keychain.KeyDescriptor{
+		KeyLocator: keychain.KeyLocator{
+			Family: keychain.KeyFamilyNodeKey,
+		},
+		PubKey: testPubKey,
+	}, testRemotePubKey)
+	require.NoError(t, err)
+	require.Equal(
+		t, testPubKey.SerializeCompressed(),
+		signer.sharedKeyReq.KeyDesc.RawKeyBytes,
+	)
+
+	signer.err = errRemote
+	_, err = keyRing.ECDH(keychain.KeyDescriptor{
+		KeyLocator: testKeyLoc,
+	}, testRemotePubKey)
+	require.Error(t, err)
+}
+
+// TestSignOutputRaw tests that the sign descriptor is passed on to the remote
+// signer completely, and that exactly one signature is expected back.
+func TestSignOutputRaw(t *testing.T) {
+	signer := &mockSignerClient{numSignatures: 1}
+	keyRing := newTestKeyRing(signer, nil)
+
+	tx := newTestTx()
+	signDesc := &input.SignDescriptor{
+		KeyDesc: keychain.KeyDescriptor{
+			KeyLocator: testKeyLoc,
+			PubKey:     testPubKey,
+		},
+		SingleTweak:   bytes.Repeat([]byte{0x33}, 32),
+		DoubleTweak:   testPrivKey,
+		WitnessScript: []byte{txscript.OP_TRUE},
+		Output: &wire.TxOut{
+			Value:    200000,
+			PkScript: []byte{txscript.OP_0, 0x20},
+		},
+		HashType:   txscript.SigHashSingle,
+		InputIndex: 0,
+	}
+
+	sig, err := keyRing.SignOutputRaw(tx, signDesc)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, tx.Serialize(&buf))
+	require.True(
+		t, sig.Verify(chainhash.DoubleHashB(buf.Bytes()), testPubKey),
+	)
+
+	req := signer.signReq
+	require.Equal(t, buf.Bytes(), req.RawTxBytes)
+	require.Len(t, req.SignDescs, 1)
+
+	rpcSignDesc := req.SignDescs[0]
+	require.EqualValues(
+		t, testKeyLoc.Family, rpcSignDesc.KeyDesc.KeyLoc.KeyFamily,
+	)
+	require.EqualValues(
+		t, testKeyLoc.Index, rpcSignDesc.KeyDesc.KeyLoc.KeyIndex,
+	)
+	require.Equal(
+		t, testPubKey.SerializeCompressed(),
+		rpcSignDesc.KeyDesc.RawKeyBytes,
+	)
+	require.Equal(t, signDesc.SingleTweak, rpcSignDesc.SingleTweak)
+	require.Equal(t, testPrivKey.Serialize(), rpcSignDesc.DoubleTweak)
+	require.Equal(t, signDesc.WitnessScript, rpcSignDesc.WitnessScript)
+	require.Equal(t, signDesc.Output.Value, rpcSignDesc.Output.Value)
+	require.Equal(
+		t, signDesc.Output.PkScript, rpcSignDesc.Output.PkScript,
+	)
+	require.EqualValues(t, txscript.SigHashSingle, rpcSignDesc.Sighash)
+	require.EqualValues(t, 0, rpcSignDesc.InputIndex)
+
+	// An unexpected number of signatures is rejected.
+	signer.numSignatures = 2
+	_, err = keyRing.SignOutputRaw(tx, signDesc)
+	require.Error(t, err)
+
+	signer.err = errRemote
+	_, err = keyRing.SignOutputRaw(tx, signDesc)
+	require.Error(t, err)
+}
+
+// TestSignPsbt tests that PSBTs are signed by the remote signer and replaced
+// by the signed version.
+func TestSignPsbt(t *testing.T) {
+	wallet := &mockWalletClient{}
+	keyRing := newTestKeyRing(nil, wallet)
+
+	packet, err := psbt.NewFromUnsignedTx(newTestTx())
+	require.NoError(t, err)
+
+	require.NoError(t, keyRing.SignPsbt(packet))
+	require.Len(t, packet.Inputs[0].PartialSigs, 1)
+	require.Equal(
+		t, testPubKey.SerializeCompressed(),
+		packet.Inputs[0].PartialSigs[0].PubKey,
+	)
+
+	wallet.err = errRemote
+	require.Error(t, keyRing.SignPsbt(packet))
+}
+
+// TestUnsupportedOperations tests that the operations that can't be carried
+// out with a remote signer are rejected without contacting it.
+func TestUnsupportedOperations(t *testing.T) {
+	keyRing := newTestKeyRing(nil, nil)
+
+	// Private keys never leave the remote signer.
+	_, err := keyRing.DerivePrivKey(keychain.KeyDescriptor{
+		KeyLocator: testKeyLoc,
+	})
+	require.Equal(t, ErrRemoteSigningPrivKeyNotSupported, err)
+
+	// Input scripts are only computed for outputs of the wallet, which
+	// never use tweaked keys.
+	_, err = keyRing.ComputeInputScript(newTestTx(), &input.SignDescriptor{
+		SingleTweak: bytes.Repeat([]byte{0x33}, 32),
+	})
+	require.Error(t, err)
+
+	_, err = keyRing.ComputeInputScript(newTestTx(), &input.SignDescriptor{
+		DoubleTweak: testPrivKey,
+	})
+	require.Error(t, err)
+
+	// Only the default account can be signed for.
+	packet, err := psbt.NewFromUnsignedTx(newTestTx())
+	require.NoError(t, err)
+	require.Error(t, keyRing.FinalizePsbt(packet, "imported"))
+}
@@ -35,18 +35,26 @@ const (
 	// outside word.
 	msgBufferSize = 100
 
-	// anchorChanReservedValue is the amount we'll keep around in the
-	// wallet in case we have to fee bump anchor channels on force close.
-	// TODO(halseth): update constant to target a specific commit size at
-	// set fee rate.
+	// anchorChanReservedValue is the minimum amount we'll keep around in
+	// the wallet per anchor channel in case we have to fee bump anchor
+	// channels on force close.
 	anchorChanReservedValue = btcutil.Amount(10_000)
 
-	// maxAnchorChanReservedValue is the maximum value we'll reserve for
-	// anchor channel fee bumping. We cap it at 10 times the per-channel
-	// amount such that nodes with a high number of channels don't have to
-	// keep around a very large amount for the unlikely scenario that they
-	// all close at the same time.
-	maxAnchorChanReservedValue = 10 * anchorChanReservedValue
+	// maxReservedAnchorChans is the maximum number of anchor channels
+	// we'll reserve value for. We cap it such that nodes with a high
+	// number of channels don't have to keep around a very large amount for
+	// the unlikely scenario that they all close at the same time.
+	maxReservedAnchorChans = 10
+
+	// anchorReserveConfTarget is the confirmation target of the fee
+	// estimate that the reserved value is sized with. As force closes with
+	// HTLCs need to confirm before a deadline, we size the reserve for a
+	// fast confirmation.
+	anchorReserveConfTarget = 2
+
+	// anchorReserveHtlcs is the number of HTLCs on the commitment
+	// transaction that the reserved value is sized for.
+	anchorReserveHtlcs = 10
 )
 
 var (
@@ -58,8 +66,7 @@ var (
 	// ErrReservedValueInvalidated is returned if we try to publish a
 	// transaction that would take the walletbalance below what we require
 	// to keep around to fee bump our open anchor channels.
-	ErrReservedValueInvalidated = errors.New("reserved wallet balance " +
-		"invalidated")
+	ErrReservedValueInvalidated = chanfunding.ErrReservedValueInvalidated
 )
 
 // PsbtFundingRequired is a type that implements the error interface and
@@ -152,6 +159,16 @@ type InitFundingReserveMsg struct {
 	// used.
 	ChanFunder chanfunding.Assembler
 
+	// Outpoints is an optional list of wallet outputs that must fund the
+	// channel. If set, exactly these outputs are spent by the default
+	// chanfunding.WalletAssembler.
+	Outpoints []wire.OutPoint
+
+	// CoinSelectionStrategy is the strategy used by the default
+	// chanfunding.WalletAssembler to pick coins. If not set, the wallet's
+	// configured strategy is used.
+	CoinSelectionStrategy chanfunding.CoinSelectionStrategy
+
 	// err is a channel in which all errors will be sent across. Will be
 	// nil if this initial set is successful.
 	//
@@ -422,15 +439,30 @@ func (l *LightningWallet) ConfirmedBalance(confs int32,
 // confirmations an output needs in order to be returned by this method. Passing
 // -1 as 'minConfs' indicates that even unconfirmed outputs should be returned.
 // Using MaxInt32 as 'maxConfs' implies returning all outputs with at least
-// 'minConfs'.
+// 'minConfs'. Frozen outputs are skipped, so the returned outputs can be used
+// for coin selection.
 //
 // NOTE: This method requires the global coin selection lock to be held.
 func (l *LightningWallet) ListUnspentWitnessFromDefaultAccount(
 	minConfs, maxConfs int32) ([]*Utxo, error) {
 
-	return l.WalletController.ListUnspentWitness(
+	utxos, err := l.WalletController.ListUnspentWitness(
 		minConfs, maxConfs, DefaultAccountName,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	spendable := make([]*Utxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		if utxo.Frozen {
+			continue
+		}
+
+		spendable = append(spendable, utxo)
+	}
+
+	return spendable, nil
 }
 
 // LockedOutpoints returns a list of all currently locked outpoint.
@@ -694,6 +726,8 @@ func (l *LightningWallet) handleFundingReserveRequest(req *InitFundingReserveMsg
 			CoinLocker:       l,
 			Signer:           l.Cfg.Signer,
 			DustLimit:        DefaultDustLimit(),
+
+			CoinSelectionStrategy: l.Cfg.CoinSelectionStrategy,
 		}
 		req.ChanFunder = chanfunding.NewWalletAssembler(cfg)
 	}
@@ -715,10 +749,28 @@ func (l *LightningWallet) handleFundingReserveRequest(req *InitFundingReserveMsg
 	fundingIntent, ok := l.fundingIntents[req.PendingChanID]
 	l.intentMtx.Unlock()
 
+	isPublic := req.Flags&lnwire.FFAnnounceChannel != 0
+	hasAnchors := req.CommitType == CommitmentTypeAnchorsZeroFeeHtlcTx
+
 	// Otherwise, this is a normal funding flow, so we'll use the chan
 	// funder in the attached request to provision the inputs/outputs
 	// that'll ultimately be used to construct the funding transaction.
 	if !ok {
+		// If we're contributing funds to an advertised channel, coin
+		// selection needs to leave the value we reserve for anchor
+		// channel fee bumping in the wallet.
+		var reservedAmt btcutil.Amount
+		if isPublic && req.LocalFundingAmt != 0 {
+			numAnchors, err := l.numAnchorChansAfterOpen(hasAnchors)
+			if err != nil {
+				req.err <- err
+				req.resp <- nil
+				return
+			}
+
+			reservedAmt = l.RequiredReservedValue(numAnchors)
+		}
+
 		// Coin selection is done on the basis of sat/kw, so we'll use
 		// the fee rate passed in to perform coin selection.
 		var err error
@@ -728,11 +780,14 @@ func (l *LightningWallet) handleFundingReserveRequest(req *InitFundingReserveMsg
 			MinConfs:     req.MinConfs,
 			SubtractFees: req.SubtractFees,
 			FeeRate:      req.FundingFeePerKw,
+			ReservedAmt:  reservedAmt,
+			Outpoints:    req.Outpoints,
 			ChangeAddr: func() (btcutil.Address, error) {
 				return l.NewAddress(
 					WitnessPubKey, true, DefaultAccountName,
 				)
 			},
+			CoinSelectionStrategy: req.CoinSelectionStrategy,
 		}
 		fundingIntent, err = req.ChanFunder.ProvisionChannel(
 			fundingReq,
@@ -793,8 +848,6 @@ func (l *LightningWallet) handleFundingReserveRequest(req *InitFundingReserveMsg
 	// intent. Note that for the PSBT intent type we don't yet have the
 	// funding tx ready, so this will always pass.  We'll do another check
 	// when the PSBT has been verified.
-	isPublic := req.Flags&lnwire.FFAnnounceChannel != 0
-	hasAnchors := req.CommitType == CommitmentTypeAnchorsZeroFeeHtlcTx
 	err = l.enforceNewReservedValue(fundingIntent, isPublic, hasAnchors)
 	if err != nil {
 		fundingIntent.Cancel()
@@ -865,16 +918,11 @@ func (l *LightningWallet) enforceNewReservedValue(fundingIntent chanfunding.Inte
 		return nil
 	}
 
-	numAnchors, err := l.currentNumAnchorChans()
+	numAnchors, err := l.numAnchorChansAfterOpen(hasAnchors)
 	if err != nil {
 		return err
 	}
 
-	// Add the to-be-opened channel.
-	if hasAnchors {
-		numAnchors++
-	}
-
 	return l.WithCoinSelectLock(func() error {
 		_, err := l.CheckReservedValue(
 			fundingIntent.Inputs(), fundingIntent.Outputs(),
@@ -884,6 +932,24 @@ func (l *LightningWallet) enforceNewReservedValue(fundingIntent chanfunding.Inte
 	})
 }
 
+// numAnchorChansAfterOpen returns the number of non-private anchor channels
+// the wallet should be ready to fee bump once a new channel is opened.
+func (l *LightningWallet) numAnchorChansAfterOpen(hasAnchors bool) (int,
+	error) {
+
+	numAnchors, err := l.currentNumAnchorChans()
+	if err != nil {
+		return 0, err
+	}
+
+	// Add the to-be-opened channel.
+	if hasAnchors {
+		numAnchors++
+	}
+
+	return numAnchors, nil
+}
+
 // currentNumAnchorChans returns the current number of non-private anchor
 // channels the wallet should be ready to fee bump if needed.
 func (l *LightningWallet) currentNumAnchorChans() (int, error) {
@@ -1012,10 +1078,7 @@ func (l *LightningWallet) CheckReservedValue(in []wire.OutPoint,
 	}
 
 	// We reserve a given amount for each anchor channel.
-	reserved := btcutil.Amount(numAnchorChans) * anchorChanReservedValue
-	if reserved > maxAnchorChanReservedValue {
-		reserved = maxAnchorChanReservedValue
-	}
+	reserved := l.RequiredReservedValue(numAnchorChans)
 
 	if walletBalance < reserved {
 		walletLog.Debugf("Reserved value=%v above final "+
@@ -1027,6 +1090,108 @@ func (l *LightningWallet) CheckReservedValue(in []wire.OutPoint,
 	return reserved, nil
 }
 
+// RequiredReservedValue returns the value we keep around in the wallet to fee
+// bump the given number of anchor channels. For each channel, we reserve the
+// fee of the package of its commitment transaction and the transaction
+// sweeping its anchor at the current fee estimate for a fast confirmation,
+// but at least anchorChanReservedValue. We reserve for at most
+// maxReservedAnchorChans channels.
+func (l *LightningWallet) RequiredReservedValue(
+	numAnchorChans int) btcutil.Amount {
+
+	if numAnchorChans > maxReservedAnchorChans {
+		numAnchorChans = maxReservedAnchorChans
+	}
+
+	perChanReserve := anchorChanReservedValue
+	feeRate, err := l.Cfg.FeeEstimator.EstimateFeePerKW(
+		anchorReserveConfTarget,
+	)
+	if err != nil {
+		walletLog.Warnf("Unable to estimate fee rate for anchor "+
+			"reserve, using minimum reserve: %v", err)
+	} else {
+		fee := feeRate.FeeForWeight(anchorReserveWeight())
+		if fee > perChanReserve {
+			perChanReserve = fee
+		}
+	}
+
+	return btcutil.Amount(numAnchorChans) * perChanReserve
+}
+
+// anchorReserveWeight returns the weight of the package of an anchor
+// commitment transaction with anchorReserveHtlcs HTLCs and a transaction that
+// sweeps its anchor together with a wallet input paying for the fees.
+func anchorReserveWeight() int64 {
+	var weightEstimate input.TxWeightEstimator
+	weightEstimate.AddWitnessInput(input.AnchorWitnessSize)
+	weightEstimate.AddP2WKHInput()
+	weightEstimate.AddP2WKHOutput()
+
+	commitWeight := input.AnchorCommitWeight +
+		anchorReserveHtlcs*input.HTLCWeight
+
+	return int64(commitWeight + weightEstimate.Weight())
+}
+
+// AnchorReserve describes the value that is reserved in the wallet for fee
+// bumping anchor channels, and the wallet balance that is available for it.
+type AnchorReserve struct {
+	// NumAnchorChans is the number of anchor channels that the wallet
+	// should be ready to fee bump.
+	NumAnchorChans int
+
+	// Reserved is the value that is reserved for fee bumping these
+	// channels.
+	Reserved btcutil.Amount
+
+	// Balance is the balance of the wallet's default account, including
+	// unconfirmed outputs.
+	Balance btcutil.Amount
+}
+
+// Shortfall returns by how much the wallet balance falls short of the reserved
+// value. It is zero if the balance covers the reserved value.
+func (r *AnchorReserve) Shortfall() btcutil.Amount {
+	if r.Balance >= r.Reserved {
+		return 0
+	}
+
+	return r.Reserved - r.Balance
+}
+
+// CheckAnchorReserve returns the value that is reserved in the wallet for fee
+// bumping the currently open anchor channels, together with the wallet balance
+// that it is compared to when publishing transactions.
+func (l *LightningWallet) CheckAnchorReserve() (*AnchorReserve, error) {
+	numAnchors, err := l.currentNumAnchorChans()
+	if err != nil {
+		return nil, err
+	}
+
+	l.coinSelectMtx.Lock()
+	defer l.coinSelectMtx.Unlock()
+
+	witnessOutputs, err := l.ListUnspentWitnessFromDefaultAccount(
+		0, math.MaxInt32,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance btcutil.Amount
+	for _, utxo := range witnessOutputs {
+		balance += utxo.Value
+	}
+
+	return &AnchorReserve{
+		NumAnchorChans: numAnchors,
+		Reserved:       l.RequiredReservedValue(numAnchors),
+		Balance:        balance,
+	}, nil
+}
+
 // CheckReservedValueTx calls CheckReservedValue with the inputs and outputs
 // from the given tx, with the number of anchor channels currently open in the
 // database.
@@ -1048,6 +1213,105 @@ func (l *LightningWallet) CheckReservedValueTx(tx *wire.MsgTx) (btcutil.Amount,
 	return l.CheckReservedValue(inputs, tx.TxOut, numAnchors)
 }
 
+// CreateCoinControlTx creates and signs a transaction paying to the given
+// outputs. If outpoints are given, exactly those wallet outputs are spent.
+// Otherwise the wallet's coins are picked with the given strategy, falling back
+// to the configured strategy if none is set. Any change is sent to a new
+// address of the default account. The transaction is not published.
+//
+// NOTE: This method should only be run with the CoinSelectLock held.
+func (l *LightningWallet) CreateCoinControlTx(outputs []*wire.TxOut,
+	feeRate chainfee.SatPerKWeight, minConfs int32,
+	outpoints []wire.OutPoint,
+	strategy chanfunding.CoinSelectionStrategy) (*wire.MsgTx, error) {
+
+	coinSource := NewCoinSource(l)
+	coins, err := coinSource.ListCoins(minConfs, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == chanfunding.CoinSelectionDefault {
+		strategy = l.Cfg.CoinSelectionStrategy
+	}
+	if len(outpoints) > 0 {
+		coins, err = chanfunding.FilterCoins(coins, outpoints)
+		if err != nil {
+			return nil, err
+		}
+
+		strategy = chanfunding.CoinSelectionManual
+	}
+
+	selectedCoins, changeAmt, err := chanfunding.CoinSelectOutputs(
+		feeRate, outputs, DefaultDustLimit(), coins, strategy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(2)
+	for _, coin := range selectedCoins {
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: coin.OutPoint,
+		})
+	}
+	for _, output := range outputs {
+		tx.AddTxOut(output)
+	}
+
+	if changeAmt != 0 {
+		changeAddr, err := l.NewAddress(
+			WitnessPubKey, true, DefaultAccountName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		changeScript, err := txscript.PayToAddrScript(changeAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		tx.AddTxOut(&wire.TxOut{
+			Value:    int64(changeAmt),
+			PkScript: changeScript,
+		})
+	}
+
+	txsort.InPlaceSort(tx)
+
+	// With the transaction assembled, we'll now sign each of our inputs.
+	coinIndex := make(map[wire.OutPoint]chanfunding.Coin)
+	for _, coin := range selectedCoins {
+		coinIndex[coin.OutPoint] = coin
+	}
+
+	signDesc := input.SignDescriptor{
+		HashType:  txscript.SigHashAll,
+		SigHashes: txscript.NewTxSigHashes(tx),
+	}
+	for i, txIn := range tx.TxIn {
+		coin := coinIndex[txIn.PreviousOutPoint]
+		signDesc.Output = &wire.TxOut{
+			Value:    coin.Value,
+			PkScript: coin.PkScript,
+		}
+		signDesc.InputIndex = i
+
+		inputScript, err := l.Cfg.Signer.ComputeInputScript(
+			tx, &signDesc,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		txIn.SignatureScript = inputScript.SigScript
+		txIn.Witness = inputScript.Witness
+	}
+
+	return tx, nil
+}
+
 // initOurContribution initializes the given ChannelReservation with our coins
 // and change reserved for the channel, and derives the keys to use for this
 // channel.
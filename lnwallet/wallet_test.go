@@ -0,0 +1,124 @@
+package lnwallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequiredReservedValue tests that the value reserved for fee bumping
+// anchor channels is sized from the fee estimate, is at least the minimum
+// per-channel value and is capped at maxReservedAnchorChans channels.
+func TestRequiredReservedValue(t *testing.T) {
+	t.Parallel()
+
+	reserveWeight := anchorReserveWeight()
+	highFeeRate := chainfee.SatPerKWeight(50000)
+
+	tests := []struct {
+		name           string
+		feeRate        chainfee.SatPerKWeight
+		numAnchorChans int
+		expected       btcutil.Amount
+	}{
+		{
+			name:           "no anchor channels",
+			feeRate:        highFeeRate,
+			numAnchorChans: 0,
+			expected:       0,
+		},
+		{
+			name:           "low fee rate",
+			feeRate:        chainfee.FeePerKwFloor,
+			numAnchorChans: 2,
+			expected:       2 * anchorChanReservedValue,
+		},
+		{
+			name:           "high fee rate",
+			feeRate:        highFeeRate,
+			numAnchorChans: 2,
+			expected: 2 * highFeeRate.FeeForWeight(
+				reserveWeight,
+			),
+		},
+		{
+			name:           "capped number of channels",
+			feeRate:        chainfee.FeePerKwFloor,
+			numAnchorChans: 2 * maxReservedAnchorChans,
+			expected: maxReservedAnchorChans *
+				anchorChanReservedValue,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			estimator := chainfee.NewStaticEstimator(
+				test.feeRate, 0,
+			)
+			wallet := &LightningWallet{
+				Cfg: Config{
+					FeeEstimator: estimator,
+				},
+			}
+
+			reserved := wallet.RequiredReservedValue(
+				test.numAnchorChans,
+			)
+			require.Equal(t, test.expected, reserved)
+		})
+	}
+}
+
+// TestAnchorReserveShortfall tests that the shortfall of the anchor reserve is
+// the difference between the reserved value and the wallet balance, and zero
+// if the balance covers the reserved value.
+func TestAnchorReserveShortfall(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		reserved btcutil.Amount
+		balance  btcutil.Amount
+		expected btcutil.Amount
+	}{
+		{
+			name:     "no anchor channels",
+			reserved: 0,
+			balance:  0,
+			expected: 0,
+		},
+		{
+			name:     "balance above reserve",
+			reserved: 20_000,
+			balance:  50_000,
+			expected: 0,
+		},
+		{
+			name:     "balance equal to reserve",
+			reserved: 20_000,
+			balance:  20_000,
+			expected: 0,
+		},
+		{
+			name:     "balance below reserve",
+			reserved: 20_000,
+			balance:  5_000,
+			expected: 15_000,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			reserve := &AnchorReserve{
+				NumAnchorChans: 2,
+				Reserved:       test.reserved,
+				Balance:        test.balance,
+			}
+			require.Equal(t, test.expected, reserve.Shortfall())
+		})
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
-	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lnwallet"
@@ -13,12 +12,12 @@ import (
 // NodeSigner is an implementation of the MessageSigner interface backed by the
 // identity private key of running lnd node.
 type NodeSigner struct {
-	keySigner keychain.SingleKeyDigestSigner
+	keySigner keychain.SingleKeyMessageSigner
 }
 
 // NewNodeSigner creates a new instance of the NodeSigner backed by the target
 // private key.
-func NewNodeSigner(keySigner keychain.SingleKeyDigestSigner) *NodeSigner {
+func NewNodeSigner(keySigner keychain.SingleKeyMessageSigner) *NodeSigner {
 	return &NodeSigner{
 		keySigner: keySigner,
 	}
@@ -37,9 +36,7 @@ func (n *NodeSigner) SignMessage(pubKey *btcec.PublicKey,
 	}
 
 	// Otherwise, we'll sign the dsha256 of the target message.
-	var digest [32]byte
-	copy(digest[:], chainhash.DoubleHashB(msg))
-	sig, err := n.keySigner.SignDigest(digest)
+	sig, err := n.keySigner.SignMessage(msg, true)
 	if err != nil {
 		return nil, fmt.Errorf("can't sign the message: %v", err)
 	}
@@ -51,22 +48,19 @@ func (n *NodeSigner) SignMessage(pubKey *btcec.PublicKey,
 // resident node's private key. The returned signature is a pubkey-recoverable
 // signature.
 func (n *NodeSigner) SignCompact(msg []byte) ([]byte, error) {
-	// We'll sign the dsha256 of the target message.
-	digest := chainhash.DoubleHashB(msg)
-
-	return n.SignDigestCompact(digest)
+	return n.SignMessageCompact(msg, true)
 }
 
-// SignDigestCompact signs the provided message digest under the resident
-// node's private key. The returned signature is a pubkey-recoverable signature.
-func (n *NodeSigner) SignDigestCompact(hash []byte) ([]byte, error) {
-	var digest [32]byte
-	copy(digest[:], hash)
+// SignMessageCompact signs a single or double sha256 digest of the msg
+// parameter under the resident node's private key. The returned signature is a
+// pubkey-recoverable signature.
+func (n *NodeSigner) SignMessageCompact(msg []byte, doubleHash bool) ([]byte,
+	error) {
 
-	// keychain.SignDigestCompact returns a pubkey-recoverable signature.
-	sig, err := n.keySigner.SignDigestCompact(digest)
+	// keychain.SignMessageCompact returns a pubkey-recoverable signature.
+	sig, err := n.keySigner.SignMessageCompact(msg, doubleHash)
 	if err != nil {
-		return nil, fmt.Errorf("can't sign the hash: %v", err)
+		return nil, fmt.Errorf("can't sign the message: %v", err)
 	}
 
 	return sig, nil
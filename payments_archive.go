@@ -0,0 +1,226 @@
+package lnd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnrpc"
+)
+
+const (
+	// defaultPaymentsArchiveFilename is the default name of the file that
+	// deleted payments are archived to.
+	defaultPaymentsArchiveFilename = "payments.archive"
+)
+
+// paymentsArchive appends payments that are deleted from the database to an
+// archive file, so that a record of them is kept outside of the live payment
+// index. Every line of the archive is a payment in the JSON encoding of the
+// lnrpc.Payment message, the same format ListPayments returns. Payments are
+// identified by their hash and index, and each of them is only archived once,
+// so a deletion that is retried after its payments were archived doesn't
+// duplicate them.
+type paymentsArchive struct {
+	// path is the location of the archive file.
+	path string
+
+	// marshall converts a payment from the database into its rpc
+	// representation.
+	marshall func(*channeldb.MPPayment) (*lnrpc.Payment, error)
+
+	// mu serializes writes to the archive file.
+	mu sync.Mutex
+
+	// archived is the set of payments in the archive file, or nil if the
+	// file hasn't been read yet.
+	archived map[archivedPayment]struct{}
+
+	// size is the size of the archive file when we last read or wrote
+	// it. If the size changed, the file was modified by someone else and
+	// we read it again.
+	size int64
+}
+
+// archivedPayment identifies a payment in the archive. As a failed payment can
+// be retried with the same payment hash, which assigns it a new index, the
+// hash alone doesn't identify a payment.
+type archivedPayment struct {
+	hash  string
+	index uint64
+}
+
+// newPaymentsArchive creates a new payments archive that writes to the file at
+// the given path.
+func newPaymentsArchive(path string,
+	marshall func(*channeldb.MPPayment) (*lnrpc.Payment,
+		error)) *paymentsArchive {
+
+	return &paymentsArchive{
+		path:     path,
+		marshall: marshall,
+	}
+}
+
+// archive appends the given payments to the archive file, skipping those that
+// are archived already. The file is synced to disk before archive returns, so
+// that the payments can safely be deleted from the database afterwards.
+func (a *paymentsArchive) archive(payments []*channeldb.MPPayment) error {
+	marshaler := &jsonpb.Marshaler{
+		OrigName: true,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.readArchived(); err != nil {
+		return err
+	}
+
+	var (
+		b     bytes.Buffer
+		added []archivedPayment
+	)
+	for _, payment := range payments {
+		rpcPayment, err := a.marshall(payment)
+		if err != nil {
+			return err
+		}
+
+		key := archivedPayment{
+			hash:  rpcPayment.PaymentHash,
+			index: rpcPayment.PaymentIndex,
+		}
+		if _, ok := a.archived[key]; ok {
+			continue
+		}
+
+		if err := marshaler.Marshal(&b, rpcPayment); err != nil {
+			return err
+		}
+		b.WriteByte('\n')
+
+		a.archived[key] = struct{}{}
+		added = append(added, key)
+	}
+
+	// If the file can't be written, we forget about the payments we just
+	// added, so that they are written by the next attempt.
+	if err := a.write(b.Bytes()); err != nil {
+		for _, key := range added {
+			delete(a.archived, key)
+		}
+		return err
+	}
+
+	rpcsLog.Infof("Archived %v payments to %v, skipped %v that were "+
+		"archived already", len(added), a.path,
+		len(payments)-len(added))
+
+	return nil
+}
+
+// write appends the given lines to the archive file and syncs it to disk.
+//
+// NOTE: The mutex must be held when calling this method.
+func (a *paymentsArchive) write(lines []byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(
+		a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to open payments archive: %v", err)
+	}
+
+	if _, err := f.Write(lines); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("unable to write payments archive: %v", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("unable to sync payments archive: %v", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	a.size = info.Size()
+
+	return f.Close()
+}
+
+// readArchived reads the set of archived payments from the archive file, unless
+// it is known already and the file hasn't been modified since.
+//
+// NOTE: The mutex must be held when calling this method.
+func (a *paymentsArchive) readArchived() error {
+	f, err := os.Open(a.path)
+	switch {
+	case os.IsNotExist(err):
+		a.archived = make(map[archivedPayment]struct{})
+		a.size = 0
+		return nil
+
+	case err != nil:
+		return fmt.Errorf("unable to open payments archive: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if a.archived != nil && info.Size() == a.size {
+		return nil
+	}
+
+	// We only decode the fields that identify a payment. The jsonpb
+	// encoding represents 64-bit integers as strings.
+	var line struct {
+		PaymentHash  string `json:"payment_hash"`
+		PaymentIndex uint64 `json:"payment_index,string"`
+	}
+
+	archived := make(map[archivedPayment]struct{})
+	r := bufio.NewReader(f)
+	for {
+		b, err := r.ReadBytes('\n')
+		if len(b) > 0 {
+			line.PaymentHash, line.PaymentIndex = "", 0
+			if err := json.Unmarshal(b, &line); err != nil {
+				rpcsLog.Warnf("Skipping malformed line in "+
+					"payments archive %v: %v", a.path, err)
+			} else {
+				archived[archivedPayment{
+					hash:  line.PaymentHash,
+					index: line.PaymentIndex,
+				}] = struct{}{}
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read payments archive: "+
+				"%v", err)
+		}
+	}
+
+	a.archived = archived
+	a.size = info.Size()
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// +build !rpctest
+
+package lnd
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/stretchr/testify/require"
+)
+
+// testArchiveMarshall is a minimal marshall function for archive tests.
+func testArchiveMarshall(p *channeldb.MPPayment) (*lnrpc.Payment, error) {
+	return &lnrpc.Payment{
+		PaymentHash:    p.Info.PaymentIdentifier.String(),
+		PaymentIndex:   p.SequenceNum,
+		CreationTimeNs: p.Info.CreationTime.UnixNano(),
+	}, nil
+}
+
+// newArchivePayment creates a payment with the given sequence number to a
+// hash that is derived from the given byte.
+func newArchivePayment(hashByte byte, seqNum uint64) *channeldb.MPPayment {
+	return &channeldb.MPPayment{
+		SequenceNum: seqNum,
+		Info: &channeldb.PaymentCreationInfo{
+			PaymentIdentifier: lntypes.Hash{hashByte},
+		},
+	}
+}
+
+// readArchive returns the payment indexes of the lines of the archive file at
+// the given path, asserting that each line is a payment that was created by
+// newArchivePayment with its index as hash byte.
+func readArchive(t *testing.T, path string) []uint64 {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var indexes []uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var payment lnrpc.Payment
+		err := jsonpb.UnmarshalString(scanner.Text(), &payment)
+		require.NoError(t, err)
+
+		hash := lntypes.Hash{byte(payment.PaymentIndex % 10)}
+		require.Equal(t, hash.String(), payment.PaymentHash)
+
+		indexes = append(indexes, payment.PaymentIndex)
+	}
+	require.NoError(t, scanner.Err())
+
+	return indexes
+}
+
+// TestPaymentsArchive tests that archived payments are appended to the
+// archive file, one JSON encoded payment per line.
+func TestPaymentsArchive(t *testing.T) {
+	t.Parallel()
+
+	tempDir, err := ioutil.TempDir("", "paymentsarchive")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, defaultPaymentsArchiveFilename)
+	archive := newPaymentsArchive(path, testArchiveMarshall)
+
+	// Archive the payments in two batches, which should both be appended
+	// to the file.
+	err = archive.archive([]*channeldb.MPPayment{
+		newArchivePayment(1, 1), newArchivePayment(2, 2),
+	})
+	require.NoError(t, err)
+
+	err = archive.archive([]*channeldb.MPPayment{newArchivePayment(3, 3)})
+	require.NoError(t, err)
+
+	require.Equal(t, []uint64{1, 2, 3}, readArchive(t, path))
+}
+
+// TestPaymentsArchiveRetry tests that archiving payments again, as happens
+// when a deletion is retried after its payments were archived, doesn't
+// duplicate them in the archive.
+func TestPaymentsArchiveRetry(t *testing.T) {
+	t.Parallel()
+
+	tempDir, err := ioutil.TempDir("", "paymentsarchive")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, defaultPaymentsArchiveFilename)
+	archive := newPaymentsArchive(path, testArchiveMarshall)
+
+	err = archive.archive([]*channeldb.MPPayment{
+		newArchivePayment(1, 1), newArchivePayment(2, 2),
+	})
+	require.NoError(t, err)
+
+	// A retry that includes one of the archived payments only appends
+	// the new one. A payment that reuses the hash of an archived payment
+	// with a new index is a different payment, so it is archived as well.
+	err = archive.archive([]*channeldb.MPPayment{
+		newArchivePayment(2, 2), newArchivePayment(3, 3),
+		newArchivePayment(1, 11),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 3, 11}, readArchive(t, path))
+
+	// After a restart, the archived payments are read from the file.
+	restarted := newPaymentsArchive(path, testArchiveMarshall)
+	err = restarted.archive([]*channeldb.MPPayment{
+		newArchivePayment(3, 3), newArchivePayment(4, 4),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 3, 11, 4}, readArchive(t, path))
+
+	// If the file is moved away, for example to rotate it, the payments
+	// are archived to the new file again.
+	require.NoError(t, os.Rename(path, path+".old"))
+	err = restarted.archive([]*channeldb.MPPayment{
+		newArchivePayment(4, 4), newArchivePayment(5, 5),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{4, 5}, readArchive(t, path))
+}
@@ -318,6 +318,26 @@ type Config struct {
 	// that is accumulated before signing a new commitment.
 	ChannelCommitBatchSize uint32
 
+	// PeerAddLimiter is used when creating ChannelLinks and is the rate
+	// limiter shared by all links with this peer that bounds how quickly
+	// the peer may ask us to forward htlcs.
+	PeerAddLimiter *htlcswitch.AddLimiter
+
+	// ChannelAddLimiters is used when creating ChannelLinks and holds the
+	// rate limiters applied to the htlcs the peer asks us to forward over
+	// a single channel.
+	ChannelAddLimiters *htlcswitch.ChannelAddLimiters
+
+	// ReservedSlotFraction is used when creating ChannelLinks and is the
+	// fraction of a channel's outgoing htlc slots that forwarded htlcs may
+	// not use.
+	ReservedSlotFraction float64
+
+	// ReservedValueFraction is used when creating ChannelLinks and is the
+	// fraction of a channel's outgoing max value in flight that forwarded
+	// htlcs may not use.
+	ReservedValueFraction float64
+
 	// Quit is the server's quit channel. If this is closed, we halt operation.
 	Quit chan struct{}
 }
@@ -839,6 +859,10 @@ func (p *Brontide) addLink(chanPoint *wire.OutPoint,
 		NotifyActiveChannel:     p.cfg.ChannelNotifier.NotifyActiveChannelEvent,
 		NotifyInactiveChannel:   p.cfg.ChannelNotifier.NotifyInactiveChannelEvent,
 		HtlcNotifier:            p.cfg.HtlcNotifier,
+		PeerAddLimiter:          p.cfg.PeerAddLimiter,
+		ChannelAddLimiters:      p.cfg.ChannelAddLimiters,
+		ReservedSlotFraction:    p.cfg.ReservedSlotFraction,
+		ReservedValueFraction:   p.cfg.ReservedValueFraction,
 	}
 
 	link := htlcswitch.NewChannelLink(linkCfg, lnChan)
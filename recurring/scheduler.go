@@ -0,0 +1,783 @@
+package recurring
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
+	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/zpay32"
+)
+
+const (
+	// MinInterval is the minimum interval of a recurring payment.
+	MinInterval = time.Minute
+
+	// DefaultMaxAttempts is the default number of payment attempts that
+	// are made for a single run of a recurring payment before the run is
+	// given up.
+	DefaultMaxAttempts = 5
+
+	// DefaultMinBackoff is the default time that is waited before the
+	// first retry of a failed run. The time is doubled for every further
+	// retry.
+	DefaultMinBackoff = time.Minute
+
+	// DefaultMaxBackoff is the default maximum time that is waited before
+	// a failed run is retried.
+	DefaultMaxBackoff = time.Hour
+
+	// DefaultPayAttemptTimeout is the default time that a single payment
+	// attempt may take.
+	DefaultPayAttemptTimeout = time.Minute
+
+	// DefaultKeysendFinalCltvDelta is the default final CLTV delta of
+	// keysend payments, which don't come with an invoice that specifies
+	// it. It matches the default CLTV delta of the invoices created by
+	// lnd, as the BOLT 11 default of 9 blocks is rejected by lnd nodes.
+	DefaultKeysendFinalCltvDelta = 40
+)
+
+var (
+	// errShuttingDown is returned when a run is interrupted because the
+	// scheduler is stopped or the recurring payment is deleted.
+	errShuttingDown = errors.New("recurring payment scheduler shutting " +
+		"down")
+)
+
+// Store is the database that the recurring payments and the history of their
+// runs are stored in.
+type Store interface {
+	// AddRecurringPayment adds the given recurring payment and assigns
+	// its ID.
+	AddRecurringPayment(payment *channeldb.RecurringPayment) error
+
+	// FetchRecurringPayments returns all recurring payments.
+	FetchRecurringPayments() ([]*channeldb.RecurringPayment, error)
+
+	// DeleteRecurringPayment removes the recurring payment with the given
+	// ID along with the history of its runs.
+	DeleteRecurringPayment(id uint64) error
+
+	// AddRecurringPaymentRun adds a new run to the recurring payment with
+	// the given ID and assigns the run's number.
+	AddRecurringPaymentRun(id uint64,
+		run *channeldb.RecurringPaymentRun) error
+
+	// UpdateRecurringPaymentRun replaces the stored run of the recurring
+	// payment with the given ID.
+	UpdateRecurringPaymentRun(id uint64,
+		run *channeldb.RecurringPaymentRun) error
+
+	// CompleteRecurringPaymentRun stores the given completed run of the
+	// recurring payment with the given ID and sets the scheduled time of
+	// the payment's next run.
+	CompleteRecurringPaymentRun(id uint64,
+		run *channeldb.RecurringPaymentRun, nextRun time.Time) error
+
+	// FetchRecurringPaymentRuns returns the runs of the recurring payment
+	// with the given ID.
+	FetchRecurringPaymentRuns(id uint64) ([]*channeldb.RecurringPaymentRun,
+		error)
+}
+
+// Config holds the dependencies of the recurring payment scheduler.
+type Config struct {
+	// Store persists the recurring payments and their runs.
+	Store Store
+
+	// SendPayment dispatches the given payment without waiting for its
+	// result. Once SendPayment returns without an error, the payment must
+	// be known to SubscribePayment.
+	SendPayment func(payment *routing.LightningPayment) error
+
+	// SubscribePayment returns a channel that receives the
+	// *channeldb.MPPayment updates of the payment with the given
+	// identifier, starting with its current state, along with a function
+	// that cancels the subscription.
+	SubscribePayment func(id lntypes.Hash) (<-chan interface{}, func(),
+		error)
+
+	// FetchPayment returns the current state of the payment with the
+	// given identifier. channeldb.ErrPaymentNotInitiated is returned if
+	// the payment was never sent.
+	FetchPayment func(id lntypes.Hash) (*channeldb.MPPayment, error)
+
+	// ActiveNetParams are the parameters of the network that AMP invoices
+	// must be encoded for.
+	ActiveNetParams *chaincfg.Params
+
+	// KeysendFinalCltvDelta is the final CLTV delta of keysend payments.
+	// AMP payments use the final CLTV delta of their invoice.
+	KeysendFinalCltvDelta uint16
+
+	// MaxTotalTimelock is the maximum total time lock of the routes of
+	// the payments.
+	MaxTotalTimelock uint32
+
+	// MaxParts is the maximum number of partial payments that AMP
+	// payments may be split into.
+	MaxParts uint32
+
+	// PayAttemptTimeout is the time that a single payment attempt may
+	// take.
+	PayAttemptTimeout time.Duration
+
+	// MaxAttempts is the number of payment attempts that are made for a
+	// single run before the run is given up.
+	MaxAttempts uint32
+
+	// MinBackoff is the time that is waited before the first retry of a
+	// failed run. The time is doubled for every further retry.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the maximum time that is waited before a failed run is
+	// retried.
+	MaxBackoff time.Duration
+
+	// Clock is the clock that runs are scheduled with.
+	Clock clock.Clock
+}
+
+// Scheduler executes the recurring payments that are stored in the database.
+// Every recurring payment is executed by its own goroutine, which starts a
+// run whenever one is due and retries failed runs with exponential backoff.
+// The progress of every run is persisted, so that runs which are interrupted
+// by a restart are resumed instead of being paid twice.
+//
+// Runs that were due while the node was offline, or while an earlier run was
+// still being retried, are skipped, so that a recurring payment never pays
+// more than one run at a time.
+type Scheduler struct {
+	started sync.Once
+	stopped sync.Once
+
+	cfg *Config
+
+	mu sync.Mutex
+
+	// cancels holds the channel that stops the goroutine of every
+	// recurring payment that is executed.
+	cancels map[uint64]chan struct{}
+
+	// dones holds the channel that is closed once the goroutine of the
+	// recurring payment has exited.
+	dones map[uint64]chan struct{}
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// New creates a new recurring payment scheduler.
+func New(cfg *Config) *Scheduler {
+	return &Scheduler{
+		cfg:     cfg,
+		cancels: make(map[uint64]chan struct{}),
+		dones:   make(map[uint64]chan struct{}),
+		quit:    make(chan struct{}),
+	}
+}
+
+// Start starts executing the recurring payments that are stored in the
+// database.
+func (s *Scheduler) Start() error {
+	var err error
+	s.started.Do(func() {
+		err = s.start()
+	})
+	return err
+}
+
+// start launches the goroutines of all recurring payments that are not
+// finished yet.
+func (s *Scheduler) start() error {
+	payments, err := s.cfg.Store.FetchRecurringPayments()
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range payments {
+		if payment.Finished() {
+			continue
+		}
+
+		s.launch(payment)
+	}
+
+	log.Infof("Recurring payment scheduler started with %v recurring "+
+		"payments", len(payments))
+
+	return nil
+}
+
+// Stop stops executing recurring payments. Payments that are in flight are
+// not affected, their results are picked up on the next start.
+func (s *Scheduler) Stop() error {
+	s.stopped.Do(func() {
+		log.Info("Recurring payment scheduler shutting down")
+
+		close(s.quit)
+		s.wg.Wait()
+	})
+	return nil
+}
+
+// AddPayment validates the given recurring payment, adds it to the database
+// and starts executing it. If no start time is set, the first run is started
+// immediately.
+func (s *Scheduler) AddPayment(payment *channeldb.RecurringPayment) error {
+	now := s.cfg.Clock.Now()
+	if payment.StartTime.IsZero() {
+		payment.StartTime = now
+	}
+	payment.NextRun = payment.StartTime
+	payment.CreationTime = now
+
+	if err := s.validatePayment(payment); err != nil {
+		return err
+	}
+
+	if err := s.cfg.Store.AddRecurringPayment(payment); err != nil {
+		return err
+	}
+
+	log.Infof("Added recurring payment %v over %v every %v",
+		payment.ID, payment.Amt, payment.Interval)
+
+	s.launch(payment)
+
+	return nil
+}
+
+// DeletePayment stops executing the recurring payment with the given ID and
+// removes it from the database along with the history of its runs. A payment
+// attempt that is in flight is not affected.
+func (s *Scheduler) DeletePayment(id uint64) error {
+	s.mu.Lock()
+	cancel, ok := s.cancels[id]
+	done := s.dones[id]
+	if ok {
+		delete(s.cancels, id)
+		delete(s.dones, id)
+		close(cancel)
+	}
+	s.mu.Unlock()
+
+	// Wait for the goroutine of the payment to exit, so that it doesn't
+	// write to the deleted payment.
+	if ok {
+		<-done
+	}
+
+	if err := s.cfg.Store.DeleteRecurringPayment(id); err != nil {
+		return err
+	}
+
+	log.Infof("Deleted recurring payment %v", id)
+
+	return nil
+}
+
+// validatePayment checks that the given recurring payment can be executed.
+// If the amount of an AMP payment is not set, it is taken from the invoice.
+func (s *Scheduler) validatePayment(payment *channeldb.RecurringPayment) error {
+	if payment.Interval < MinInterval {
+		return fmt.Errorf("interval must be at least %v", MinInterval)
+	}
+
+	if !payment.EndTime.IsZero() &&
+		payment.EndTime.Before(payment.StartTime) {
+
+		return errors.New("end time must not be before the start time")
+	}
+
+	var finalCltvDelta uint16
+	switch {
+	case payment.IsKeysend() && payment.Dest == route.Vertex{}:
+		return errors.New("either a destination or an AMP invoice " +
+			"must be set")
+
+	case payment.IsKeysend():
+		finalCltvDelta = s.cfg.KeysendFinalCltvDelta
+
+	case payment.Dest != route.Vertex{}:
+		return errors.New("destination and AMP invoice cannot be " +
+			"set together")
+
+	default:
+		payReq, err := s.decodeInvoice(payment.AmpInvoice)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case payReq.MilliSat == nil:
+
+		case payment.Amt == 0:
+			payment.Amt = *payReq.MilliSat
+
+		case payment.Amt != *payReq.MilliSat:
+			return fmt.Errorf("amount %v does not match the "+
+				"amount %v of the AMP invoice", payment.Amt,
+				*payReq.MilliSat)
+		}
+
+		finalCltvDelta = uint16(payReq.MinFinalCLTVExpiry())
+	}
+
+	if payment.Amt == 0 {
+		return errors.New("amount must be specified")
+	}
+
+	return routing.ValidateCLTVLimit(
+		s.cfg.MaxTotalTimelock, finalCltvDelta, true,
+	)
+}
+
+// decodeInvoice decodes the given AMP invoice and checks that it can be paid.
+func (s *Scheduler) decodeInvoice(invoice string) (*zpay32.Invoice, error) {
+	payReq, err := zpay32.Decode(invoice, s.cfg.ActiveNetParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if !payReq.Features.HasFeature(lnwire.AMPOptional) {
+		return nil, errors.New("invoice does not support AMP payments")
+	}
+
+	validUntil := payReq.Timestamp.Add(payReq.Expiry())
+	if s.cfg.Clock.Now().After(validUntil) {
+		return nil, fmt.Errorf("invoice expired. Valid until %v",
+			validUntil)
+	}
+
+	return payReq, nil
+}
+
+// launch starts the goroutine that executes the given recurring payment.
+func (s *Scheduler) launch(payment *channeldb.RecurringPayment) {
+	cancel := make(chan struct{})
+	done := make(chan struct{})
+
+	s.mu.Lock()
+	s.cancels[payment.ID] = cancel
+	s.dones[payment.ID] = done
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(done)
+
+		err := s.execute(payment, cancel)
+		switch {
+		case err == errShuttingDown:
+
+		case err != nil:
+			log.Errorf("Unable to execute recurring payment %v: %v",
+				payment.ID, err)
+
+		default:
+			log.Infof("Recurring payment %v finished", payment.ID)
+		}
+
+		s.mu.Lock()
+		if s.cancels[payment.ID] == cancel {
+			delete(s.cancels, payment.ID)
+			delete(s.dones, payment.ID)
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// execute starts the runs of the given recurring payment as they become due,
+// until the payment is finished. A run that was not completed before a
+// restart is resumed first.
+func (s *Scheduler) execute(payment *channeldb.RecurringPayment,
+	cancel <-chan struct{}) error {
+
+	runs, err := s.cfg.Store.FetchRecurringPaymentRuns(payment.ID)
+	if err != nil {
+		return err
+	}
+
+	var run *channeldb.RecurringPaymentRun
+	if len(runs) > 0 {
+		lastRun := runs[len(runs)-1]
+		if lastRun.Status == channeldb.RecurringPaymentRunInFlight {
+			log.Infof("Resuming run %v of recurring payment %v",
+				lastRun.Number, payment.ID)
+
+			run = lastRun
+		}
+	}
+
+	for {
+		if run == nil {
+			if payment.Finished() {
+				return nil
+			}
+
+			delay := payment.NextRun.Sub(s.cfg.Clock.Now())
+			if err := s.wait(delay, cancel); err != nil {
+				return err
+			}
+
+			run = &channeldb.RecurringPaymentRun{
+				ScheduledTime: payment.NextRun,
+			}
+			err := s.cfg.Store.AddRecurringPaymentRun(
+				payment.ID, run,
+			)
+			if err != nil {
+				return err
+			}
+
+			log.Debugf("Starting run %v of recurring payment %v",
+				run.Number, payment.ID)
+		}
+
+		if err := s.completeRun(payment, run, cancel); err != nil {
+			return err
+		}
+
+		nextRun := s.nextRun(payment, run)
+		err := s.cfg.Store.CompleteRecurringPaymentRun(
+			payment.ID, run, nextRun,
+		)
+		if err != nil {
+			return err
+		}
+		payment.NextRun = nextRun
+
+		log.Infof("Run %v of recurring payment %v %v after %v "+
+			"attempts, next run at %v", run.Number, payment.ID,
+			run.Status, run.Attempts, nextRun)
+
+		run = nil
+	}
+}
+
+// nextRun returns the scheduled time of the run that follows the given run.
+// Runs that are already overdue are skipped.
+func (s *Scheduler) nextRun(payment *channeldb.RecurringPayment,
+	run *channeldb.RecurringPaymentRun) time.Time {
+
+	nextRun := run.ScheduledTime.Add(payment.Interval)
+
+	now := s.cfg.Clock.Now()
+	if nextRun.Before(now) {
+		skipped := now.Sub(nextRun)/payment.Interval + 1
+		nextRun = nextRun.Add(skipped * payment.Interval)
+
+		log.Warnf("Skipping %v overdue runs of recurring payment %v",
+			int64(skipped), payment.ID)
+	}
+
+	return nextRun
+}
+
+// completeRun makes payment attempts for the given run until one of them
+// succeeds, or the maximum number of attempts is reached. The status of the
+// run is set accordingly. If the latest attempt of the run is still
+// outstanding, its result is awaited first.
+func (s *Scheduler) completeRun(payment *channeldb.RecurringPayment,
+	run *channeldb.RecurringPaymentRun, cancel <-chan struct{}) error {
+
+	for {
+		// An attempt without a failure reason is outstanding.
+		if run.Attempts > 0 && run.FailureReason == "" {
+			reason, err := s.awaitPayment(run.PaymentID, cancel)
+			if err != nil {
+				return err
+			}
+
+			if reason == "" {
+				run.Status =
+					channeldb.RecurringPaymentRunSucceeded
+
+				return nil
+			}
+
+			log.Debugf("Attempt %v of run %v of recurring payment "+
+				"%v failed: %v", run.Attempts, run.Number,
+				payment.ID, reason)
+
+			run.FailureReason = reason
+			err = s.cfg.Store.UpdateRecurringPaymentRun(
+				payment.ID, run,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if run.Attempts >= s.cfg.MaxAttempts {
+			run.Status = channeldb.RecurringPaymentRunFailed
+			return nil
+		}
+
+		if run.Attempts > 0 {
+			err := s.wait(s.backoff(run.Attempts), cancel)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := s.attempt(payment, run); err != nil {
+			return err
+		}
+	}
+}
+
+// attempt makes a new payment attempt for the given run. The attempt is
+// persisted before the payment is sent, so that it can be resumed after a
+// restart.
+func (s *Scheduler) attempt(payment *channeldb.RecurringPayment,
+	run *channeldb.RecurringPaymentRun) error {
+
+	run.Attempts++
+	run.LastAttemptTime = s.cfg.Clock.Now()
+	run.PaymentID = lntypes.ZeroHash
+	run.FailureReason = ""
+
+	lnPayment, err := s.newPayment(payment)
+	if err != nil {
+		run.FailureReason = err.Error()
+		return s.cfg.Store.UpdateRecurringPaymentRun(payment.ID, run)
+	}
+
+	run.PaymentID = lnPayment.Identifier()
+	err = s.cfg.Store.UpdateRecurringPaymentRun(payment.ID, run)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cfg.SendPayment(lnPayment); err != nil {
+		run.FailureReason = err.Error()
+		return s.cfg.Store.UpdateRecurringPaymentRun(payment.ID, run)
+	}
+
+	return nil
+}
+
+// newPayment creates a new payment for the given recurring payment. Every
+// payment carries fresh randomness, so that it has its own identifier.
+func (s *Scheduler) newPayment(payment *channeldb.RecurringPayment) (
+	*routing.LightningPayment, error) {
+
+	lnPayment := &routing.LightningPayment{
+		Target:            payment.Dest,
+		Amount:            payment.Amt,
+		FeeLimit:          payment.MaxFee,
+		CltvLimit:         s.cfg.MaxTotalTimelock,
+		PayAttemptTimeout: s.cfg.PayAttemptTimeout,
+		MaxParts:          1,
+	}
+
+	if payment.IsKeysend() {
+		var preimage lntypes.Preimage
+		if _, err := rand.Read(preimage[:]); err != nil {
+			return nil, err
+		}
+
+		lnPayment.FinalCLTVDelta = s.cfg.KeysendFinalCltvDelta
+		lnPayment.DestCustomRecords = record.CustomSet{
+			record.KeySendType: preimage[:],
+		}
+
+		err := lnPayment.SetPaymentHash(preimage.Hash())
+		if err != nil {
+			return nil, err
+		}
+
+		return lnPayment, nil
+	}
+
+	payReq, err := s.decodeInvoice(payment.AmpInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	var setID, rootShare [32]byte
+	if _, err := rand.Read(setID[:]); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(rootShare[:]); err != nil {
+		return nil, err
+	}
+
+	err = lnPayment.SetAMP(&routing.AMPOptions{
+		SetID:     setID,
+		RootShare: rootShare,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copy(lnPayment.Target[:], payReq.Destination.SerializeCompressed())
+	lnPayment.FinalCLTVDelta = uint16(payReq.MinFinalCLTVExpiry())
+	lnPayment.RouteHints = payReq.RouteHints
+	lnPayment.DestFeatures = payReq.Features
+	lnPayment.PaymentAddr = payReq.PaymentAddr
+	lnPayment.Metadata = payReq.Metadata
+	lnPayment.PaymentRequest = []byte(payment.AmpInvoice)
+	lnPayment.MaxParts = s.cfg.MaxParts
+
+	return lnPayment, nil
+}
+
+// awaitPayment waits for the payment with the given identifier to be
+// resolved. An empty string is returned if the payment succeeded, otherwise
+// the reason it failed.
+//
+// A payment whose state can't be determined is never reported as failed, as
+// the run would then be retried while the payment might still be in flight,
+// paying twice. Instead, the payment is looked up and tracked again until it
+// is resolved.
+func (s *Scheduler) awaitPayment(id lntypes.Hash,
+	cancel <-chan struct{}) (string, error) {
+
+	for {
+		reason, resolved, err := s.trackPayment(id, cancel)
+		if err != nil {
+			return "", err
+		}
+		if resolved {
+			return reason, nil
+		}
+
+		reason, resolved, err = s.lookupPayment(id)
+		if resolved {
+			return reason, nil
+		}
+
+		if err != nil {
+			log.Warnf("Unable to look up payment %v, retrying in "+
+				"%v: %v", id, s.cfg.MinBackoff, err)
+		} else {
+			log.Debugf("Payment %v still in flight, tracking it "+
+				"again in %v", id, s.cfg.MinBackoff)
+		}
+
+		if err := s.wait(s.cfg.MinBackoff, cancel); err != nil {
+			return "", err
+		}
+	}
+}
+
+// trackPayment follows the updates of the payment with the given identifier
+// until it is resolved. False is returned if the payment couldn't be tracked
+// to its end, in which case its state is unknown.
+func (s *Scheduler) trackPayment(id lntypes.Hash,
+	cancel <-chan struct{}) (string, bool, error) {
+
+	updates, unsubscribe, err := s.cfg.SubscribePayment(id)
+	if err != nil {
+		log.Warnf("Unable to track payment %v: %v", id, err)
+		return "", false, nil
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return "", false, nil
+			}
+
+			payment, ok := update.(*channeldb.MPPayment)
+			if !ok {
+				continue
+			}
+
+			reason, resolved := paymentResult(payment)
+			if resolved {
+				return reason, true, nil
+			}
+
+		case <-cancel:
+			return "", false, errShuttingDown
+
+		case <-s.quit:
+			return "", false, errShuttingDown
+		}
+	}
+}
+
+// lookupPayment fetches the current state of the payment with the given
+// identifier. False is returned if the payment is still in flight or its
+// state couldn't be fetched.
+func (s *Scheduler) lookupPayment(id lntypes.Hash) (string, bool, error) {
+	payment, err := s.cfg.FetchPayment(id)
+	switch {
+	// The attempt was persisted, but the payment was never sent, so it is
+	// safe to make another attempt.
+	case err == channeldb.ErrPaymentNotInitiated:
+		return "payment was not sent", true, nil
+
+	case err != nil:
+		return "", false, err
+	}
+
+	reason, resolved := paymentResult(payment)
+	return reason, resolved, nil
+}
+
+// paymentResult returns whether the given payment is resolved, along with the
+// reason it failed. The reason is empty if the payment succeeded.
+func paymentResult(payment *channeldb.MPPayment) (string, bool) {
+	switch payment.Status {
+	case channeldb.StatusSucceeded:
+		return "", true
+
+	case channeldb.StatusFailed:
+		reason := "unknown failure"
+		if payment.FailureReason != nil {
+			reason = payment.FailureReason.String()
+		}
+
+		return reason, true
+	}
+
+	return "", false
+}
+
+// backoff returns the time that is waited after the given number of failed
+// attempts before the next attempt is made.
+func (s *Scheduler) backoff(attempts uint32) time.Duration {
+	backoff := s.cfg.MinBackoff
+	for i := uint32(1); i < attempts && backoff < s.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > s.cfg.MaxBackoff {
+		backoff = s.cfg.MaxBackoff
+	}
+
+	return backoff
+}
+
+// wait blocks for the given duration. errShuttingDown is returned if the
+// wait is interrupted.
+func (s *Scheduler) wait(delay time.Duration, cancel <-chan struct{}) error {
+	select {
+	case <-s.cfg.Clock.TickAfter(delay):
+		return nil
+
+	case <-cancel:
+		return errShuttingDown
+
+	case <-s.quit:
+		return errShuttingDown
+	}
+}
@@ -0,0 +1,517 @@
+package recurring
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/record"
+	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testStart = time.Unix(1600000000, 0)
+
+	testDest = route.Vertex{2, 202, 4}
+
+	testTimeout = 5 * time.Second
+)
+
+// mockPayer records the payments that are sent by the scheduler and lets the
+// test resolve them.
+type mockPayer struct {
+	sent chan *routing.LightningPayment
+
+	mu           sync.Mutex
+	updates      map[lntypes.Hash]chan interface{}
+	payments     map[lntypes.Hash]*channeldb.MPPayment
+	subscribeErr error
+}
+
+func newMockPayer() *mockPayer {
+	return &mockPayer{
+		sent:     make(chan *routing.LightningPayment),
+		updates:  make(map[lntypes.Hash]chan interface{}),
+		payments: make(map[lntypes.Hash]*channeldb.MPPayment),
+	}
+}
+
+func (m *mockPayer) SendPayment(payment *routing.LightningPayment) error {
+	m.mu.Lock()
+	m.updates[payment.Identifier()] = make(chan interface{}, 1)
+	m.payments[payment.Identifier()] = &channeldb.MPPayment{
+		Status: channeldb.StatusInFlight,
+	}
+	m.mu.Unlock()
+
+	m.sent <- payment
+	return nil
+}
+
+func (m *mockPayer) SubscribePayment(id lntypes.Hash) (<-chan interface{},
+	func(), error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.subscribeErr != nil {
+		return nil, nil, m.subscribeErr
+	}
+
+	updates, ok := m.updates[id]
+	if !ok {
+		return nil, nil, channeldb.ErrPaymentNotInitiated
+	}
+
+	return updates, func() {}, nil
+}
+
+func (m *mockPayer) FetchPayment(id lntypes.Hash) (*channeldb.MPPayment,
+	error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	payment, ok := m.payments[id]
+	if !ok {
+		return nil, channeldb.ErrPaymentNotInitiated
+	}
+
+	return payment, nil
+}
+
+// setInFlight marks the payment with the given identifier as in flight, as
+// if it was sent before the scheduler was started.
+func (m *mockPayer) setInFlight(id lntypes.Hash) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.updates[id] = make(chan interface{}, 1)
+	m.payments[id] = &channeldb.MPPayment{
+		Status: channeldb.StatusInFlight,
+	}
+}
+
+// setSubscribeErr makes the subscriptions to payments fail with the given
+// error.
+func (m *mockPayer) setSubscribeErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subscribeErr = err
+}
+
+// nextPayment returns the next payment that is sent by the scheduler.
+func (m *mockPayer) nextPayment(t *testing.T) *routing.LightningPayment {
+	select {
+	case payment := <-m.sent:
+		return payment
+
+	case <-time.After(testTimeout):
+		t.Fatal("no payment sent")
+		return nil
+	}
+}
+
+// resolve settles or fails the payment with the given identifier.
+func (m *mockPayer) resolve(id lntypes.Hash, succeeded bool) {
+	payment := &channeldb.MPPayment{
+		Status: channeldb.StatusSucceeded,
+	}
+	if !succeeded {
+		reason := channeldb.FailureReasonNoRoute
+		payment.Status = channeldb.StatusFailed
+		payment.FailureReason = &reason
+	}
+
+	m.mu.Lock()
+	updates, ok := m.updates[id]
+	if !ok {
+		updates = make(chan interface{}, 1)
+		m.updates[id] = updates
+	}
+	m.payments[id] = payment
+	m.mu.Unlock()
+
+	updates <- payment
+}
+
+// testContext holds the scheduler under test along with its dependencies.
+type testContext struct {
+	t          *testing.T
+	db         *channeldb.DB
+	payer      *mockPayer
+	clock      *clock.TestClock
+	tickSignal chan time.Duration
+	scheduler  *Scheduler
+}
+
+func newTestContext(t *testing.T) *testContext {
+	db, cleanup, err := channeldb.MakeTestDB()
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	ctx := &testContext{
+		t:          t,
+		db:         db,
+		payer:      newMockPayer(),
+		tickSignal: make(chan time.Duration, 10),
+	}
+	ctx.clock = clock.NewTestClockWithTickSignal(testStart, ctx.tickSignal)
+	ctx.scheduler = ctx.newScheduler()
+
+	return ctx
+}
+
+// newScheduler creates a new scheduler that shares the database, payer and
+// clock of the test context.
+func (c *testContext) newScheduler() *Scheduler {
+	return New(&Config{
+		Store:                 c.db,
+		SendPayment:           c.payer.SendPayment,
+		SubscribePayment:      c.payer.SubscribePayment,
+		FetchPayment:          c.payer.FetchPayment,
+		ActiveNetParams:       &chaincfg.RegressionNetParams,
+		KeysendFinalCltvDelta: DefaultKeysendFinalCltvDelta,
+		MaxTotalTimelock:      2016,
+		MaxParts:              16,
+		PayAttemptTimeout:     DefaultPayAttemptTimeout,
+		MaxAttempts:           2,
+		MinBackoff:            time.Minute,
+		MaxBackoff:            time.Hour,
+		Clock:                 c.clock,
+	})
+}
+
+// expectTick waits for the scheduler to wait for the given duration.
+func (c *testContext) expectTick(delay time.Duration) {
+	select {
+	case tick := <-c.tickSignal:
+		require.Equal(c.t, delay, tick)
+
+	case <-time.After(testTimeout):
+		c.t.Fatalf("no tick after %v requested", delay)
+	}
+}
+
+// expectRuns waits for the runs of the given recurring payment to match the
+// given statuses and attempts.
+func (c *testContext) expectRuns(id uint64,
+	statuses []channeldb.RecurringPaymentRunStatus, attempts []uint32) {
+
+	require.Eventually(c.t, func() bool {
+		runs, err := c.db.FetchRecurringPaymentRuns(id)
+		require.NoError(c.t, err)
+
+		if len(runs) != len(statuses) {
+			return false
+		}
+
+		for i, run := range runs {
+			if run.Status != statuses[i] ||
+				run.Attempts != attempts[i] {
+
+				return false
+			}
+		}
+
+		return true
+	}, testTimeout, 10*time.Millisecond)
+}
+
+// TestSchedulerKeysend tests the execution of a recurring keysend payment,
+// including retries of failed runs and the end of the payment.
+func TestSchedulerKeysend(t *testing.T) {
+	ctx := newTestContext(t)
+	require.NoError(t, ctx.scheduler.Start())
+	defer func() {
+		require.NoError(t, ctx.scheduler.Stop())
+	}()
+
+	payment := &channeldb.RecurringPayment{
+		Dest:     testDest,
+		Amt:      10000,
+		MaxFee:   100,
+		Interval: time.Hour,
+		EndTime:  testStart.Add(90 * time.Minute),
+	}
+	require.NoError(t, ctx.scheduler.AddPayment(payment))
+	require.Equal(t, testStart, payment.StartTime)
+
+	// The first run is started immediately, as no start time was set.
+	ctx.expectTick(0)
+	lnPayment := ctx.payer.nextPayment(t)
+
+	require.Equal(t, testDest, lnPayment.Target)
+	require.EqualValues(t, 10000, lnPayment.Amount)
+	require.EqualValues(t, 100, lnPayment.FeeLimit)
+	require.EqualValues(
+		t, DefaultKeysendFinalCltvDelta, lnPayment.FinalCLTVDelta,
+	)
+
+	var preimage lntypes.Preimage
+	copy(preimage[:], lnPayment.DestCustomRecords[record.KeySendType])
+	require.EqualValues(t, preimage.Hash(), lnPayment.Identifier())
+
+	// Fail the first attempt. It is retried after the minimum backoff,
+	// with a new payment hash.
+	ctx.payer.resolve(lnPayment.Identifier(), false)
+	ctx.expectTick(time.Minute)
+	ctx.clock.SetTime(testStart.Add(time.Minute))
+
+	retry := ctx.payer.nextPayment(t)
+	require.NotEqual(t, lnPayment.Identifier(), retry.Identifier())
+
+	// Let the retry succeed, which completes the first run. The second
+	// run is scheduled one interval after the first one.
+	ctx.payer.resolve(retry.Identifier(), true)
+	ctx.expectTick(59 * time.Minute)
+	ctx.expectRuns(
+		payment.ID,
+		[]channeldb.RecurringPaymentRunStatus{
+			channeldb.RecurringPaymentRunSucceeded,
+		},
+		[]uint32{2},
+	)
+
+	// Fail both attempts of the second run, which fails the run. As the
+	// next run would be after the end time, the payment is finished.
+	ctx.clock.SetTime(testStart.Add(time.Hour))
+	lnPayment = ctx.payer.nextPayment(t)
+	ctx.payer.resolve(lnPayment.Identifier(), false)
+
+	ctx.expectTick(time.Minute)
+	ctx.clock.SetTime(testStart.Add(61 * time.Minute))
+	lnPayment = ctx.payer.nextPayment(t)
+	ctx.payer.resolve(lnPayment.Identifier(), false)
+
+	ctx.expectRuns(
+		payment.ID,
+		[]channeldb.RecurringPaymentRunStatus{
+			channeldb.RecurringPaymentRunSucceeded,
+			channeldb.RecurringPaymentRunFailed,
+		},
+		[]uint32{2, 2},
+	)
+
+	runs, err := ctx.db.FetchRecurringPaymentRuns(payment.ID)
+	require.NoError(t, err)
+	require.Equal(t, testStart.Add(time.Hour), runs[1].ScheduledTime)
+	require.EqualValues(t, lnPayment.Identifier(), runs[1].PaymentID)
+	require.Equal(
+		t, channeldb.FailureReasonNoRoute.String(),
+		runs[1].FailureReason,
+	)
+
+	require.Eventually(t, func() bool {
+		payment, err := ctx.db.FetchRecurringPayment(payment.ID)
+		require.NoError(t, err)
+
+		return payment.Finished()
+	}, testTimeout, 10*time.Millisecond)
+}
+
+// TestSchedulerResume tests that a run which was interrupted by a restart is
+// resumed without paying it twice, and that overdue runs are skipped.
+func TestSchedulerResume(t *testing.T) {
+	ctx := newTestContext(t)
+
+	// Add a recurring payment whose first run has an attempt in flight,
+	// as if the node was restarted while the attempt was in flight.
+	payment := &channeldb.RecurringPayment{
+		Dest:      testDest,
+		Amt:       10000,
+		Interval:  time.Hour,
+		StartTime: testStart,
+		NextRun:   testStart,
+	}
+	require.NoError(t, ctx.db.AddRecurringPayment(payment))
+
+	run := &channeldb.RecurringPaymentRun{
+		ScheduledTime:   testStart,
+		Status:          channeldb.RecurringPaymentRunInFlight,
+		Attempts:        1,
+		LastAttemptTime: testStart,
+		PaymentID:       lntypes.Hash{1},
+	}
+	require.NoError(t, ctx.db.AddRecurringPaymentRun(payment.ID, run))
+
+	// The node comes back three and a half hours later, and the attempt
+	// succeeded in the meantime.
+	ctx.clock.SetTime(testStart.Add(210 * time.Minute))
+	ctx.payer.resolve(run.PaymentID, true)
+
+	require.NoError(t, ctx.scheduler.Start())
+	defer func() {
+		require.NoError(t, ctx.scheduler.Stop())
+	}()
+
+	// The run completes without a new payment, and the overdue runs are
+	// skipped.
+	ctx.expectTick(30 * time.Minute)
+	ctx.expectRuns(
+		payment.ID,
+		[]channeldb.RecurringPaymentRunStatus{
+			channeldb.RecurringPaymentRunSucceeded,
+		},
+		[]uint32{1},
+	)
+
+	stored, err := ctx.db.FetchRecurringPayment(payment.ID)
+	require.NoError(t, err)
+	require.Equal(t, testStart.Add(4*time.Hour), stored.NextRun)
+
+	// Deleting the payment stops its execution.
+	require.NoError(t, ctx.scheduler.DeletePayment(payment.ID))
+
+	_, err = ctx.db.FetchRecurringPayment(payment.ID)
+	require.Equal(t, channeldb.ErrRecurringPaymentNotFound, err)
+
+	select {
+	case <-ctx.payer.sent:
+		t.Fatal("payment sent after deletion")
+
+	default:
+	}
+}
+
+// TestSchedulerUntrackedPayment tests that an attempt which can't be tracked
+// isn't counted as failed while it is still in flight, so that the run isn't
+// paid twice.
+func TestSchedulerUntrackedPayment(t *testing.T) {
+	ctx := newTestContext(t)
+
+	payment := &channeldb.RecurringPayment{
+		Dest:      testDest,
+		Amt:       10000,
+		Interval:  time.Hour,
+		StartTime: testStart,
+		NextRun:   testStart,
+	}
+	require.NoError(t, ctx.db.AddRecurringPayment(payment))
+
+	run := &channeldb.RecurringPaymentRun{
+		ScheduledTime:   testStart,
+		Status:          channeldb.RecurringPaymentRunInFlight,
+		Attempts:        1,
+		LastAttemptTime: testStart,
+		PaymentID:       lntypes.Hash{1},
+	}
+	require.NoError(t, ctx.db.AddRecurringPaymentRun(payment.ID, run))
+
+	// The attempt is in flight, but it can't be tracked.
+	ctx.payer.setInFlight(run.PaymentID)
+	ctx.payer.setSubscribeErr(errors.New("subscription failed"))
+
+	require.NoError(t, ctx.scheduler.Start())
+	defer func() {
+		require.NoError(t, ctx.scheduler.Stop())
+	}()
+
+	// The payment is looked up, and as it is still in flight, it is
+	// tracked again after the minimum backoff instead of being retried.
+	ctx.expectTick(time.Minute)
+
+	select {
+	case <-ctx.payer.sent:
+		t.Fatal("payment retried while in flight")
+
+	default:
+	}
+
+	runs, err := ctx.db.FetchRecurringPaymentRuns(payment.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Empty(t, runs[0].FailureReason)
+
+	// Once the attempt succeeded, the lookup completes the run without a
+	// further payment.
+	ctx.payer.resolve(run.PaymentID, true)
+	ctx.clock.SetTime(testStart.Add(time.Minute))
+
+	ctx.expectTick(59 * time.Minute)
+	ctx.expectRuns(
+		payment.ID,
+		[]channeldb.RecurringPaymentRunStatus{
+			channeldb.RecurringPaymentRunSucceeded,
+		},
+		[]uint32{1},
+	)
+}
+
+// TestAddPaymentValidation tests that invalid recurring payments are
+// rejected.
+func TestAddPaymentValidation(t *testing.T) {
+	ctx := newTestContext(t)
+
+	tests := []struct {
+		name    string
+		payment *channeldb.RecurringPayment
+	}{
+		{
+			name: "interval too short",
+			payment: &channeldb.RecurringPayment{
+				Dest:     testDest,
+				Amt:      1000,
+				Interval: time.Second,
+			},
+		},
+		{
+			name: "end before start",
+			payment: &channeldb.RecurringPayment{
+				Dest:      testDest,
+				Amt:       1000,
+				Interval:  time.Hour,
+				StartTime: testStart,
+				EndTime:   testStart.Add(-time.Hour),
+			},
+		},
+		{
+			name: "no destination",
+			payment: &channeldb.RecurringPayment{
+				Amt:      1000,
+				Interval: time.Hour,
+			},
+		},
+		{
+			name: "destination and invoice",
+			payment: &channeldb.RecurringPayment{
+				Dest:       testDest,
+				AmpInvoice: "lnbcrt1invalid",
+				Amt:        1000,
+				Interval:   time.Hour,
+			},
+		},
+		{
+			name: "invalid invoice",
+			payment: &channeldb.RecurringPayment{
+				AmpInvoice: "lnbcrt1invalid",
+				Amt:        1000,
+				Interval:   time.Hour,
+			},
+		},
+		{
+			name: "no amount",
+			payment: &channeldb.RecurringPayment{
+				Dest:     testDest,
+				Interval: time.Hour,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := ctx.scheduler.AddPayment(test.payment)
+		require.Error(t, err, test.name)
+	}
+
+	payments, err := ctx.db.FetchRecurringPayments()
+	require.NoError(t, err)
+	require.Empty(t, payments)
+}
@@ -0,0 +1,471 @@
+package localchans
+
+import (
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/ticker"
+)
+
+// FeeBounds are the bounds within which the fee autopilot keeps the fees of a
+// channel.
+type FeeBounds struct {
+	// MinBaseFee is the lowest base fee the autopilot will set.
+	MinBaseFee lnwire.MilliSatoshi
+
+	// MaxBaseFee is the highest base fee the autopilot will set.
+	MaxBaseFee lnwire.MilliSatoshi
+
+	// MinFeeRate is the lowest proportional fee, in parts per million,
+	// the autopilot will set.
+	MinFeeRate uint32
+
+	// MaxFeeRate is the highest proportional fee, in parts per million,
+	// the autopilot will set.
+	MaxFeeRate uint32
+}
+
+// clamp returns the given fees limited to the bounds.
+func (b FeeBounds) clamp(baseFee lnwire.MilliSatoshi,
+	feeRate uint32) (lnwire.MilliSatoshi, uint32) {
+
+	switch {
+	case baseFee < b.MinBaseFee:
+		baseFee = b.MinBaseFee
+	case baseFee > b.MaxBaseFee:
+		baseFee = b.MaxBaseFee
+	}
+
+	switch {
+	case feeRate < b.MinFeeRate:
+		feeRate = b.MinFeeRate
+	case feeRate > b.MaxFeeRate:
+		feeRate = b.MaxFeeRate
+	}
+
+	return baseFee, feeRate
+}
+
+// FeeAutopilotConfig holds the configuration of the fee autopilot.
+type FeeAutopilotConfig struct {
+	// ForAllOutgoingChannels is used to iterate over all our local
+	// channels and their current policies.
+	ForAllOutgoingChannels func(cb func(*channeldb.ChannelEdgeInfo,
+		*channeldb.ChannelEdgePolicy) error) error
+
+	// FetchChannel is used to query the local balance of a channel.
+	FetchChannel func(chanPoint wire.OutPoint) (*channeldb.OpenChannel,
+		error)
+
+	// QueryForwardingLog is used to query the forwarding log for the
+	// volume that was recently forwarded over our channels.
+	QueryForwardingLog func(q channeldb.ForwardingEventQuery) (
+		channeldb.ForwardingLogTimeSlice, error)
+
+	// UpdatePolicies applies new policies to our channels. This is
+	// expected to be backed by the Manager so that the new policies are
+	// persisted, applied to the links and broadcast to the network.
+	UpdatePolicies func(map[wire.OutPoint]routing.ChannelPolicy) error
+
+	// Ticker signals the autopilot to evaluate the fees of our channels.
+	Ticker ticker.Ticker
+
+	// Clock is the time source of the autopilot.
+	Clock clock.Clock
+
+	// DryRun, if set, causes the autopilot to only report the fee changes
+	// it would make rather than applying them.
+	DryRun bool
+
+	// Bounds are the default bounds for the fees of our channels.
+	Bounds FeeBounds
+
+	// ChannelBounds overrides the default fee bounds for specific
+	// channels.
+	ChannelBounds map[lnwire.ShortChannelID]FeeBounds
+
+	// SkipChannels is the set of channels whose fees are never touched by
+	// the autopilot.
+	SkipChannels map[lnwire.ShortChannelID]struct{}
+
+	// BaseFeeStep is the amount by which the base fee is raised or
+	// lowered in a single adjustment.
+	BaseFeeStep lnwire.MilliSatoshi
+
+	// FeeRateStep is the amount, in parts per million, by which the
+	// proportional fee is raised or lowered in a single adjustment.
+	FeeRateStep uint32
+
+	// LowLiquidityRatio is the ratio of local balance to capacity below
+	// which a channel is considered to be depleted, causing its fees to
+	// be raised.
+	LowLiquidityRatio float64
+
+	// HighLiquidityRatio is the ratio of local balance to capacity above
+	// which a channel is considered to have excess liquidity, causing its
+	// fees to be lowered.
+	HighLiquidityRatio float64
+
+	// ForwardingWindow is the period over which the forwarding volume of
+	// a channel is considered. Channels with balanced liquidity that did
+	// not forward anything within this window have their fees lowered.
+	ForwardingWindow time.Duration
+
+	// MinUpdateInterval is the minimum time between two fee updates of
+	// the same channel. This prevents the autopilot from flooding the
+	// network with channel updates.
+	MinUpdateInterval time.Duration
+}
+
+// FeeProposal is a fee change proposed by the autopilot for a single channel.
+type FeeProposal struct {
+	// ChanPoint is the funding outpoint of the channel.
+	ChanPoint wire.OutPoint
+
+	// ChanID is the short channel id of the channel.
+	ChanID lnwire.ShortChannelID
+
+	// LocalRatio is the ratio of local balance to capacity of the
+	// channel.
+	LocalRatio float64
+
+	// ForwardedAmt is the amount forwarded over the channel within the
+	// forwarding window.
+	ForwardedAmt lnwire.MilliSatoshi
+
+	// OldBaseFee is the current base fee of the channel.
+	OldBaseFee lnwire.MilliSatoshi
+
+	// OldFeeRate is the current proportional fee of the channel.
+	OldFeeRate uint32
+
+	// NewBaseFee is the proposed base fee of the channel.
+	NewBaseFee lnwire.MilliSatoshi
+
+	// NewFeeRate is the proposed proportional fee of the channel.
+	NewFeeRate uint32
+
+	// policy is the full channel policy that carries the proposed fees.
+	policy routing.ChannelPolicy
+}
+
+// FeeProposalRound holds the fee changes the autopilot proposed in a single
+// evaluation of our channels.
+type FeeProposalRound struct {
+	// Timestamp is the time of the evaluation.
+	Timestamp time.Time
+
+	// Applied indicates whether the proposed fees were applied to our
+	// channels. This is false in dry run mode.
+	Applied bool
+
+	// Proposals are the proposed fee changes.
+	Proposals []FeeProposal
+}
+
+// FeeAutopilot periodically adjusts the fees of our channels based on their
+// liquidity and recent forwarding volume. Fees of channels that are running
+// out of outbound liquidity are raised, while fees of channels with excess
+// outbound liquidity, or that haven't forwarded anything recently, are
+// lowered.
+type FeeAutopilot struct {
+	started sync.Once
+	stopped sync.Once
+
+	cfg *FeeAutopilotConfig
+
+	// lastUpdate tracks when we last updated the fees of each channel.
+	lastUpdate map[lnwire.ShortChannelID]time.Time
+
+	// lastRound holds the fee changes the autopilot proposed in its most
+	// recent evaluation. It is protected by roundMtx.
+	lastRound *FeeProposalRound
+	roundMtx  sync.Mutex
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// NewFeeAutopilot creates a new fee autopilot.
+func NewFeeAutopilot(cfg *FeeAutopilotConfig) *FeeAutopilot {
+	return &FeeAutopilot{
+		cfg:        cfg,
+		lastUpdate: make(map[lnwire.ShortChannelID]time.Time),
+		quit:       make(chan struct{}),
+	}
+}
+
+// Start starts the fee autopilot.
+func (f *FeeAutopilot) Start() error {
+	f.started.Do(func() {
+		log.Infof("Fee autopilot starting (dry run: %v)", f.cfg.DryRun)
+
+		f.cfg.Ticker.Resume()
+
+		f.wg.Add(1)
+		go f.run()
+	})
+
+	return nil
+}
+
+// Stop stops the fee autopilot.
+func (f *FeeAutopilot) Stop() error {
+	f.stopped.Do(func() {
+		log.Info("Fee autopilot shutting down")
+
+		close(f.quit)
+		f.wg.Wait()
+
+		f.cfg.Ticker.Stop()
+	})
+
+	return nil
+}
+
+// run is the main loop of the fee autopilot.
+//
+// NOTE: This MUST be run as a goroutine.
+func (f *FeeAutopilot) run() {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case <-f.cfg.Ticker.Ticks():
+			if err := f.adjustFees(); err != nil {
+				log.Errorf("Unable to adjust channel fees: %v",
+					err)
+			}
+
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// adjustFees evaluates the fees of all our channels and applies the proposed
+// changes. In dry run mode, the proposed changes are only logged and kept so
+// that they can be queried through LastProposals.
+func (f *FeeAutopilot) adjustFees() error {
+	proposals, err := f.Propose()
+	if err != nil {
+		return err
+	}
+
+	round := &FeeProposalRound{
+		Timestamp: f.cfg.Clock.Now(),
+		Proposals: proposals,
+	}
+
+	if len(proposals) == 0 {
+		log.Debugf("Fee autopilot has no fee changes to propose")
+		f.setLastRound(round)
+
+		return nil
+	}
+
+	policies := make(map[wire.OutPoint]routing.ChannelPolicy)
+	for _, p := range proposals {
+		log.Infof("Fee autopilot proposes fees for channel %v "+
+			"(local ratio %.2f, forwarded %v): base fee %v -> %v, "+
+			"fee rate %v -> %v ppm", p.ChanPoint, p.LocalRatio,
+			p.ForwardedAmt, p.OldBaseFee, p.NewBaseFee,
+			p.OldFeeRate, p.NewFeeRate)
+
+		policies[p.ChanPoint] = p.policy
+	}
+
+	if f.cfg.DryRun {
+		f.setLastRound(round)
+		return nil
+	}
+
+	if err := f.cfg.UpdatePolicies(policies); err != nil {
+		return err
+	}
+
+	for _, p := range proposals {
+		f.lastUpdate[p.ChanID] = round.Timestamp
+	}
+
+	round.Applied = true
+	f.setLastRound(round)
+
+	return nil
+}
+
+// setLastRound replaces the most recent round of proposals.
+func (f *FeeAutopilot) setLastRound(round *FeeProposalRound) {
+	f.roundMtx.Lock()
+	defer f.roundMtx.Unlock()
+
+	f.lastRound = round
+}
+
+// LastProposals returns the fee changes the autopilot proposed in its most
+// recent evaluation of our channels, or nil if it hasn't evaluated them yet.
+func (f *FeeAutopilot) LastProposals() *FeeProposalRound {
+	f.roundMtx.Lock()
+	defer f.roundMtx.Unlock()
+
+	return f.lastRound
+}
+
+// forwardedAmounts returns the amount forwarded over each of our channels
+// within the forwarding window.
+func (f *FeeAutopilot) forwardedAmounts() (
+	map[lnwire.ShortChannelID]lnwire.MilliSatoshi, error) {
+
+	now := f.cfg.Clock.Now()
+	query := channeldb.ForwardingEventQuery{
+		StartTime:    now.Add(-f.cfg.ForwardingWindow),
+		EndTime:      now,
+		NumMaxEvents: channeldb.MaxResponseEvents,
+	}
+
+	amounts := make(map[lnwire.ShortChannelID]lnwire.MilliSatoshi)
+	for {
+		timeSlice, err := f.cfg.QueryForwardingLog(query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range timeSlice.ForwardingEvents {
+			amounts[event.OutgoingChanID] += event.AmtOut
+		}
+
+		if len(timeSlice.ForwardingEvents) < int(query.NumMaxEvents) {
+			return amounts, nil
+		}
+
+		query.IndexOffset = timeSlice.LastIndexOffset
+	}
+}
+
+// Propose returns the fee changes the autopilot would currently make.
+// Channels that were updated less than the minimum update interval ago are
+// not included.
+func (f *FeeAutopilot) Propose() ([]FeeProposal, error) {
+	forwarded, err := f.forwardedAmounts()
+	if err != nil {
+		return nil, err
+	}
+
+	now := f.cfg.Clock.Now()
+
+	var proposals []FeeProposal
+	err = f.cfg.ForAllOutgoingChannels(func(info *channeldb.ChannelEdgeInfo,
+		edge *channeldb.ChannelEdgePolicy) error {
+
+		chanID := lnwire.NewShortChanIDFromInt(info.ChannelID)
+		if _, ok := f.cfg.SkipChannels[chanID]; ok {
+			return nil
+		}
+
+		lastUpdate, ok := f.lastUpdate[chanID]
+		if ok && now.Sub(lastUpdate) < f.cfg.MinUpdateInterval {
+			return nil
+		}
+
+		channel, err := f.cfg.FetchChannel(info.ChannelPoint)
+		if err != nil {
+			log.Warnf("Unable to fetch channel %v: %v",
+				info.ChannelPoint, err)
+			return nil
+		}
+
+		capacity := lnwire.NewMSatFromSatoshis(info.Capacity)
+		if capacity == 0 {
+			return nil
+		}
+		localBalance := channel.LocalCommitment.LocalBalance
+		localRatio := float64(localBalance) / float64(capacity)
+
+		proposal := f.propose(
+			chanID, edge, localRatio, forwarded[chanID],
+		)
+		if proposal == nil {
+			return nil
+		}
+		proposal.ChanPoint = info.ChannelPoint
+
+		proposals = append(proposals, *proposal)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return proposals, nil
+}
+
+// propose determines the new fees of a single channel. It returns nil if the
+// fees of the channel should stay unchanged.
+func (f *FeeAutopilot) propose(chanID lnwire.ShortChannelID,
+	edge *channeldb.ChannelEdgePolicy, localRatio float64,
+	forwardedAmt lnwire.MilliSatoshi) *FeeProposal {
+
+	bounds := f.cfg.Bounds
+	if chanBounds, ok := f.cfg.ChannelBounds[chanID]; ok {
+		bounds = chanBounds
+	}
+
+	oldBaseFee := edge.FeeBaseMSat
+	oldFeeRate := uint32(edge.FeeProportionalMillionths)
+	baseFee, feeRate := oldBaseFee, oldFeeRate
+
+	switch {
+	// The channel is running out of outbound liquidity, so we'll make it
+	// more expensive to route through it.
+	case localRatio < f.cfg.LowLiquidityRatio:
+		baseFee += f.cfg.BaseFeeStep
+		feeRate += f.cfg.FeeRateStep
+
+	// The channel has either more outbound liquidity than we'd like, or
+	// it hasn't been used recently, so we'll make it cheaper to route
+	// through it.
+	case localRatio > f.cfg.HighLiquidityRatio || forwardedAmt == 0:
+		if baseFee > f.cfg.BaseFeeStep {
+			baseFee -= f.cfg.BaseFeeStep
+		} else {
+			baseFee = 0
+		}
+
+		if feeRate > f.cfg.FeeRateStep {
+			feeRate -= f.cfg.FeeRateStep
+		} else {
+			feeRate = 0
+		}
+	}
+
+	baseFee, feeRate = bounds.clamp(baseFee, feeRate)
+	if baseFee == oldBaseFee && feeRate == oldFeeRate {
+		return nil
+	}
+
+	minHTLC := edge.MinHTLC
+	return &FeeProposal{
+		ChanID:       chanID,
+		LocalRatio:   localRatio,
+		ForwardedAmt: forwardedAmt,
+		OldBaseFee:   oldBaseFee,
+		OldFeeRate:   oldFeeRate,
+		NewBaseFee:   baseFee,
+		NewFeeRate:   feeRate,
+		policy: routing.ChannelPolicy{
+			FeeSchema: routing.FeeSchema{
+				BaseFee: baseFee,
+				FeeRate: feeRate,
+			},
+			TimeLockDelta: uint32(edge.TimeLockDelta),
+			MaxHTLC:       edge.MaxHTLC,
+			MinHTLC:       &minHTLC,
+		},
+	}
+}
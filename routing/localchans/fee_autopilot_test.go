@@ -0,0 +1,226 @@
+package localchans
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/ticker"
+	"github.com/stretchr/testify/require"
+)
+
+// testAutopilotChannel describes a channel used in the fee autopilot tests.
+type testAutopilotChannel struct {
+	chanID       uint64
+	localBalance btcutil.Amount
+	forwarded    lnwire.MilliSatoshi
+}
+
+// TestFeeAutopilot tests that the fee autopilot raises and lowers fees based
+// on the liquidity and forwarding volume of our channels, respects the
+// configured bounds and rate limits updates.
+func TestFeeAutopilot(t *testing.T) {
+	const (
+		capacity = btcutil.Amount(1000000)
+		baseFee  = lnwire.MilliSatoshi(1000)
+		feeRate  = 100
+	)
+
+	channels := []testAutopilotChannel{
+		// A depleted channel, which should become more expensive.
+		{chanID: 1, localBalance: 100000, forwarded: 5000},
+
+		// A channel with excess liquidity, which should become
+		// cheaper.
+		{chanID: 2, localBalance: 900000, forwarded: 5000},
+
+		// A balanced channel that forwards, which should be left
+		// alone.
+		{chanID: 3, localBalance: 500000, forwarded: 5000},
+
+		// A balanced channel without forwards, which should become
+		// cheaper.
+		{chanID: 4, localBalance: 500000},
+
+		// A depleted channel whose fee rate is pinned.
+		{chanID: 5, localBalance: 100000},
+
+		// A depleted channel that is skipped.
+		{chanID: 6, localBalance: 100000},
+	}
+
+	chanPoint := func(chanID uint64) wire.OutPoint {
+		return wire.OutPoint{
+			Hash:  chainhash.Hash{byte(chanID)},
+			Index: uint32(chanID),
+		}
+	}
+
+	balances := make(map[wire.OutPoint]btcutil.Amount)
+	var fwdEvents []channeldb.ForwardingEvent
+	for _, c := range channels {
+		balances[chanPoint(c.chanID)] = c.localBalance
+
+		if c.forwarded > 0 {
+			fwdEvents = append(fwdEvents, channeldb.ForwardingEvent{
+				OutgoingChanID: lnwire.NewShortChanIDFromInt(
+					c.chanID,
+				),
+				AmtOut: c.forwarded,
+			})
+		}
+	}
+
+	forAllOutgoingChannels := func(cb func(*channeldb.ChannelEdgeInfo,
+		*channeldb.ChannelEdgePolicy) error) error {
+
+		for _, c := range channels {
+			info := &channeldb.ChannelEdgeInfo{
+				ChannelID:    c.chanID,
+				ChannelPoint: chanPoint(c.chanID),
+				Capacity:     capacity,
+			}
+			edge := &channeldb.ChannelEdgePolicy{
+				FeeBaseMSat:               baseFee,
+				FeeProportionalMillionths: feeRate,
+				TimeLockDelta:             40,
+				MinHTLC:                   1000,
+				MaxHTLC:                   500000000,
+			}
+
+			if err := cb(info, edge); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	fetchChannel := func(op wire.OutPoint) (*channeldb.OpenChannel,
+		error) {
+
+		return &channeldb.OpenChannel{
+			LocalCommitment: channeldb.ChannelCommitment{
+				LocalBalance: lnwire.NewMSatFromSatoshis(
+					balances[op],
+				),
+			},
+		}, nil
+	}
+
+	queryForwardingLog := func(q channeldb.ForwardingEventQuery) (
+		channeldb.ForwardingLogTimeSlice, error) {
+
+		return channeldb.ForwardingLogTimeSlice{
+			ForwardingEventQuery: q,
+			ForwardingEvents:     fwdEvents,
+			LastIndexOffset:      uint32(len(fwdEvents)),
+		}, nil
+	}
+
+	var applied map[wire.OutPoint]routing.ChannelPolicy
+	updatePolicies := func(
+		policies map[wire.OutPoint]routing.ChannelPolicy) error {
+
+		applied = policies
+		return nil
+	}
+
+	testClock := clock.NewTestClock(time.Unix(1000000, 0))
+	autopilot := NewFeeAutopilot(&FeeAutopilotConfig{
+		ForAllOutgoingChannels: forAllOutgoingChannels,
+		FetchChannel:           fetchChannel,
+		QueryForwardingLog:     queryForwardingLog,
+		UpdatePolicies:         updatePolicies,
+		Ticker:                 ticker.NewForce(time.Hour),
+		Clock:                  testClock,
+		DryRun:                 true,
+		Bounds: FeeBounds{
+			MinBaseFee: 0,
+			MaxBaseFee: 2000,
+			MinFeeRate: 10,
+			MaxFeeRate: 1000,
+		},
+		ChannelBounds: map[lnwire.ShortChannelID]FeeBounds{
+			lnwire.NewShortChanIDFromInt(5): {
+				MinBaseFee: baseFee,
+				MaxBaseFee: baseFee,
+				MinFeeRate: feeRate,
+				MaxFeeRate: feeRate,
+			},
+		},
+		SkipChannels: map[lnwire.ShortChannelID]struct{}{
+			lnwire.NewShortChanIDFromInt(6): {},
+		},
+		BaseFeeStep:        100,
+		FeeRateStep:        25,
+		LowLiquidityRatio:  0.2,
+		HighLiquidityRatio: 0.8,
+		ForwardingWindow:   24 * time.Hour,
+		MinUpdateInterval:  6 * time.Hour,
+	})
+
+	// Only the depleted channel, the channel with excess liquidity and the
+	// idle channel should have new fees proposed.
+	proposals, err := autopilot.Propose()
+	require.NoError(t, err)
+	require.Len(t, proposals, 3)
+
+	expected := map[uint64][2]uint64{
+		1: {1100, 125},
+		2: {900, 75},
+		4: {900, 75},
+	}
+	for _, p := range proposals {
+		fees, ok := expected[p.ChanID.ToUint64()]
+		require.True(t, ok, "unexpected proposal for %v", p.ChanID)
+		require.EqualValues(t, fees[0], p.NewBaseFee)
+		require.EqualValues(t, fees[1], p.NewFeeRate)
+		require.Equal(t, chanPoint(p.ChanID.ToUint64()), p.ChanPoint)
+	}
+
+	// Before the first evaluation, there are no proposals to report.
+	require.Nil(t, autopilot.LastProposals())
+
+	// In dry run mode, nothing should be applied, but the proposals should
+	// be reported.
+	require.NoError(t, autopilot.adjustFees())
+	require.Nil(t, applied)
+
+	round := autopilot.LastProposals()
+	require.NotNil(t, round)
+	require.False(t, round.Applied)
+	require.Equal(t, testClock.Now(), round.Timestamp)
+	require.ElementsMatch(t, proposals, round.Proposals)
+
+	// Once we leave dry run mode, the proposals should be applied while
+	// keeping the other policy parameters of the channel.
+	autopilot.cfg.DryRun = false
+	require.NoError(t, autopilot.adjustFees())
+	require.Len(t, applied, 3)
+	require.True(t, autopilot.LastProposals().Applied)
+
+	policy := applied[chanPoint(1)]
+	require.EqualValues(t, 1100, policy.BaseFee)
+	require.EqualValues(t, 125, policy.FeeRate)
+	require.EqualValues(t, 40, policy.TimeLockDelta)
+	require.EqualValues(t, 500000000, policy.MaxHTLC)
+	require.EqualValues(t, 1000, *policy.MinHTLC)
+
+	// The updated channels shouldn't be touched again until the minimum
+	// update interval has passed.
+	proposals, err = autopilot.Propose()
+	require.NoError(t, err)
+	require.Empty(t, proposals)
+
+	testClock.SetTime(testClock.Now().Add(6 * time.Hour))
+	proposals, err = autopilot.Propose()
+	require.NoError(t, err)
+	require.Len(t, proposals, 3)
+}
@@ -2,6 +2,8 @@ package localchans
 
 import (
 	"github.com/btcsuite/btclog"
+	"github.com/lightningnetwork/lnd/build"
+	"github.com/lightningnetwork/lnd/routing"
 )
 
 // log is a logger that is initialized with no output filters.  This
@@ -9,6 +11,11 @@ import (
 // requests it.
 var log btclog.Logger
 
+// The default amount of logging is none.
+func init() {
+	UseLogger(build.NewSubLogger(routing.Subsystem, nil))
+}
+
 // UseLogger uses a specified Logger to output package logging info. This
 // function is called from the parent package htlcswitch logger initialization.
 func UseLogger(logger btclog.Logger) {
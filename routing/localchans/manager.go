@@ -47,9 +47,6 @@ type Manager struct {
 func (r *Manager) UpdatePolicy(newSchema routing.ChannelPolicy,
 	chanPoints ...wire.OutPoint) error {
 
-	r.policyUpdateLock.Lock()
-	defer r.policyUpdateLock.Unlock()
-
 	// First, we'll construct a set of all the channels that need to be
 	// updated.
 	chansToUpdate := make(map[wire.OutPoint]struct{})
@@ -59,25 +56,65 @@ func (r *Manager) UpdatePolicy(newSchema routing.ChannelPolicy,
 
 	haveChanFilter := len(chansToUpdate) != 0
 
+	return r.updatePolicies(func(
+		chanPoint wire.OutPoint) *routing.ChannelPolicy {
+
+		// If we have a channel filter, and this channel isn't a part
+		// of it, then we'll skip it.
+		_, ok := chansToUpdate[chanPoint]
+		if !ok && haveChanFilter {
+			return nil
+		}
+
+		return &newSchema
+	})
+}
+
+// UpdatePolicies updates each of the given channels with its own policy, on
+// disk and in the active links. Channels that are not part of the map are left
+// untouched.
+func (r *Manager) UpdatePolicies(
+	policies map[wire.OutPoint]routing.ChannelPolicy) error {
+
+	return r.updatePolicies(func(
+		chanPoint wire.OutPoint) *routing.ChannelPolicy {
+
+		policy, ok := policies[chanPoint]
+		if !ok {
+			return nil
+		}
+
+		return &policy
+	})
+}
+
+// updatePolicies applies the policy returned by policyFor to each of our
+// outgoing channels. If policyFor returns nil for a channel, the channel is
+// skipped.
+func (r *Manager) updatePolicies(
+	policyFor func(wire.OutPoint) *routing.ChannelPolicy) error {
+
+	r.policyUpdateLock.Lock()
+	defer r.policyUpdateLock.Unlock()
+
 	var edgesToUpdate []discovery.EdgeWithInfo
 	policiesToUpdate := make(map[wire.OutPoint]htlcswitch.ForwardingPolicy)
 
-	// Next, we'll loop over all the outgoing channels the router knows of.
-	// If we have a filter then we'll only collected those channels,
-	// otherwise we'll collect them all.
+	// Next, we'll loop over all the outgoing channels the router knows of
+	// and collect the ones that have a new policy.
 	err := r.ForAllOutgoingChannels(func(
 		info *channeldb.ChannelEdgeInfo,
 		edge *channeldb.ChannelEdgePolicy) error {
 
-		// If we have a channel filter, and this channel isn't a part
-		// of it, then we'll skip it.
-		_, ok := chansToUpdate[info.ChannelPoint]
-		if !ok && haveChanFilter {
+		// If there is no new policy for this channel, then we'll skip
+		// it.
+		newSchema := policyFor(info.ChannelPoint)
+		if newSchema == nil {
 			return nil
 		}
 
 		// Apply the new policy to the edge.
-		err := r.updateEdge(info.ChannelPoint, edge, newSchema)
+		err := r.updateEdge(info.ChannelPoint, edge, *newSchema)
 		if err != nil {
 			log.Warnf("Cannot update policy for %v: %v\n",
 				info.ChannelPoint, err,
@@ -89,6 +89,7 @@ type finalHopParams struct {
 	cltvDelta   uint16
 	records     record.CustomSet
 	paymentAddr *[32]byte
+	metadata    []byte
 }
 
 // newRoute constructs a route using the provided path and final hop constraints.
@@ -138,6 +139,7 @@ func newRoute(sourceVertex route.Vertex,
 			tlvPayload       bool
 			customRecords    record.CustomSet
 			mpp              *record.MPP
+			metadata         []byte
 		)
 
 		// Define a helper function that checks this edge's feature
@@ -185,6 +187,14 @@ func newRoute(sourceVertex route.Vertex,
 			}
 			customRecords = finalHop.records
 
+			// Payment metadata can only be attached if the
+			// receiver supports TLV as well.
+			if !tlvPayload && finalHop.metadata != nil {
+				return nil, errors.New("cannot attach " +
+					"payment metadata")
+			}
+			metadata = finalHop.metadata
+
 			// If we're attaching a payment addr but the receiver
 			// doesn't support both TLV and payment addrs, fail.
 			payAddr := supports(lnwire.PaymentAddrOptional)
@@ -232,6 +242,7 @@ func newRoute(sourceVertex route.Vertex,
 			LegacyPayload:    !tlvPayload,
 			CustomRecords:    customRecords,
 			MPP:              mpp,
+			Metadata:         metadata,
 		}
 
 		hops = append([]*route.Hop{currentHop}, hops...)
@@ -330,6 +341,10 @@ type RestrictParams struct {
 	// mitigate probing vectors and payment sniping attacks on overpaid
 	// invoices.
 	PaymentAddr *[32]byte
+
+	// Metadata is additional data that is sent along with the payment to
+	// the payee.
+	Metadata []byte
 }
 
 // PathFindingConfig defines global parameters that control the trade-off in
@@ -462,7 +477,7 @@ func findPath(g *graphParams, r *RestrictParams, cfg *PathFindingConfig,
 
 	// If the caller needs to send custom records, check that our
 	// destination feature vector supports TLV.
-	if len(r.DestCustomRecords) > 0 &&
+	if (len(r.DestCustomRecords) > 0 || r.Metadata != nil) &&
 		!features.HasFeature(lnwire.TLVOnionPayloadOptional) {
 
 		return nil, errNoTlvPayload
@@ -549,7 +564,8 @@ func findPath(g *graphParams, r *RestrictParams, cfg *PathFindingConfig,
 		LegacyPayload: !features.HasFeature(
 			lnwire.TLVOnionPayloadOptional,
 		),
-		MPP: mpp,
+		MPP:      mpp,
+		Metadata: r.Metadata,
 	}
 
 	// We can't always assume that the end destination is publicly
@@ -38,6 +38,12 @@ var (
 	// ErrAMPMissingMPP is returned when the caller tries to attach an AMP
 	// record but no MPP record is presented for the final hop.
 	ErrAMPMissingMPP = errors.New("cannot send AMP without MPP record")
+
+	// ErrIntermediateMetadataHop is returned when a hop tries to deliver
+	// payment metadata to an intermediate hop, only final hops can receive
+	// payment metadata.
+	ErrIntermediateMetadataHop = errors.New("cannot send payment " +
+		"metadata to intermediate")
 )
 
 // Vertex is a simple alias for the serialization of a compressed Bitcoin
@@ -123,6 +129,10 @@ type Hop struct {
 	// should be included in the forwarding instructions for this node.
 	CustomRecords record.CustomSet
 
+	// Metadata is additional data that is sent along with the payment to
+	// the payee. This field should only be set for the final hop.
+	Metadata []byte
+
 	// LegacyPayload if true, then this signals that this node doesn't
 	// understand the new TLV payload, so we must instead use the legacy
 	// payload.
@@ -205,6 +215,16 @@ func (h *Hop) PackHopPayload(w io.Writer, nextChanID uint64) error {
 		}
 	}
 
+	// If payment metadata is destined for this hop, ensure that we only
+	// ever attach it to the final hop.
+	if h.Metadata != nil {
+		if nextChanID != 0 {
+			return ErrIntermediateMetadataHop
+		}
+
+		records = append(records, record.NewMetadataRecord(&h.Metadata))
+	}
+
 	// Append any custom types destined for this hop.
 	tlvRecords := tlv.MapToRecords(h.CustomRecords)
 	records = append(records, tlvRecords...)
@@ -259,6 +279,11 @@ func (h *Hop) PayloadSize(nextChanID uint64) uint64 {
 		addRecord(record.AMPOnionType, h.AMP.PayloadSize())
 	}
 
+	// Add metadata if present.
+	if h.Metadata != nil {
+		addRecord(record.MetadataOnionType, uint64(len(h.Metadata)))
+	}
+
 	// Add custom records.
 	for k, v := range h.CustomRecords {
 		addRecord(tlv.Type(k), uint64(len(v)))
@@ -159,6 +159,36 @@ func TestAMPHop(t *testing.T) {
 	}
 }
 
+// TestMetadataHop asserts that a Hop will encode payment metadata to final
+// nodes, and fail when trying to send it to intermediaries.
+func TestMetadataHop(t *testing.T) {
+	t.Parallel()
+
+	hop := Hop{
+		ChannelID:        1,
+		OutgoingTimeLock: 44,
+		AmtToForward:     testAmt,
+		LegacyPayload:    false,
+		Metadata:         []byte{1, 2, 3},
+	}
+
+	// Encoding metadata to an intermediate hop should result in a
+	// failure.
+	var b bytes.Buffer
+	err := hop.PackHopPayload(&b, 2)
+	if err != ErrIntermediateMetadataHop {
+		t.Fatalf("expected err: %v, got: %v",
+			ErrIntermediateMetadataHop, err)
+	}
+
+	// Encoding metadata to a final hop should be successful.
+	b.Reset()
+	err = hop.PackHopPayload(&b, 0)
+	if err != nil {
+		t.Fatalf("expected err: %v, got: %v", nil, err)
+	}
+}
+
 // TestPayloadSize tests the payload size calculation that is provided by Hop
 // structs.
 func TestPayloadSize(t *testing.T) {
@@ -182,6 +212,7 @@ func TestPayloadSize(t *testing.T) {
 			OutgoingTimeLock: 700000,
 			MPP:              record.NewMPP(500, [32]byte{}),
 			AMP:              record.NewAMP([32]byte{}, [32]byte{}, 8),
+			Metadata:         []byte{6, 7, 8, 9},
 			CustomRecords: map[uint64][]byte{
 				100000:  {1, 2, 3},
 				1000000: {4, 5},
@@ -1849,10 +1849,22 @@ type LightningPayment struct {
 	// invoice to prevent probing of the destination.
 	PaymentAddr *[32]byte
 
+	// Metadata is additional data that is sent along with the payment to
+	// the payee. It is taken from the receiver's invoice.
+	Metadata []byte
+
 	// PaymentRequest is an optional payment request that this payment is
 	// attempting to complete.
 	PaymentRequest []byte
 
+	// Description is the description of the invoice that this payment is
+	// attempting to complete, if any.
+	Description string
+
+	// Label is an optional user-supplied label that is stored with the
+	// payment, so that it can be looked up later on.
+	Label string
+
 	// DestCustomRecords are TLV records that are to be sent to the final
 	// hop in the new onion payload format. If the destination does not
 	// understand this new onion payload format, then the payment will
@@ -2012,6 +2024,10 @@ func (r *ChannelRouter) preparePayment(payment *LightningPayment) (
 		Value:             payment.Amount,
 		CreationTime:      r.cfg.Clock.Now(),
 		PaymentRequest:    payment.PaymentRequest,
+		Destination:       payment.Target,
+		PaymentAddr:       payment.PaymentAddr,
+		Description:       payment.Description,
+		Label:             payment.Label,
 	}
 
 	// Create a new ShardTracker that we'll use during the life cycle of
@@ -2079,6 +2095,11 @@ func (r *ChannelRouter) SendToRoute(htlcHash lntypes.Hash, rt *route.Route) (
 		Value:             amt,
 		CreationTime:      r.cfg.Clock.Now(),
 		PaymentRequest:    nil,
+		Destination:       finalHop.PubKeyBytes,
+	}
+	if mpp != nil {
+		paymentAddr := mpp.PaymentAddr()
+		info.PaymentAddr = &paymentAddr
 	}
 
 	err := r.cfg.Control.InitPayment(paymentIdentifier, info)
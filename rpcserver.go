@@ -29,6 +29,7 @@ import (
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
 	"github.com/davecgh/go-spew/spew"
 	proxy "github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/lightningnetwork/lnd/accounting"
 	"github.com/lightningnetwork/lnd/autopilot"
 	"github.com/lightningnetwork/lnd/build"
 	"github.com/lightningnetwork/lnd/chainreg"
@@ -37,6 +38,7 @@ import (
 	"github.com/lightningnetwork/lnd/chanfitness"
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/channelnotifier"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/contractcourt"
 	"github.com/lightningnetwork/lnd/discovery"
 	"github.com/lightningnetwork/lnd/feature"
@@ -53,6 +55,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
 	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnurl"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/btcwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
@@ -68,9 +71,11 @@ import (
 	"github.com/lightningnetwork/lnd/rpcperms"
 	"github.com/lightningnetwork/lnd/signal"
 	"github.com/lightningnetwork/lnd/sweep"
+	"github.com/lightningnetwork/lnd/ticker"
 	"github.com/lightningnetwork/lnd/watchtower"
 	"github.com/lightningnetwork/lnd/zpay32"
 	"github.com/tv42/zbase32"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -257,6 +262,41 @@ func calculateFeeRate(satPerByte, satPerVByte uint64, targetConf uint32,
 
 }
 
+// anchorFeePreference returns the fee preference for sweeping the anchors of a
+// force closed channel from the fee related fields of a close request. Unlike
+// calculateFeeRate, a confirmation target isn't resolved into a fee rate, so
+// that the sweeper can re-estimate it as blocks come in.
+func anchorFeePreference(satPerByte, satPerVByte uint64,
+	targetConf uint32) (sweep.FeePreference, error) {
+
+	var feePref sweep.FeePreference
+
+	// We only allow using either the deprecated field or the new field.
+	if satPerByte != 0 && satPerVByte != 0 {
+		return feePref, fmt.Errorf("either SatPerByte or " +
+			"SatPerVByte should be set, but not both")
+	}
+	if satPerByte != 0 {
+		satPerVByte = satPerByte
+	}
+
+	switch {
+	case targetConf != 0 && satPerVByte != 0:
+		return feePref, fmt.Errorf("either TargetConf or " +
+			"SatPerVByte should be set, but not both")
+
+	case targetConf != 0:
+		feePref.ConfTarget = targetConf
+
+	default:
+		feePref.FeeRate = chainfee.SatPerKVByte(
+			satPerVByte * 1000,
+		).FeePerKWeight()
+	}
+
+	return feePref, nil
+}
+
 // MainRPCServerPermissions returns a mapping of the main RPC server calls to
 // the permissions they require.
 func MainRPCServerPermissions() map[string][]bakery.Op {
@@ -300,6 +340,13 @@ func MainRPCServerPermissions() map[string][]bakery.Op {
 			Entity: "offchain",
 			Action: "write",
 		}},
+		"/lnrpc.Lightning/BatchOpenChannel": {{
+			Entity: "onchain",
+			Action: "write",
+		}, {
+			Entity: "offchain",
+			Action: "write",
+		}},
 		"/lnrpc.Lightning/OpenChannelSync": {{
 			Entity: "onchain",
 			Action: "write",
@@ -390,6 +437,18 @@ func MainRPCServerPermissions() map[string][]bakery.Op {
 			Entity: "invoices",
 			Action: "read",
 		}},
+		"/lnrpc.Lightning/AddLnurlUser": {{
+			Entity: "invoices",
+			Action: "write",
+		}},
+		"/lnrpc.Lightning/ListLnurlUsers": {{
+			Entity: "invoices",
+			Action: "read",
+		}},
+		"/lnrpc.Lightning/DeleteLnurlUser": {{
+			Entity: "invoices",
+			Action: "write",
+		}},
 		"/lnrpc.Lightning/SubscribeTransactions": {{
 			Entity: "onchain",
 			Action: "read",
@@ -438,6 +497,26 @@ func MainRPCServerPermissions() map[string][]bakery.Op {
 			Entity: "offchain",
 			Action: "write",
 		}},
+		"/lnrpc.Lightning/DeletePayment": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
+		"/lnrpc.Lightning/AddRecurringPayment": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
+		"/lnrpc.Lightning/ListRecurringPayments": {{
+			Entity: "offchain",
+			Action: "read",
+		}},
+		"/lnrpc.Lightning/RecurringPaymentHistory": {{
+			Entity: "offchain",
+			Action: "read",
+		}},
+		"/lnrpc.Lightning/DeleteRecurringPayment": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
 		"/lnrpc.Lightning/DebugLevel": {{
 			Entity: "info",
 			Action: "write",
@@ -454,10 +533,24 @@ func MainRPCServerPermissions() map[string][]bakery.Op {
 			Entity: "offchain",
 			Action: "write",
 		}},
+		"/lnrpc.Lightning/FeeAutopilotProposals": {{
+			Entity: "offchain",
+			Action: "read",
+		}},
 		"/lnrpc.Lightning/ForwardingHistory": {{
 			Entity: "offchain",
 			Action: "read",
 		}},
+		"/lnrpc.Lightning/AccountingReport": {{
+			Entity: "onchain",
+			Action: "read",
+		}, {
+			Entity: "offchain",
+			Action: "read",
+		}, {
+			Entity: "invoices",
+			Action: "read",
+		}},
 		"/lnrpc.Lightning/RestoreChannelBackups": {{
 			Entity: "offchain",
 			Action: "write",
@@ -537,6 +630,14 @@ type rpcServer struct {
 	// rpc sub server.
 	routerBackend *routerrpc.RouterBackend
 
+	// paymentsArchive is used to archive payments before they are
+	// deleted.
+	paymentsArchive *paymentsArchive
+
+	// lnurlServer serves the LNURL-pay endpoints on the REST listeners.
+	// It is nil if the LNURL-pay server is not active.
+	lnurlServer *lnurl.Server
+
 	// chanPredicate is used in the bidirectional ChannelAcceptor streaming
 	// method.
 	chanPredicate *chanacceptor.ChainedAcceptor
@@ -589,7 +690,7 @@ func newRPCServer(cfg *Config, interceptorChain *rpcperms.InterceptorChain,
 		)
 	}
 
-	return &rpcServer{
+	r := &rpcServer{
 		cfg:              cfg,
 		subGrpcHandlers:  subServerHandlers,
 		interceptorChain: interceptorChain,
@@ -598,6 +699,33 @@ func newRPCServer(cfg *Config, interceptorChain *rpcperms.InterceptorChain,
 		quit:             make(chan struct{}, 1),
 		interceptor:      interceptor,
 	}
+
+	// The LNURL-pay server is created before the dependencies are
+	// available, as it is registered with the REST listeners right away.
+	// It only serves requests once the RPC server is started.
+	if cfg.Lnurl.Active {
+		r.lnurlServer = lnurl.New(&lnurl.Config{
+			Domain: cfg.Lnurl.Domain,
+			FetchUser: func(username string) (*channeldb.LnurlUser,
+				error) {
+
+				return r.server.remoteChanDB.FetchLnurlUser(
+					username,
+				)
+			},
+			AddInvoice:           r.addLnurlInvoice,
+			RemoveExpiredInvoice: r.removeLnurlInvoice,
+			FetchPendingInvoices: r.fetchPendingLnurlInvoices,
+			InvoiceExpiry:        cfg.Lnurl.InvoiceExpiry,
+			MaxPendingInvoices:   cfg.Lnurl.MaxPendingInvoices,
+			RateLimit:            rate.Limit(cfg.Lnurl.RateLimit),
+			RateBurst:            cfg.Lnurl.RateBurst,
+			Clock:                clock.NewDefaultClock(),
+			GcTicker:             ticker.New(lnurl.GcInterval),
+		})
+	}
+
+	return r
 }
 
 // addDeps populates all dependencies needed by the RPC server, and any
@@ -648,6 +776,7 @@ func (r *rpcServer) addDeps(s *server, macService *macaroons.Service,
 		MaxTotalTimelock:       r.cfg.MaxOutgoingCltvExpiry,
 		DefaultFinalCltvDelta:  uint16(r.cfg.Bitcoin.TimeLockDelta),
 		SubscribeHtlcEvents:    s.htlcNotifier.SubscribeHtlcEvents,
+		QueryHtlcEvents:        s.queryHtlcEvents,
 		InterceptableForwarder: s.interceptableSwitch,
 		SetChannelEnabled: func(outpoint wire.OutPoint) error {
 			return s.chanStatusMgr.RequestEnable(outpoint, true)
@@ -757,6 +886,9 @@ func (r *rpcServer) addDeps(s *server, macService *macaroons.Service,
 	r.server = s
 	r.subServers = subServers
 	r.routerBackend = routerBackend
+	r.paymentsArchive = newPaymentsArchive(
+		r.cfg.PaymentsArchiveFile, routerBackend.MarshallPayment,
+	)
 	r.chanPredicate = chanPredicate
 	r.macService = macService
 	r.selfNode = selfNode.PubKeyBytes
@@ -817,6 +949,12 @@ func (r *rpcServer) Start() error {
 		}
 	}
 
+	if r.lnurlServer != nil {
+		if err := r.lnurlServer.Start(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -870,6 +1008,13 @@ func (r *rpcServer) Stop() error {
 
 	close(r.quit)
 
+	if r.lnurlServer != nil {
+		if err := r.lnurlServer.Stop(); err != nil {
+			rpcsLog.Errorf("unable to stop LNURL-pay server: %v",
+				err)
+		}
+	}
+
 	// After we've signalled all of our active goroutines to exit, we'll
 	// then do the same to signal a graceful shutdown of all the sub
 	// servers.
@@ -966,16 +1111,33 @@ func allowCORS(handler http.Handler, origins []string) http.Handler {
 
 // sendCoinsOnChain makes an on-chain transaction in or to send coins to one or
 // more addresses specified in the passed payment map. The payment map maps an
-// address to a specified output value to be sent to that address.
+// address to a specified output value to be sent to that address. If
+// outpoints are given, exactly those wallet outputs are spent.
 func (r *rpcServer) sendCoinsOnChain(paymentMap map[string]int64,
-	feeRate chainfee.SatPerKWeight, minConfs int32,
-	label string) (*chainhash.Hash, error) {
+	feeRate chainfee.SatPerKWeight, minConfs int32, label string,
+	outpoints []wire.OutPoint,
+	strategy chanfunding.CoinSelectionStrategy) (*chainhash.Hash, error) {
 
 	outputs, err := addrPairsToOutputs(paymentMap, r.cfg.ActiveNetParams.Params)
 	if err != nil {
 		return nil, err
 	}
 
+	// The wallet's internal coin selection only knows the largest and
+	// random strategies. If the caller picked the coins or asked for a
+	// specific strategy, or the privacy strategy is configured, we'll
+	// select the coins ourselves.
+	wallet := r.server.cc.Wallet
+	if len(outpoints) > 0 ||
+		strategy != chanfunding.CoinSelectionDefault ||
+		wallet.Cfg.CoinSelectionStrategy ==
+			chanfunding.CoinSelectionPrivacy {
+
+		return r.sendCoinControlTx(
+			outputs, feeRate, minConfs, label, outpoints, strategy,
+		)
+	}
+
 	// We first do a dry run, to sanity check we won't spend our wallet
 	// balance below the reserved amount.
 	authoredTx, err := r.server.cc.Wallet.CreateSimpleTx(
@@ -985,7 +1147,13 @@ func (r *rpcServer) sendCoinsOnChain(paymentMap map[string]int64,
 		return nil, err
 	}
 
-	_, err = r.server.cc.Wallet.CheckReservedValueTx(authoredTx.Tx)
+	reservedVal, err := r.server.cc.Wallet.CheckReservedValueTx(
+		authoredTx.Tx,
+	)
+	if err == lnwallet.ErrReservedValueInvalidated {
+		return nil, fmt.Errorf("%w: %v must remain in the wallet to "+
+			"fee bump anchor channels", err, reservedVal)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1003,6 +1171,43 @@ func (r *rpcServer) sendCoinsOnChain(paymentMap map[string]int64,
 	return &txHash, nil
 }
 
+// sendCoinControlTx creates a transaction paying to the given outputs with
+// coins selected by lnd itself, instead of the wallet's internal coin
+// selection, and publishes it.
+//
+// NOTE: This method should only be run with the CoinSelectLock held.
+func (r *rpcServer) sendCoinControlTx(outputs []*wire.TxOut,
+	feeRate chainfee.SatPerKWeight, minConfs int32, label string,
+	outpoints []wire.OutPoint,
+	strategy chanfunding.CoinSelectionStrategy) (*chainhash.Hash, error) {
+
+	wallet := r.server.cc.Wallet
+	tx, err := wallet.CreateCoinControlTx(
+		outputs, feeRate, minConfs, outpoints, strategy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure we don't spend our wallet balance below the reserved
+	// amount before publishing the transaction.
+	reservedVal, err := wallet.CheckReservedValueTx(tx)
+	if err == lnwallet.ErrReservedValueInvalidated {
+		return nil, fmt.Errorf("%w: %v must remain in the wallet to "+
+			"fee bump anchor channels", err, reservedVal)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wallet.PublishTransaction(tx, label); err != nil {
+		return nil, err
+	}
+
+	txHash := tx.TxHash()
+	return &txHash, nil
+}
+
 // ListUnspent returns useful information about each unspent output owned by the
 // wallet, as reported by the underlying `ListUnspentWitness`; the information
 // returned is: outpoint, amount in satoshis, address, address type,
@@ -1175,6 +1380,13 @@ func (r *rpcServer) SendCoins(ctx context.Context,
 		return nil, err
 	}
 
+	outpoints, strategy, err := lnrpc.UnmarshallCoinSelection(
+		in.Utxos, in.CoinSelectionStrategy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	var txid *chainhash.Hash
 
 	wallet := r.server.cc.Wallet
@@ -1191,6 +1403,14 @@ func (r *rpcServer) SendCoins(ctx context.Context,
 				"active")
 		}
 
+		// A sweep of all coins can't be restricted to some of them.
+		if len(outpoints) > 0 ||
+			strategy != chanfunding.CoinSelectionDefault {
+
+			return nil, fmt.Errorf("coin selection options " +
+				"cannot be used while SendAll is active")
+		}
+
 		_, bestHeight, err := r.server.cc.ChainIO.GetBestBlock()
 		if err != nil {
 			return nil, err
@@ -1310,6 +1530,7 @@ func (r *rpcServer) SendCoins(ctx context.Context,
 		err := wallet.WithCoinSelectLock(func() error {
 			newTXID, err := r.sendCoinsOnChain(
 				paymentMap, feePerKw, minConfs, label,
+				outpoints, strategy,
 			)
 			if err != nil {
 				return err
@@ -1355,6 +1576,13 @@ func (r *rpcServer) SendMany(ctx context.Context,
 		return nil, err
 	}
 
+	outpoints, strategy, err := lnrpc.UnmarshallCoinSelection(
+		in.Utxos, in.CoinSelectionStrategy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	rpcsLog.Infof("[sendmany] outputs=%v, sat/kw=%v",
 		spew.Sdump(in.AddrToAmount), int64(feePerKw))
 
@@ -1366,7 +1594,8 @@ func (r *rpcServer) SendMany(ctx context.Context,
 	wallet := r.server.cc.Wallet
 	err = wallet.WithCoinSelectLock(func() error {
 		sendManyTXID, err := r.sendCoinsOnChain(
-			in.AddrToAmount, feePerKw, minConfs, label,
+			in.AddrToAmount, feePerKw, minConfs, label, outpoints,
+			strategy,
 		)
 		if err != nil {
 			return err
@@ -1435,6 +1664,14 @@ func (r *rpcServer) NewAddress(ctx context.Context,
 		}
 	}
 
+	// Attach the optional label and tag to the new address.
+	if in.Label != "" || in.Tag != "" {
+		err := r.server.cc.Wallet.LabelAddress(addr, in.Label, in.Tag)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	rpcsLog.Debugf("[newaddress] account=%v type=%v addr=%v", account,
 		in.Type, addr.String())
 	return &lnrpc.NewAddressResponse{Address: addr.String()}, nil
@@ -1908,6 +2145,19 @@ func (r *rpcServer) parseOpenChannelReq(in *lnrpc.OpenChannelRequest,
 			err)
 	}
 
+	// Coin control only applies to funding transactions crafted by our
+	// own wallet, so it can't be combined with a funding shim.
+	outpoints, strategy, err := lnrpc.UnmarshallCoinSelection(
+		in.Utxos, in.CoinSelectionStrategy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(outpoints) > 0 && in.FundingShim != nil {
+		return nil, fmt.Errorf("utxos cannot be specified together " +
+			"with a funding shim")
+	}
+
 	// Instruct the server to trigger the necessary events to attempt to
 	// open a new channel. A stream is returned in place, this stream will
 	// be used to consume updates of the state of the pending channel.
@@ -1925,6 +2175,9 @@ func (r *rpcServer) parseOpenChannelReq(in *lnrpc.OpenChannelRequest,
 		MaxValueInFlight: maxValue,
 		MaxHtlcs:         maxHtlcs,
 		MaxLocalCsv:      uint16(in.MaxLocalCsv),
+		Outpoints:        outpoints,
+
+		CoinSelectionStrategy: strategy,
 	}, nil
 }
 
@@ -2028,6 +2281,48 @@ out:
 	return nil
 }
 
+// BatchOpenChannel attempts to open multiple single-funded channels in a
+// single transaction in an atomic way. This means either all channel open
+// requests succeed at once or all attempts are aborted if any of them fail.
+func (r *rpcServer) BatchOpenChannel(ctx context.Context,
+	in *lnrpc.BatchOpenChannelRequest) (*lnrpc.BatchOpenChannelResponse,
+	error) {
+
+	if err := r.canOpenChannel(); err != nil {
+		return nil, err
+	}
+
+	// Each channel is opened with its own PSBT funding flow. The batcher
+	// then funds a single transaction that pays to all funding outputs.
+	batcher := funding.NewBatcher(&funding.BatchConfig{
+		RequestParser: func(req *lnrpc.OpenChannelRequest) (
+			*funding.InitFundingMsg, error) {
+
+			return r.parseOpenChannelReq(req, false)
+		},
+		ChannelOpener: r.server.OpenChannel,
+		ChannelAbandoner: func(point *wire.OutPoint) error {
+			_, bestHeight, err := r.server.cc.ChainIO.GetBestBlock()
+			if err != nil {
+				return err
+			}
+
+			return r.abandonChan(point, uint32(bestHeight))
+		},
+		Wallet:    r.server.cc.Wallet,
+		NetParams: &r.server.cc.Wallet.Cfg.NetParams,
+		Quit:      r.quit,
+	})
+	rpcPoints, err := batcher.BatchFund(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("batch funding failed: %v", err)
+	}
+
+	return &lnrpc.BatchOpenChannelResponse{
+		PendingChannels: rpcPoints,
+	}, nil
+}
+
 // OpenChannelSync is a synchronous version of the OpenChannel RPC call. This
 // call is meant to be consumed by clients to the REST proxy. As with all other
 // sync calls, all byte slices are instead to be populated as hex encoded
@@ -2091,15 +2386,9 @@ func (r *rpcServer) CloseChannel(in *lnrpc.CloseChannelRequest,
 		return fmt.Errorf("must specify channel point in close channel")
 	}
 
-	// If force closing a channel, the fee set in the commitment transaction
-	// is used.
-	if in.Force && (in.SatPerByte != 0 || in.SatPerVbyte != 0 || // nolint:staticcheck
-		in.TargetConf != 0) {
-
-		return fmt.Errorf("force closing a channel uses a pre-defined fee")
-	}
-
 	force := in.Force
+	forceFee := in.SatPerByte != 0 || in.SatPerVbyte != 0 || // nolint:staticcheck
+		in.TargetConf != 0
 	index := in.ChannelPoint.OutputIndex
 	txid, err := lnrpc.GetChanPointFundingTxid(in.GetChannelPoint())
 	if err != nil {
@@ -2138,6 +2427,14 @@ func (r *rpcServer) CloseChannel(in *lnrpc.CloseChannelRequest,
 			channel.ChanStatus())
 	}
 
+	// If force closing a channel, the fee set in the commitment transaction
+	// is used. Only for anchor channels, the package fee rate of the
+	// commitment and the anchor sweep can be targeted.
+	if force && forceFee && !channel.ChanType.HasAnchors() {
+		return fmt.Errorf("force closing a channel without anchors " +
+			"uses a pre-defined fee")
+	}
+
 	// Retrieve the best height of the chain, which we'll use to complete
 	// either closing flow.
 	_, bestHeight, err := r.server.cc.ChainIO.GetBestBlock()
@@ -2167,11 +2464,29 @@ func (r *rpcServer) CloseChannel(in *lnrpc.CloseChannelRequest,
 		}
 
 		// With the necessary indexes cleaned up, we'll now force close
-		// the channel.
+		// the channel. If the user specified a fee, the anchors of the
+		// commitment are swept with it.
 		chainArbitrator := r.server.chainArb
-		closingTx, err := chainArbitrator.ForceCloseContract(
-			*chanPoint,
-		)
+		var closingTx *wire.MsgTx
+		if forceFee {
+			var feePref sweep.FeePreference
+			feePref, err = anchorFeePreference(
+				uint64(in.SatPerByte), in.SatPerVbyte, // nolint:staticcheck
+				uint32(in.TargetConf),
+			)
+			if err != nil {
+				return err
+			}
+
+			closingTx, err = chainArbitrator.
+				ForceCloseContractWithAnchorFee(
+					*chanPoint, feePref,
+				)
+		} else {
+			closingTx, err = chainArbitrator.ForceCloseContract(
+				*chanPoint,
+			)
+		}
 		if err != nil {
 			rpcsLog.Errorf("unable to force close transaction: %v", err)
 			return err
@@ -2362,41 +2677,11 @@ func abandonChanFromGraph(chanGraph *channeldb.ChannelGraph,
 	return chanGraph.DeleteChannelEdges(false, chanID)
 }
 
-// AbandonChannel removes all channel state from the database except for a
-// close summary. This method can be used to get rid of permanently unusable
-// channels due to bugs fixed in newer versions of lnd.
-func (r *rpcServer) AbandonChannel(_ context.Context,
-	in *lnrpc.AbandonChannelRequest) (*lnrpc.AbandonChannelResponse, error) {
-
-	// If this isn't the dev build, then we won't allow the RPC to be
-	// executed, as it's an advanced feature and won't be activated in
-	// regular production/release builds except for the explicit case of
-	// externally funded channels that are still pending. Due to repeated
-	// requests, we also allow this requirement to be overwritten by a new
-	// flag that attests to the user knowing what they're doing and the risk
-	// associated with the command/RPC.
-	if !in.IKnowWhatIAmDoing && !in.PendingFundingShimOnly &&
-		!build.IsDevBuild() {
-
-		return nil, fmt.Errorf("AbandonChannel RPC call only " +
-			"available in dev builds")
-	}
-
-	// We'll parse out the arguments to we can obtain the chanPoint of the
-	// target channel.
-	txid, err := lnrpc.GetChanPointFundingTxid(in.GetChannelPoint())
-	if err != nil {
-		return nil, err
-	}
-	index := in.ChannelPoint.OutputIndex
-	chanPoint := wire.NewOutPoint(txid, index)
-
-	// When we remove the channel from the database, we need to set a close
-	// height, so we'll just use the current best known height.
-	_, bestHeight, err := r.server.cc.ChainIO.GetBestBlock()
-	if err != nil {
-		return nil, err
-	}
+// abandonChan removes a channel from the database, graph and contract court.
+// If the channel is still loaded into the link/peer, it is marked as borked and
+// wiped from the peer first.
+func (r *rpcServer) abandonChan(chanPoint *wire.OutPoint,
+	bestHeight uint32) error {
 
 	dbChan, err := r.server.remoteChanDB.FetchChannel(*chanPoint)
 	switch {
@@ -2409,27 +2694,11 @@ func (r *rpcServer) AbandonChannel(_ context.Context,
 	// on-disk state, we'll remove the channel from the switch and peer
 	// state if it's been loaded in.
 	case err == nil:
-		// If the user requested the more safe version that only allows
-		// the removal of externally (shim) funded channels that are
-		// still pending, we enforce this option now that we know the
-		// state of the channel.
-		//
-		// TODO(guggero): Properly store the funding type (wallet, shim,
-		// PSBT) on the channel so we don't need to use the thaw height.
-		isShimFunded := dbChan.ThawHeight > 0
-		isPendingShimFunded := isShimFunded && dbChan.IsPending
-		if !in.IKnowWhatIAmDoing && in.PendingFundingShimOnly &&
-			!isPendingShimFunded {
-
-			return nil, fmt.Errorf("channel %v is not externally "+
-				"funded or not pending", chanPoint)
-		}
-
 		// We'll mark the channel as borked before we remove the state
 		// from the switch/peer so it won't be loaded back in if the
 		// peer reconnects.
 		if err := dbChan.MarkBorked(); err != nil {
-			return nil, err
+			return err
 		}
 		remotePub := dbChan.IdentityPub
 		if peer, err := r.server.FindPeer(remotePub); err == nil {
@@ -2437,7 +2706,7 @@ func (r *rpcServer) AbandonChannel(_ context.Context,
 		}
 
 	default:
-		return nil, err
+		return err
 	}
 
 	// Abandoning a channel is a three step process: remove from the open
@@ -2445,19 +2714,19 @@ func (r *rpcServer) AbandonChannel(_ context.Context,
 	// court. Between any step it's possible that the users restarts the
 	// process all over again. As a result, each of the steps below are
 	// intended to be idempotent.
-	err = r.server.remoteChanDB.AbandonChannel(chanPoint, uint32(bestHeight))
+	err = r.server.remoteChanDB.AbandonChannel(chanPoint, bestHeight)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	err = abandonChanFromGraph(
 		r.server.localChanDB.ChannelGraph(), chanPoint,
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	err = r.server.chainArb.ResolveContract(*chanPoint)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// If this channel was in the process of being closed, but didn't fully
@@ -2466,29 +2735,99 @@ func (r *rpcServer) AbandonChannel(_ context.Context,
 	// state for this channel from the nursery.
 	err = r.server.utxoNursery.cfg.Store.RemoveChannel(chanPoint)
 	if err != nil && err != ErrContractNotFound {
-		return nil, err
+		return err
 	}
 
 	// Finally, notify the backup listeners that the channel can be removed
 	// from any channel backups.
 	r.server.channelNotifier.NotifyClosedChannelEvent(*chanPoint)
 
-	return &lnrpc.AbandonChannelResponse{}, nil
+	return nil
 }
 
-// GetInfo returns general information concerning the lightning node including
-// its identity pubkey, alias, the chains it is connected to, and information
-// concerning the number of open+pending channels.
-func (r *rpcServer) GetInfo(_ context.Context,
-	_ *lnrpc.GetInfoRequest) (*lnrpc.GetInfoResponse, error) {
+// AbandonChannel removes all channel state from the database except for a
+// close summary. This method can be used to get rid of permanently unusable
+// channels due to bugs fixed in newer versions of lnd.
+func (r *rpcServer) AbandonChannel(_ context.Context,
+	in *lnrpc.AbandonChannelRequest) (*lnrpc.AbandonChannelResponse, error) {
 
-	serverPeers := r.server.Peers()
+	// If this isn't the dev build, then we won't allow the RPC to be
+	// executed, as it's an advanced feature and won't be activated in
+	// regular production/release builds except for the explicit case of
+	// externally funded channels that are still pending. Due to repeated
+	// requests, we also allow this requirement to be overwritten by a new
+	// flag that attests to the user knowing what they're doing and the risk
+	// associated with the command/RPC.
+	if !in.IKnowWhatIAmDoing && !in.PendingFundingShimOnly &&
+		!build.IsDevBuild() {
 
-	openChannels, err := r.server.remoteChanDB.FetchAllOpenChannels()
+		return nil, fmt.Errorf("AbandonChannel RPC call only " +
+			"available in dev builds")
+	}
+
+	// We'll parse out the arguments to we can obtain the chanPoint of the
+	// target channel.
+	txid, err := lnrpc.GetChanPointFundingTxid(in.GetChannelPoint())
 	if err != nil {
 		return nil, err
 	}
-
+	index := in.ChannelPoint.OutputIndex
+	chanPoint := wire.NewOutPoint(txid, index)
+
+	// When we remove the channel from the database, we need to set a close
+	// height, so we'll just use the current best known height.
+	_, bestHeight, err := r.server.cc.ChainIO.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	dbChan, err := r.server.remoteChanDB.FetchChannel(*chanPoint)
+	switch {
+	// If the channel isn't found in the set of open channels, then we can
+	// continue on as it can't be loaded into the link/peer.
+	case err == channeldb.ErrChannelNotFound:
+		break
+
+	// If the user requested the more safe version that only allows the
+	// removal of externally (shim) funded channels that are still pending,
+	// we enforce this option now that we know the state of the channel.
+	//
+	// TODO(guggero): Properly store the funding type (wallet, shim, PSBT)
+	// on the channel so we don't need to use the thaw height.
+	case err == nil:
+		isShimFunded := dbChan.ThawHeight > 0
+		isPendingShimFunded := isShimFunded && dbChan.IsPending
+		if !in.IKnowWhatIAmDoing && in.PendingFundingShimOnly &&
+			!isPendingShimFunded {
+
+			return nil, fmt.Errorf("channel %v is not externally "+
+				"funded or not pending", chanPoint)
+		}
+
+	default:
+		return nil, err
+	}
+
+	if err := r.abandonChan(chanPoint, uint32(bestHeight)); err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.AbandonChannelResponse{}, nil
+}
+
+// GetInfo returns general information concerning the lightning node including
+// its identity pubkey, alias, the chains it is connected to, and information
+// concerning the number of open+pending channels.
+func (r *rpcServer) GetInfo(_ context.Context,
+	_ *lnrpc.GetInfoRequest) (*lnrpc.GetInfoResponse, error) {
+
+	serverPeers := r.server.Peers()
+
+	openChannels, err := r.server.remoteChanDB.FetchAllOpenChannels()
+	if err != nil {
+		return nil, err
+	}
+
 	var activeChannels uint32
 	for _, channel := range openChannels {
 		chanID := lnwire.NewChanIDFromOutPoint(&channel.FundingOutpoint)
@@ -2877,11 +3216,60 @@ func (r *rpcServer) WalletBalance(ctx context.Context,
 		"unconfirmed=%v)", totalBalance, confirmedBalance,
 		unconfirmedBalance)
 
+	// Get the value we reserve for fee bumping anchor channels, and by
+	// how much the wallet balance falls short of it.
+	anchorReserve, err := r.server.cc.Wallet.CheckAnchorReserve()
+	if err != nil {
+		return nil, err
+	}
+
+	// Break the balance of the unspent outputs down by their tags. Frozen
+	// outputs are listed as well, and count towards their tag.
+	var utxos []*lnwallet.Utxo
+	err = r.server.cc.Wallet.WithCoinSelectLock(func() error {
+		var err error
+		utxos, err = r.server.cc.Wallet.ListUnspentWitness(
+			0, math.MaxInt32, "",
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var frozenBalance btcutil.Amount
+	rpcTagBalances := make(map[string]*lnrpc.WalletAccountBalance)
+	for _, utxo := range utxos {
+		if utxo.Frozen {
+			frozenBalance += utxo.Value
+		}
+
+		if utxo.Tag == "" {
+			continue
+		}
+
+		tagBalance, ok := rpcTagBalances[utxo.Tag]
+		if !ok {
+			tagBalance = &lnrpc.WalletAccountBalance{}
+			rpcTagBalances[utxo.Tag] = tagBalance
+		}
+
+		if utxo.Confirmations > 0 {
+			tagBalance.ConfirmedBalance += int64(utxo.Value)
+		} else {
+			tagBalance.UnconfirmedBalance += int64(utxo.Value)
+		}
+	}
+
 	return &lnrpc.WalletBalanceResponse{
-		TotalBalance:       int64(totalBalance),
-		ConfirmedBalance:   int64(confirmedBalance),
-		UnconfirmedBalance: int64(unconfirmedBalance),
-		AccountBalance:     rpcAccountBalances,
+		TotalBalance:              int64(totalBalance),
+		ConfirmedBalance:          int64(confirmedBalance),
+		UnconfirmedBalance:        int64(unconfirmedBalance),
+		AccountBalance:            rpcAccountBalances,
+		ReservedBalanceAnchorChan: int64(anchorReserve.Reserved),
+		AnchorReserveShortfall:    int64(anchorReserve.Shortfall()),
+		TagBalance:                rpcTagBalances,
+		FrozenBalance:             int64(frozenBalance),
 	}, nil
 }
 
@@ -4185,7 +4573,9 @@ type rpcPaymentIntent struct {
 	lastHop            *route.Vertex
 	destFeatures       *lnwire.FeatureVector
 	paymentAddr        *[32]byte
+	metadata           []byte
 	payReq             []byte
+	description        string
 
 	destCustomRecords record.CustomSet
 
@@ -4319,6 +4709,10 @@ func (r *rpcServer) extractPaymentIntent(rpcPayReq *rpcPaymentRequest) (rpcPayme
 		payIntent.payReq = []byte(rpcPayReq.PaymentRequest)
 		payIntent.destFeatures = payReq.Features
 		payIntent.paymentAddr = payReq.PaymentAddr
+		payIntent.metadata = payReq.Metadata
+		if payReq.Description != nil {
+			payIntent.description = *payReq.Description
+		}
 
 		if err := validateDest(payIntent.dest); err != nil {
 			return payIntent, err
@@ -4461,10 +4855,12 @@ func (r *rpcServer) dispatchPaymentIntent(
 			OutgoingChannelIDs: payIntent.outgoingChannelIDs,
 			LastHop:            payIntent.lastHop,
 			PaymentRequest:     payIntent.payReq,
+			Description:        payIntent.description,
 			PayAttemptTimeout:  routing.DefaultPayAttemptTimeout,
 			DestCustomRecords:  payIntent.destCustomRecords,
 			DestFeatures:       payIntent.destFeatures,
 			PaymentAddr:        payIntent.paymentAddr,
+			Metadata:           payIntent.metadata,
 
 			// Don't enable multi-part payments on the main rpc.
 			// Users need to use routerrpc for that.
@@ -4807,26 +5203,7 @@ func (r *rpcServer) sendPaymentSync(ctx context.Context,
 func (r *rpcServer) AddInvoice(ctx context.Context,
 	invoice *lnrpc.Invoice) (*lnrpc.AddInvoiceResponse, error) {
 
-	defaultDelta := r.cfg.Bitcoin.TimeLockDelta
-	if r.cfg.registeredChains.PrimaryChain() == chainreg.LitecoinChain {
-		defaultDelta = r.cfg.Litecoin.TimeLockDelta
-	}
-
-	addInvoiceCfg := &invoicesrpc.AddInvoiceConfig{
-		AddInvoice:        r.server.invoices.AddInvoice,
-		IsChannelActive:   r.server.htlcSwitch.HasActiveLink,
-		ChainParams:       r.cfg.ActiveNetParams.Params,
-		NodeSigner:        r.server.nodeSigner,
-		DefaultCLTVExpiry: defaultDelta,
-		ChanDB:            r.server.remoteChanDB,
-		Graph:             r.server.localChanDB.ChannelGraph(),
-		GenInvoiceFeatures: func() *lnwire.FeatureVector {
-			return r.server.featureMgr.Get(feature.SetInvoice)
-		},
-		GenAmpInvoiceFeatures: func() *lnwire.FeatureVector {
-			return r.server.featureMgr.Get(feature.SetInvoiceAmp)
-		},
-	}
+	addInvoiceCfg := r.addInvoiceConfig()
 
 	value, err := lnrpc.UnmarshallAmt(invoice.Value, invoice.ValueMsat)
 	if err != nil {
@@ -4848,6 +5225,10 @@ func (r *rpcServer) AddInvoice(ctx context.Context,
 		Private:         invoice.Private,
 		RouteHints:      routeHints,
 		Amp:             invoice.IsAmp,
+		Metadata:        invoice.PaymentMetadata,
+		MaxPaymentAmt:   lnwire.MilliSatoshi(invoice.MaxPaymentMsat),
+		AcceptPartial:   invoice.AcceptPartial,
+		MaxAmtPaid:      lnwire.MilliSatoshi(invoice.MaxAmtPaidMsat),
 	}
 
 	if invoice.RPreimage != nil {
@@ -4873,6 +5254,221 @@ func (r *rpcServer) AddInvoice(ctx context.Context,
 	}, nil
 }
 
+// addInvoiceConfig returns the configuration that is used to add invoices.
+func (r *rpcServer) addInvoiceConfig() *invoicesrpc.AddInvoiceConfig {
+	defaultDelta := r.cfg.Bitcoin.TimeLockDelta
+	if r.cfg.registeredChains.PrimaryChain() == chainreg.LitecoinChain {
+		defaultDelta = r.cfg.Litecoin.TimeLockDelta
+	}
+
+	return &invoicesrpc.AddInvoiceConfig{
+		AddInvoice:        r.server.invoices.AddInvoice,
+		IsChannelActive:   r.server.htlcSwitch.HasActiveLink,
+		ChainParams:       r.cfg.ActiveNetParams.Params,
+		NodeSigner:        r.server.nodeSigner,
+		DefaultCLTVExpiry: defaultDelta,
+		ChanDB:            r.server.remoteChanDB,
+		Graph:             r.server.localChanDB.ChannelGraph(),
+		GenInvoiceFeatures: func() *lnwire.FeatureVector {
+			return r.server.featureMgr.Get(feature.SetInvoice)
+		},
+		GenAmpInvoiceFeatures: func() *lnwire.FeatureVector {
+			return r.server.featureMgr.Get(feature.SetInvoiceAmp)
+		},
+	}
+}
+
+// addLnurlInvoice adds an invoice for a payment through the LNURL-pay server.
+// The invoice commits to the given description hash and the memo is only
+// stored locally. The payment request of the invoice is returned.
+func (r *rpcServer) addLnurlInvoice(amt lnwire.MilliSatoshi,
+	descHash [32]byte, memo string, expiry time.Duration) (string,
+	lntypes.Hash, error) {
+
+	hash, dbInvoice, err := invoicesrpc.AddInvoice(
+		context.Background(), r.addInvoiceConfig(),
+		&invoicesrpc.AddInvoiceData{
+			Memo:            memo,
+			Value:           amt,
+			DescriptionHash: descHash[:],
+			Expiry:          int64(expiry.Seconds()),
+		},
+	)
+	if err != nil {
+		return "", lntypes.Hash{}, err
+	}
+
+	return string(dbInvoice.PaymentRequest), *hash, nil
+}
+
+// fetchPendingLnurlInvoices returns the payment hashes and expiry times of the
+// invoices that were created by the LNURL-pay server and weren't removed yet.
+// Settled invoices are skipped, as they no longer need to be removed.
+func (r *rpcServer) fetchPendingLnurlInvoices() (map[lntypes.Hash]time.Time,
+	error) {
+
+	var pending map[lntypes.Hash]time.Time
+	reset := func() {
+		pending = make(map[lntypes.Hash]time.Time)
+	}
+
+	scanFunc := func(hash lntypes.Hash, invoice *channeldb.Invoice) error {
+		if invoice.State == channeldb.ContractSettled {
+			return nil
+		}
+
+		if !strings.HasPrefix(
+			string(invoice.Memo), lnurl.InvoiceMemoPrefix,
+		) {
+
+			return nil
+		}
+
+		pending[hash] = invoice.CreationDate.Add(invoice.Terms.Expiry)
+
+		return nil
+	}
+
+	err := r.server.remoteChanDB.ScanInvoices(scanFunc, reset)
+	if err != nil && err != channeldb.ErrNoInvoicesCreated {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// removeLnurlInvoice deletes an expired invoice of the LNURL-pay server if it
+// was canceled. It returns true if the invoice was either paid or deleted.
+func (r *rpcServer) removeLnurlInvoice(hash lntypes.Hash) (bool, error) {
+	invoice, err := r.server.invoices.LookupInvoice(hash)
+	switch {
+	case err == channeldb.ErrInvoiceNotFound:
+		return true, nil
+
+	case err != nil:
+		return false, err
+	}
+
+	switch invoice.State {
+	case channeldb.ContractSettled:
+		return true, nil
+
+	// Open invoices are canceled by the invoice expiry watcher shortly
+	// after they expire, and accepted invoices may still be settled. Both
+	// are retried later.
+	case channeldb.ContractOpen, channeldb.ContractAccepted:
+		return false, nil
+	}
+
+	deleteRef := channeldb.InvoiceDeleteRef{
+		PayHash:     hash,
+		AddIndex:    invoice.AddIndex,
+		SettleIndex: invoice.SettleIndex,
+	}
+	if invoice.Terms.PaymentAddr != channeldb.BlankPayAddr {
+		deleteRef.PayAddr = &invoice.Terms.PaymentAddr
+	}
+
+	err = r.server.remoteChanDB.DeleteInvoice(
+		[]channeldb.InvoiceDeleteRef{deleteRef},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AddLnurlUser adds a user to the LNURL-pay server, or replaces the user if a
+// user with the same name already exists.
+func (r *rpcServer) AddLnurlUser(_ context.Context,
+	req *lnrpc.LnurlUser) (*lnrpc.AddLnurlUserResponse, error) {
+
+	if err := lnurl.ValidateUsername(req.Username); err != nil {
+		return nil, err
+	}
+
+	if req.MinSendableMsat == 0 {
+		return nil, fmt.Errorf("min_sendable_msat must be positive")
+	}
+
+	if req.MaxSendableMsat < req.MinSendableMsat {
+		return nil, fmt.Errorf("max_sendable_msat must not be below " +
+			"min_sendable_msat")
+	}
+
+	if req.CommentAllowed > lnurl.MaxCommentLength {
+		return nil, fmt.Errorf("comment_allowed must not exceed %v",
+			lnurl.MaxCommentLength)
+	}
+
+	user := &channeldb.LnurlUser{
+		Username:       req.Username,
+		Description:    req.Description,
+		MinSendable:    lnwire.MilliSatoshi(req.MinSendableMsat),
+		MaxSendable:    lnwire.MilliSatoshi(req.MaxSendableMsat),
+		CommentAllowed: uint16(req.CommentAllowed),
+	}
+	if err := r.server.remoteChanDB.PutLnurlUser(user); err != nil {
+		return nil, err
+	}
+
+	rpcsLog.Infof("Added LNURL-pay user %v", user.Username)
+
+	return &lnrpc.AddLnurlUserResponse{
+		LightningAddress: r.lightningAddress(user.Username),
+	}, nil
+}
+
+// ListLnurlUsers returns all users of the LNURL-pay server.
+func (r *rpcServer) ListLnurlUsers(_ context.Context,
+	_ *lnrpc.ListLnurlUsersRequest) (*lnrpc.ListLnurlUsersResponse, error) {
+
+	users, err := r.server.remoteChanDB.FetchLnurlUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &lnrpc.ListLnurlUsersResponse{}
+	for _, user := range users {
+		resp.Users = append(resp.Users, &lnrpc.LnurlUser{
+			Username:         user.Username,
+			Description:      user.Description,
+			MinSendableMsat:  uint64(user.MinSendable),
+			MaxSendableMsat:  uint64(user.MaxSendable),
+			CommentAllowed:   uint32(user.CommentAllowed),
+			LightningAddress: r.lightningAddress(user.Username),
+		})
+	}
+
+	return resp, nil
+}
+
+// DeleteLnurlUser removes a user from the LNURL-pay server.
+func (r *rpcServer) DeleteLnurlUser(_ context.Context,
+	req *lnrpc.DeleteLnurlUserRequest) (*lnrpc.DeleteLnurlUserResponse,
+	error) {
+
+	err := r.server.remoteChanDB.DeleteLnurlUser(req.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcsLog.Infof("Deleted LNURL-pay user %v", req.Username)
+
+	return &lnrpc.DeleteLnurlUserResponse{}, nil
+}
+
+// lightningAddress returns the lightning address of the LNURL-pay user with
+// the given name, or an empty string if the LNURL-pay server is not active.
+func (r *rpcServer) lightningAddress(username string) string {
+	if r.lnurlServer == nil {
+		return ""
+	}
+
+	return r.lnurlServer.Address(username)
+}
+
 // LookupInvoice attempts to look up an invoice according to its payment hash.
 // The passed payment hash *must* be exactly 32 bytes, if not an error is
 // returned.
@@ -4939,10 +5535,25 @@ func (r *rpcServer) ListInvoices(ctx context.Context,
 	// Next, we'll map the proto request into a format that is understood by
 	// the database.
 	q := channeldb.InvoiceQuery{
-		IndexOffset:    req.IndexOffset,
-		NumMaxInvoices: req.NumMaxInvoices,
-		PendingOnly:    req.PendingOnly,
-		Reversed:       req.Reversed,
+		IndexOffset:       req.IndexOffset,
+		NumMaxInvoices:    req.NumMaxInvoices,
+		PendingOnly:       req.PendingOnly,
+		Reversed:          req.Reversed,
+		Archived:          req.Archived,
+		CreationDateStart: rangeStartTime(req.CreationDateStart),
+		CreationDateEnd:   rangeEndTime(req.CreationDateEnd),
+		SettleDateStart:   rangeStartTime(req.SettleDateStart),
+		SettleDateEnd:     rangeEndTime(req.SettleDateEnd),
+		MinValue:          lnwire.MilliSatoshi(req.MinValueMsat),
+		MaxValue:          lnwire.MilliSatoshi(req.MaxValueMsat),
+		MemoSubstring:     req.MemoContains,
+	}
+	for _, rpcState := range req.States {
+		state, err := invoicesrpc.UnmarshallInvoiceState(rpcState)
+		if err != nil {
+			return nil, err
+		}
+		q.States = append(q.States, state)
 	}
 	invoiceSlice, err := r.server.remoteChanDB.QueryInvoices(q)
 	if err != nil {
@@ -4969,6 +5580,27 @@ func (r *rpcServer) ListInvoices(ctx context.Context,
 	return resp, nil
 }
 
+// rangeStartTime converts the unix timestamp in seconds that starts a time
+// range filter into a time. A zero timestamp leaves the range unbounded.
+func rangeStartTime(timestamp uint64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(int64(timestamp), 0)
+}
+
+// rangeEndTime converts the unix timestamp in seconds that ends a time range
+// filter into a time, such that the whole second is part of the range. A zero
+// timestamp leaves the range unbounded.
+func rangeEndTime(timestamp uint64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(int64(timestamp), int64(time.Second-1))
+}
+
 // SubscribeInvoices returns a uni-directional stream (server -> client) for
 // notifying the client of newly added/settled invoices.
 func (r *rpcServer) SubscribeInvoices(req *lnrpc.InvoiceSubscription,
@@ -5722,6 +6354,38 @@ func (r *rpcServer) ListPayments(ctx context.Context,
 		MaxPayments:       req.MaxPayments,
 		Reversed:          req.Reversed,
 		IncludeIncomplete: req.IncludeIncomplete,
+		CreationDateStart: rangeStartTime(req.CreationDateStart),
+		CreationDateEnd:   rangeEndTime(req.CreationDateEnd),
+		MinValue:          lnwire.MilliSatoshi(req.MinValueMsat),
+		MaxValue:          lnwire.MilliSatoshi(req.MaxValueMsat),
+		Description:       req.Description,
+		Label:             req.PaymentLabel,
+	}
+	for _, rpcStatus := range req.Statuses {
+		status, err := routerrpc.UnmarshallPaymentStatus(rpcStatus)
+		if err != nil {
+			return nil, err
+		}
+		query.Statuses = append(query.Statuses, status)
+	}
+
+	if len(req.Dest) > 0 {
+		dest, err := route.NewVertexFromBytes(req.Dest)
+		if err != nil {
+			return nil, err
+		}
+		query.Destination = &dest
+	}
+
+	if len(req.PaymentAddr) > 0 {
+		if len(req.PaymentAddr) != 32 {
+			return nil, fmt.Errorf("payment address must be 32 "+
+				"bytes, got %v", len(req.PaymentAddr))
+		}
+
+		var paymentAddr [32]byte
+		copy(paymentAddr[:], req.PaymentAddr)
+		query.PaymentAddr = &paymentAddr
 	}
 
 	// If the maximum number of payments wasn't specified, then we'll
@@ -5762,17 +6426,233 @@ func (r *rpcServer) DeleteAllPayments(ctx context.Context,
 	*lnrpc.DeleteAllPaymentsResponse, error) {
 
 	rpcsLog.Infof("[DeleteAllPayments] failed_payments_only=%v, "+
-		"failed_htlcs_only=%v", req.FailedPaymentsOnly,
-		req.FailedHtlcsOnly)
-
-	err := r.server.remoteChanDB.DeletePayments(
+		"failed_htlcs_only=%v, created_before=%v, archive=%v",
 		req.FailedPaymentsOnly, req.FailedHtlcsOnly,
+		req.CreatedBefore, req.Archive)
+
+	if req.CreatedBefore < 0 {
+		return nil, fmt.Errorf("created_before must not be negative")
+	}
+
+	query := channeldb.PaymentsDeleteQuery{
+		FailedOnly:      req.FailedPaymentsOnly,
+		FailedHtlcsOnly: req.FailedHtlcsOnly,
+	}
+	if req.CreatedBefore != 0 {
+		query.CreatedBefore = time.Unix(req.CreatedBefore, 0)
+	}
+	if req.Archive {
+		query.Archive = r.paymentsArchive.archive
+	}
+
+	numDeleted, err := r.server.remoteChanDB.DeleteFilteredPayments(query)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcsLog.Infof("[DeleteAllPayments] deleted %v payments", numDeleted)
+
+	return &lnrpc.DeleteAllPaymentsResponse{
+		NumDeleted: uint64(numDeleted),
+	}, nil
+}
+
+// DeletePayment deletes a single completed outgoing payment from DB.
+func (r *rpcServer) DeletePayment(ctx context.Context,
+	req *lnrpc.DeletePaymentRequest) (*lnrpc.DeletePaymentResponse,
+	error) {
+
+	hash, err := lntypes.MakeHash(req.PaymentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcsLog.Infof("[DeletePayment] payment_hash=%v, failed_htlcs_only=%v, "+
+		"archive=%v", hash, req.FailedHtlcsOnly, req.Archive)
+
+	var archive func([]*channeldb.MPPayment) error
+	if req.Archive {
+		archive = r.paymentsArchive.archive
+	}
+
+	err = r.server.remoteChanDB.DeletePayment(
+		hash, req.FailedHtlcsOnly, archive,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &lnrpc.DeleteAllPaymentsResponse{}, nil
+	return &lnrpc.DeletePaymentResponse{}, nil
+}
+
+// AddRecurringPayment adds a payment that is sent repeatedly at a fixed
+// interval.
+func (r *rpcServer) AddRecurringPayment(_ context.Context,
+	req *lnrpc.AddRecurringPaymentRequest) (*lnrpc.RecurringPayment,
+	error) {
+
+	rpcsLog.Debugf("[addrecurringpayment] amt_msat=%v, interval=%vs",
+		req.AmtMsat, req.IntervalSeconds)
+
+	payment := &channeldb.RecurringPayment{
+		Label:      req.Label,
+		AmpInvoice: req.AmpInvoice,
+		Amt:        lnwire.MilliSatoshi(req.AmtMsat),
+		MaxFee:     lnwire.MilliSatoshi(req.MaxFeeMsat),
+		Interval:   time.Duration(req.IntervalSeconds) * time.Second,
+	}
+
+	if len(req.Dest) > 0 {
+		dest, err := route.NewVertexFromBytes(req.Dest)
+		if err != nil {
+			return nil, err
+		}
+		payment.Dest = dest
+	}
+
+	if req.StartTime < 0 || req.EndTime < 0 {
+		return nil, errors.New("start_time and end_time must not be " +
+			"negative")
+	}
+	if req.StartTime != 0 {
+		payment.StartTime = time.Unix(req.StartTime, 0)
+	}
+	if req.EndTime != 0 {
+		payment.EndTime = time.Unix(req.EndTime, 0)
+	}
+
+	err := r.server.recurringPayments.AddPayment(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshallRecurringPayment(payment), nil
+}
+
+// ListRecurringPayments returns all recurring payments.
+func (r *rpcServer) ListRecurringPayments(_ context.Context,
+	_ *lnrpc.ListRecurringPaymentsRequest) (
+	*lnrpc.ListRecurringPaymentsResponse, error) {
+
+	payments, err := r.server.remoteChanDB.FetchRecurringPayments()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &lnrpc.ListRecurringPaymentsResponse{}
+	for _, payment := range payments {
+		resp.Payments = append(
+			resp.Payments, marshallRecurringPayment(payment),
+		)
+	}
+
+	return resp, nil
+}
+
+// RecurringPaymentHistory returns the runs of a recurring payment.
+func (r *rpcServer) RecurringPaymentHistory(_ context.Context,
+	req *lnrpc.RecurringPaymentHistoryRequest) (
+	*lnrpc.RecurringPaymentHistoryResponse, error) {
+
+	runs, err := r.server.remoteChanDB.FetchRecurringPaymentRuns(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &lnrpc.RecurringPaymentHistoryResponse{}
+	for _, run := range runs {
+		rpcRun, err := marshallRecurringPaymentRun(run)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Runs = append(resp.Runs, rpcRun)
+	}
+
+	return resp, nil
+}
+
+// DeleteRecurringPayment stops a recurring payment and removes it along with
+// the history of its runs.
+func (r *rpcServer) DeleteRecurringPayment(_ context.Context,
+	req *lnrpc.DeleteRecurringPaymentRequest) (
+	*lnrpc.DeleteRecurringPaymentResponse, error) {
+
+	err := r.server.recurringPayments.DeletePayment(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.DeleteRecurringPaymentResponse{}, nil
+}
+
+// marshallRecurringPayment converts a recurring payment into its RPC
+// representation.
+func marshallRecurringPayment(
+	payment *channeldb.RecurringPayment) *lnrpc.RecurringPayment {
+
+	rpcPayment := &lnrpc.RecurringPayment{
+		Id:              payment.ID,
+		Label:           payment.Label,
+		AmpInvoice:      payment.AmpInvoice,
+		AmtMsat:         uint64(payment.Amt),
+		MaxFeeMsat:      uint64(payment.MaxFee),
+		IntervalSeconds: uint64(payment.Interval / time.Second),
+		StartTime:       payment.StartTime.Unix(),
+		NextRunTime:     payment.NextRun.Unix(),
+		Finished:        payment.Finished(),
+		CreationTime:    payment.CreationTime.Unix(),
+	}
+
+	if payment.IsKeysend() {
+		rpcPayment.Dest = payment.Dest[:]
+	}
+
+	if !payment.EndTime.IsZero() {
+		rpcPayment.EndTime = payment.EndTime.Unix()
+	}
+
+	return rpcPayment
+}
+
+// marshallRecurringPaymentRun converts a run of a recurring payment into its
+// RPC representation.
+func marshallRecurringPaymentRun(run *channeldb.RecurringPaymentRun) (
+	*lnrpc.RecurringPaymentRun, error) {
+
+	var status lnrpc.RecurringPaymentRun_RunStatus
+	switch run.Status {
+	case channeldb.RecurringPaymentRunInFlight:
+		status = lnrpc.RecurringPaymentRun_IN_FLIGHT
+
+	case channeldb.RecurringPaymentRunSucceeded:
+		status = lnrpc.RecurringPaymentRun_SUCCEEDED
+
+	case channeldb.RecurringPaymentRunFailed:
+		status = lnrpc.RecurringPaymentRun_FAILED
+
+	default:
+		return nil, fmt.Errorf("unknown recurring payment run "+
+			"status: %v", run.Status)
+	}
+
+	rpcRun := &lnrpc.RecurringPaymentRun{
+		Number:        run.Number,
+		ScheduledTime: run.ScheduledTime.Unix(),
+		Status:        status,
+		Attempts:      run.Attempts,
+		FailureReason: run.FailureReason,
+	}
+
+	if run.Attempts > 0 {
+		rpcRun.LastAttemptTime = run.LastAttemptTime.Unix()
+	}
+
+	if run.PaymentID != lntypes.ZeroHash {
+		rpcRun.PaymentId = run.PaymentID.String()
+	}
+
+	return rpcRun, nil
 }
 
 // DebugLevel allows a caller to programmatically set the logging verbosity of
@@ -5870,6 +6750,7 @@ func (r *rpcServer) DecodePayReq(ctx context.Context,
 		RouteHints:      routeHints,
 		PaymentAddr:     paymentAddr,
 		Features:        invoicesrpc.CreateRPCFeatures(payReq.Features),
+		PaymentMetadata: payReq.Metadata,
 	}, nil
 }
 
@@ -6109,6 +6990,50 @@ func (r *rpcServer) UpdateChannelPolicy(ctx context.Context,
 	return &lnrpc.PolicyUpdateResponse{}, nil
 }
 
+// FeeAutopilotProposals returns the fee changes the fee autopilot proposed in
+// its most recent evaluation of our channels.
+func (r *rpcServer) FeeAutopilotProposals(ctx context.Context,
+	_ *lnrpc.FeeAutopilotProposalsRequest) (
+	*lnrpc.FeeAutopilotProposalsResponse, error) {
+
+	if r.server.feeAutopilot == nil {
+		return nil, fmt.Errorf("fee autopilot is not active")
+	}
+
+	round := r.server.feeAutopilot.LastProposals()
+	if round == nil {
+		return nil, fmt.Errorf("fee autopilot has not evaluated the " +
+			"channels yet")
+	}
+
+	resp := &lnrpc.FeeAutopilotProposalsResponse{
+		Timestamp: round.Timestamp.Unix(),
+		Applied:   round.Applied,
+		Proposals: make(
+			[]*lnrpc.FeeAutopilotProposal, 0, len(round.Proposals),
+		),
+	}
+	for _, p := range round.Proposals {
+		resp.Proposals = append(resp.Proposals, &lnrpc.FeeAutopilotProposal{
+			ChanPoint: &lnrpc.ChannelPoint{
+				FundingTxid: &lnrpc.ChannelPoint_FundingTxidBytes{
+					FundingTxidBytes: p.ChanPoint.Hash[:],
+				},
+				OutputIndex: p.ChanPoint.Index,
+			},
+			ChanId:         p.ChanID.ToUint64(),
+			LocalRatio:     p.LocalRatio,
+			ForwardedMsat:  uint64(p.ForwardedAmt),
+			OldBaseFeeMsat: int64(p.OldBaseFee),
+			OldFeeRatePpm:  p.OldFeeRate,
+			NewBaseFeeMsat: int64(p.NewBaseFee),
+			NewFeeRatePpm:  p.NewFeeRate,
+		})
+	}
+
+	return resp, nil
+}
+
 // ForwardingHistory allows the caller to query the htlcswitch for a record of
 // all HTLC's forwarded within the target time range, and integer offset within
 // that time range. If no time-range is specified, then the first chunk of the
@@ -6203,6 +7128,214 @@ func (r *rpcServer) ForwardingHistory(ctx context.Context,
 	return resp, nil
 }
 
+// AccountingReport returns an accounting report of the funds that moved
+// through the node within the target time range.
+func (r *rpcServer) AccountingReport(ctx context.Context,
+	req *lnrpc.AccountingReportRequest) (*lnrpc.AccountingReportResponse,
+	error) {
+
+	rpcsLog.Debugf("[accountingreport] start=%v, end=%v", req.StartTime,
+		req.EndTime)
+
+	if req.EndTime != 0 && req.EndTime < req.StartTime {
+		return nil, fmt.Errorf("end time %v is before start time %v",
+			req.EndTime, req.StartTime)
+	}
+
+	chanDB := r.server.remoteChanDB
+	cfg := &accounting.Config{
+		ListTransactions: func() ([]*lnwallet.TransactionDetail,
+			error) {
+
+			return r.server.cc.Wallet.ListTransactionDetails(
+				0, btcwallet.UnconfirmedHeight, "",
+			)
+		},
+		OpenChannels: chanDB.FetchAllOpenChannels,
+		ClosedChannels: func() ([]*channeldb.ChannelCloseSummary,
+			error) {
+
+			return chanDB.FetchClosedChannels(false)
+		},
+		ListSweeps:         r.server.sweeper.ListSweeps,
+		QueryForwardingLog: chanDB.ForwardingLog().Query,
+		QueryPayments:      chanDB.QueryPayments,
+		QueryInvoices:      chanDB.QueryInvoices,
+		BlockTimestamp: func(height uint32) (time.Time, error) {
+			hash, err := r.server.cc.ChainIO.GetBlockHash(
+				int64(height),
+			)
+			if err != nil {
+				return time.Time{}, err
+			}
+
+			block, err := r.server.cc.ChainIO.GetBlock(hash)
+			if err != nil {
+				return time.Time{}, err
+			}
+
+			return block.Header.Timestamp, nil
+		},
+	}
+
+	resp := &lnrpc.AccountingReportResponse{}
+
+	// The price file is read for every report, so that it can be updated
+	// without restarting lnd.
+	if r.cfg.Accounting.PriceCSV != "" {
+		priceSource, err := accounting.LoadCSVPriceSource(
+			r.cfg.Accounting.PriceCSV, r.cfg.Accounting.Currency,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load prices: %v", err)
+		}
+
+		cfg.PriceSource = priceSource
+		resp.FiatCurrency = r.cfg.Accounting.Currency
+	}
+
+	entries, err := accounting.Report(
+		cfg, rangeStartTime(req.StartTime), rangeEndTime(req.EndTime),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		rpcEntry, err := marshallAccountingEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Entries = append(resp.Entries, rpcEntry)
+	}
+
+	return resp, nil
+}
+
+// fiatPrecision is the number of decimal places that prices and fiat values
+// of accounting entries are reported with. We report fractions of cents so
+// that the values of small entries, like forwarding fees, add up correctly.
+const fiatPrecision = 8
+
+// marshallAccountingEntry converts an accounting entry into its RPC
+// representation.
+func marshallAccountingEntry(entry *accounting.Entry) (*lnrpc.AccountingEntry,
+	error) {
+
+	var entryType lnrpc.AccountingEntry_EntryType
+	switch entry.Type {
+	case accounting.EntryTypeLocalChannelOpen:
+		entryType = lnrpc.AccountingEntry_LOCAL_CHANNEL_OPEN
+
+	case accounting.EntryTypeRemoteChannelOpen:
+		entryType = lnrpc.AccountingEntry_REMOTE_CHANNEL_OPEN
+
+	case accounting.EntryTypeChannelOpenFee:
+		entryType = lnrpc.AccountingEntry_CHANNEL_OPEN_FEE
+
+	case accounting.EntryTypeChannelClose:
+		entryType = lnrpc.AccountingEntry_CHANNEL_CLOSE
+
+	case accounting.EntryTypeChannelCloseFee:
+		entryType = lnrpc.AccountingEntry_CHANNEL_CLOSE_FEE
+
+	case accounting.EntryTypeReceipt:
+		entryType = lnrpc.AccountingEntry_RECEIPT
+
+	case accounting.EntryTypePayment:
+		entryType = lnrpc.AccountingEntry_PAYMENT
+
+	case accounting.EntryTypeFee:
+		entryType = lnrpc.AccountingEntry_FEE
+
+	case accounting.EntryTypeCircularReceipt:
+		entryType = lnrpc.AccountingEntry_CIRCULAR_RECEIPT
+
+	case accounting.EntryTypeCircularPayment:
+		entryType = lnrpc.AccountingEntry_CIRCULAR_PAYMENT
+
+	case accounting.EntryTypeCircularFee:
+		entryType = lnrpc.AccountingEntry_CIRCULAR_FEE
+
+	case accounting.EntryTypeForward:
+		entryType = lnrpc.AccountingEntry_FORWARD
+
+	case accounting.EntryTypeForwardFee:
+		entryType = lnrpc.AccountingEntry_FORWARD_FEE
+
+	case accounting.EntryTypeSweep:
+		entryType = lnrpc.AccountingEntry_SWEEP
+
+	case accounting.EntryTypeSweepFee:
+		entryType = lnrpc.AccountingEntry_SWEEP_FEE
+
+	case accounting.EntryTypeJustice:
+		entryType = lnrpc.AccountingEntry_JUSTICE
+
+	default:
+		return nil, fmt.Errorf("unknown accounting entry type: %v",
+			entry.Type)
+	}
+
+	debitAccount, err := marshallAccountingAccount(entry.DebitAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	creditAccount, err := marshallAccountingAccount(entry.CreditAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcEntry := &lnrpc.AccountingEntry{
+		Timestamp:     entry.Timestamp.Unix(),
+		AmountMsat:    uint64(entry.Amount),
+		DebitAccount:  debitAccount,
+		CreditAccount: creditAccount,
+		Type:          entryType,
+		OnChain:       entry.OnChain,
+		Txid:          entry.TxID,
+		Reference:     entry.Reference,
+		Note:          entry.Note,
+	}
+
+	if entry.BTCPrice != nil {
+		rpcEntry.BtcPrice = entry.BTCPrice.Price.FloatString(
+			fiatPrecision,
+		)
+		rpcEntry.FiatValue = entry.FiatValue.FloatString(fiatPrecision)
+	}
+
+	return rpcEntry, nil
+}
+
+// marshallAccountingAccount converts the account of an accounting entry into
+// its RPC representation.
+func marshallAccountingAccount(account accounting.Account) (
+	lnrpc.AccountingEntry_Account, error) {
+
+	switch account {
+	case accounting.AccountWallet:
+		return lnrpc.AccountingEntry_WALLET, nil
+
+	case accounting.AccountChannels:
+		return lnrpc.AccountingEntry_CHANNELS, nil
+
+	case accounting.AccountExternal:
+		return lnrpc.AccountingEntry_EXTERNAL, nil
+
+	case accounting.AccountFees:
+		return lnrpc.AccountingEntry_FEES, nil
+
+	case accounting.AccountForwardingIncome:
+		return lnrpc.AccountingEntry_FORWARDING_INCOME, nil
+
+	default:
+		return 0, fmt.Errorf("unknown accounting account: %v", account)
+	}
+}
+
 // ExportChannelBackup attempts to return an encrypted static channel backup
 // for the target channel identified by it channel point. The backup is
 // encrypted with a key generated from the aezeed seed of the user. The
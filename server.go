@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"image/color"
@@ -50,6 +51,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnpeer"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/lightningnetwork/lnd/lnwire"
@@ -59,6 +61,7 @@ import (
 	"github.com/lightningnetwork/lnd/peernotifier"
 	"github.com/lightningnetwork/lnd/pool"
 	"github.com/lightningnetwork/lnd/queue"
+	"github.com/lightningnetwork/lnd/recurring"
 	"github.com/lightningnetwork/lnd/routing"
 	"github.com/lightningnetwork/lnd/routing/localchans"
 	"github.com/lightningnetwork/lnd/routing/route"
@@ -71,6 +74,7 @@ import (
 	"github.com/lightningnetwork/lnd/watchtower/wtclient"
 	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
 	"github.com/lightningnetwork/lnd/watchtower/wtserver"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -236,6 +240,18 @@ type server struct {
 
 	htlcNotifier *htlcswitch.HtlcNotifier
 
+	// peerAddLimiters hands out the rate limiters that bound how quickly
+	// each of our peers may ask us to forward htlcs.
+	peerAddLimiters *htlcswitch.PeerAddLimiters
+
+	// chanAddLimiters hands out the rate limiters that bound how quickly
+	// our peers may ask us to forward htlcs over each channel.
+	chanAddLimiters *htlcswitch.ChannelAddLimiters
+
+	// htlcEventRecorder persists the outcome of forwarded htlcs. It is nil
+	// if htlc event recording is disabled.
+	htlcEventRecorder *htlcswitch.HtlcEventRecorder
+
 	witnessBeacon contractcourt.WitnessBeacon
 
 	breachArbiter *breachArbiter
@@ -246,10 +262,16 @@ type server struct {
 
 	controlTower routing.ControlTower
 
+	recurringPayments *recurring.Scheduler
+
 	authGossiper *discovery.AuthenticatedGossiper
 
 	localChanMgr *localchans.Manager
 
+	// feeAutopilot adjusts the fees of our channels. It is nil if the fee
+	// autopilot is disabled.
+	feeAutopilot *localchans.FeeAutopilot
+
 	utxoNursery *utxoNursery
 
 	sweeper *sweep.UtxoSweeper
@@ -362,8 +384,8 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 	var (
 		err           error
 		nodeKeyECDH   = keychain.NewPubKeyECDH(*nodeKeyDesc, cc.KeyRing)
-		nodeKeySigner = keychain.NewPubKeyDigestSigner(
-			*nodeKeyDesc, cc.KeyRing,
+		nodeKeySigner = keychain.NewPubKeyMessageSigner(
+			nodeKeyDesc.PubKey, nodeKeyDesc.KeyLocator, cc.KeyRing,
 		)
 	)
 
@@ -431,6 +453,51 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		GcCanceledInvoicesOnStartup: cfg.GcCanceledInvoicesOnStartup,
 		GcCanceledInvoicesOnTheFly:  cfg.GcCanceledInvoicesOnTheFly,
 		KeysendHoldTime:             cfg.KeysendHoldTime,
+		ArchiveSettledInvoicesAfter: cfg.ArchiveSettledInvoicesAfter,
+		UnresolvedHtlcHashes: func() (map[lntypes.Hash]struct{},
+			error) {
+
+			return unresolvedHtlcHashes(remoteChanDB)
+		},
+		AcceptStatelessInvoices: cfg.AcceptStatelessInvoices,
+	}
+
+	// The spontaneous payment policy is built from the plain config
+	// options, and validated before the registry enforces it.
+	spontaneous := cfg.Spontaneous
+	registryConfig.SpontaneousPolicy = invoices.SpontaneousPolicy{
+		MinAmt:                lnwire.MilliSatoshi(spontaneous.MinAmtMsat),
+		MaxAmt:                lnwire.MilliSatoshi(spontaneous.MaxAmtMsat),
+		RestrictCustomRecords: spontaneous.RestrictCustomRecords,
+		AllowedCustomRecords:  spontaneous.AllowedCustomRecords,
+		RateLimit:             spontaneous.RateLimit,
+		RateLimitInterval:     spontaneous.RateLimitInterval,
+		InvoiceLabel:          spontaneous.InvoiceLabel,
+	}
+	if err := registryConfig.SpontaneousPolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid spontaneous payment policy: %v",
+			err)
+	}
+
+	// If we accept stateless invoices, derive the secret from which their
+	// preimages are derived. The secret needs to be stable across
+	// restarts, so that invoices created earlier can still be paid.
+	if cfg.AcceptStatelessInvoices {
+		statelessKey, err := cc.KeyRing.DerivePrivKey(
+			keychain.KeyDescriptor{
+				KeyLocator: keychain.KeyLocator{
+					Family: keychain.KeyFamilyStatelessInvoice,
+					Index:  0,
+				},
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive stateless "+
+				"invoice key: %v", err)
+		}
+		registryConfig.StatelessInvoiceSecret = sha256.Sum256(
+			statelessKey.Serialize(),
+		)
 	}
 
 	s := &server{
@@ -488,12 +555,60 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		clock.NewDefaultClock(), cfg.Invoices.HoldExpiryDelta,
 		uint32(currentHeight), currentHash, cc.ChainNotifier,
 	)
+
+	// Spontaneous payments are rate limited per source route. The only hop
+	// of a source route that is visible to us is the peer it reaches us
+	// through, which we look up from the incoming channel.
+	var selfKey [33]byte
+	copy(selfKey[:], nodeKeyDesc.PubKey.SerializeCompressed())
+	registryConfig.IncomingPeer = func(
+		chanID lnwire.ShortChannelID) ([33]byte, error) {
+
+		info, _, _, err := s.localChanDB.ChannelGraph().
+			FetchChannelEdgesByID(chanID.ToUint64())
+		if err != nil {
+			return [33]byte{}, err
+		}
+
+		if info.NodeKey1Bytes == selfKey {
+			return info.NodeKey2Bytes, nil
+		}
+
+		return info.NodeKey1Bytes, nil
+	}
+
 	s.invoices = invoices.NewRegistry(
 		remoteChanDB, expiryWatcher, &registryConfig,
 	)
 
 	s.htlcNotifier = htlcswitch.NewHtlcNotifier(time.Now)
 
+	if cfg.RecordHtlcEvents {
+		s.htlcEventRecorder = htlcswitch.NewHtlcEventRecorder(
+			&htlcswitch.HtlcEventRecorderConfig{
+				SubscribeHtlcEvents: s.htlcNotifier.SubscribeHtlcEvents,
+				AddHtlcEvents:       remoteChanDB.HtlcEventLog().AddHtlcEvents,
+				FlushTicker: ticker.New(
+					htlcswitch.DefaultFwdEventInterval,
+				),
+				MaxInFlight: htlcswitch.DefaultMaxInFlightForwards,
+			},
+		)
+	}
+
+	s.peerAddLimiters = htlcswitch.NewPeerAddLimiters(
+		htlcswitch.AddRateLimit{
+			Rate:  rate.Limit(cfg.HtlcLimits.PeerAddRate),
+			Burst: cfg.HtlcLimits.PeerAddBurst,
+		}, clock.NewDefaultClock(),
+	)
+	s.chanAddLimiters = htlcswitch.NewChannelAddLimiters(
+		htlcswitch.AddRateLimit{
+			Rate:  rate.Limit(cfg.HtlcLimits.ChannelAddRate),
+			Burst: cfg.HtlcLimits.ChannelAddBurst,
+		}, clock.NewDefaultClock(),
+	)
+
 	s.htlcSwitch, err = htlcswitch.New(htlcswitch.Config{
 		DB: remoteChanDB,
 		LocalChannelClose: func(pubKey []byte,
@@ -802,6 +917,31 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		return nil, fmt.Errorf("can't create router: %v", err)
 	}
 
+	s.recurringPayments = recurring.New(&recurring.Config{
+		Store:       remoteChanDB,
+		SendPayment: s.chanRouter.SendPaymentAsync,
+		SubscribePayment: func(id lntypes.Hash) (<-chan interface{},
+			func(), error) {
+
+			sub, err := s.controlTower.SubscribePayment(id)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return sub.Updates, sub.Close, nil
+		},
+		FetchPayment:          s.controlTower.FetchPayment,
+		ActiveNetParams:       cfg.ActiveNetParams.Params,
+		KeysendFinalCltvDelta: recurring.DefaultKeysendFinalCltvDelta,
+		MaxTotalTimelock:      cfg.MaxOutgoingCltvExpiry,
+		MaxParts:              routerrpc.DefaultMaxParts,
+		PayAttemptTimeout:     recurring.DefaultPayAttemptTimeout,
+		MaxAttempts:           recurring.DefaultMaxAttempts,
+		MinBackoff:            recurring.DefaultMinBackoff,
+		MaxBackoff:            recurring.DefaultMaxBackoff,
+		Clock:                 clock.NewDefaultClock(),
+	})
+
 	chanSeries := discovery.NewChanSeries(s.localChanDB.ChannelGraph())
 	gossipMessageStore, err := discovery.NewMessageStore(s.remoteChanDB)
 	if err != nil {
@@ -850,6 +990,32 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		FetchChannel:              s.remoteChanDB.FetchChannel,
 	}
 
+	if cfg.FeeAutopilot.Active {
+		bounds, chanBounds := feeAutopilotBounds(cfg.FeeAutopilot)
+		s.feeAutopilot = localchans.NewFeeAutopilot(
+			&localchans.FeeAutopilotConfig{
+				ForAllOutgoingChannels: s.chanRouter.ForAllOutgoingChannels,
+				FetchChannel:           s.remoteChanDB.FetchChannel,
+				QueryForwardingLog:     s.remoteChanDB.ForwardingLog().Query,
+				UpdatePolicies:         s.localChanMgr.UpdatePolicies,
+				Ticker: ticker.New(
+					cfg.FeeAutopilot.Interval,
+				),
+				Clock:              clock.NewDefaultClock(),
+				DryRun:             cfg.FeeAutopilot.DryRun,
+				Bounds:             bounds,
+				ChannelBounds:      chanBounds,
+				SkipChannels:       cfg.FeeAutopilot.SkipChannels,
+				BaseFeeStep:        lnwire.MilliSatoshi(cfg.FeeAutopilot.BaseFeeStep),
+				FeeRateStep:        cfg.FeeAutopilot.FeeRateStep,
+				LowLiquidityRatio:  cfg.FeeAutopilot.LowLiquidityRatio,
+				HighLiquidityRatio: cfg.FeeAutopilot.HighLiquidityRatio,
+				ForwardingWindow:   cfg.FeeAutopilot.ForwardingWindow,
+				MinUpdateInterval:  cfg.FeeAutopilot.MinUpdateInterval,
+			},
+		)
+	}
+
 	utxnStore, err := newNurseryStore(s.cfg.ActiveNetParams.GenesisHash, remoteChanDB)
 	if err != nil {
 		srvrLog.Errorf("unable to create nursery store: %v", err)
@@ -1008,6 +1174,7 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		OnionProcessor:                s.sphinx,
 		PaymentsExpirationGracePeriod: cfg.PaymentsExpirationGracePeriod,
 		IsForwardedHTLC:               s.htlcSwitch.IsForwardedHTLC,
+		FindIncomingHTLCExpiry:        s.htlcSwitch.FindIncomingHTLCExpiry,
 		Clock:                         clock.NewDefaultClock(),
 	}, remoteChanDB)
 
@@ -1043,6 +1210,7 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 	s.fundingMgr, err = funding.NewFundingManager(funding.Config{
 		NoWumboChans:       !cfg.ProtocolOptions.Wumbo(),
 		IDKey:              nodeKeyECDH.PubKey(),
+		IDKeyLoc:           nodeKeyDesc.KeyLocator,
 		Wallet:             cc.Wallet,
 		PublishTransaction: cc.Wallet.PublishTransaction,
 		UpdateLabel: func(hash chainhash.Hash, label string) error {
@@ -1050,14 +1218,10 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		},
 		Notifier:     cc.ChainNotifier,
 		FeeEstimator: cc.FeeEstimator,
-		SignMessage: func(pubKey *btcec.PublicKey,
-			msg []byte) (input.Signature, error) {
+		SignMessage: func(keyLoc keychain.KeyLocator,
+			msg []byte) (*btcec.Signature, error) {
 
-			if pubKey.IsEqual(nodeKeyECDH.PubKey()) {
-				return s.nodeSigner.SignMessage(pubKey, msg)
-			}
-
-			return cc.MsgSigner.SignMessage(pubKey, msg)
+			return cc.KeyRing.SignMessage(keyLoc, msg, true)
 		},
 		CurrentNodeAnnouncement: func() (lnwire.NodeAnnouncement, error) {
 			return s.genNodeAnnouncement(true)
@@ -1509,6 +1673,11 @@ func (s *server) Start() error {
 			go s.watchExternalIP()
 		}
 
+		if s.cfg.AnchorReserveCheckInterval != 0 {
+			s.wg.Add(1)
+			go s.watchAnchorReserve()
+		}
+
 		if s.hostAnn != nil {
 			if err := s.hostAnn.Start(); err != nil {
 				startErr = err
@@ -1573,6 +1742,14 @@ func (s *server) Start() error {
 		}
 		cleanup = cleanup.add(s.htlcNotifier.Stop)
 
+		if s.htlcEventRecorder != nil {
+			if err := s.htlcEventRecorder.Start(); err != nil {
+				startErr = err
+				return
+			}
+			cleanup = cleanup.add(s.htlcEventRecorder.Stop)
+		}
+
 		if err := s.sphinx.Start(); err != nil {
 			startErr = err
 			return
@@ -1636,6 +1813,12 @@ func (s *server) Start() error {
 		}
 		cleanup = cleanup.add(s.chanRouter.Stop)
 
+		if err := s.recurringPayments.Start(); err != nil {
+			startErr = err
+			return
+		}
+		cleanup = cleanup.add(s.recurringPayments.Stop)
+
 		if err := s.fundingMgr.Start(); err != nil {
 			startErr = err
 			return
@@ -1654,6 +1837,14 @@ func (s *server) Start() error {
 		}
 		cleanup = cleanup.add(s.chanStatusMgr.Stop)
 
+		if s.feeAutopilot != nil {
+			if err := s.feeAutopilot.Start(); err != nil {
+				startErr = err
+				return
+			}
+			cleanup = cleanup.add(s.feeAutopilot.Stop)
+		}
+
 		if err := s.chanEventStore.Start(); err != nil {
 			startErr = err
 			return
@@ -1822,9 +2013,19 @@ func (s *server) Stop() error {
 
 		// Shutdown the wallet, funding manager, and the rpc server.
 		s.chanStatusMgr.Stop()
+		if s.feeAutopilot != nil {
+			if err := s.feeAutopilot.Stop(); err != nil {
+				srvrLog.Warnf("failed to stop feeAutopilot: %v",
+					err)
+			}
+		}
 		if err := s.cc.ChainNotifier.Stop(); err != nil {
 			srvrLog.Warnf("Unable to stop ChainNotifier: %v", err)
 		}
+		if err := s.recurringPayments.Stop(); err != nil {
+			srvrLog.Warnf("failed to stop recurring payment "+
+				"scheduler: %v", err)
+		}
 		if err := s.chanRouter.Stop(); err != nil {
 			srvrLog.Warnf("failed to stop chanRouter: %v", err)
 		}
@@ -1855,6 +2056,12 @@ func (s *server) Stop() error {
 		if err := s.peerNotifier.Stop(); err != nil {
 			srvrLog.Warnf("failed to stop peerNotifier: %v", err)
 		}
+		if s.htlcEventRecorder != nil {
+			if err := s.htlcEventRecorder.Stop(); err != nil {
+				srvrLog.Warnf("failed to stop "+
+					"htlcEventRecorder: %v", err)
+			}
+		}
 		if err := s.htlcNotifier.Stop(); err != nil {
 			srvrLog.Warnf("failed to stop htlcNotifier: %v", err)
 		}
@@ -1971,6 +2178,40 @@ func (s *server) removePortForwarding() {
 	}
 }
 
+// watchAnchorReserve checks on startup and then every
+// AnchorReserveCheckInterval whether the wallet balance covers the value
+// reserved for fee bumping anchor channels, and logs a warning if it doesn't.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *server) watchAnchorReserve() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.AnchorReserveCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		reserve, err := s.cc.Wallet.CheckAnchorReserve()
+		switch {
+		case err != nil:
+			srvrLog.Errorf("Unable to check the value reserved for "+
+				"fee bumping anchor channels: %v", err)
+
+		case reserve.Shortfall() > 0:
+			srvrLog.Warnf("Wallet balance of %v is %v below the %v "+
+				"reserved for fee bumping %d anchor channels, "+
+				"force closes may not confirm in time",
+				reserve.Balance, reserve.Shortfall(),
+				reserve.Reserved, reserve.NumAnchorChans)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
 // watchExternalIP continuously checks for an updated external IP address every
 // 15 minutes. Once a new IP address has been detected, it will automatically
 // handle port forwarding rules and send updated node announcements to the
@@ -3276,12 +3517,19 @@ func (s *server) peerConnected(conn net.Conn, connReq *connmgr.ConnReq,
 			s.cfg.MaxCommitFeeRateAnchors * 1000).FeePerKWeight(),
 		ChannelCommitInterval:  s.cfg.ChannelCommitInterval,
 		ChannelCommitBatchSize: s.cfg.ChannelCommitBatchSize,
+		ChannelAddLimiters:     s.chanAddLimiters,
+		ReservedSlotFraction:   s.cfg.HtlcLimits.ReservedSlotFraction,
+		ReservedValueFraction:  s.cfg.HtlcLimits.ReservedValueFraction,
 		Quit:                   s.quit,
 	}
 
 	copy(pCfg.PubKeyBytes[:], peerAddr.IdentityKey.SerializeCompressed())
 	copy(pCfg.ServerPubKey[:], s.identityECDH.PubKey().SerializeCompressed())
 
+	// The limiter is released by the peer termination watcher once the
+	// peer disconnects.
+	pCfg.PeerAddLimiter = s.peerAddLimiters.Acquire(pCfg.PubKeyBytes)
+
 	p := peer.NewBrontide(pCfg)
 
 	// TODO(roasbeef): update IP address for link-node
@@ -3447,6 +3695,10 @@ func (s *server) peerTerminationWatcher(p *peer.Brontide, ready chan struct{}) {
 		s.htlcSwitch.RemoveLink(link.ChanID())
 	}
 
+	// The peer's links are gone, so we release the peer's rate limiter.
+	// It is discarded once its bucket is full again.
+	s.peerAddLimiters.Release(p.PubKey())
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -3940,6 +4192,91 @@ func (s *server) applyChannelUpdate(update *lnwire.ChannelUpdate) error {
 	}
 }
 
+// unresolvedHtlcHashes returns the payment hashes of all incoming htlcs that
+// are still part of the commitments of our open channels, or of closed
+// channels whose contracts haven't been fully resolved yet.
+func unresolvedHtlcHashes(chanDB *channeldb.DB) (map[lntypes.Hash]struct{},
+	error) {
+
+	hashes := make(map[lntypes.Hash]struct{})
+	addHtlcs := func(channel *channeldb.OpenChannel) {
+		commitments := []channeldb.ChannelCommitment{
+			channel.LocalCommitment, channel.RemoteCommitment,
+		}
+		for _, commitment := range commitments {
+			for _, htlc := range commitment.Htlcs {
+				if htlc.Incoming {
+					hashes[htlc.RHash] = struct{}{}
+				}
+			}
+		}
+	}
+
+	// Open channels include the channels that wait for their closing
+	// transaction to confirm.
+	channels, err := chanDB.FetchAllChannels()
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		addHtlcs(channel)
+	}
+
+	// Once the closing transaction confirmed, the htlcs of a channel are
+	// resolved on chain, using the commitments we kept of the channel.
+	closed, err := chanDB.FetchClosedChannels(true)
+	if err != nil {
+		return nil, err
+	}
+	for _, summary := range closed {
+		channel, err := chanDB.FetchHistoricalChannel(
+			&summary.ChanPoint,
+		)
+		switch {
+		// Channels that were closed before we started to keep their
+		// commitments can't be checked.
+		case err == channeldb.ErrNoHistoricalBucket ||
+			err == channeldb.ErrChannelNotFound:
+
+			continue
+
+		case err != nil:
+			return nil, err
+		}
+
+		addHtlcs(channel)
+	}
+
+	return hashes, nil
+}
+
+// feeAutopilotBounds converts the fee bounds of the fee autopilot config into
+// the default bounds and the per channel bounds of the fee autopilot. Channel
+// bounds only override the fee rate, the base fee bounds are shared.
+func feeAutopilotBounds(cfg *lncfg.FeeAutopilot) (localchans.FeeBounds,
+	map[lnwire.ShortChannelID]localchans.FeeBounds) {
+
+	bounds := localchans.FeeBounds{
+		MinBaseFee: lnwire.MilliSatoshi(cfg.MinBaseFee),
+		MaxBaseFee: lnwire.MilliSatoshi(cfg.MaxBaseFee),
+		MinFeeRate: cfg.MinFeeRate,
+		MaxFeeRate: cfg.MaxFeeRate,
+	}
+
+	chanBounds := make(
+		map[lnwire.ShortChannelID]localchans.FeeBounds,
+		len(cfg.ChannelBounds),
+	)
+	for scid, rateBounds := range cfg.ChannelBounds {
+		b := bounds
+		b.MinFeeRate = rateBounds.MinFeeRate
+		b.MaxFeeRate = rateBounds.MaxFeeRate
+		chanBounds[scid] = b
+	}
+
+	return bounds, chanBounds
+}
+
 // newSweepPkScriptGen creates closure that generates a new public key script
 // which should be used to sweep any funds into the on-chain wallet.
 // Specifically, the script generated is a version 0, pay-to-witness-pubkey-hash
@@ -3958,3 +4295,18 @@ func newSweepPkScriptGen(
 		return txscript.PayToAddrScript(sweepAddr)
 	}
 }
+
+// queryHtlcEvents queries the htlc event log. Before the query is performed,
+// any resolved htlcs that have not been written to disk yet are flushed so
+// that the result reflects the current state.
+func (s *server) queryHtlcEvents(q channeldb.HtlcEventQuery) (
+	channeldb.HtlcEventTimeSlice, error) {
+
+	if s.htlcEventRecorder != nil {
+		if err := s.htlcEventRecorder.FlushHtlcEvents(); err != nil {
+			return channeldb.HtlcEventTimeSlice{}, err
+		}
+	}
+
+	return s.remoteChanDB.HtlcEventLog().Query(q)
+}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btclog"
 	"github.com/lightningnetwork/lnd/autopilot"
 	"github.com/lightningnetwork/lnd/chainreg"
@@ -173,6 +174,32 @@ func (s *subRPCServerConfigs) PopulateDependencies(cfg *Config,
 			subCfgValue.FieldByName("ChainParams").Set(
 				reflect.ValueOf(activeNetParams),
 			)
+			subCfgValue.FieldByName("CoinType").Set(
+				reflect.ValueOf(cfg.ActiveNetParams.CoinType),
+			)
+
+			// A transaction funds one of our channels if it
+			// creates the funding output of a pending channel.
+			isChannelFundingTx := func(txid chainhash.Hash) (bool,
+				error) {
+
+				pending, err :=
+					remoteChanDB.FetchPendingChannels()
+				if err != nil {
+					return false, err
+				}
+
+				for _, channel := range pending {
+					if channel.FundingOutpoint.Hash == txid {
+						return true, nil
+					}
+				}
+
+				return false, nil
+			}
+			subCfgValue.FieldByName("IsChannelFundingTx").Set(
+				reflect.ValueOf(isChannelFundingTx),
+			)
 
 		case *autopilotrpc.Config:
 			subCfgValue := extractReflectValue(subCfg)
@@ -0,0 +1,74 @@
+package sweep
+
+import (
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+)
+
+// deadlineFeeRate is the fee function used for inputs that need to confirm
+// before a deadline. It linearly raises the fee rate from startFeeRate at
+// startHeight to maxFeeRate at deadlineHeight, so that the fee rate is bumped
+// every block as the deadline approaches. Once the deadline is reached, the
+// maximum fee rate is used.
+func deadlineFeeRate(startFeeRate, maxFeeRate chainfee.SatPerKWeight,
+	startHeight, deadlineHeight,
+	currentHeight int32) chainfee.SatPerKWeight {
+
+	switch {
+	case startFeeRate >= maxFeeRate:
+		return maxFeeRate
+
+	case currentHeight >= deadlineHeight || startHeight >= deadlineHeight:
+		return maxFeeRate
+
+	case currentHeight <= startHeight:
+		return startFeeRate
+	}
+
+	elapsed := chainfee.SatPerKWeight(currentHeight - startHeight)
+	width := chainfee.SatPerKWeight(deadlineHeight - startHeight)
+
+	return startFeeRate + (maxFeeRate-startFeeRate)*elapsed/width
+}
+
+// budgetFeeRate returns the highest fee rate at which sweeping the given input
+// by itself doesn't pay more than the given fee budget. For inputs with an
+// unconfirmed parent, this is the package fee rate of the parent and the sweep
+// transaction, where the fee already paid by the parent is added to the budget.
+//
+// Inputs whose own value can't pay the budget, like anchors and the zero-fee
+// second-level HTLC transactions of anchor channels, are swept with a wallet
+// input paying for the fees, so the weight of that input is accounted for too.
+// The sweep output then is the change output of the wallet input.
+func budgetFeeRate(inp input.Input,
+	budget btcutil.Amount) (chainfee.SatPerKWeight, error) {
+
+	weightEstimate := newWeightEstimator(0)
+	if err := weightEstimate.add(inp); err != nil {
+		return 0, err
+	}
+
+	var available btcutil.Amount
+	if signDesc := inp.SignDesc(); signDesc.Output != nil {
+		available = btcutil.Amount(signDesc.Output.Value)
+	}
+	if reqOut := inp.RequiredTxOut(); reqOut != nil {
+		weightEstimate.addOutput(reqOut)
+		available -= btcutil.Amount(reqOut.Value)
+	}
+	if available < budget {
+		weightEstimate.addP2WKHInput()
+	}
+	weightEstimate.addP2WKHOutput()
+
+	weight := int64(weightEstimate.weight())
+	if parent := inp.UnconfParent(); parent != nil {
+		budget += parent.Fee
+		weight += parent.Weight
+	}
+
+	return chainfee.SatPerKWeight(
+		budget * 1000 / btcutil.Amount(weight),
+	), nil
+}
@@ -0,0 +1,154 @@
+package sweep
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeadlineFeeRate tests that the deadline fee function linearly raises the
+// fee rate towards the maximum fee rate as the deadline approaches.
+func TestDeadlineFeeRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		startFeeRate    chainfee.SatPerKWeight
+		maxFeeRate      chainfee.SatPerKWeight
+		currentHeight   int32
+		expectedFeeRate chainfee.SatPerKWeight
+	}{
+		{
+			name:            "start height",
+			startFeeRate:    1000,
+			maxFeeRate:      11000,
+			currentHeight:   100,
+			expectedFeeRate: 1000,
+		},
+		{
+			name:            "before start height",
+			startFeeRate:    1000,
+			maxFeeRate:      11000,
+			currentHeight:   90,
+			expectedFeeRate: 1000,
+		},
+		{
+			name:            "one block later",
+			startFeeRate:    1000,
+			maxFeeRate:      11000,
+			currentHeight:   101,
+			expectedFeeRate: 2000,
+		},
+		{
+			name:            "half way",
+			startFeeRate:    1000,
+			maxFeeRate:      11000,
+			currentHeight:   105,
+			expectedFeeRate: 6000,
+		},
+		{
+			name:            "deadline",
+			startFeeRate:    1000,
+			maxFeeRate:      11000,
+			currentHeight:   110,
+			expectedFeeRate: 11000,
+		},
+		{
+			name:            "past deadline",
+			startFeeRate:    1000,
+			maxFeeRate:      11000,
+			currentHeight:   120,
+			expectedFeeRate: 11000,
+		},
+		{
+			name:            "start fee rate above maximum",
+			startFeeRate:    12000,
+			maxFeeRate:      11000,
+			currentHeight:   100,
+			expectedFeeRate: 11000,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			feeRate := deadlineFeeRate(
+				test.startFeeRate, test.maxFeeRate, 100, 110,
+				test.currentHeight,
+			)
+			require.Equal(t, test.expectedFeeRate, feeRate)
+		})
+	}
+}
+
+// TestBudgetFeeRate tests that the budget fee rate of an input accounts for the
+// wallet input paying for its fees if the input can't pay the budget itself,
+// and that for an input with an unconfirmed parent it is the package fee rate
+// of the parent and the sweep transaction.
+func TestBudgetFeeRate(t *testing.T) {
+	t.Parallel()
+
+	const budget = btcutil.Amount(10000)
+
+	// Determine the weight of a transaction sweeping an anchor, with a
+	// wallet input paying for the fees and a change output.
+	anchor := input.MakeBaseInput(
+		&wire.OutPoint{}, input.CommitmentAnchor,
+		&input.SignDescriptor{}, 0, nil,
+	)
+	weightEstimate := newWeightEstimator(0)
+	require.NoError(t, weightEstimate.add(&anchor))
+	weightEstimate.addP2WKHInput()
+	weightEstimate.addP2WKHOutput()
+	weight := btcutil.Amount(weightEstimate.weight())
+
+	feeRate, err := budgetFeeRate(&anchor, budget)
+	require.NoError(t, err)
+	require.Equal(t, chainfee.SatPerKWeight(budget*1000/weight), feeRate)
+
+	// If the anchor is part of an unconfirmed commitment, the fee and the
+	// weight of the commitment are part of the package.
+	parent := &input.TxInfo{
+		Weight: 1000,
+		Fee:    2000,
+	}
+	anchor = input.MakeBaseInput(
+		&wire.OutPoint{}, input.CommitmentAnchor,
+		&input.SignDescriptor{}, 0, parent,
+	)
+
+	feeRate, err = budgetFeeRate(&anchor, budget)
+	require.NoError(t, err)
+	require.Equal(
+		t, chainfee.SatPerKWeight(
+			(budget+parent.Fee)*1000/(weight+1000),
+		), feeRate,
+	)
+
+	// A zero-fee second-level HTLC transaction commits to an output of its
+	// full value, so the sweep transaction also contains that output in
+	// addition to the wallet input and the change output.
+	baseInput := createTestInput(100000, input.HtlcOfferedTimeoutSecondLevel)
+	reqTxOut := &wire.TxOut{
+		PkScript: make([]byte, input.P2WSHSize),
+		Value:    100000,
+	}
+	htlc := &testInput{
+		BaseInput: &baseInput,
+		reqTxOut:  reqTxOut,
+	}
+	weightEstimate = newWeightEstimator(0)
+	require.NoError(t, weightEstimate.add(htlc))
+	weightEstimate.addOutput(reqTxOut)
+	weightEstimate.addP2WKHInput()
+	weightEstimate.addP2WKHOutput()
+	weight = btcutil.Amount(weightEstimate.weight())
+
+	feeRate, err = budgetFeeRate(htlc, budget)
+	require.NoError(t, err)
+	require.Equal(t, chainfee.SatPerKWeight(budget*1000/weight), feeRate)
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/davecgh/go-spew/spew"
@@ -81,6 +82,20 @@ type Params struct {
 	// ExclusiveGroup is an identifier that, if set, prevents other inputs
 	// with the same identifier from being batched together.
 	ExclusiveGroup *uint64
+
+	// DeadlineHeight is the height by which the input should be swept, if
+	// any. For inputs with a deadline, the fee preference only determines
+	// the initial fee rate. The fee rate is then raised every block until
+	// the deadline, where the maximum fee rate allowed by the budget is
+	// reached.
+	DeadlineHeight uint32
+
+	// Budget is the maximum fee, if any, that may be spent on sweeping the
+	// input. It caps the fee rate of the input at the rate at which
+	// sweeping the input by itself would cost the full budget. Without a
+	// budget, the fee rate of an input with a deadline is only limited by
+	// the maximum fee rate of the UtxoSweeper.
+	Budget btcutil.Amount
 }
 
 // ParamsUpdate contains a new set of parameters to update a pending sweep with.
@@ -97,8 +112,9 @@ type ParamsUpdate struct {
 
 // String returns a human readable interpretation of the sweep parameters.
 func (p Params) String() string {
-	return fmt.Sprintf("fee=%v, force=%v, exclusive_group=%v",
-		p.Fee, p.Force, p.ExclusiveGroup)
+	return fmt.Sprintf("fee=%v, force=%v, exclusive_group=%v, "+
+		"deadline_height=%v, budget=%v", p.Fee, p.Force,
+		p.ExclusiveGroup, p.DeadlineHeight, p.Budget)
 }
 
 // pendingInput is created when an input reaches the main loop for the first
@@ -129,6 +145,17 @@ type pendingInput struct {
 	// lastFeeRate is the most recent fee rate used for this input within a
 	// transaction broadcast to the network.
 	lastFeeRate chainfee.SatPerKWeight
+
+	// publishedFeeRate is the fee rate of the last sweep transaction of
+	// this input that was actually published. Replacements of that
+	// transaction need to pay a higher fee rate.
+	publishedFeeRate chainfee.SatPerKWeight
+
+	// startHeight is the height at which the input was offered to the
+	// UtxoSweeper, or the locktime it requires if that is later. For
+	// inputs with a deadline, it is the height at which the fee function
+	// starts raising the fee rate.
+	startHeight int32
 }
 
 // parameters returns the sweep parameters for this input.
@@ -507,6 +534,85 @@ func (s *UtxoSweeper) feeRateForPreference(
 	return feeRate, nil
 }
 
+// feeRateForInput returns the fee rate that the given pending input should be
+// swept with at the given height. For inputs with a deadline, the fee rate is
+// raised every block following the deadline fee function. It is always capped
+// by the fee budget of the input.
+func (s *UtxoSweeper) feeRateForInput(input *pendingInput,
+	currentHeight int32) (chainfee.SatPerKWeight, error) {
+
+	feeRate, err := s.feeRateForPreference(input.params.Fee)
+	if err != nil {
+		return 0, err
+	}
+
+	// Determine the highest fee rate that we're willing to pay for this
+	// input.
+	maxFeeRate := s.cfg.MaxFeeRate
+	if input.params.Budget != 0 {
+		budgetRate, err := budgetFeeRate(input, input.params.Budget)
+		if err != nil {
+			return 0, err
+		}
+		if budgetRate < maxFeeRate {
+			maxFeeRate = budgetRate
+		}
+	}
+
+	// Without a deadline, the fee preference determines the fee rate.
+	if input.params.DeadlineHeight == 0 {
+		if feeRate > maxFeeRate {
+			feeRate = maxFeeRate
+		}
+
+		return feeRate, nil
+	}
+
+	feeRate = deadlineFeeRate(
+		feeRate, maxFeeRate, input.startHeight,
+		int32(input.params.DeadlineHeight), currentHeight,
+	)
+
+	// A new sweep transaction replaces the one that was published before,
+	// so BIP125 requires it to pay at least the minimum relay fee rate on
+	// top of the previous fee rate. Make sure we always bump by at least
+	// that much, unless we're already at the maximum fee rate.
+	if input.publishedFeeRate != 0 {
+		minFeeRate := input.publishedFeeRate + s.relayFeeRate
+		if feeRate < minFeeRate {
+			feeRate = minFeeRate
+		}
+		if feeRate > maxFeeRate {
+			feeRate = maxFeeRate
+		}
+	}
+
+	// Never go below the minimum relay fee rate, even if the budget is
+	// too small to cover it.
+	if feeRate < s.relayFeeRate {
+		feeRate = s.relayFeeRate
+	}
+
+	return feeRate, nil
+}
+
+// feeStartHeight returns the height from which the fee rate of an input with a
+// deadline is raised, given the height at which it was offered. Inputs that
+// require a locktime in the future can't be swept before it is reached, so
+// raising the fee rate before then would only skip part of the fee function.
+func feeStartHeight(inp input.Input, offerHeight int32) int32 {
+	lt, ok := inp.RequiredLockTime()
+	if !ok || lt >= txscript.LockTimeThreshold {
+		return offerHeight
+	}
+
+	if int32(lt) > offerHeight {
+		return int32(lt)
+	}
+
+	return offerHeight
+}
+
 // collector is the sweeper main loop. It processes new inputs, spend
 // notifications and counts down to publication of the sweep tx.
 func (s *UtxoSweeper) collector(blockEpochs <-chan *chainntnfs.BlockEpoch) {
@@ -539,6 +645,9 @@ func (s *UtxoSweeper) collector(blockEpochs <-chan *chainntnfs.BlockEpoch) {
 				// change to the unconfirmed parent tx info.
 				pendInput.params = input.params
 				pendInput.Input = input.input
+				pendInput.startHeight = feeStartHeight(
+					input.input, pendInput.startHeight,
+				)
 
 				// Add additional result channel to signal
 				// spend of this input.
@@ -557,6 +666,9 @@ func (s *UtxoSweeper) collector(blockEpochs <-chan *chainntnfs.BlockEpoch) {
 				Input:            input.input,
 				minPublishHeight: bestHeight,
 				params:           input.params,
+				startHeight: feeStartHeight(
+					input.input, bestHeight,
+				),
 			}
 			s.pendingInputs[outpoint] = pendInput
 
@@ -676,7 +788,7 @@ func (s *UtxoSweeper) collector(blockEpochs <-chan *chainntnfs.BlockEpoch) {
 			// this to ensure any inputs which have had their fee
 			// rate bumped are broadcast first in order enforce the
 			// RBF policy.
-			inputClusters := s.createInputClusters()
+			inputClusters := s.createInputClusters(bestHeight)
 			sort.Slice(inputClusters, func(i, j int) bool {
 				return inputClusters[i].sweepFeeRate >
 					inputClusters[j].sweepFeeRate
@@ -783,16 +895,20 @@ func (s *UtxoSweeper) bucketForFeeRate(
 // inputs known by the UtxoSweeper. It clusters inputs by
 // 1) Required tx locktime
 // 2) Similar fee rates
-func (s *UtxoSweeper) createInputClusters() []inputCluster {
+func (s *UtxoSweeper) createInputClusters(currentHeight int32) []inputCluster {
 	inputs := s.pendingInputs
 
 	// We start by getting the inputs clusters by locktime. Since the
 	// inputs commit to the locktime, they can only be clustered together
 	// if the locktime is equal.
-	lockTimeClusters, nonLockTimeInputs := s.clusterByLockTime(inputs)
+	lockTimeClusters, nonLockTimeInputs := s.clusterByLockTime(
+		inputs, currentHeight,
+	)
 
 	// Cluster the the remaining inputs by sweep fee rate.
-	feeClusters := s.clusterBySweepFeeRate(nonLockTimeInputs)
+	feeClusters := s.clusterBySweepFeeRate(
+		nonLockTimeInputs, currentHeight,
+	)
 
 	// Since the inputs that we clustered by fee rate don't commit to a
 	// specific locktime, we can try to merge a locktime cluster with a fee
@@ -805,8 +921,8 @@ func (s *UtxoSweeper) createInputClusters() []inputCluster {
 // is determined by calculating the average fee rate of all inputs within that
 // cluster. In addition to the created clusters, inputs that did not specify a
 // required lock time are returned.
-func (s *UtxoSweeper) clusterByLockTime(inputs pendingInputs) ([]inputCluster,
-	pendingInputs) {
+func (s *UtxoSweeper) clusterByLockTime(inputs pendingInputs,
+	currentHeight int32) ([]inputCluster, pendingInputs) {
 
 	locktimes := make(map[uint32]pendingInputs)
 	inputFeeRates := make(map[wire.OutPoint]chainfee.SatPerKWeight)
@@ -820,6 +936,20 @@ func (s *UtxoSweeper) clusterByLockTime(inputs pendingInputs) ([]inputCluster,
 			continue
 		}
 
+		// Hold back inputs whose locktime hasn't been reached yet. A
+		// transaction with such a locktime can't be included in the
+		// next block, and would prevent the inputs it is clustered
+		// with from being swept. This is the case for second-level
+		// HTLC timeout transactions that are offered before the HTLC
+		// expires. Once it expires, all timeout transactions sharing
+		// the same expiry are swept together.
+		if lt < txscript.LockTimeThreshold && int32(lt) > currentHeight {
+			log.Debugf("Holding back input %v until locktime %v "+
+				"is reached", op, lt)
+
+			continue
+		}
+
 		// Check if we already have inputs with this locktime.
 		p, ok := locktimes[lt]
 		if !ok {
@@ -830,7 +960,7 @@ func (s *UtxoSweeper) clusterByLockTime(inputs pendingInputs) ([]inputCluster,
 		locktimes[lt] = p
 
 		// We also get the preferred fee rate for this input.
-		feeRate, err := s.feeRateForPreference(input.params.Fee)
+		feeRate, err := s.feeRateForInput(input, currentHeight)
 		if err != nil {
 			log.Warnf("Skipping input %v: %v", op, err)
 			continue
@@ -866,14 +996,16 @@ func (s *UtxoSweeper) clusterByLockTime(inputs pendingInputs) ([]inputCluster,
 // and clusters those together with similar fee rates. Each cluster contains a
 // sweep fee rate, which is determined by calculating the average fee rate of
 // all inputs within that cluster.
-func (s *UtxoSweeper) clusterBySweepFeeRate(inputs pendingInputs) []inputCluster {
+func (s *UtxoSweeper) clusterBySweepFeeRate(inputs pendingInputs,
+	currentHeight int32) []inputCluster {
+
 	bucketInputs := make(map[int]*bucketList)
 	inputFeeRates := make(map[wire.OutPoint]chainfee.SatPerKWeight)
 
 	// First, we'll group together all inputs with similar fee rates. This
 	// is done by determining the fee rate bucket they should belong in.
 	for op, input := range inputs {
-		feeRate, err := s.feeRateForPreference(input.params.Fee)
+		feeRate, err := s.feeRateForInput(input, currentHeight)
 		if err != nil {
 			log.Warnf("Skipping input %v: %v", op, err)
 			continue
@@ -1041,7 +1173,7 @@ func (s *UtxoSweeper) scheduleSweep(currentHeight int32) error {
 
 	// We'll only start our timer once we have inputs we're able to sweep.
 	startTimer := false
-	for _, cluster := range s.createInputClusters() {
+	for _, cluster := range s.createInputClusters(currentHeight) {
 		// Examine pending inputs and try to construct lists of inputs.
 		// We don't need to obtain the coin selection lock, because we
 		// just need an indication as to whether we can sweep. More
@@ -1246,6 +1378,23 @@ func (s *UtxoSweeper) sweep(inputs inputSet, feeRate chainfee.SatPerKWeight,
 
 		// Record another publish attempt.
 		pi.publishAttempts++
+		if err == nil {
+			pi.publishedFeeRate = feeRate
+		}
+
+		// Inputs with a deadline are bumped every block until they
+		// confirm. We never give up on them, as they'd be lost to the
+		// remote party otherwise.
+		if pi.params.DeadlineHeight != 0 {
+			pi.minPublishHeight = currentHeight + 1
+
+			log.Debugf("Rescheduling deadline input %v after %v "+
+				"attempts at height %v (deadline %v)",
+				input.PreviousOutPoint, pi.publishAttempts,
+				pi.minPublishHeight, pi.params.DeadlineHeight)
+
+			continue
+		}
 
 		// We don't care what the result of the publish call was. Even
 		// if it is published successfully, it can still be that it
@@ -1505,6 +1654,12 @@ func (s *UtxoSweeper) ListSweeps() ([]chainhash.Hash, error) {
 	return s.cfg.Store.ListSweeps()
 }
 
+// IsSweepTx returns whether the transaction with the given hash was published
+// by the sweeper.
+func (s *UtxoSweeper) IsSweepTx(hash chainhash.Hash) (bool, error) {
+	return s.cfg.Store.IsOurTx(hash)
+}
+
 // init initializes the random generator for random input rescheduling.
 func init() {
 	rand.Seed(time.Now().Unix())
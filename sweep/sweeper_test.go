@@ -1253,6 +1253,128 @@ func TestBumpFeeRBF(t *testing.T) {
 	ctx.finish(1)
 }
 
+// TestDeadlineFeeBump asserts that the fee rate of an input with a deadline is
+// raised every block until the maximum fee rate allowed by its budget is
+// reached at the deadline.
+func TestDeadlineFeeBump(t *testing.T) {
+	ctx := createSweeperTestContext(t)
+
+	feePref := FeePreference{ConfTarget: 144}
+	startFeeRate := chainfee.FeePerKwFloor
+	ctx.estimator.blocksToFee[feePref.ConfTarget] = startFeeRate
+
+	input := createTestInput(
+		btcutil.SatoshiPerBitcoin, input.CommitmentTimeLock,
+	)
+	budget := btcutil.Amount(10000)
+	deadline := mockChainHeight + 4
+
+	maxFeeRate, err := budgetFeeRate(&input, budget)
+	require.NoError(t, err)
+
+	sweepResult, err := ctx.sweeper.SweepInput(
+		&input, Params{
+			Fee:            feePref,
+			DeadlineHeight: uint32(deadline),
+			Budget:         budget,
+		},
+	)
+	require.NoError(t, err)
+
+	// The first sweep transaction uses the fee rate of the fee preference.
+	ctx.tick()
+	sweepTx := ctx.receiveTx()
+	assertTxFeeRate(t, &sweepTx, startFeeRate, &input)
+
+	// With every new block, the fee rate is raised until it reaches the
+	// maximum fee rate at the deadline.
+	for height := mockChainHeight + 1; height <= deadline; height++ {
+		ctx.notifier.NotifyEpoch(height)
+		ctx.tick()
+
+		expectedFeeRate := deadlineFeeRate(
+			startFeeRate, maxFeeRate, mockChainHeight, deadline,
+			height,
+		)
+		sweepTx := ctx.receiveTx()
+		assertTxFeeRate(t, &sweepTx, expectedFeeRate, &input)
+	}
+
+	// Even though the number of attempts exceeds the maximum number of
+	// sweep attempts, the sweeper keeps sweeping the input at the maximum
+	// fee rate.
+	ctx.notifier.NotifyEpoch(deadline + 1)
+	ctx.tick()
+	sweepTx = ctx.receiveTx()
+	assertTxFeeRate(t, &sweepTx, maxFeeRate, &input)
+
+	ctx.backend.mine()
+	ctx.expectResult(sweepResult, nil)
+
+	ctx.finish(1)
+}
+
+// TestDeadlineFeeBumpLockTime asserts that the fee rate of an input that
+// requires a locktime far in the future isn't raised before the locktime is
+// reached, as the input can't be swept until then.
+func TestDeadlineFeeBumpLockTime(t *testing.T) {
+	ctx := createSweeperTestContext(t)
+
+	feePref := FeePreference{ConfTarget: 144}
+	startFeeRate := chainfee.FeePerKwFloor
+	ctx.estimator.blocksToFee[feePref.ConfTarget] = startFeeRate
+
+	baseInput := createTestInput(
+		btcutil.SatoshiPerBitcoin, input.CommitmentTimeLock,
+	)
+	lockTime := uint32(mockChainHeight + 1000)
+	inp := &testInput{
+		BaseInput: &baseInput,
+		locktime:  &lockTime,
+	}
+	budget := btcutil.Amount(10000)
+	deadline := int32(lockTime) + 4
+
+	maxFeeRate, err := budgetFeeRate(inp, budget)
+	require.NoError(t, err)
+
+	sweepResult, err := ctx.sweeper.SweepInput(
+		inp, Params{
+			Fee:            feePref,
+			DeadlineHeight: uint32(deadline),
+			Budget:         budget,
+		},
+	)
+	require.NoError(t, err)
+
+	// The input is held back until its locktime is reached.
+	ctx.assertNoTick()
+
+	// Once the locktime is reached, the first sweep transaction uses the
+	// fee rate of the fee preference, as the fee function only starts at
+	// the locktime.
+	ctx.notifier.NotifyEpoch(int32(lockTime))
+	ctx.tick()
+	sweepTx := ctx.receiveTx()
+	require.Equal(t, lockTime, sweepTx.LockTime)
+	assertTxFeeRate(t, &sweepTx, startFeeRate, inp)
+
+	// From then on, the fee rate is raised towards the deadline.
+	ctx.notifier.NotifyEpoch(int32(lockTime) + 1)
+	ctx.tick()
+	expectedFeeRate := deadlineFeeRate(
+		startFeeRate, maxFeeRate, int32(lockTime), deadline,
+		int32(lockTime)+1,
+	)
+	sweepTx = ctx.receiveTx()
+	assertTxFeeRate(t, &sweepTx, expectedFeeRate, inp)
+
+	ctx.backend.mine()
+	ctx.expectResult(sweepResult, nil)
+
+	ctx.finish(1)
+}
+
 // TestExclusiveGroup tests the sweeper exclusive group functionality.
 func TestExclusiveGroup(t *testing.T) {
 	ctx := createSweeperTestContext(t)
@@ -1783,6 +1905,101 @@ func TestLockTimes(t *testing.T) {
 	}
 }
 
+// TestAggregateSecondLevel checks that inputs with a required TxOut, like
+// second-level HTLC transactions of anchor channels, are aggregated into a
+// single sweep transaction per locktime, and that inputs with a locktime in the
+// future are held back until the locktime is reached.
+func TestAggregateSecondLevel(t *testing.T) {
+	ctx := createSweeperTestContext(t)
+
+	// Create inputs that commit to an output of their full value, like
+	// zero-fee second-level HTLC transactions. The success transactions
+	// have a locktime of zero, the timeout transactions have the expiry of
+	// the HTLC as locktime.
+	var (
+		results     []chan Result
+		successLT   = uint32(0)
+		timeoutLT   = uint32(mockChainHeight + 2)
+		successOps  = make(map[wire.OutPoint]struct{})
+		timeoutOps  = make(map[wire.OutPoint]struct{})
+		numPerGroup = 3
+	)
+	offer := func(lt *uint32, ops map[wire.OutPoint]struct{}) {
+		for i := 0; i < numPerGroup; i++ {
+			baseInput := createTestInput(
+				int64(100000+i), input.CommitmentTimeLock,
+			)
+			value := baseInput.SignDesc().Output.Value
+			inp := &testInput{
+				BaseInput: &baseInput,
+				locktime:  lt,
+				reqTxOut: &wire.TxOut{
+					PkScript: []byte("aaa"),
+					Value:    value,
+				},
+			}
+
+			result, err := ctx.sweeper.SweepInput(
+				inp, Params{
+					Fee: FeePreference{ConfTarget: 6},
+				},
+			)
+			require.NoError(t, err)
+
+			results = append(results, result)
+			ops[*inp.OutPoint()] = struct{}{}
+		}
+	}
+	offer(&successLT, successOps)
+	offer(&timeoutLT, timeoutOps)
+
+	// assertAggregated asserts that the sweep transaction spends all of
+	// the given inputs, paying to their required outputs first, and uses a
+	// wallet input to pay for the fees.
+	assertAggregated := func(tx wire.MsgTx, lt uint32,
+		ops map[wire.OutPoint]struct{}) {
+
+		require.Equal(t, lt, tx.LockTime)
+		require.Len(t, tx.TxIn, numPerGroup+1)
+		require.Len(t, tx.TxOut, numPerGroup+1)
+
+		for i := 0; i < numPerGroup; i++ {
+			_, ok := ops[tx.TxIn[i].PreviousOutPoint]
+			require.True(t, ok)
+			require.Equal(t, []byte("aaa"), tx.TxOut[i].PkScript)
+		}
+	}
+
+	// Only the success transactions are swept, as the locktime of the
+	// timeout transactions hasn't been reached yet.
+	ctx.tick()
+	assertAggregated(ctx.receiveTx(), successLT, successOps)
+	ctx.assertNoTx()
+
+	ctx.backend.mine()
+	for _, result := range results[:numPerGroup] {
+		ctx.expectResult(result, nil)
+	}
+
+	// One block before the locktime, the timeout transactions are still
+	// held back.
+	ctx.notifier.NotifyEpoch(mockChainHeight + 1)
+	ctx.assertNoTick()
+
+	// Once the locktime is reached, all timeout transactions are swept
+	// together.
+	ctx.notifier.NotifyEpoch(int32(timeoutLT))
+	ctx.tick()
+	assertAggregated(ctx.receiveTx(), timeoutLT, timeoutOps)
+
+	ctx.backend.mine()
+	for _, result := range results[numPerGroup:] {
+		ctx.expectResult(result, nil)
+	}
+
+	ctx.finish(1)
+}
+
 // TestRequiredTxOuts checks that inputs having a required TxOut gets swept with
 // sweep transactions paying into these outputs.
 func TestRequiredTxOuts(t *testing.T) {
@@ -71,6 +71,12 @@ func (w *weightEstimator) tryAddParent(inp input.Input) {
 	w.parentsWeight += unconfParent.Weight
 }
 
+// addP2WKHInput updates the weight estimate to account for an additional
+// native P2WKH input, like a wallet input paying for the fees.
+func (w *weightEstimator) addP2WKHInput() {
+	w.estimator.AddP2WKHInput()
+}
+
 // addP2WKHOutput updates the weight estimate to account for an additional
 // native P2WKH output.
 func (w *weightEstimator) addP2WKHOutput() {
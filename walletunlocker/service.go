@@ -3,12 +3,15 @@ package walletunlocker
 import (
 	"context"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/lightningnetwork/lnd/aezeed"
 	"github.com/lightningnetwork/lnd/chanbackup"
@@ -66,6 +69,19 @@ type WalletInitMsg struct {
 	// initialized stateless, which means no unencrypted macaroons should be
 	// written to disk.
 	StatelessInit bool
+
+	// WatchOnlyAccounts is a map of scoped account extended public keys
+	// that should be imported to create a watch-only wallet. If set,
+	// WalletSeed is nil.
+	WatchOnlyAccounts map[waddrmgr.ScopedIndex]*hdkeychain.ExtendedKey
+
+	// WatchOnlyBirthday is the birthday of the master root key the above
+	// watch-only account xpubs were derived from.
+	WatchOnlyBirthday time.Time
+
+	// WatchOnlyMasterFingerprint is the fingerprint of the master root key
+	// the above watch-only account xpubs were derived from.
+	WatchOnlyMasterFingerprint uint32
 }
 
 // WalletUnlockMsg is a message sent by the UnlockerService when a user wishes
@@ -350,29 +366,45 @@ func (u *UnlockerService) InitWallet(ctx context.Context,
 		return nil, fmt.Errorf("wallet already exists")
 	}
 
-	// At this point, we know that the wallet doesn't already exist. So
-	// we'll map the user provided aezeed and passphrase into a decoded
-	// cipher seed instance.
-	var mnemonic aezeed.Mnemonic
-	copy(mnemonic[:], in.CipherSeedMnemonic[:])
-
-	// If we're unable to map it back into the ciphertext, then either the
-	// mnemonic is wrong, or the passphrase is wrong.
-	cipherSeed, err := mnemonic.ToCipherSeed(in.AezeedPassphrase)
-	if err != nil {
-		return nil, err
-	}
-
-	// With the cipher seed deciphered, and the auth service created, we'll
-	// now send over the wallet password and the seed. This will allow the
-	// daemon to initialize itself and startup.
 	initMsg := &WalletInitMsg{
 		Passphrase:     password,
-		WalletSeed:     cipherSeed,
 		RecoveryWindow: uint32(recoveryWindow),
 		StatelessInit:  in.StatelessInit,
 	}
 
+	// A watch-only wallet is created from the account public keys of the
+	// remote signer instead of a seed.
+	if in.WatchOnly != nil {
+		if len(in.CipherSeedMnemonic) > 0 {
+			return nil, fmt.Errorf("cannot create a watch-only " +
+				"wallet from a seed")
+		}
+
+		err := parseWatchOnly(in.WatchOnly, initMsg)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// At this point, we know that the wallet doesn't already
+		// exist. So we'll map the user provided aezeed and passphrase
+		// into a decoded cipher seed instance.
+		var mnemonic aezeed.Mnemonic
+		copy(mnemonic[:], in.CipherSeedMnemonic[:])
+
+		// If we're unable to map it back into the ciphertext, then
+		// either the mnemonic is wrong, or the passphrase is wrong.
+		cipherSeed, err := mnemonic.ToCipherSeed(in.AezeedPassphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		// With the cipher seed deciphered, and the auth service
+		// created, we'll now send over the wallet password and the
+		// seed. This will allow the daemon to initialize itself and
+		// startup.
+		initMsg.WalletSeed = cipherSeed
+	}
+
 	// Before we return the unlock payload, we'll check if we can extract
 	// any channel backups to pass up to the higher level sub-system.
 	chansToRestore := extractChanBackups(in.ChannelBackups)
@@ -401,6 +433,71 @@ func (u *UnlockerService) InitWallet(ctx context.Context,
 	}
 }
 
+// parseWatchOnly parses the account public keys, birthday and master key
+// fingerprint of a watch-only wallet into the given init message.
+func parseWatchOnly(watchOnly *lnrpc.WatchOnly, initMsg *WalletInitMsg) error {
+	if len(watchOnly.Accounts) == 0 {
+		return fmt.Errorf("a watch-only wallet needs at least one " +
+			"account")
+	}
+
+	switch len(watchOnly.MasterKeyFingerprint) {
+	case 0:
+
+	case 4:
+		initMsg.WatchOnlyMasterFingerprint = binary.BigEndian.Uint32(
+			watchOnly.MasterKeyFingerprint,
+		)
+
+	default:
+		return fmt.Errorf("invalid master key fingerprint length %d",
+			len(watchOnly.MasterKeyFingerprint))
+	}
+
+	initMsg.WatchOnlyBirthday = aezeed.BitcoinGenesisDate
+	if watchOnly.MasterKeyBirthdayTimestamp != 0 {
+		initMsg.WatchOnlyBirthday = time.Unix(
+			int64(watchOnly.MasterKeyBirthdayTimestamp), 0,
+		)
+	}
+
+	initMsg.WatchOnlyAccounts = make(
+		map[waddrmgr.ScopedIndex]*hdkeychain.ExtendedKey,
+		len(watchOnly.Accounts),
+	)
+	for _, account := range watchOnly.Accounts {
+		index := waddrmgr.ScopedIndex{
+			Scope: waddrmgr.KeyScope{
+				Purpose: account.Purpose,
+				Coin:    account.CoinType,
+			},
+			Index: account.Account,
+		}
+		if _, ok := initMsg.WatchOnlyAccounts[index]; ok {
+			return fmt.Errorf("duplicate account %v/%d'",
+				index.Scope, index.Index)
+		}
+
+		accountPubKey, err := hdkeychain.NewKeyFromString(account.Xpub)
+		if err != nil {
+			return fmt.Errorf("invalid xpub of account %v/%d': %v",
+				index.Scope, index.Index, err)
+		}
+		if accountPubKey.IsPrivate() {
+			return fmt.Errorf("account %v/%d' must be an extended "+
+				"public key", index.Scope, index.Index)
+		}
+		if accountPubKey.Depth() != 3 {
+			return fmt.Errorf("xpub of account %v/%d' must be at "+
+				"depth 3", index.Scope, index.Index)
+		}
+
+		initMsg.WatchOnlyAccounts[index] = accountPubKey
+	}
+
+	return nil
+}
+
 // LoadAndUnlock creates a loader for the wallet and tries to unlock the wallet
 // with the given password and recovery window. If the drop wallet transactions
 // flag is set, the history state drop is performed before unlocking the wallet
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/snacl"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
@@ -369,6 +370,112 @@ func TestCreateWalletInvalidEntropy(t *testing.T) {
 	require.Error(t, err)
 }
 
+// TestInitWalletWatchOnly tests that the account public keys of a watch-only
+// wallet are parsed and validated correctly and then sent over the init
+// channel.
+func TestInitWalletWatchOnly(t *testing.T) {
+	t.Parallel()
+
+	// testDir is empty, meaning wallet was not created from before.
+	testDir, err := ioutil.TempDir("", "testcreate")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(testDir)
+	}()
+
+	// Create new UnlockerService.
+	service := walletunlocker.New(
+		testDir, testNetParams, true, nil, kvdb.DefaultDBTimeout,
+		false, testLoaderOpts(testDir),
+	)
+
+	// Derive the BIP-0084 account key of a test master key and export it
+	// as an extended public key.
+	masterKey, err := hdkeychain.NewMaster(testSeed, testNetParams)
+	require.NoError(t, err)
+	accountKey := masterKey
+	for _, index := range []uint32{84, 0, 0} {
+		accountKey, err = accountKey.Derive(
+			hdkeychain.HardenedKeyStart + index,
+		)
+		require.NoError(t, err)
+	}
+	accountPubKey, err := accountKey.Neuter()
+	require.NoError(t, err)
+
+	birthday := time.Unix(1600000000, 0)
+	watchOnly := &lnrpc.WatchOnly{
+		MasterKeyBirthdayTimestamp: uint64(birthday.Unix()),
+		MasterKeyFingerprint:       []byte{0x01, 0x02, 0x03, 0x04},
+		Accounts: []*lnrpc.WatchOnlyAccount{{
+			Purpose:  84,
+			CoinType: 0,
+			Account:  0,
+			Xpub:     accountPubKey.String(),
+		}},
+	}
+
+	// A mnemonic can't be used together with the watch-only accounts.
+	ctx := context.Background()
+	_, mnemonic := createSeedAndMnemonic(t, nil)
+	_, err = service.InitWallet(ctx, &lnrpc.InitWalletRequest{
+		WalletPassword:     testPassword,
+		CipherSeedMnemonic: mnemonic[:],
+		WatchOnly:          watchOnly,
+	})
+	require.Error(t, err)
+
+	// Extended private keys must be rejected as well.
+	_, err = service.InitWallet(ctx, &lnrpc.InitWalletRequest{
+		WalletPassword: testPassword,
+		WatchOnly: &lnrpc.WatchOnly{
+			Accounts: []*lnrpc.WatchOnlyAccount{{
+				Purpose: 84,
+				Xpub:    accountKey.String(),
+			}},
+		},
+	})
+	require.Error(t, err)
+
+	req := &lnrpc.InitWalletRequest{
+		WalletPassword: testPassword,
+		WatchOnly:      watchOnly,
+		StatelessInit:  true,
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := service.InitWallet(ctx, req)
+		if err != nil {
+			errChan <- err
+		}
+	}()
+
+	select {
+	case err := <-errChan:
+		t.Fatalf("InitWallet call failed: %v", err)
+
+	case msg := <-service.InitMsgs:
+		require.Equal(t, testPassword, msg.Passphrase)
+		require.Nil(t, msg.WalletSeed)
+		require.Equal(t, birthday, msg.WatchOnlyBirthday)
+		require.Equal(
+			t, uint32(0x01020304), msg.WatchOnlyMasterFingerprint,
+		)
+
+		index := waddrmgr.ScopedIndex{Scope: waddrmgr.KeyScopeBIP0084}
+		require.Len(t, msg.WatchOnlyAccounts, 1)
+		require.Equal(
+			t, accountPubKey.String(),
+			msg.WatchOnlyAccounts[index].String(),
+		)
+
+		service.MacResponseChan <- testMac
+
+	case <-time.After(defaultTestTimeout):
+		t.Fatalf("password not received")
+	}
+}
+
 // TestUnlockWallet checks that trying to unlock non-existing wallet fail, that
 // unlocking existing wallet with wrong passphrase fails, and that unlocking
 // existing wallet with correct passphrase succeeds.
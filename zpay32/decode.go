@@ -287,6 +287,14 @@ func parseTaggedFields(invoice *Invoice, fields []byte, net *chaincfg.Params) er
 			}
 
 			invoice.Features, err = parseFeatures(base32Data)
+		case fieldTypeM:
+			if invoice.Metadata != nil {
+				// We skip the field if we have already seen a
+				// supported one.
+				continue
+			}
+
+			invoice.Metadata, err = parseMetadata(base32Data)
 		default:
 			// Ignore unknown type.
 		}
@@ -345,6 +353,12 @@ func parseDescription(data []byte) (*string, error) {
 	return &description, nil
 }
 
+// parseMetadata converts the data (encoded in base32) into a byte slice to
+// use as the payment metadata.
+func parseMetadata(data []byte) ([]byte, error) {
+	return bech32.ConvertBits(data, 5, 8, false)
+}
+
 // parseDestination converts the data (encoded in base32) into a 33-byte public
 // key of the payee node.
 func parseDestination(data []byte) (*btcec.PublicKey, error) {
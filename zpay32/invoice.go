@@ -73,6 +73,10 @@ const (
 	// probing the recipient.
 	fieldTypeS = 16
 
+	// fieldTypeM contains opaque payment metadata that the sender should
+	// include in the final hop's payload.
+	fieldTypeM = 27
+
 	// maxInvoiceLength is the maximum total length an invoice can have.
 	// This is chosen to be the maximum number of bytes that can fit into a
 	// single QR code: https://en.wikipedia.org/wiki/QR_code#Storage
@@ -183,6 +187,13 @@ type Invoice struct {
 	// Features represents an optional field used to signal optional or
 	// required support for features by the receiver.
 	Features *lnwire.FeatureVector
+
+	// Metadata is opaque data that the receiver wants the sender to
+	// include in the final hop's payload, allowing the receiver to
+	// reconstruct the context of the payment.
+	//
+	// NOTE: This is optional.
+	Metadata []byte
 }
 
 // Amount is a functional option that allows callers of NewInvoice to set the
@@ -273,6 +284,14 @@ func PaymentAddr(addr [32]byte) func(*Invoice) {
 	}
 }
 
+// Metadata is a functional option that allows callers of NewInvoice to set
+// the payment metadata that is included in the invoice.
+func Metadata(metadata []byte) func(*Invoice) {
+	return func(i *Invoice) {
+		i.Metadata = metadata
+	}
+}
+
 // NewInvoice creates a new Invoice object. The last parameter is a set of
 // variadic arguments for setting optional fields of the invoice.
 //
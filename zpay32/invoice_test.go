@@ -27,6 +27,7 @@ var (
 	testMillisat2500uBTC = lnwire.MilliSatoshi(250000000)
 	testMillisat25mBTC   = lnwire.MilliSatoshi(2500000000)
 	testMillisat20mBTC   = lnwire.MilliSatoshi(2000000000)
+	testMillisat10mBTC   = lnwire.MilliSatoshi(1000000000)
 
 	testPaymentHash = [32]byte{
 		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
@@ -55,6 +56,9 @@ var (
 	testCupOfNonsense  = "ナンセンス 1杯"
 	testPleaseConsider = "Please consider supporting this project"
 
+	testPaymentMetadataDesc = "payment metadata inside"
+	testPaymentMetadata     = []byte{0x01, 0xfa, 0xfa, 0xf0}
+
 	testPrivKeyBytes, _     = hex.DecodeString("e126f68f7eafcc8b74f54d269fe206be715000f94dac067d1c04a8ca3b2db734")
 	testPrivKey, testPubKey = btcec.PrivKeyFromBytes(btcec.S256(), testPrivKeyBytes)
 
@@ -642,6 +646,59 @@ func TestDecodeEncode(t *testing.T) {
 				return i
 			},
 		},
+		{
+			// Please send 0.01 BTC with payment metadata 0x01fafaf0,
+			// using secret 0x11...
+			encodedInvoice: "lnbc10m1pvjluezpp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqdp9wpshjmt9de6zqmt9w3skgct5vysxjmnnd9jx2mq8q8a04uqsp5zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygs9q2gqqqqqqsgq7hf8he7ecf7n4ffphs6awl9t6676rrclv9ckg3d3ncn7fct63p6s365duk5wrk202cfy3aj5xnnp5gs3vrdvruverwwq7yzhkf5a3xqpd05wjc",
+			valid:          true,
+			decodedInvoice: func() *Invoice {
+				return &Invoice{
+					Net:         &chaincfg.MainNetParams,
+					MilliSat:    &testMillisat10mBTC,
+					Timestamp:   time.Unix(1496314658, 0),
+					PaymentHash: &testPaymentHash,
+					PaymentAddr: &specPaymentAddr,
+					Description: &testPaymentMetadataDesc,
+					Destination: testPubKey,
+					Metadata:    testPaymentMetadata,
+					Features: lnwire.NewFeatureVector(
+						lnwire.NewRawFeatureVector(8, 14, 48),
+						lnwire.Features,
+					),
+				}
+			},
+			// The spec orders the fields differently from our
+			// encoder, so we can't reproduce the same string.
+			skipEncoding: true,
+		},
+		{
+			// The same invoice with payment metadata, encoded in
+			// the field order used by our encoder.
+			encodedInvoice: "lnbc10m1pvjluezpp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqdp9wpshjmt9de6zqmt9w3skgct5vysxjmnnd9jx2sp5zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygsmq8q8a04uq9q2gqqqqqqsgq2vsa59arthfu07ujuamwltjd9wmje4nuky3re84jf77xw7sw6nuhftla6mepkuavgwqqa5h46407hxnrug8hx44wm6v6n8qc9x55rycq2zzaqc",
+			valid:          true,
+			decodedInvoice: func() *Invoice {
+				return &Invoice{
+					Net:         &chaincfg.MainNetParams,
+					MilliSat:    &testMillisat10mBTC,
+					Timestamp:   time.Unix(1496314658, 0),
+					PaymentHash: &testPaymentHash,
+					PaymentAddr: &specPaymentAddr,
+					Description: &testPaymentMetadataDesc,
+					Destination: testPubKey,
+					Metadata:    testPaymentMetadata,
+					Features: lnwire.NewFeatureVector(
+						lnwire.NewRawFeatureVector(8, 14, 48),
+						lnwire.Features,
+					),
+				}
+			},
+			beforeEncoding: func(i *Invoice) {
+				// Since this destination pubkey was recovered
+				// from the signature, we must set it nil before
+				// encoding to get back the same invoice string.
+				i.Destination = nil
+			},
+		},
 		{
 			// Decode a mainnet invoice while expecting active net to be testnet
 			encodedInvoice: "lnbc241pveeq09pp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqdqqnp4q0n326hr8v9zprg8gsvezcch06gfaqqhde2aj730yg0durunfhv66jd3m5klcwhq68vdsmx2rjgxeay5v0tkt2v5sjaky4eqahe4fx3k9sqavvce3capfuwv8rvjng57jrtfajn5dkpqv8yelsewtljwmmycq62k443",
@@ -1016,6 +1073,11 @@ func compareInvoices(expected, actual *Invoice) error {
 			expected.Features, actual.Features)
 	}
 
+	if !bytes.Equal(expected.Metadata, actual.Metadata) {
+		return fmt.Errorf("expected metadata %x, got %x",
+			expected.Metadata, actual.Metadata)
+	}
+
 	return nil
 }
 